@@ -164,9 +164,18 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 	if len(marshalledValues) != 1 {
 		return fmt.Errorf("abi: wrong length, expected single value, got %d", len(marshalledValues))
 	}
+	argument := arguments.NonIndexed()[0]
 	elem := reflect.ValueOf(v).Elem()
+
+	// A single value unpacked into a struct (e.g. the only non-indexed argument
+	// of an event) is assigned to the field named after the argument
+	if elem.Kind() == reflect.Struct {
+		if field := elem.FieldByName(capitalise(argument.Name)); field.IsValid() {
+			elem = field
+		}
+	}
 	reflectValue := reflect.ValueOf(marshalledValues[0])
-	return set(elem, reflectValue, arguments.NonIndexed()[0])
+	return set(elem, reflectValue, argument)
 }
 
 // Computes the full size of an array;
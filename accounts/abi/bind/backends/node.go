@@ -0,0 +1,42 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"github.com/gdachain/go-gdachain/accounts/abi/bind"
+	"github.com/gdachain/go-gdachain/gdaclient"
+	"github.com/gdachain/go-gdachain/node"
+)
+
+// These nil assignments ensure at compile time that the RPC client implements
+// the binding backends.
+var (
+	_ bind.ContractBackend = (*gdaclient.Client)(nil)
+	_ bind.DeployBackend   = (*gdaclient.Client)(nil)
+)
+
+// NewNodeBackend creates a binding backend connected to an in-process node
+// through its RPC API. Unlike the simulated backend, contracts run through the
+// transaction pool, miner and chain rules of a fully configured gda service,
+// which makes it suitable for testing against the actual network parameters.
+func NewNodeBackend(stack *node.Node) (*gdaclient.Client, error) {
+	client, err := stack.Attach()
+	if err != nil {
+		return nil, err
+	}
+	return gdaclient.NewClient(client), nil
+}
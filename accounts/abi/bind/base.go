@@ -53,6 +53,9 @@ type TransactOpts struct {
 	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
 	GasLimit uint64   // Gas limit to set for the transaction execution (0 = estimate)
 
+	ChainID   *big.Int           // Chain ID to sign replay protected transactions for (nil = unprotected)
+	GasPricer gdaereum.GasPricer // Gas price oracle to consult if no gas price is set (nil = backend)
+
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
@@ -202,7 +205,11 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	// Figure out the gas allowance and gas price values
 	gasPrice := opts.GasPrice
 	if gasPrice == nil {
-		gasPrice, err = c.transactor.SuggestGasPrice(ensureContext(opts.Context))
+		var oracle gdaereum.GasPricer = c.transactor
+		if opts.GasPricer != nil {
+			oracle = opts.GasPricer
+		}
+		gasPrice, err = oracle.SuggestGasPrice(ensureContext(opts.Context))
 		if err != nil {
 			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
 		}
@@ -234,7 +241,11 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	if opts.Signer == nil {
 		return nil, errors.New("no signer to authorize the transaction with")
 	}
-	signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, rawTx)
+	var signer types.Signer = types.HomesteadSigner{}
+	if opts.ChainID != nil {
+		signer = types.NewEIP155Signer(opts.ChainID)
+	}
+	signedTx, err := opts.Signer(signer, opts.From, rawTx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,65 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/accounts/abi"
+	"github.com/gdachain/go-gdachain/accounts/abi/bind"
+	"github.com/gdachain/go-gdachain/accounts/abi/bind/backends"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+type fixedGasPricer struct{ price *big.Int }
+
+func (p fixedGasPricer) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return p.price, nil
+}
+
+// Tests that transactions are signed for the requested chain and priced by the
+// requested gas oracle.
+func TestTransactChainIDAndGasPricer(t *testing.T) {
+	auth := bind.NewKeyedTransactor(testKey)
+	sim := backends.NewSimulatedBackendWithConfig(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}}, params.TestChainConfig)
+
+	auth.ChainID = params.TestChainConfig.ChainId
+	auth.GasPricer = fixedGasPricer{big.NewInt(7)}
+	auth.GasLimit = params.TxGas
+
+	contract := bind.NewBoundContract(common.Address{0x01}, abi.ABI{}, sim, sim, sim)
+	tx, err := contract.Transfer(auth)
+	if err != nil {
+		t.Fatalf("failed to transfer: %v", err)
+	}
+	if !tx.Protected() || tx.ChainId().Cmp(auth.ChainID) != 0 {
+		t.Errorf("chain ID mismatch: have %v (protected %v), want %v", tx.ChainId(), tx.Protected(), auth.ChainID)
+	}
+	if tx.GasPrice().Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("gas price mismatch: have %v, want 7", tx.GasPrice())
+	}
+	sim.Commit()
+
+	receipt, err := sim.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil || receipt == nil {
+		t.Fatalf("transaction not mined: %v", err)
+	}
+}
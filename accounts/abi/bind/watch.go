@@ -0,0 +1,253 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+const (
+	// watchReorgDepth is the number of recent blocks whose delivered events are
+	// remembered, so they can be reported as removed if they get reorged out
+	// while the subscription is being re-established.
+	watchReorgDepth = 64
+
+	// watchRetryInterval is the maximum time to wait between two attempts to
+	// re-establish a failed subscription.
+	watchRetryInterval = 30 * time.Second
+)
+
+var errInvalidEventSink = errors.New("event sink must be a channel of struct pointers")
+
+// eventKey uniquely identifies a log on a given chain.
+type eventKey struct {
+	block common.Hash
+	index uint
+}
+
+// eventWatcher keeps a contract event subscription alive across backend failures,
+// backfilling the events missed in between and reconciling the ones affected by
+// chain reorganisations.
+type eventWatcher struct {
+	contract *BoundContract
+	name     string
+	query    gdaereum.FilterQuery
+	sink     reflect.Value
+
+	start     *uint64                // First block to backfill from (nil = latest)
+	head      uint64                 // Highest block number an event was delivered from
+	delivered map[eventKey]types.Log // Recently delivered events, for reorg reconciliation
+}
+
+// WatchEvents subscribes to the named contract event, decoding every matching
+// log into a new value of the sink's element type, which must be a pointer to
+// a struct. If the struct has a Raw field of type types.Log, it is set to the
+// log the event was decoded from.
+//
+// Unlike WatchLogs, the returned subscription survives backend failures: it is
+// re-established with backoff, the events missed in the meantime are delivered
+// and the previously delivered ones that got reorged out are delivered again
+// with Raw.Removed set. Events reorged out by the backend while connected are
+// forwarded the same way. Without opts.Start, only the events emitted after
+// the first delivered one are backfilled.
+func (c *BoundContract) WatchEvents(opts *WatchOpts, name string, sink interface{}, query ...[]interface{}) (event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	ev, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	sinkval := reflect.ValueOf(sink)
+	if sinkval.Kind() != reflect.Chan || sinkval.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, errInvalidEventSink
+	}
+	if elem := sinkval.Type().Elem(); elem.Kind() != reflect.Ptr || elem.Elem().Kind() != reflect.Struct {
+		return nil, errInvalidEventSink
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{ev.Id()}}, query...)
+
+	topics, err := makeTopics(query...)
+	if err != nil {
+		return nil, err
+	}
+	w := &eventWatcher{
+		contract: c,
+		name:     name,
+		query: gdaereum.FilterQuery{
+			Addresses: []common.Address{c.address},
+			Topics:    topics,
+		},
+		sink:      sinkval,
+		start:     opts.Start,
+		delivered: make(map[eventKey]types.Log),
+	}
+	// Establish the first subscription synchronously to report setup errors,
+	// any later failure is retried in the background.
+	first, err := w.subscribe(ensureContext(opts.Context))
+	if err != nil {
+		return nil, err
+	}
+	return event.Resubscribe(watchRetryInterval, func(ctx context.Context) (event.Subscription, error) {
+		if first != nil {
+			sub := first
+			first = nil
+			return sub, nil
+		}
+		return w.subscribe(ctx)
+	}), nil
+}
+
+// subscribe opens a new log subscription on the backend and retrieves the logs
+// needed to catch up with the chain, returning a subscription that delivers the
+// backfilled events first and the live ones after.
+func (w *eventWatcher) subscribe(ctx context.Context) (event.Subscription, error) {
+	logs := make(chan types.Log, 128)
+	sub, err := w.contract.filterer.SubscribeFilterLogs(ctx, w.query, logs)
+	if err != nil {
+		return nil, err
+	}
+	// Backfill from the oldest remembered block, or the requested start if no
+	// event was delivered yet.
+	from := w.start
+	if len(w.delivered) > 0 {
+		block := uint64(0)
+		if w.head > watchReorgDepth {
+			block = w.head - watchReorgDepth
+		}
+		if from == nil || *from < block {
+			from = &block
+		}
+	}
+	var past []types.Log
+	if from != nil {
+		query := w.query
+		query.FromBlock = new(big.Int).SetUint64(*from)
+		if past, err = w.contract.filterer.FilterLogs(ctx, query); err != nil {
+			sub.Unsubscribe()
+			return nil, err
+		}
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+
+		if from != nil && !w.reconcile(*from, past, quit) {
+			return nil
+		}
+		for {
+			select {
+			case log := <-logs:
+				if !w.process(log, quit) {
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// reconcile delivers the events found in the backfilled logs that were not yet
+// delivered, and reports the remembered events at or above the from block that
+// are not among them anymore as removed. It returns false if the subscription
+// was torn down meanwhile.
+func (w *eventWatcher) reconcile(from uint64, past []types.Log, quit <-chan struct{}) bool {
+	canonical := make(map[eventKey]bool, len(past))
+	for _, log := range past {
+		canonical[eventKey{log.BlockHash, log.Index}] = true
+	}
+	for key, log := range w.delivered {
+		if log.BlockNumber >= from && !canonical[key] {
+			log.Removed = true
+			if !w.process(log, quit) {
+				return false
+			}
+		}
+	}
+	for _, log := range past {
+		if !w.process(log, quit) {
+			return false
+		}
+	}
+	return true
+}
+
+// process delivers a single log to the sink, unless it is a duplicate of an
+// already delivered one, or a removal of an event that was never delivered. It
+// returns false if the subscription was torn down meanwhile.
+func (w *eventWatcher) process(log types.Log, quit <-chan struct{}) bool {
+	key := eventKey{log.BlockHash, log.Index}
+	_, known := w.delivered[key]
+	switch {
+	case log.Removed && !known:
+		return true // never delivered, nothing to retract
+	case !log.Removed && known:
+		return true // duplicate from an overlapping backfill
+	}
+	if !w.send(log, quit) {
+		return false
+	}
+	if log.Removed {
+		delete(w.delivered, key)
+		return true
+	}
+	w.delivered[key] = log
+	if log.BlockNumber > w.head {
+		w.head = log.BlockNumber
+		for key, log := range w.delivered {
+			if log.BlockNumber+watchReorgDepth < w.head {
+				delete(w.delivered, key)
+			}
+		}
+	}
+	return true
+}
+
+// send decodes a log into a new event and delivers it to the sink. Logs that
+// cannot be decoded are dropped. It returns false if the subscription was torn
+// down before the event could be delivered.
+func (w *eventWatcher) send(raw types.Log, quit <-chan struct{}) bool {
+	ev := reflect.New(w.sink.Type().Elem().Elem())
+	if err := w.contract.UnpackLog(ev.Interface(), w.name, raw); err != nil {
+		log.Warn("Failed to decode contract event", "event", w.name, "block", raw.BlockNumber, "index", raw.Index, "err", err)
+		return true
+	}
+	if field := ev.Elem().FieldByName("Raw"); field.IsValid() && field.Type() == reflect.TypeOf(raw) {
+		field.Set(reflect.ValueOf(raw))
+	}
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: w.sink, Send: ev},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(quit)},
+	}
+	chosen, _, _ := reflect.Select(cases)
+	return chosen == 0
+}
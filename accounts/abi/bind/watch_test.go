@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/accounts/abi"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+)
+
+const watchTestABI = `[{"anonymous":false,"inputs":[{"indexed":false,"name":"value","type":"uint256"}],"name":"Received","type":"event"}]`
+
+type watchTestEvent struct {
+	Value *big.Int
+	Raw   types.Log
+}
+
+// watchTestFilterer is a log filterer whose canonical logs and live
+// subscription are controlled by the test.
+type watchTestFilterer struct {
+	lock sync.Mutex
+	logs []types.Log
+	sink chan<- types.Log
+	fail chan error
+	subs chan struct{}
+}
+
+func (f *watchTestFilterer) FilterLogs(ctx context.Context, query gdaereum.FilterQuery) ([]types.Log, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var logs []types.Log
+	for _, log := range f.logs {
+		if log.BlockNumber >= query.FromBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (f *watchTestFilterer) SubscribeFilterLogs(ctx context.Context, query gdaereum.FilterQuery, ch chan<- types.Log) (gdaereum.Subscription, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fail := make(chan error)
+	f.sink, f.fail = ch, fail
+	f.subs <- struct{}{}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case err := <-fail:
+			return err
+		case <-quit:
+			return nil
+		}
+	}), nil
+}
+
+func TestWatchEvents(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(watchTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newLog := func(number uint64, hash byte, value int64) types.Log {
+		return types.Log{
+			Topics:      []common.Hash{parsed.Events["Received"].Id()},
+			Data:        common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+			BlockNumber: number,
+			BlockHash:   common.Hash{hash},
+		}
+	}
+	var (
+		a  = newLog(1, 1, 1)
+		b  = newLog(2, 2, 2)
+		b2 = newLog(2, 3, 3)
+	)
+	filterer := &watchTestFilterer{logs: []types.Log{a}, subs: make(chan struct{}, 2)}
+	contract := NewBoundContract(common.Address{}, parsed, nil, nil, filterer)
+
+	events := make(chan *watchTestEvent)
+	start := uint64(0)
+	sub, err := contract.WatchEvents(&WatchOpts{Start: &start}, "Received", events)
+	if err != nil {
+		t.Fatalf("failed to watch events: %v", err)
+	}
+	defer sub.Unsubscribe()
+	<-filterer.subs
+
+	expect := func(value int64, removed bool) {
+		select {
+		case ev := <-events:
+			if ev.Value.Int64() != value || ev.Raw.Removed != removed {
+				t.Fatalf("event mismatch: have (%v, removed %v), want (%d, removed %v)", ev.Value, ev.Raw.Removed, value, removed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for event %d", value)
+		}
+	}
+	// The backfilled event is delivered first, then the live ones.
+	expect(1, false)
+	filterer.lock.Lock()
+	filterer.logs = append(filterer.logs, b)
+	filterer.sink <- b
+	filterer.lock.Unlock()
+	expect(2, false)
+
+	// Reorg the second event out while the subscription is down and check that
+	// it is retracted and its replacement delivered after resubscribing.
+	filterer.lock.Lock()
+	filterer.logs = []types.Log{a, b2}
+	fail := filterer.fail
+	filterer.lock.Unlock()
+	fail <- errors.New("connection lost")
+	<-filterer.subs
+
+	expect(2, true)
+	expect(3, false)
+
+	// Events reorged out by the backend are forwarded, unknown removals are not.
+	filterer.lock.Lock()
+	removed, unknown := b2, newLog(4, 4, 4)
+	removed.Removed, unknown.Removed = true, true
+	filterer.sink <- unknown
+	filterer.sink <- removed
+	filterer.lock.Unlock()
+	expect(3, true)
+}
+
+func TestWatchEventsInvalidSink(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(watchTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := NewBoundContract(common.Address{}, parsed, nil, nil, &watchTestFilterer{subs: make(chan struct{}, 1)})
+	for _, sink := range []interface{}{nil, make(chan watchTestEvent), make(<-chan *watchTestEvent), make(chan *uint64)} {
+		if _, err := contract.WatchEvents(nil, "Received", sink); err != errInvalidEventSink {
+			t.Errorf("sink %T: error mismatch: have %v, want %v", sink, err, errInvalidEventSink)
+		}
+	}
+}
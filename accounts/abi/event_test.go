@@ -45,6 +45,15 @@ var jsonEventTransfer = []byte(`{
   "type": "event"
 }`)
 
+var jsonEventDeposit = []byte(`{
+  "anonymous": false,
+  "inputs": [{
+      "indexed": false, "name": "value", "type": "uint256"
+  }],
+  "name": "Deposit",
+  "type": "event"
+}`)
+
 var jsonEventPledge = []byte(`{
   "anonymous": false,
   "inputs": [{
@@ -158,6 +167,13 @@ func TestEventTupleUnpack(t *testing.T) {
 		jsonEventTransfer,
 		"",
 		"Can unpack ERC20 Transfer event into slice",
+	}, {
+		transferData1,
+		&EventTransfer{},
+		&EventTransfer{Value: bigintExpected},
+		jsonEventDeposit,
+		"",
+		"Can unpack single argument event into structure",
 	}, {
 		pledgeData1,
 		&EventPledge{},
@@ -0,0 +1,260 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an account backend forwarding all signing requests
+// to an external signer process, so that keys never enter the node.
+//
+// The signer is reached over any RPC transport (IPC, HTTP or websocket) and must
+// serve the following methods in the "account" namespace:
+//
+//	account_version()                              -> version string
+//	account_list()                                 -> [address, ...]
+//	account_signHash(address, hash)                -> 65 byte [R || S || V] signature
+//	account_signTransaction(address, tx, chainId)  -> RLP encoded signed transaction
+//
+// The signer is expected to ask its operator for approval before signing, and to
+// reject requests by returning an error, which is passed on to the caller.
+package external
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Scheme is the protocol scheme prefixing account and wallet URLs.
+const Scheme = "extapi"
+
+// ExternalBackend is an account backend with a single wallet, the external signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer at the given endpoint (an
+// IPC path or an HTTP/websocket URL).
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer is available for the
+// entire lifetime of the backend, so no events are ever fired.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is a wallet forwarding all requests to an external signer.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	lock     sync.RWMutex
+	accounts []accounts.Account // Accounts last reported by the signer
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signer := newExternalSigner(client, endpoint)
+
+	// Ensure the signer is reachable and speaks the expected protocol
+	var version string
+	if err := client.Call(&version, "account_version"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("external signer unavailable: %v", err)
+	}
+	log.Info("Connected to external signer", "endpoint", endpoint, "version", version)
+	return signer, nil
+}
+
+// newExternalSigner creates a wallet on top of an established signer connection.
+func newExternalSigner(client *rpc.Client, endpoint string) *ExternalSigner {
+	return &ExternalSigner{client: client, endpoint: endpoint}
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (api *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: Scheme, Path: api.endpoint}
+}
+
+// Status implements accounts.Wallet, returning the version reported by the signer,
+// or the error encountered when reaching it.
+func (api *ExternalSigner) Status() (string, error) {
+	var version string
+	if err := api.client.Call(&version, "account_version"); err != nil {
+		return "Unavailable", err
+	}
+	return "Online, version " + version, nil
+}
+
+// Open implements accounts.Wallet. The signer needs no opening, its connection is
+// established on creation, so this method is a noop.
+func (api *ExternalSigner) Open(passphrase string) error {
+	return nil
+}
+
+// Close implements accounts.Wallet. The connection to the signer is kept for the
+// lifetime of the node, so this method is a noop.
+func (api *ExternalSigner) Close() error {
+	return nil
+}
+
+// Accounts implements accounts.Wallet, retrieving the accounts managed by the
+// signer. If the signer cannot be reached, the last known list is returned.
+func (api *ExternalSigner) Accounts() []accounts.Account {
+	var addresses []common.Address
+	if err := api.client.Call(&addresses, "account_list"); err != nil {
+		log.Warn("Failed to list external signer accounts", "endpoint", api.endpoint, "err", err)
+
+		api.lock.RLock()
+		defer api.lock.RUnlock()
+		return append([]accounts.Account{}, api.accounts...)
+	}
+	list := make([]accounts.Account, 0, len(addresses))
+	for _, address := range addresses {
+		list = append(list, accounts.Account{Address: address, URL: api.URL()})
+	}
+	api.lock.Lock()
+	api.accounts = list
+	api.lock.Unlock()
+
+	return append([]accounts.Account{}, list...)
+}
+
+// Contains implements accounts.Wallet, checking whgdaer the signer reported the
+// account when last listed.
+func (api *ExternalSigner) Contains(account accounts.Account) bool {
+	api.lock.RLock()
+	known := api.accounts
+	api.lock.RUnlock()
+
+	if known == nil {
+		known = api.Accounts()
+	}
+	for _, acc := range known {
+		if acc.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == acc.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but account management is left to the signer.
+func (api *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but account management is left to the
+// signer, so this method is a noop.
+func (api *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain gdaereum.ChainStateReader) {
+}
+
+// SignHash implements accounts.Wallet, requesting the signer to sign the hash.
+func (api *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := api.client.Call(&signature, "account_signHash", account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("invalid signature length: have %d, want 65", len(signature))
+	}
+	return signature, nil
+}
+
+// SignTx implements accounts.Wallet, requesting the signer to sign the transaction.
+// The signed transaction is verified to originate from the requested account.
+func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var chain *hexutil.Big
+	if chainID != nil {
+		chain = (*hexutil.Big)(chainID)
+	}
+	unsigned, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var raw hexutil.Bytes
+	if err := api.client.Call(&raw, "account_signTransaction", account.Address, hexutil.Bytes(unsigned), chain); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, signed); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction: %v", err)
+	}
+	if !sameContents(tx, signed) {
+		return nil, fmt.Errorf("signed transaction %x differs from the requested one", signed.Hash())
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != account.Address {
+		return nil, fmt.Errorf("signer mismatch: expected %s, got %s", account.Address.Hex(), sender.Hex())
+	}
+	return signed, nil
+}
+
+// sameContents reports whgdaer two transactions are identical apart from their
+// signatures.
+func sameContents(a, b *types.Transaction) bool {
+	if a.Nonce() != b.Nonce() || a.Gas() != b.Gas() || a.GasPrice().Cmp(b.GasPrice()) != 0 || a.Value().Cmp(b.Value()) != 0 {
+		return false
+	}
+	if (a.To() == nil) != (b.To() == nil) || (a.To() != nil && *a.To() != *b.To()) {
+		return false
+	}
+	return bytes.Equal(a.Data(), b.Data())
+}
+
+// SignHashWithPassphrase implements accounts.Wallet. Approval is handled by the
+// signer, so the passphrase is ignored.
+func (api *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return api.SignHash(account, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. Approval is handled by the
+// signer, so the passphrase is ignored.
+func (api *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return api.SignTx(account, tx, chainID)
+}
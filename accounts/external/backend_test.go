@@ -0,0 +1,122 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Tests that the external signer satisfies the wallet interface.
+var _ = accounts.Wallet(&ExternalSigner{})
+var _ = accounts.Backend(&ExternalBackend{})
+
+// TestSigner is an external signer holding a single key, approving requests
+// only while enabled.
+type TestSigner struct {
+	key     *ecdsa.PrivateKey
+	approve bool
+}
+
+func (s *TestSigner) Version() string { return "1.0.0" }
+
+func (s *TestSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *TestSigner) SignHash(address common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	if !s.approve {
+		return nil, errors.New("request denied")
+	}
+	return crypto.Sign(hash, s.key)
+}
+
+func (s *TestSigner) SignTransaction(address common.Address, raw hexutil.Bytes, chainID *hexutil.Big) (hexutil.Bytes, error) {
+	if !s.approve {
+		return nil, errors.New("request denied")
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer((*big.Int)(chainID)), s.key)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// Tests that signing requests are forwarded to the external signer, and that
+// denied requests are reported back.
+func TestExternalSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	service := &TestSigner{key: key, approve: true}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", service); err != nil {
+		t.Fatalf("failed to register signer: %v", err)
+	}
+	defer server.Stop()
+
+	signer := newExternalSigner(rpc.DialInProc(server), "test")
+
+	// Ensure the accounts of the signer are listed
+	list := signer.Accounts()
+	if len(list) != 1 || list[0].Address != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("account list mismatch: have %v", list)
+	}
+	if !signer.Contains(accounts.Account{Address: list[0].Address}) {
+		t.Fatalf("listed account not contained")
+	}
+	// Ensure hashes and transactions are signed by the signer's key
+	hash := crypto.Keccak256([]byte("hello"))
+	sig, err := signer.SignHash(list[0], hash)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != list[0].Address {
+		t.Fatalf("hash signer mismatch: %v", err)
+	}
+	chainID := big.NewInt(15)
+	tx := types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signed, err := signer.SignTx(list[0], tx, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if sender, _ := types.Sender(types.NewEIP155Signer(chainID), signed); sender != list[0].Address {
+		t.Fatalf("transaction signer mismatch: have %x, want %x", sender, list[0].Address)
+	}
+	// Ensure denied requests fail
+	service.approve = false
+	if _, err := signer.SignHash(list[0], hash); err == nil {
+		t.Fatalf("denied hash signing succeeded")
+	}
+	if _, err := signer.SignTx(list[0], tx, chainID); err == nil {
+		t.Fatalf("denied transaction signing succeeded")
+	}
+}
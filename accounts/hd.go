@@ -39,6 +39,20 @@ var DefaultBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + 60,
 // at m/44'/60'/0'/1, etc.
 var DefaultLedgerBaseDerivationPath = DerivationPath{0x80000000 + 44, 0x80000000 + 60, 0x80000000 + 0, 0}
 
+// DefaultBaseDerivationPathFor returns the base path from which accounts are
+// derived on wallets of the given URL scheme. Ledger wallets use the legacy
+// path, where the account index is the fourth component, all others use the
+// standard BIP-44 path.
+func DefaultBaseDerivationPathFor(scheme string) DerivationPath {
+	base := DefaultBaseDerivationPath
+	if scheme == "ledger" {
+		base = DefaultLedgerBaseDerivationPath
+	}
+	path := make(DerivationPath, len(base))
+	copy(path, base)
+	return path
+}
+
 // DerivationPath represents the computer friendly version of a hierarchical
 // deterministic wallet account derivaion path.
 //
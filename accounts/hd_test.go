@@ -77,3 +77,19 @@ func TestHDPathParsing(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the default base derivation path is picked by wallet scheme, and
+// that the returned path can be modified without affecting the defaults.
+func TestDefaultBaseDerivationPathFor(t *testing.T) {
+	if path := DefaultBaseDerivationPathFor("ledger"); !reflect.DeepEqual(path, DefaultLedgerBaseDerivationPath) {
+		t.Errorf("ledger path mismatch: have %v, want %v", path, DefaultLedgerBaseDerivationPath)
+	}
+	path := DefaultBaseDerivationPathFor("trezor")
+	if !reflect.DeepEqual(path, DefaultBaseDerivationPath) {
+		t.Errorf("trezor path mismatch: have %v, want %v", path, DefaultBaseDerivationPath)
+	}
+	path[len(path)-1]++
+	if DefaultBaseDerivationPath[len(DefaultBaseDerivationPath)-1] != 0 {
+		t.Errorf("default path modified through returned copy: %v", DefaultBaseDerivationPath)
+	}
+}
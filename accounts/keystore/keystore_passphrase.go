@@ -113,6 +113,19 @@ func (ks keyStorePassphrase) JoinPath(filename string) string {
 	}
 }
 
+// ValidateScryptParams checks that the given scrypt parameters are usable for
+// key encryption: N must be a power of two greater than one, and P positive
+// with N*P within the limits of the algorithm.
+func ValidateScryptParams(scryptN, scryptP int) error {
+	if scryptN <= 1 || scryptN&(scryptN-1) != 0 {
+		return fmt.Errorf("scrypt N must be a power of two greater than 1, have %d", scryptN)
+	}
+	if scryptP <= 0 || uint64(scryptR)*uint64(scryptP) >= 1<<30 {
+		return fmt.Errorf("scrypt P out of range, have %d", scryptP)
+	}
+	return nil
+}
+
 // EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
@@ -58,3 +58,25 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that invalid scrypt parameters are rejected.
+func TestValidateScryptParams(t *testing.T) {
+	tests := []struct {
+		n, p int
+		ok   bool
+	}{
+		{StandardScryptN, StandardScryptP, true},
+		{LightScryptN, LightScryptP, true},
+		{veryLightScryptN, veryLightScryptP, true},
+		{0, 1, false},
+		{1, 1, false},
+		{3000, 1, false},
+		{LightScryptN, 0, false},
+		{LightScryptN, -1, false},
+	}
+	for i, tt := range tests {
+		if err := ValidateScryptParams(tt.n, tt.p); (err == nil) != tt.ok {
+			t.Errorf("test %d (N=%d, P=%d): validity mismatch: have %v, want ok=%v", i, tt.n, tt.p, err, tt.ok)
+		}
+	}
+}
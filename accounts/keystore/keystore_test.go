@@ -68,6 +68,28 @@ func TestKeyStore(t *testing.T) {
 	}
 }
 
+// Tests that importing an account already present in the keystore is rejected,
+// instead of creating a duplicate key file.
+func TestImportDuplicate(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyjson, err := ks.Export(a, "foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to export account: %v", err)
+	}
+	if _, err := ks.Import(keyjson, "bar", "baz"); err != ErrAccountAlreadyExists {
+		t.Fatalf("duplicate import error mismatch: have %v, want %v", err, ErrAccountAlreadyExists)
+	}
+	if n := len(ks.Accounts()); n != 1 {
+		t.Fatalf("account count mismatch: have %d, want 1", n)
+	}
+}
+
 func TestSign(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -0,0 +1,100 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainevents
+
+import (
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// NewBlock converts a block into its public event summary.
+func NewBlock(block *types.Block) Block {
+	txs := block.Transactions()
+
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	return Block{
+		Number:     hexutil.Uint64(block.NumberU64()),
+		Hash:       block.Hash(),
+		ParentHash: block.ParentHash(),
+		Time:       hexutil.Uint64(block.Time().Uint64()),
+		Coinbase:   block.Coinbase(),
+		GasUsed:    hexutil.Uint64(block.GasUsed()),
+		GasLimit:   hexutil.Uint64(block.GasLimit()),
+		TxHashes:   hashes,
+	}
+}
+
+// NewLogs converts a batch of contract logs into their public event form.
+func NewLogs(logs []*types.Log) []Log {
+	result := make([]Log, len(logs))
+	for i, log := range logs {
+		result[i] = Log{
+			Address:     log.Address,
+			Topics:      append([]common.Hash{}, log.Topics...),
+			Data:        common.CopyBytes(log.Data),
+			BlockNumber: hexutil.Uint64(log.BlockNumber),
+			BlockHash:   log.BlockHash,
+			TxHash:      log.TxHash,
+			TxIndex:     hexutil.Uint(log.TxIndex),
+			Index:       hexutil.Uint(log.Index),
+			Removed:     log.Removed,
+		}
+	}
+	return result
+}
+
+// NewTransaction converts a transaction into its public event summary, using
+// the given signer to derive the sender. The sender is left empty if the
+// signature is invalid.
+func NewTransaction(tx *types.Transaction, signer types.Signer) Transaction {
+	from, _ := types.Sender(signer, tx)
+	return Transaction{
+		Hash:     tx.Hash(),
+		From:     from,
+		To:       tx.To(),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Input:    tx.Data(),
+	}
+}
+
+// NewReorg converts a chain reorganisation event into its public form, ordering
+// both segments by ascending block number.
+func NewReorg(ev core.ReorgEvent) *Reorg {
+	return &Reorg{
+		CommonAncestor: NewBlock(ev.CommonAncestor),
+		Removed:        newSegment(ev.OldChain),
+		Added:          newSegment(ev.NewChain),
+	}
+}
+
+// newSegment converts a chain segment ordered from its highest block downwards
+// into a list of block summaries in ascending order.
+func newSegment(blocks types.Blocks) []Block {
+	segment := make([]Block, len(blocks))
+	for i, block := range blocks {
+		segment[len(blocks)-1-i] = NewBlock(block)
+	}
+	return segment
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package chainevents defines a stable, versioned representation of the chain
+// and transaction pool events, meant to be consumed by plugins and exporters
+// without depending on the internal event types of package core.
+//
+// Events are plain data structures which may be passed around in process or
+// serialized into a self describing envelope carrying the API version and the
+// event kind, so that consumers can decode them into the matching typed event.
+package chainevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+)
+
+// Version is the version of the event API defined by this package. It is bumped
+// whenever an existing event type changes in an incompatible way.
+const Version = 1
+
+// Event kinds, identifying the type of the payload within an envelope.
+const (
+	KindChainHead   = "chainHead"   // A new block was appended to the canonical chain
+	KindChainSide   = "chainSide"   // A block was imported into a side chain
+	KindReorg       = "reorg"       // The canonical chain was reorganised
+	KindLogsRemoved = "logsRemoved" // Logs were removed from the canonical chain
+	KindTxPending   = "txPending"   // A transaction entered the transaction pool
+)
+
+var (
+	errUnknownKind        = errors.New("unknown event kind")
+	errUnsupportedVersion = errors.New("unsupported event API version")
+)
+
+// Event is implemented by all the typed events of the API.
+type Event interface {
+	// Kind returns the identifier of the event type.
+	Kind() string
+}
+
+// Block is the summary of a block carried by the chain events.
+type Block struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+	Coinbase   common.Address `json:"miner"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	TxHashes   []common.Hash  `json:"transactions"`
+}
+
+// Log is a contract log event as carried by the chain events.
+type Log struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	Index       hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+// Transaction is the summary of a pooled transaction.
+type Transaction struct {
+	Hash     common.Hash     `json:"hash"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Value    *hexutil.Big    `json:"value"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Input    hexutil.Bytes   `json:"input"`
+}
+
+// ChainHead is emitted when a block becomes the new head of the canonical chain,
+// together with the logs it generated.
+type ChainHead struct {
+	Block Block `json:"block"`
+	Logs  []Log `json:"logs"`
+}
+
+// ChainSide is emitted when a block is imported into a non-canonical side chain.
+type ChainSide struct {
+	Block Block `json:"block"`
+}
+
+// Reorg is emitted when the canonical chain is reorganised. Both segments are
+// ordered by ascending block number, starting with the first block after the
+// common ancestor.
+type Reorg struct {
+	CommonAncestor Block   `json:"commonAncestor"`
+	Removed        []Block `json:"removed"`
+	Added          []Block `json:"added"`
+}
+
+// LogsRemoved is emitted with the batch of logs dropped from the canonical chain
+// by a reorganisation.
+type LogsRemoved struct {
+	Logs []Log `json:"logs"`
+}
+
+// TxPending is emitted when a transaction enters the transaction pool.
+type TxPending struct {
+	Tx Transaction `json:"transaction"`
+}
+
+// Kind implements Event, returning the identifier of the event type.
+func (*ChainHead) Kind() string   { return KindChainHead }
+func (*ChainSide) Kind() string   { return KindChainSide }
+func (*Reorg) Kind() string       { return KindReorg }
+func (*LogsRemoved) Kind() string { return KindLogsRemoved }
+func (*TxPending) Kind() string   { return KindTxPending }
+
+// Envelope is the serialized form of an event, tagged with the API version and
+// the kind of event contained in the payload.
+type Envelope struct {
+	Version int             `json:"version"`
+	Seq     uint64          `json:"seq,omitempty"` // Sequence number within the originating feed, if any
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Encode serializes an event into a versioned envelope.
+func Encode(ev Event) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&Envelope{Version: Version, Kind: ev.Kind(), Payload: payload})
+}
+
+// Decode parses a versioned envelope, returning the typed event within.
+func Decode(data []byte) (Event, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != Version {
+		return nil, fmt.Errorf("%v: have %d, want %d", errUnsupportedVersion, env.Version, Version)
+	}
+	var ev Event
+	switch env.Kind {
+	case KindChainHead:
+		ev = new(ChainHead)
+	case KindChainSide:
+		ev = new(ChainSide)
+	case KindReorg:
+		ev = new(Reorg)
+	case KindLogsRemoved:
+		ev = new(LogsRemoved)
+	case KindTxPending:
+		ev = new(TxPending)
+	default:
+		return nil, fmt.Errorf("%v: %q", errUnknownKind, env.Kind)
+	}
+	if err := json.Unmarshal(env.Payload, ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainevents
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// Tests that all event kinds survive an encoding round trip into the typed
+// event, and that envelopes of unknown versions or kinds are rejected.
+func TestEnvelopeRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.HomesteadSigner{}
+
+	tx, _ := types.SignTx(types.NewTransaction(1, common.Address{0x01}, big.NewInt(2), 21000, big.NewInt(3), []byte{0x04}), signer, key)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(5), Time: big.NewInt(6)}, []*types.Transaction{tx}, nil, nil)
+	logs := []*types.Log{{Address: common.Address{0x07}, Topics: []common.Hash{{0x08}}, Data: []byte{0x09}, BlockNumber: 5, Removed: true}}
+
+	events := []Event{
+		&ChainHead{Block: NewBlock(block), Logs: NewLogs(logs)},
+		&ChainSide{Block: NewBlock(block)},
+		&Reorg{CommonAncestor: NewBlock(block), Removed: []Block{NewBlock(block)}, Added: []Block{NewBlock(block)}},
+		&LogsRemoved{Logs: NewLogs(logs)},
+		&TxPending{Tx: NewTransaction(tx, signer)},
+	}
+	for i, ev := range events {
+		blob, err := Encode(ev)
+		if err != nil {
+			t.Fatalf("event %d: failed to encode: %v", i, err)
+		}
+		dec, err := Decode(blob)
+		if err != nil {
+			t.Fatalf("event %d: failed to decode: %v", i, err)
+		}
+		if !reflect.DeepEqual(dec, ev) {
+			t.Errorf("event %d: round trip mismatch: have %+v, want %+v", i, dec, ev)
+		}
+	}
+	if from := events[4].(*TxPending).Tx.From; from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("transaction sender mismatch: have %x, want %x", from, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	if _, err := Decode([]byte(`{"version":2,"kind":"chainHead","payload":{}}`)); err == nil {
+		t.Errorf("unsupported version accepted")
+	}
+	if _, err := Decode([]byte(`{"version":1,"kind":"unknown","payload":{}}`)); err == nil {
+		t.Errorf("unknown kind accepted")
+	}
+}
+
+// Tests that the feed translates a chain reorganisation into a reorg event with
+// both segments ordered by ascending block number.
+func TestFeedReorg(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	forks, _ := core.GenerateChain(gspec.Config, chain[0], ethash.NewFaker(), db, 4, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	feed := NewFeed(blockchain, nil)
+	defer feed.Stop()
+
+	ch := make(chan Notification, 64)
+	sub := feed.Subscribe(ch)
+	defer sub.Unsubscribe()
+
+	if _, err := blockchain.InsertChain(forks); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case n := <-ch:
+			reorg, ok := n.Event.(*Reorg)
+			if !ok {
+				continue
+			}
+			if reorg.CommonAncestor.Hash != chain[0].Hash() {
+				t.Errorf("common ancestor mismatch: have %x, want %x", reorg.CommonAncestor.Hash, chain[0].Hash())
+			}
+			if len(reorg.Removed) != 2 || reorg.Removed[0].Hash != chain[1].Hash() || reorg.Removed[1].Hash != chain[2].Hash() {
+				t.Errorf("removed segment mismatch: have %d blocks", len(reorg.Removed))
+			}
+			if len(reorg.Added) == 0 || reorg.Added[0].Hash != forks[0].Hash() {
+				t.Errorf("added segment mismatch: have %d blocks", len(reorg.Added))
+			}
+			return
+		case <-timeout:
+			t.Fatalf("reorg event timeout")
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainevents
+
+import (
+	"sync"
+
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// ChainSource is the chain whose events are translated by a Feed.
+type ChainSource interface {
+	Config() *params.ChainConfig
+	CurrentBlock() *types.Block
+
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
+	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
+}
+
+// PoolSource is the transaction pool whose events are translated by a Feed.
+type PoolSource interface {
+	SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription
+}
+
+// Notification is a single event delivered to the subscribers of a Feed. The
+// sequence number is increasing without gaps, allowing consumers to detect
+// events dropped on their side.
+type Notification struct {
+	Seq   uint64
+	Event Event
+}
+
+// Feed translates the internal chain and transaction pool events into their
+// versioned public counterparts and delivers them to all subscribers.
+//
+// Note, during a reorganisation only the new head block is announced via a
+// ChainHead event; the rest of the new canonical segment is delivered in the
+// Added field of the preceding Reorg event.
+type Feed struct {
+	chain ChainSource
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+	seq   uint64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFeed creates an event translator for the given chain and transaction pool
+// and starts following their events. The pool may be nil if transaction events
+// are not needed.
+func NewFeed(chain ChainSource, pool PoolSource) *Feed {
+	f := &Feed{
+		chain: chain,
+		quit:  make(chan struct{}),
+	}
+	var (
+		chainCh   = make(chan core.ChainEvent, 64)
+		sideCh    = make(chan core.ChainSideEvent, 64)
+		reorgCh   = make(chan core.ReorgEvent, 16)
+		rmLogsCh  = make(chan core.RemovedLogsEvent, 16)
+		txCh      = make(chan core.TxPreEvent, 256)
+		chainSub  = chain.SubscribeChainEvent(chainCh)
+		sideSub   = chain.SubscribeChainSideEvent(sideCh)
+		reorgSub  = chain.SubscribeReorgEvent(reorgCh)
+		rmLogsSub = chain.SubscribeRemovedLogsEvent(rmLogsCh)
+		txSub     event.Subscription
+		txErr     <-chan error
+	)
+	if pool != nil {
+		txSub = pool.SubscribeTxPreEvent(txCh)
+		txErr = txSub.Err()
+	}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer chainSub.Unsubscribe()
+		defer sideSub.Unsubscribe()
+		defer reorgSub.Unsubscribe()
+		defer rmLogsSub.Unsubscribe()
+		if txSub != nil {
+			defer txSub.Unsubscribe()
+		}
+		for {
+			select {
+			case ev := <-chainCh:
+				f.send(&ChainHead{Block: NewBlock(ev.Block), Logs: NewLogs(ev.Logs)})
+			case ev := <-sideCh:
+				f.send(&ChainSide{Block: NewBlock(ev.Block)})
+			case ev := <-reorgCh:
+				f.send(NewReorg(ev))
+			case ev := <-rmLogsCh:
+				f.send(&LogsRemoved{Logs: NewLogs(ev.Logs)})
+			case ev := <-txCh:
+				f.send(&TxPending{Tx: NewTransaction(ev.Tx, types.MakeSigner(chain.Config(), chain.CurrentBlock().Number()))})
+
+			case <-chainSub.Err():
+				return
+			case <-sideSub.Err():
+				return
+			case <-reorgSub.Err():
+				return
+			case <-rmLogsSub.Err():
+				return
+			case <-txErr:
+				return
+			case <-f.quit:
+				return
+			}
+		}
+	}()
+	return f
+}
+
+// send delivers an event to all current subscribers.
+func (f *Feed) send(ev Event) {
+	f.seq++
+	f.feed.Send(Notification{Seq: f.seq, Event: ev})
+}
+
+// Subscribe registers a subscription for all the translated events. Slow
+// subscribers block the delivery of events to all others, so the channel should
+// have ample buffer space.
+func (f *Feed) Subscribe(ch chan<- Notification) event.Subscription {
+	return f.scope.Track(f.feed.Subscribe(ch))
+}
+
+// Stop terminates the event translation and all the active subscriptions.
+func (f *Feed) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+	f.scope.Close()
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainevents
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// maxSocketBacklog is the number of events that may be waiting to be written
+// to a socket client before it is considered stalled and disconnected.
+const maxSocketBacklog = 256
+
+// SocketPublisher writes the chain events of a Feed to all the clients of a
+// local socket (unix domain socket or Windows named pipe), one JSON envelope per
+// line. It allows co-located services to follow the chain without speaking the
+// RPC protocol. Transaction pool events are not published.
+type SocketPublisher struct {
+	listener net.Listener
+	sub      event.Subscription
+
+	clients map[net.Conn]chan []byte // Active clients and their pending lines
+	lock    sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSocketPublisher opens a local socket at the given endpoint and starts
+// publishing the events of the feed to all connecting clients.
+func NewSocketPublisher(feed *Feed, endpoint string) (*SocketPublisher, error) {
+	listener, err := rpc.CreateIPCListener(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	p := &SocketPublisher{
+		listener: listener,
+		clients:  make(map[net.Conn]chan []byte),
+		quit:     make(chan struct{}),
+	}
+	events := make(chan Notification, maxSocketBacklog)
+	p.sub = feed.Subscribe(events)
+
+	p.wg.Add(2)
+	go p.accept()
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case n := <-events:
+				if n.Event.Kind() == KindTxPending {
+					continue
+				}
+				line, err := encodeLine(n)
+				if err != nil {
+					log.Warn("Failed to encode chain event", "kind", n.Event.Kind(), "err", err)
+					continue
+				}
+				p.publish(line)
+			case <-p.sub.Err():
+				return
+			case <-p.quit:
+				return
+			}
+		}
+	}()
+	log.Info("Chain event socket opened", "endpoint", endpoint)
+	return p, nil
+}
+
+// encodeLine serializes a notification into a newline terminated envelope.
+func encodeLine(n Notification) ([]byte, error) {
+	payload, err := json.Marshal(n.Event)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(&Envelope{Version: Version, Seq: n.Seq, Kind: n.Event.Kind(), Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// accept registers new clients until the listener is closed.
+func (p *SocketPublisher) accept() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		queue := make(chan []byte, maxSocketBacklog)
+
+		p.lock.Lock()
+		p.clients[conn] = queue
+		p.lock.Unlock()
+
+		p.wg.Add(1)
+		go p.serve(conn, queue)
+	}
+}
+
+// serve writes the queued lines to a single client until it disconnects, stalls
+// or the publisher is closed.
+func (p *SocketPublisher) serve(conn net.Conn, queue chan []byte) {
+	defer p.wg.Done()
+	defer p.drop(conn)
+
+	for {
+		select {
+		case line, ok := <-queue:
+			if !ok {
+				return
+			}
+			if _, err := conn.Write(line); err != nil {
+				return
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// publish queues a line to all the connected clients, disconnecting any that
+// fell too far behind.
+func (p *SocketPublisher) publish(line []byte) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for conn, queue := range p.clients {
+		select {
+		case queue <- line:
+		default:
+			log.Debug("Dropping stalled chain event client", "backlog", len(queue))
+			delete(p.clients, conn)
+			close(queue)
+			conn.Close()
+		}
+	}
+}
+
+// drop closes a client connection, removing it from the active set if still
+// present.
+func (p *SocketPublisher) drop(conn net.Conn) {
+	p.lock.Lock()
+	if queue, ok := p.clients[conn]; ok {
+		delete(p.clients, conn)
+		close(queue)
+	}
+	p.lock.Unlock()
+
+	conn.Close()
+}
+
+// Close stops publishing events, disconnecting all clients and removing the
+// socket.
+func (p *SocketPublisher) Close() error {
+	close(p.quit)
+	p.sub.Unsubscribe()
+	err := p.listener.Close()
+
+	// Interrupt any writes blocked on unresponsive clients
+	p.lock.Lock()
+	for conn := range p.clients {
+		conn.Close()
+	}
+	p.lock.Unlock()
+
+	p.wg.Wait()
+	return err
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !windows
+
+package chainevents
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// Tests that chain events are published to socket clients as JSON lines.
+func TestSocketPublisher(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	feed := NewFeed(blockchain, nil)
+	defer feed.Stop()
+
+	dir, err := ioutil.TempDir("", "chainevents-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	endpoint := filepath.Join(dir, "events.ipc")
+	publisher, err := NewSocketPublisher(feed, endpoint)
+	if err != nil {
+		t.Fatalf("failed to open event socket: %v", err)
+	}
+	defer publisher.Close()
+
+	conn, err := net.Dial("unix", endpoint)
+	if err != nil {
+		t.Fatalf("failed to connect to event socket: %v", err)
+	}
+	defer conn.Close()
+
+	// Wait for the client to be registered before generating any events
+	for i := 0; ; i++ {
+		publisher.lock.Lock()
+		clients := len(publisher.clients)
+		publisher.lock.Unlock()
+		if clients == 1 {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, gen *core.BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("failed to read event: %v", err)
+	}
+	var env Envelope
+	if err := json.Unmarshal(line, &env); err != nil {
+		t.Fatalf("failed to parse envelope: %v", err)
+	}
+	if env.Seq != 1 {
+		t.Errorf("sequence number mismatch: have %d, want %d", env.Seq, 1)
+	}
+	ev, err := Decode(line)
+	if err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	head, ok := ev.(*ChainHead)
+	if !ok {
+		t.Fatalf("event type mismatch: have %T, want %T", ev, head)
+	}
+	if head.Block.Hash != chain[0].Hash() {
+		t.Errorf("head mismatch: have %x, want %x", head.Block.Hash, chain[0].Hash())
+	}
+}
@@ -245,7 +245,7 @@ func newFaucet(genesis *core.Genesis, port int, enodes []*discv5.Node, network u
 		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			var serv *les.Lightgdachain
 			ctx.Service(&serv)
-			return gdastats.New(stats, nil, serv)
+			return gdastats.New(gdastats.Config{URL: stats}, nil, serv)
 		}); err != nil {
 			return nil, err
 		}
@@ -54,6 +54,8 @@ passwordfile as argument containing the wallet password in plaintext.`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
 	ggda wallet [options] /path/to/my/presale.wallet
@@ -110,6 +112,8 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
     ggda account new
@@ -135,6 +139,8 @@ password to file or expose in any other way.
 					utils.DataDirFlag,
 					utils.KeyStoreDirFlag,
 					utils.LightKDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				Description: `
     ggda account update <address>
@@ -164,6 +170,8 @@ changing your password is only possible interactively.
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.ScryptNFlag,
+					utils.ScryptPFlag,
 				},
 				ArgsUsage: "<keyFile>",
 				Description: `
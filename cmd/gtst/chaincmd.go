@@ -33,7 +33,6 @@ import (
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/gda/downloader"
 	"github.com/gdachain/go-gdachain/gdadb"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/log"
 	"github.com/gdachain/go-gdachain/trie"
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -300,7 +299,7 @@ func copyDb(ctx *cli.Context) error {
 	chain, chainDb := utils.MakeChain(ctx, stack)
 
 	syncmode := *utils.GlobalTextMarshaler(ctx, utils.SyncModeFlag.Name).(*downloader.SyncMode)
-	dl := downloader.New(syncmode, chainDb, new(event.TypeMux), chain, nil, nil)
+	dl := downloader.New(syncmode, chainDb, chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
 	db, err := gdadb.NewLDBDatabase(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
@@ -29,9 +29,12 @@ import (
 
 	"github.com/gdachain/go-gdachain/cmd/utils"
 	"github.com/gdachain/go-gdachain/dashboard"
+	"github.com/gdachain/go-gdachain/faucet"
 	"github.com/gdachain/go-gdachain/gda"
+	"github.com/gdachain/go-gdachain/gdastats"
 	"github.com/gdachain/go-gdachain/node"
 	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/telemetry"
 	whisper "github.com/gdachain/go-gdachain/whisper/whisperv5"
 	"github.com/naoina/toml"
 )
@@ -71,7 +74,9 @@ var tomlSettings = toml.Config{
 }
 
 type gdastatsConfig struct {
-	URL string `toml:",omitempty"`
+	URL    string `toml:",omitempty"`
+	Token  string `toml:",omitempty"`
+	CAFile string `toml:",omitempty"`
 }
 
 type ggdaConfig struct {
@@ -80,6 +85,9 @@ type ggdaConfig struct {
 	Node      node.Config
 	gdastats  gdastatsConfig
 	Dashboard dashboard.Config
+	Faucet    faucet.Config
+	Telemetry telemetry.Config
+	Plugins   map[string]map[string]interface{} `toml:",omitempty"`
 }
 
 func loadConfig(file string, cfg *ggdaConfig) error {
@@ -114,6 +122,8 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, ggdaConfig) {
 		Shh:       whisper.DefaultConfig,
 		Node:      defaultNodeConfig(),
 		Dashboard: dashboard.DefaultConfig,
+		Faucet:    faucet.DefaultConfig,
+		Telemetry: telemetry.DefaultConfig,
 	}
 
 	// Load config file.
@@ -125,6 +135,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, ggdaConfig) {
 
 	// Apply flags.
 	utils.SetNodeConfig(ctx, &cfg.Node)
+	utils.SetMetricsTags(ctx, &cfg.Node, cfg.gda.NetworkId)
 	stack, err := node.New(&cfg.Node)
 	if err != nil {
 		utils.Fatalf("Failed to create the protocol stack: %v", err)
@@ -133,9 +144,17 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, ggdaConfig) {
 	if ctx.GlobalIsSet(utils.gdaStatsURLFlag.Name) {
 		cfg.gdastats.URL = ctx.GlobalString(utils.gdaStatsURLFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.gdaStatsTokenFlag.Name) {
+		cfg.gdastats.Token = ctx.GlobalString(utils.gdaStatsTokenFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.gdaStatsCAFlag.Name) {
+		cfg.gdastats.CAFile = ctx.GlobalString(utils.gdaStatsCAFlag.Name)
+	}
 
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 	utils.SetDashboardConfig(ctx, &cfg.Dashboard)
+	utils.SetFaucetConfig(ctx, &cfg.Faucet)
+	utils.SetTelemetryConfig(ctx, &cfg.Telemetry)
 
 	return stack, cfg
 }
@@ -173,8 +192,22 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 
 	// Add the gdachain Stats daemon if requested.
 	if cfg.gdastats.URL != "" {
-		utils.RegistergdaStatsService(stack, cfg.gdastats.URL)
+		utils.RegistergdaStatsService(stack, gdastats.Config{
+			URL:    cfg.gdastats.URL,
+			Token:  cfg.gdastats.Token,
+			CAFile: cfg.gdastats.CAFile,
+		})
+	}
+	// Add the anonymous usage reporter if opted in.
+	if cfg.Telemetry.Endpoint != "" {
+		utils.RegisterTelemetryService(stack, &cfg.Telemetry, cfg.Node.Version, cfg.gda.SyncMode)
+	}
+	// Add the testnet faucet if requested.
+	if ctx.GlobalBool(utils.FaucetEnabledFlag.Name) {
+		utils.RegisterFaucetService(stack, &cfg.Faucet)
 	}
+	// Add the services of all the plugins compiled into the binary.
+	utils.RegisterPlugins(stack, cfg.Plugins)
 	return stack
 }
 
@@ -61,11 +61,21 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
 		utils.DashboardRefreshFlag,
 		utils.DashboardAssetsFlag,
+		utils.FaucetEnabledFlag,
+		utils.FaucetAddrFlag,
+		utils.FaucetPortFlag,
+		utils.FaucetAccountFlag,
+		utils.FaucetPasswordFlag,
+		utils.FaucetAmountFlag,
+		utils.FaucetPeriodFlag,
+		utils.FaucetCaptchaFlag,
+		utils.FaucetWebhookFlag,
 		utils.gdaashCacheDirFlag,
 		utils.gdaashCachesInMemoryFlag,
 		utils.gdaashCachesOnDiskFlag,
@@ -75,8 +85,13 @@ var (
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolRemoteJournalFlag,
+		utils.TxPoolPolicyFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
+		utils.TxPoolFreeSendersFlag,
+		utils.TxPoolFreeRateFlag,
+		utils.TxPoolFreeBurstFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
@@ -85,17 +100,25 @@ var (
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
+		utils.CheckpointsFlag,
 		utils.GCModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.LightRPCFlag,
 		utils.LightKDFFlag,
+		utils.ScryptNFlag,
+		utils.ScryptPFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
+		utils.CacheJournalFlag,
 		utils.TrieCacheGenFlag,
+		utils.ShutdownTimeoutFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.PeerRequestRateFlag,
+		utils.PeerRequestBurstFlag,
 		utils.gdaerbaseFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
@@ -112,16 +135,56 @@ var (
 		utils.TestnetFlag,
 		utils.RinkebyFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMProfileFlag,
+		utils.RevertReasonsFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.RPCGlobalTxFeeCapFlag,
+		utils.RPCGlobalGasCapFlag,
+		utils.RPCGlobalEVMTimeoutFlag,
+		utils.RPCGlobalEVMMemoryFlag,
+		utils.RPCLogsRangeFlag,
+		utils.RPCLogsLimitFlag,
+		utils.RPCLogsTimeoutFlag,
+		utils.RPCImportLagFlag,
+		utils.RPCTxLookupScanFlag,
+		utils.AddressIndexFlag,
+		utils.TokenIndexFlag,
+		utils.RPCMaxRequestSizeFlag,
+		utils.RPCMethodTimeoutsFlag,
+		utils.RPCMaxConcurrentFlag,
+		utils.RPCMaxBatchSizeFlag,
+		utils.RPCJWTSecretFlag,
+		utils.RPCAuthAPIFlag,
 		utils.gdaStatsURLFlag,
+		utils.gdaStatsTokenFlag,
+		utils.gdaStatsCAFlag,
+		utils.TelemetryEndpointFlag,
+		utils.TelemetryIntervalFlag,
 		utils.MetricsEnabledFlag,
+		utils.MetricsHTTPFlag,
+		utils.MetricsPathFlag,
+		utils.MetricsInfluxDBEndpointFlag,
+		utils.MetricsInfluxDBDatabaseFlag,
+		utils.MetricsInfluxDBUsernameFlag,
+		utils.MetricsInfluxDBPasswordFlag,
+		utils.MetricsInfluxDBTokenFlag,
+		utils.MetricsInfluxDBOrgFlag,
+		utils.MetricsInfluxDBBucketFlag,
+		utils.MetricsInfluxDBTagsFlag,
+		utils.HealthMinPeersFlag,
+		utils.HealthMaxHeadAgeFlag,
+		utils.HealthNotSyncingFlag,
+		utils.HealthDBWritableFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
 		utils.ExtraDataFlag,
+		utils.MinerRecommitFlag,
+		utils.MinerEmptyWaitFlag,
+		utils.MinerDeadlineFlag,
 		configFileFlag,
 	}
 
@@ -137,6 +200,7 @@ var (
 		utils.WSAllowedOriginsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
+		utils.EventSocketFlag,
 	}
 
 	whisperFlags = []cli.Flag{
@@ -267,11 +331,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 				status, _ := event.Wallet.Status()
 				log.Info("New wallet appeared", "url", event.Wallet.URL(), "status", status)
 
-				if event.Wallet.URL().Scheme == "ledger" {
-					event.Wallet.SelfDerive(accounts.DefaultLedgerBaseDerivationPath, stateReader)
-				} else {
-					event.Wallet.SelfDerive(accounts.DefaultBaseDerivationPath, stateReader)
-				}
+				event.Wallet.SelfDerive(accounts.DefaultBaseDerivationPathFor(event.Wallet.URL().Scheme), stateReader)
 
 			case accounts.WalletDropped:
 				log.Info("Old wallet dropped", "url", event.Wallet.URL())
@@ -280,7 +340,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 	}()
 	// Start auxiliary services if enabled
-	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
+	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) {
 		// Mining only makes sense if a full gdachain node is running
 		if ctx.GlobalBool(utils.LightModeFlag.Name) || ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support mining")
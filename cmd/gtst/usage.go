@@ -71,16 +71,25 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
+			utils.ExternalSignerFlag,
 			utils.NetworkIdFlag,
 			utils.TestnetFlag,
 			utils.RinkebyFlag,
 			utils.SyncModeFlag,
+			utils.CheckpointsFlag,
 			utils.GCModeFlag,
 			utils.gdaStatsURLFlag,
+			utils.gdaStatsTokenFlag,
+			utils.gdaStatsCAFlag,
+			utils.TelemetryEndpointFlag,
+			utils.TelemetryIntervalFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.LightRPCFlag,
 			utils.LightKDFFlag,
+			utils.ScryptNFlag,
+			utils.ScryptPFlag,
 		},
 	},
 	{Name: "DEVELOPER CHAIN",
@@ -116,8 +125,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolNoLocalsFlag,
 			utils.TxPoolJournalFlag,
 			utils.TxPoolRejournalFlag,
+			utils.TxPoolRemoteJournalFlag,
+			utils.TxPoolPolicyFlag,
 			utils.TxPoolPriceLimitFlag,
 			utils.TxPoolPriceBumpFlag,
+			utils.TxPoolFreeSendersFlag,
+			utils.TxPoolFreeRateFlag,
+			utils.TxPoolFreeBurstFlag,
 			utils.TxPoolAccountSlotsFlag,
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
@@ -131,7 +145,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.CacheJournalFlag,
 			utils.TrieCacheGenFlag,
+			utils.ShutdownTimeoutFlag,
 		},
 	},
 	{
@@ -155,8 +171,26 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WSAllowedOriginsFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
+			utils.EventSocketFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.RPCGlobalTxFeeCapFlag,
+			utils.RPCGlobalGasCapFlag,
+			utils.RPCGlobalEVMTimeoutFlag,
+			utils.RPCGlobalEVMMemoryFlag,
+			utils.RPCLogsRangeFlag,
+			utils.RPCLogsLimitFlag,
+			utils.RPCLogsTimeoutFlag,
+			utils.RPCImportLagFlag,
+			utils.RPCTxLookupScanFlag,
+			utils.AddressIndexFlag,
+			utils.TokenIndexFlag,
+			utils.RPCMaxRequestSizeFlag,
+			utils.RPCMethodTimeoutsFlag,
+			utils.RPCMaxConcurrentFlag,
+			utils.RPCMaxBatchSizeFlag,
+			utils.RPCJWTSecretFlag,
+			utils.RPCAuthAPIFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -171,6 +205,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.PeerRequestRateFlag,
+			utils.PeerRequestBurstFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
@@ -188,6 +224,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerRecommitFlag,
+			utils.MinerEmptyWaitFlag,
+			utils.MinerDeadlineFlag,
 		},
 	},
 	{
@@ -201,16 +240,46 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMProfileFlag,
+			utils.RevertReasonsFlag,
 		},
 	},
 	{
 		Name: "LOGGING AND DEBUGGING",
 		Flags: append([]cli.Flag{
 			utils.MetricsEnabledFlag,
+			utils.MetricsHTTPFlag,
+			utils.MetricsPathFlag,
+			utils.MetricsInfluxDBEndpointFlag,
+			utils.MetricsInfluxDBDatabaseFlag,
+			utils.MetricsInfluxDBUsernameFlag,
+			utils.MetricsInfluxDBPasswordFlag,
+			utils.MetricsInfluxDBTokenFlag,
+			utils.MetricsInfluxDBOrgFlag,
+			utils.MetricsInfluxDBBucketFlag,
+			utils.MetricsInfluxDBTagsFlag,
+			utils.HealthMinPeersFlag,
+			utils.HealthMaxHeadAgeFlag,
+			utils.HealthNotSyncingFlag,
+			utils.HealthDBWritableFlag,
 			utils.FakePoWFlag,
 			utils.NoCompactionFlag,
 		}, debug.Flags...),
 	},
+	{
+		Name: "FAUCET",
+		Flags: []cli.Flag{
+			utils.FaucetEnabledFlag,
+			utils.FaucetAddrFlag,
+			utils.FaucetPortFlag,
+			utils.FaucetAccountFlag,
+			utils.FaucetPasswordFlag,
+			utils.FaucetAmountFlag,
+			utils.FaucetPeriodFlag,
+			utils.FaucetCaptchaFlag,
+			utils.FaucetWebhookFlag,
+		},
+	},
 	{
 		Name:  "WHISPER (EXPERIMENTAL)",
 		Flags: whisperFlags,
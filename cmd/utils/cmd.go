@@ -27,16 +27,12 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core"
-	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda"
 	"github.com/gdachain/go-gdachain/internal/debug"
 	"github.com/gdachain/go-gdachain/log"
 	"github.com/gdachain/go-gdachain/node"
-	"github.com/gdachain/go-gdachain/rlp"
-)
-
-const (
-	importBatchSize = 2500
 )
 
 // Fatalf formats a message to standard error and exits the program.
@@ -95,14 +91,6 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 		}
 		close(stop)
 	}()
-	checkInterrupt := func() bool {
-		select {
-		case <-stop:
-			return true
-		default:
-			return false
-		}
-	}
 
 	log.Info("Importing blockchain", "file", fn)
 	fh, err := os.Open(fn)
@@ -117,67 +105,16 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			return err
 		}
 	}
-	stream := rlp.NewStream(reader, 0)
-
-	// Run actual the import.
-	blocks := make(types.Blocks, importBatchSize)
-	n := 0
-	for batch := 0; ; batch++ {
-		// Load a batch of RLP blocks.
-		if checkInterrupt() {
-			return fmt.Errorf("interrupted")
-		}
-		i := 0
-		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
-				break
-			} else if err != nil {
-				return fmt.Errorf("at block %d: %v", n, err)
-			}
-			// don't import first block
-			if b.NumberU64() == 0 {
-				i--
-				continue
-			}
-			blocks[i] = &b
-			n++
-		}
-		if i == 0 {
-			break
-		}
-		// Import the batch.
-		if checkInterrupt() {
-			return fmt.Errorf("interrupted")
-		}
-		missing := missingBlocks(chain, blocks[:i])
-		if len(missing) == 0 {
-			log.Info("Skipping batch as all blocks present", "batch", batch, "first", blocks[0].Hash(), "last", blocks[i-1].Hash())
-			continue
-		}
-		if _, err := chain.InsertChain(missing); err != nil {
-			return fmt.Errorf("invalid block %d: %v", n, err)
-		}
-	}
-	return nil
-}
-
-func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block {
-	head := chain.CurrentBlock()
-	for i, block := range blocks {
-		// If we're behind the chain head, only check block, state is available at head
-		if head.NumberU64() > block.NumberU64() {
-			if !chain.HasBlock(block.Hash(), block.NumberU64()) {
-				return blocks[i:]
-			}
-			continue
-		}
-		// If we're above the chain head, state availability is a must
-		if !chain.HasBlockAndState(block.Hash(), block.NumberU64()) {
-			return blocks[i:]
-		}
+	progress, err := gda.ImportBlocks(chain, reader, gda.ImportOptions{
+		Stop: stop,
+		Progress: func(progress gda.ImportProgress) {
+			log.Info("Imported block batch", "number", progress.Number, "imported", progress.Imported, "skipped", progress.Skipped, "elapsed", common.PrettyDuration(progress.Elapsed))
+		},
+	})
+	if err == nil {
+		log.Info("Imported blockchain", "file", fn, "imported", progress.Imported, "skipped", progress.Skipped)
 	}
-	return nil
+	return err
 }
 
 func ExportChain(blockchain *core.BlockChain, fn string) error {
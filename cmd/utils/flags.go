@@ -27,6 +27,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/accounts/keystore"
@@ -40,6 +41,7 @@ import (
 	"github.com/gdachain/go-gdachain/core/vm"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/dashboard"
+	"github.com/gdachain/go-gdachain/faucet"
 	"github.com/gdachain/go-gdachain/gda"
 	"github.com/gdachain/go-gdachain/gda/downloader"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
@@ -55,6 +57,8 @@ import (
 	"github.com/gdachain/go-gdachain/p2p/nat"
 	"github.com/gdachain/go-gdachain/p2p/netutil"
 	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/plugins"
+	"github.com/gdachain/go-gdachain/telemetry"
 	whisper "github.com/gdachain/go-gdachain/whisper/whisperv5"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -122,6 +126,11 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer endpoint (IPC path or HTTP/WS URL), keeping keys out of the node",
+		Value: "",
+	}
 	NoUSBFlag = cli.BoolFlag{
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
@@ -167,9 +176,13 @@ var (
 	defaultSyncMode = gda.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "light" or "header")`,
 		Value: &defaultSyncMode,
 	}
+	CheckpointsFlag = cli.StringFlag{
+		Name:  "checkpoints",
+		Usage: `Comma separated number=hash checkpoints overriding the hard-coded ones ("none" to disable)`,
+	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
@@ -185,10 +198,24 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: gda.DefaultConfig.LightPeers,
 	}
+	LightRPCFlag = cli.StringFlag{
+		Name:  "lightrpc",
+		Usage: "Trusted full node RPC endpoint (with the odr API enabled) to retrieve light client data from when no LES server is reachable",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	ScryptNFlag = cli.IntFlag{
+		Name:  "scryptn",
+		Usage: "Scrypt N parameter for encrypting keys, a power of two (0 = default, overrides --lightkdf)",
+		Value: 0,
+	}
+	ScryptPFlag = cli.IntFlag{
+		Name:  "scryptp",
+		Usage: "Scrypt P parameter for encrypting keys (0 = default, overrides --lightkdf)",
+		Value: 0,
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  "dashboard",
@@ -214,6 +241,47 @@ var (
 		Usage: "Developer flag to serve the dashboard from the local file system",
 		Value: dashboard.DefaultConfig.Assets,
 	}
+	// Faucet settings
+	FaucetEnabledFlag = cli.BoolFlag{
+		Name:  "faucet",
+		Usage: "Enable the testnet faucet funding requests from a keystore account",
+	}
+	FaucetAddrFlag = cli.StringFlag{
+		Name:  "faucet.addr",
+		Usage: "Faucet HTTP listening interface",
+		Value: faucet.DefaultConfig.Host,
+	}
+	FaucetPortFlag = cli.IntFlag{
+		Name:  "faucet.port",
+		Usage: "Faucet HTTP listening port",
+		Value: faucet.DefaultConfig.Port,
+	}
+	FaucetAccountFlag = cli.StringFlag{
+		Name:  "faucet.account",
+		Usage: "Keystore account funding the faucet requests",
+	}
+	FaucetPasswordFlag = cli.StringFlag{
+		Name:  "faucet.password",
+		Usage: "File containing the passphrase of the faucet account",
+	}
+	FaucetAmountFlag = BigFlag{
+		Name:  "faucet.amount",
+		Usage: "Number of wei sent for each faucet request",
+		Value: faucet.DefaultConfig.Amount,
+	}
+	FaucetPeriodFlag = cli.DurationFlag{
+		Name:  "faucet.period",
+		Usage: "Time an address has to wait between two faucet requests",
+		Value: faucet.DefaultConfig.Period,
+	}
+	FaucetCaptchaFlag = cli.StringFlag{
+		Name:  "faucet.captcha",
+		Usage: "reCaptcha secret to verify faucet requests with (disabled if empty)",
+	}
+	FaucetWebhookFlag = cli.StringFlag{
+		Name:  "faucet.webhook",
+		Usage: "URL approving each faucet request before it is funded (disabled if empty)",
+	}
 	// gdaash settings
 	gdaashCacheDirFlag = DirectoryFlag{
 		Name:  "ethash.cachedir",
@@ -259,11 +327,33 @@ var (
 		Usage: "Time interval to regenerate the local transaction journal",
 		Value: core.DefaultTxPoolConfig.Rejournal,
 	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk snapshot of remote transactions to restore on restart (disabled if empty)",
+	}
+	TxPoolPolicyFlag = cli.StringFlag{
+		Name:  "txpool.policy",
+		Usage: "JSON ruleset of addresses allowed or denied to send and receive transactions (disabled if empty)",
+	}
 	TxPoolPriceLimitFlag = cli.Uint64Flag{
 		Name:  "txpool.pricelimit",
 		Usage: "Minimum gas price limit to enforce for acceptance into the pool",
 		Value: gda.DefaultConfig.TxPool.PriceLimit,
 	}
+	TxPoolFreeSendersFlag = cli.StringFlag{
+		Name:  "txpool.freesenders",
+		Usage: "Comma separated senders allowed to submit transactions below the price limit, down to zero",
+	}
+	TxPoolFreeRateFlag = cli.Float64Flag{
+		Name:  "txpool.freerate",
+		Usage: "Transactions per second accepted below the price limit from each gas-free sender (0 = unlimited)",
+		Value: gda.DefaultConfig.TxPool.FreeRate,
+	}
+	TxPoolFreeBurstFlag = cli.IntFlag{
+		Name:  "txpool.freeburst",
+		Usage: "Maximum number of transactions accepted below the price limit at once from each gas-free sender",
+		Value: gda.DefaultConfig.TxPool.FreeBurst,
+	}
 	TxPoolPriceBumpFlag = cli.Uint64Flag{
 		Name:  "txpool.pricebump",
 		Usage: "Price bump percentage to replace an already existing transaction",
@@ -310,11 +400,21 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning",
 		Value: 25,
 	}
+	CacheJournalFlag = cli.StringFlag{
+		Name:  "cache.journal",
+		Usage: "Disk journal persisting the trie cache across restarts (empty = disabled)",
+		Value: gda.DefaultConfig.TrieJournal,
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	ShutdownTimeoutFlag = cli.DurationFlag{
+		Name:  "shutdown.timeout",
+		Usage: "Maximum time to wait for the trie caches and transaction journal to be flushed on shutdown (0 = indefinitely)",
+		Value: gda.DefaultConfig.ShutdownTimeout,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -344,6 +444,18 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerRecommitFlag = cli.DurationFlag{
+		Name:  "miner.recommit",
+		Usage: "Time interval to recreate the block being mined with new transactions (0 = only on new heads)",
+	}
+	MinerEmptyWaitFlag = cli.DurationFlag{
+		Name:  "miner.emptywait",
+		Usage: "Maximum time after its parent to hold back sealing an empty block (0 = seal at once)",
+	}
+	MinerDeadlineFlag = cli.DurationFlag{
+		Name:  "miner.deadline",
+		Usage: "Maximum time to spend assembling a block before sealing it (0 = unlimited)",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -360,15 +472,109 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMProfileFlag = cli.IntFlag{
+		Name:  "vmprofile",
+		Usage: "Number of recent blocks whose executed opcodes are profiled (0 = disabled)",
+		Value: 0,
+	}
+	RevertReasonsFlag = cli.BoolFlag{
+		Name:  "receipts.revertreasons",
+		Usage: "Store the revert data of failed transactions in their receipts",
+	}
 	// Logging and debug settings
 	gdaStatsURLFlag = cli.StringFlag{
 		Name:  "gdastats",
 		Usage: "Reporting URL of a gdastats service (nodename:secret@host:port)",
 	}
+	gdaStatsTokenFlag = cli.StringFlag{
+		Name:  "gdastats.token",
+		Usage: "Bearer token authenticating with the gdastats service, requires TLS",
+	}
+	gdaStatsCAFlag = cli.StringFlag{
+		Name:  "gdastats.ca",
+		Usage: "PEM certificates to verify the gdastats service with (default = system roots)",
+	}
+	TelemetryEndpointFlag = cli.StringFlag{
+		Name:  "telemetry",
+		Usage: "Opt-in URL to periodically post anonymous node usage statistics to",
+	}
+	TelemetryIntervalFlag = cli.DurationFlag{
+		Name:  "telemetry.interval",
+		Usage: "Time interval between two anonymous usage reports",
+		Value: telemetry.DefaultConfig.Interval,
+	}
 	MetricsEnabledFlag = cli.BoolFlag{
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsHTTPFlag = cli.StringFlag{
+		Name:  "metricsaddr",
+		Usage: "Listening address (host:port) of the Prometheus metrics endpoint (disabled if empty)",
+		Value: "",
+	}
+	MetricsPathFlag = cli.StringFlag{
+		Name:  "metricspath",
+		Usage: "HTTP path of the Prometheus metrics endpoint",
+		Value: node.DefaultMetricsPath,
+	}
+	MetricsInfluxDBEndpointFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.endpoint",
+		Usage: "URL of the InfluxDB server to report metrics to (disabled if empty)",
+		Value: "",
+	}
+	MetricsInfluxDBDatabaseFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.database",
+		Usage: "InfluxDB v1 database to report metrics to",
+		Value: "gtst",
+	}
+	MetricsInfluxDBUsernameFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.username",
+		Usage: "Username to authorize access to the InfluxDB v1 database",
+		Value: "",
+	}
+	MetricsInfluxDBPasswordFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.password",
+		Usage: "Password to authorize access to the InfluxDB v1 database",
+		Value: "",
+	}
+	MetricsInfluxDBTokenFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.token",
+		Usage: "Token to authorize access to the InfluxDB v2 API (selects the v2 API)",
+		Value: "",
+	}
+	MetricsInfluxDBOrgFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.organization",
+		Usage: "InfluxDB v2 organization owning the metrics bucket",
+		Value: "",
+	}
+	MetricsInfluxDBBucketFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.bucket",
+		Usage: "InfluxDB v2 bucket to report metrics to",
+		Value: "",
+	}
+	MetricsInfluxDBTagsFlag = cli.StringFlag{
+		Name:  "metrics.influxdb.tags",
+		Usage: "Comma separated key=value tags attached to all reported metrics (network and instance are added automatically)",
+		Value: "",
+	}
+	HealthMinPeersFlag = cli.IntFlag{
+		Name:  "health.minpeers",
+		Usage: "Minimum number of peers for the node to be reported healthy (0 = not checked)",
+		Value: 0,
+	}
+	HealthMaxHeadAgeFlag = cli.DurationFlag{
+		Name:  "health.maxheadage",
+		Usage: "Maximum age of the head block for the node to be reported healthy (0 = not checked)",
+		Value: 0,
+	}
+	HealthNotSyncingFlag = cli.BoolFlag{
+		Name:  "health.notsyncing",
+		Usage: "Report the node degraded while it is synchronising",
+	}
+	HealthDBWritableFlag = cli.BoolFlag{
+		Name:  "health.dbwritable",
+		Usage: "Report the node degraded if its database rejects writes",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -407,6 +613,89 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	RPCGlobalTxFeeCapFlag = cli.Float64Flag{
+		Name:  "rpctxfeecap",
+		Usage: "Sets a cap on transaction fee (in gdaer) that can be sent via the RPC APIs (0 = no cap)",
+		Value: gda.DefaultConfig.RPCTxFeeCap,
+	}
+	RPCGlobalGasCapFlag = cli.Uint64Flag{
+		Name:  "rpcgascap",
+		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas (0 = no cap)",
+		Value: gda.DefaultConfig.RPCGasCap,
+	}
+	RPCGlobalEVMTimeoutFlag = cli.DurationFlag{
+		Name:  "rpcevmtimeout",
+		Usage: "Sets a timeout used for eth_call (0 = no timeout)",
+		Value: gda.DefaultConfig.RPCEVMTimeout,
+	}
+	RPCGlobalEVMMemoryFlag = cli.Uint64Flag{
+		Name:  "rpcevmmemory",
+		Usage: "Sets a memory limit in bytes for the EVM executions of eth_call/estimateGas and traces (0 = no limit)",
+		Value: gda.DefaultConfig.RPCEVMMemory,
+	}
+	RPCLogsRangeFlag = cli.Uint64Flag{
+		Name:  "rpclogsrange",
+		Usage: "Maximum number of blocks a single log query may span (0 = unlimited)",
+		Value: gda.DefaultConfig.Filters.RangeLimit,
+	}
+	RPCLogsLimitFlag = cli.IntFlag{
+		Name:  "rpclogslimit",
+		Usage: "Maximum number of logs a single log query may return (0 = unlimited)",
+		Value: gda.DefaultConfig.Filters.ResultLimit,
+	}
+	RPCLogsTimeoutFlag = cli.DurationFlag{
+		Name:  "rpclogstimeout",
+		Usage: "Maximum time a single log query may execute for (0 = unlimited)",
+		Value: gda.DefaultConfig.Filters.Timeout,
+	}
+	RPCImportLagFlag = cli.Uint64Flag{
+		Name:  "rpcimportlag",
+		Usage: "Maximum number of blocks the chain head may lag before debug/trace requests are deferred (0 = never defer)",
+		Value: gda.DefaultConfig.DebugImportLag,
+	}
+	RPCTxLookupScanFlag = cli.Uint64Flag{
+		Name:  "rpctxlookupscan",
+		Usage: "Number of recent blocks scanned for transactions missing from the lookup index (0 = no scan)",
+		Value: gda.DefaultConfig.TxLookupScan,
+	}
+	AddressIndexFlag = cli.BoolFlag{
+		Name:  "index.addresses",
+		Usage: "Index the transactions and internal transfers of every address (gda_getTransactionsByAddress)",
+	}
+	TokenIndexFlag = cli.BoolFlag{
+		Name:  "index.tokens",
+		Usage: "Index the ERC-20 token transfers and balances of every address (token RPC namespace)",
+	}
+	RPCMaxRequestSizeFlag = cli.Int64Flag{
+		Name:  "rpcmaxrequestsize",
+		Usage: "Maximum size in bytes of an HTTP-RPC request body, after decompression (0 = 128KB)",
+		Value: node.DefaultConfig.HTTPMaxRequestSize,
+	}
+	RPCMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpctimeouts",
+		Usage: "Comma separated list of RPC method execution timeouts (e.g. debug_*=1m,gda_call=5s)",
+		Value: "",
+	}
+	RPCMaxConcurrentFlag = cli.IntFlag{
+		Name:  "rpcmaxconcurrent",
+		Usage: "Maximum number of concurrently executing RPC calls per endpoint (0 = unlimited)",
+		Value: node.DefaultConfig.RPCMaxConcurrent,
+	}
+	RPCMaxBatchSizeFlag = cli.IntFlag{
+		Name:  "rpcmaxbatch",
+		Usage: "Maximum number of requests in an RPC batch (0 = unlimited)",
+		Value: node.DefaultConfig.RPCMaxBatchSize,
+	}
+	RPCJWTSecretFlag = cli.StringFlag{
+		Name:  "rpcjwtsecret",
+		Usage: "Path to a hex encoded secret for authenticating HTTP/WS-RPC requests to protected APIs with JWTs",
+		Value: "",
+	}
+	RPCAuthAPIFlag = cli.StringFlag{
+		Name:  "rpcauthapi",
+		Usage: "APIs requiring authentication over HTTP/WS-RPC once a JWT secret is set (default: admin,debug,miner,personal)",
+		Value: "",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -415,6 +704,10 @@ var (
 		Name:  "ipcpath",
 		Usage: "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
 	}
+	EventSocketFlag = DirectoryFlag{
+		Name:  "eventsocket",
+		Usage: "Filename for the socket/pipe publishing chain events as JSON lines within the datadir (explicit paths escape it)",
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -459,6 +752,16 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	PeerRequestRateFlag = cli.Float64Flag{
+		Name:  "peer.reqrate",
+		Usage: "Data requests served per second to a single peer before throttling it (0 = unlimited)",
+		Value: gda.DefaultConfig.PeerRequestRate,
+	}
+	PeerRequestBurstFlag = cli.IntFlag{
+		Name:  "peer.reqburst",
+		Usage: "Data requests served to a single peer in a burst",
+		Value: gda.DefaultConfig.PeerRequestBurst,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -698,6 +1001,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(RPCVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(RPCVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(RPCMaxRequestSizeFlag.Name) {
+		cfg.HTTPMaxRequestSize = ctx.GlobalInt64(RPCMaxRequestSizeFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -721,6 +1027,120 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setRPCLimits configures the resource limits of the RPC endpoints from the set
+// command line flags.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCMethodTimeoutsFlag.Name) {
+		cfg.RPCMethodTimeouts = make(map[string]time.Duration)
+		for _, entry := range splitAndTrim(ctx.GlobalString(RPCMethodTimeoutsFlag.Name)) {
+			if entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				Fatalf("Invalid RPC method timeout %q, expected method=duration", entry)
+			}
+			timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+			if err != nil {
+				Fatalf("Invalid RPC method timeout %q: %v", entry, err)
+			}
+			cfg.RPCMethodTimeouts[strings.TrimSpace(parts[0])] = timeout
+		}
+	}
+	if ctx.GlobalIsSet(RPCMaxConcurrentFlag.Name) {
+		cfg.RPCMaxConcurrent = ctx.GlobalInt(RPCMaxConcurrentFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMaxBatchSizeFlag.Name) {
+		cfg.RPCMaxBatchSize = ctx.GlobalInt(RPCMaxBatchSizeFlag.Name)
+	}
+}
+
+// setRPCAuth configures the authentication of the HTTP and websocket RPC
+// endpoints from the set command line flags.
+func setRPCAuth(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCJWTSecretFlag.Name) {
+		cfg.RPCJWTSecretFile = ctx.GlobalString(RPCJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCAuthAPIFlag.Name) {
+		cfg.RPCAuthModules = splitAndTrim(ctx.GlobalString(RPCAuthAPIFlag.Name))
+	}
+}
+
+// setMetrics configures the Prometheus metrics endpoint from the set command
+// line flags.
+func setMetrics(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(MetricsHTTPFlag.Name) {
+		cfg.MetricsHTTP = ctx.GlobalString(MetricsHTTPFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsPathFlag.Name) {
+		cfg.MetricsPath = ctx.GlobalString(MetricsPathFlag.Name)
+	}
+	influx := &cfg.MetricsInfluxDB
+	if ctx.GlobalIsSet(MetricsInfluxDBEndpointFlag.Name) {
+		influx.Endpoint = ctx.GlobalString(MetricsInfluxDBEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBDatabaseFlag.Name) || influx.Database == "" {
+		influx.Database = ctx.GlobalString(MetricsInfluxDBDatabaseFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBUsernameFlag.Name) {
+		influx.Username = ctx.GlobalString(MetricsInfluxDBUsernameFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBPasswordFlag.Name) {
+		influx.Password = ctx.GlobalString(MetricsInfluxDBPasswordFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBTokenFlag.Name) {
+		influx.Token = ctx.GlobalString(MetricsInfluxDBTokenFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBOrgFlag.Name) {
+		influx.Org = ctx.GlobalString(MetricsInfluxDBOrgFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBBucketFlag.Name) {
+		influx.Bucket = ctx.GlobalString(MetricsInfluxDBBucketFlag.Name)
+	}
+	if ctx.GlobalIsSet(MetricsInfluxDBTagsFlag.Name) {
+		if influx.Tags == nil {
+			influx.Tags = make(map[string]string)
+		}
+		for _, tag := range splitAndTrim(ctx.GlobalString(MetricsInfluxDBTagsFlag.Name)) {
+			kv := strings.SplitN(tag, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				Fatalf("Invalid metrics tag %q, expected key=value", tag)
+			}
+			influx.Tags[kv[0]] = kv[1]
+		}
+	}
+}
+
+// SetMetricsTags tags the metrics reported to InfluxDB with the network id and
+// the instance name of the node (its identity, or the host name without one),
+// unless configured explicitly. The network id is resolved the same way as by
+// SetgdaConfig, starting from the configured one.
+func SetMetricsTags(ctx *cli.Context, cfg *node.Config, networkId uint64) {
+	switch {
+	case ctx.GlobalIsSet(NetworkIdFlag.Name):
+		networkId = ctx.GlobalUint64(NetworkIdFlag.Name)
+	case ctx.GlobalBool(TestnetFlag.Name):
+		networkId = 3
+	case ctx.GlobalBool(RinkebyFlag.Name):
+		networkId = 4
+	}
+	tags := make(map[string]string)
+	for k, v := range cfg.MetricsInfluxDB.Tags {
+		tags[k] = v
+	}
+	if _, ok := tags["network"]; !ok {
+		tags["network"] = strconv.FormatUint(networkId, 10)
+	}
+	if _, ok := tags["instance"]; !ok {
+		instance := cfg.UserIdent
+		if instance == "" {
+			instance, _ = os.Hostname()
+		}
+		tags["instance"] = instance
+	}
+	cfg.MetricsInfluxDB.Tags = tags
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -870,12 +1290,32 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setHealth configures the health check criteria from the set command line flags.
+func setHealth(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(HealthMinPeersFlag.Name) {
+		cfg.HealthMinPeers = ctx.GlobalInt(HealthMinPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthMaxHeadAgeFlag.Name) {
+		cfg.HealthMaxHeadAge = ctx.GlobalDuration(HealthMaxHeadAgeFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthNotSyncingFlag.Name) {
+		cfg.HealthNotSyncing = ctx.GlobalBool(HealthNotSyncingFlag.Name)
+	}
+	if ctx.GlobalIsSet(HealthDBWritableFlag.Name) {
+		cfg.HealthDBWritable = ctx.GlobalBool(HealthDBWritableFlag.Name)
+	}
+}
+
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	SetP2PConfig(ctx, &cfg.P2P)
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setRPCLimits(ctx, cfg)
+	setRPCAuth(ctx, cfg)
+	setMetrics(ctx, cfg)
+	setHealth(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -895,9 +1335,18 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
 	}
+	if ctx.GlobalIsSet(ScryptNFlag.Name) {
+		cfg.KeyStoreScryptN = ctx.GlobalInt(ScryptNFlag.Name)
+	}
+	if ctx.GlobalIsSet(ScryptPFlag.Name) {
+		cfg.KeyStoreScryptP = ctx.GlobalInt(ScryptPFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -919,12 +1368,33 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.GlobalDuration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPolicyFlag.Name) {
+		cfg.PolicyRules = ctx.GlobalString(TxPoolPolicyFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.GlobalUint64(TxPoolPriceLimitFlag.Name)
 	}
 	if ctx.GlobalIsSet(TxPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.GlobalUint64(TxPoolPriceBumpFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolFreeSendersFlag.Name) {
+		cfg.FreeSenders = nil
+		for _, sender := range strings.Split(ctx.GlobalString(TxPoolFreeSendersFlag.Name), ",") {
+			if sender = strings.TrimSpace(sender); !common.IsHexAddress(sender) {
+				Fatalf("Invalid gas-free sender: %q", sender)
+			}
+			cfg.FreeSenders = append(cfg.FreeSenders, common.HexToAddress(sender))
+		}
+	}
+	if ctx.GlobalIsSet(TxPoolFreeRateFlag.Name) {
+		cfg.FreeRate = ctx.GlobalFloat64(TxPoolFreeRateFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolFreeBurstFlag.Name) {
+		cfg.FreeBurst = ctx.GlobalInt(TxPoolFreeBurstFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolAccountSlotsFlag.Name) {
 		cfg.AccountSlots = ctx.GlobalUint64(TxPoolAccountSlotsFlag.Name)
 	}
@@ -1033,15 +1503,27 @@ func SetgdaConfig(ctx *cli.Context, stack *node.Node, cfg *gda.Config) {
 	case ctx.GlobalBool(LightModeFlag.Name):
 		cfg.SyncMode = downloader.LightSync
 	}
+	if ctx.GlobalIsSet(CheckpointsFlag.Name) {
+		cfg.Checkpoints = ctx.GlobalString(CheckpointsFlag.Name)
+	}
 	if ctx.GlobalIsSet(LightServFlag.Name) {
 		cfg.LightServ = ctx.GlobalInt(LightServFlag.Name)
 	}
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightRPCFlag.Name) {
+		cfg.LightRPC = ctx.GlobalString(LightRPCFlag.Name)
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
+	if ctx.GlobalIsSet(PeerRequestRateFlag.Name) {
+		cfg.PeerRequestRate = ctx.GlobalFloat64(PeerRequestRateFlag.Name)
+	}
+	if ctx.GlobalIsSet(PeerRequestBurstFlag.Name) {
+		cfg.PeerRequestBurst = ctx.GlobalInt(PeerRequestBurstFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
@@ -1056,22 +1538,79 @@ func SetgdaConfig(ctx *cli.Context, stack *node.Node, cfg *gda.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheJournalFlag.Name) {
+		cfg.TrieJournal = ctx.GlobalString(CacheJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(ShutdownTimeoutFlag.Name) {
+		cfg.ShutdownTimeout = ctx.GlobalDuration(ShutdownTimeoutFlag.Name)
+	}
 	if ctx.GlobalIsSet(MinerThreadsFlag.Name) {
 		cfg.MinerThreads = ctx.GlobalInt(MinerThreadsFlag.Name)
 	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
+		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCGlobalGasCapFlag.Name) {
+		cfg.RPCGasCap = ctx.GlobalUint64(RPCGlobalGasCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCGlobalEVMTimeoutFlag.Name) {
+		cfg.RPCEVMTimeout = ctx.GlobalDuration(RPCGlobalEVMTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCGlobalEVMMemoryFlag.Name) {
+		cfg.RPCEVMMemory = ctx.GlobalUint64(RPCGlobalEVMMemoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogsRangeFlag.Name) {
+		cfg.Filters.RangeLimit = ctx.GlobalUint64(RPCLogsRangeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogsLimitFlag.Name) {
+		cfg.Filters.ResultLimit = ctx.GlobalInt(RPCLogsLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogsTimeoutFlag.Name) {
+		cfg.Filters.Timeout = ctx.GlobalDuration(RPCLogsTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCImportLagFlag.Name) {
+		cfg.DebugImportLag = ctx.GlobalUint64(RPCImportLagFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTxLookupScanFlag.Name) {
+		cfg.TxLookupScan = ctx.GlobalUint64(RPCTxLookupScanFlag.Name)
+	}
+	if ctx.GlobalIsSet(AddressIndexFlag.Name) {
+		cfg.AddressIndex = ctx.GlobalBool(AddressIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(TokenIndexFlag.Name) {
+		cfg.TokenIndex = ctx.GlobalBool(TokenIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(EventSocketFlag.Name) {
+		cfg.EventSocket = ctx.GlobalString(EventSocketFlag.Name)
+	}
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerRecommitFlag.Name) {
+		cfg.MinerRecommit = ctx.GlobalDuration(MinerRecommitFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerEmptyWaitFlag.Name) {
+		cfg.MinerEmptyWait = ctx.GlobalDuration(MinerEmptyWaitFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerDeadlineFlag.Name) {
+		cfg.MinerDeadline = ctx.GlobalDuration(MinerDeadlineFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMProfileFlag.Name) {
+		cfg.VMProfileBlocks = ctx.GlobalInt(VMProfileFlag.Name)
+	}
+	if ctx.GlobalIsSet(RevertReasonsFlag.Name) {
+		cfg.RecordRevertReasons = ctx.GlobalBool(RevertReasonsFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1086,25 +1625,9 @@ func SetgdaConfig(ctx *cli.Context, stack *node.Node, cfg *gda.Config) {
 		}
 		cfg.Genesis = core.DefaultRinkebyGenesisBlock()
 	case ctx.GlobalBool(DeveloperFlag.Name):
-		// Create new developer account or reuse existing one
-		var (
-			developer accounts.Account
-			err       error
-		)
-		if accs := ks.Accounts(); len(accs) > 0 {
-			developer = ks.Accounts()[0]
-		} else {
-			developer, err = ks.NewAccount("")
-			if err != nil {
-				Fatalf("Failed to create developer account: %v", err)
-			}
-		}
-		if err := ks.Unlock(developer, ""); err != nil {
-			Fatalf("Failed to unlock developer account: %v", err)
-		}
-		log.Info("Using developer account", "address", developer.Address)
-
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		// The developer account and chain are set up by the gdachain service
+		cfg.DevMode = true
+		cfg.DevPeriod = uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name))
 		if !ctx.GlobalIsSet(GasPriceFlag.Name) {
 			cfg.GasPrice = big.NewInt(1)
 		}
@@ -1123,6 +1646,38 @@ func SetDashboardConfig(ctx *cli.Context, cfg *dashboard.Config) {
 	cfg.Assets = ctx.GlobalString(DashboardAssetsFlag.Name)
 }
 
+// SetFaucetConfig applies faucet related command line flags to the config.
+func SetFaucetConfig(ctx *cli.Context, cfg *faucet.Config) {
+	if ctx.GlobalIsSet(FaucetAddrFlag.Name) {
+		cfg.Host = ctx.GlobalString(FaucetAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetPortFlag.Name) {
+		cfg.Port = ctx.GlobalInt(FaucetPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetAccountFlag.Name) {
+		account := ctx.GlobalString(FaucetAccountFlag.Name)
+		if !common.IsHexAddress(account) {
+			Fatalf("Invalid faucet account: %q", account)
+		}
+		cfg.Account = common.HexToAddress(account)
+	}
+	if ctx.GlobalIsSet(FaucetPasswordFlag.Name) {
+		cfg.PasswordFile = ctx.GlobalString(FaucetPasswordFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetAmountFlag.Name) {
+		cfg.Amount = GlobalBig(ctx, FaucetAmountFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetPeriodFlag.Name) {
+		cfg.Period = ctx.GlobalDuration(FaucetPeriodFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetCaptchaFlag.Name) {
+		cfg.Captcha = ctx.GlobalString(FaucetCaptchaFlag.Name)
+	}
+	if ctx.GlobalIsSet(FaucetWebhookFlag.Name) {
+		cfg.Webhook = ctx.GlobalString(FaucetWebhookFlag.Name)
+	}
+}
+
 // RegistergdaService adds an gdachain client to the stack.
 func RegistergdaService(stack *node.Node, cfg *gda.Config) {
 	var err error
@@ -1163,7 +1718,7 @@ func RegisterShhService(stack *node.Node, cfg *whisper.Config) {
 
 // RegistergdaStatsService configures the gdachain Stats daemon and adds it to
 // th egiven node.
-func RegistergdaStatsService(stack *node.Node, url string) {
+func RegistergdaStatsService(stack *node.Node, cfg gdastats.Config) {
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		// Retrieve both gda and les services
 		var gdaServ *gda.gdachain
@@ -1172,12 +1727,72 @@ func RegistergdaStatsService(stack *node.Node, url string) {
 		var lesServ *les.Lightgdachain
 		ctx.Service(&lesServ)
 
-		return gdastats.New(url, gdaServ, lesServ)
+		return gdastats.New(cfg, gdaServ, lesServ)
 	}); err != nil {
 		Fatalf("Failed to register the gdachain Stats service: %v", err)
 	}
 }
 
+// RegisterFaucetService configures the testnet faucet and adds it to the given
+// node. The faucet requires a full node to send its transactions through.
+func RegisterFaucetService(stack *node.Node, cfg *faucet.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var gdaServ *gda.gdachain
+		if err := ctx.Service(&gdaServ); err != nil {
+			return nil, fmt.Errorf("faucet requires a full node: %v", err)
+		}
+		return faucet.New(cfg, gdaServ)
+	}); err != nil {
+		Fatalf("Failed to register the faucet service: %v", err)
+	}
+}
+
+// RegisterPlugins adds the services of all the plugins compiled into the binary
+// to the given node, configuring each from its section of the settings. Plugins
+// require a full node.
+func RegisterPlugins(stack *node.Node, settings map[string]map[string]interface{}) {
+	for name := range settings {
+		if plugins.Lookup(name) == nil {
+			Fatalf("Settings given for unknown plugin %s", name)
+		}
+	}
+	for _, name := range plugins.Names() {
+		plugin := plugins.Lookup(name)
+		if err := plugins.Configure(plugin, settings[name]); err != nil {
+			Fatalf("Failed to configure plugin: %v", err)
+		}
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			var gdaServ *gda.gdachain
+			if err := ctx.Service(&gdaServ); err != nil {
+				return nil, fmt.Errorf("plugin %s requires a full node: %v", plugin.Name(), err)
+			}
+			return plugin.New(&plugins.Host{Context: ctx, Backend: gdaServ.ApiBackend, Events: gdaServ.ChainEvents()})
+		}); err != nil {
+			Fatalf("Failed to register plugin %s: %v", name, err)
+		}
+	}
+}
+
+// SetTelemetryConfig applies telemetry related command line flags to the config.
+func SetTelemetryConfig(ctx *cli.Context, cfg *telemetry.Config) {
+	if ctx.GlobalIsSet(TelemetryEndpointFlag.Name) {
+		cfg.Endpoint = ctx.GlobalString(TelemetryEndpointFlag.Name)
+	}
+	if ctx.GlobalIsSet(TelemetryIntervalFlag.Name) {
+		cfg.Interval = ctx.GlobalDuration(TelemetryIntervalFlag.Name)
+	}
+}
+
+// RegisterTelemetryService configures the anonymous usage reporter and adds it
+// to the given node.
+func RegisterTelemetryService(stack *node.Node, cfg *telemetry.Config, version string, mode downloader.SyncMode) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return telemetry.New(cfg, version, mode.String(), ctx.ResolvePath("telemetry-id"))
+	}); err != nil {
+		Fatalf("Failed to register the telemetry service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
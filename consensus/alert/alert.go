@@ -0,0 +1,98 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package alert implements hooks notifying chain operators about consensus
+// faults observed by the node, such as invalid seals, signers equivocating on
+// a clique network or unusually deep chain reorganisations.
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+// Kind is the type of a consensus fault.
+type Kind string
+
+const (
+	InvalidSeal Kind = "invalid-seal" // A header with an invalid seal was received
+	DoubleSign  Kind = "double-sign"  // A clique signer sealed two blocks at the same height
+	DeepReorg   Kind = "deep-reorg"   // The canonical chain was reorganised deeper than allowed
+)
+
+// Alert describes a single consensus fault.
+type Alert struct {
+	Kind    Kind            `json:"kind"`
+	Number  uint64          `json:"number"`           // Number of the offending block (common ancestor for reorgs)
+	Hash    common.Hash     `json:"hash"`             // Hash of the offending block (common ancestor for reorgs)
+	Signer  *common.Address `json:"signer,omitempty"` // Author of the offending block, if it could be recovered
+	Message string          `json:"message"`
+	Time    time.Time       `json:"time"`
+}
+
+// Hook is notified about consensus faults. Implementations must not block, as
+// alerts are raised from within block verification.
+type Hook interface {
+	Alert(alert *Alert)
+}
+
+// raise logs the alert and forwards it to the hook.
+func raise(hook Hook, alert *Alert) {
+	alert.Time = time.Now()
+	log.Error("Consensus fault detected", "kind", alert.Kind, "number", alert.Number, "hash", alert.Hash, "msg", alert.Message)
+	if hook != nil {
+		hook.Alert(alert)
+	}
+}
+
+// reorgSource is the part of the blockchain announcing chain reorganisations.
+type reorgSource interface {
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
+}
+
+// WatchReorgs raises a DeepReorg alert for every chain reorganisation dropping
+// more than depth blocks from the canonical chain, until the returned
+// subscription is cancelled.
+func WatchReorgs(chain reorgSource, depth uint64, hook Hook) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		reorgs := make(chan core.ReorgEvent, 16)
+		sub := chain.SubscribeReorgEvent(reorgs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-reorgs:
+				if dropped := uint64(len(ev.OldChain)); dropped > depth {
+					raise(hook, &Alert{
+						Kind:    DeepReorg,
+						Number:  ev.CommonAncestor.NumberU64(),
+						Hash:    ev.CommonAncestor.Hash(),
+						Message: fmt.Sprintf("reorg dropped %d blocks, added %d", dropped, len(ev.NewChain)),
+					})
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package alert
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+)
+
+// hookFunc is a Hook calling a function.
+type hookFunc func(*Alert)
+
+func (f hookFunc) Alert(alert *Alert) { f(alert) }
+
+// Tests that a signer sealing two different blocks at the same height raises a
+// double sign alert, while reverifying the same block does not.
+func TestDoubleSign(t *testing.T) {
+	var alerts []*Alert
+	engine := Wrap(ethash.NewFaker(), hookFunc(func(alert *Alert) { alerts = append(alerts, alert) }))
+	engine.trackSigners = true // the fake engine attributes blocks to the coinbase
+
+	signer := common.HexToAddress("0x01")
+	first := &types.Header{Number: big.NewInt(10), Coinbase: signer, Extra: []byte("first")}
+	second := &types.Header{Number: big.NewInt(10), Coinbase: signer, Extra: []byte("second")}
+	other := &types.Header{Number: big.NewInt(10), Coinbase: common.HexToAddress("0x02")}
+
+	engine.inspect(nil, first, nil)
+	engine.inspect(nil, first, nil)
+	engine.inspect(nil, other, nil)
+	if len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+	engine.inspect(nil, second, nil)
+	if len(alerts) != 1 {
+		t.Fatalf("alert count mismatch: have %d, want 1", len(alerts))
+	}
+	if alerts[0].Kind != DoubleSign || alerts[0].Hash != second.Hash() || *alerts[0].Signer != signer {
+		t.Fatalf("alert mismatch: have %+v", alerts[0])
+	}
+}
+
+// testReorgFeed is a reorg source fed by the test.
+type testReorgFeed struct {
+	feed event.Feed
+}
+
+func (f *testReorgFeed) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return f.feed.Subscribe(ch)
+}
+
+// Tests that only reorgs deeper than the limit are reported, and that alerts
+// are delivered to the webhook.
+func TestDeepReorgWebhook(t *testing.T) {
+	delivered := make(chan *Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := new(Alert)
+		if err := json.NewDecoder(r.Body).Decode(alert); err != nil {
+			t.Errorf("invalid alert: %v", err)
+		}
+		delivered <- alert
+	}))
+	defer server.Close()
+
+	hook := NewWebhook(server.URL)
+	defer hook.Close()
+
+	var (
+		feed     = new(testReorgFeed)
+		sub      = WatchReorgs(feed, 2, hook)
+		ancestor = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(100)})
+	)
+	defer sub.Unsubscribe()
+
+	// Wait until the watcher subscribed to the feed
+	for feed.feed.Send(core.ReorgEvent{CommonAncestor: ancestor, OldChain: make(types.Blocks, 2)}) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	feed.feed.Send(core.ReorgEvent{CommonAncestor: ancestor, OldChain: make(types.Blocks, 3), NewChain: make(types.Blocks, 4)})
+
+	select {
+	case alert := <-delivered:
+		if alert.Kind != DeepReorg || alert.Hash != ancestor.Hash() || alert.Number != 100 {
+			t.Fatalf("alert mismatch: have %+v", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert not delivered")
+	}
+	select {
+	case alert := <-delivered:
+		t.Fatalf("unexpected alert delivered: %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
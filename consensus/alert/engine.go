@@ -0,0 +1,170 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package alert
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus"
+	"github.com/gdachain/go-gdachain/consensus/clique"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// signerHistory is the number of recent block heights for which the signers are
+// tracked to detect double signing.
+const signerHistory = 1024
+
+// Engine wraps a consensus engine, raising alerts for the faults observed while
+// verifying headers.
+type Engine struct {
+	consensus.Engine
+	hook Hook
+
+	trackSigners bool                                      // Whether the engine is signature based (clique)
+	signed       map[uint64]map[common.Address]common.Hash // Blocks sealed by each signer, per height
+	lock         sync.Mutex                                // Protects the signed map
+}
+
+// Wrap creates a consensus engine forwarding all operations to engine and
+// reporting consensus faults to hook.
+func Wrap(engine consensus.Engine, hook Hook) *Engine {
+	_, isClique := engine.(*clique.Clique)
+	return &Engine{
+		Engine:       engine,
+		hook:         hook,
+		trackSigners: isClique,
+		signed:       make(map[uint64]map[common.Address]common.Hash),
+	}
+}
+
+// VerifyHeader implements consensus.Engine, checking the header with the wrapped
+// engine and raising alerts for invalid seals and double signing.
+func (e *Engine) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	err := e.Engine.VerifyHeader(chain, header, seal)
+	e.inspect(chain, header, err)
+	return err
+}
+
+// VerifyHeaders implements consensus.Engine, checking the headers with the
+// wrapped engine and raising alerts for invalid seals and double signing.
+func (e *Engine) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	var (
+		abort          = make(chan struct{})
+		results        = make(chan error, len(headers))
+		inAbort, inRes = e.Engine.VerifyHeaders(chain, headers, seals)
+	)
+	go func() {
+		defer close(inAbort)
+		for _, header := range headers {
+			select {
+			case err := <-inRes:
+				e.inspect(chain, header, err)
+				results <- err
+			case <-abort:
+				return
+			}
+		}
+	}()
+	return abort, results
+}
+
+// VerifySeal implements consensus.Engine, checking the seal with the wrapped
+// engine and raising an alert if it's invalid.
+func (e *Engine) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	err := e.Engine.VerifySeal(chain, header)
+	if err != nil && !benign(err) {
+		e.raiseInvalidSeal(header, err)
+	}
+	return err
+}
+
+// inspect checks the outcome of a header verification. Failing headers are
+// checked for an invalid seal, valid ones are tracked to detect double signing.
+func (e *Engine) inspect(chain consensus.ChainReader, header *types.Header, err error) {
+	if err != nil {
+		if benign(err) {
+			return
+		}
+		// Only report the failure if it was the seal being invalid
+		if err := e.Engine.VerifySeal(chain, header); err != nil && !benign(err) {
+			e.raiseInvalidSeal(header, err)
+		}
+		return
+	}
+	if e.trackSigners {
+		e.trackSigner(header)
+	}
+}
+
+// trackSigner records the signer of a valid header, raising an alert if it has
+// sealed a different block at the same height before.
+func (e *Engine) trackSigner(header *types.Header) {
+	signer, err := e.Engine.Author(header)
+	if err != nil {
+		return
+	}
+	number, hash := header.Number.Uint64(), header.Hash()
+
+	e.lock.Lock()
+	signers := e.signed[number]
+	if signers == nil {
+		signers = make(map[common.Address]common.Hash)
+		e.signed[number] = signers
+	}
+	previous, seen := signers[signer]
+	signers[signer] = hash
+	if number > signerHistory {
+		delete(e.signed, number-signerHistory)
+	}
+	e.lock.Unlock()
+
+	if seen && previous != hash {
+		raise(e.hook, &Alert{
+			Kind:    DoubleSign,
+			Number:  number,
+			Hash:    hash,
+			Signer:  &signer,
+			Message: fmt.Sprintf("signer also sealed block %x", previous),
+		})
+	}
+}
+
+// raiseInvalidSeal reports a header with an invalid seal.
+func (e *Engine) raiseInvalidSeal(header *types.Header, err error) {
+	alert := &Alert{
+		Kind:    InvalidSeal,
+		Number:  header.Number.Uint64(),
+		Hash:    header.Hash(),
+		Message: err.Error(),
+	}
+	if signer, err := e.Engine.Author(header); err == nil {
+		alert.Signer = &signer
+	}
+	raise(e.hook, alert)
+}
+
+// benign reports whether a verification error is caused by the local chain
+// state rather than the header being faulty.
+func benign(err error) bool {
+	switch err {
+	case consensus.ErrUnknownAncestor, consensus.ErrPrunedAncestor, consensus.ErrFutureBlock:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gdachain/go-gdachain/log"
+)
+
+const (
+	webhookQueue   = 64               // Maximum number of alerts waiting for delivery
+	webhookTimeout = 10 * time.Second // Timeout of a single webhook request
+)
+
+// Webhook is a hook delivering alerts as JSON documents POSTed to an URL.
+// Alerts are sent in the background, dropping them if the endpoint can't keep up.
+type Webhook struct {
+	url    string
+	client *http.Client
+	queue  chan *Alert
+	quit   chan struct{}
+	done   chan struct{}
+}
+
+// NewWebhook creates a hook POSTing alerts to the given URL.
+func NewWebhook(url string) *Webhook {
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan *Alert, webhookQueue),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.loop()
+	return w
+}
+
+// Alert implements Hook, queueing the alert for delivery.
+func (w *Webhook) Alert(alert *Alert) {
+	select {
+	case w.queue <- alert:
+	default:
+		log.Warn("Alert webhook congested, dropping alert", "kind", alert.Kind, "hash", alert.Hash)
+	}
+}
+
+// Close stops delivering alerts. Queued alerts are discarded.
+func (w *Webhook) Close() {
+	close(w.quit)
+	<-w.done
+}
+
+// loop delivers the queued alerts one by one.
+func (w *Webhook) loop() {
+	defer close(w.done)
+	for {
+		select {
+		case alert := <-w.queue:
+			if err := w.post(alert); err != nil {
+				log.Warn("Failed to deliver alert", "kind", alert.Kind, "hash", alert.Hash, "err", err)
+			}
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// post sends a single alert to the webhook endpoint.
+func (w *Webhook) post(alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	res, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", res.Status)
+	}
+	return nil
+}
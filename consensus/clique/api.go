@@ -17,6 +17,8 @@
 package clique
 
 import (
+	"fmt"
+
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/consensus"
 	"github.com/gdachain/go-gdachain/core/types"
@@ -88,6 +90,46 @@ func (api *API) GetSignersAtHash(hash common.Hash) ([]common.Address, error) {
 	return snap.signers(), nil
 }
 
+// ExportSnapshot retrieves the voting snapshot at a given block in a form that
+// can be handed to ImportSnapshot on another node, sparing it from replaying
+// the entire header chain to derive the current signer set.
+func (api *API) ExportSnapshot(number *rpc.BlockNumber) (*Snapshot, error) {
+	return api.GetSnapshot(number)
+}
+
+// ImportSnapshot injects a voting snapshot exported from a trusted node. If the
+// block the snapshot was taken at is already known locally, it must be part of
+// the canonical chain.
+func (api *API) ImportSnapshot(snap *Snapshot) error {
+	if snap == nil {
+		return errInvalidSnapshot
+	}
+	if err := snap.validate(); err != nil {
+		return err
+	}
+	if header := api.chain.GetHeaderByNumber(snap.Number); header != nil && header.Hash() != snap.Hash {
+		return fmt.Errorf("snapshot hash mismatch at block %d: have %x, want %x", snap.Number, snap.Hash, header.Hash())
+	}
+	return api.clique.importSnapshot(snap)
+}
+
+// SignerStats retrieves the block production statistics of the signers over the
+// given number of recent blocks (default 256), to spot inactive sealers.
+func (api *API) SignerStats(blocks *uint64) (map[common.Address]*SignerStats, error) {
+	window := uint64(defaultStatsWindow)
+	if blocks != nil {
+		window = *blocks
+	}
+	if window > maxStatsWindow {
+		return nil, fmt.Errorf("window too large: %d > %d", window, maxStatsWindow)
+	}
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.clique.signerStats(api.chain, header, window)
+}
+
 // Proposals returns the current proposals the node tries to uphold and vote on.
 func (api *API) Proposals() map[common.Address]bool {
 	api.clique.lock.RLock()
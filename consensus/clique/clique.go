@@ -76,6 +76,10 @@ var (
 	// that is not part of the local blockchain.
 	errUnknownBlock = errors.New("unknown block")
 
+	// errInvalidSnapshot is returned if an imported voting snapshot is missing or
+	// internally inconsistent.
+	errInvalidSnapshot = errors.New("invalid snapshot")
+
 	// errInvalidCheckpointBeneficiary is returned if a checkpoint/epoch transition
 	// block has a beneficiary set to non-zeroes.
 	errInvalidCheckpointBeneficiary = errors.New("beneficiary in checkpoint block non-zero")
@@ -206,6 +210,8 @@ type Clique struct {
 	signer common.Address // gdachain address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
 	lock   sync.RWMutex   // Protects the signer fields
+
+	monitor *signerMonitor // Tracks the block production of the signers
 }
 
 // New creates a Clique proof-of-authority consensus engine with the initial
@@ -226,6 +232,7 @@ func New(config *params.CliqueConfig, db gdadb.Database) *Clique {
 		recents:    recents,
 		signatures: signatures,
 		proposals:  make(map[common.Address]bool),
+		monitor:    newSignerMonitor(),
 	}
 }
 
@@ -367,6 +374,26 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	return c.verifySeal(chain, header, parents)
 }
 
+// importSnapshot stores an externally provided voting snapshot both in memory
+// and on disk, making it usable as a starting point for further snapshots.
+//
+// Note, snapshots taken at non-checkpoint heights are only looked up in memory,
+// so they need to be reimported after a restart until the chain progresses past
+// the next checkpoint.
+func (c *Clique) importSnapshot(snap *Snapshot) error {
+	snap = snap.copy()
+	snap.config = c.config
+	snap.sigcache = c.signatures
+
+	if err := snap.store(c.db); err != nil {
+		return err
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	log.Info("Imported voting snapshot", "number", snap.Number, "hash", snap.Hash, "signers", len(snap.Signers))
+	return nil
+}
+
 // snapshot retrieves the authorization snapshot at a given point in time.
 func (c *Clique) snapshot(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
 	// Search for a snapshot in memory or on disk for checkpoints
@@ -499,6 +526,7 @@ func (c *Clique) verifySeal(chain consensus.ChainReader, header *types.Header, p
 	if !inturn && header.Difficulty.Cmp(diffNoTurn) != 0 {
 		return errInvalidDifficulty
 	}
+	c.monitor.observe(number, signer, snap)
 	return nil
 }
 
@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+const (
+	defaultStatsWindow = 256  // Number of recent blocks signer statistics are gathered over by default
+	maxStatsWindow     = 8192 // Maximum number of blocks signer statistics may be gathered over
+)
+
+// inactiveSignersGauge counts the authorized signers that haven't sealed a block
+// in twice the number of signers.
+var inactiveSignersGauge = metrics.NewRegisteredGauge("clique/signers/inactive", nil)
+
+// SignerStats summarises the recent block production of a signer.
+type SignerStats struct {
+	Signed     uint64 `json:"signed"`     // Number of blocks sealed within the window
+	LastSigned uint64 `json:"lastSigned"` // Number of the last block sealed, 0 if none within the window
+	LastTime   uint64 `json:"lastTime"`   // Timestamp of the last block sealed, 0 if none within the window
+	Missed     uint64 `json:"missed"`     // Number of in-turn slots sealed by another signer
+}
+
+// signerStats gathers the block production statistics of all signers over the
+// window of blocks ending at head.
+func (c *Clique) signerStats(chain consensus.ChainReader, head *types.Header, window uint64) (map[common.Address]*SignerStats, error) {
+	number := head.Number.Uint64()
+	if window > number {
+		window = number
+	}
+	// Collect the headers of the window, oldest first
+	headers, parent := make([]*types.Header, window), head
+	for i := len(headers) - 1; i >= 0; i-- {
+		headers[i] = parent
+		if parent = chain.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			return nil, errUnknownBlock
+		}
+	}
+	// Replay the window on top of the snapshot preceding it
+	snap, err := c.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[common.Address]*SignerStats)
+	get := func(signer common.Address) *SignerStats {
+		if stats[signer] == nil {
+			stats[signer] = new(SignerStats)
+		}
+		return stats[signer]
+	}
+	for _, header := range headers {
+		signer, err := ecrecover(header, c.signatures)
+		if err != nil {
+			return nil, err
+		}
+		s := get(signer)
+		s.Signed++
+		s.LastSigned, s.LastTime = header.Number.Uint64(), header.Time.Uint64()
+
+		if signers := snap.signers(); len(signers) > 0 {
+			if expected := signers[header.Number.Uint64()%uint64(len(signers))]; expected != signer {
+				get(expected).Missed++
+			}
+		}
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			return nil, err
+		}
+	}
+	// Report the current signers even if they didn't seal anything
+	for signer := range snap.Signers {
+		get(signer)
+	}
+	return stats, nil
+}
+
+// signerMonitor updates the signer metrics from the headers verified by the
+// engine, following the chain as it progresses.
+type signerMonitor struct {
+	start uint64                    // First block number observed
+	head  uint64                    // Highest block number observed
+	last  map[common.Address]uint64 // Number of the last block sealed by each signer
+	lock  sync.Mutex
+}
+
+func newSignerMonitor() *signerMonitor {
+	return &signerMonitor{last: make(map[common.Address]uint64)}
+}
+
+// observe records a block sealed by signer on top of the given snapshot. Blocks
+// not advancing the observed head (side chains, reverifications) are ignored.
+func (m *signerMonitor) observe(number uint64, signer common.Address, snap *Snapshot) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if number <= m.head {
+		return
+	}
+	if m.start == 0 {
+		m.start = number
+	}
+	m.head = number
+	m.last[signer] = number
+
+	prefix := fmt.Sprintf("clique/signers/%x/", signer)
+	metrics.GetOrRegisterCounter(prefix+"signed", nil).Inc(1)
+	metrics.GetOrRegisterGauge(prefix+"last", nil).Update(int64(number))
+
+	signers := snap.signers()
+	if expected := signers[number%uint64(len(signers))]; expected != signer {
+		metrics.GetOrRegisterCounter(fmt.Sprintf("clique/signers/%x/missed", expected), nil).Inc(1)
+	}
+	// Count the signers silent for two full rounds
+	inactive := 0
+	for _, signer := range signers {
+		last, ok := m.last[signer]
+		if !ok {
+			last = m.start
+		}
+		if number-last > uint64(2*len(signers)) {
+			inactive++
+		}
+	}
+	inactiveSignersGauge.Update(int64(inactive))
+}
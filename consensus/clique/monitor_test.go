@@ -0,0 +1,130 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// statsChainReader serves a fixed header chain on top of the tester genesis.
+type statsChainReader struct {
+	testerChainReader
+	headers []*types.Header
+}
+
+func (r *statsChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if number == 0 {
+		return r.GetHeaderByNumber(0)
+	}
+	return r.headers[number-1]
+}
+
+func (r *statsChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range r.headers {
+		if header != nil && header.Hash() == hash {
+			return header
+		}
+	}
+	if genesis := r.GetHeaderByNumber(0); genesis.Hash() == hash {
+		return genesis
+	}
+	return nil
+}
+
+func (r *statsChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number == 0 {
+		return r.testerChainReader.GetHeaderByNumber(0)
+	}
+	if number > uint64(len(r.headers)) {
+		return nil
+	}
+	return r.headers[number-1]
+}
+
+// Tests that signer statistics count the sealed blocks and attribute the out of
+// turn blocks as missed slots of the in-turn signers.
+func TestSignerStats(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	// Sort the signers the same way the snapshot does to know their turns
+	names := []string{"A", "B", "C"}
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			if bytes.Compare(accounts.address(names[i]).Bytes(), accounts.address(names[j]).Bytes()) > 0 {
+				names[i], names[j] = names[j], names[i]
+			}
+		}
+	}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+	}
+	for i, name := range names {
+		copy(genesis.ExtraData[extraVanity+i*common.AddressLength:], accounts.address(name).Bytes())
+	}
+	db, _ := gdadb.NewMemDatabase()
+	genesis.Commit(db)
+
+	// Blocks 1-3 are sealed in turn, blocks 4 and 5 out of turn
+	sealers := []string{names[1], names[2], names[0], names[2], names[1]}
+	headers := make([]*types.Header, len(sealers))
+	for i, sealer := range sealers {
+		headers[i] = &types.Header{
+			Number: big.NewInt(int64(i) + 1),
+			Time:   big.NewInt(int64(i+1) * 15),
+			Extra:  make([]byte, extraVanity+extraSeal),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		accounts.sign(headers[i], sealer)
+	}
+	chain := &statsChainReader{testerChainReader{db: db}, headers}
+	engine := New(&params.CliqueConfig{Epoch: 30000}, db)
+
+	stats, err := engine.signerStats(chain, headers[len(headers)-1], 10)
+	if err != nil {
+		t.Fatalf("failed to gather signer stats: %v", err)
+	}
+	want := map[string]SignerStats{
+		names[0]: {Signed: 1, LastSigned: 3, LastTime: 45, Missed: 0},
+		names[1]: {Signed: 2, LastSigned: 5, LastTime: 75, Missed: 1},
+		names[2]: {Signed: 2, LastSigned: 4, LastTime: 60, Missed: 1},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("signer count mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for name, want := range want {
+		if have := stats[accounts.address(name)]; have == nil || *have != want {
+			t.Errorf("signer %s: stats mismatch: have %+v, want %+v", name, have, want)
+		}
+	}
+	// A window of two blocks only covers the out of turn ones
+	if stats, err = engine.signerStats(chain, headers[len(headers)-1], 2); err != nil {
+		t.Fatalf("failed to gather windowed signer stats: %v", err)
+	}
+	if have := stats[accounts.address(names[0])]; have == nil || *have != (SignerStats{}) {
+		t.Errorf("idle signer stats mismatch: have %+v", have)
+	}
+}
@@ -19,6 +19,7 @@ package clique
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core/types"
@@ -100,6 +101,48 @@ func (s *Snapshot) store(db gdadb.Database) error {
 	return db.Put(append([]byte("clique-"), s.Hash[:]...), blob)
 }
 
+// validate checks the internal consistency of a snapshot received from an
+// external source: the signer set must be non-empty, recents and votes must
+// reference authorized signers within range, and the tally must match the votes.
+func (s *Snapshot) validate() error {
+	if len(s.Signers) == 0 {
+		return fmt.Errorf("%v: no signers", errInvalidSnapshot)
+	}
+	limit := uint64(len(s.Signers)/2 + 1)
+	for block, signer := range s.Recents {
+		if block > s.Number || s.Number-block >= limit {
+			return fmt.Errorf("%v: recent signer at block %d out of range", errInvalidSnapshot, block)
+		}
+		if _, ok := s.Signers[signer]; !ok {
+			return fmt.Errorf("%v: recent signer %x unauthorized", errInvalidSnapshot, signer)
+		}
+	}
+	tally := make(map[common.Address]Tally)
+	for _, vote := range s.Votes {
+		if vote == nil {
+			return fmt.Errorf("%v: nil vote", errInvalidSnapshot)
+		}
+		if _, ok := s.Signers[vote.Signer]; !ok {
+			return fmt.Errorf("%v: vote from unauthorized signer %x", errInvalidSnapshot, vote.Signer)
+		}
+		if vote.Block > s.Number {
+			return fmt.Errorf("%v: vote from future block %d", errInvalidSnapshot, vote.Block)
+		}
+		t := tally[vote.Address]
+		t.Authorize, t.Votes = vote.Authorize, t.Votes+1
+		tally[vote.Address] = t
+	}
+	if len(tally) != len(s.Tally) {
+		return fmt.Errorf("%v: tally mismatch", errInvalidSnapshot)
+	}
+	for address, t := range tally {
+		if s.Tally[address] != t {
+			return fmt.Errorf("%v: tally mismatch for %x", errInvalidSnapshot, address)
+		}
+	}
+	return nil
+}
+
 // copy creates a deep copy of the snapshot, though not the individual votes.
 func (s *Snapshot) copy() *Snapshot {
 	cpy := &Snapshot{
@@ -19,10 +19,13 @@ package clique
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"encoding/json"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
@@ -403,3 +406,83 @@ func TestVoting(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a voting snapshot exported from one node can be imported into
+// another and used to verify subsequent headers without the prior history.
+func TestSnapshotExportImport(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	names := []string{"A", "B", "C"}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(names)+extraSeal),
+	}
+	for i, name := range names {
+		copy(genesis.ExtraData[extraVanity+i*common.AddressLength:], accounts.address(name).Bytes())
+	}
+	db, _ := gdadb.NewMemDatabase()
+	genesis.Commit(db)
+
+	// Create a short chain with a pending vote to carry over in the snapshot
+	sealers := []string{"A", "B", "C", "A", "B"}
+	headers := make([]*types.Header, len(sealers))
+	for i, sealer := range sealers {
+		headers[i] = &types.Header{
+			Number: big.NewInt(int64(i) + 1),
+			Time:   big.NewInt(int64(i+1) * 15),
+			Extra:  make([]byte, extraVanity+extraSeal),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+		if i == 1 {
+			headers[i].Coinbase = accounts.address("D")
+			copy(headers[i].Nonce[:], nonceAuthVote)
+		}
+		accounts.sign(headers[i], sealer)
+	}
+	source := New(&params.CliqueConfig{Epoch: 30000}, db)
+	sourceChain := &statsChainReader{testerChainReader{db: db}, headers}
+
+	exported, err := (&API{chain: sourceChain, clique: source}).GetSnapshotAtHash(headers[2].Hash())
+	if err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	blob, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	// Import the snapshot into a node that only knows the headers following it
+	imported := new(Snapshot)
+	if err := json.Unmarshal(blob, imported); err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	fresh, _ := gdadb.NewMemDatabase()
+	genesis.Commit(fresh)
+
+	target := New(&params.CliqueConfig{Epoch: 30000}, fresh)
+	targetChain := &statsChainReader{testerChainReader{db: fresh}, append(make([]*types.Header, 3), headers[3:]...)}
+
+	if _, err := target.snapshot(targetChain, 5, headers[4].Hash(), nil); err != consensus.ErrUnknownAncestor {
+		t.Fatalf("snapshot without history: have %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+	api := &API{chain: targetChain, clique: target}
+	if err := api.ImportSnapshot(imported); err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	have, err := target.snapshot(targetChain, 5, headers[4].Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to derive snapshot from import: %v", err)
+	}
+	want, err := source.snapshot(sourceChain, 5, headers[4].Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to derive reference snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(have.Signers, want.Signers) || !reflect.DeepEqual(have.Recents, want.Recents) || !reflect.DeepEqual(have.Tally, want.Tally) {
+		t.Errorf("snapshot mismatch: have %+v, want %+v", have, want)
+	}
+	// Inconsistent snapshots must be rejected
+	imported.Tally = make(map[common.Address]Tally)
+	if err := api.ImportSnapshot(imported); err == nil {
+		t.Errorf("inconsistent tally accepted")
+	}
+}
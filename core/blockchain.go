@@ -69,6 +69,8 @@ type CacheConfig struct {
 	Disabled      bool          // Whgdaer to disable trie write caching (archive node)
 	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
 	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Journal       string        // Journal file persisting the in-memory trie across restarts (empty = disabled)
+	TdCacheLimit  int           // Number of block total difficulties to keep in memory (0 = default)
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -99,6 +101,7 @@ type BlockChain struct {
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -126,8 +129,13 @@ type BlockChain struct {
 	processor Processor // block processor interface
 	validator Validator // block and state validator interface
 	vmConfig  vm.Config
+	preimages int32        // Whether to record the SHA3 preimages seen by the VM (atomic)
+	profiles  atomic.Value // Execution profiles of the recently imported blocks (*vm.ProfileWindow)
 
 	badBlocks *lru.Cache // Bad block cache
+
+	sidecarProducers []SidecarProducer // Plugins generating extension data for imported blocks
+	sidecarMu        sync.RWMutex      // Lock protecting the sidecar producers
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -163,16 +171,23 @@ func NewBlockChain(db gdadb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
+	bc.SetPreimageRecording(vmConfig.EnablePreimageRecording)
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
 	if err != nil {
 		return nil, err
 	}
+	if cacheConfig.TdCacheLimit > 0 {
+		bc.hc.setTdCacheLimit(cacheConfig.TdCacheLimit)
+	}
 	bc.genesisBlock = bc.GetBlockByNumber(0)
 	if bc.genesisBlock == nil {
 		return nil, ErrNoGenesis
 	}
+	for _, bad := range GetBadBlocks(db) {
+		bc.badBlocks.Add(bad.Block.Hash(), bad)
+	}
 	if err := bc.loadLasgdaate(); err != nil {
 		return nil, err
 	}
@@ -189,6 +204,12 @@ func NewBlockChain(db gdadb.Database, cacheConfig *CacheConfig, chainConfig *par
 			}
 		}
 	}
+	// Warm up the trie cache from the journal of the previous run, if any
+	if !cacheConfig.Disabled && cacheConfig.Journal != "" {
+		if err := bc.loadTrieJournal(); err != nil {
+			log.Warn("Failed to load trie cache journal", "err", err)
+		}
+	}
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -257,6 +278,20 @@ func (bc *BlockChain) loadLasgdaate() error {
 	return nil
 }
 
+// SetCheckpoints sets the canonical hashes enforced at certain heights during
+// block and header import. If the local chain already contradicts any of them,
+// it is rewound to below the offending block.
+func (bc *BlockChain) SetCheckpoints(checkpoints params.Checkpoints) {
+	bc.hc.SetCheckpoints(checkpoints)
+
+	for number, hash := range checkpoints {
+		if header := bc.GetHeaderByNumber(number); number > 0 && header != nil && header.Hash() != hash {
+			log.Error("Local chain contradicts checkpoint, rewinding", "number", number, "have", header.Hash(), "want", hash)
+			bc.SetHead(number - 1)
+		}
+	}
+}
+
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
@@ -270,6 +305,7 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.db, hash, num)
+		DeleteSidecars(bc.db, bc.db, hash, num)
 	}
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
@@ -371,12 +407,51 @@ func (bc *BlockChain) Validator() Validator {
 }
 
 // Processor returns the current processor.
+// SetPreimageRecording toggles the recording of the SHA3 preimages seen by the VM
+// while processing blocks.
+func (bc *BlockChain) SetPreimageRecording(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&bc.preimages, 1)
+	} else {
+		atomic.StoreInt32(&bc.preimages, 0)
+	}
+}
+
+// PreimageRecording returns whether the SHA3 preimages seen by the VM are being
+// recorded while processing blocks.
+func (bc *BlockChain) PreimageRecording() bool {
+	return atomic.LoadInt32(&bc.preimages) == 1
+}
+
+// SetVMProfiling enables the profiling of the opcodes executed while importing
+// blocks, retaining the profiles of the given number of most recent blocks. A
+// zero window disables profiling.
+func (bc *BlockChain) SetVMProfiling(blocks int) {
+	var profiles *vm.ProfileWindow
+	if blocks > 0 {
+		profiles = vm.NewProfileWindow(blocks)
+	}
+	bc.profiles.Store(profiles)
+}
+
+// VMProfiles returns the execution profiles of the recently imported blocks, or
+// nil if profiling is disabled.
+func (bc *BlockChain) VMProfiles() *vm.ProfileWindow {
+	profiles, _ := bc.profiles.Load().(*vm.ProfileWindow)
+	return profiles
+}
+
 func (bc *BlockChain) Processor() Processor {
 	bc.procmu.RLock()
 	defer bc.procmu.RUnlock()
 	return bc.processor
 }
 
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
 // State returns a new mutable state based on the current HEAD block.
 func (bc *BlockChain) State() (*state.StateDB, error) {
 	return bc.StateAt(bc.CurrentBlock().Root())
@@ -650,6 +725,12 @@ func (bc *BlockChain) Stop() {
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 
+		// Journal the trie cache before committing, as commits evict the nodes
+		if bc.cacheConfig.Journal != "" {
+			if err := bc.saveTrieJournal(); err != nil {
+				log.Error("Failed to journal trie cache", "err", err)
+			}
+		}
 		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
 				recent := bc.GetBlockByNumber(number - offset)
@@ -855,12 +936,17 @@ func (bc *BlockChain) WriteBlockWithougdaate(block *types.Block, td *big.Int) (e
 	bc.wg.Add(1)
 	defer bc.wg.Done()
 
-	if err := bc.hc.WriteTd(block.Hash(), block.NumberU64(), td); err != nil {
+	batch := bc.db.NewBatch()
+	if err := WriteTd(batch, block.Hash(), block.NumberU64(), td); err != nil {
 		return err
 	}
-	if err := WriteBlock(bc.db, block); err != nil {
+	if err := WriteBlock(batch, block); err != nil {
 		return err
 	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	bc.hc.cacheTd(block.Hash(), td)
 	return nil
 }
 
@@ -882,12 +968,12 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	localTd := bc.GetTd(currentBlock.Hash(), currentBlock.NumberU64())
 	externTd := new(big.Int).Add(block.Difficulty(), ptd)
 
-	// Irrelevant of the canonical status, write the block itself to the database
-	if err := bc.hc.WriteTd(block.Hash(), block.NumberU64(), externTd); err != nil {
+	// Irrelevant of the canonical status, write the block itself to the database,
+	// batching its total difficulty along with all the other block data.
+	batch := bc.db.NewBatch()
+	if err := WriteTd(batch, block.Hash(), block.NumberU64(), externTd); err != nil {
 		return NonStatTy, err
 	}
-	// Write other block data using a batch.
-	batch := bc.db.NewBatch()
 	if err := WriteBlock(batch, block); err != nil {
 		return NonStatTy, err
 	}
@@ -950,6 +1036,12 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
 		return NonStatTy, err
 	}
+	// Store any extension data the registered plugins derive from the block
+	if sidecars := bc.produceSidecars(block, receipts); len(sidecars) > 0 {
+		if err := WriteSidecars(batch, block.Hash(), block.NumberU64(), sidecars); err != nil {
+			return NonStatTy, err
+		}
+	}
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -981,6 +1073,7 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	if err := batch.Write(); err != nil {
 		return NonStatTy, err
 	}
+	bc.hc.cacheTd(block.Hash(), externTd)
 
 	// Set new head.
 	if status == CanonStatTy {
@@ -1056,6 +1149,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			bc.reportBlock(block, nil, ErrBlacklistedHash)
 			return i, events, coalescedLogs, ErrBlacklistedHash
 		}
+		// If the block contradicts a checkpoint, the whole chain is bogus
+		if bc.hc.checkpoints.Conflicts(block.NumberU64(), block.Hash()) {
+			bc.reportBlock(block, nil, ErrCheckpointMismatch)
+			return i, events, coalescedLogs, ErrCheckpointMismatch
+		}
 		// Wait for the block's verification to complete
 		bstart := time.Now()
 
@@ -1138,7 +1236,14 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			return i, events, coalescedLogs, err
 		}
 		// Process block using the parent state as reference point.
-		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
+		vmConfig := bc.vmConfig
+		vmConfig.EnablePreimageRecording = bc.PreimageRecording()
+
+		profiles := bc.VMProfiles()
+		if profiles != nil {
+			vmConfig.Profile = vm.NewProfile()
+		}
+		receipts, logs, usedGas, err := bc.processor.Process(block, state, vmConfig)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			return i, events, coalescedLogs, err
@@ -1150,7 +1255,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			return i, events, coalescedLogs, err
 		}
 		proctime := time.Since(bstart)
-
+		if profiles != nil {
+			profiles.Add(block.NumberU64(), proctime, vmConfig.Profile)
+		}
 		// Write the block to the chain and get the status.
 		status, err := bc.WriteBlockWithState(block, receipts, state)
 		if err != nil {
@@ -1336,6 +1443,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	if len(deletedLogs) > 0 {
 		go bc.rmLogsFeed.Send(RemovedLogsEvent{deletedLogs})
 	}
+	if len(oldChain) > 0 && len(newChain) > 0 {
+		go bc.reorgFeed.Send(ReorgEvent{CommonAncestor: commonBlock, OldChain: oldChain, NewChain: newChain})
+	}
 	if len(oldChain) > 0 {
 		go func() {
 			for _, block := range oldChain {
@@ -1386,28 +1496,57 @@ func (bc *BlockChain) update() {
 type BadBlockArgs struct {
 	Hash   common.Hash   `json:"hash"`
 	Header *types.Header `json:"header"`
+	Error  string        `json:"error"`
+	Peer   string        `json:"peer,omitempty"`
+	Time   uint64        `json:"time"`
 }
 
 // BadBlocks returns a list of the last 'bad blocks' that the client has seen on the network
 func (bc *BlockChain) BadBlocks() ([]BadBlockArgs, error) {
 	headers := make([]BadBlockArgs, 0, bc.badBlocks.Len())
 	for _, hash := range bc.badBlocks.Keys() {
-		if hdr, exist := bc.badBlocks.Peek(hash); exist {
-			header := hdr.(*types.Header)
-			headers = append(headers, BadBlockArgs{header.Hash(), header})
+		if entry, exist := bc.badBlocks.Peek(hash); exist {
+			bad := entry.(*BadBlock)
+			headers = append(headers, BadBlockArgs{
+				Hash:   bad.Block.Hash(),
+				Header: bad.Block.Header(),
+				Error:  bad.Error,
+				Peer:   bad.Peer,
+				Time:   bad.Time,
+			})
 		}
 	}
 	return headers, nil
 }
 
-// addBadBlock adds a bad block to the bad-block LRU cache
-func (bc *BlockChain) addBadBlock(block *types.Block) {
-	bc.badBlocks.Add(block.Header().Hash(), block.Header())
+// BadBlock retrieves a recently rejected block by hash, or nil if it's unknown.
+func (bc *BlockChain) BadBlock(hash common.Hash) *BadBlock {
+	if entry, exist := bc.badBlocks.Peek(hash); exist {
+		return entry.(*BadBlock)
+	}
+	return GetBadBlock(bc.db, hash)
+}
+
+// addBadBlock adds a bad block to the bad-block LRU cache and persists it, so
+// it can be analysed after a restart.
+func (bc *BlockChain) addBadBlock(block *types.Block, err error) {
+	bad := &BadBlock{
+		Block: block,
+		Error: err.Error(),
+		Time:  uint64(time.Now().Unix()),
+	}
+	if block.ReceivedFrom != nil {
+		bad.Peer = fmt.Sprint(block.ReceivedFrom)
+	}
+	bc.badBlocks.Add(block.Hash(), bad)
+	if err := WriteBadBlock(bc.db, bad); err != nil {
+		log.Warn("Failed to store bad block", "hash", block.Hash(), "err", err)
+	}
 }
 
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
-	bc.addBadBlock(block)
+	bc.addBadBlock(block, err)
 
 	var receipgdaring string
 	for _, receipt := range receipts {
@@ -1537,6 +1676,11 @@ func (bc *BlockChain) SubscribeRemovedLogsEvent(ch chan<- RemovedLogsEvent) even
 	return bc.scope.Track(bc.rmLogsFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeChainEvent registers a subscription of ChainEvent.
 func (bc *BlockChain) SubscribeChainEvent(ch chan<- ChainEvent) event.Subscription {
 	return bc.scope.Track(bc.chainFeed.Subscribe(ch))
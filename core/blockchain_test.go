@@ -978,6 +978,48 @@ done:
 
 }
 
+// Tests that a reorg event is fired with the correct common ancestor and chain
+// segments when the canonical chain is reorganised.
+func TestReorgEvent(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	chain, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Fork off after the first block with a heavier (more blocks) side chain
+	forks, _ := GenerateChain(gspec.Config, chain[0], ethash.NewFaker(), db, 4, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	reorgCh := make(chan ReorgEvent, 4)
+	sub := blockchain.SubscribeReorgEvent(reorgCh)
+	defer sub.Unsubscribe()
+
+	if _, err := blockchain.InsertChain(forks); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	select {
+	case ev := <-reorgCh:
+		if ev.CommonAncestor.Hash() != chain[0].Hash() {
+			t.Errorf("common ancestor mismatch: have %x, want %x", ev.CommonAncestor.Hash(), chain[0].Hash())
+		}
+		if len(ev.OldChain) != 2 || ev.OldChain[0].Hash() != chain[2].Hash() || ev.OldChain[1].Hash() != chain[1].Hash() {
+			t.Errorf("old chain mismatch: have %d blocks", len(ev.OldChain))
+		}
+		if len(ev.NewChain) == 0 || ev.NewChain[len(ev.NewChain)-1].Hash() != forks[0].Hash() {
+			t.Errorf("new chain mismatch: have %d blocks", len(ev.NewChain))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("reorg event timeout")
+	}
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	_, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
@@ -1338,3 +1380,31 @@ func TestLargeReorgTrieGC(t *testing.T) {
 		}
 	}
 }
+
+// Tests that total difficulties are persisted along with their blocks and that
+// the cached values cannot be corrupted by callers modifying the returned ones.
+func TestTdCacheIsolation(t *testing.T) {
+	_, blockchain, err := newCanonical(ethash.NewFaker(), 0, true)
+	if err != nil {
+		t.Fatalf("failed to create pristine chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	blocks := makeBlockChain(blockchain.CurrentBlock(), 8, ethash.NewFaker(), blockchain.db, 0)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := blockchain.CurrentBlock()
+	want := GetTd(blockchain.db, head.Hash(), head.NumberU64())
+	if want == nil {
+		t.Fatalf("head total difficulty not persisted")
+	}
+	td := blockchain.GetTdByHash(head.Hash())
+	if td.Cmp(want) != 0 {
+		t.Fatalf("total difficulty mismatch: have %v, want %v", td, want)
+	}
+	td.Add(td, big.NewInt(1))
+	if td := blockchain.GetTd(head.Hash(), head.NumberU64()); td.Cmp(want) != 0 {
+		t.Fatalf("cached total difficulty corrupted: have %v, want %v", td, want)
+	}
+}
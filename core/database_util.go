@@ -23,6 +23,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
+	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core/types"
@@ -31,6 +33,7 @@ import (
 	"github.com/gdachain/go-gdachain/metrics"
 	"github.com/gdachain/go-gdachain/params"
 	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 // DatabaseReader wraps the Get method of a backing data store.
@@ -49,6 +52,10 @@ var (
 	headFastKey   = []byte("LastFast")
 	trieSyncKey   = []byte("TrieSync")
 
+	uncleanShutdownKey = []byte("unclean-shutdown") // RLP list of the sessions not shut down cleanly
+	badBlockKey        = []byte("InvalidBlock")     // RLP list of the most recent blocks failing validation
+	healthProbeKey     = []byte("LastHealthProbe")  // Empty value rewritten by the health check to probe database writes
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
 	headerPrefix        = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	tdSuffix            = []byte("t") // headerPrefix + num (uint64 big endian) + hash + tdSuffix -> td
@@ -58,12 +65,14 @@ var (
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 	lookupPrefix        = []byte("l") // lookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix     = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	sidecarPrefix       = []byte("x") // sidecarPrefix + num (uint64 big endian) + hash (+ name) -> sidecar names (sidecar data)
 
 	preimagePrefix = "secure-key-"              // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("gdaereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	customIndexPrefix    = []byte("iX") // customIndexPrefix + name + "-" + (d|m) -> data and progress of a registered index
 
 	// used by old db, now only used for conversion
 	oldReceiptsPrefix = []byte("receipts-")
@@ -262,6 +271,33 @@ func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.
 	return receipts
 }
 
+// sidecarKey = sidecarPrefix + num (uint64 big endian) + hash + name
+func sidecarKey(hash common.Hash, number uint64, name string) []byte {
+	return append(append(append(sidecarPrefix, encodeBlockNumber(number)...), hash.Bytes()...), name...)
+}
+
+// GetSidecarNames retrieves the names of all the sidecars stored alongside the
+// block with the given hash.
+func GetSidecarNames(db DatabaseReader, hash common.Hash, number uint64) []string {
+	data, _ := db.Get(sidecarKey(hash, number, ""))
+	if len(data) == 0 {
+		return nil
+	}
+	var names []string
+	if err := rlp.DecodeBytes(data, &names); err != nil {
+		log.Error("Invalid sidecar name list RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return names
+}
+
+// GetSidecar retrieves the sidecar data with the given name, stored alongside
+// the block with the given hash.
+func GetSidecar(db DatabaseReader, hash common.Hash, number uint64, name string) []byte {
+	data, _ := db.Get(sidecarKey(hash, number, name))
+	return data
+}
+
 // GetTxLookupEntry retrieves the positional metadata associated with a transaction
 // hash to allow retrieving the transaction or receipt by hash.
 func GetTxLookupEntry(db DatabaseReader, hash common.Hash) (common.Hash, uint64, uint64) {
@@ -479,6 +515,28 @@ func WriteBlockReceipts(db gdadb.Putter, hash common.Hash, number uint64, receip
 	return nil
 }
 
+// WriteSidecars stores a set of named sidecars alongside the block with the given
+// hash, along with the list of their names to allow enumerating and pruning them.
+func WriteSidecars(db gdadb.Putter, hash common.Hash, number uint64, sidecars map[string][]byte) error {
+	names := make([]string, 0, len(sidecars))
+	for name, data := range sidecars {
+		if name == "" {
+			return errors.New("empty sidecar name")
+		}
+		if err := db.Put(sidecarKey(hash, number, name), data); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data, err := rlp.EncodeToBytes(names)
+	if err != nil {
+		return err
+	}
+	return db.Put(sidecarKey(hash, number, ""), data)
+}
+
 // WriteTxLookupEntries stores a positional metadata for every transaction from
 // a block, enabling hash based transaction and receipt lookups.
 func WriteTxLookupEntries(db gdadb.Putter, block *types.Block) error {
@@ -534,7 +592,9 @@ func DeleteTd(db DatabaseDeleter, hash common.Hash, number uint64) {
 	db.Delete(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...), tdSuffix...))
 }
 
-// DeleteBlock removes all block data associated with a hash.
+// DeleteBlock removes all block data associated with a hash. Sidecars need to
+// be enumerated before they can be removed, so they're removed separately with
+// DeleteSidecars.
 func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteBlockReceipts(db, hash, number)
 	DeleteHeader(db, hash, number)
@@ -542,6 +602,15 @@ func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteTd(db, hash, number)
 }
 
+// DeleteSidecars removes all the sidecars stored alongside a block, enumerating
+// them through the given reader.
+func DeleteSidecars(reader DatabaseReader, db DatabaseDeleter, hash common.Hash, number uint64) {
+	for _, name := range GetSidecarNames(reader, hash, number) {
+		db.Delete(sidecarKey(hash, number, name))
+	}
+	db.Delete(sidecarKey(hash, number, ""))
+}
+
 // DeleteBlockReceipts removes all receipt data associated with a block hash.
 func DeleteBlockReceipts(db DatabaseDeleter, hash common.Hash, number uint64) {
 	db.Delete(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
@@ -579,6 +648,41 @@ func WritePreimages(db gdadb.Database, number uint64, preimages map[common.Hash]
 	return nil
 }
 
+// IteratePreimages calls fn for all the preimages stored in the database, until
+// fn returns an error. Only LevelDB and in-memory databases can be iterated.
+func IteratePreimages(db gdadb.Database, fn func(hash common.Hash, preimage []byte) error) error {
+	prefix := []byte(preimagePrefix)
+
+	switch db := db.(type) {
+	case *gdadb.LDBDatabase:
+		it := db.LDB().NewIterator(util.BytesPrefix(prefix), nil)
+		defer it.Release()
+
+		for it.Next() {
+			if key := it.Key(); len(key) == len(prefix)+common.HashLength {
+				if err := fn(common.BytesToHash(key[len(prefix):]), common.CopyBytes(it.Value())); err != nil {
+					return err
+				}
+			}
+		}
+		return it.Error()
+
+	case *gdadb.MemDatabase:
+		for _, key := range db.Keys() {
+			if len(key) == len(prefix)+common.HashLength && bytes.HasPrefix(key, prefix) {
+				preimage, _ := db.Get(key)
+				if err := fn(common.BytesToHash(key[len(prefix):]), preimage); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("preimage iteration not supported by %T", db)
+	}
+}
+
 // GetBlockChainVersion reads the version number from db.
 func GetBlockChainVersion(db DatabaseReader) int {
 	var vsn uint
@@ -624,6 +728,127 @@ func GetChainConfig(db DatabaseReader, hash common.Hash) (*params.ChainConfig, e
 	return &config, nil
 }
 
+// WriteHealthProbe overwrites the health probe entry, checking that the database
+// still accepts writes.
+func WriteHealthProbe(db gdadb.Putter) error {
+	return db.Put(healthProbeKey, []byte{})
+}
+
+// maxUncleanShutdownMarkers is the number of unclean shutdown markers retained.
+const maxUncleanShutdownMarkers = 10
+
+// GetUncleanShutdownMarkers retrieves the markers of the sessions that didn't shut
+// down cleanly, oldest first, each being the last time the session was known to
+// be alive. The last marker belongs to the running session if it pushed one.
+func GetUncleanShutdownMarkers(db DatabaseReader) []uint64 {
+	var markers []uint64
+	if enc, _ := db.Get(uncleanShutdownKey); len(enc) > 0 {
+		if err := rlp.DecodeBytes(enc, &markers); err != nil {
+			log.Error("Invalid unclean shutdown markers", "err", err)
+			return nil
+		}
+	}
+	return markers
+}
+
+// writeUncleanShutdownMarkers stores the unclean shutdown markers.
+func writeUncleanShutdownMarkers(db gdadb.Putter, markers []uint64) error {
+	enc, err := rlp.EncodeToBytes(markers)
+	if err != nil {
+		return err
+	}
+	return db.Put(uncleanShutdownKey, enc)
+}
+
+// PushUncleanShutdownMarker records the startup of a session, which is removed
+// again on clean shutdown, and returns the markers left by previous sessions.
+func PushUncleanShutdownMarker(db gdadb.Database) ([]uint64, error) {
+	previous := GetUncleanShutdownMarkers(db)
+
+	markers := append(previous, uint64(time.Now().Unix()))
+	if len(markers) > maxUncleanShutdownMarkers {
+		markers = markers[len(markers)-maxUncleanShutdownMarkers:]
+	}
+	return previous, writeUncleanShutdownMarkers(db, markers)
+}
+
+// UpdateUncleanShutdownMarker refreshes the marker of the running session, so
+// the time of a crash can be told apart from the time of its startup.
+func UpdateUncleanShutdownMarker(db gdadb.Database) error {
+	markers := GetUncleanShutdownMarkers(db)
+	if len(markers) == 0 {
+		return errors.New("no unclean shutdown marker")
+	}
+	markers[len(markers)-1] = uint64(time.Now().Unix())
+	return writeUncleanShutdownMarkers(db, markers)
+}
+
+// PopUncleanShutdownMarker removes the marker of the running session on clean
+// shutdown.
+func PopUncleanShutdownMarker(db gdadb.Database) error {
+	markers := GetUncleanShutdownMarkers(db)
+	if len(markers) == 0 {
+		return errors.New("no unclean shutdown marker")
+	}
+	return writeUncleanShutdownMarkers(db, markers[:len(markers)-1])
+}
+
+// maxBadBlocks is the number of bad blocks retained in the database.
+const maxBadBlocks = 10
+
+// BadBlock is a block that failed validation, along with the reason of the
+// failure and the peer it was received from.
+type BadBlock struct {
+	Block *types.Block
+	Error string
+	Peer  string // Origin of the block, empty if unknown or local
+	Time  uint64 // Unix time the block was rejected at
+}
+
+// GetBadBlocks retrieves the bad blocks stored in the database, oldest first.
+func GetBadBlocks(db DatabaseReader) []*BadBlock {
+	var blocks []*BadBlock
+	if enc, _ := db.Get(badBlockKey); len(enc) > 0 {
+		if err := rlp.DecodeBytes(enc, &blocks); err != nil {
+			log.Error("Invalid bad block list", "err", err)
+			return nil
+		}
+	}
+	return blocks
+}
+
+// GetBadBlock retrieves the bad block with the given hash from the database,
+// or nil if it's not stored.
+func GetBadBlock(db DatabaseReader, hash common.Hash) *BadBlock {
+	for _, bad := range GetBadBlocks(db) {
+		if bad.Block.Hash() == hash {
+			return bad
+		}
+	}
+	return nil
+}
+
+// WriteBadBlock stores a bad block, replacing an earlier entry of the same block
+// and dropping the oldest ones beyond the retention limit.
+func WriteBadBlock(db gdadb.Database, bad *BadBlock) error {
+	blocks := GetBadBlocks(db)
+	for i, old := range blocks {
+		if old.Block.Hash() == bad.Block.Hash() {
+			blocks = append(blocks[:i], blocks[i+1:]...)
+			break
+		}
+	}
+	blocks = append(blocks, bad)
+	if len(blocks) > maxBadBlocks {
+		blocks = blocks[len(blocks)-maxBadBlocks:]
+	}
+	enc, err := rlp.EncodeToBytes(blocks)
+	if err != nil {
+		return err
+	}
+	return db.Put(badBlockKey, enc)
+}
+
 // FindCommonAncestor returns the last common ancestor of two block headers
 func FindCommonAncestor(db DatabaseReader, a, b *types.Header) *types.Header {
 	for bn := b.Number.Uint64(); a.Number.Uint64() > bn; {
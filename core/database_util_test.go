@@ -19,10 +19,12 @@ package core
 import (
 	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/crypto/sha3"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/rlp"
@@ -386,3 +388,141 @@ func TestBlockReceipgdaorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests that block sidecars can be stored, enumerated and pruned.
+func TestSidecarStorage(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+
+	hash, number := common.Hash{0: 0xff}, uint64(314)
+	if names := GetSidecarNames(db, hash, number); len(names) != 0 {
+		t.Fatalf("non existent sidecars returned: %v", names)
+	}
+	sidecars := map[string][]byte{
+		"da":     {0x01, 0x02},
+		"commit": {0x03},
+	}
+	if err := WriteSidecars(db, hash, number, sidecars); err != nil {
+		t.Fatalf("failed to write sidecars into database: %v", err)
+	}
+	if names := GetSidecarNames(db, hash, number); len(names) != 2 || names[0] != "commit" || names[1] != "da" {
+		t.Fatalf("sidecar names mismatch: have %v, want [commit da]", names)
+	}
+	for name, data := range sidecars {
+		if entry := GetSidecar(db, hash, number, name); !bytes.Equal(entry, data) {
+			t.Fatalf("sidecar %q mismatch: have %x, want %x", name, entry, data)
+		}
+	}
+	DeleteSidecars(db, db, hash, number)
+	if names := GetSidecarNames(db, hash, number); len(names) != 0 {
+		t.Fatalf("deleted sidecar names returned: %v", names)
+	}
+	if entry := GetSidecar(db, hash, number, "da"); entry != nil {
+		t.Fatalf("deleted sidecar returned: %x", entry)
+	}
+}
+
+// Tests that unclean shutdown markers are retained for the sessions that never
+// popped theirs, up to the retention limit.
+func TestUncleanShutdownMarkers(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+
+	// A clean session leaves no markers behind
+	if previous, err := PushUncleanShutdownMarker(db); err != nil || len(previous) != 0 {
+		t.Fatalf("first push mismatch: have %v, %v, want none", previous, err)
+	}
+	if err := PopUncleanShutdownMarker(db); err != nil {
+		t.Fatalf("failed to pop marker: %v", err)
+	}
+	if markers := GetUncleanShutdownMarkers(db); len(markers) != 0 {
+		t.Fatalf("clean shutdown left markers: %v", markers)
+	}
+	// Crashed sessions are reported to the next ones
+	for i := 0; i < maxUncleanShutdownMarkers+5; i++ {
+		previous, err := PushUncleanShutdownMarker(db)
+		if err != nil {
+			t.Fatalf("push %d: failed to push marker: %v", i, err)
+		}
+		want := i
+		if want > maxUncleanShutdownMarkers {
+			want = maxUncleanShutdownMarkers
+		}
+		if len(previous) != want {
+			t.Fatalf("push %d: previous markers mismatch: have %d, want %d", i, len(previous), want)
+		}
+	}
+	if err := UpdateUncleanShutdownMarker(db); err != nil {
+		t.Fatalf("failed to update marker: %v", err)
+	}
+	if err := PopUncleanShutdownMarker(db); err != nil {
+		t.Fatalf("failed to pop marker: %v", err)
+	}
+	if markers := GetUncleanShutdownMarkers(db); len(markers) != maxUncleanShutdownMarkers-1 {
+		t.Fatalf("retained markers mismatch: have %d, want %d", len(markers), maxUncleanShutdownMarkers-1)
+	}
+}
+
+// Tests that all the stored preimages, and only those, are iterated over.
+func TestIteratePreimages(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+
+	preimages := map[common.Hash][]byte{
+		crypto.Keccak256Hash([]byte{1}): {1},
+		crypto.Keccak256Hash([]byte{2}): {2},
+	}
+	if err := WritePreimages(db, 0, preimages); err != nil {
+		t.Fatalf("failed to write preimages: %v", err)
+	}
+	WriteHeadBlockHash(db, common.Hash{0x01})
+
+	found := make(map[common.Hash][]byte)
+	err := IteratePreimages(db, func(hash common.Hash, preimage []byte) error {
+		found[hash] = preimage
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate preimages: %v", err)
+	}
+	if !reflect.DeepEqual(found, preimages) {
+		t.Fatalf("preimages mismatch: have %x, want %x", found, preimages)
+	}
+}
+
+// Tests that bad blocks are stored without duplicates, up to the retention limit.
+func TestBadBlockStorage(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+
+	newBad := func(number int64) *BadBlock {
+		header := &types.Header{Number: big.NewInt(number), Extra: []byte("bad block")}
+		return &BadBlock{Block: types.NewBlockWithHeader(header), Error: "invalid", Peer: "peer"}
+	}
+	first := newBad(1)
+	if err := WriteBadBlock(db, first); err != nil {
+		t.Fatalf("failed to write bad block: %v", err)
+	}
+	if bad := GetBadBlock(db, first.Block.Hash()); bad == nil || bad.Error != "invalid" || bad.Peer != "peer" {
+		t.Fatalf("stored bad block mismatch: have %+v", bad)
+	}
+	// Rewriting the same block must not duplicate it
+	if err := WriteBadBlock(db, first); err != nil {
+		t.Fatalf("failed to rewrite bad block: %v", err)
+	}
+	if blocks := GetBadBlocks(db); len(blocks) != 1 {
+		t.Fatalf("bad block count mismatch: have %d, want 1", len(blocks))
+	}
+	// Old blocks are dropped beyond the retention limit
+	for i := 0; i < maxBadBlocks; i++ {
+		if err := WriteBadBlock(db, newBad(int64(i+2))); err != nil {
+			t.Fatalf("failed to write bad block %d: %v", i, err)
+		}
+	}
+	blocks := GetBadBlocks(db)
+	if len(blocks) != maxBadBlocks {
+		t.Fatalf("bad block count mismatch: have %d, want %d", len(blocks), maxBadBlocks)
+	}
+	if GetBadBlock(db, first.Block.Hash()) != nil {
+		t.Fatalf("oldest bad block not dropped")
+	}
+	if number := blocks[len(blocks)-1].Block.NumberU64(); number != maxBadBlocks+1 {
+		t.Fatalf("newest bad block mismatch: have #%d, want #%d", number, maxBadBlocks+1)
+	}
+}
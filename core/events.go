@@ -17,6 +17,8 @@
 package core
 
 import (
+	"time"
+
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core/types"
 )
@@ -24,9 +26,32 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDropReason describes why a transaction left the transaction pool without
+// being included in a block.
+type TxDropReason string
+
+const (
+	TxDropReplaced    TxDropReason = "replaced"    // Replaced by a better priced transaction with the same nonce
+	TxDropUnderpriced TxDropReason = "underpriced" // Evicted by better priced transactions or a raised price floor
+	TxDropUnpayable   TxDropReason = "unpayable"   // Balance too low for the cost, or gas above the block limit
+	TxDropNonceTooLow TxDropReason = "nonce"       // Queued nonce used by another transaction in the meantime
+	TxDropLimit       TxDropReason = "limit"       // Account or global pool capacity exceeded
+	TxDropExpired     TxDropReason = "expired"     // Queued for longer than the pool's lifetime
+	TxDropPolicy      TxDropReason = "policy"      // Rejected by an updated admission policy or hook
+)
+
+// TxDropEvent is posted when a previously accepted transaction is removed from
+// the transaction pool without being included in a block.
+type TxDropEvent struct {
+	Tx          *types.Transaction
+	Reason      TxDropReason
+	Replacement common.Hash // Transaction superseding the dropped one, if replaced
+}
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
+	Time time.Time // Time the logs were posted at
 }
 
 // PendingStateEvent is posted pre mining and notifies of pending state changes.
@@ -35,6 +60,10 @@ type PendingStateEvent struct{}
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct{ Block *types.Block }
 
+// PendingBlockEvent is posted by the miner whenever it assembles a new pending
+// block.
+type PendingBlockEvent struct{ Block *types.Block }
+
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
@@ -52,3 +81,12 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain is reorganised. Both chain
+// segments are ordered from their highest block down to the first block after
+// the common ancestor.
+type ReorgEvent struct {
+	CommonAncestor *types.Block
+	OldChain       types.Blocks
+	NewChain       types.Blocks
+}
@@ -85,6 +85,37 @@ type GenesisAccount struct {
 	PrivateKey []byte                      `json:"secretKey,omitempty"` // for tests
 }
 
+// SetBalance credits the given account with the specified genesis balance,
+// creating it if it doesn't exist yet.
+func (ga GenesisAlloc) SetBalance(addr common.Address, balance *big.Int) {
+	account := ga[addr]
+	account.Balance = new(big.Int).Set(balance)
+	ga[addr] = account
+}
+
+// SetCode sets the contract code of the given genesis account.
+func (ga GenesisAlloc) SetCode(addr common.Address, code []byte) {
+	account := ga[addr]
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	account.Code = common.CopyBytes(code)
+	ga[addr] = account
+}
+
+// SetState sets a storage slot of the given genesis account.
+func (ga GenesisAlloc) SetState(addr common.Address, key, value common.Hash) {
+	account := ga[addr]
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	if account.Storage == nil {
+		account.Storage = make(map[common.Hash]common.Hash)
+	}
+	account.Storage[key] = value
+	ga[addr] = account
+}
+
 // field type overrides for gencodec
 type genesisSpecMarshaling struct {
 	Nonce      math.HexOrDecimal64
@@ -302,6 +333,27 @@ func (g *Genesis) MustCommit(db gdadb.Database) *types.Block {
 	return block
 }
 
+// SetCliqueSigners encodes the initial set of authorized signers into the extra
+// data field in the layout expected by the clique consensus engine, retaining
+// any existing vanity prefix.
+func (g *Genesis) SetCliqueSigners(signers []common.Address) {
+	sorted := make([]common.Address, len(signers))
+	copy(sorted, signers)
+	for i := 0; i < len(sorted); i++ {
+		for j := i + 1; j < len(sorted); j++ {
+			if bytes.Compare(sorted[i][:], sorted[j][:]) > 0 {
+				sorted[i], sorted[j] = sorted[j], sorted[i]
+			}
+		}
+	}
+	extra := make([]byte, 32, 32+len(sorted)*common.AddressLength+65)
+	copy(extra, g.ExtraData)
+	for _, signer := range sorted {
+		extra = append(extra, signer[:]...)
+	}
+	g.ExtraData = append(extra, make([]byte, 65)...)
+}
+
 // GenesisBlockForTesting creates and writes a block in which addr has the given wei balance.
 func GenesisBlockForTesting(db gdadb.Database, addr common.Address, balance *big.Int) *types.Block {
 	g := Genesis{Alloc: GenesisAlloc{addr: {Balance: balance}}}
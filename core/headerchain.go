@@ -60,6 +60,7 @@ type HeaderChain struct {
 	numberCache *lru.Cache // Cache for the most recent block numbers
 
 	procInterrupt func() bool
+	checkpoints   params.Checkpoints // Canonical hashes enforced at certain heights
 
 	rand   *mrand.Rand
 	engine consensus.Engine
@@ -196,6 +197,12 @@ func (hc *HeaderChain) WriteHeader(header *types.Header) (status WriteStatus, er
 	return
 }
 
+// SetCheckpoints sets the canonical hashes enforced at certain heights during
+// header validation.
+func (hc *HeaderChain) SetCheckpoints(checkpoints params.Checkpoints) {
+	hc.checkpoints = checkpoints
+}
+
 // WhCallback is a callback function for inserting individual headers.
 // A callback is used for two reasons: first, in a LightChain, status should be
 // processed and light chain events sent, while in a BlockChain this is not
@@ -241,6 +248,10 @@ func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header, checkFreq int)
 		if BadHashes[header.Hash()] {
 			return i, ErrBlacklistedHash
 		}
+		// If the header contradicts a checkpoint, the whole chain is bogus
+		if hc.checkpoints.Conflicts(header.Number.Uint64(), header.Hash()) {
+			return i, ErrCheckpointMismatch
+		}
 		// Otherwise wait for headers checks and ensure they pass
 		if err := <-results; err != nil {
 			return i, err
@@ -310,11 +321,12 @@ func (hc *HeaderChain) GetBlockHashesFromHash(hash common.Hash, max uint64) []co
 }
 
 // GetTd retrieves a block's total difficulty in the canonical chain from the
-// database by hash and number, caching it if found.
+// database by hash and number, caching it if found. The returned value is a copy
+// that callers are free to modify.
 func (hc *HeaderChain) GetTd(hash common.Hash, number uint64) *big.Int {
 	// Short circuit if the td's already in the cache, retrieve otherwise
 	if cached, ok := hc.tdCache.Get(hash); ok {
-		return cached.(*big.Int)
+		return new(big.Int).Set(cached.(*big.Int))
 	}
 	td := GetTd(hc.chainDb, hash, number)
 	if td == nil {
@@ -322,7 +334,7 @@ func (hc *HeaderChain) GetTd(hash common.Hash, number uint64) *big.Int {
 	}
 	// Cache the found body for next time and return
 	hc.tdCache.Add(hash, td)
-	return td
+	return new(big.Int).Set(td)
 }
 
 // GetTdByHash retrieves a block's total difficulty in the canonical chain from the
@@ -337,10 +349,24 @@ func (hc *HeaderChain) WriteTd(hash common.Hash, number uint64, td *big.Int) err
 	if err := WriteTd(hc.chainDb, hash, number, td); err != nil {
 		return err
 	}
-	hc.tdCache.Add(hash, new(big.Int).Set(td))
+	hc.cacheTd(hash, td)
 	return nil
 }
 
+// cacheTd caches a block's total difficulty already persisted by the caller,
+// e.g. as part of a larger batch.
+func (hc *HeaderChain) cacheTd(hash common.Hash, td *big.Int) {
+	hc.tdCache.Add(hash, new(big.Int).Set(td))
+}
+
+// setTdCacheLimit replaces the total difficulty cache with one retaining up to
+// limit entries, so deep reorgs can be resolved without hitting the database.
+func (hc *HeaderChain) setTdCacheLimit(limit int) {
+	if tdCache, err := lru.New(limit); err == nil {
+		hc.tdCache = tdCache
+	}
+}
+
 // GetHeader retrieves a block header from the database by hash and number,
 // caching it if found.
 func (hc *HeaderChain) GetHeader(hash common.Hash, number uint64) *types.Header {
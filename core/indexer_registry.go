@@ -0,0 +1,117 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// IndexerSpec describes a user-defined section based chain index, which full
+// nodes maintain alongside their built in indexes through block imports and
+// reorgs. External modules register their indexes from an init function.
+type IndexerSpec struct {
+	Name        string        // Unique name of the index, namespacing its data in the database
+	SectionSize uint64        // Number of blocks in a single section of the index
+	Confirms    uint64        // Number of confirmations before a completed section is processed
+	Throttling  time.Duration // Time to wait between processing two consecutive sections
+
+	// New creates the backend generating the index. The database passed in is
+	// private to the index and should be used for all the data it writes.
+	New func(db gdadb.Database) (ChainIndexerBackend, error)
+}
+
+var (
+	indexerRegistry = make(map[string]IndexerSpec)
+	indexerLock     sync.RWMutex
+)
+
+// RegisterIndexer adds a user-defined index to the registry. It panics if the
+// specification is invalid or an index with the same name was already registered.
+func RegisterIndexer(spec IndexerSpec) {
+	indexerLock.Lock()
+	defer indexerLock.Unlock()
+
+	if err := spec.validate(); err != nil {
+		panic("core: RegisterIndexer " + err.Error())
+	}
+	if _, ok := indexerRegistry[spec.Name]; ok {
+		panic("core: RegisterIndexer called twice for index " + spec.Name)
+	}
+	indexerRegistry[spec.Name] = spec
+}
+
+// RegisteredIndexers returns the specifications of all the registered indexes,
+// sorted by name.
+func RegisteredIndexers() []IndexerSpec {
+	indexerLock.RLock()
+	defer indexerLock.RUnlock()
+
+	specs := make([]IndexerSpec, 0, len(indexerRegistry))
+	for _, spec := range indexerRegistry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// validate checks that the specification describes a usable index. Names are
+// restricted to lowercase letters, digits and underscores so that the database
+// namespaces of different indexes can never overlap.
+func (spec IndexerSpec) validate() error {
+	if spec.Name == "" {
+		return fmt.Errorf("index name is empty")
+	}
+	for _, c := range spec.Name {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '_' {
+			return fmt.Errorf("invalid character %q in index name %s", c, spec.Name)
+		}
+	}
+	if spec.SectionSize == 0 {
+		return fmt.Errorf("zero section size for index %s", spec.Name)
+	}
+	if spec.New == nil {
+		return fmt.Errorf("no backend constructor for index %s", spec.Name)
+	}
+	return nil
+}
+
+// IndexerDatabase returns the namespaced view of the chain database holding the
+// data of the named index, for readers of the index outside of its backend.
+func IndexerDatabase(chainDb gdadb.Database, name string) gdadb.Database {
+	return gdadb.NewTable(chainDb, string(customIndexPrefix)+name+"-d")
+}
+
+// NewRegisteredIndexer creates the chain indexer maintaining a registered index
+// on top of the given chain database. The index data and the indexer progress
+// are both kept under the index's own database prefix.
+func NewRegisteredIndexer(chainDb gdadb.Database, spec IndexerSpec) (*ChainIndexer, error) {
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+	backend, err := spec.New(IndexerDatabase(chainDb, spec.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index %s: %v", spec.Name, err)
+	}
+	table := gdadb.NewTable(chainDb, string(customIndexPrefix)+spec.Name+"-m")
+
+	return NewChainIndexer(chainDb, table, backend, spec.SectionSize, spec.Confirms, spec.Throttling, spec.Name), nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// registryTestBackend is a chain indexer backend storing the hash of the last
+// header of every section it processes.
+type registryTestBackend struct {
+	db      gdadb.Database
+	section uint64
+	head    common.Hash
+}
+
+func (b *registryTestBackend) Reset(section uint64, prevHead common.Hash) error {
+	b.section, b.head = section, common.Hash{}
+	return nil
+}
+
+func (b *registryTestBackend) Process(header *types.Header) {
+	b.head = header.Hash()
+}
+
+func (b *registryTestBackend) Commit() error {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, b.section)
+	return b.db.Put(key, b.head.Bytes())
+}
+
+// Tests that invalid index specifications are rejected by the registry.
+func TestIndexerSpecValidation(t *testing.T) {
+	create := func(db gdadb.Database) (ChainIndexerBackend, error) { return &registryTestBackend{db: db}, nil }
+
+	tests := []struct {
+		spec IndexerSpec
+		ok   bool
+	}{
+		{IndexerSpec{Name: "creations", SectionSize: 4096, New: create}, true},
+		{IndexerSpec{Name: "address_txs_2", SectionSize: 1, New: create}, true},
+		{IndexerSpec{Name: "", SectionSize: 4096, New: create}, false},
+		{IndexerSpec{Name: "Creations", SectionSize: 4096, New: create}, false},
+		{IndexerSpec{Name: "address-txs", SectionSize: 4096, New: create}, false},
+		{IndexerSpec{Name: "creations", SectionSize: 0, New: create}, false},
+		{IndexerSpec{Name: "creations", SectionSize: 4096}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.spec.validate(); (err == nil) != tt.ok {
+			t.Errorf("test %d: validity mismatch: have %v, want %v", i, err, tt.ok)
+		}
+	}
+	// Registering the same index twice must be refused
+	spec := IndexerSpec{Name: "registry_test_duplicate", SectionSize: 16, New: create}
+	RegisterIndexer(spec)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate registration accepted")
+		}
+	}()
+	RegisterIndexer(spec)
+}
+
+// Tests that a registered index is processed section by section and that both
+// its data and its progress are kept in its own database namespace.
+func TestRegisteredIndexer(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	defer db.Close()
+
+	spec := IndexerSpec{
+		Name:        "sectionheads",
+		SectionSize: 4,
+		New:         func(db gdadb.Database) (ChainIndexerBackend, error) { return &registryTestBackend{db: db}, nil },
+	}
+	indexer, err := NewRegisteredIndexer(db, spec)
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	defer indexer.Close()
+
+	for i := uint64(0); i < 8; i++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(i)}
+		if i > 0 {
+			header.ParentHash = GetCanonicalHash(db, i-1)
+		}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), i)
+	}
+	indexer.newHead(7, false)
+
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if sections, _, _ := indexer.Sections(); sections == 2 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("index sections not processed")
+		}
+	}
+	for section := uint64(0); section < 2; section++ {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, section)
+
+		want := GetCanonicalHash(db, section*4+3)
+		if have, _ := IndexerDatabase(db, spec.Name).Get(key); !bytes.Equal(have, want.Bytes()) {
+			t.Errorf("section %d: index data mismatch: have %x, want %x", section, have, want)
+		}
+		if have, _ := db.Get(append([]byte("iXsectionheads-d"), key...)); !bytes.Equal(have, want.Bytes()) {
+			t.Errorf("section %d: namespaced data mismatch: have %x, want %x", section, have, want)
+		}
+	}
+	if ok, _ := db.Has([]byte("iXsectionheads-mcount")); !ok {
+		t.Errorf("index progress not stored in its namespace")
+	}
+}
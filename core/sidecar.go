@@ -0,0 +1,87 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+// SidecarProducer is a plugin that derives extension data (e.g. encrypted mempool
+// commitments or data availability attestations) for every imported block. The
+// produced data is stored alongside the block and pruned together with it.
+type SidecarProducer interface {
+	// Name returns the unique key under which the sidecar data is stored.
+	Name() string
+
+	// Produce derives the sidecar data for a block being written. A nil result
+	// means the block has no associated data for this producer.
+	Produce(block *types.Block, receipts types.Receipts) ([]byte, error)
+}
+
+// RegisterSidecarProducer adds a plugin which is invoked for every block written
+// into the database to generate its sidecar data.
+func (bc *BlockChain) RegisterSidecarProducer(producer SidecarProducer) {
+	bc.sidecarMu.Lock()
+	defer bc.sidecarMu.Unlock()
+
+	bc.sidecarProducers = append(bc.sidecarProducers, producer)
+}
+
+// produceSidecars runs all the registered sidecar producers on a block. Failing
+// producers are logged and skipped, as sidecars are not consensus critical.
+func (bc *BlockChain) produceSidecars(block *types.Block, receipts types.Receipts) map[string][]byte {
+	bc.sidecarMu.RLock()
+	defer bc.sidecarMu.RUnlock()
+
+	if len(bc.sidecarProducers) == 0 {
+		return nil
+	}
+	sidecars := make(map[string][]byte)
+	for _, producer := range bc.sidecarProducers {
+		data, err := producer.Produce(block, receipts)
+		if err != nil {
+			log.Warn("Failed to produce block sidecar", "name", producer.Name(), "number", block.Number(), "hash", block.Hash(), "err", err)
+			continue
+		}
+		if data != nil {
+			sidecars[producer.Name()] = data
+		}
+	}
+	return sidecars
+}
+
+// GetSidecarNames retrieves the names of all the sidecars stored alongside the
+// block with the given hash.
+func (bc *BlockChain) GetSidecarNames(hash common.Hash) []string {
+	number := bc.hc.GetBlockNumber(hash)
+	if number == missingNumber {
+		return nil
+	}
+	return GetSidecarNames(bc.db, hash, number)
+}
+
+// GetSidecar retrieves a named sidecar stored alongside the block with the given
+// hash, or nil if no such data is stored.
+func (bc *BlockChain) GetSidecar(hash common.Hash, name string) []byte {
+	number := bc.hc.GetBlockNumber(hash)
+	if number == missingNumber {
+		return nil
+	}
+	return GetSidecar(bc.db, hash, number, name)
+}
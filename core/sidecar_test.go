@@ -0,0 +1,95 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// testSidecarProducer is a sidecar plugin storing the number of every block, or
+// failing for all of them.
+type testSidecarProducer struct {
+	name string
+	fail bool
+}
+
+func (p *testSidecarProducer) Name() string { return p.name }
+
+func (p *testSidecarProducer) Produce(block *types.Block, receipts types.Receipts) ([]byte, error) {
+	if p.fail {
+		return nil, errors.New("producer failure")
+	}
+	return block.Number().Bytes(), nil
+}
+
+// Tests that the registered sidecar producers are run for every imported block,
+// that failing ones are skipped and that sidecars are pruned on rewinds.
+func TestSidecarProducers(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, nil)
+
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	chain.RegisterSidecarProducer(&testSidecarProducer{name: "number"})
+	chain.RegisterSidecarProducer(&testSidecarProducer{name: "broken", fail: true})
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	for _, block := range blocks {
+		if names := chain.GetSidecarNames(block.Hash()); len(names) != 1 || names[0] != "number" {
+			t.Errorf("block %d: sidecar names mismatch: have %v, want [number]", block.NumberU64(), names)
+		}
+		if data := chain.GetSidecar(block.Hash(), "number"); !bytes.Equal(data, block.Number().Bytes()) {
+			t.Errorf("block %d: sidecar mismatch: have %x, want %x", block.NumberU64(), data, block.Number().Bytes())
+		}
+		if data := chain.GetSidecar(block.Hash(), "broken"); data != nil {
+			t.Errorf("block %d: failed producer stored data: %x", block.NumberU64(), data)
+		}
+	}
+	if names := chain.GetSidecarNames(genesis.Hash()); len(names) != 0 {
+		t.Errorf("genesis sidecars returned: %v", names)
+	}
+	// Rewinding the chain must prune the sidecars of the dropped blocks
+	if err := chain.SetHead(1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if names := GetSidecarNames(db, blocks[0].Hash(), 1); len(names) != 1 {
+		t.Errorf("retained block sidecars pruned: %v", names)
+	}
+	for _, block := range blocks[1:] {
+		if names := GetSidecarNames(db, block.Hash(), block.NumberU64()); len(names) != 0 {
+			t.Errorf("block %d: rewound block sidecar names retained: %v", block.NumberU64(), names)
+		}
+		if data := GetSidecar(db, block.Hash(), block.NumberU64(), "number"); data != nil {
+			t.Errorf("block %d: rewound block sidecar retained: %x", block.NumberU64(), data)
+		}
+	}
+}
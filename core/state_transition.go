@@ -58,6 +58,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	vmerr      error
 }
 
 // Message represents a message sent to a contract.
@@ -132,6 +133,13 @@ func ApplyMessage(evm *vm.EVM, msg Message, gp *GasPool) ([]byte, uint64, bool,
 	return NewStateTransition(evm, msg, gp).TransitionDb()
 }
 
+// VMError returns the error the EVM aborted the execution of the message with,
+// such as vm.ErrExecutionReverted or vm.ErrOutOfGas. Unlike the errors returned
+// by TransitionDb, these do not invalidate the message.
+func (st *StateTransition) VMError() error {
+	return st.vmerr
+}
+
 func (st *StateTransition) from() vm.AccountRef {
 	f := st.msg.From()
 	if !st.state.Exist(f) {
@@ -245,6 +253,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 			return nil, 0, false, vmerr
 		}
 	}
+	st.vmerr = vmerr
 	st.refundGas()
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
@@ -0,0 +1,120 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+// trieJournalVersion is the version of the trie cache journal format.
+const trieJournalVersion = 1
+
+// trieJournalHeader precedes the cached trie nodes in the trie cache journal.
+type trieJournalHeader struct {
+	Version uint64
+	Head    common.Hash       // Head block the cache was journalled at
+	Roots   []trieJournalRoot // State roots pending garbage collection
+}
+
+// trieJournalRoot is a state root referenced by the trie cache.
+type trieJournalRoot struct {
+	Root   common.Hash
+	Number uint64
+}
+
+// saveTrieJournal writes the in-memory trie cache, along with the state roots
+// pending garbage collection, into the trie cache journal.
+func (bc *BlockChain) saveTrieJournal() error {
+	header := trieJournalHeader{Version: trieJournalVersion, Head: bc.CurrentBlock().Hash()}
+
+	// Collect the state roots pending garbage collection, restoring the queue
+	for !bc.triegc.Empty() {
+		root, number := bc.triegc.Pop()
+		header.Roots = append(header.Roots, trieJournalRoot{Root: root.(common.Hash), Number: uint64(-number)})
+	}
+	for _, root := range header.Roots {
+		bc.triegc.Push(root.Root, -float32(root.Number))
+	}
+	// Write the journal next to the live one and swap them once complete
+	path := bc.cacheConfig.Journal
+	file, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err := rlp.Encode(w, &header); err != nil {
+		file.Close()
+		return err
+	}
+	if err := bc.stateCache.TrieDB().Journal(w); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+// loadTrieJournal restores the in-memory trie cache from the trie cache journal
+// if it was written at the current head block. The journal is deleted in any
+// case, as it goes stale as soon as the chain progresses.
+func (bc *BlockChain) loadTrieJournal() error {
+	path := bc.cacheConfig.Journal
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	defer file.Close()
+
+	// The header and the nodes are decoded from the same buffered reader, so no
+	// data is read ahead of the trie database
+	r := bufio.NewReader(file)
+
+	var header trieJournalHeader
+	if err := rlp.NewStream(r, 0).Decode(&header); err != nil {
+		return fmt.Errorf("invalid trie journal header: %v", err)
+	}
+	if header.Version != trieJournalVersion {
+		return fmt.Errorf("unsupported trie journal version %d", header.Version)
+	}
+	if head := bc.CurrentBlock().Hash(); header.Head != head {
+		log.Warn("Discarding stale trie cache journal", "journalled", header.Head, "head", head)
+		return nil
+	}
+	if err := bc.stateCache.TrieDB().LoadJournal(r); err != nil {
+		return err
+	}
+	for _, root := range header.Roots {
+		bc.triegc.Push(root.Root, -float32(root.Number))
+	}
+	return nil
+}
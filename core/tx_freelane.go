@@ -0,0 +1,99 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// ErrFreeTxRateExceeded is returned if a gas-free sender submits transactions
+// faster than permitted by the pool.
+var ErrFreeTxRateExceeded = errors.New("gas-free transaction rate exceeded")
+
+// freeLane admits transactions priced below the pool's floor, down to zero, from
+// an allowlisted set of senders, rate limiting each sender with a token bucket.
+// It is used by permissioned chains not relying on gas economics.
+type freeLane struct {
+	rate    float64                        // Transactions accepted per second and sender (0 = unlimited)
+	burst   float64                        // Maximum number of transactions accepted at once
+	buckets map[common.Address]*freeBucket // Token buckets of the allowlisted senders
+}
+
+// freeBucket is the rate limiting state of a single gas-free sender.
+type freeBucket struct {
+	tokens float64   // Transactions currently allowed
+	last   time.Time // Time the tokens were last replenished
+}
+
+// newFreeLane creates a gas-free lane for the given senders, or nil if there are
+// none configured.
+func newFreeLane(senders []common.Address, rate float64, burst int) *freeLane {
+	if len(senders) == 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	lane := &freeLane{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[common.Address]*freeBucket, len(senders)),
+	}
+	for _, sender := range senders {
+		lane.buckets[sender] = &freeBucket{tokens: lane.burst}
+	}
+	return lane
+}
+
+// contains checks whether an address is an allowlisted gas-free sender.
+func (lane *freeLane) contains(addr common.Address) bool {
+	if lane == nil {
+		return false
+	}
+	_, ok := lane.buckets[addr]
+	return ok
+}
+
+// admit consumes the allowance of a gas-free sender for a single transaction,
+// returning an error if the sender is not allowlisted or exceeded its rate.
+func (lane *freeLane) admit(addr common.Address, now time.Time) error {
+	if !lane.contains(addr) {
+		return ErrUnderpriced
+	}
+	if lane.rate <= 0 {
+		return nil
+	}
+	bucket := lane.buckets[addr]
+	if bucket.last.IsZero() {
+		bucket.last = now
+	}
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * lane.rate
+		if bucket.tokens > lane.burst {
+			bucket.tokens = lane.burst
+		}
+		bucket.last = now
+	}
+	if bucket.tokens < 1 {
+		return ErrFreeTxRateExceeded
+	}
+	bucket.tokens--
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+var (
+	// ErrSenderDenied is returned if a transaction's sender is not permitted by
+	// the admission policy of the pool.
+	ErrSenderDenied = errors.New("sender denied by policy")
+
+	// ErrRecipientDenied is returned if a transaction's recipient is not
+	// permitted by the admission policy of the pool.
+	ErrRecipientDenied = errors.New("recipient denied by policy")
+)
+
+// TxValidator is an admission hook of the transaction pool, consulted after a
+// transaction passed the consensus and pricing checks. Returning an error rejects
+// the transaction.
+type TxValidator interface {
+	ValidateTx(tx *types.Transaction, from common.Address) error
+}
+
+// PolicyRules is an address based admission ruleset. Empty allowlists permit
+// every address, the denylists take precedence over the allowlists. Contract
+// creations are only subject to the sender rules.
+type PolicyRules struct {
+	AllowFrom []common.Address `json:"allowFrom,omitempty"`
+	AllowTo   []common.Address `json:"allowTo,omitempty"`
+	DenyFrom  []common.Address `json:"denyFrom,omitempty"`
+	DenyTo    []common.Address `json:"denyTo,omitempty"`
+}
+
+// LoadPolicyRules reads an admission ruleset from a JSON file. A missing file
+// is treated as an empty ruleset.
+func LoadPolicyRules(path string) (*PolicyRules, error) {
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return new(PolicyRules), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rules := new(PolicyRules)
+	if err := json.Unmarshal(blob, rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// save writes the admission ruleset into a JSON file, replacing it atomically.
+func (rules *PolicyRules) save(path string) error {
+	blob, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".new", blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+// addressPolicy is a TxValidator enforcing an address based ruleset.
+type addressPolicy struct {
+	allowFrom, allowTo map[common.Address]struct{}
+	denyFrom, denyTo   map[common.Address]struct{}
+}
+
+// newAddressPolicy creates a validator enforcing the given ruleset.
+func newAddressPolicy(rules *PolicyRules) *addressPolicy {
+	set := func(addrs []common.Address) map[common.Address]struct{} {
+		if len(addrs) == 0 {
+			return nil
+		}
+		set := make(map[common.Address]struct{}, len(addrs))
+		for _, addr := range addrs {
+			set[addr] = struct{}{}
+		}
+		return set
+	}
+	return &addressPolicy{
+		allowFrom: set(rules.AllowFrom),
+		allowTo:   set(rules.AllowTo),
+		denyFrom:  set(rules.DenyFrom),
+		denyTo:    set(rules.DenyTo),
+	}
+}
+
+// denyAllPolicy creates a validator rejecting every transaction, enforced in place
+// of a configured ruleset that cannot be loaded.
+func denyAllPolicy() *addressPolicy {
+	return &addressPolicy{allowFrom: make(map[common.Address]struct{})}
+}
+
+// ValidateTx implements TxValidator, checking the sender and the recipient of a
+// transaction against the ruleset.
+func (p *addressPolicy) ValidateTx(tx *types.Transaction, from common.Address) error {
+	if !permitted(from, p.allowFrom, p.denyFrom) {
+		return ErrSenderDenied
+	}
+	if to := tx.To(); to != nil && !permitted(*to, p.allowTo, p.denyTo) {
+		return ErrRecipientDenied
+	}
+	return nil
+}
+
+// permitted checks whether an address passes an allowlist and a denylist, nil
+// allowlists permitting everything.
+func permitted(addr common.Address, allow, deny map[common.Address]struct{}) bool {
+	if _, ok := deny[addr]; ok {
+		return false
+	}
+	if allow == nil {
+		return true
+	}
+	_, ok := allow[addr]
+	return ok
+}
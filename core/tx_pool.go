@@ -129,9 +129,16 @@ type TxPoolConfig struct {
 	Journal   string        // Journal of local transactions to survive node restarts
 	Rejournal time.Duration // Time interval to regenerate the local transaction journal
 
+	RemoteJournal string // Snapshot of remote transactions to survive node restarts (disabled if empty)
+	PolicyRules   string // JSON ruleset of the address admission policy (disabled if empty)
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
+	FreeSenders []common.Address // Senders allowed to submit transactions below the price limit, down to zero
+	FreeRate    float64          // Transactions per second accepted below the price limit from each sender (0 = unlimited)
+	FreeBurst   int              // Maximum number of transactions accepted below the price limit at once from each sender
+
 	AccountSlots uint64 // Minimum number of executable transaction slots guaranteed per account
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
@@ -149,6 +156,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	PriceLimit: 1,
 	PriceBump:  10,
 
+	FreeRate:  1,
+	FreeBurst: 16,
+
 	AccountSlots: 16,
 	GlobalSlots:  4096,
 	AccountQueue: 64,
@@ -189,6 +199,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	dropFeed     event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -200,8 +211,16 @@ type TxPool struct {
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
+	free    *freeLane   // Gas-free lane of permissioned senders (nil = disabled)
+	exempt  *accountSet // Set of local and gas-free senders to exempt from price based eviction
 	journal *txJournal  // Journal of local transaction to back up to disk
 
+	reinjecting bool // Whether reorged transactions are being re-added (no gas-free rate charge)
+
+	rules      *PolicyRules   // Address admission ruleset currently enforced (nil = none)
+	policy     *addressPolicy // Validator enforcing the address admission ruleset
+	validators []TxValidator  // Additional admission hooks, consulted in order
+
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
 	beats   map[common.Address]time.Time       // Last heartbeat from each known account
@@ -233,9 +252,24 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.free = newFreeLane(config.FreeSenders, config.FreeRate, config.FreeBurst)
+	pool.exempt = newAccountSet(pool.signer)
+	for _, sender := range config.FreeSenders {
+		pool.exempt.add(sender)
+	}
 	pool.priced = newTxPricedList(&pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
+	// If an admission policy is configured, enforce it before accepting anything
+	if config.PolicyRules != "" {
+		rules, err := LoadPolicyRules(config.PolicyRules)
+		if err != nil {
+			log.Error("Failed to load transaction admission policy, denying all transactions", "err", err)
+			pool.policy = denyAllPolicy()
+		} else {
+			pool.rules, pool.policy = rules, newAddressPolicy(rules)
+		}
+	}
 	// If local transactions and journaling is enabled, load from disk
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
@@ -247,6 +281,12 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transactions were snapshotted on shutdown, revalidate and restore them
+	if config.RemoteJournal != "" {
+		if err := newTxJournal(config.RemoteJournal).load(pool.AddRemote); err != nil {
+			log.Warn("Failed to load remote transaction snapshot", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -283,7 +323,9 @@ func (pool *TxPool) loop() {
 		select {
 		// Handle ChainHeadEvent
 		case ev := <-pool.chainHeadCh:
-			if ev.Block != nil {
+			// Heads rewound with SetHead in the meantime are skipped, their state
+			// would override the one the pool was explicitly Reset to
+			if ev.Block != nil && ev.Block.Hash() == pool.chain.CurrentBlock().Hash() {
 				pool.mu.Lock()
 				if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
@@ -321,6 +363,7 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash())
+						pool.dropped(tx, TxDropExpired)
 					}
 				}
 			}
@@ -348,6 +391,16 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	pool.reset(oldHead, newHead)
 }
 
+// Reset synchronously rebuilds the pool on top of the current head block. The
+// pool tracks chain head events in the background, but rewinding the chain with
+// SetHead emits none, leaving the pool with stale state until the next block.
+func (pool *TxPool) Reset() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.reset(nil, pool.chain.CurrentBlock().Header())
+}
+
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
@@ -369,33 +422,39 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 				rem = pool.chain.GetBlock(oldHead.Hash(), oldHead.Number.Uint64())
 				add = pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64())
 			)
-			for rem.NumberU64() > add.NumberU64() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-					log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-					return
-				}
-			}
-			for add.NumberU64() > rem.NumberU64() {
-				included = append(included, add.Transactions()...)
-				if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-					log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-					return
+			if rem == nil || add == nil {
+				// The old chain is gone if it was rewound with SetHead, in which
+				// case its transactions are dropped instead of reinjected.
+				log.Debug("Skipping transaction reorg of rewound chain", "old", oldHead.Number, "new", newHead.Number)
+			} else {
+				for rem.NumberU64() > add.NumberU64() {
+					discarded = append(discarded, rem.Transactions()...)
+					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
+						return
+					}
 				}
-			}
-			for rem.Hash() != add.Hash() {
-				discarded = append(discarded, rem.Transactions()...)
-				if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
-					log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
-					return
+				for add.NumberU64() > rem.NumberU64() {
+					included = append(included, add.Transactions()...)
+					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
+						return
+					}
 				}
-				included = append(included, add.Transactions()...)
-				if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
-					log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
-					return
+				for rem.Hash() != add.Hash() {
+					discarded = append(discarded, rem.Transactions()...)
+					if rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1); rem == nil {
+						log.Error("Unrooted old chain seen by tx pool", "block", oldHead.Number, "hash", oldHead.Hash())
+						return
+					}
+					included = append(included, add.Transactions()...)
+					if add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1); add == nil {
+						log.Error("Unrooted new chain seen by tx pool", "block", newHead.Number, "hash", newHead.Hash())
+						return
+					}
 				}
+				reinject = types.TxDifference(discarded, included)
 			}
-			reinject = types.TxDifference(discarded, included)
 		}
 	}
 	// Initialize the internal state to the current head
@@ -413,7 +472,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
-	pool.addTxsLocked(reinject, false)
+	pool.reinject(reinject)
 
 	// validate the pool of pending transactions, this will remove
 	// any transactions that have been included in the block or
@@ -441,8 +500,27 @@ func (pool *TxPool) Stop() {
 	pool.wg.Wait()
 
 	if pool.journal != nil {
+		// Regenerate the journal, so no local transactions are lost or replayed
+		pool.mu.Lock()
+		if err := pool.journal.rotate(pool.local()); err != nil {
+			log.Warn("Failed to rotate local tx journal", "err", err)
+		}
+		pool.mu.Unlock()
+
 		pool.journal.close()
 	}
+	if pool.config.RemoteJournal != "" {
+		// Snapshot the remote transactions, so they can be restored on the next boot
+		journal := newTxJournal(pool.config.RemoteJournal)
+
+		pool.mu.Lock()
+		if err := journal.rotate(pool.remote()); err != nil {
+			log.Warn("Failed to snapshot remote transactions", "err", err)
+		}
+		pool.mu.Unlock()
+
+		journal.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -452,6 +530,24 @@ func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.Subscription
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxDropEvent registers a subscription of TxDropEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxDropEvent(ch chan<- TxDropEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// dropped notifies any subsystems of a transaction removed from the pool
+// without being included in a block.
+func (pool *TxPool) dropped(tx *types.Transaction, reason TxDropReason) {
+	go pool.dropFeed.Send(TxDropEvent{Tx: tx, Reason: reason})
+}
+
+// replaced notifies any subsystems of a transaction superseded by another one
+// with the same nonce.
+func (pool *TxPool) replaced(tx *types.Transaction, by common.Hash) {
+	go pool.dropFeed.Send(TxDropEvent{Tx: tx, Reason: TxDropReplaced, Replacement: by})
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -467,8 +563,9 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
-	for _, tx := range pool.priced.Cap(price, pool.locals) {
+	for _, tx := range pool.priced.Cap(price, pool.exempt) {
 		pool.removeTx(tx.Hash())
+		pool.dropped(tx, TxDropUnderpriced)
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
@@ -551,6 +648,94 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves all currently known transactions, grouped by origin account
+// and sorted by nonce, sent by accounts not marked as local. The returned
+// transaction set is a copy and can be freely modified by calling code.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], list.Flatten()...)
+		}
+	}
+	for addr, list := range pool.queue {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], list.Flatten()...)
+		}
+	}
+	return txs
+}
+
+// admit checks a transaction against the admission policy and all the admission
+// hooks of the pool.
+func (pool *TxPool) admit(tx *types.Transaction, from common.Address) error {
+	if pool.policy != nil {
+		if err := pool.policy.ValidateTx(tx, from); err != nil {
+			return err
+		}
+	}
+	for _, validator := range pool.validators {
+		if err := validator.ValidateTx(tx, from); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddValidator registers an admission hook with the pool, dropping all pooled
+// transactions it rejects. Hooks are invoked with the pool locked, so they must
+// not call back into it.
+func (pool *TxPool) AddValidator(validator TxValidator) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.validators = append(pool.validators, validator)
+	pool.evictInadmissible()
+}
+
+// PolicyRules retrieves the address admission ruleset currently enforced.
+func (pool *TxPool) PolicyRules() *PolicyRules {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	if pool.rules == nil {
+		return new(PolicyRules)
+	}
+	return pool.rules
+}
+
+// SetPolicyRules replaces the address admission ruleset of the pool, persisting
+// it if a ruleset file is configured and dropping all pooled transactions the
+// new rules reject.
+func (pool *TxPool) SetPolicyRules(rules *PolicyRules) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.config.PolicyRules != "" {
+		if err := rules.save(pool.config.PolicyRules); err != nil {
+			return err
+		}
+	}
+	pool.rules, pool.policy = rules, newAddressPolicy(rules)
+	pool.evictInadmissible()
+
+	log.Info("Updated transaction admission policy", "allowFrom", len(rules.AllowFrom), "allowTo", len(rules.AllowTo), "denyFrom", len(rules.DenyFrom), "denyTo", len(rules.DenyTo))
+	return nil
+}
+
+// evictInadmissible drops all pooled transactions rejected by the current
+// admission policy or hooks.
+func (pool *TxPool) evictInadmissible() {
+	for hash, tx := range pool.all {
+		from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+		if err := pool.admit(tx, from); err != nil {
+			log.Trace("Removing inadmissible transaction", "hash", hash, "err", err)
+			pool.removeTx(hash)
+			pool.dropped(tx, TxDropPolicy)
+		}
+	}
+}
+
 // validateTx checks whgdaer a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -572,9 +757,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price
+	// Drop transactions rejected by the admission policy or hooks
+	if err := pool.admit(tx, from); err != nil {
+		return err
+	}
+	// Drop non-local transactions under our own minimal accepted gas price,
+	// unless the sender is permitted to use the gas-free lane
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	underpriced := !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0
+	if underpriced && !pool.free.contains(from) {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
@@ -593,6 +784,13 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	// Charge the gas-free allowance last, so invalid transactions don't use it
+	// up, and only once, not again when a reorg reinjects the transaction
+	if underpriced && !pool.reinjecting {
+		if err := pool.free.admit(from, time.Now()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -620,17 +818,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
-		if pool.priced.Underpriced(tx, pool.locals) {
+		if pool.priced.Underpriced(tx, pool.exempt) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.exempt)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash())
+			pool.dropped(tx, TxDropUnderpriced)
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
@@ -647,6 +846,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			delete(pool.all, old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
+			pool.replaced(old, hash)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.priced.Put(tx)
@@ -667,6 +867,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// Mark local addresses and journal local transactions
 	if local {
 		pool.locals.add(from)
+		pool.exempt.add(from)
 	}
 	pool.journalTx(from, tx)
 
@@ -694,6 +895,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		delete(pool.all, old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
+		pool.replaced(old, hash)
 	}
 	pool.all[hash] = tx
 	pool.priced.Put(tx)
@@ -729,6 +931,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
+		pool.replaced(tx, list.txs.Get(tx.Nonce()).Hash())
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -737,6 +940,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
+		pool.replaced(old, hash)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all[hash] == nil {
@@ -804,6 +1008,15 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
 	return pool.addTxsLocked(txs, local)
 }
 
+// reinject re-adds the transactions dropped from the canonical chain by a reorg,
+// whilst assuming the transaction pool lock is already held.
+func (pool *TxPool) reinject(txs []*types.Transaction) {
+	pool.reinjecting = true
+	defer func() { pool.reinjecting = false }()
+
+	pool.addTxsLocked(txs, false)
+}
+
 // addTxsLocked attempts to queue a batch of transactions if they are valid,
 // whilst assuming the transaction pool lock is already held.
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) []error {
@@ -926,6 +1139,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.dropped(tx, TxDropNonceTooLow)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currengdaate.GetBalance(addr), pool.currentMaxGas)
@@ -935,6 +1149,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.all, hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
+			pool.dropped(tx, TxDropUnpayable)
 		}
 		// Gather all executable transactions and promote them
 		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
@@ -950,6 +1165,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
+				pool.dropped(tx, TxDropLimit)
 			}
 		}
 		// Delete the entire queue entry if it became empty.
@@ -999,6 +1215,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							pool.dropped(tx, TxDropLimit)
 						}
 						pending--
 					}
@@ -1021,6 +1238,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						pool.dropped(tx, TxDropLimit)
 					}
 					pending--
 				}
@@ -1054,6 +1272,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash())
+					pool.dropped(tx, TxDropLimit)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1063,6 +1282,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash())
+				pool.dropped(txs[i], TxDropLimit)
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
@@ -1093,6 +1313,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			delete(pool.all, hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
+			pool.dropped(tx, TxDropUnpayable)
 		}
 		for _, tx := range invalids {
 			hash := tx.Hash()
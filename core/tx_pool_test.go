@@ -1449,6 +1449,72 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that transactions leaving the pool without being mined are announced
+// together with the reason of their removal.
+func TestTransactionDropEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	drops := make(chan TxDropEvent, 32)
+	sub := pool.SubscribeTxDropEvent(drops)
+	defer sub.Unsubscribe()
+
+	expect := func(tx *types.Transaction, reason TxDropReason, replacement common.Hash) {
+		select {
+		case ev := <-drops:
+			if ev.Tx.Hash() != tx.Hash() {
+				t.Fatalf("dropped transaction mismatch: have %x, want %x", ev.Tx.Hash(), tx.Hash())
+			}
+			if ev.Reason != reason {
+				t.Fatalf("drop reason mismatch: have %s, want %s", ev.Reason, reason)
+			}
+			if ev.Replacement != replacement {
+				t.Fatalf("replacement mismatch: have %x, want %x", ev.Replacement, replacement)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("drop event for %x not fired", tx.Hash())
+		}
+	}
+	// Replace a pending and a queued transaction
+	pending, bumped := pricedTransaction(0, 100000, big.NewInt(1), key), pricedTransaction(0, 100000, big.NewInt(2), key)
+	if err := pool.AddRemote(pending); err != nil {
+		t.Fatalf("failed to add pending transaction: %v", err)
+	}
+	if err := pool.AddRemote(bumped); err != nil {
+		t.Fatalf("failed to replace pending transaction: %v", err)
+	}
+	expect(pending, TxDropReplaced, bumped.Hash())
+
+	queued, requeued := pricedTransaction(2, 100000, big.NewInt(1), key), pricedTransaction(2, 100000, big.NewInt(2), key)
+	if err := pool.AddRemote(queued); err != nil {
+		t.Fatalf("failed to add queued transaction: %v", err)
+	}
+	if err := pool.AddRemote(requeued); err != nil {
+		t.Fatalf("failed to replace queued transaction: %v", err)
+	}
+	expect(queued, TxDropReplaced, requeued.Hash())
+
+	// Raise the price floor above all transactions in the pool
+	pool.SetGasPrice(big.NewInt(3))
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-drops:
+			if ev.Reason != TxDropUnderpriced {
+				t.Fatalf("drop reason mismatch: have %s, want %s", ev.Reason, TxDropUnderpriced)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("underpriced drop event %d not fired", i)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -1563,6 +1629,203 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that remote transactions are snapshotted on shutdown and revalidated
+// when restored on the next boot.
+func TestTransactionRemoteSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the snapshot
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary snapshot: %v", err)
+	}
+	snapshot := file.Name()
+	defer os.Remove(snapshot)
+
+	file.Close()
+	os.Remove(snapshot)
+
+	// Create the original pool and fill it with remote transactions
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.RemoteJournal = snapshot
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// Add two executable and one gapped transaction
+	for _, nonce := range []uint64{0, 1, 3} {
+		if err := pool.AddRemote(pricedTransaction(nonce, 100000, big.NewInt(1), key)); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", nonce, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	// Restart the pool with the first transaction already included
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(key.PublicKey), 1)
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the address admission policy rejects new transactions and evicts
+// the pooled ones it denies when updated.
+func TestTransactionPolicyRules(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	other, _ := crypto.GenerateKey()
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000))
+
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	denied := transaction(0, 100000, other)
+	if err := pool.AddRemote(denied); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	drops := make(chan TxDropEvent, 1)
+	sub := pool.SubscribeTxDropEvent(drops)
+	defer sub.Unsubscribe()
+
+	// Deny the second sender and ensure its transactions are dropped
+	if err := pool.SetPolicyRules(&PolicyRules{DenyFrom: []common.Address{crypto.PubkeyToAddress(other.PublicKey)}}); err != nil {
+		t.Fatalf("failed to set policy rules: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	select {
+	case ev := <-drops:
+		if ev.Tx.Hash() != denied.Hash() || ev.Reason != TxDropPolicy {
+			t.Fatalf("drop event mismatch: have %x/%s, want %x/%s", ev.Tx.Hash(), ev.Reason, denied.Hash(), TxDropPolicy)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("policy drop event not fired")
+	}
+	if err := pool.AddRemote(transaction(1, 100000, other)); err != ErrSenderDenied {
+		t.Fatalf("denied sender error mismatch: have %v, want %v", err, ErrSenderDenied)
+	}
+	// Allow only other recipients and ensure the remaining transaction is dropped
+	if err := pool.SetPolicyRules(&PolicyRules{AllowTo: []common.Address{{0x01}}}); err != nil {
+		t.Fatalf("failed to set policy rules: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 0 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 0)
+	}
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != ErrRecipientDenied {
+		t.Fatalf("denied recipient error mismatch: have %v, want %v", err, ErrRecipientDenied)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a malformed admission ruleset makes the pool deny all transactions
+// instead of running without a policy.
+func TestTransactionPolicyRulesMalformed(t *testing.T) {
+	t.Parallel()
+
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary rules file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"denyFrom": [`)
+	file.Close()
+
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.PolicyRules = file.Name()
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != ErrSenderDenied {
+		t.Fatalf("transaction error mismatch: have %v, want %v", err, ErrSenderDenied)
+	}
+}
+
+// Tests that allowlisted senders may submit zero gas price transactions up to
+// their rate limit, and that those survive price floor increases.
+func TestTransactionFreeLane(t *testing.T) {
+	t.Parallel()
+
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	free, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	config := testTxPoolConfig
+	config.FreeSenders = []common.Address{crypto.PubkeyToAddress(free.PublicKey)}
+	config.FreeRate = 0.001
+	config.FreeBurst = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(free.PublicKey), big.NewInt(1000000))
+	pool.currengdaate.AddBalance(crypto.PubkeyToAddress(other.PublicKey), big.NewInt(1000000))
+
+	// Invalid transactions must not use up the allowance of the sender
+	if err := pool.AddRemote(pricedTransaction(0, 1000, big.NewInt(0), free)); err != ErrIntrinsicGas {
+		t.Fatalf("invalid gas-free transaction error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	// The burst of the allowlisted sender is accepted, anything more is not
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := pool.AddRemote(pricedTransaction(nonce, 100000, big.NewInt(0), free)); err != nil {
+			t.Fatalf("failed to add gas-free transaction %d: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(0), free)); err != ErrFreeTxRateExceeded {
+		t.Fatalf("rate limited error mismatch: have %v, want %v", err, ErrFreeTxRateExceeded)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(0), other)); err != ErrUnderpriced {
+		t.Fatalf("non-allowlisted error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Transactions reinjected by a reorg were already admitted once, so they
+	// must be accepted regardless of the rate limit
+	pool.mu.Lock()
+	pool.reinject(types.Transactions{pricedTransaction(2, 100000, big.NewInt(0), free)})
+	pool.mu.Unlock()
+
+	// Raising the price floor must not evict the gas-free transactions
+	pool.SetGasPrice(big.NewInt(10))
+	if pending, _ := pool.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
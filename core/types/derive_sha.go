@@ -30,6 +30,13 @@ type DerivableList interface {
 }
 
 func DeriveSha(list DerivableList) common.Hash {
+	return DeriveTrie(list).Hash()
+}
+
+// DeriveTrie builds the trie DeriveSha hashes, keyed by the RLP encoded index
+// of each list item. It can be used to prove the inclusion of items, such as
+// receipts, against the roots in block headers.
+func DeriveTrie(list DerivableList) *trie.Trie {
 	keybuf := new(bytes.Buffer)
 	trie := new(trie.Trie)
 	for i := 0; i < list.Len(); i++ {
@@ -37,5 +44,5 @@ func DeriveSha(list DerivableList) common.Hash {
 		rlp.Encode(keybuf, uint(i))
 		trie.Update(keybuf.Bytes(), list.GetRlp(i))
 	}
-	return trie.Hash()
+	return trie
 }
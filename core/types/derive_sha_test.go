@@ -0,0 +1,53 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/trie"
+)
+
+// Tests that receipts can be proven against the root DeriveSha computes.
+func TestDeriveTrieProof(t *testing.T) {
+	receipts := make(Receipts, 200)
+	for i := range receipts {
+		receipts[i] = NewReceipt(nil, i%3 == 0, uint64(21000*(i+1)))
+		receipts[i].Logs = []*Log{}
+	}
+	root := DeriveSha(receipts)
+
+	tr := DeriveTrie(receipts)
+	for _, index := range []uint{0, 1, 127, 128, 199} {
+		key, _ := rlp.EncodeToBytes(index)
+
+		proof, _ := gdadb.NewMemDatabase()
+		if err := tr.Prove(key, 0, proof); err != nil {
+			t.Fatalf("receipt %d: failed to prove: %v", index, err)
+		}
+		value, err, _ := trie.VerifyProof(root, key, proof)
+		if err != nil {
+			t.Fatalf("receipt %d: failed to verify proof: %v", index, err)
+		}
+		if want := receipts.GetRlp(int(index)); !bytes.Equal(value, want) {
+			t.Errorf("receipt %d: value mismatch: have %x, want %x", index, value, want)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math/big"
 	"unsafe"
 
 	"github.com/gdachain/go-gdachain/common"
@@ -55,6 +56,15 @@ type Receipt struct {
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
+	ReturnData      []byte         `json:"returnData,omitempty"` // Revert data of failed transactions, if recorded
+
+	// Inclusion fields, derived from the block and its transactions (not stored)
+	BlockHash         common.Hash     `json:"blockHash,omitempty"`
+	BlockNumber       *big.Int        `json:"blockNumber,omitempty"`
+	TransactionIndex  uint            `json:"transactionIndex"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	EffectiveGasPrice *big.Int        `json:"effectiveGasPrice,omitempty"`
 }
 
 type receiptMarshaling struct {
@@ -62,6 +72,10 @@ type receiptMarshaling struct {
 	Status            hexutil.Uint
 	CumulativeGasUsed hexutil.Uint64
 	GasUsed           hexutil.Uint64
+	ReturnData        hexutil.Bytes
+	BlockNumber       *hexutil.Big
+	TransactionIndex  hexutil.Uint
+	EffectiveGasPrice *hexutil.Big
 }
 
 // receiptRLP is the consensus encoding of a receipt.
@@ -80,6 +94,7 @@ type receipgdaorageRLP struct {
 	ContractAddress   common.Address
 	Logs              []*LogForStorage
 	GasUsed           uint64
+	Extra             []rlp.RawValue `rlp:"tail"` // Optional return data of failed transactions
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -140,7 +155,7 @@ func (r *Receipt) statusEncoding() []byte {
 // Size returns the approximate memory used by all internal contents. It is used
 // to approximate and limit the memory consumption of various caches.
 func (r *Receipt) Size() common.StorageSize {
-	size := common.StorageSize(unsafe.Sizeof(*r)) + common.StorageSize(len(r.Posgdaate))
+	size := common.StorageSize(unsafe.Sizeof(*r)) + common.StorageSize(len(r.Posgdaate)+len(r.ReturnData))
 
 	size += common.StorageSize(len(r.Logs)) * common.StorageSize(unsafe.Sizeof(Log{}))
 	for _, log := range r.Logs {
@@ -176,6 +191,15 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
 	}
+	// Only append the return data if present, keeping the encoding of all other
+	// receipts identical to the one without it
+	if len(r.ReturnData) > 0 {
+		data, err := rlp.EncodeToBytes(r.ReturnData)
+		if err != nil {
+			return err
+		}
+		enc.Extra = []rlp.RawValue{data}
+	}
 	return rlp.Encode(w, enc)
 }
 
@@ -197,6 +221,11 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	}
 	// Assign the implementation fields
 	r.TxHash, r.ContractAddress, r.GasUsed = dec.TxHash, dec.ContractAddress, dec.GasUsed
+	if len(dec.Extra) > 0 {
+		if err := rlp.DecodeBytes(dec.Extra[0], &r.ReturnData); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -206,6 +235,27 @@ type Receipts []*Receipt
 // Len returns the number of receipts in this list.
 func (r Receipts) Len() int { return len(r) }
 
+// DeriveFields fills in the inclusion fields of the receipts of a block, which
+// are not stored along with them, from the block and its transactions.
+func (r Receipts) DeriveFields(signer Signer, hash common.Hash, number uint64, txs Transactions) error {
+	if len(txs) != len(r) {
+		return fmt.Errorf("transaction and receipt count mismatch: %d != %d", len(txs), len(r))
+	}
+	for i, receipt := range r {
+		from, err := Sender(signer, txs[i])
+		if err != nil {
+			return err
+		}
+		receipt.BlockHash = hash
+		receipt.BlockNumber = new(big.Int).SetUint64(number)
+		receipt.TransactionIndex = uint(i)
+		receipt.From = from
+		receipt.To = txs[i].To()
+		receipt.EffectiveGasPrice = txs[i].GasPrice()
+	}
+	return nil
+}
+
 // GetRlp returns the RLP encoding of one receipt from the list.
 func (r Receipts) GetRlp(i int) []byte {
 	bytes, err := rlp.EncodeToBytes(r[i])
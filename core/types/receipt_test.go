@@ -0,0 +1,64 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+// Tests that the return data of failed transactions survives the storage
+// encoding, while receipts without it encode as they did before.
+func TestReceiptStorageReturnData(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceipgdaatusFailed,
+		CumulativeGasUsed: 21000,
+		Logs:              []*Log{},
+		TxHash:            common.HexToHash("0x01"),
+		GasUsed:           21000,
+	}
+	plain, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	receipt.ReturnData = []byte{0x08, 0xc3, 0x79, 0xa0}
+	blob, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	var dec ReceiptForStorage
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if !bytes.Equal(dec.ReturnData, receipt.ReturnData) {
+		t.Errorf("return data mismatch: have %x, want %x", dec.ReturnData, receipt.ReturnData)
+	}
+	// Receipts stored without return data must still decode
+	dec = ReceiptForStorage{}
+	if err := rlp.DecodeBytes(plain, &dec); err != nil {
+		t.Fatalf("failed to decode plain receipt: %v", err)
+	}
+	if dec.ReturnData != nil {
+		t.Errorf("unexpected return data: %x", dec.ReturnData)
+	}
+	if dec.TxHash != receipt.TxHash || dec.GasUsed != receipt.GasUsed {
+		t.Errorf("implementation fields mismatch: have %x/%d", dec.TxHash, dec.GasUsed)
+	}
+}
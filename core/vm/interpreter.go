@@ -39,6 +39,13 @@ type Config struct {
 	NoRecursion bool
 	// Enable recording of SHA3/keccak preimages
 	EnablePreimageRecording bool
+	// Profile aggregates the executed opcodes and their gas (nil = disabled)
+	Profile *Profile
+	// Enable storing the return data of failed transactions in their receipts
+	RecordRevertReasons bool
+	// MemoryLimit caps the memory in bytes all the call frames of an execution
+	// may allocate together (0 = unlimited)
+	MemoryLimit uint64
 	// JumpTable contains the EVM instruction table. This
 	// may be left uninitialised and will be set to the default
 	// table.
@@ -106,7 +113,7 @@ func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack
 //
 // It's important to note that any errors returned by the interpreter should be
 // considered a revert-and-consume-all-gas operation except for
-// errExecutionReverted which means revert-and-keep-gas-left.
+// ErrExecutionReverted which means revert-and-keep-gas-left.
 func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	// Increment the call depth which is restricted to 1024
 	in.evm.depth++
@@ -137,6 +144,20 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 	)
 	contract.Input = input
 
+	// Release the memory of the frame from the execution wide usage on return
+	if in.cfg.MemoryLimit != 0 {
+		defer func() { in.evm.memoryUsed -= uint64(mem.Len()) }()
+	}
+	// Account the execution to the contract whose code is run
+	var stats *ContractStats
+	if in.cfg.Profile != nil {
+		addr := contract.Address()
+		if contract.CodeAddr != nil {
+			addr = *contract.CodeAddr
+		}
+		stats = in.cfg.Profile.contract(addr)
+		stats.Calls++
+	}
 	if in.cfg.Debug {
 		defer func() {
 			if err != nil {
@@ -186,6 +207,12 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 			if memorySize, overflow = math.SafeMul(toWordSize(memSize), 32); overflow {
 				return nil, errGasUintOverflow
 			}
+			// Reject the expansion if it would exceed the execution's memory limit
+			if limit := in.cfg.MemoryLimit; limit != 0 && memorySize > uint64(mem.Len()) {
+				if in.evm.memoryUsed+memorySize-uint64(mem.Len()) > limit {
+					return nil, ErrMemoryLimitExceeded
+				}
+			}
 		}
 		// consume the gas and return an error if not enough gas is available.
 		// cost is explicitly set so that the capture state defer method cas get the proper cost
@@ -194,8 +221,24 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 			return nil, ErrOutOfGas
 		}
 		if memorySize > 0 {
+			if in.cfg.MemoryLimit != 0 && memorySize > uint64(mem.Len()) {
+				in.evm.memoryUsed += memorySize - uint64(mem.Len())
+			}
 			mem.Resize(memorySize)
 		}
+		if stats != nil {
+			// Calls are charged the gas forwarded to the callee, which is
+			// accounted to the callee's code instead
+			self := cost
+			switch op {
+			case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+				self -= in.evm.callGasTemp
+			}
+			in.cfg.Profile.Ops[op].Count++
+			in.cfg.Profile.Ops[op].Gas += self
+			stats.Ops++
+			stats.Gas += self
+		}
 
 		if in.cfg.Debug {
 			in.cfg.Tracer.CaptureState(in.evm, pc, op, gasCopy, cost, mem, stack, contract, in.evm.depth, err)
@@ -219,7 +262,7 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 		case err != nil:
 			return nil, err
 		case operation.reverts:
-			return res, errExecutionReverted
+			return res, ErrExecutionReverted
 		case operation.halts:
 			return res, nil
 		case !operation.jumps:
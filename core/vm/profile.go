@@ -0,0 +1,147 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+// OpStats is the execution statistics of a single opcode.
+type OpStats struct {
+	Count uint64 // Number of times the opcode was executed
+	Gas   uint64 // Gas consumed by the executions, excluding gas forwarded to calls
+}
+
+// ContractStats is the execution statistics of the code of a single contract.
+type ContractStats struct {
+	Calls uint64 // Number of times the code was entered
+	Ops   uint64 // Number of opcodes executed
+	Gas   uint64 // Gas consumed by the opcodes, excluding gas forwarded to calls
+}
+
+// Profile aggregates the opcodes executed by the interpreter and the gas they
+// consumed, both per opcode and per contract code. It is not safe for concurrent
+// use, a profile is meant to be filled by the processing of a single block.
+type Profile struct {
+	Ops       [256]OpStats
+	Contracts map[common.Address]*ContractStats
+}
+
+// NewProfile creates an empty execution profile.
+func NewProfile() *Profile {
+	return &Profile{Contracts: make(map[common.Address]*ContractStats)}
+}
+
+// contract retrieves the statistics of a contract code, creating them if needed.
+func (p *Profile) contract(addr common.Address) *ContractStats {
+	stats := p.Contracts[addr]
+	if stats == nil {
+		stats = new(ContractStats)
+		p.Contracts[addr] = stats
+	}
+	return stats
+}
+
+// Merge adds the statistics of another profile into this one.
+func (p *Profile) Merge(other *Profile) {
+	for op := range other.Ops {
+		p.Ops[op].Count += other.Ops[op].Count
+		p.Ops[op].Gas += other.Ops[op].Gas
+	}
+	for addr, stats := range other.Contracts {
+		merged := p.contract(addr)
+		merged.Calls += stats.Calls
+		merged.Ops += stats.Ops
+		merged.Gas += stats.Gas
+	}
+}
+
+// profiledBlock is the execution profile of a block in a profile window.
+type profiledBlock struct {
+	number  uint64
+	elapsed time.Duration
+	profile *Profile
+}
+
+// ProfileWindow retains the execution profiles of a rolling window of recently
+// processed blocks. It is safe for concurrent use.
+type ProfileWindow struct {
+	blocks []profiledBlock // Ring buffer of the profiled blocks
+	next   int             // Index of the slot the next block is stored in
+	lock   sync.RWMutex
+}
+
+// NewProfileWindow creates a profile window retaining the given number of blocks.
+func NewProfileWindow(size int) *ProfileWindow {
+	return &ProfileWindow{blocks: make([]profiledBlock, 0, size)}
+}
+
+// Add records the execution profile of a processed block, evicting the oldest
+// block if the window is full. The opcode statistics are also reported to the
+// metrics system if enabled.
+func (w *ProfileWindow) Add(number uint64, elapsed time.Duration, profile *Profile) {
+	if metrics.Enabled {
+		for op, stats := range profile.Ops {
+			if stats.Count > 0 {
+				name := OpCode(op).String()
+				metrics.GetOrRegisterCounter("vm/op/"+name+"/count", nil).Inc(int64(stats.Count))
+				metrics.GetOrRegisterCounter("vm/op/"+name+"/gas", nil).Inc(int64(stats.Gas))
+			}
+		}
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	block := profiledBlock{number: number, elapsed: elapsed, profile: profile}
+	if len(w.blocks) < cap(w.blocks) {
+		w.blocks = append(w.blocks, block)
+	} else {
+		w.blocks[w.next] = block
+	}
+	w.next = (w.next + 1) % cap(w.blocks)
+}
+
+// ProfileSummary is the merged execution profile of the blocks in a window.
+type ProfileSummary struct {
+	First, Last uint64        // Lowest and highest block numbers in the window
+	Blocks      int           // Number of blocks profiled
+	Elapsed     time.Duration // Total processing time of the blocks
+	Profile     *Profile      // Merged execution profile of the blocks
+}
+
+// Summary merges the execution profiles of the blocks in the window.
+func (w *ProfileWindow) Summary() *ProfileSummary {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	summary := &ProfileSummary{Blocks: len(w.blocks), Profile: NewProfile()}
+	for i, block := range w.blocks {
+		if i == 0 || block.number < summary.First {
+			summary.First = block.number
+		}
+		if i == 0 || block.number > summary.Last {
+			summary.Last = block.number
+		}
+		summary.Elapsed += block.elapsed
+		summary.Profile.Merge(block.profile)
+	}
+	return summary
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// Tests that the profile window only retains the most recent blocks.
+func TestProfileWindow(t *testing.T) {
+	window := NewProfileWindow(3)
+	for number := uint64(1); number <= 5; number++ {
+		profile := NewProfile()
+		profile.Ops[ADD] = OpStats{Count: number, Gas: 3 * number}
+		profile.contract(common.Address{byte(number % 2)}).Gas += number
+
+		window.Add(number, time.Millisecond, profile)
+	}
+	summary := window.Summary()
+	if summary.First != 3 || summary.Last != 5 || summary.Blocks != 3 {
+		t.Fatalf("window mismatch: have #%d-#%d (%d blocks), want #3-#5 (3 blocks)", summary.First, summary.Last, summary.Blocks)
+	}
+	if summary.Elapsed != 3*time.Millisecond {
+		t.Errorf("elapsed time mismatch: have %v, want %v", summary.Elapsed, 3*time.Millisecond)
+	}
+	if stats := summary.Profile.Ops[ADD]; stats.Count != 12 || stats.Gas != 36 {
+		t.Errorf("ADD stats mismatch: have %+v, want 12 executions for 36 gas", stats)
+	}
+	if gas := summary.Profile.Contracts[common.Address{1}].Gas; gas != 8 {
+		t.Errorf("odd contract gas mismatch: have %d, want 8", gas)
+	}
+	if gas := summary.Profile.Contracts[common.Address{0}].Gas; gas != 4 {
+		t.Errorf("even contract gas mismatch: have %d, want 4", gas)
+	}
+}
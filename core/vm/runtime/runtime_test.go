@@ -149,3 +149,32 @@ func BenchmarkCall(b *testing.B) {
 		}
 	}
 }
+
+func TestProfile(t *testing.T) {
+	profile := vm.NewProfile()
+	_, _, err := Execute([]byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}, nil, &Config{EVMConfig: vm.Config{Profile: profile}})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if stats := profile.Ops[vm.PUSH1]; stats.Count != 4 || stats.Gas != 12 {
+		t.Errorf("PUSH1 stats mismatch: have %+v, want 4 executions for 12 gas", stats)
+	}
+	if stats := profile.Ops[vm.MSTORE]; stats.Count != 1 {
+		t.Errorf("MSTORE count mismatch: have %d, want 1", stats.Count)
+	}
+	if len(profile.Contracts) != 1 {
+		t.Fatalf("contract count mismatch: have %d, want 1", len(profile.Contracts))
+	}
+	for addr, stats := range profile.Contracts {
+		if stats.Calls != 1 || stats.Ops != 6 {
+			t.Errorf("contract %x stats mismatch: have %+v, want 1 call of 6 ops", addr, stats)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package faucet
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// DefaultConfig contains default settings for the faucet.
+var DefaultConfig = Config{
+	Host:   "localhost",
+	Port:   8088,
+	Amount: big.NewInt(1000000000000000000), // 1 ether
+	Period: 24 * time.Hour,
+}
+
+// Config contains the configuration parameters of the faucet.
+type Config struct {
+	// Host is the host interface on which to start the faucet's HTTP endpoint.
+	Host string `toml:",omitempty"`
+
+	// Port is the TCP port number on which to start the faucet's HTTP endpoint.
+	Port int `toml:",omitempty"`
+
+	// Account is the keystore account funding the requests. It must be known to
+	// the node's account manager.
+	Account common.Address
+
+	// PasswordFile is the file containing the passphrase of the funding account.
+	PasswordFile string `toml:",omitempty"`
+
+	// Amount is the number of wei sent for each accepted request.
+	Amount *big.Int `toml:",omitempty"`
+
+	// Period is the time an address has to wait between two funding requests.
+	Period time.Duration `toml:",omitempty"`
+
+	// Captcha is the reCaptcha secret used to verify the requests. If empty, no
+	// captcha is required.
+	Captcha string `toml:",omitempty"`
+
+	// Webhook is an URL consulted before funding each request with a POST of the
+	// requested address and the requester's IP. Any non-2xx response rejects the
+	// request. If empty, no webhook is consulted.
+	Webhook string `toml:",omitempty"`
+}
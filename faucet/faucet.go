@@ -0,0 +1,309 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package faucet implements a service funding testnet accounts over HTTP from a
+// keystore account of the node.
+package faucet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// captchaVerifyURL is the endpoint verifying the reCaptcha responses.
+const captchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+var (
+	errInvalidAddress  = errors.New("invalid address")
+	errCaptchaFailed   = errors.New("captcha verification failed")
+	errWebhookRejected = errors.New("request rejected")
+)
+
+// Backend is the node functionality needed by the faucet, implemented by the
+// full gdachain service.
+type Backend interface {
+	AccountManager() *accounts.Manager
+	BlockChain() *core.BlockChain
+	TxPool() *core.TxPool
+}
+
+// Status is the public state of the faucet reported to HTTP GET requests.
+type Status struct {
+	Account common.Address `json:"account"`
+	Balance *big.Int       `json:"balance"`
+	Amount  *big.Int       `json:"amount"`
+	Period  string         `json:"period"`
+}
+
+// Faucet is a node service funding the addresses requested over HTTP, at most
+// once per configured period for each address.
+type Faucet struct {
+	config  *Config
+	backend Backend
+
+	account    accounts.Account
+	wallet     accounts.Wallet
+	passphrase string
+
+	fund   func(common.Address) (common.Hash, error) // Funds an address, replaceable for testing
+	status func() Status                             // Reports the faucet status, replaceable for testing
+	client *http.Client                              // Client verifying the captchas and consulting the webhook
+
+	funded map[common.Address]time.Time // Time each address was last funded
+	lock   sync.Mutex                   // Serializes the funding transactions
+
+	listener net.Listener
+}
+
+// New creates a faucet funding requests from the configured keystore account.
+func New(config *Config, backend Backend) (*Faucet, error) {
+	if backend == nil {
+		return nil, errors.New("faucet requires a full node")
+	}
+	if config.Amount == nil || config.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid faucet amount: %v", config.Amount)
+	}
+	account := accounts.Account{Address: config.Account}
+	wallet, err := backend.AccountManager().Find(account)
+	if err != nil {
+		return nil, fmt.Errorf("faucet account %x: %v", config.Account, err)
+	}
+	var passphrase string
+	if config.PasswordFile != "" {
+		blob, err := ioutil.ReadFile(config.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read faucet password file: %v", err)
+		}
+		passphrase = strings.TrimRight(string(blob), "\r\n")
+	}
+	f := newFaucet(config)
+	f.backend = backend
+	f.account, f.wallet, f.passphrase = account, wallet, passphrase
+	f.fund, f.status = f.transfer, f.currentStatus
+	return f, nil
+}
+
+// newFaucet creates a faucet without any funding backend.
+func newFaucet(config *Config) *Faucet {
+	return &Faucet{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		funded: make(map[common.Address]time.Time),
+	}
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the faucet (nil as it doesn't use the devp2p overlay network).
+func (f *Faucet) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// faucet (nil as it doesn't provide any user callable APIs).
+func (f *Faucet) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting the faucet's HTTP endpoint.
+func (f *Faucet) Start(server *p2p.Server) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", f.config.Host, f.config.Port))
+	if err != nil {
+		return err
+	}
+	f.listener = listener
+	go http.Serve(listener, f)
+
+	log.Info("Faucet started", "url", fmt.Sprintf("http://%s", listener.Addr()), "account", f.config.Account, "amount", f.config.Amount)
+	return nil
+}
+
+// Stop implements node.Service, stopping the faucet's HTTP endpoint.
+func (f *Faucet) Stop() error {
+	if f.listener != nil {
+		f.listener.Close()
+	}
+	log.Info("Faucet stopped")
+	return nil
+}
+
+// ServeHTTP reports the faucet status to GET requests and funds the address of
+// POST requests, given as the "address" form value along with the "captcha"
+// response if captchas are required.
+func (f *Faucet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		reply(w, http.StatusOK, f.status())
+
+	case http.MethodPost:
+		hash, code, err := f.request(r)
+		if err != nil {
+			reply(w, code, map[string]string{"error": err.Error()})
+			return
+		}
+		reply(w, http.StatusOK, map[string]common.Hash{"tx": hash})
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// request verifies and funds a single request, returning the HTTP status code
+// to reply with on failure.
+func (f *Faucet) request(r *http.Request) (common.Hash, int, error) {
+	addr := r.FormValue("address")
+	if !common.IsHexAddress(addr) {
+		return common.Hash{}, http.StatusBadRequest, errInvalidAddress
+	}
+	address := common.HexToAddress(addr)
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	if f.config.Captcha != "" {
+		if err := f.verifyCaptcha(r.FormValue("captcha"), ip); err != nil {
+			return common.Hash{}, http.StatusForbidden, err
+		}
+	}
+	if f.config.Webhook != "" {
+		if err := f.consultWebhook(address, ip); err != nil {
+			return common.Hash{}, http.StatusForbidden, err
+		}
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	now := time.Now()
+	if last, ok := f.funded[address]; ok && now.Sub(last) < f.config.Period {
+		wait := f.config.Period - now.Sub(last)
+		return common.Hash{}, http.StatusTooManyRequests, fmt.Errorf("address funded recently, retry in %v", common.PrettyDuration(wait))
+	}
+	hash, err := f.fund(address)
+	if err != nil {
+		log.Warn("Failed to fund faucet request", "address", address, "err", err)
+		return common.Hash{}, http.StatusInternalServerError, err
+	}
+	// Record the request and forget the addresses allowed to request again
+	f.funded[address] = now
+	for addr, last := range f.funded {
+		if now.Sub(last) >= f.config.Period {
+			delete(f.funded, addr)
+		}
+	}
+	log.Info("Funded faucet request", "address", address, "ip", ip, "tx", hash)
+	return hash, 0, nil
+}
+
+// verifyCaptcha checks a reCaptcha response with the verification service.
+func (f *Faucet) verifyCaptcha(response, ip string) error {
+	form := url.Values{}
+	form.Add("secret", f.config.Captcha)
+	form.Add("response", response)
+	form.Add("remoteip", ip)
+
+	res, err := f.client.PostForm(captchaVerifyURL, form)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return errCaptchaFailed
+	}
+	return nil
+}
+
+// consultWebhook asks the configured webhook whether a request may be funded.
+func (f *Faucet) consultWebhook(address common.Address, ip string) error {
+	blob, err := json.Marshal(map[string]string{"address": address.Hex(), "ip": ip})
+	if err != nil {
+		return err
+	}
+	res, err := f.client.Post(f.config.Webhook, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errWebhookRejected
+	}
+	return nil
+}
+
+// transfer sends the configured amount from the faucet account to an address,
+// signing the transaction with the keystore. It must be called with the lock
+// held to serialize the nonces.
+func (f *Faucet) transfer(to common.Address) (common.Hash, error) {
+	var (
+		chain = f.backend.BlockChain()
+		pool  = f.backend.TxPool()
+	)
+	nonce := pool.State().GetNonce(f.account.Address)
+	tx := types.NewTransaction(nonce, to, f.config.Amount, params.TxGas, pool.GasPrice(), nil)
+
+	var chainID *big.Int
+	if config := chain.Config(); config.IsEIP155(chain.CurrentBlock().Number()) {
+		chainID = config.ChainId
+	}
+	signed, err := f.wallet.SignTxWithPassphrase(f.account, f.passphrase, tx, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := pool.AddLocal(signed); err != nil {
+		return common.Hash{}, err
+	}
+	return signed.Hash(), nil
+}
+
+// currentStatus reports the funding account, its balance and the request terms.
+func (f *Faucet) currentStatus() Status {
+	var balance *big.Int
+	if state, err := f.backend.BlockChain().State(); err == nil {
+		balance = state.GetBalance(f.account.Address)
+	}
+	return Status{
+		Account: f.config.Account,
+		Balance: balance,
+		Amount:  f.config.Amount,
+		Period:  f.config.Period.String(),
+	}
+}
+
+// reply writes a JSON encoded response.
+func reply(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(value)
+}
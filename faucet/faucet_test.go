@@ -0,0 +1,86 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package faucet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// Tests that requests are funded at most once per period for each address, and
+// that the webhook can veto requests.
+func TestFaucetRequests(t *testing.T) {
+	var allow = true
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allow {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer hook.Close()
+
+	config := DefaultConfig
+	config.Period = time.Hour
+	config.Webhook = hook.URL
+
+	var funded []common.Address
+	f := newFaucet(&config)
+	f.fund = func(addr common.Address) (common.Hash, error) {
+		funded = append(funded, addr)
+		return common.Hash{byte(len(funded))}, nil
+	}
+	request := func(addr string) (int, map[string]string) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"address": {addr}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rec := httptest.NewRecorder()
+		f.ServeHTTP(rec, req)
+
+		var result map[string]string
+		json.NewDecoder(rec.Body).Decode(&result)
+		return rec.Code, result
+	}
+	addr := common.Address{0x01}
+	if code, result := request(addr.Hex()); code != http.StatusOK || result["tx"] != (common.Hash{0x01}).Hex() {
+		t.Fatalf("first request mismatch: code %d, result %v", code, result)
+	}
+	if code, _ := request(addr.Hex()); code != http.StatusTooManyRequests {
+		t.Fatalf("repeated request code mismatch: have %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code, _ := request("not an address"); code != http.StatusBadRequest {
+		t.Fatalf("invalid request code mismatch: have %d, want %d", code, http.StatusBadRequest)
+	}
+	allow = false
+	if code, _ := request(common.Address{0x02}.Hex()); code != http.StatusForbidden {
+		t.Fatalf("vetoed request code mismatch: have %d, want %d", code, http.StatusForbidden)
+	}
+	if len(funded) != 1 || funded[0] != addr {
+		t.Fatalf("funded addresses mismatch: have %v, want %v", funded, []common.Address{addr})
+	}
+	// Addresses may request again once the period elapsed
+	f.funded[addr] = time.Now().Add(-config.Period)
+	allow = true
+	if code, _ := request(addr.Hex()); code != http.StatusOK {
+		t.Fatalf("request after period code mismatch: have %d, want %d", code, http.StatusOK)
+	}
+}
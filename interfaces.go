@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core/types"
@@ -103,6 +104,13 @@ type SyncProgress struct {
 	HighestBlock  uint64 // Highest alleged block number in the chain
 	PulledStates  uint64 // Number of state trie entries already downloaded
 	KnownStates   uint64 // Total number of state trie entries known about
+
+	PulledHeaders  uint64        // Number of headers processed in the current sync cycle
+	PulledBodies   uint64        // Number of block bodies imported in the current sync cycle
+	PulledReceipts uint64        // Number of block receipts imported in the current sync cycle
+	QueuedBodies   uint64        // Number of block bodies scheduled but not yet retrieved
+	QueuedReceipts uint64        // Number of block receipts scheduled but not yet retrieved
+	ETA            time.Duration // Estimated time until the chain is caught up, zero if unknown
 }
 
 // ChainSyncReader wraps access to the node's current sync status. If there's no
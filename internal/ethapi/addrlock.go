@@ -17,14 +17,19 @@
 package ethapi
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 )
 
+// AddrLocker serializes nonce assignment per account and keeps track of nonces
+// handed out to external signers that have not yet reached the transaction pool.
 type AddrLocker struct {
-	mu    sync.Mutex
-	locks map[common.Address]*sync.Mutex
+	mu           sync.Mutex
+	locks        map[common.Address]*sync.Mutex
+	reservations map[common.Address]map[uint64]time.Time // Reserved nonces and their expiry
 }
 
 // lock returns the lock of the given address.
@@ -51,3 +56,79 @@ func (l *AddrLocker) LockAddr(address common.Address) {
 func (l *AddrLocker) UnlockAddr(address common.Address) {
 	l.lock(address).Unlock()
 }
+
+// reserved reports whether the nonce of the given account is reserved, dropping
+// expired reservations and those already consumed by the transaction pool.
+func (l *AddrLocker) reserved(address common.Address, poolNonce uint64, nonce uint64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	nonces := l.reservations[address]
+	for n, expiry := range nonces {
+		if n < poolNonce || now.After(expiry) {
+			delete(nonces, n)
+		}
+	}
+	if len(nonces) == 0 {
+		delete(l.reservations, address)
+		return false
+	}
+	_, ok := nonces[nonce]
+	return ok
+}
+
+// nextNonce returns the lowest nonce of the given account that is neither used
+// by a pending transaction, nor reserved. The caller needs to hold the account's
+// lock.
+func (l *AddrLocker) nextNonce(ctx context.Context, b Backend, address common.Address) (uint64, error) {
+	poolNonce, err := b.GetPoolNonce(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	nonce := poolNonce
+	for l.reserved(address, poolNonce, nonce, now) {
+		nonce++
+	}
+	return nonce, nil
+}
+
+// reserveNonce reserves the next free nonce of the given account until the ttl
+// expires or a transaction with that nonce enters the transaction pool.
+func (l *AddrLocker) reserveNonce(ctx context.Context, b Backend, address common.Address, ttl time.Duration) (uint64, time.Time, error) {
+	l.LockAddr(address)
+	defer l.UnlockAddr(address)
+
+	nonce, err := l.nextNonce(ctx, b, address)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	expiry := time.Now().Add(ttl)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.reservations == nil {
+		l.reservations = make(map[common.Address]map[uint64]time.Time)
+	}
+	if l.reservations[address] == nil {
+		l.reservations[address] = make(map[uint64]time.Time)
+	}
+	l.reservations[address][nonce] = expiry
+	return nonce, expiry, nil
+}
+
+// releaseNonce drops the reservation of a nonce, returning whether it existed.
+func (l *AddrLocker) releaseNonce(address common.Address, nonce uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	nonces := l.reservations[address]
+	if _, ok := nonces[nonce]; !ok {
+		return false
+	}
+	delete(nonces, nonce)
+	if len(nonces) == 0 {
+		delete(l.reservations, address)
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// nonceBackend is a backend only reporting the pool nonce of accounts.
+type nonceBackend struct {
+	Backend
+	nonce uint64
+}
+
+func (b *nonceBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return b.nonce, nil
+}
+
+// Tests that reserved nonces are skipped until they expire, are released or are
+// consumed by the transaction pool.
+func TestNonceReservation(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		backend = &nonceBackend{nonce: 3}
+		locker  = new(AddrLocker)
+		addr    = common.Address{0x01}
+	)
+	for want := uint64(3); want < 6; want++ {
+		nonce, _, err := locker.reserveNonce(ctx, backend, addr, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to reserve nonce: %v", err)
+		}
+		if nonce != want {
+			t.Fatalf("reserved nonce mismatch: have %d, want %d", nonce, want)
+		}
+	}
+	// Other accounts are not affected by the reservations
+	if nonce, _ := locker.nextNonce(ctx, backend, common.Address{0x02}); nonce != 3 {
+		t.Fatalf("unrelated nonce mismatch: have %d, want %d", nonce, 3)
+	}
+	// Released nonces become available again
+	if !locker.releaseNonce(addr, 4) {
+		t.Fatalf("failed to release reserved nonce")
+	}
+	if locker.releaseNonce(addr, 4) {
+		t.Fatalf("released nonce twice")
+	}
+	if nonce, _ := locker.nextNonce(ctx, backend, addr); nonce != 4 {
+		t.Fatalf("nonce after release mismatch: have %d, want %d", nonce, 4)
+	}
+	// Nonces consumed by the pool are dropped from the reservations
+	backend.nonce = 5
+	if nonce, _ := locker.nextNonce(ctx, backend, addr); nonce != 6 {
+		t.Fatalf("nonce after pool update mismatch: have %d, want %d", nonce, 6)
+	}
+	// Expired reservations are dropped
+	if _, _, err := locker.reserveNonce(ctx, backend, addr, -time.Second); err != nil {
+		t.Fatalf("failed to reserve nonce: %v", err)
+	}
+	backend.nonce = 6
+	if nonce, _ := locker.nextNonce(ctx, backend, addr); nonce != 6 {
+		t.Fatalf("nonce after expiry mismatch: have %d, want %d", nonce, 6)
+	}
+}
@@ -40,12 +40,22 @@ import (
 	"github.com/gdachain/go-gdachain/params"
 	"github.com/gdachain/go-gdachain/rlp"
 	"github.com/gdachain/go-gdachain/rpc"
+	signercore "github.com/gdachain/go-gdachain/signer/core"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
 	defaultGasPrice = 50 * params.Shannon
+
+	// defaultNonceReservation is the time a reserved nonce is held if the caller
+	// does not specify otherwise, maxNonceReservation the longest allowed.
+	defaultNonceReservation = time.Minute
+	maxNonceReservation     = 10 * time.Minute
+
+	// maxBatchReads is the maximum number of accounts or storage slots that can
+	// be read from the state in a single batch request.
+	maxBatchReads = 10000
 )
 
 // PublicgdachainAPI provides an API to access gdachain related information.
@@ -76,6 +86,12 @@ func (s *PublicgdachainAPI) ProtocolVersion() hexutil.Uint {
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// - pulledHeaders:  number of headers processed in the current sync cycle
+// - pulledBodies:   number of block bodies imported in the current sync cycle
+// - pulledReceipts: number of block receipts imported in the current sync cycle
+// - queuedBodies:   number of block bodies scheduled but not yet retrieved
+// - queuedReceipts: number of block receipts scheduled but not yet retrieved
+// - eta:            estimated number of seconds until the chain is caught up (0 if unknown)
 func (s *PublicgdachainAPI) Syncing() (interface{}, error) {
 	progress := s.b.Downloader().Progress()
 
@@ -85,11 +101,17 @@ func (s *PublicgdachainAPI) Syncing() (interface{}, error) {
 	}
 	// Otherwise gather the block sync stats
 	return map[string]interface{}{
-		"startingBlock": hexutil.Uint64(progress.StartingBlock),
-		"currentBlock":  hexutil.Uint64(progress.CurrentBlock),
-		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
-		"pulledStates":  hexutil.Uint64(progress.PulledStates),
-		"knownStates":   hexutil.Uint64(progress.KnownStates),
+		"startingBlock":  hexutil.Uint64(progress.StartingBlock),
+		"currentBlock":   hexutil.Uint64(progress.CurrentBlock),
+		"highestBlock":   hexutil.Uint64(progress.HighestBlock),
+		"pulledStates":   hexutil.Uint64(progress.PulledStates),
+		"knownStates":    hexutil.Uint64(progress.KnownStates),
+		"pulledHeaders":  hexutil.Uint64(progress.PulledHeaders),
+		"pulledBodies":   hexutil.Uint64(progress.PulledBodies),
+		"pulledReceipts": hexutil.Uint64(progress.PulledReceipts),
+		"queuedBodies":   hexutil.Uint64(progress.QueuedBodies),
+		"queuedReceipts": hexutil.Uint64(progress.QueuedReceipts),
+		"eta":            hexutil.Uint64(progress.ETA / time.Second),
 	}, nil
 }
 
@@ -174,6 +196,63 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCDroppedTransaction is the notification of a transaction that was removed
+// from the transaction pool without being included in a block.
+type RPCDroppedTransaction struct {
+	Hash        common.Hash    `json:"hash"`
+	From        common.Address `json:"from"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Reason      string         `json:"reason"`
+	Replacement *common.Hash   `json:"replacement,omitempty"`
+}
+
+// Dropped creates a subscription that is triggered each time a transaction is
+// evicted from the pool, replaced by another one with the same nonce, or
+// otherwise removed from the pool without being mined.
+func (s *PublicTxPoolAPI) Dropped(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		drops := make(chan core.TxDropEvent, 128)
+		sub := s.b.SubscribeTxDropEvent(drops)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-drops:
+				var signer types.Signer = types.FrontierSigner{}
+				if ev.Tx.Protected() {
+					signer = types.NewEIP155Signer(ev.Tx.ChainId())
+				}
+				from, _ := types.Sender(signer, ev.Tx)
+
+				dropped := &RPCDroppedTransaction{
+					Hash:   ev.Tx.Hash(),
+					From:   from,
+					Nonce:  hexutil.Uint64(ev.Tx.Nonce()),
+					Reason: string(ev.Reason),
+				}
+				if ev.Reason == core.TxDropReplaced {
+					dropped.Replacement = &ev.Replacement
+				}
+				notifier.Notify(rpcSub.ID, dropped)
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -202,6 +281,7 @@ func (s *PublicAccountAPI) Accounts() []common.Address {
 type PrivateAccountAPI struct {
 	am        *accounts.Manager
 	nonceLock *AddrLocker
+	keyOps    *keyThrottle
 	b         Backend
 }
 
@@ -210,6 +290,7 @@ func NewPrivateAccountAPI(b Backend, nonceLock *AddrLocker) *PrivateAccountAPI {
 	return &PrivateAccountAPI{
 		am:        b.AccountManager(),
 		nonceLock: nonceLock,
+		keyOps:    newKeyThrottle(keyOpInterval),
 		b:         b,
 	}
 }
@@ -234,25 +315,40 @@ type rawWallet struct {
 	Accounts []accounts.Account `json:"accounts,omitempty"`
 }
 
+// newRawWallet extracts the current status and accounts of a wallet.
+func newRawWallet(wallet accounts.Wallet) rawWallet {
+	status, failure := wallet.Status()
+
+	raw := rawWallet{
+		URL:      wallet.URL().String(),
+		Status:   status,
+		Accounts: wallet.Accounts(),
+	}
+	if failure != nil {
+		raw.Failure = failure.Error()
+	}
+	return raw
+}
+
 // ListWallets will return a list of wallets this node manages.
 func (s *PrivateAccountAPI) ListWallets() []rawWallet {
 	wallets := make([]rawWallet, 0) // return [] instead of nil if empty
 	for _, wallet := range s.am.Wallets() {
-		status, failure := wallet.Status()
-
-		raw := rawWallet{
-			URL:      wallet.URL().String(),
-			Status:   status,
-			Accounts: wallet.Accounts(),
-		}
-		if failure != nil {
-			raw.Failure = failure.Error()
-		}
-		wallets = append(wallets, raw)
+		wallets = append(wallets, newRawWallet(wallet))
 	}
 	return wallets
 }
 
+// WalletStatus returns the status and pinned accounts of a single wallet, e.g.
+// to check whgdaer a hardware wallet is unlocked and ready to sign.
+func (s *PrivateAccountAPI) WalletStatus(url string) (rawWallet, error) {
+	wallet, err := s.am.Wallet(url)
+	if err != nil {
+		return rawWallet{}, err
+	}
+	return newRawWallet(wallet), nil
+}
+
 // OpenWallet initiates a hardware wallet opening procedure, establishing a USB
 // connection and attempting to authenticate via the provided passphrase. Note,
 // the method may return an extra challenge requiring a second open (e.g. the
@@ -270,15 +366,19 @@ func (s *PrivateAccountAPI) OpenWallet(url string, passphrase *string) error {
 }
 
 // DeriveAccount requests a HD wallet to derive a new account, optionally pinning
-// it for later reuse.
-func (s *PrivateAccountAPI) DeriveAccount(url string, path string, pin *bool) (accounts.Account, error) {
+// it for later reuse. Paths may be absolute (m/44'/60'/0'/0/1) or relative to
+// the default root (0/1). If no path is given, the first account on the default
+// base path of the wallet is derived.
+func (s *PrivateAccountAPI) DeriveAccount(url string, path *string, pin *bool) (accounts.Account, error) {
 	wallet, err := s.am.Wallet(url)
 	if err != nil {
 		return accounts.Account{}, err
 	}
-	derivPath, err := accounts.ParseDerivationPath(path)
-	if err != nil {
-		return accounts.Account{}, err
+	derivPath := accounts.DefaultBaseDerivationPathFor(wallet.URL().Scheme)
+	if path != nil && *path != "" {
+		if derivPath, err = accounts.ParseDerivationPath(*path); err != nil {
+			return accounts.Account{}, err
+		}
 	}
 	if pin == nil {
 		pin = new(bool)
@@ -347,6 +447,9 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 	if err := args.setDefaults(ctx, s.b); err != nil {
 		return nil, err
 	}
+	if err := args.checkTxFee(s.b.RPCTxFeeCap()); err != nil {
+		return nil, err
+	}
 	// Assemble the transaction and sign with the wallet
 	tx := args.toTransaction()
 
@@ -366,6 +469,12 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 		// the same nonce to multiple accounts.
 		s.nonceLock.LockAddr(args.From)
 		defer s.nonceLock.UnlockAddr(args.From)
+
+		nonce, err := s.nonceLock.nextNonce(ctx, s.b, args.From)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
 	}
 	signed, err := s.signTransaction(ctx, args, passwd)
 	if err != nil {
@@ -439,6 +548,33 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// SignTypedData calculates an ECDSA signature over typed structured data (EIP-712):
+// keccak256("\x19\x01" + hashStruct(domain) + hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The key used to calculate the signature is decrypted with the given password.
+func (s *PrivateAccountAPI) SignTypedData(ctx context.Context, addr common.Address, typedData signercore.TypedData, passwd string) (hexutil.Bytes, error) {
+	hash, err := typedData.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := wallet.SignHashWithPassphrase(account, passwd, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
@@ -529,6 +665,15 @@ func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash comm
 	return nil, err
 }
 
+// GetTdByHash returns the total difficulty of the chain up to and including the
+// requested block, or nil if the block is unknown.
+func (s *PublicBlockChainAPI) GetTdByHash(ctx context.Context, blockHash common.Hash) *hexutil.Big {
+	if td := s.b.GetTd(blockHash); td != nil {
+		return (*hexutil.Big)(td)
+	}
+	return nil
+}
+
 // GetUncleByBlockNumberAndIndex returns the uncle block for the given block hash and index. When fullTx is true
 // all transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) (map[string]interface{}, error) {
@@ -589,6 +734,18 @@ func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Addres
 	return code, state.Error()
 }
 
+// GetCodeHash returns the hash of the code stored at the given address in the
+// state for the given block number. Accounts without code report the hash of
+// the empty code, missing accounts the zero hash.
+func (s *PublicBlockChainAPI) GetCodeHash(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (common.Hash, error) {
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return common.Hash{}, err
+	}
+	hash := state.GetCodeHash(address)
+	return hash, state.Error()
+}
+
 // GegdaorageAt returns the storage from the state at the given address, key and
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
@@ -601,6 +758,53 @@ func (s *PublicBlockChainAPI) GegdaorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// GetStorageSlots returns the values of multiple storage slots of an account from
+// the state at the given block number, read in a single request.
+func (s *PublicBlockChainAPI) GetStorageSlots(ctx context.Context, address common.Address, keys []string, blockNr rpc.BlockNumber) ([]common.Hash, error) {
+	if len(keys) > maxBatchReads {
+		return nil, fmt.Errorf("too many storage slots requested: %d, limit %d", len(keys), maxBatchReads)
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	values := make([]common.Hash, len(keys))
+	for i, key := range keys {
+		values[i] = state.Gegdaate(address, common.HexToHash(key))
+	}
+	return values, state.Error()
+}
+
+// AccountState is the balance, nonce and code hash of an account.
+type AccountState struct {
+	Address  common.Address `json:"address"`
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// GetAccounts returns the balance, nonce and code hash of multiple accounts from
+// the state at the given block number, read in a single request.
+func (s *PublicBlockChainAPI) GetAccounts(ctx context.Context, addresses []common.Address, blockNr rpc.BlockNumber) ([]*AccountState, error) {
+	if len(addresses) > maxBatchReads {
+		return nil, fmt.Errorf("too many accounts requested: %d, limit %d", len(addresses), maxBatchReads)
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	accounts := make([]*AccountState, len(addresses))
+	for i, address := range addresses {
+		accounts[i] = &AccountState{
+			Address:  address,
+			Balance:  (*hexutil.Big)(state.GetBalance(address)),
+			Nonce:    hexutil.Uint64(state.GetNonce(address)),
+			CodeHash: state.GetCodeHash(address),
+		}
+	}
+	return accounts, state.Error()
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -611,33 +815,63 @@ type CallArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+// callResult is the outcome of executing a call against the state.
+type callResult struct {
+	ret     []byte // Data returned by the EVM, the revert reason if it reverted
+	usedGas uint64 // Gas used by the call, refunds already deducted
+	vmErr   error  // Error the EVM aborted the execution with, if any
+}
+
+// failed reports whether the EVM aborted the execution of the call.
+func (r *callResult) failed() bool {
+	return r.vmErr != nil
+}
+
+// callSender returns the sender of a call, defaulting to the first account of
+// the first wallet if none was specified.
+func (s *PublicBlockChainAPI) callSender(args CallArgs) common.Address {
+	if args.From != (common.Address{}) {
+		return args.From
+	}
+	if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+		if accounts := wallets[0].Accounts(); len(accounts) > 0 {
+			return accounts[0].Address
+		}
+	}
+	return common.Address{}
+}
+
+// callGasPrice returns the gas price of a call, defaulting to defaultGasPrice
+// if none was specified.
+func callGasPrice(args CallArgs) *big.Int {
+	if price := args.GasPrice.ToInt(); price.Sign() != 0 {
+		return price
+	}
+	return new(big.Int).SetUint64(defaultGasPrice)
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) (*callResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
-		return nil, 0, false, err
-	}
-	// Set sender address or use a default if none specified
-	addr := args.From
-	if addr == (common.Address{}) {
-		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
-			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
-				addr = accounts[0].Address
-			}
-		}
+		return nil, err
 	}
-	// Set default gas & gas price if none were set
-	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
+	// Set default gas if none was set, and cap it to the node's allowance
+	gas := uint64(args.Gas)
 	if gas == 0 {
 		gas = math.MaxUint64 / 2
 	}
-	if gasPrice.Sign() == 0 {
-		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	if cap := s.b.RPCGasCap(); cap != 0 && gas > cap {
+		log.Debug("Capping call gas to the RPC limit", "requested", gas, "cap", cap)
+		gas = cap
+	}
+	// Bound the memory the execution may allocate, unless explicitly configured
+	if vmCfg.MemoryLimit == 0 {
+		vmCfg.MemoryLimit = s.b.RPCEVMMemory()
 	}
-
 	// Create new call message
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	msg := types.NewMessage(s.callSender(args), args.To, 0, args.Value.ToInt(), gas, callGasPrice(args), args.Data, false)
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -654,7 +888,7 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Get a new instance of the EVM.
 	evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vmCfg)
 	if err != nil {
-		return nil, 0, false, err
+		return nil, err
 	}
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -666,29 +900,93 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	res, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	st := core.NewStateTransition(evm, msg, gp)
+
+	res, gas, _, err := st.TransitionDb()
 	if err := vmError(); err != nil {
-		return nil, 0, false, err
+		return nil, err
+	}
+	// If the timer caused an abort, return an appropriate error message
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	}
+	if err != nil {
+		return nil, err
 	}
-	return res, gas, failed, err
+	return &callResult{ret: res, usedGas: gas, vmErr: st.VMError()}, nil
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
-	return (hexutil.Bytes)(result), err
+	result, err := s.doCall(ctx, args, blockNr, vm.Config{}, s.b.RPCEVMTimeout())
+	if err != nil {
+		return nil, err
+	}
+	// Surface reverts as errors, carrying the revert data and decoded reason
+	if result.vmErr == vm.ErrExecutionReverted {
+		return nil, &RevertError{Data: result.ret}
+	}
+	if result.vmErr == vm.ErrMemoryLimitExceeded {
+		return nil, result.vmErr
+	}
+	return (hexutil.Bytes)(result.ret), nil
+}
+
+// revertSelector is the selector of the Error(string) revert reason emitted by
+// Solidity's require and revert statements.
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// unpackRevert extracts the message of an ABI encoded Error(string) revert
+// reason, returning false if the data is not one.
+func unpackRevert(data []byte) (string, bool) {
+	if len(data) < 4+64 || !bytes.Equal(data[:4], revertSelector) {
+		return "", false
+	}
+	data = data[4:]
+
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data)-32) {
+		return "", false
+	}
+	start := offset.Uint64() + 32
+	size := new(big.Int).SetBytes(data[start-32 : start])
+	if !size.IsUint64() || size.Uint64() > uint64(len(data))-start {
+		return "", false
+	}
+	return string(data[start : start+size.Uint64()]), true
+}
+
+// RevertError is returned by calls that revert and by gas estimation if the
+// transaction reverts at any gas allowance, carrying the data returned by the
+// reverting contract.
+type RevertError struct {
+	Data []byte // Data returned by the REVERT opcode
+}
+
+func (e *RevertError) Error() string {
+	if reason, ok := unpackRevert(e.Data); ok {
+		return "execution reverted: " + reason
+	}
+	return "execution reverted"
+}
+
+func (e *RevertError) ErrorCode() int { return 3 }
+
+func (e *RevertError) ErrorData() interface{} {
+	return hexutil.Encode(e.Data)
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
+//
+// The estimate is the lowest gas allowance the transaction executes successfully
+// with, which may be higher than the gas it ends up using due to refunds and the
+// 63/64 rule of calls. If the transaction fails even at the highest allowance, a
+// RevertError carrying the revert data is returned if it reverted.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
-	// Binary search the gas requirement, as it may be higher than the amount used
-	var (
-		lo  uint64 = params.TxGas - 1
-		hi  uint64
-		cap uint64
-	)
+	// Determine the highest gas allowance, capped by what the sender can afford
+	var hi uint64
 	if uint64(args.Gas) >= params.TxGas {
 		hi = uint64(args.Gas)
 	} else {
@@ -699,33 +997,77 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 		}
 		hi = block.GasLimit()
 	}
-	cap = hi
+	if cap := s.b.RPCGasCap(); cap != 0 && hi > cap {
+		log.Debug("Capping gas estimation to the RPC limit", "requested", hi, "cap", cap)
+		hi = cap
+	}
+	state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.PendingBlockNumber)
+	if state == nil || err != nil {
+		return 0, err
+	}
+	balance := state.GetBalance(s.callSender(args))
+	if value := args.Value.ToInt(); value.Sign() > 0 {
+		if balance.Cmp(value) < 0 {
+			return 0, core.ErrInsufficientFunds
+		}
+		balance = new(big.Int).Sub(balance, value)
+	}
+	if allowance := new(big.Int).Div(balance, callGasPrice(args)); allowance.IsUint64() && allowance.Uint64() < hi {
+		log.Debug("Gas estimation capped by sender balance", "original", hi, "balance", balance, "allowance", allowance)
+		hi = allowance.Uint64()
+	}
+	cap := hi
 
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	// Create a helper to check if a gas allowance results in an executable
+	// transaction. Only errors unrelated to the allowance are returned.
+	executable := func(gas uint64) (*callResult, bool, error) {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
-		if err != nil || failed {
-			return false
+		result, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		if err != nil {
+			// Allowances below the intrinsic gas are rejected before execution
+			if err == vm.ErrOutOfGas {
+				return nil, false, nil
+			}
+			return nil, false, err
 		}
-		return true
+		return result, !result.failed(), nil
+	}
+	// Reject the transaction right away if it fails even at the highest allowance
+	if cap < params.TxGas {
+		return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+	}
+	result, ok, err := executable(cap)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if result != nil && result.vmErr == vm.ErrExecutionReverted {
+			return 0, &RevertError{Data: result.ret}
+		}
+		if result != nil && result.vmErr != vm.ErrOutOfGas {
+			return 0, fmt.Errorf("always failing transaction: %v", result.vmErr)
+		}
+		return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
+	}
+	// The allowance must at least cover the gas used, which already has the
+	// refunds deducted, so start the binary search right below it
+	lo, hi := params.TxGas-1, cap
+	if result.usedGas > params.TxGas {
+		lo = result.usedGas - 1
 	}
-	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
-		mid := (hi + lo) / 2
-		if !executable(mid) {
+		mid := lo + (hi-lo)/2
+		_, ok, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
 			lo = mid
 		} else {
 			hi = mid
 		}
 	}
-	// Reject the transaction as invalid if it still fails at the highest allowance
-	if hi == cap {
-		if !executable(hi) {
-			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
-		}
-	}
 	return hexutil.Uint64(hi), nil
 }
 
@@ -1003,28 +1345,28 @@ func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, addr
 }
 
 // GetTransactionByHash returns the transaction for the given hash
-func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) *RPCTransaction {
+func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, hash common.Hash) (*RPCTransaction, error) {
 	// Try to return an already finalized transaction
-	if tx, blockHash, blockNumber, index := core.GetTransaction(s.b.ChainDb(), hash); tx != nil {
-		return newRPCTransaction(tx, blockHash, blockNumber, index)
+	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if tx != nil {
+		return newRPCTransaction(tx, blockHash, blockNumber, index), nil
 	}
 	// No finalized transaction, try to retrieve it from the pool
 	if tx := s.b.GetPoolTransaction(hash); tx != nil {
-		return newRPCPendingTransaction(tx)
+		return newRPCPendingTransaction(tx), nil
 	}
-	// Transaction unknown, return as such
-	return nil
+	// Transaction unknown, return as such, or why it cannot be found
+	return nil, err
 }
 
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
 func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
-	var tx *types.Transaction
-
 	// Retrieve a finalized transaction, or a pooled otherwise
-	if tx, _, _, _ = core.GetTransaction(s.b.ChainDb(), hash); tx == nil {
+	tx, _, _, _, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil {
 		if tx = s.b.GetPoolTransaction(hash); tx == nil {
 			// Transaction not found anywhere, abort
-			return nil, nil
+			return nil, err
 		}
 	}
 	// Serialize to RLP and return
@@ -1033,9 +1375,9 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
-	tx, blockHash, blockNumber, index := core.GetTransaction(s.b.ChainDb(), hash)
+	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
 	if tx == nil {
-		return nil, nil
+		return nil, err
 	}
 	receipts, err := s.b.GetReceipts(ctx, blockHash)
 	if err != nil {
@@ -1064,6 +1406,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
+		"effectiveGasPrice": (*hexutil.Big)(tx.GasPrice()),
 	}
 
 	// Assign receipt status or post state.
@@ -1079,9 +1422,79 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Attach the revert data of failed transactions if the node recorded it
+	if len(receipt.ReturnData) > 0 {
+		fields["returnData"] = hexutil.Bytes(receipt.ReturnData)
+		if reason, ok := unpackRevert(receipt.ReturnData); ok {
+			fields["revertReason"] = reason
+		}
+	}
 	return fields, nil
 }
 
+// ReceiptProof is a Merkle proof of the inclusion of a receipt in the receipt
+// trie of a block, verifiable against the receipts root of its header.
+type ReceiptProof struct {
+	BlockHash    common.Hash     `json:"blockHash"`
+	BlockNumber  hexutil.Uint64  `json:"blockNumber"`
+	ReceiptsRoot common.Hash     `json:"receiptsRoot"`
+	Key          hexutil.Bytes   `json:"key"`   // RLP encoded index of the transaction
+	Value        hexutil.Bytes   `json:"value"` // Consensus RLP encoding of the receipt
+	Proof        []hexutil.Bytes `json:"proof"` // Trie nodes on the path from the root to the receipt
+}
+
+// proofList collects the nodes of a Merkle proof in the order they are added.
+type proofList []hexutil.Bytes
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// GetReceiptProof returns the Merkle proof of the receipt of the given
+// transaction, built from the receipts of its block.
+func (s *PublicTransactionPoolAPI) GetReceiptProof(ctx context.Context, hash common.Hash) (*ReceiptProof, error) {
+	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil {
+		return nil, err
+	}
+	header, err := s.b.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+	if header == nil || err != nil {
+		return nil, err
+	}
+	if header.Hash() != blockHash {
+		return nil, fmt.Errorf("block %x reorged out", blockHash)
+	}
+	receipts, err := s.b.GetReceipts(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) <= int(index) {
+		return nil, nil
+	}
+	// Rebuild the receipt trie and make sure it matches the header
+	trie := types.DeriveTrie(receipts)
+	if root := trie.Hash(); root != header.ReceiptHash {
+		return nil, fmt.Errorf("receipt root mismatch for block %x: have %x, want %x", blockHash, root, header.ReceiptHash)
+	}
+	key, err := rlp.EncodeToBytes(uint(index))
+	if err != nil {
+		return nil, err
+	}
+	var proof proofList
+	if err := trie.Prove(key, 0, &proof); err != nil {
+		return nil, err
+	}
+	return &ReceiptProof{
+		BlockHash:    blockHash,
+		BlockNumber:  hexutil.Uint64(blockNumber),
+		ReceiptsRoot: header.ReceiptHash,
+		Key:          key,
+		Value:        receipts.GetRlp(int(index)),
+		Proof:        proof,
+	}, nil
+}
+
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
@@ -1111,6 +1524,10 @@ type SendTxArgs struct {
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// OverrideFeeCap explicitly allows the transaction to exceed the node's
+	// configured fee cap for locally signed transactions.
+	OverrideFeeCap bool `json:"overrideFeeCap"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1154,6 +1571,21 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	return nil
 }
 
+// checkTxFee is an internal function used to check whether the fee (gas price
+// times gas limit) of the transaction is within the given cap, which is
+// denominated in gdaer. A zero cap or an explicit override skips the check.
+func (args *SendTxArgs) checkTxFee(feeCap float64) error {
+	if feeCap == 0 || args.OverrideFeeCap {
+		return nil
+	}
+	fee := new(big.Int).Mul(args.GasPrice.ToInt(), new(big.Int).SetUint64(uint64(*args.Gas)))
+	feeGda := new(big.Float).Quo(new(big.Float).SetInt(fee), new(big.Float).SetInt(big.NewInt(1000*params.Finney)))
+	if f, _ := feeGda.Float64(); f > feeCap {
+		return fmt.Errorf("tx fee (%.2f gdaer) exceeds the configured cap (%.2f gdaer), set overrideFeeCap to send anyway", f, feeCap)
+	}
+	return nil
+}
+
 func (args *SendTxArgs) toTransaction() *types.Transaction {
 	var input []byte
 	if args.Data != nil {
@@ -1203,12 +1635,21 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 		// the same nonce to multiple accounts.
 		s.nonceLock.LockAddr(args.From)
 		defer s.nonceLock.UnlockAddr(args.From)
+
+		nonce, err := s.nonceLock.nextNonce(ctx, s.b, args.From)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		args.Nonce = (*hexutil.Uint64)(&nonce)
 	}
 
 	// Set some sanity defaults and terminate on failure
 	if err := args.setDefaults(ctx, s.b); err != nil {
 		return common.Hash{}, err
 	}
+	if err := args.checkTxFee(s.b.RPCTxFeeCap()); err != nil {
+		return common.Hash{}, err
+	}
 	// Assemble the transaction and sign with the wallet
 	tx := args.toTransaction()
 
@@ -1233,6 +1674,38 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	return submitTransaction(ctx, s.b, tx)
 }
 
+// NonceReservation is a nonce reserved for an account and the unix time at
+// which the reservation expires.
+type NonceReservation struct {
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	Expires hexutil.Uint64 `json:"expires"`
+}
+
+// ReserveNonce reserves the next nonce of the given account that is neither used
+// by a pending transaction nor reserved already, so that multiple signers can
+// sign transactions of the same account concurrently. The reservation is held
+// for ttl seconds, or until a transaction with the nonce enters the pool.
+func (s *PublicTransactionPoolAPI) ReserveNonce(ctx context.Context, address common.Address, ttl *hexutil.Uint64) (*NonceReservation, error) {
+	lifetime := defaultNonceReservation
+	if ttl != nil {
+		lifetime = time.Duration(*ttl) * time.Second
+	}
+	if lifetime <= 0 || lifetime > maxNonceReservation {
+		return nil, fmt.Errorf("reservation ttl out of range: %v, limit %v", lifetime, maxNonceReservation)
+	}
+	nonce, expiry, err := s.nonceLock.reserveNonce(ctx, s.b, address, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	return &NonceReservation{Nonce: hexutil.Uint64(nonce), Expires: hexutil.Uint64(expiry.Unix())}, nil
+}
+
+// ReleaseNonce releases a nonce reservation that will not be used, returning
+// whether the reservation existed.
+func (s *PublicTransactionPoolAPI) ReleaseNonce(address common.Address, nonce hexutil.Uint64) bool {
+	return s.nonceLock.releaseNonce(address, uint64(nonce))
+}
+
 // Sign calculates an ECDSA signature for:
 // keccack256("\x19gdachain Signed Message:\n" + len(message) + message).
 //
@@ -1258,6 +1731,33 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	return signature, err
 }
 
+// SignTypedData calculates an ECDSA signature over typed structured data (EIP-712):
+// keccak256("\x19\x01" + hashStruct(domain) + hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The account associated with addr must be unlocked.
+func (s *PublicTransactionPoolAPI) SignTypedData(addr common.Address, typedData signercore.TypedData) (hexutil.Bytes, error) {
+	hash, err := typedData.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	// Look up the wallet containing the requested signer
+	account := accounts.Account{Address: addr}
+
+	wallet, err := s.b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+	// Sign the typed data hash with the wallet
+	signature, err := wallet.SignHash(account, hash)
+	if err == nil {
+		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	}
+	return signature, err
+}
+
 // SignTransactionResult represents a RLP encoded signed transaction.
 type SignTransactionResult struct {
 	Raw hexutil.Bytes      `json:"raw"`
@@ -0,0 +1,294 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/accounts/keystore"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/common/math"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Tests that revert reasons are decoded from the data of reverted calls.
+func TestRevertError(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		// revert("denied")
+		{"0x08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000006" +
+			"64656e6965640000000000000000000000000000000000000000000000000000", "execution reverted: denied"},
+		// Plain revert without a reason
+		{"0x", "execution reverted"},
+		// Custom revert data
+		{"0xdeadbeef", "execution reverted"},
+		// Error(string) with an out of bounds length
+		{"0x08c379a0" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000040", "execution reverted"},
+	}
+	for i, tt := range tests {
+		err := &RevertError{Data: hexutil.MustDecode(tt.data)}
+		if have := err.Error(); have != tt.want {
+			t.Errorf("test %d: message mismatch: have %q, want %q", i, have, tt.want)
+		}
+		if have := err.ErrorData(); have != tt.data {
+			t.Errorf("test %d: data mismatch: have %v, want %v", i, have, tt.data)
+		}
+	}
+}
+
+// stateBackend is a backend only serving a fixed state.
+type stateBackend struct {
+	Backend
+	state *state.StateDB
+}
+
+func (b *stateBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.state, &types.Header{Number: new(big.Int)}, nil
+}
+
+// Tests that batched account and storage reads return the same values as the
+// individual ones.
+func TestBatchStateReads(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	contract := common.Address{0x01}
+	statedb.Segdaate(contract, common.Hash{0x01}, common.Hash{0xaa})
+	statedb.Segdaate(contract, common.Hash{0x02}, common.Hash{0xbb})
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	statedb.SetBalance(common.Address{0x02}, big.NewInt(1000))
+	statedb.SetNonce(common.Address{0x02}, 7)
+
+	api := NewPublicBlockChainAPI(&stateBackend{state: statedb})
+
+	keys := []string{common.Hash{0x01}.Hex(), common.Hash{0x02}.Hex(), common.Hash{0x03}.Hex()}
+	slots, err := api.GetStorageSlots(context.Background(), contract, keys, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to read storage slots: %v", err)
+	}
+	for i, key := range keys {
+		single, _ := api.GegdaorageAt(context.Background(), contract, key, rpc.LatestBlockNumber)
+		if slots[i] != common.BytesToHash(single) {
+			t.Errorf("slot %d mismatch: have %x, want %x", i, slots[i], single)
+		}
+	}
+	accounts, err := api.GetAccounts(context.Background(), []common.Address{contract, {0x02}}, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to read accounts: %v", err)
+	}
+	if accounts[0].CodeHash != statedb.GetCodeHash(contract) || accounts[0].Nonce != 0 {
+		t.Errorf("contract account mismatch: %+v", accounts[0])
+	}
+	if accounts[1].Balance.ToInt().Int64() != 1000 || accounts[1].Nonce != 7 || accounts[1].CodeHash != crypto.Keccak256Hash(nil) {
+		t.Errorf("plain account mismatch: %+v", accounts[1])
+	}
+	if _, err := api.GetStorageSlots(context.Background(), contract, make([]string, maxBatchReads+1), rpc.LatestBlockNumber); err == nil {
+		t.Errorf("oversized batch accepted")
+	}
+	for addr, want := range map[common.Address]common.Hash{
+		contract: crypto.Keccak256Hash([]byte{0x60, 0x00}),
+		{0x02}:   crypto.Keccak256Hash(nil),
+		{0x03}:   {},
+	} {
+		if hash, err := api.GetCodeHash(context.Background(), addr, rpc.LatestBlockNumber); err != nil || hash != want {
+			t.Errorf("code hash of %x mismatch: have %x, %v, want %x", addr, hash, err, want)
+		}
+	}
+}
+
+// callBackend is a backend executing calls against a fixed state, with the
+// given node level call limits.
+type callBackend struct {
+	stateBackend
+	gasCap  uint64
+	timeout time.Duration
+	memory  uint64
+}
+
+func (b *callBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.state, &types.Header{Number: new(big.Int), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: math.MaxUint64}, nil
+}
+
+func (b *callBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	state.SetBalance(msg.From(), math.MaxBig256)
+	context := core.NewEVMContext(msg, header, nil, &header.Coinbase)
+	return vm.NewEVM(context, state, params.TestChainConfig, vmCfg), func() error { return nil }, nil
+}
+
+func (b *callBackend) RPCGasCap() uint64            { return b.gasCap }
+func (b *callBackend) RPCEVMTimeout() time.Duration { return b.timeout }
+func (b *callBackend) RPCEVMMemory() uint64         { return b.memory }
+
+// Tests that calls into never ending contracts are interrupted by either the
+// node level gas cap or the execution timeout.
+func TestCallLimits(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	contract := common.Address{0x01}
+	statedb.SetCode(contract, []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0x00, byte(vm.JUMP)})
+
+	args := CallArgs{From: common.Address{0x02}, To: &contract}
+
+	// Ensure the gas cap bounds calls not specifying any gas
+	api := NewPublicBlockChainAPI(&callBackend{stateBackend: stateBackend{state: statedb}, gasCap: 1000000})
+	result, err := api.doCall(context.Background(), args, rpc.LatestBlockNumber, vm.Config{}, 0)
+	if err != nil {
+		t.Fatalf("failed to execute capped call: %v", err)
+	}
+	if result.vmErr != vm.ErrOutOfGas || result.usedGas != 1000000 {
+		t.Fatalf("capped call mismatch: have %v error, %d gas used, want %v, %d", result.vmErr, result.usedGas, vm.ErrOutOfGas, 1000000)
+	}
+	// Ensure uncapped calls are interrupted by the timeout
+	api = NewPublicBlockChainAPI(&callBackend{stateBackend: stateBackend{state: statedb}, timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := api.Call(context.Background(), args, rpc.LatestBlockNumber); err == nil || !strings.Contains(err.Error(), "execution aborted") {
+		t.Fatalf("timed out call error mismatch: have %v, want execution aborted", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("call not interrupted in time: took %v", elapsed)
+	}
+}
+
+// Tests that calls expanding the memory beyond the node level limit are aborted
+// with an explicit error.
+func TestCallMemoryLimit(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	// Load a word at the given offset, expanding the memory up to it
+	code := func(offset byte) []byte {
+		return []byte{byte(vm.PUSH3), offset, 0x00, 0x00, byte(vm.MLOAD), byte(vm.STOP)}
+	}
+	small, large := common.Address{0x01}, common.Address{0x02}
+	statedb.SetCode(small, code(0x01)) // 64KB
+	statedb.SetCode(large, code(0x20)) // 2MB
+
+	api := NewPublicBlockChainAPI(&callBackend{stateBackend: stateBackend{state: statedb}, memory: 1024 * 1024})
+
+	if _, err := api.Call(context.Background(), CallArgs{From: common.Address{0xff}, To: &small}, rpc.LatestBlockNumber); err != nil {
+		t.Fatalf("call within memory limit failed: %v", err)
+	}
+	if _, err := api.Call(context.Background(), CallArgs{From: common.Address{0xff}, To: &large}, rpc.LatestBlockNumber); err != vm.ErrMemoryLimitExceeded {
+		t.Fatalf("call beyond memory limit error mismatch: have %v, want %v", err, vm.ErrMemoryLimitExceeded)
+	}
+}
+
+// feeBackend is a backend signing transactions from a keystore and recording
+// the ones submitted to the pool, with the given node level fee cap.
+type feeBackend struct {
+	Backend
+	am     *accounts.Manager
+	feeCap float64
+	sent   []*types.Transaction
+}
+
+func (b *feeBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *feeBackend) ChainConfig() *params.ChainConfig  { return params.TestChainConfig }
+func (b *feeBackend) RPCTxFeeCap() float64              { return b.feeCap }
+
+func (b *feeBackend) CurrentBlock() *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: new(big.Int)})
+}
+
+func (b *feeBackend) SendTx(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+// Tests that transactions exceeding the node level fee cap are rejected by both
+// the public and the personal send methods, unless the cap is disabled or the
+// caller explicitly overrides it.
+func TestSendTxFeeCap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethapi-feecap-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary keystore: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	// Create transaction arguments paying a fee of 2 gdaer (1M gas at 2 Szabo)
+	makeArgs := func(override bool) SendTxArgs {
+		var (
+			to    = common.Address{0x01}
+			gas   = hexutil.Uint64(1000000)
+			price = (*hexutil.Big)(big.NewInt(2 * params.Szabo))
+			nonce = hexutil.Uint64(0)
+		)
+		return SendTxArgs{From: account.Address, To: &to, Gas: &gas, GasPrice: price, Nonce: &nonce, OverrideFeeCap: override}
+	}
+	tests := []struct {
+		feeCap   float64
+		override bool
+		fail     bool
+	}{
+		{feeCap: 1, override: false, fail: true},  // fee above the cap
+		{feeCap: 3, override: false, fail: false}, // fee below the cap
+		{feeCap: 0, override: false, fail: false}, // cap disabled
+		{feeCap: 1, override: true, fail: false},  // cap explicitly overridden
+	}
+	for i, tt := range tests {
+		backend := &feeBackend{am: accounts.NewManager(ks), feeCap: tt.feeCap}
+
+		public := NewPublicTransactionPoolAPI(backend, new(AddrLocker))
+		_, err := public.SendTransaction(context.Background(), makeArgs(tt.override))
+		if fail := err != nil; fail != tt.fail {
+			t.Errorf("test %d: public send failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		personal := NewPrivateAccountAPI(backend, new(AddrLocker))
+		_, err = personal.SendTransaction(context.Background(), makeArgs(tt.override), "")
+		if fail := err != nil; fail != tt.fail {
+			t.Errorf("test %d: personal send failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		want := 2
+		if tt.fail {
+			want = 0
+		}
+		if len(backend.sent) != want {
+			t.Errorf("test %d: submitted transaction count mismatch: have %d, want %d", i, len(backend.sent), want)
+		}
+	}
+}
@@ -20,6 +20,7 @@ package ethapi
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/common"
@@ -42,8 +43,11 @@ type Backend interface {
 	ProtocolVersion() int
 	SuggestPrice(ctx context.Context) (*big.Int, error)
 	ChainDb() gdadb.Database
-	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
+	RPCTxFeeCap() float64         // global tx fee cap for all transaction related APIs
+	RPCGasCap() uint64            // global gas cap for eth_call and gas estimation
+	RPCEVMTimeout() time.Duration // global timeout for eth_call over rpc
+	RPCEVMMemory() uint64         // global memory limit of the EVM for rpc executions
 
 	// BlockChain API
 	SetHead(number uint64)
@@ -52,6 +56,7 @@ type Backend interface {
 	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
 	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
 	GetTd(blockHash common.Hash) *big.Int
 	GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error)
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
@@ -66,6 +71,7 @@ type Backend interface {
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
+	SubscribeTxDropEvent(chan<- core.TxDropEvent) event.Subscription
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
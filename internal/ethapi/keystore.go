@@ -0,0 +1,129 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+)
+
+const (
+	// maxKeystoreBatch is the maximum number of keys imported in a single call.
+	maxKeystoreBatch = 256
+
+	// keyOpInterval is the minimum time between the starts of two passphrase
+	// checked keystore operations.
+	keyOpInterval = 100 * time.Millisecond
+)
+
+// keyThrottle serializes expensive keystore operations and spaces them out, so
+// that bulk imports and exports can neither exhaust the node's memory with
+// parallel scrypt derivations, nor be used to brute force passphrases.
+type keyThrottle struct {
+	slot     chan struct{}
+	interval time.Duration
+	last     time.Time // Start of the last operation, guarded by slot
+}
+
+// newKeyThrottle creates a throttle admitting one operation per interval.
+func newKeyThrottle(interval time.Duration) *keyThrottle {
+	return &keyThrottle{
+		slot:     make(chan struct{}, 1),
+		interval: interval,
+	}
+}
+
+// acquire waits until an operation may start, or the context is cancelled.
+func (t *keyThrottle) acquire(ctx context.Context) error {
+	select {
+	case t.slot <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if wait := t.interval - time.Since(t.last); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			<-t.slot
+			return ctx.Err()
+		}
+	}
+	t.last = time.Now()
+	return nil
+}
+
+// release allows the next operation to start.
+func (t *keyThrottle) release() {
+	<-t.slot
+}
+
+// importResult is the outcome of importing a single key of a batch.
+type importResult struct {
+	Address *common.Address `json:"address,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// ImportKeystoreBatch imports a batch of encrypted JSON key files, all protected
+// by the same passphrase, into the keystore. The keys are stored encrypted with
+// newPassphrase, or with the original passphrase if none is given. The outcome
+// of each import is reported individually, so that a single bad key does not
+// abort the migration of the remaining ones.
+func (s *PrivateAccountAPI) ImportKeystoreBatch(ctx context.Context, keys []json.RawMessage, passphrase string, newPassphrase *string) ([]importResult, error) {
+	if len(keys) > maxKeystoreBatch {
+		return nil, fmt.Errorf("batch too large: %d keys, limit %d", len(keys), maxKeystoreBatch)
+	}
+	pass := passphrase
+	if newPassphrase != nil {
+		pass = *newPassphrase
+	}
+	ks := fetchKeystore(s.am)
+
+	results := make([]importResult, len(keys))
+	for i, key := range keys {
+		if err := s.keyOps.acquire(ctx); err != nil {
+			return nil, err
+		}
+		account, err := ks.Import(key, passphrase, pass)
+		s.keyOps.release()
+
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Address = &account.Address
+	}
+	return results, nil
+}
+
+// ExportAccount returns the encrypted JSON key file of an account, protected by
+// the account's passphrase.
+func (s *PrivateAccountAPI) ExportAccount(ctx context.Context, addr common.Address, passphrase string) (json.RawMessage, error) {
+	if err := s.keyOps.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.keyOps.release()
+
+	return fetchKeystore(s.am).Export(accounts.Account{Address: addr}, passphrase, passphrase)
+}
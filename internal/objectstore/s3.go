@@ -0,0 +1,154 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package objectstore implements a minimal client for S3-compatible object
+// storage services, sufficient to upload chain backups.
+package objectstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Credentials contains the access configuration of an S3-compatible service.
+type Credentials struct {
+	Endpoint  string `json:"endpoint"`  // Base URL of the service (e.g. https://s3.amazonaws.com)
+	Region    string `json:"region"`    // Region used for request signing
+	AccessKey string `json:"accessKey"` // Access key id
+	SecretKey string `json:"secretKey"` // Secret access key
+}
+
+// LoadCredentials reads the object store credentials from a JSON file, so that
+// secrets never need to pass through the RPC layer.
+func LoadCredentials(path string) (*Credentials, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	creds := new(Credentials)
+	if err := json.Unmarshal(blob, creds); err != nil {
+		return nil, fmt.Errorf("invalid credentials file: %v", err)
+	}
+	if creds.Endpoint == "" || creds.AccessKey == "" || creds.SecretKey == "" {
+		return nil, errors.New("incomplete credentials: endpoint, accessKey and secretKey required")
+	}
+	if creds.Region == "" {
+		creds.Region = "us-east-1"
+	}
+	return creds, nil
+}
+
+// Client is an S3-compatible object store client using path-style addressing
+// and AWS signature version 4 request signing.
+type Client struct {
+	creds  *Credentials
+	client *http.Client
+}
+
+// NewClient creates an object store client with the given credentials.
+func NewClient(creds *Credentials) *Client {
+	return &Client{
+		creds:  creds,
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}
+}
+
+// Put uploads an object into the given bucket, returning the hex encoded SHA256
+// checksum of the uploaded content.
+func (c *Client) Put(bucket, key string, data []byte) (string, error) {
+	endpoint, err := url.Parse(c.creds.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	endpoint.Path = "/" + bucket + "/" + strings.TrimPrefix(key, "/")
+
+	req, err := http.NewRequest(http.MethodPut, endpoint.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	checksum := sha256.Sum256(data)
+	c.sign(req, hex.EncodeToString(checksum[:]), time.Now().UTC())
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return "", fmt.Errorf("upload of %s/%s failed: %s: %s", bucket, key, res.Status, strings.TrimSpace(string(body)))
+	}
+	return hex.EncodeToString(checksum[:]), nil
+}
+
+// sign adds an AWS signature version 4 authorization header to the request.
+func (c *Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	var (
+		date  = now.Format("20060102")
+		stamp = now.Format("20060102T150405Z")
+		scope = date + "/" + c.creds.Region + "/s3/aws4_request"
+	)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + stamp + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	digest := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.creds.SecretKey), date)
+	key = hmacSHA256(key, c.creds.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.creds.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package objectstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests that objects are uploaded path-style with a signed payload checksum.
+func TestPut(t *testing.T) {
+	var (
+		path, auth, hash string
+		body             []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth, hash = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	client := NewClient(&Credentials{Endpoint: server.URL, Region: "eu-west-1", AccessKey: "key", SecretKey: "secret"})
+	checksum, err := client.Put("backups", "blocks-1.rlp", []byte("payload"))
+	if err != nil {
+		t.Fatalf("failed to upload object: %v", err)
+	}
+	want := sha256.Sum256([]byte("payload"))
+	if checksum != hex.EncodeToString(want[:]) || hash != checksum {
+		t.Errorf("checksum mismatch: have %s (header %s), want %x", checksum, hash, want)
+	}
+	if path != "/backups/blocks-1.rlp" {
+		t.Errorf("object path mismatch: have %s, want /backups/blocks-1.rlp", path)
+	}
+	if string(body) != "payload" {
+		t.Errorf("object content mismatch: have %q, want %q", body, "payload")
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("invalid authorization header: %s", auth)
+	}
+}
+
+// Tests that failed uploads are reported.
+func TestPutFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(&Credentials{Endpoint: server.URL, Region: "eu-west-1", AccessKey: "key", SecretKey: "secret"})
+	if _, err := client.Put("backups", "blocks-1.rlp", []byte("payload")); err == nil {
+		t.Fatalf("rejected upload reported success")
+	}
+}
@@ -23,12 +23,15 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
 	"gda":        gda_JS,
+	"les":        LES_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
+	"node":       Node_JS,
 	"personal":   Personal_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
+	"token":      Token_JS,
 	"txpool":     TxPool_JS,
 }
 
@@ -99,6 +102,23 @@ web3._extend({
 			call: 'clique_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'signerStats',
+			call: 'clique_signerStats',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'exportSnapshot',
+			call: 'clique_exportSnapshot',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'importSnapshot',
+			call: 'clique_importSnapshot',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -123,6 +143,12 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'probePeer',
+			call: 'admin_probePeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -159,6 +185,42 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'setRPCCors',
+			call: 'admin_setRPCCors',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setRPCVirtualHosts',
+			call: 'admin_setRPCVirtualHosts',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setWSOrigins',
+			call: 'admin_setWSOrigins',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'drain',
+			call: 'admin_drain',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'exportToObjectStore',
+			call: 'admin_exportToObjectStore',
+			params: 4
+		}),
+		new web3._extend.Method({
+			name: 'validateChainConfig',
+			call: 'admin_validateChainConfig',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setPeerAllowlist',
+			call: 'admin_setPeerAllowlist',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -173,6 +235,26 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'chainConfig',
+			getter: 'admin_chainConfig'
+		}),
+		new web3._extend.Property({
+			name: 'indexers',
+			getter: 'admin_indexers'
+		}),
+		new web3._extend.Property({
+			name: 'rpcConnections',
+			getter: 'admin_rpcConnections'
+		}),
+		new web3._extend.Property({
+			name: 'natStatus',
+			getter: 'admin_natStatus'
+		}),
+		new web3._extend.Property({
+			name: 'peerAllowlist',
+			getter: 'admin_peerAllowlist'
+		}),
 	]
 });
 `
@@ -186,6 +268,37 @@ web3._extend({
 			call: 'debug_printBlock',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportBlocks',
+			call: 'debug_exportBlocks',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'exportStatus',
+			call: 'debug_exportStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'cancelExport',
+			call: 'debug_cancelExport',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stateStats',
+			call: 'debug_stateStats',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'jobStatus',
+			call: 'debug_jobStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'cancelJob',
+			call: 'debug_cancelJob',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getBlockRlp',
 			call: 'debug_getBlockRlp',
@@ -363,11 +476,42 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'codeByHash',
+			call: 'debug_codeByHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'propagationStats',
+			call: 'debug_propagationStats',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setPreimageRecording',
+			call: 'debug_setPreimageRecording',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'exportPreimages',
+			call: 'debug_exportPreimages',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'vmProfile',
+			call: 'debug_vmProfile',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'getBadBlocks',
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'badBlockRLP',
+			call: 'debug_badBlockRLP',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -400,6 +544,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'signTypedData',
+			call: 'gda_signTypedData',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'resend',
 			call: 'eth_resend',
@@ -431,8 +581,100 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getLogsPage',
+			call: 'gda_getLogsPage',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlockConfidence',
+			call: 'gda_getBlockConfidence',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reserveNonce',
+			call: 'gda_reserveNonce',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'releaseNonce',
+			call: 'gda_releaseNonce',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getLocalTxStatus',
+			call: 'gda_getLocalTxStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionsByAddress',
+			call: 'gda_getTransactionsByAddress',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'gda_callBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getReceiptProof',
+			call: 'gda_getReceiptProof',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getHeaderByNumber',
+			call: 'gda_getHeaderByNumber',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getHeaderByHash',
+			call: 'gda_getHeaderByHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getCodeHash',
+			call: 'gda_getCodeHash',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getStorageSlots',
+			call: 'gda_getStorageSlots',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getAccounts',
+			call: 'gda_getAccounts',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTdByHash',
+			call: 'gda_getTdByHash',
+			params: 1,
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Method({
+			name: 'cancelSync',
+			call: 'gda_cancelSync',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'setSyncConcurrency',
+			call: 'gda_setSyncConcurrency',
+			params: 1
+		}),
 	],
 	properties: [
+		new web3._extend.Property({
+			name: 'syncPeers',
+			getter: 'gda_syncPeers'
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'eth_pendingTransactions',
@@ -474,6 +716,16 @@ web3._extend({
 			call: 'miner_setExtra',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setUnclePolicy',
+			call: 'miner_setUnclePolicy',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setRecommitInterval',
+			call: 'miner_setRecommitInterval',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'setGasPrice',
 			call: 'miner_setGasPrice',
@@ -502,6 +754,19 @@ web3._extend({
 });
 `
 
+const Node_JS = `
+web3._extend({
+	property: 'node',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'health',
+			getter: 'node_health'
+		}),
+	]
+});
+`
+
 const Personal_JS = `
 web3._extend({
 	property: 'personal',
@@ -532,6 +797,28 @@ web3._extend({
 			call: 'personal_deriveAccount',
 			params: 3
 		}),
+		new web3._extend.Method({
+			name: 'importKeystoreBatch',
+			call: 'personal_importKeystoreBatch',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'exportAccount',
+			call: 'personal_exportAccount',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'signTypedData',
+			call: 'personal_signTypedData',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'walletStatus',
+			call: 'personal_walletStatus',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'signTransaction',
 			call: 'personal_signTransaction',
@@ -605,12 +892,98 @@ web3._extend({
 });
 `
 
+const LES_JS = `
+web3._extend({
+	property: 'les',
+	methods: [
+		new web3._extend.Method({
+			name: 'getCheckpoint',
+			call: 'les_getCheckpoint',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionStatus',
+			call: 'les_getTransactionStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addTrustedServer',
+			call: 'les_addTrustedServer',
+			params: 1
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'indexerStatus',
+			getter: 'les_indexerStatus'
+		}),
+		new web3._extend.Property({
+			name: 'serverPoolStats',
+			getter: 'les_serverPoolStats'
+		}),
+	]
+});
+`
+
+const Token_JS = `
+web3._extend({
+	property: 'token',
+	methods: [
+		new web3._extend.Method({
+			name: 'getTransfers',
+			call: 'token_getTransfers',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getTokens',
+			call: 'token_getTokens',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getBalance',
+			call: 'token_getBalance',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter],
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+	]
+});
+`
+
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'setAutoBump',
+			call: 'txpool_setAutoBump',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setGasPrice',
+			call: 'txpool_setGasPrice',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'setPolicyRules',
+			call: 'txpool_setPolicyRules',
+			params: 1
+		}),
+	],
 	properties:
 	[
+		new web3._extend.Property({
+			name: 'autoBump',
+			getter: 'txpool_autoBump'
+		}),
+		new web3._extend.Property({
+			name: 'policyRules',
+			getter: 'txpool_policyRules'
+		}),
 		new web3._extend.Property({
 			name: 'content',
 			getter: 'txpool_content'
@@ -0,0 +1,257 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/p2p/discover"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// PublicLightAPI provides light client specific RPC methods.
+type PublicLightAPI struct {
+	les *Lightgdachain
+}
+
+// NewPublicLightAPI creates a new light client specific API.
+func NewPublicLightAPI(les *Lightgdachain) *PublicLightAPI {
+	return &PublicLightAPI{les: les}
+}
+
+// TransactionStatus is the status of a transaction as reported by the light
+// servers. The block position is only filled in for included transactions,
+// after their inclusion has been proven.
+type TransactionStatus struct {
+	Status      string          `json:"status"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+	BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+	Index       *hexutil.Uint64 `json:"transactionIndex"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// GetTransactionStatus retrieves the status of a transaction from the light
+// servers. The inclusion of mined transactions is proven against the trusted
+// CHT or the local header chain.
+func (api *PublicLightAPI) GetTransactionStatus(ctx context.Context, hash common.Hash) (*TransactionStatus, error) {
+	stats, err := light.GetTxStatus(ctx, api.les.odr, []common.Hash{hash})
+	if err != nil {
+		return nil, err
+	}
+	stat := stats[0]
+
+	result := &TransactionStatus{Error: stat.Error}
+	switch stat.Status {
+	case core.TxStatusQueued:
+		result.Status = "queued"
+	case core.TxStatusPending:
+		result.Status = "pending"
+	case core.TxStatusIncluded:
+		result.Status = "included"
+		number, index := hexutil.Uint64(stat.Lookup.BlockIndex), hexutil.Uint64(stat.Lookup.Index)
+		result.BlockHash, result.BlockNumber, result.Index = &stat.Lookup.BlockHash, &number, &index
+	default:
+		result.Status = "unknown"
+	}
+	return result, nil
+}
+
+// rpcConfirmation is the notification sent to TransactionConfirmed subscribers.
+type rpcConfirmation struct {
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Receipt     interface{}    `json:"receipt"`
+}
+
+// ConfirmationCriteria lists the transactions and addresses tracked by a
+// TransactionConfirmed subscription.
+type ConfirmationCriteria struct {
+	Transactions []common.Hash    `json:"transactions"`
+	Addresses    []common.Address `json:"addresses"`
+}
+
+// TransactionConfirmed creates a subscription that is triggered each time one of
+// the given transactions, or a transaction emitting logs related to one of the
+// given addresses, is included in the chain. The transactions and addresses are
+// tracked only for the lifetime of the subscription, at most
+// light.MaxTrackedPerSubscription of them.
+func (api *PublicLightAPI) TransactionConfirmed(ctx context.Context, crit ConfirmationCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	confirmations := make(chan light.TransactionConfirmed)
+	confirmSub, err := api.les.txPool.SubscribeTransactionConfirmed(confirmations, crit.Transactions, crit.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer confirmSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-confirmations:
+				notifier.Notify(rpcSub.ID, &rpcConfirmation{
+					TxHash:      ev.Tx.Hash(),
+					BlockHash:   ev.BlockHash,
+					BlockNumber: hexutil.Uint64(ev.BlockNumber),
+					Receipt:     ev.Receipt,
+				})
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// PrivateLightAPI provides light client specific RPC methods for managing the
+// server pool.
+type PrivateLightAPI struct {
+	les *Lightgdachain
+}
+
+// NewPrivateLightAPI creates a new light client server pool management API.
+func NewPrivateLightAPI(les *Lightgdachain) *PrivateLightAPI {
+	return &PrivateLightAPI{les: les}
+}
+
+// ServerPoolEntry is the service quality of a single known or pinned light server.
+// Availability and timeouts are ratios, block delays and response times are in
+// milliseconds, all adjusted towards the recently observed values.
+type ServerPoolEntry struct {
+	ID           string  `json:"id"`
+	Address      string  `json:"address"`
+	State        string  `json:"state"`
+	Known        bool    `json:"known"`
+	Trusted      bool    `json:"trusted"`
+	Fails        uint    `json:"fails"`
+	Availability float64 `json:"availability"`
+	BlockDelay   float64 `json:"blockDelay"`
+	ResponseTime float64 `json:"responseTime"`
+	Timeouts     float64 `json:"timeouts"`
+}
+
+// AddTrustedServer pins a light server, which is then dialed with priority and
+// retained across restarts regardless of its service quality.
+func (api *PrivateLightAPI) AddTrustedServer(url string) (bool, error) {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	api.les.serverPool.addTrusted(node)
+	return true, nil
+}
+
+// ServerPoolStats returns the persisted service quality statistics of all the
+// known and pinned light servers.
+func (api *PrivateLightAPI) ServerPoolStats() []*ServerPoolEntry {
+	return api.les.serverPool.stats()
+}
+
+// Checkpoint is the latest locally known set of helper trie roots, which can be
+// used to bootstrap other light clients.
+type Checkpoint struct {
+	SectionIdx    hexutil.Uint64 `json:"sectionIndex"`
+	SectionHead   common.Hash    `json:"sectionHead"`
+	ChtRoot       common.Hash    `json:"chtRoot"`
+	BloomTrieRoot common.Hash    `json:"bloomTrieRoot"`
+}
+
+// GetCheckpoint returns the latest checkpoint for which both the CHT and the
+// bloom trie are available locally.
+func (api *PublicLightAPI) GetCheckpoint() (*Checkpoint, error) {
+	chtSections, _, _ := api.les.chtIndexer.Sections()
+	bloomTrieSections, _, _ := api.les.bloomTrieIndexer.Sections()
+
+	// Both tries need to be available for the checkpoint to be usable
+	sections := chtSections
+	if bloomTrieSections < sections {
+		sections = bloomTrieSections
+	}
+	if sections == 0 {
+		return nil, errors.New("no checkpoint available")
+	}
+	idx := sections - 1
+	head := api.les.chtIndexer.SectionHead(idx)
+
+	checkpoint := &Checkpoint{
+		SectionIdx:    hexutil.Uint64(idx),
+		SectionHead:   head,
+		ChtRoot:       light.GetChtRoot(api.les.chainDb, idx, head),
+		BloomTrieRoot: light.GetBloomTrieRoot(api.les.chainDb, idx, head),
+	}
+	if checkpoint.ChtRoot == (common.Hash{}) || checkpoint.BloomTrieRoot == (common.Hash{}) {
+		return nil, errors.New("checkpoint roots unavailable")
+	}
+	return checkpoint, nil
+}
+
+// IndexerStatus is the progress of a single chain indexer.
+type IndexerStatus struct {
+	SectionSize hexutil.Uint64  `json:"sectionSize"`
+	Sections    hexutil.Uint64  `json:"sections"`
+	LastHeader  *hexutil.Uint64 `json:"lastHeader"`
+	SectionHead common.Hash     `json:"sectionHead"`
+}
+
+// IndexersStatus is the progress of all the light client chain indexers.
+type IndexersStatus struct {
+	Head      hexutil.Uint64 `json:"head"`
+	Cht       IndexerStatus  `json:"cht"`
+	BloomTrie IndexerStatus  `json:"bloomTrie"`
+	BloomBits IndexerStatus  `json:"bloomBits"`
+}
+
+// IndexerStatus returns the progress of the CHT, bloom trie and bloom bits
+// indexers. Log filtering only uses the bloom bits up to the last indexed header
+// of the bloom bits indexer, later blocks are filtered header by header.
+func (api *PublicLightAPI) IndexerStatus() *IndexersStatus {
+	return &IndexersStatus{
+		Head:      hexutil.Uint64(api.les.blockchain.CurrentHeader().Number.Uint64()),
+		Cht:       indexerStatus(api.les.chtIndexer, light.CHTFrequencyClient),
+		BloomTrie: indexerStatus(api.les.bloomTrieIndexer, light.BloomTrieFrequency),
+		BloomBits: indexerStatus(api.les.bloomIndexer, light.BloomTrieFrequency),
+	}
+}
+
+// indexerStatus collects the progress of a single chain indexer.
+func indexerStatus(indexer *core.ChainIndexer, size uint64) IndexerStatus {
+	sections, last, head := indexer.Sections()
+
+	status := IndexerStatus{
+		SectionSize: hexutil.Uint64(size),
+		Sections:    hexutil.Uint64(sections),
+	}
+	if sections > 0 {
+		lastHeader := hexutil.Uint64(last)
+		status.LastHeader = &lastHeader
+		status.SectionHead = head
+	}
+	return status
+}
@@ -19,6 +19,7 @@ package les
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/common"
@@ -29,6 +30,7 @@ import (
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/core/vm"
 	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/event"
@@ -91,6 +93,17 @@ func (b *LesApiBackend) GetLogs(ctx context.Context, blockHash common.Hash) ([][
 	return light.GetBlockLogs(ctx, b.gda.odr, blockHash, core.GetBlockNumber(b.gda.chainDb, blockHash))
 }
 
+// FilterLogs delegates a log filter query to the light servers, implementing
+// filters.LogsDelegator.
+func (b *LesApiBackend) FilterLogs(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	return light.GetFilteredLogs(ctx, b.gda.odr, begin, end, light.LogFilter{Addresses: addresses, Topics: topics})
+}
+
+func (b *LesApiBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, blockHash, blockNumber, index := core.GetTransaction(b.gda.chainDb, txHash)
+	return tx, blockHash, blockNumber, index, nil
+}
+
 func (b *LesApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.gda.blockchain.GetTdByHash(blockHash)
 }
@@ -133,6 +146,15 @@ func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.gda.txPool.SubscribeTxPreEvent(ch)
 }
 
+// SubscribeTxDropEvent returns a subscription that never fires, as the light
+// transaction pool does not evict transactions.
+func (b *LesApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.gda.blockchain.SubscribeChainEvent(ch)
 }
@@ -153,6 +175,15 @@ func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.gda.blockchain.SubscribeRemovedLogsEvent(ch)
 }
 
+// SubscribePendingLogsEvent returns a subscription that never fires, as light
+// clients do not mine pending blocks.
+func (b *LesApiBackend) SubscribePendingLogsEvent(ch chan<- core.PendingLogsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) Downloader() *downloader.Downloader {
 	return b.gda.Downloader()
 }
@@ -169,24 +200,48 @@ func (b *LesApiBackend) ChainDb() gdadb.Database {
 	return b.gda.chainDb
 }
 
-func (b *LesApiBackend) EventMux() *event.TypeMux {
-	return b.gda.eventMux
-}
-
 func (b *LesApiBackend) AccountManager() *accounts.Manager {
 	return b.gda.accountManager
 }
 
+func (b *LesApiBackend) RPCTxFeeCap() float64 {
+	return b.gda.config.RPCTxFeeCap
+}
+
+func (b *LesApiBackend) RPCGasCap() uint64 {
+	return b.gda.config.RPCGasCap
+}
+
+func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
+	return b.gda.config.RPCEVMTimeout
+}
+
+func (b *LesApiBackend) RPCEVMMemory() uint64 {
+	return b.gda.config.RPCEVMMemory
+}
+
+// BloomStatus reports the sections whose bloom bits are either indexed locally
+// or retrievable from servers with proofs against a trusted bloom trie.
 func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	if b.gda.bloomIndexer == nil {
 		return 0, 0
 	}
 	sections, _, _ := b.gda.bloomIndexer.Sections()
+	if b.gda.bloomTrieIndexer != nil {
+		if proven, _, _ := b.gda.bloomTrieIndexer.Sections(); proven > sections {
+			sections = proven
+		}
+	}
 	return light.BloomTrieFrequency, sections
 }
 
 func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
-	for i := 0; i < bloomFilterThreads; i++ {
-		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.gda.bloomRequests)
+	requests := b.gda.bloomRequests
+	if filters.IsPriority(ctx) {
+		requests = b.gda.bloomPriorityRequests
+	}
+	config := b.gda.config
+	for i := 0; i < config.BloomFilterThreads; i++ {
+		go session.Multiplex(config.BloomRetrievalBatch, config.BloomRetrievalWait, requests)
 	}
 }
@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/gda"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// newTestLightAPIBackend creates a light client with an empty chain and client
+// mode indexers, suitable for testing the light client RPC APIs.
+func newTestLightAPIBackend(t *testing.T) *Lightgdachain {
+	db, _ := gdadb.NewMemDatabase()
+	core.GenesisBlockForTesting(db, testBankAddress, testBankFunds)
+
+	les := &Lightgdachain{
+		chainDb:          db,
+		chtIndexer:       light.NewChtIndexer(db, true),
+		bloomTrieIndexer: light.NewBloomTrieIndexer(db, true),
+		bloomIndexer:     gda.NewBloomIndexer(db, light.BloomTrieFrequency),
+	}
+	les.odr = NewLesOdr(db, les.chtIndexer, les.bloomTrieIndexer, les.bloomIndexer, nil)
+
+	chain, err := light.NewLightChain(les.odr, params.TestChainConfig, ethash.NewFaker())
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	les.blockchain = chain
+	return les
+}
+
+// closeTestLightAPIBackend stops the indexers of a test light client.
+func closeTestLightAPIBackend(les *Lightgdachain) {
+	les.chtIndexer.Close()
+	les.bloomTrieIndexer.Close()
+	les.bloomIndexer.Close()
+}
+
+// Tests that the checkpoint is only reported once both helper tries of a
+// section are available.
+func TestLightAPIGetCheckpoint(t *testing.T) {
+	les := newTestLightAPIBackend(t)
+	defer closeTestLightAPIBackend(les)
+
+	api := NewPublicLightAPI(les)
+	if _, err := api.GetCheckpoint(); err == nil {
+		t.Fatalf("checkpoint reported without indexed sections")
+	}
+	var (
+		head      = common.HexToHash("0x01")
+		chtRoot   = common.HexToHash("0x02")
+		bloomRoot = common.HexToHash("0x03")
+	)
+	// Mark the first section known only for the CHT, the checkpoint needs both.
+	// The client CHT indexer stores roots by its own section index.
+	les.chtIndexer.AddKnownSectionHead(0, head)
+	light.StoreChtRoot(les.chainDb, 0, head, chtRoot)
+	if _, err := api.GetCheckpoint(); err == nil {
+		t.Fatalf("checkpoint reported without bloom trie sections")
+	}
+	// Mark the section known for the bloom trie too, but without the root
+	les.bloomTrieIndexer.AddKnownSectionHead(0, head)
+	if _, err := api.GetCheckpoint(); err == nil {
+		t.Fatalf("checkpoint reported without bloom trie root")
+	}
+	light.StoreBloomTrieRoot(les.chainDb, 0, head, bloomRoot)
+
+	checkpoint, err := api.GetCheckpoint()
+	if err != nil {
+		t.Fatalf("failed to retrieve checkpoint: %v", err)
+	}
+	if checkpoint.SectionIdx != 0 {
+		t.Errorf("section index mismatch: have %d, want %d", checkpoint.SectionIdx, 0)
+	}
+	if checkpoint.SectionHead != head {
+		t.Errorf("section head mismatch: have %x, want %x", checkpoint.SectionHead, head)
+	}
+	if checkpoint.ChtRoot != chtRoot {
+		t.Errorf("CHT root mismatch: have %x, want %x", checkpoint.ChtRoot, chtRoot)
+	}
+	if checkpoint.BloomTrieRoot != bloomRoot {
+		t.Errorf("bloom trie root mismatch: have %x, want %x", checkpoint.BloomTrieRoot, bloomRoot)
+	}
+}
+
+// Tests that the indexer status reflects the processed sections of each of the
+// light client chain indexers.
+func TestLightAPIIndexerStatus(t *testing.T) {
+	les := newTestLightAPIBackend(t)
+	defer closeTestLightAPIBackend(les)
+
+	api := NewPublicLightAPI(les)
+
+	status := api.IndexerStatus()
+	if status.Head != 0 {
+		t.Errorf("head mismatch: have %d, want %d", status.Head, 0)
+	}
+	for name, indexer := range map[string]IndexerStatus{"cht": status.Cht, "bloomTrie": status.BloomTrie, "bloomBits": status.BloomBits} {
+		if indexer.Sections != 0 || indexer.LastHeader != nil {
+			t.Errorf("%s: unexpected progress without sections: %d sections, last header %v", name, indexer.Sections, indexer.LastHeader)
+		}
+	}
+	// Mark two CHT sections known and ensure only that indexer progressed
+	head := common.HexToHash("0x01")
+	les.chtIndexer.AddKnownSectionHead(1, head)
+
+	status = api.IndexerStatus()
+	if status.Cht.SectionSize != light.CHTFrequencyClient {
+		t.Errorf("CHT section size mismatch: have %d, want %d", status.Cht.SectionSize, light.CHTFrequencyClient)
+	}
+	if status.Cht.Sections != 2 {
+		t.Errorf("CHT sections mismatch: have %d, want %d", status.Cht.Sections, 2)
+	}
+	if status.Cht.LastHeader == nil || uint64(*status.Cht.LastHeader) != 2*light.CHTFrequencyClient-1 {
+		t.Errorf("CHT last header mismatch: have %v, want %d", status.Cht.LastHeader, 2*light.CHTFrequencyClient-1)
+	}
+	if status.Cht.SectionHead != head {
+		t.Errorf("CHT section head mismatch: have %x, want %x", status.Cht.SectionHead, head)
+	}
+	if status.BloomTrie.Sections != 0 || status.BloomBits.Sections != 0 {
+		t.Errorf("unexpected bloom progress: bloom trie %d, bloom bits %d", status.BloomTrie.Sections, status.BloomBits.Sections)
+	}
+}
+
+// Tests that the light client reports the bloom bits of sections proven by the
+// bloom trie as available, even if not indexed locally.
+func TestLightBloomStatus(t *testing.T) {
+	les := newTestLightAPIBackend(t)
+	defer closeTestLightAPIBackend(les)
+
+	backend := &LesApiBackend{gda: les}
+	if size, sections := backend.BloomStatus(); size != light.BloomTrieFrequency || sections != 0 {
+		t.Fatalf("bloom status mismatch: have %d/%d, want %d/%d", size, sections, light.BloomTrieFrequency, 0)
+	}
+	les.bloomTrieIndexer.AddKnownSectionHead(1, common.HexToHash("0x01"))
+	if _, sections := backend.BloomStatus(); sections != 2 {
+		t.Fatalf("proven sections mismatch: have %d, want %d", sections, 2)
+	}
+	les.bloomIndexer.AddKnownSectionHead(2, common.HexToHash("0x02"))
+	if _, sections := backend.BloomStatus(); sections != 3 {
+		t.Fatalf("indexed sections mismatch: have %d, want %d", sections, 3)
+	}
+}
@@ -34,7 +34,6 @@ import (
 	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
 	"github.com/gdachain/go-gdachain/gdadb"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/internal/ethapi"
 	"github.com/gdachain/go-gdachain/light"
 	"github.com/gdachain/go-gdachain/log"
@@ -65,11 +64,11 @@ type Lightgdachain struct {
 	chainDb gdadb.Database // Block chain database
 
 	bloomRequests                              chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
+	bloomPriorityRequests                      chan chan *bloombits.Retrieval // Channel receiving latency sensitive bloom data retrieval requests
 	bloomIndexer, chtIndexer, bloomTrieIndexer *core.ChainIndexer
 
 	ApiBackend *LesApiBackend
 
-	eventMux       *event.TypeMux
 	engine         consensus.Engine
 	accountManager *accounts.Manager
 
@@ -80,6 +79,15 @@ type Lightgdachain struct {
 }
 
 func New(ctx *node.ServiceContext, config *gda.Config) (*Lightgdachain, error) {
+	if config.BloomFilterThreads <= 0 {
+		config.BloomFilterThreads = bloomFilterThreads
+	}
+	if config.BloomRetrievalBatch <= 0 {
+		config.BloomRetrievalBatch = bloomRetrievalBatch
+	}
+	if config.BloomRetrievalWait <= 0 {
+		config.BloomRetrievalWait = bloomRetrievalWait
+	}
 	chainDb, err := gda.CreateDB(ctx, config, "lightchaindata")
 	if err != nil {
 		return nil, err
@@ -97,7 +105,6 @@ func New(ctx *node.ServiceContext, config *gda.Config) (*Lightgdachain, error) {
 		config:           config,
 		chainConfig:      chainConfig,
 		chainDb:          chainDb,
-		eventMux:         ctx.EventMux,
 		peers:            peers,
 		reqDist:          newRequestDistributor(peers, quitSync),
 		accountManager:   ctx.AccountManager,
@@ -108,15 +115,29 @@ func New(ctx *node.ServiceContext, config *gda.Config) (*Lightgdachain, error) {
 		bloomIndexer:     gda.NewBloomIndexer(chainDb, light.BloomTrieFrequency),
 		chtIndexer:       light.NewChtIndexer(chainDb, true),
 		bloomTrieIndexer: light.NewBloomTrieIndexer(chainDb, true),
+
+		bloomPriorityRequests: make(chan chan *bloombits.Retrieval),
 	}
 
 	lgda.relay = NewLesTxRelay(peers, lgda.reqDist)
 	lgda.serverPool = newServerPool(chainDb, quitSync, &lgda.wg)
 	lgda.retriever = newRetrieveManager(peers, lgda.reqDist, lgda.serverPool)
 	lgda.odr = NewLesOdr(chainDb, lgda.chtIndexer, lgda.bloomTrieIndexer, lgda.bloomIndexer, lgda.retriever)
+	if config.LightRPC != "" {
+		rpcOdr, err := newRPCOdr(config.LightRPC)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to light RPC endpoint: %v", err)
+		}
+		lgda.odr.setRPCFallback(rpcOdr)
+	}
 	if lgda.blockchain, err = light.NewLightChain(lgda.odr, lgda.chainConfig, lgda.engine); err != nil {
 		return nil, err
 	}
+	checkpoints, err := gda.MakeCheckpoints(config.Checkpoints, genesisHash)
+	if err != nil {
+		return nil, err
+	}
+	lgda.blockchain.SetCheckpoints(checkpoints)
 	lgda.bloomIndexer.Start(lgda.blockchain)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -126,9 +147,10 @@ func New(ctx *node.ServiceContext, config *gda.Config) (*Lightgdachain, error) {
 	}
 
 	lgda.txPool = light.NewTxPool(lgda.chainConfig, lgda.blockchain, lgda.relay)
-	if lgda.protocolManager, err = NewProtocolManager(lgda.chainConfig, true, ClientProtocolVersions, config.NetworkId, lgda.eventMux, lgda.engine, lgda.peers, lgda.blockchain, nil, chainDb, lgda.odr, lgda.relay, quitSync, &lgda.wg); err != nil {
+	if lgda.protocolManager, err = NewProtocolManager(lgda.chainConfig, true, ClientProtocolVersions, config.NetworkId, lgda.engine, lgda.peers, lgda.blockchain, nil, chainDb, lgda.odr, lgda.relay, quitSync, &lgda.wg); err != nil {
 		return nil, err
 	}
+	lgda.protocolManager.downloader.SetCheckpoints(checkpoints)
 	lgda.ApiBackend = &LesApiBackend{lgda, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -145,6 +167,8 @@ func lesTopic(genesisHash common.Hash, protocolVersion uint) discv5.Topic {
 		name = "LES"
 	case lpv2:
 		name = "LES2"
+	case lpv3:
+		name = "LES3"
 	default:
 		panic(nil)
 	}
@@ -185,13 +209,28 @@ func (s *Lightgdachain) APIs() []rpc.API {
 		}, {
 			Namespace: "gda",
 			Version:   "1.0",
-			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader, s.eventMux),
+			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader),
 			Public:    true,
 		}, {
 			Namespace: "gda",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true),
+			Service:   downloader.NewPrivateDownloaderAPI(s.protocolManager.downloader),
+			Public:    false,
+		}, {
+			Namespace: "gda",
+			Version:   "1.0",
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, true, s.config.Filters),
 			Public:    true,
+		}, {
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPublicLightAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -210,7 +249,20 @@ func (s *Lightgdachain) TxPool() *light.TxPool              { return s.txPool }
 func (s *Lightgdachain) Engine() consensus.Engine           { return s.engine }
 func (s *Lightgdachain) LesVersion() int                    { return int(s.protocolManager.SubProtocols[0].Version) }
 func (s *Lightgdachain) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
-func (s *Lightgdachain) EventMux() *event.TypeMux           { return s.eventMux }
+
+// HealthState implements node.HealthChecker, reporting the head of the header
+// chain, the sync status and, if requested, whgdaer the chain database accepts
+// writes.
+func (s *Lightgdachain) HealthState(probeDB bool) node.HealthState {
+	state := node.HealthState{
+		Head:    time.Unix(s.blockchain.CurrentHeader().Time.Int64(), 0),
+		Syncing: s.protocolManager.downloader.Synchronising(),
+	}
+	if probeDB {
+		state.DBError = core.WriteHealthProbe(s.chainDb)
+	}
+	return state
+}
 
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
@@ -223,6 +275,7 @@ func (s *Lightgdachain) Protocols() []p2p.Protocol {
 func (s *Lightgdachain) Start(srvr *p2p.Server) error {
 	s.startBloomHandlers()
 	log.Warn("Light client mode is an experimental feature")
+	s.odr.setTrafficCheck(srvr.TrafficExhausted)
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.networkId)
 	// clients are searching for the first advertised protocol in the list
 	protocolVersion := AdvertiseProtocolVersions[0]
@@ -248,8 +301,6 @@ func (s *Lightgdachain) Stop() error {
 	s.protocolManager.Stop()
 	s.txPool.Stop()
 
-	s.eventMux.Stop()
-
 	time.Sleep(time.Millisecond * 200)
 	s.chainDb.Close()
 	close(s.shutdownChan)
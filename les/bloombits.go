@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/gdachain/go-gdachain/common/bitutil"
+	"github.com/gdachain/go-gdachain/core/bloombits"
 	"github.com/gdachain/go-gdachain/light"
 )
 
@@ -28,16 +29,16 @@ const (
 	// instance to service bloombits lookups for all running filters.
 	bloomServiceThreads = 16
 
-	// bloomFilterThreads is the number of goroutines used locally per filter to
-	// multiplex requests onto the global servicing goroutines.
+	// bloomFilterThreads is the default number of goroutines used locally per
+	// filter to multiplex requests onto the global servicing goroutines.
 	bloomFilterThreads = 3
 
-	// bloomRetrievalBatch is the maximum number of bloom bit retrievals to service
-	// in a single batch.
+	// bloomRetrievalBatch is the default maximum number of bloom bit retrievals
+	// to service in a single batch.
 	bloomRetrievalBatch = 16
 
-	// bloomRetrievalWait is the maximum time to wait for enough bloom bit requests
-	// to accumulate request an entire batch (avoiding hysteresis).
+	// bloomRetrievalWait is the default maximum time to wait for enough bloom bit
+	// requests to accumulate request an entire batch (avoiding hysteresis).
 	bloomRetrievalWait = time.Microsecond * 100
 )
 
@@ -45,32 +46,52 @@ const (
 // retrievals from possibly a range of filters and serving the data to satisfy.
 func (gda *Lightgdachain) startBloomHandlers() {
 	for i := 0; i < bloomServiceThreads; i++ {
-		go func() {
-			for {
-				select {
-				case <-gda.shutdownChan:
-					return
+		go gda.bloomHandler()
+	}
+}
+
+// bloomHandler is a single bloom bit servicing goroutine, serving retrievals from
+// the priority lane ahead of any bulk ones until the node is shut down.
+func (gda *Lightgdachain) bloomHandler() {
+	for {
+		// Drain any pending priority retrievals before touching bulk ones
+		select {
+		case request := <-gda.bloomPriorityRequests:
+			gda.serveBloomRetrieval(request)
+			continue
+		default:
+		}
+		select {
+		case <-gda.shutdownChan:
+			return
+
+		case request := <-gda.bloomPriorityRequests:
+			gda.serveBloomRetrieval(request)
+
+		case request := <-gda.bloomRequests:
+			gda.serveBloomRetrieval(request)
+		}
+	}
+}
 
-				case request := <-gda.bloomRequests:
-					task := <-request
-					task.Bitsets = make([][]byte, len(task.Sections))
-					compVectors, err := light.GetBloomBits(task.Context, gda.odr, task.Bit, task.Sections)
-					if err == nil {
-						for i := range task.Sections {
-							if blob, err := bitutil.DecompressBytes(compVectors[i], int(light.BloomTrieFrequency/8)); err == nil {
-								task.Bitsets[i] = blob
-							} else {
-								task.Error = err
-							}
-						}
-					} else {
-						task.Error = err
-					}
-					request <- task
-				}
+// serveBloomRetrieval retrieves a single bloom bit task from the network and
+// sends the results back to the requester.
+func (gda *Lightgdachain) serveBloomRetrieval(request chan *bloombits.Retrieval) {
+	task := <-request
+	task.Bitsets = make([][]byte, len(task.Sections))
+	compVectors, err := light.GetBloomBits(task.Context, gda.odr, task.Bit, task.Sections)
+	if err == nil {
+		for i := range task.Sections {
+			if blob, err := bitutil.DecompressBytes(compVectors[i], int(light.BloomTrieFrequency/8)); err == nil {
+				task.Bitsets[i] = blob
+			} else {
+				task.Error = err
 			}
-		}()
+		}
+	} else {
+		task.Error = err
 	}
+	request <- task
 }
 
 const (
@@ -0,0 +1,170 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common/mclock"
+	"github.com/gdachain/go-gdachain/les/flowcontrol"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+const (
+	// defaultBufLimit and defaultMinRecharge are the baseline flow control
+	// parameters advertised to clients, scaled by the capacity tuner.
+	defaultBufLimit    = 300000000
+	defaultMinRecharge = 50000
+
+	capacityTuneInterval = time.Minute // Time between two flow control parameter adjustments
+	capacityMinScale     = 0.25        // Minimum scaling applied to the baseline parameters
+	capacityMaxScale     = 4           // Maximum scaling applied to the baseline parameters
+	capacityMaxStep      = 1.25        // Maximum relative change in a single adjustment
+	capacityLowWater     = 0.5         // Utilisation (relative to target) below which capacity is raised
+)
+
+var capacityScaleGauge = metrics.NewRegisteredGauge("les/server/capacity/scale", nil)
+
+// capacityTuner is a feedback controller adjusting the flow control parameters
+// advertised to newly connecting clients based on the measured cost of recently
+// served requests. The cost of a request is the wall clock time spent serving
+// it, so a loaded machine serving requests slower is automatically accounted for.
+//
+// If the fraction of time spent serving requests exceeds the configured target,
+// the advertised buffer limit and recharge rate are reduced; if the server is
+// underutilised while clients are being served, they are raised. Already
+// connected clients keep the parameters they were handed in the handshake.
+type capacityTuner struct {
+	target float64 // Target fraction of wall clock time spent serving requests
+	served uint64  // Serving time (ns) accumulated since the last adjustment (atomic)
+
+	lock     sync.RWMutex
+	scale    float64                   // Current scaling of the baseline parameters
+	current  *flowcontrol.ServerParams // Currently advertised parameters
+	lastTune mclock.AbsTime            // Time of the last adjustment
+
+	quit chan struct{}
+}
+
+// newCapacityTuner creates a capacity controller aiming to spend the given
+// percentage of wall clock time on serving light client requests.
+func newCapacityTuner(targetPercent int) *capacityTuner {
+	t := &capacityTuner{
+		target:   float64(targetPercent) / 100,
+		scale:    1,
+		lastTune: mclock.Now(),
+		quit:     make(chan struct{}),
+	}
+	t.current = t.scaled(t.scale)
+	capacityScaleGauge.Update(100)
+	return t
+}
+
+// start launches the background adjustment loop.
+func (t *capacityTuner) start() {
+	go func() {
+		ticker := time.NewTicker(capacityTuneInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				now := mclock.Now()
+
+				t.lock.Lock()
+				elapsed := time.Duration(now - t.lastTune)
+				t.lastTune = now
+				t.lock.Unlock()
+
+				t.tune(atomic.SwapUint64(&t.served, 0), elapsed)
+
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop terminates the background adjustment loop.
+func (t *capacityTuner) stop() {
+	close(t.quit)
+}
+
+// params returns the flow control parameters to advertise to a new client.
+func (t *capacityTuner) params() *flowcontrol.ServerParams {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.current
+}
+
+// addServed accounts the measured cost of a served request.
+func (t *capacityTuner) addServed(cost uint64) {
+	atomic.AddUint64(&t.served, cost)
+}
+
+// tune adjusts the advertised parameters based on the time spent serving requests
+// during the elapsed adjustment period.
+func (t *capacityTuner) tune(served uint64, elapsed time.Duration) {
+	if elapsed <= 0 || served == 0 || t.target <= 0 {
+		return // No traffic, nothing to base an adjustment on
+	}
+	utilisation := float64(served) / float64(elapsed)
+
+	// Scale proportionally towards the target, limiting the step size to damp
+	// oscillations caused by the delayed effect on connected clients
+	ratio := t.target / utilisation
+	if ratio > 1 && utilisation > t.target*capacityLowWater {
+		return // Within the comfortable band, leave the parameters alone
+	}
+	if ratio > capacityMaxStep {
+		ratio = capacityMaxStep
+	}
+	if ratio < 1/capacityMaxStep {
+		ratio = 1 / capacityMaxStep
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	scale := t.scale * ratio
+	if scale < capacityMinScale {
+		scale = capacityMinScale
+	}
+	if scale > capacityMaxScale {
+		scale = capacityMaxScale
+	}
+	if scale == t.scale {
+		return
+	}
+	t.scale, t.current = scale, t.scaled(scale)
+	capacityScaleGauge.Update(int64(scale * 100))
+
+	log.Debug("Adjusted light client capacity", "utilisation", utilisation, "target", t.target, "scale", scale,
+		"buflimit", t.current.BufLimit, "recharge", t.current.MinRecharge)
+}
+
+// scaled returns the baseline flow control parameters scaled by the given factor.
+// A fresh struct is returned as connected clients retain their old parameters.
+func (t *capacityTuner) scaled(scale float64) *flowcontrol.ServerParams {
+	return &flowcontrol.ServerParams{
+		BufLimit:    uint64(defaultBufLimit * scale),
+		MinRecharge: uint64(defaultMinRecharge * scale),
+	}
+}
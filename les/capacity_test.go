@@ -0,0 +1,62 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the capacity tuner lowers the advertised flow control parameters
+// when overloaded, raises them when underutilised and leaves them alone when
+// the utilisation is within the comfortable band.
+func TestCapacityTuning(t *testing.T) {
+	tuner := newCapacityTuner(50)
+	base := tuner.params()
+
+	// Overloaded server (90% busy vs 50% target), parameters must shrink
+	tuner.tune(uint64(90*time.Millisecond), 100*time.Millisecond)
+	shrunk := tuner.params()
+	if shrunk.BufLimit >= base.BufLimit || shrunk.MinRecharge >= base.MinRecharge {
+		t.Fatalf("overloaded server did not reduce capacity: have %+v, base %+v", shrunk, base)
+	}
+	if shrunk == base {
+		t.Fatalf("adjusted parameters must not alias previously advertised ones")
+	}
+	// Comfortably loaded server (40% busy vs 50% target), parameters must stay
+	tuner.tune(uint64(40*time.Millisecond), 100*time.Millisecond)
+	if kept := tuner.params(); kept != shrunk {
+		t.Fatalf("balanced server changed capacity: have %+v, want %+v", kept, shrunk)
+	}
+	// Idle server, no measurements to base an adjustment on
+	tuner.tune(0, 100*time.Millisecond)
+	if kept := tuner.params(); kept != shrunk {
+		t.Fatalf("idle server changed capacity: have %+v, want %+v", kept, shrunk)
+	}
+	// Underutilised server (5% busy vs 50% target), parameters must grow
+	tuner.tune(uint64(5*time.Millisecond), 100*time.Millisecond)
+	if grown := tuner.params(); grown.MinRecharge <= shrunk.MinRecharge {
+		t.Fatalf("underutilised server did not raise capacity: have %+v, previous %+v", grown, shrunk)
+	}
+	// Repeated adjustments must stay within the permitted scaling range
+	for i := 0; i < 100; i++ {
+		tuner.tune(uint64(time.Millisecond), 100*time.Millisecond)
+	}
+	if max := tuner.params(); max.MinRecharge != uint64(defaultMinRecharge*capacityMaxScale) {
+		t.Fatalf("capacity scaling unbounded: have %d, want %d", max.MinRecharge, uint64(defaultMinRecharge*capacityMaxScale))
+	}
+}
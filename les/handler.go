@@ -18,6 +18,7 @@
 package les
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -58,6 +59,8 @@ const (
 	MaxHelperTrieProofsFetch = 64  // Amount of merkle proofs to be fetched per retrieval request
 	MaxTxSend                = 64  // Amount of transactions to be send per request
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
+	MaxTxInclusionProofs     = 64  // Amount of transaction inclusion proofs to be fetched per request
+	MaxLogsRange             = 512 // Amount of blocks to be filtered per delegated log query
 
 	disableClientRemovePeer = false
 )
@@ -113,8 +116,6 @@ type ProtocolManager struct {
 
 	SubProtocols []p2p.Protocol
 
-	eventMux *event.TypeMux
-
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
 	quitSync    chan struct{}
@@ -127,11 +128,10 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new gdaereum sub protocol manager. The gdachain sub protocol manages peers capable
 // with the gdaereum network.
-func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb gdadb.Database, odr *LesOdr, txrelay *LesTxRelay, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
+func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb gdadb.Database, odr *LesOdr, txrelay *LesTxRelay, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		lightSync:   lightSync,
-		eventMux:    mux,
 		blockchain:  blockchain,
 		chainConfig: chainConfig,
 		chainDb:     chainDb,
@@ -204,7 +204,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 	}
 
 	if lightSync {
-		manager.downloader = downloader.New(downloader.LightSync, chainDb, manager.eventMux, nil, blockchain, removePeer)
+		manager.downloader = downloader.New(downloader.LightSync, chainDb, nil, blockchain, removePeer)
 		manager.peers.notify((*downloaderPeerNotify)(manager))
 		manager.fetcher = newLightFetcher(manager)
 	}
@@ -330,7 +330,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetTxInclusionProofsMsg, GetLogsMsg}
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
@@ -349,11 +349,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
@@ -1076,6 +1076,92 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
 
+	case GetTxInclusionProofsMsg:
+		if pm.txpool == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+		p.Log().Trace("Received tx inclusion proof request")
+		var req struct {
+			ReqID uint64
+			Query txInclusionQuery
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqCnt := len(req.Query.Hashes)
+		if reject(uint64(reqCnt), MaxTxInclusionProofs) {
+			return errResp(ErrRequestRejected, "")
+		}
+		proofs := pm.txInclusionProofs(req.Query)
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
+		pm.server.fcCosgdaats.update(msg.Code, uint64(reqCnt), rcost)
+
+		return p.SendTxInclusionProofs(req.ReqID, bv, proofs)
+
+	case TxInclusionProofsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received tx inclusion proof response")
+		var resp struct {
+			ReqID, BV uint64
+			Proofs    []txInclusionProof
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgTxInclusionProofs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Proofs,
+		}
+
+	case GetLogsMsg:
+		p.Log().Trace("Received logs request")
+		var req struct {
+			ReqID uint64
+			Query logsQuery
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if req.Query.ToBlock < req.Query.FromBlock {
+			return errResp(ErrRequestRejected, "")
+		}
+		reqCnt := req.Query.ToBlock - req.Query.FromBlock + 1
+		if reject(reqCnt, MaxLogsRange) {
+			return errResp(ErrRequestRejected, "")
+		}
+		resp := pm.filterLogs(req.Query)
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + reqCnt*costs.reqCost)
+		pm.server.fcCosgdaats.update(msg.Code, reqCnt, rcost)
+
+		return p.SendLogs(req.ReqID, bv, resp)
+
+	case LogsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received logs response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      logsResp
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgLogs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
+
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -1151,6 +1237,159 @@ func (pm *ProtocolManager) txStatus(hashes []common.Hash) []txStatus {
 	return stats
 }
 
+// txInclusionProofs assembles the status of the requested transactions, and for
+// the ones already included in the canonical chain, the merkle proofs needed to
+// verify their inclusion without trusting the serving node.
+func (pm *ProtocolManager) txInclusionProofs(query txInclusionQuery) []txInclusionProof {
+	var (
+		size   int
+		proofs []txInclusionProof
+	)
+	for _, stat := range pm.txStatus(query.Hashes) {
+		proof := txInclusionProof{Status: stat}
+		if stat.Lookup != nil {
+			pm.proveTxInclusion(&proof, query.ChtCount)
+		}
+		proofs = append(proofs, proof)
+		if size += len(proof.Header) + proof.TxProof.DataSize() + proof.ChtProof.DataSize(); size >= softResponseLimit {
+			break
+		}
+	}
+	return proofs
+}
+
+// proveTxInclusion fills in the header, transaction trie and (if requested) CHT
+// proofs for a transaction that is known to be included in the local chain.
+func (pm *ProtocolManager) proveTxInclusion(proof *txInclusionProof, chtCount uint64) {
+	lookup := proof.Status.Lookup
+
+	// Only canonical blocks can be proven against the CHT
+	if core.GetCanonicalHash(pm.chainDb, lookup.BlockIndex) != lookup.BlockHash {
+		return
+	}
+	body := core.GetBody(pm.chainDb, lookup.BlockHash, lookup.BlockIndex)
+	if body == nil || lookup.Index >= uint64(len(body.Transactions)) {
+		return
+	}
+	proof.Header = core.GetHeaderRLP(pm.chainDb, lookup.BlockHash, lookup.BlockIndex)
+
+	// Recreate the transaction trie of the block and prove the requested index
+	key, _ := rlp.EncodeToBytes(uint(lookup.Index))
+	deriveTrie(types.Transactions(body.Transactions)).Prove(key, 0, &proof.TxProof)
+
+	// If the block is covered by the client's CHT sections, prove the header too
+	if lookup.BlockIndex >= chtCount*light.CHTFrequencyClient {
+		return
+	}
+	idx := chtCount - 1
+	if root, prefix := pm.getHelperTrie(htCanonical, idx); root != (common.Hash{}) {
+		chtTrie, err := trie.New(root, trie.NewDatabase(gdadb.NewTable(pm.chainDb, prefix)))
+		if err != nil {
+			return
+		}
+		var encNumber [8]byte
+		binary.BigEndian.PutUint64(encNumber[:], lookup.BlockIndex)
+
+		proof.ChtIdx = idx
+		chtTrie.Prove(encNumber[:], 0, &proof.ChtProof)
+	}
+}
+
+// filterLogs executes a delegated log filter query on the local canonical chain,
+// returning all the receipts of the blocks whose bloom matches the filter, and
+// the CHT proofs of the processed headers the client may not know.
+func (pm *ProtocolManager) filterLogs(query logsQuery) logsResp {
+	var (
+		resp     logsResp
+		size     int
+		chtTrie  *trie.Trie
+		chtProof = light.NewNodeSet()
+	)
+	provenLimit := query.ChtCount * light.CHTFrequencyClient
+	if query.FromBlock < provenLimit {
+		root, prefix := pm.getHelperTrie(htCanonical, query.ChtCount-1)
+		if root == (common.Hash{}) {
+			return resp
+		}
+		var err error
+		if chtTrie, err = trie.New(root, trie.NewDatabase(gdadb.NewTable(pm.chainDb, prefix))); err != nil {
+			return resp
+		}
+	}
+	for number := query.FromBlock; number <= query.ToBlock && size < softResponseLimit; number++ {
+		hash := core.GetCanonicalHash(pm.chainDb, number)
+		if hash == (common.Hash{}) {
+			break
+		}
+		header := core.GetHeader(pm.chainDb, hash, number)
+		if header == nil {
+			break
+		}
+		if query.Filter.MatchBloom(header.Bloom) {
+			receipts := core.GetBlockReceipts(pm.chainDb, hash, number)
+			body := core.GetBody(pm.chainDb, hash, number)
+			if body == nil || len(receipts) != len(body.Transactions) {
+				break
+			}
+			block := logsBlockProof{Number: number, Receipts: receipts}
+
+			var txTrie *trie.Trie
+			for i, receipt := range receipts {
+				matched := false
+				for _, log := range receipt.Logs {
+					if query.Filter.MatchLog(log) {
+						matched = true
+						break
+					}
+				}
+				if matched {
+					if txTrie == nil {
+						txTrie = deriveTrie(types.Transactions(body.Transactions))
+					}
+					var proof light.NodeList
+					key, _ := rlp.EncodeToBytes(uint(i))
+					txTrie.Prove(key, 0, &proof)
+					block.TxProofs = append(block.TxProofs, proof)
+
+					size += proof.DataSize()
+				}
+			}
+			resp.Blocks = append(resp.Blocks, block)
+			if enc, err := rlp.EncodeToBytes(receipts); err == nil {
+				size += len(enc)
+			}
+		}
+		// Prove the header if the client can only verify it against the CHT
+		if number < provenLimit {
+			var encNumber [8]byte
+			binary.BigEndian.PutUint64(encNumber[:], number)
+			chtTrie.Prove(encNumber[:], 0, chtProof)
+
+			enc := core.GetHeaderRLP(pm.chainDb, hash, number)
+			resp.Headers = append(resp.Headers, enc)
+			size += len(enc)
+		}
+		resp.Last = number
+	}
+	resp.ChtProof = chtProof.NodeList()
+	return resp
+}
+
+// deriveTrie recreates the in-memory merkle trie of a derivable list, the same
+// way types.DeriveSha does, so that proofs can be generated from it.
+func deriveTrie(list types.DerivableList) *trie.Trie {
+	var (
+		keybuf = new(bytes.Buffer)
+		t      = new(trie.Trie)
+	)
+	for i := 0; i < list.Len(); i++ {
+		keybuf.Reset()
+		rlp.Encode(keybuf, uint(i))
+		t.Update(keybuf.Bytes(), list.GetRlp(i))
+	}
+	return t
+}
+
 // NodeInfo represents a short summary of the gdachain sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
@@ -578,3 +578,200 @@ func TestTransactionStatusLes2(t *testing.T) {
 	test(tx1, false, txStatus{Status: core.TxStatusPending})
 	test(tx2, false, txStatus{Status: core.TxStatusPending})
 }
+
+func TestTransactionInclusionProofsLes3(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	chain := pm.blockchain.(*core.BlockChain)
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+	pm.txpool = core.NewTxPool(config, params.TestChainConfig, chain)
+	peer, _ := newTestPeer(t, "peer", 3, pm, true)
+	defer peer.close()
+
+	block := chain.GetBlockByNumber(2)
+	for i, tx := range block.Transactions() {
+		query := txInclusionQuery{Hashes: []common.Hash{tx.Hash()}}
+		proofs := pm.txInclusionProofs(query)
+		if len(proofs) != 1 {
+			t.Fatalf("tx %d: proof count mismatch: have %d, want 1", i, len(proofs))
+		}
+		header, err := verifyTxInclusion(&proofs[0], tx.Hash(), common.Hash{})
+		if err != nil {
+			t.Fatalf("tx %d: failed to verify inclusion proof: %v", i, err)
+		}
+		if header.Hash() != block.Hash() {
+			t.Errorf("tx %d: header mismatch: have %x, want %x", i, header.Hash(), block.Hash())
+		}
+		if _, err := verifyTxInclusion(&proofs[0], common.Hash{}, common.Hash{}); err != errTxHashMismatch {
+			t.Errorf("tx %d: proof verified for wrong transaction: %v", i, err)
+		}
+		// Send the request over the wire and verify the response
+		cost := peer.GetRequestCost(GetTxInclusionProofsMsg, 1)
+		sendRequest(peer.app, GetTxInclusionProofsMsg, uint64(i), cost, query)
+		if err := expectResponse(peer.app, TxInclusionProofsMsg, uint64(i), testBufLimit, proofs); err != nil {
+			t.Errorf("tx %d: proofs mismatch: %v", i, err)
+		}
+	}
+	// Unknown transactions should not produce any proofs
+	proofs := pm.txInclusionProofs(txInclusionQuery{Hashes: []common.Hash{{0x01}}})
+	if _, err := verifyTxInclusion(&proofs[0], common.Hash{0x01}, common.Hash{}); err != errTxNotIncluded {
+		t.Errorf("unknown transaction verified: %v", err)
+	}
+}
+
+func TestTransactionStatusRequestLes3(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	chain := pm.blockchain.(*core.BlockChain)
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+	pm.txpool = core.NewTxPool(config, params.TestChainConfig, chain)
+
+	block := chain.GetBlockByNumber(2)
+	hashes := []common.Hash{block.Transactions()[1].Hash(), {0x01}}
+	proofs := pm.txInclusionProofs(txInclusionQuery{Hashes: hashes})
+
+	// Statuses proven against the local canonical chain should be accepted
+	req := &TxStatusRequest{Hashes: hashes}
+	if err := req.Validate(db, &Msg{MsgType: MsgTxInclusionProofs, Obj: proofs}); err != nil {
+		t.Fatalf("failed to validate statuses: %v", err)
+	}
+	if len(req.Status) != 2 {
+		t.Fatalf("status count mismatch: have %d, want 2", len(req.Status))
+	}
+	want := core.TxLookupEntry{BlockHash: block.Hash(), BlockIndex: 2, Index: 1}
+	if req.Status[0].Status != core.TxStatusIncluded || req.Status[0].Lookup == nil || *req.Status[0].Lookup != want {
+		t.Errorf("included status mismatch: have %+v, want lookup %+v", req.Status[0], want)
+	}
+	if req.Status[1].Status != core.TxStatusUnknown || req.Status[1].Lookup != nil {
+		t.Errorf("unknown status mismatch: have %+v", req.Status[1])
+	}
+	// Headers unknown to the client must not be accepted without a CHT proof
+	ldb, _ := gdadb.NewMemDatabase()
+	req = &TxStatusRequest{Hashes: hashes}
+	if err := req.Validate(ldb, &Msg{MsgType: MsgTxInclusionProofs, Obj: proofs}); err != errNonCanonicalHeader {
+		t.Errorf("unknown header accepted: %v", err)
+	}
+	// Blocks covered by the trusted CHT must be proven by it
+	req = &TxStatusRequest{Hashes: hashes, ChtCount: 2, ChtRoot: common.Hash{0x02}}
+	if err := req.Validate(db, &Msg{MsgType: MsgTxInclusionProofs, Obj: proofs}); err != errCHTNumberMismatch {
+		t.Errorf("header accepted from the wrong CHT section: %v", err)
+	}
+	proofs = pm.txInclusionProofs(txInclusionQuery{Hashes: hashes, ChtCount: 2})
+	if err := req.Validate(db, &Msg{MsgType: MsgTxInclusionProofs, Obj: proofs}); err == nil {
+		t.Errorf("header accepted without a valid CHT proof")
+	}
+	// Empty and oversized replies are invalid
+	req = &TxStatusRequest{Hashes: hashes[:1]}
+	if err := req.Validate(db, &Msg{MsgType: MsgTxInclusionProofs, Obj: proofs}); err != errInvalidEntryCount {
+		t.Errorf("oversized reply accepted: %v", err)
+	}
+	if err := req.Validate(db, &Msg{MsgType: MsgTxInclusionProofs, Obj: []txInclusionProof{}}); err != errInvalidEntryCount {
+		t.Errorf("empty reply accepted: %v", err)
+	}
+}
+
+func TestGetLogsLes3(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	chain := pm.blockchain.(*core.BlockChain)
+	peer, _ := newTestPeer(t, "peer", 3, pm, true)
+	defer peer.close()
+
+	// Collect all the logs of the chain as the expected result of a wildcard query
+	var expected []*types.Log
+	for i := uint64(0); i <= chain.CurrentBlock().NumberU64(); i++ {
+		block := chain.GetBlockByNumber(i)
+		for _, receipt := range core.GetBlockReceipts(db, block.Hash(), i) {
+			expected = append(expected, receipt.Logs...)
+		}
+	}
+	query := logsQuery{FromBlock: 0, ToBlock: chain.CurrentBlock().NumberU64()}
+	resp := pm.filterLogs(query)
+	if resp.Last != query.ToBlock {
+		t.Fatalf("last block mismatch: have %d, want %d", resp.Last, query.ToBlock)
+	}
+	req := &LogsRequest{FromBlock: query.FromBlock, ToBlock: query.ToBlock}
+	if err := req.Validate(db, &Msg{MsgType: MsgLogs, Obj: resp}); err != nil {
+		t.Fatalf("failed to validate logs: %v", err)
+	}
+	if len(req.Logs) != len(expected) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(req.Logs), len(expected))
+	}
+	for i, log := range req.Logs {
+		if log.TxHash != expected[i].TxHash || log.Index != expected[i].Index || log.BlockHash != expected[i].BlockHash {
+			t.Errorf("log %d: derived fields mismatch: have %+v, want %+v", i, log, expected[i])
+		}
+	}
+	// Send the request over the wire and verify the response
+	cost := peer.GetRequestCost(GetLogsMsg, int(query.ToBlock-query.FromBlock+1))
+	sendRequest(peer.app, GetLogsMsg, 42, cost, query)
+	if err := expectResponse(peer.app, LogsMsg, 42, testBufLimit, resp); err != nil {
+		t.Errorf("logs mismatch: %v", err)
+	}
+	// Oversized ranges should be rejected by the request side splitting
+	req = &LogsRequest{FromBlock: 0, ToBlock: 10 * MaxLogsRange}
+	if to := req.toBlock(); to != MaxLogsRange-1 {
+		t.Errorf("request range mismatch: have %d, want %d", to, MaxLogsRange-1)
+	}
+	// Replies omitting matching blocks or altering receipts should be rejected
+	omitted := pm.filterLogs(query)
+	omitted.Blocks = omitted.Blocks[1:]
+	req = &LogsRequest{FromBlock: query.FromBlock, ToBlock: query.ToBlock}
+	if err := req.Validate(db, &Msg{MsgType: MsgLogs, Obj: omitted}); err != errLogsMissing {
+		t.Errorf("incomplete reply accepted: %v", err)
+	}
+	altered := pm.filterLogs(query)
+	for _, block := range altered.Blocks {
+		for _, receipt := range block.Receipts {
+			if len(receipt.Logs) > 0 {
+				receipt.Logs = receipt.Logs[1:]
+			}
+		}
+	}
+	if err := req.Validate(db, &Msg{MsgType: MsgLogs, Obj: altered}); err != errReceiptHashMismatch {
+		t.Errorf("altered receipts accepted: %v", err)
+	}
+	if err := (&LogsRequest{FromBlock: 1, ToBlock: query.ToBlock}).Validate(db, &Msg{MsgType: MsgLogs, Obj: logsResp{}}); err != errInvalidLogsRange {
+		t.Errorf("empty reply accepted: %v", err)
+	}
+	// Clients without the headers should only accept them proven by the CHT
+	ldb, _ := gdadb.NewMemDatabase()
+	if err := req.Validate(ldb, &Msg{MsgType: MsgLogs, Obj: resp}); err != errHeaderUnavailable {
+		t.Errorf("unknown headers accepted: %v", err)
+	}
+	triedb := trie.NewDatabase(gdadb.NewTable(db, light.ChtTablePrefix))
+	cht, _ := trie.New(common.Hash{}, triedb)
+	for i := uint64(0); i <= query.ToBlock; i++ {
+		hash := core.GetCanonicalHash(db, i)
+
+		var encNumber [8]byte
+		binary.BigEndian.PutUint64(encNumber[:], i)
+		data, _ := rlp.EncodeToBytes(light.ChtNode{Hash: hash, Td: core.GetTd(db, hash, i)})
+		cht.Update(encNumber[:], data)
+	}
+	root, _ := cht.Commit(nil)
+	triedb.Commit(root, false)
+	light.StoreChtRoot(db, light.CHTFrequencyClient/light.CHTFrequencyServer-1, common.Hash{}, root)
+
+	query.ChtCount = 1
+	proven := pm.filterLogs(query)
+	if len(proven.Headers) != int(query.ToBlock+1) {
+		t.Fatalf("proven header count mismatch: have %d, want %d", len(proven.Headers), query.ToBlock+1)
+	}
+	req = &LogsRequest{FromBlock: query.FromBlock, ToBlock: query.ToBlock, ChtCount: 1, ChtRoot: root}
+	if err := req.Validate(ldb, &Msg{MsgType: MsgLogs, Obj: proven}); err != nil {
+		t.Fatalf("failed to validate proven logs: %v", err)
+	}
+	if len(req.Logs) != len(expected) {
+		t.Fatalf("proven log count mismatch: have %d, want %d", len(req.Logs), len(expected))
+	}
+	if err := req.Validate(ldb, &Msg{MsgType: MsgLogs, Obj: resp}); err != errInvalidEntryCount {
+		t.Errorf("unproven headers accepted: %v", err)
+	}
+	req = &LogsRequest{FromBlock: query.FromBlock, ToBlock: query.ToBlock, ChtCount: 1, ChtRoot: common.Hash{0x01}}
+	if err := req.Validate(ldb, &Msg{MsgType: MsgLogs, Obj: proven}); err == nil {
+		t.Errorf("headers accepted against the wrong CHT root")
+	}
+}
@@ -33,7 +33,6 @@ import (
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/gda"
 	"github.com/gdachain/go-gdachain/gdadb"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/les/flowcontrol"
 	"github.com/gdachain/go-gdachain/light"
 	"github.com/gdachain/go-gdachain/p2p"
@@ -138,7 +137,6 @@ func testRCL() RequestCostList {
 // channels for different events.
 func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *core.BlockGen), peers *peerSet, odr *LesOdr, db gdadb.Database) (*ProtocolManager, error) {
 	var (
-		evmux  = new(event.TypeMux)
 		engine = ethash.NewFaker()
 		gspec  = core.Genesis{
 			Config: params.TestChainConfig,
@@ -178,7 +176,7 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 	} else {
 		protocolVersions = ServerProtocolVersions
 	}
-	pm, err := NewProtocolManager(gspec.Config, lightSync, protocolVersions, NetworkId, evmux, engine, peers, chain, nil, db, odr, nil, make(chan struct{}), new(sync.WaitGroup))
+	pm, err := NewProtocolManager(gspec.Config, lightSync, protocolVersions, NetworkId, engine, peers, chain, nil, db, odr, nil, make(chan struct{}), new(sync.WaitGroup))
 	if err != nil {
 		return nil, err
 	}
@@ -18,11 +18,14 @@ package les
 
 import (
 	"context"
+	"sync"
 
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/light"
 	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/hashicorp/golang-lru"
 )
 
 // LesOdr implements light.OdrBackend
@@ -31,9 +34,16 @@ type LesOdr struct {
 	chtIndexer, bloomTrieIndexer, bloomIndexer *core.ChainIndexer
 	retriever                                  *retrieveManager
 	stop                                       chan struct{}
+	exhausted                                  func() bool // Reports whether the traffic quota is used up
+	rpc                                        *rpcOdr     // Trusted full node to retrieve from if no LES server is available
+
+	cache    *lru.Cache              // Recently retrieved results, keyed by request identity
+	inflight map[string]*odrInflight // Retrievals currently in progress, keyed by request identity
+	lock     sync.Mutex              // Protects the inflight map
 }
 
 func NewLesOdr(db gdadb.Database, chtIndexer, bloomTrieIndexer, bloomIndexer *core.ChainIndexer, retriever *retrieveManager) *LesOdr {
+	cache, _ := lru.New(odrCacheItems)
 	return &LesOdr{
 		db:               db,
 		chtIndexer:       chtIndexer,
@@ -41,12 +51,29 @@ func NewLesOdr(db gdadb.Database, chtIndexer, bloomTrieIndexer, bloomIndexer *co
 		bloomIndexer:     bloomIndexer,
 		retriever:        retriever,
 		stop:             make(chan struct{}),
+		cache:            cache,
+		inflight:         make(map[string]*odrInflight),
 	}
 }
 
 // Stop cancels all pending retrievals
 func (odr *LesOdr) Stop() {
 	close(odr.stop)
+	if odr.rpc != nil {
+		odr.rpc.close()
+	}
+}
+
+// setTrafficCheck installs a callback reporting whether the network traffic
+// quota is used up, in which case retrievals fail immediately.
+func (odr *LesOdr) setTrafficCheck(exhausted func() bool) {
+	odr.exhausted = exhausted
+}
+
+// setRPCFallback installs a trusted full node to retrieve data from whenever
+// no LES server can be asked or the LES retrieval fails.
+func (odr *LesOdr) setRPCFallback(rpc *rpcOdr) {
+	odr.rpc = rpc
 }
 
 // Database returns the backing database
@@ -77,6 +104,8 @@ const (
 	MsgProofsV2
 	MsgHeaderProofs
 	MsgHelperTrieProofs
+	MsgLogs
+	MsgTxInclusionProofs
 )
 
 // Msg encodes a LES message that delivers reply data for a request
@@ -88,7 +117,73 @@ type Msg struct {
 
 // Retrieve tries to fetch an object from the LES network.
 // If the network retrieval was successful, it stores the object in local db.
-func (odr *LesOdr) Retrieve(ctx context.Context, req light.OdrRequest) (err error) {
+//
+// Identical concurrent retrievals are coalesced into a single network request
+// and recent results are served from memory.
+func (odr *LesOdr) Retrieve(ctx context.Context, req light.OdrRequest) error {
+	key := odrRequestKey(req)
+	if key == "" {
+		return odr.retrieve(ctx, req)
+	}
+	for {
+		odr.lock.Lock()
+		if cached, ok := odr.cache.Get(key); ok {
+			odr.lock.Unlock()
+			odrCacheHitMeter.Mark(1)
+			copyOdrResult(req, cached.(light.OdrRequest))
+			req.StoreResult(odr.db)
+			return nil
+		}
+		if f, ok := odr.inflight[key]; ok {
+			odr.lock.Unlock()
+			odrDedupMeter.Mark(1)
+
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			// If the leading retrieval was only cancelled by its own caller,
+			// try again on behalf of this one
+			if f.err == context.Canceled || f.err == context.DeadlineExceeded {
+				continue
+			}
+			if f.err == nil {
+				copyOdrResult(req, f.req)
+				req.StoreResult(odr.db)
+			}
+			return f.err
+		}
+		f := &odrInflight{done: make(chan struct{})}
+		odr.inflight[key] = f
+		odr.lock.Unlock()
+
+		// Share a private copy of the results, the caller is free to modify its own
+		f.err = odr.retrieve(ctx, req)
+		if f.err == nil {
+			f.req = cloneOdrResult(req)
+		}
+		odr.lock.Lock()
+		if f.err == nil {
+			odr.cache.Add(key, f.req)
+		}
+		delete(odr.inflight, key)
+		odr.lock.Unlock()
+
+		close(f.done)
+		return f.err
+	}
+}
+
+// retrieve fetches an object from the LES network without any deduplication.
+func (odr *LesOdr) retrieve(ctx context.Context, req light.OdrRequest) (err error) {
+	exhausted := odr.exhausted != nil && odr.exhausted()
+	if odr.rpc != nil && (exhausted || odr.retriever.peers.Len() == 0) {
+		return odr.retrieveRPC(ctx, req)
+	}
+	if exhausted {
+		return p2p.ErrTrafficQuotaExceeded
+	}
 	lreq := LesRequest(req)
 
 	reqID := genReqID()
@@ -113,6 +208,20 @@ func (odr *LesOdr) Retrieve(ctx context.Context, req light.OdrRequest) (err erro
 		req.StoreResult(odr.db)
 	} else {
 		log.Debug("Failed to retrieve data from network", "err", err)
+		if odr.rpc != nil && ctx.Err() == nil {
+			return odr.retrieveRPC(ctx, req)
+		}
 	}
 	return
 }
+
+// retrieveRPC fetches an object from the trusted full node and stores it in the
+// local db if the retrieval was successful.
+func (odr *LesOdr) retrieveRPC(ctx context.Context, req light.OdrRequest) error {
+	if err := odr.rpc.retrieve(ctx, odr.db, req); err != nil {
+		log.Debug("Failed to retrieve data over RPC", "err", err)
+		return err
+	}
+	req.StoreResult(odr.db)
+	return nil
+}
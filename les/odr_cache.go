@@ -0,0 +1,152 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+// odrCacheItems is the number of recently retrieved ODR results kept in memory.
+const odrCacheItems = 256
+
+var (
+	odrCacheHitMeter = metrics.NewRegisteredMeter("les/odr/cache/hit", nil)
+	odrDedupMeter    = metrics.NewRegisteredMeter("les/odr/dedup", nil)
+)
+
+// odrInflight is a network retrieval in progress that identical requests can
+// wait for instead of issuing their own.
+type odrInflight struct {
+	req  light.OdrRequest // Private copy of the retrieved results, valid after done is closed
+	done chan struct{}    // Closed when the retrieval finished
+	err  error            // Result of the retrieval, valid after done is closed
+}
+
+// odrRequestKey returns a key identifying the data requested by an ODR request,
+// or an empty string if the request type should not be deduplicated.
+func odrRequestKey(req light.OdrRequest) string {
+	switch r := req.(type) {
+	case *light.BlockRequest:
+		return "b" + string(r.Hash[:])
+	case *light.ReceiptsRequest:
+		return "r" + string(r.Hash[:])
+	case *light.CodeRequest:
+		return "c" + string(r.Hash[:])
+	case *light.TrieRequest:
+		return "t" + string(r.Id.Root[:]) + string(r.Key)
+	case *light.ChtRequest:
+		var enc [16]byte
+		binary.BigEndian.PutUint64(enc[:8], r.ChtNum)
+		binary.BigEndian.PutUint64(enc[8:], r.BlockNum)
+		return "h" + string(r.ChtRoot[:]) + string(enc[:])
+	}
+	return ""
+}
+
+// copyOdrResult deep copies the retrieved results of src into the identical
+// request dst, so that the callers of the two requests can't race on them.
+func copyOdrResult(dst, src light.OdrRequest) {
+	switch r := dst.(type) {
+	case *light.BlockRequest:
+		r.Rlp = common.CopyBytes(src.(*light.BlockRequest).Rlp)
+	case *light.ReceiptsRequest:
+		r.Receipts = copyReceipts(src.(*light.ReceiptsRequest).Receipts)
+	case *light.CodeRequest:
+		r.Data = common.CopyBytes(src.(*light.CodeRequest).Data)
+	case *light.TrieRequest:
+		r.Proof = copyNodeSet(src.(*light.TrieRequest).Proof)
+	case *light.ChtRequest:
+		s := src.(*light.ChtRequest)
+		if s.Header != nil {
+			r.Header = types.CopyHeader(s.Header)
+		}
+		if s.Td != nil {
+			r.Td = new(big.Int).Set(s.Td)
+		}
+		r.Proof = copyNodeSet(s.Proof)
+	}
+}
+
+// cloneOdrResult creates a request holding a private deep copy of the retrieved
+// results of req, to be shared with the identical requests.
+func cloneOdrResult(req light.OdrRequest) light.OdrRequest {
+	var clone light.OdrRequest
+	switch req.(type) {
+	case *light.BlockRequest:
+		clone = new(light.BlockRequest)
+	case *light.ReceiptsRequest:
+		clone = new(light.ReceiptsRequest)
+	case *light.CodeRequest:
+		clone = new(light.CodeRequest)
+	case *light.TrieRequest:
+		clone = new(light.TrieRequest)
+	case *light.ChtRequest:
+		clone = new(light.ChtRequest)
+	default:
+		return req
+	}
+	copyOdrResult(clone, req)
+	return clone
+}
+
+// copyReceipts deep copies a list of receipts along with their logs.
+func copyReceipts(receipts types.Receipts) types.Receipts {
+	if receipts == nil {
+		return nil
+	}
+	cpy := make(types.Receipts, len(receipts))
+	for i, receipt := range receipts {
+		r := *receipt
+		r.Posgdaate = common.CopyBytes(receipt.Posgdaate)
+		r.ReturnData = common.CopyBytes(receipt.ReturnData)
+		if receipt.BlockNumber != nil {
+			r.BlockNumber = new(big.Int).Set(receipt.BlockNumber)
+		}
+		if receipt.To != nil {
+			to := *receipt.To
+			r.To = &to
+		}
+		if receipt.EffectiveGasPrice != nil {
+			r.EffectiveGasPrice = new(big.Int).Set(receipt.EffectiveGasPrice)
+		}
+		if receipt.Logs != nil {
+			r.Logs = make([]*types.Log, len(receipt.Logs))
+			for j, log := range receipt.Logs {
+				l := *log
+				l.Topics = append([]common.Hash(nil), log.Topics...)
+				l.Data = common.CopyBytes(log.Data)
+				r.Logs[j] = &l
+			}
+		}
+		cpy[i] = &r
+	}
+	return cpy
+}
+
+// copyNodeSet deep copies a set of trie nodes.
+func copyNodeSet(set *light.NodeSet) *light.NodeSet {
+	if set == nil {
+		return nil
+	}
+	return set.NodeList().NodeSet()
+}
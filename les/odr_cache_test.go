@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/light"
+)
+
+func TestOdrRequestKeys(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	reqs := []light.OdrRequest{
+		&light.BlockRequest{Hash: hash},
+		&light.ReceiptsRequest{Hash: hash},
+		&light.CodeRequest{Hash: hash},
+		&light.TrieRequest{Id: &light.TrieID{Root: hash}, Key: []byte{1}},
+		&light.TrieRequest{Id: &light.TrieID{Root: hash}, Key: []byte{2}},
+		&light.ChtRequest{ChtRoot: hash, ChtNum: 1, BlockNum: 1},
+		&light.ChtRequest{ChtRoot: hash, ChtNum: 1, BlockNum: 2},
+	}
+	seen := make(map[string]int)
+	for i, req := range reqs {
+		key := odrRequestKey(req)
+		if key == "" {
+			t.Fatalf("request %d: missing key", i)
+		}
+		if j, ok := seen[key]; ok {
+			t.Errorf("request %d: key collides with request %d", i, j)
+		}
+		seen[key] = i
+	}
+	if key := odrRequestKey(&light.BloomRequest{}); key != "" {
+		t.Errorf("bloom request should not be deduplicated, have key %x", key)
+	}
+	// Identical requests must share the key and results
+	src, dst := &light.BlockRequest{Hash: hash, Rlp: []byte{0xc0}}, &light.BlockRequest{Hash: hash}
+	if odrRequestKey(src) != odrRequestKey(dst) {
+		t.Fatalf("identical requests have different keys")
+	}
+	copyOdrResult(dst, src)
+	if !bytes.Equal(dst.Rlp, src.Rlp) {
+		t.Errorf("result mismatch: have %x, want %x", dst.Rlp, src.Rlp)
+	}
+}
+
+// Tests that shared results are deep copied, so callers can't race on them.
+func TestOdrResultDeepCopy(t *testing.T) {
+	src := &light.ReceiptsRequest{
+		Receipts: types.Receipts{{
+			Logs: []*types.Log{{Topics: []common.Hash{{0x01}}, Data: []byte{0x02}}},
+		}},
+	}
+	clone := cloneOdrResult(src).(*light.ReceiptsRequest)
+
+	dst := new(light.ReceiptsRequest)
+	copyOdrResult(dst, clone)
+
+	dst.Receipts[0].Logs[0].Topics[0] = common.Hash{0xff}
+	dst.Receipts[0].Logs[0].Data[0] = 0xff
+	dst.Receipts[0].Logs[0].BlockNumber = 1
+
+	for i, req := range []*light.ReceiptsRequest{src, clone} {
+		log := req.Receipts[0].Logs[0]
+		if log.Topics[0] != (common.Hash{0x01}) || log.Data[0] != 0x02 || log.BlockNumber != 0 {
+			t.Errorf("request %d: shared result modified: %+v", i, log)
+		}
+	}
+}
+
+// Tests that results served from the cache or from an identical in-flight
+// retrieval are stored in the database and private to each caller.
+func TestOdrSharedResults(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	odr := NewLesOdr(db, nil, nil, nil, nil)
+
+	// Serve a block body from the cache
+	hash, body := common.HexToHash("0x01"), []byte{0xc2, 0xc0, 0xc0}
+	odr.cache.Add(odrRequestKey(&light.BlockRequest{Hash: hash}), &light.BlockRequest{Hash: hash, Rlp: common.CopyBytes(body)})
+
+	req := &light.BlockRequest{Hash: hash, Number: 1}
+	if err := odr.Retrieve(context.Background(), req); err != nil {
+		t.Fatalf("failed to retrieve cached block: %v", err)
+	}
+	if !bytes.Equal(req.Rlp, body) {
+		t.Fatalf("cached body mismatch: have %x, want %x", req.Rlp, body)
+	}
+	if stored := core.GetBodyRLP(db, hash, 1); !bytes.Equal(stored, body) {
+		t.Fatalf("cached body not stored: have %x, want %x", stored, body)
+	}
+	req.Rlp[0] = 0xff
+
+	req = &light.BlockRequest{Hash: hash, Number: 1}
+	if err := odr.Retrieve(context.Background(), req); err != nil || !bytes.Equal(req.Rlp, body) {
+		t.Fatalf("cached body modified by caller: have %x, want %x", req.Rlp, body)
+	}
+	// Serve a block body from an identical in-flight retrieval
+	hash, body = common.HexToHash("0x02"), []byte{0xc2, 0xc1, 0xc0}
+	key := odrRequestKey(&light.BlockRequest{Hash: hash})
+
+	f := &odrInflight{done: make(chan struct{})}
+	odr.inflight[key] = f
+
+	req = &light.BlockRequest{Hash: hash, Number: 2}
+	errc := make(chan error)
+	go func() { errc <- odr.Retrieve(context.Background(), req) }()
+
+	time.Sleep(50 * time.Millisecond)
+	f.req = &light.BlockRequest{Hash: hash, Rlp: common.CopyBytes(body)}
+	close(f.done)
+
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to retrieve deduplicated block: %v", err)
+	}
+	if !bytes.Equal(req.Rlp, body) {
+		t.Fatalf("deduplicated body mismatch: have %x, want %x", req.Rlp, body)
+	}
+	if stored := core.GetBodyRLP(db, hash, 2); !bytes.Equal(stored, body) {
+		t.Fatalf("deduplicated body not stored: have %x, want %x", stored, body)
+	}
+	req.Rlp[0] = 0xff
+	if f.req.(*light.BlockRequest).Rlp[0] != body[0] {
+		t.Fatalf("in-flight result modified by caller")
+	}
+}
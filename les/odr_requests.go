@@ -45,6 +45,11 @@ var (
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
 	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
+	errTxNotIncluded       = errors.New("transaction not included")
+	errTxLookupMismatch    = errors.New("transaction lookup mismatch")
+	errInvalidLogsRange    = errors.New("invalid logs range")
+	errNonCanonicalHeader  = errors.New("non-canonical header")
+	errLogsMissing         = errors.New("missing logs of matching block")
 )
 
 type LesOdrRequest interface {
@@ -68,6 +73,10 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
 		return (*BloomRequest)(r)
+	case *light.LogsRequest:
+		return (*LogsRequest)(r)
+	case *light.TxStatusRequest:
+		return (*TxStatusRequest)(r)
 	default:
 		return nil
 	}
@@ -193,7 +202,7 @@ func (r *TrieRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetProofsV1Msg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetProofsV2Msg, 1)
 	default:
 		panic(nil)
@@ -353,7 +362,7 @@ func (r *ChtRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetHeaderProofsMsg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetHelperTrieProofsMsg, 1)
 	default:
 		panic(nil)
@@ -543,6 +552,351 @@ func (r *BloomRequest) Validate(db gdadb.Database, msg *Msg) error {
 	return nil
 }
 
+// LogsRequest is the ODR request type for delegated log filter queries, see
+// LesOdrRequest interface
+type LogsRequest light.LogsRequest
+
+// toBlock returns the last block of the range that can be sent in a single
+// request.
+func (r *LogsRequest) toBlock() uint64 {
+	if r.ToBlock-r.FromBlock >= MaxLogsRange {
+		return r.FromBlock + MaxLogsRange - 1
+	}
+	return r.ToBlock
+}
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *LogsRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetLogsMsg, int(r.toBlock()-r.FromBlock+1))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *LogsRequest) CanSend(peer *peer) bool {
+	peer.lock.RLock()
+	defer peer.lock.RUnlock()
+
+	if peer.version < lpv3 || peer.headInfo.Number < r.FromBlock {
+		return false
+	}
+	if r.FromBlock >= r.ChtCount*light.CHTFrequencyClient {
+		return true
+	}
+	return peer.headInfo.Number >= light.HelperTrieConfirmations && r.ChtCount-1 <= (peer.headInfo.Number-light.HelperTrieConfirmations)/light.CHTFrequencyClient
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *LogsRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting filtered logs", "from", r.FromBlock, "to", r.toBlock())
+	return peer.RequestLogs(reqID, r.GetCost(peer), logsQuery{FromBlock: r.FromBlock, ToBlock: r.toBlock(), Filter: r.Filter, ChtCount: r.ChtCount})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *LogsRequest) Validate(db gdadb.Database, msg *Msg) error {
+	log.Debug("Validating filtered logs", "from", r.FromBlock, "to", r.toBlock())
+
+	// Ensure we have a correct message with a sane range
+	if msg.MsgType != MsgLogs {
+		return errInvalidMessageType
+	}
+	resp := msg.Obj.(logsResp)
+	if resp.Last < r.FromBlock || resp.Last > r.toBlock() {
+		return errInvalidLogsRange
+	}
+	// Verify the headers covered by the trusted CHT, all of them are needed
+	provenLimit := r.ChtCount * light.CHTFrequencyClient
+	proven, err := r.verifyHeaders(resp, provenLimit)
+	if err != nil {
+		return err
+	}
+	// Walk the processed range and ensure the receipts of all the blocks whose
+	// bloom matches the filter are present and complete
+	var (
+		logs   []*types.Log
+		blocks = resp.Blocks
+	)
+	for number := r.FromBlock; number <= resp.Last; number++ {
+		var header *types.Header
+		if number < provenLimit {
+			header = proven[number-r.FromBlock]
+		} else if hash := core.GetCanonicalHash(db, number); hash != (common.Hash{}) {
+			header = core.GetHeader(db, hash, number)
+		}
+		if header == nil {
+			return errHeaderUnavailable
+		}
+		if !r.Filter.MatchBloom(header.Bloom) {
+			continue
+		}
+		if len(blocks) == 0 || blocks[0].Number != number {
+			return errLogsMissing
+		}
+		matches, err := verifyBlockLogs(header, &blocks[0], &r.Filter)
+		if err != nil {
+			return err
+		}
+		logs = append(logs, matches...)
+		blocks = blocks[1:]
+	}
+	if len(blocks) > 0 {
+		return errInvalidEntryCount
+	}
+	// Verifications passed, store and return
+	r.Logs = logs
+	r.Last = resp.Last
+	return nil
+}
+
+// verifyHeaders checks the headers of a logs reply below the given limit against
+// the trusted CHT root, returning them in order.
+func (r *LogsRequest) verifyHeaders(resp logsResp, provenLimit uint64) ([]*types.Header, error) {
+	var count uint64
+	if r.FromBlock < provenLimit {
+		count = resp.Last - r.FromBlock + 1
+		if resp.Last >= provenLimit {
+			count = provenLimit - r.FromBlock
+		}
+	}
+	if uint64(len(resp.Headers)) != count {
+		return nil, errInvalidEntryCount
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	var (
+		nodeSet = resp.ChtProof.NodeSet()
+		reads   = &readTraceDB{db: nodeSet}
+		headers = make([]*types.Header, count)
+	)
+	for i, enc := range resp.Headers {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(enc, header); err != nil {
+			return nil, errHeaderUnavailable
+		}
+		number := r.FromBlock + uint64(i)
+		if header.Number.Uint64() != number {
+			return nil, errCHTNumberMismatch
+		}
+		var encNumber [8]byte
+		binary.BigEndian.PutUint64(encNumber[:], number)
+
+		value, err, _ := trie.VerifyProof(r.ChtRoot, encNumber[:], reads)
+		if err != nil {
+			return nil, fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+		var node light.ChtNode
+		if err := rlp.DecodeBytes(value, &node); err != nil {
+			return nil, err
+		}
+		if node.Hash != header.Hash() {
+			return nil, errCHTHashMismatch
+		}
+		headers[i] = header
+	}
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return nil, errUselessNodes
+	}
+	return headers, nil
+}
+
+// verifyBlockLogs checks the receipts of a block against its receipt root and
+// returns the logs matching the filter. Their positions are derived from the
+// receipts and the transactions containing them are proven against the header.
+func verifyBlockLogs(header *types.Header, block *logsBlockProof, filter *light.LogFilter) ([]*types.Log, error) {
+	if types.DeriveSha(block.Receipts) != header.ReceiptHash {
+		return nil, errReceiptHashMismatch
+	}
+	var (
+		hash     = header.Hash()
+		proofs   = block.TxProofs
+		logs     []*types.Log
+		logIndex uint
+	)
+	for i, receipt := range block.Receipts {
+		var matches []*types.Log
+		for j, l := range receipt.Logs {
+			if filter.MatchLog(l) {
+				l.Index = logIndex + uint(j)
+				matches = append(matches, l)
+			}
+		}
+		logIndex += uint(len(receipt.Logs))
+		if len(matches) == 0 {
+			continue
+		}
+		// Prove the transaction of the receipt to fill in the derived fields
+		if len(proofs) == 0 {
+			return nil, errInvalidEntryCount
+		}
+		key, _ := rlp.EncodeToBytes(uint(i))
+		value, err, _ := trie.VerifyProof(header.TxHash, key, proofs[0].NodeSet())
+		if err != nil {
+			return nil, fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+		proofs = proofs[1:]
+
+		tx := new(types.Transaction)
+		if value == nil {
+			return nil, errTxHashMismatch
+		}
+		if err := rlp.DecodeBytes(value, tx); err != nil {
+			return nil, err
+		}
+		for _, l := range matches {
+			l.BlockNumber = block.Number
+			l.BlockHash = hash
+			l.TxHash = tx.Hash()
+			l.TxIndex = uint(i)
+		}
+		logs = append(logs, matches...)
+	}
+	if len(proofs) > 0 {
+		return nil, errInvalidEntryCount
+	}
+	return logs, nil
+}
+
+// TxStatusRequest is the ODR request type for proven transaction statuses, see
+// LesOdrRequest interface
+type TxStatusRequest light.TxStatusRequest
+
+// hashes returns the transaction hashes that can be sent in a single request.
+func (r *TxStatusRequest) hashes() []common.Hash {
+	if len(r.Hashes) > MaxTxInclusionProofs {
+		return r.Hashes[:MaxTxInclusionProofs]
+	}
+	return r.Hashes
+}
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *TxStatusRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetTxInclusionProofsMsg, len(r.hashes()))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *TxStatusRequest) CanSend(peer *peer) bool {
+	peer.lock.RLock()
+	defer peer.lock.RUnlock()
+
+	if peer.version < lpv3 {
+		return false
+	}
+	if r.ChtCount == 0 {
+		return true
+	}
+	return peer.headInfo.Number >= light.HelperTrieConfirmations && r.ChtCount-1 <= (peer.headInfo.Number-light.HelperTrieConfirmations)/light.CHTFrequencyClient
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *TxStatusRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting transaction status", "count", len(r.hashes()))
+	return peer.RequestTxInclusionProofs(reqID, r.GetCost(peer), txInclusionQuery{Hashes: r.hashes(), ChtCount: r.ChtCount})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *TxStatusRequest) Validate(db gdadb.Database, msg *Msg) error {
+	log.Debug("Validating transaction status", "count", len(r.hashes()))
+
+	// Ensure we have a correct message with a prefix of the requested statuses
+	if msg.MsgType != MsgTxInclusionProofs {
+		return errInvalidMessageType
+	}
+	proofs := msg.Obj.([]txInclusionProof)
+	if len(proofs) == 0 || len(proofs) > len(r.hashes()) {
+		return errInvalidEntryCount
+	}
+	status := make([]light.TxStatus, len(proofs))
+	for i := range proofs {
+		proof := &proofs[i]
+
+		status[i] = light.TxStatus{Status: proof.Status.Status, Error: proof.Status.Error}
+		if proof.Status.Status != core.TxStatusIncluded {
+			continue
+		}
+		// Included transactions need to be proven, the header either by the
+		// trusted CHT or by the local canonical chain
+		var chtRoot common.Hash
+		if lookup := proof.Status.Lookup; lookup != nil && lookup.BlockIndex < r.ChtCount*light.CHTFrequencyClient {
+			if proof.ChtIdx != r.ChtCount-1 {
+				return errCHTNumberMismatch
+			}
+			chtRoot = r.ChtRoot
+		}
+		header, err := verifyTxInclusion(proof, r.Hashes[i], chtRoot)
+		if err != nil {
+			return err
+		}
+		if chtRoot == (common.Hash{}) && core.GetCanonicalHash(db, header.Number.Uint64()) != header.Hash() {
+			return errNonCanonicalHeader
+		}
+		status[i].Lookup = proof.Status.Lookup
+	}
+	// Verifications passed, store and return
+	r.Status = status
+	return nil
+}
+
+// verifyTxInclusion checks a transaction inclusion proof returned by a server
+// for the transaction with the given hash. The header is always checked against
+// the lookup entry and the transaction against the header's transaction trie.
+// If chtRoot is not empty, the header is also verified to be canonical by the
+// CHT proof, otherwise the caller is expected to already know the header.
+func verifyTxInclusion(proof *txInclusionProof, hash common.Hash, chtRoot common.Hash) (*types.Header, error) {
+	lookup := proof.Status.Lookup
+	if proof.Status.Status != core.TxStatusIncluded || lookup == nil {
+		return nil, errTxNotIncluded
+	}
+	if len(proof.Header) == 0 {
+		return nil, errHeaderUnavailable
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(proof.Header, header); err != nil {
+		return nil, errHeaderUnavailable
+	}
+	if header.Hash() != lookup.BlockHash || header.Number.Uint64() != lookup.BlockIndex {
+		return nil, errTxLookupMismatch
+	}
+	// Verify the transaction against the transaction trie of the header
+	key, _ := rlp.EncodeToBytes(uint(lookup.Index))
+	value, err, _ := trie.VerifyProof(header.TxHash, key, proof.TxProof.NodeSet())
+	if err != nil {
+		return nil, fmt.Errorf("merkle proof verification failed: %v", err)
+	}
+	if value == nil {
+		return nil, errTxNotIncluded
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(value, tx); err != nil {
+		return nil, err
+	}
+	if tx.Hash() != hash {
+		return nil, errTxHashMismatch
+	}
+	// Verify the header against the trusted CHT root if requested
+	if chtRoot != (common.Hash{}) {
+		var encNumber [8]byte
+		binary.BigEndian.PutUint64(encNumber[:], lookup.BlockIndex)
+
+		value, err, _ := trie.VerifyProof(chtRoot, encNumber[:], proof.ChtProof.NodeSet())
+		if err != nil {
+			return nil, fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+		var node light.ChtNode
+		if err := rlp.DecodeBytes(value, &node); err != nil {
+			return nil, err
+		}
+		if node.Hash != lookup.BlockHash {
+			return nil, errCHTHashMismatch
+		}
+	}
+	return header, nil
+}
+
 // readTraceDB stores the keys of database reads. We use this to check that received node
 // sets contain only the trie nodes necessary to make proofs pass.
 type readTraceDB struct {
@@ -28,8 +28,10 @@ import (
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/crypto/secp256k1"
+	"github.com/gdachain/go-gdachain/light"
 	"github.com/gdachain/go-gdachain/rlp"
 )
 
@@ -37,17 +39,18 @@ import (
 const (
 	lpv1 = 1
 	lpv2 = 2
+	lpv3 = 3
 )
 
 // Supported versions of the les protocol (first is primary)
 var (
-	ClientProtocolVersions    = []uint{lpv2, lpv1}
-	ServerProtocolVersions    = []uint{lpv2, lpv1}
+	ClientProtocolVersions    = []uint{lpv3, lpv2, lpv1}
+	ServerProtocolVersions    = []uint{lpv3, lpv2, lpv1}
 	AdvertiseProtocolVersions = []uint{lpv2} // clients are searching for the first advertised protocol in the list
 )
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 22}
+var ProtocolLengths = map[uint]uint64{lpv1: 15, lpv2: 22, lpv3: 26}
 
 const (
 	NetworkId          = 1
@@ -80,6 +83,11 @@ const (
 	SendTxV2Msg            = 0x13
 	GetTxStatusMsg         = 0x14
 	TxStatusMsg            = 0x15
+	// Protocol messages belonging to LPV3
+	GetTxInclusionProofsMsg = 0x16
+	TxInclusionProofsMsg    = 0x17
+	GetLogsMsg              = 0x18
+	LogsMsg                 = 0x19
 )
 
 type errCode int
@@ -227,3 +235,54 @@ type txStatus struct {
 	Lookup *core.TxLookupEntry `rlp:"nil"`
 	Error  string
 }
+
+// txInclusionProof is the response to a single transaction inclusion proof
+// request. Besides the plain status it carries the header of the including
+// block, a merkle proof of the transaction in the block's transaction trie and,
+// if the block is already covered by a CHT section, a proof of the header in
+// the canonical hash trie so the client can verify it against a trusted root.
+type txInclusionProof struct {
+	Status   txStatus
+	Header   rlp.RawValue
+	TxProof  light.NodeList
+	ChtIdx   uint64
+	ChtProof light.NodeList
+}
+
+// txInclusionQuery requests the inclusion proofs of a batch of transactions.
+// Headers of blocks covered by the first ChtCount CHT sections are proven
+// against the last of these sections, the ones the client is able to verify.
+type txInclusionQuery struct {
+	Hashes   []common.Hash
+	ChtCount uint64
+}
+
+// logsQuery is a log filter query over a bounded block range delegated to a
+// server. Headers of blocks covered by the first ChtCount CHT sections are
+// proven against the last of these sections, the ones the client is able to
+// verify.
+type logsQuery struct {
+	FromBlock, ToBlock uint64
+	Filter             light.LogFilter
+	ChtCount           uint64
+}
+
+// logsBlockProof contains all the receipts of a block whose bloom matches a
+// query, along with the proofs of the transactions of the receipts containing
+// matching logs, in the order of the receipts.
+type logsBlockProof struct {
+	Number   uint64
+	Receipts types.Receipts
+	TxProofs []light.NodeList
+}
+
+// logsResp is the reply to a logs query. The server may stop early if the reply
+// grows too large, Last is the number of the last block it processed. Headers
+// contains all the processed headers covered by the requested CHT sections,
+// proven by ChtProof, so that the client can check their blooms too.
+type logsResp struct {
+	Last     uint64
+	Headers  []rlp.RawValue
+	ChtProof light.NodeList
+	Blocks   []logsBlockProof
+}
@@ -0,0 +1,124 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+var errRPCOdrUnsupported = errors.New("request not retrievable over RPC")
+
+// rpcOdr retrieves on-demand data from the odr API of a trusted full node over
+// RPC, letting light clients work even if no LES servers are reachable. Replies
+// are validated the same way as LES replies, except canonical headers which are
+// accepted from the trusted node without CHT proofs.
+type rpcOdr struct {
+	client *rpc.Client
+}
+
+// newRPCOdr connects to the odr API of the full node at the given endpoint.
+func newRPCOdr(endpoint string) (*rpcOdr, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &rpcOdr{client: client}, nil
+}
+
+// close disconnects from the full node.
+func (r *rpcOdr) close() {
+	r.client.Close()
+}
+
+// retrieve fetches the data of an ODR request from the full node and fills in
+// the request if the reply is valid.
+func (r *rpcOdr) retrieve(ctx context.Context, db gdadb.Database, req light.OdrRequest) error {
+	var msg *Msg
+
+	switch req := req.(type) {
+	case *light.BlockRequest:
+		var enc hexutil.Bytes
+		if err := r.client.CallContext(ctx, &enc, "odr_getBlockBody", req.Hash); err != nil {
+			return err
+		}
+		body := new(types.Body)
+		if err := rlp.DecodeBytes(enc, body); err != nil {
+			return err
+		}
+		msg = &Msg{MsgType: MsgBlockBodies, Obj: []*types.Body{body}}
+
+	case *light.ReceiptsRequest:
+		var enc hexutil.Bytes
+		if err := r.client.CallContext(ctx, &enc, "odr_getReceipts", req.Hash); err != nil {
+			return err
+		}
+		var receipts types.Receipts
+		if err := rlp.DecodeBytes(enc, &receipts); err != nil {
+			return err
+		}
+		msg = &Msg{MsgType: MsgReceipts, Obj: []types.Receipts{receipts}}
+
+	case *light.CodeRequest:
+		var code hexutil.Bytes
+		if err := r.client.CallContext(ctx, &code, "odr_getCode", req.Hash); err != nil {
+			return err
+		}
+		msg = &Msg{MsgType: MsgCode, Obj: [][]byte{code}}
+
+	case *light.TrieRequest:
+		var proof []hexutil.Bytes
+		if err := r.client.CallContext(ctx, &proof, "odr_getProof", req.Id.BlockHash, hexutil.Bytes(req.Id.AccKey), hexutil.Bytes(req.Key)); err != nil {
+			return err
+		}
+		nodes := make(light.NodeList, len(proof))
+		for i, node := range proof {
+			nodes[i] = rlp.RawValue(node)
+		}
+		msg = &Msg{MsgType: MsgProofsV2, Obj: nodes}
+
+	case *light.ChtRequest:
+		var res struct {
+			Header hexutil.Bytes `json:"header"`
+			Td     *hexutil.Big  `json:"td"`
+		}
+		if err := r.client.CallContext(ctx, &res, "odr_getHeaderByNumber", hexutil.Uint64(req.BlockNum)); err != nil {
+			return err
+		}
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(res.Header, header); err != nil {
+			return err
+		}
+		if header.Number.Uint64() != req.BlockNum || res.Td == nil {
+			return fmt.Errorf("invalid header #%d reply", req.BlockNum)
+		}
+		req.Header, req.Td = header, res.Td.ToInt()
+		return nil
+
+	default:
+		return errRPCOdrUnsupported
+	}
+	return LesRequest(req).Validate(db, msg)
+}
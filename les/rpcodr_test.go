@@ -0,0 +1,85 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// TestOdrService is a stand-in for the odr API of a full node, serving block
+// bodies from a fixed set.
+type TestOdrService struct {
+	bodies map[common.Hash]hexutil.Bytes
+}
+
+func (s *TestOdrService) GetBlockBody(hash common.Hash) (hexutil.Bytes, error) {
+	return s.bodies[hash], nil
+}
+
+// Tests that block bodies retrieved over RPC are validated against the locally
+// known headers before being accepted.
+func TestRPCOdrBlockBodies(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	genesis := core.GenesisBlockForTesting(db, testBankAddress, testBankFunds)
+
+	blocks, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 2, func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBankAddress), common.Address{1}, big.NewInt(int64(i+1)), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		block.AddTx(tx)
+	})
+	service := &TestOdrService{bodies: make(map[common.Hash]hexutil.Bytes)}
+	for _, block := range blocks {
+		core.WriteHeader(db, block.Header())
+		service.bodies[block.Hash()], _ = rlp.EncodeToBytes(block.Body())
+	}
+	// Serve the first block's body for the second block too
+	service.bodies[blocks[1].Hash()] = service.bodies[blocks[0].Hash()]
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("odr", service); err != nil {
+		t.Fatalf("failed to register odr service: %v", err)
+	}
+	odr := &rpcOdr{client: rpc.DialInProc(server)}
+	defer odr.close()
+
+	req := &light.BlockRequest{Hash: blocks[0].Hash(), Number: blocks[0].NumberU64()}
+	if err := odr.retrieve(context.Background(), db, req); err != nil {
+		t.Fatalf("failed to retrieve valid body: %v", err)
+	}
+	if want, _ := rlp.EncodeToBytes(blocks[0].Body()); string(req.Rlp) != string(want) {
+		t.Errorf("retrieved body mismatch")
+	}
+	req = &light.BlockRequest{Hash: blocks[1].Hash(), Number: blocks[1].NumberU64()}
+	if err := odr.retrieve(context.Background(), db, req); err != errTxHashMismatch {
+		t.Errorf("invalid body error mismatch: have %v, want %v", err, errTxHashMismatch)
+	}
+	if err := odr.retrieve(context.Background(), db, &light.BloomRequest{}); err != errRPCOdrUnsupported {
+		t.Errorf("unsupported request error mismatch: have %v, want %v", err, errRPCOdrUnsupported)
+	}
+}
@@ -42,6 +42,7 @@ type LesServer struct {
 	fcManager       *flowcontrol.ClientManager // nil if our node is client only
 	fcCosgdaats     *requestCosgdaats
 	defParams       *flowcontrol.ServerParams
+	capacity        *capacityTuner // nil if flow control parameters are static
 	lesTopics       []discv5.Topic
 	privateKey      *ecdsa.PrivateKey
 	quitSync        chan struct{}
@@ -51,7 +52,7 @@ type LesServer struct {
 
 func NewLesServer(gda *gda.gdachain, config *gda.Config) (*LesServer, error) {
 	quitSync := make(chan struct{})
-	pm, err := NewProtocolManager(gda.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, gda.EventMux(), gda.Engine(), newPeerSet(), gda.BlockChain(), gda.TxPool(), gda.ChainDb(), nil, nil, quitSync, new(sync.WaitGroup))
+	pm, err := NewProtocolManager(gda.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, gda.Engine(), newPeerSet(), gda.BlockChain(), gda.TxPool(), gda.ChainDb(), nil, nil, quitSync, new(sync.WaitGroup))
 	if err != nil {
 		return nil, err
 	}
@@ -94,14 +95,24 @@ func NewLesServer(gda *gda.gdachain, config *gda.Config) (*LesServer, error) {
 	pm.server = srv
 
 	srv.defParams = &flowcontrol.ServerParams{
-		BufLimit:    300000000,
-		MinRecharge: 50000,
+		BufLimit:    defaultBufLimit,
+		MinRecharge: defaultMinRecharge,
 	}
+	srv.capacity = newCapacityTuner(config.LightServ)
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
 	srv.fcCosgdaats = newCosgdaats(gda.ChainDb())
+	srv.fcCosgdaats.capacity = srv.capacity
 	return srv, nil
 }
 
+// fcParams returns the flow control parameters to advertise to a new client.
+func (s *LesServer) fcParams() *flowcontrol.ServerParams {
+	if s.capacity != nil {
+		return s.capacity.params()
+	}
+	return s.defParams
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
@@ -123,6 +134,9 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 	}
 	s.privateKey = srvr.PrivateKey
 	s.protocolManager.blockLoop()
+	if s.capacity != nil {
+		s.capacity.start()
+	}
 }
 
 func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
@@ -135,6 +149,9 @@ func (s *LesServer) Stop() {
 	// bloom trie indexer is closed by parent bloombits indexer
 	s.fcCosgdaats.store()
 	s.fcManager.Stop()
+	if s.capacity != nil {
+		s.capacity.stop()
+	}
 	go func() {
 		<-s.protocolManager.noMorePeers
 	}()
@@ -223,9 +240,10 @@ func linRegFromBytes(data []byte) *linReg {
 }
 
 type requestCosgdaats struct {
-	lock  sync.RWMutex
-	db    gdadb.Database
-	stats map[uint64]*linReg
+	lock     sync.RWMutex
+	db       gdadb.Database
+	stats    map[uint64]*linReg
+	capacity *capacityTuner // optional controller fed with the measured costs
 }
 
 type requestCosgdaatsRlp []struct {
@@ -307,6 +325,9 @@ func (s *requestCosgdaats) update(msgCode, reqCnt, cost uint64) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.capacity != nil {
+		s.capacity.addServed(cost)
+	}
 	c, ok := s.stats[msgCode]
 	if !ok || reqCnt == 0 {
 		return
@@ -89,18 +89,22 @@ const (
 	// inigdaatsWeight is used to initialize previously unknown peers with good
 	// statistics to give a chance to prove themselves
 	inigdaatsWeight = 1
+	// known node statistics are saved into the database every saveInterval so they
+	// survive unclean shutdowns too
+	saveInterval = time.Minute * 5
 )
 
 // serverPool implements a pool for storing and selecting newly discovered and already
 // known light server nodes. It received discovered nodes, stores statistics about
 // known nodes and takes care of always having enough good quality servers connected.
 type serverPool struct {
-	db     gdadb.Database
-	dbKey  []byte
-	server *p2p.Server
-	quit   chan struct{}
-	wg     *sync.WaitGroup
-	connWg sync.WaitGroup
+	db         gdadb.Database
+	dbKey      []byte
+	trustedKey []byte
+	server     *p2p.Server
+	quit       chan struct{}
+	wg         *sync.WaitGroup
+	connWg     sync.WaitGroup
 
 	topic discv5.Topic
 
@@ -117,6 +121,8 @@ type serverPool struct {
 	knownSelect, newSelect     *weightedRandomSelect
 	knownSelected, newSelected int
 	fastDiscover               bool
+
+	trusted map[discover.NodeID]*discover.Node // Manually pinned servers, always dialed when available
 }
 
 // newServerPool creates a new serverPool instance
@@ -132,6 +138,7 @@ func newServerPool(db gdadb.Database, quit chan struct{}, wg *sync.WaitGroup) *s
 		knownSelect:  newWeightedRandomSelect(),
 		newSelect:    newWeightedRandomSelect(),
 		fastDiscover: true,
+		trusted:      make(map[discover.NodeID]*discover.Node),
 	}
 	pool.knownQueue = newPoolEntryQueue(maxKnownEntries, pool.removeEntry)
 	pool.newQueue = newPoolEntryQueue(maxNewEntries, pool.removeEntry)
@@ -142,8 +149,10 @@ func (pool *serverPool) start(server *p2p.Server, topic discv5.Topic) {
 	pool.server = server
 	pool.topic = topic
 	pool.dbKey = append([]byte("serverPool/"), []byte(topic)...)
+	pool.trustedKey = append([]byte("serverPoolTrusted/"), []byte(topic)...)
 	pool.wg.Add(1)
 	pool.loadNodes()
+	pool.loadTrusted()
 
 	if pool.server.DiscV5 != nil {
 		pool.discSetPeriod = make(chan time.Duration, 1)
@@ -281,6 +290,9 @@ func (pool *serverPool) eventLoop() {
 	if pool.discSetPeriod != nil {
 		pool.discSetPeriod <- time.Millisecond * 100
 	}
+	save := time.NewTicker(saveInterval)
+	defer save.Stop()
+
 	for {
 		select {
 		case entry := <-pool.timeout:
@@ -331,6 +343,11 @@ func (pool *serverPool) eventLoop() {
 				}
 			}
 
+		case <-save.C:
+			pool.lock.Lock()
+			pool.saveNodes()
+			pool.lock.Unlock()
+
 		case <-pool.quit:
 			if pool.discSetPeriod != nil {
 				close(pool.discSetPeriod)
@@ -405,11 +422,14 @@ func (pool *serverPool) loadNodes() {
 }
 
 // saveNodes saves known nodes and their statistics into the database. Nodes are
-// ordered from least to most recently connected.
+// ordered from least to most recently connected. The known queue is left intact,
+// so saving can be done any time, not only on shutdown.
 func (pool *serverPool) saveNodes() {
-	list := make([]*poolEntry, len(pool.knownQueue.queue))
-	for i := range list {
-		list[i] = pool.knownQueue.fetchOldest()
+	list := make([]*poolEntry, 0, len(pool.knownQueue.queue))
+	for i := pool.knownQueue.oldPtr; i < pool.knownQueue.newPtr; i++ {
+		if e := pool.knownQueue.queue[i]; e != nil {
+			list = append(list, e)
+		}
 	}
 	enc, err := rlp.EncodeToBytes(list)
 	if err == nil {
@@ -417,12 +437,107 @@ func (pool *serverPool) saveNodes() {
 	}
 }
 
+// loadTrusted loads the manually pinned servers from the database
+func (pool *serverPool) loadTrusted() {
+	enc, err := pool.db.Get(pool.trustedKey)
+	if err != nil {
+		return
+	}
+	var urls []string
+	if err := rlp.DecodeBytes(enc, &urls); err != nil {
+		log.Debug("Failed to decode trusted server list", "err", err)
+		return
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, url := range urls {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			log.Debug("Failed to parse trusted server", "enode", url, "err", err)
+			continue
+		}
+		pool.setTrusted(node)
+	}
+}
+
+// saveTrusted saves the manually pinned servers into the database
+func (pool *serverPool) saveTrusted() {
+	urls := make([]string, 0, len(pool.trusted))
+	for _, node := range pool.trusted {
+		urls = append(urls, node.String())
+	}
+	enc, err := rlp.EncodeToBytes(urls)
+	if err == nil {
+		pool.db.Put(pool.trustedKey, enc)
+	}
+}
+
+// addTrusted pins a server, dialing it with priority whenever it is not connected
+// and retaining it across restarts regardless of its statistics.
+func (pool *serverPool) addTrusted(node *discover.Node) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	entry := pool.setTrusted(node)
+	pool.saveTrusted()
+
+	// Dialing needs a running server, an unstarted pool dials the pinned
+	// servers when it's started
+	if pool.server != nil {
+		pool.updateCheckDial(entry)
+	}
+}
+
+// stats returns a snapshot of the statistics of all the known and pinned servers.
+func (pool *serverPool) stats() []*ServerPoolEntry {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	states := map[int]string{psNotConnected: "idle", psDialed: "dialed", psConnected: "connected", psRegistered: "registered"}
+
+	var list []*ServerPoolEntry
+	for _, entry := range pool.entries {
+		if !entry.known && !entry.trusted {
+			continue
+		}
+		stats := &ServerPoolEntry{
+			ID:           entry.id.String(),
+			State:        states[entry.state],
+			Known:        entry.known,
+			Trusted:      entry.trusted,
+			Availability: entry.connecgdaats.recentAvg(),
+			BlockDelay:   time.Duration(entry.delayStats.recentAvg()).Seconds() * 1000,
+			ResponseTime: time.Duration(entry.responseStats.recentAvg()).Seconds() * 1000,
+			Timeouts:     entry.timeougdaats.recentAvg(),
+		}
+		if addr := entry.lastConnected; addr != nil {
+			stats.Address, stats.Fails = addr.strKey(), addr.fails
+		}
+		list = append(list, stats)
+	}
+	return list
+}
+
+// setTrusted marks the pool entry of a server as trusted, creating it if needed.
+func (pool *serverPool) setTrusted(node *discover.Node) *poolEntry {
+	pool.trusted[node.ID] = node
+
+	entry := pool.findOrNewNode(node.ID, node.IP, node.TCP)
+	entry.trusted = true
+	return entry
+}
+
 // removeEntry removes a pool entry when the entry count limit is reached.
 // Note that it is called by the new/known queues from which the entry has already
 // been removed so removing it from the queues is not necessary.
 func (pool *serverPool) removeEntry(entry *poolEntry) {
 	pool.newSelect.remove((*discoveredEntry)(entry))
 	pool.knownSelect.remove((*knownEntry)(entry))
+	if entry.trusted {
+		// Pinned servers are only dropped from the queues, they are still dialed
+		return
+	}
 	entry.removed = true
 	delete(pool.entries, entry.id)
 }
@@ -459,6 +574,12 @@ func (pool *serverPool) updateCheckDial(entry *poolEntry) {
 // checkDial checks if new dials can/should be made. It tries to select servers both
 // based on good statistics and recent discovery.
 func (pool *serverPool) checkDial() {
+	// Keep pinned servers connected whenever they are available
+	for id := range pool.trusted {
+		if entry := pool.entries[id]; entry != nil && entry.state == psNotConnected && !entry.delayedRetry {
+			pool.dial(entry, true)
+		}
+	}
 	fillWithKnownSelects := !pool.fastDiscover
 	for pool.knownSelected < targetKnownSelect {
 		entry := pool.knownSelect.choose()
@@ -552,6 +673,7 @@ type poolEntry struct {
 
 	lastDiscovered              mclock.AbsTime
 	known, knownSelected        bool
+	trusted                     bool
 	connecgdaats, delayStats    poolStats
 	responseStats, timeougdaats poolStats
 	state                       int
@@ -573,7 +695,7 @@ func (e *poolEntry) DecodeRLP(s *rlp.Stream) error {
 		IP                         net.IP
 		Port                       uint16
 		Fails                      uint
-		CStat, DStat, RStat, gdaat poolStats
+		CStat, DStat, RStat, TStat poolStats
 	}
 	if err := s.Decode(&entry); err != nil {
 		return err
@@ -588,7 +710,7 @@ func (e *poolEntry) DecodeRLP(s *rlp.Stream) error {
 	e.connecgdaats = entry.CStat
 	e.delayStats = entry.DStat
 	e.responseStats = entry.RStat
-	e.timeougdaats = entry.gdaat
+	e.timeougdaats = entry.TStat
 	e.shortRetry = shortRetryCnt
 	e.known = true
 	return nil
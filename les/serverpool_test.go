@@ -0,0 +1,101 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/p2p/discover"
+	"github.com/gdachain/go-gdachain/p2p/discv5"
+)
+
+// Tests that the server pool statistics and pinned servers survive restarts and
+// that saving the statistics does not disturb the running pool.
+func TestServerPoolPersistence(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+
+	key, _ := crypto.GenerateKey()
+	server := &p2p.Server{Config: p2p.Config{PrivateKey: key, MaxPeers: 10, NoDiscovery: true}}
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start p2p server: %v", err)
+	}
+	defer server.Stop()
+
+	// startPool starts a server pool, returning a function to stop it
+	startPool := func() (*serverPool, func()) {
+		quit, wg := make(chan struct{}), new(sync.WaitGroup)
+		pool := newServerPool(db, quit, wg)
+		pool.start(server, discv5.Topic("test"))
+		return pool, func() {
+			close(quit)
+			wg.Wait()
+		}
+	}
+	pool, stop := startPool()
+
+	// Register a known server and pin a never seen one
+	pool.lock.Lock()
+	known := pool.findOrNewNode(discover.NodeID{1}, net.IP{127, 0, 0, 1}, 30303)
+	for _, addr := range known.addr {
+		known.lastConnected = addr
+	}
+	pool.lock.Unlock()
+
+	pool.registered(known)
+
+	pool.lock.Lock()
+	known.responseStats.add(float64(50*1000*1000), 10)
+	pool.lock.Unlock()
+
+	pool.addTrusted(discover.NewNode(discover.NodeID{2}, net.IP{127, 0, 0, 2}, 30303, 30303))
+
+	pool.lock.Lock()
+	pool.saveNodes()
+	if len(pool.knownQueue.queue) != 1 {
+		t.Fatalf("known queue drained by saving: have %d entries, want 1", len(pool.knownQueue.queue))
+	}
+	pool.lock.Unlock()
+
+	// Restart the pool and ensure everything was restored
+	stop()
+	pool, stop = startPool()
+	defer stop()
+
+	pool.lock.Lock()
+	if entry := pool.entries[discover.NodeID{1}]; entry == nil || !entry.known || entry.responseStats.weight != known.responseStats.weight {
+		t.Fatalf("known server not restored: %v", entry)
+	}
+	if entry := pool.entries[discover.NodeID{2}]; entry == nil || !entry.trusted {
+		t.Fatalf("pinned server not restored: %v", entry)
+	}
+	pool.lock.Unlock()
+
+	stats := pool.stats()
+	if len(stats) != 2 {
+		t.Fatalf("server stats count mismatch: have %d, want 2", len(stats))
+	}
+	for _, s := range stats {
+		if s.ID == (discover.NodeID{2}).String() && !s.Trusted {
+			t.Errorf("pinned server not reported as trusted")
+		}
+	}
+}
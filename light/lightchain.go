@@ -159,6 +159,20 @@ func (self *LightChain) loadLasgdaate() error {
 	return nil
 }
 
+// SetCheckpoints sets the canonical hashes enforced at certain heights during
+// header import. If the local chain already contradicts any of them, it is
+// rewound to below the offending header.
+func (bc *LightChain) SetCheckpoints(checkpoints params.Checkpoints) {
+	bc.hc.SetCheckpoints(checkpoints)
+
+	for number, hash := range checkpoints {
+		if header := bc.GetHeaderByNumber(number); number > 0 && header != nil && header.Hash() != hash {
+			log.Error("Local chain contradicts checkpoint, rewinding", "number", number, "have", header.Hash(), "want", hash)
+			bc.SetHead(number - 1)
+		}
+	}
+}
+
 // SetHead rewinds the local chain to a new head. Everything above the new
 // head will be deleted and the new one set.
 func (bc *LightChain) SetHead(head uint64) {
@@ -0,0 +1,116 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// LogFilter is the address and topic criteria of a log query that can be
+// delegated to a light server.
+type LogFilter struct {
+	Addresses []common.Address
+	Topics    [][]common.Hash
+}
+
+// MatchBloom reports whether a block with the given bloom may contain logs
+// matching the filter.
+func (f *LogFilter) MatchBloom(bloom types.Bloom) bool {
+	if len(f.Addresses) > 0 {
+		var included bool
+		for _, addr := range f.Addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range f.Topics {
+		included := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchLog reports whether the given log matches the filter.
+func (f *LogFilter) MatchLog(log *types.Log) bool {
+	if len(f.Addresses) > 0 {
+		var included bool
+		for _, addr := range f.Addresses {
+			if addr == log.Address {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	// If the to filtered topics is greater than the amount of topics in logs, skip.
+	if len(f.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range f.Topics {
+		match := len(sub) == 0 // empty rule set == wildcard
+		for _, topic := range sub {
+			if log.Topics[i] == topic {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// GetFilteredLogs delegates a log filter query over the given block range to the
+// light servers, issuing as many requests as needed to cover the whole range.
+// Historical headers are proven against the trusted CHT, so that the servers
+// can't omit blocks whose bloom matches the filter.
+func GetFilteredLogs(ctx context.Context, odr OdrBackend, from, to uint64, filter LogFilter) ([]*types.Log, error) {
+	chtCount, chtRoot := trustedCht(odr)
+
+	var logs []*types.Log
+	for from <= to {
+		r := &LogsRequest{FromBlock: from, ToBlock: to, Filter: filter, ChtCount: chtCount, ChtRoot: chtRoot}
+		if err := odr.Retrieve(ctx, r); err != nil {
+			return nil, err
+		}
+		logs = append(logs, r.Logs...)
+		if r.Last >= to {
+			break
+		}
+		from = r.Last + 1
+	}
+	return logs, nil
+}
@@ -169,3 +169,48 @@ func (req *BloomRequest) StoreResult(db gdadb.Database) {
 		core.WriteBloomBits(db, req.BitIdx, sectionIdx, sectionHead, req.BloomBits[i])
 	}
 }
+
+// LogsRequest is the ODR request type for delegating a log filter query over a
+// bounded block range to a server. The server may process only a prefix of the
+// range, Last reports the number of the last block covered by the reply. Headers
+// covered by the trusted CHT are proven against it, the others are expected to
+// be known locally.
+type LogsRequest struct {
+	OdrRequest
+	FromBlock, ToBlock uint64
+	Filter             LogFilter
+	ChtCount           uint64
+	ChtRoot            common.Hash
+	Logs               []*types.Log
+	Last               uint64
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *LogsRequest) StoreResult(db gdadb.Database) {
+	// the logs are verified against the proven headers, nothing to store
+}
+
+// TxStatus is the inclusion status of a single transaction. Lookup is only set
+// for included transactions, after their inclusion has been proven.
+type TxStatus struct {
+	Status core.TxStatus
+	Lookup *core.TxLookupEntry
+	Error  string
+}
+
+// TxStatusRequest is the ODR request type for retrieving the status of
+// transactions. Included transactions are proven against the trusted CHT if
+// their block is covered by it, or against the local header chain otherwise.
+// The server may answer only a prefix of the hashes.
+type TxStatusRequest struct {
+	OdrRequest
+	Hashes   []common.Hash
+	ChtCount uint64
+	ChtRoot  common.Hash
+	Status   []TxStatus
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *TxStatusRequest) StoreResult(db gdadb.Database) {
+	// the lookup entries are not stored, the transactions are not known locally
+}
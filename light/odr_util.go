@@ -40,8 +40,23 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 		}
 		return header, nil
 	}
+	chtCount, chtRoot := trustedCht(odr)
+	if number >= chtCount*CHTFrequencyClient {
+		return nil, ErrNoTrustedCht
+	}
+	r := &ChtRequest{ChtRoot: chtRoot, ChtNum: chtCount - 1, BlockNum: number}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Header, nil
+}
 
+// trustedCht returns the number of CHT sections consistent with the local
+// canonical chain, along with the root of the last one. Historical headers can
+// be proven against this root.
+func trustedCht(odr OdrBackend) (uint64, common.Hash) {
 	var (
+		db                       = odr.Database()
 		chtCount, sectionHeadNum uint64
 		sectionHead              common.Hash
 	)
@@ -58,14 +73,10 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 			}
 		}
 	}
-	if number >= chtCount*CHTFrequencyClient {
-		return nil, ErrNoTrustedCht
+	if chtCount == 0 {
+		return 0, common.Hash{}
 	}
-	r := &ChtRequest{ChtRoot: GetChtRoot(db, chtCount-1, sectionHead), ChtNum: chtCount - 1, BlockNum: number}
-	if err := odr.Retrieve(ctx, r); err != nil {
-		return nil, err
-	}
-	return r.Header, nil
+	return chtCount, GetChtRoot(db, chtCount-1, sectionHead)
 }
 
 func GetCanonicalHash(ctx context.Context, odr OdrBackend, number uint64) (common.Hash, error) {
@@ -227,3 +238,20 @@ func GetBloomBits(ctx context.Context, odr OdrBackend, bitIdx uint, sectionIdxLi
 		return result, nil
 	}
 }
+
+// GetTxStatus retrieves the status of the given transactions from the light
+// servers. The inclusion of mined transactions is proven against the trusted
+// CHT or the local header chain, the other statuses are taken as reported.
+func GetTxStatus(ctx context.Context, odr OdrBackend, hashes []common.Hash) ([]TxStatus, error) {
+	chtCount, chtRoot := trustedCht(odr)
+
+	status := make([]TxStatus, 0, len(hashes))
+	for len(status) < len(hashes) {
+		r := &TxStatusRequest{Hashes: hashes[len(status):], ChtCount: chtCount, ChtRoot: chtRoot}
+		if err := odr.Retrieve(ctx, r); err != nil {
+			return nil, err
+		}
+		status = append(status, r.Status...)
+	}
+	return status, nil
+}
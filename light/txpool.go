@@ -66,6 +66,13 @@ type TxPool struct {
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
 
+	trackLock    sync.Mutex                 // protects the confirmation subscriptions and tracked entries
+	watches      map[*confirmWatch]struct{} // active confirmation subscriptions
+	trackedTxs   map[common.Hash]int        // number of subscriptions tracking a transaction
+	trackedAddrs map[common.Address]int     // number of subscriptions tracking an address
+	trackedHead  uint64                     // last block checked for tracked address activity (tracking loop only)
+	trackCh      chan struct{}              // notifies the tracking loop about new heads
+
 	homestead bool
 }
 
@@ -87,23 +94,29 @@ type TxRelayBackend interface {
 // NewTxPool creates a new light transaction pool
 func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBackend) *TxPool {
 	pool := &TxPool{
-		config:      config,
-		signer:      types.NewEIP155Signer(config.ChainId),
-		nonce:       make(map[common.Address]uint64),
-		pending:     make(map[common.Hash]*types.Transaction),
-		mined:       make(map[common.Hash][]*types.Transaction),
-		quit:        make(chan bool),
-		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
-		chain:       chain,
-		relay:       relay,
-		odr:         chain.Odr(),
-		chainDb:     chain.Odr().Database(),
-		head:        chain.CurrentHeader().Hash(),
-		clearIdx:    chain.CurrentHeader().Number.Uint64(),
+		config:       config,
+		signer:       types.NewEIP155Signer(config.ChainId),
+		nonce:        make(map[common.Address]uint64),
+		pending:      make(map[common.Hash]*types.Transaction),
+		mined:        make(map[common.Hash][]*types.Transaction),
+		watches:      make(map[*confirmWatch]struct{}),
+		trackedTxs:   make(map[common.Hash]int),
+		trackedAddrs: make(map[common.Address]int),
+		trackedHead:  chain.CurrentHeader().Number.Uint64(),
+		trackCh:      make(chan struct{}, 1),
+		quit:         make(chan bool),
+		chainHeadCh:  make(chan core.ChainHeadEvent, chainHeadChanSize),
+		chain:        chain,
+		relay:        relay,
+		odr:          chain.Odr(),
+		chainDb:      chain.Odr().Database(),
+		head:         chain.CurrentHeader().Hash(),
+		clearIdx:     chain.CurrentHeader().Number.Uint64(),
 	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 	go pool.eventLoop()
+	go pool.trackLoop()
 
 	return pool
 }
@@ -286,6 +299,10 @@ func (pool *TxPool) eventLoop() {
 		select {
 		case ev := <-pool.chainHeadCh:
 			pool.setNewHead(ev.Block.Header())
+			select {
+			case pool.trackCh <- struct{}{}:
+			default:
+			}
 			// hack in order to avoid hogging the lock; this part will
 			// be replaced by a subsequent PR.
 			time.Sleep(time.Millisecond)
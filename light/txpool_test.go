@@ -28,6 +28,7 @@ import (
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/params"
 )
@@ -141,3 +142,119 @@ func TestTxPool(t *testing.T) {
 		}
 	}
 }
+
+func TestTxPoolConfirmations(t *testing.T) {
+	var (
+		sdb, _  = gdadb.NewMemDatabase()
+		ldb, _  = gdadb.NewMemDatabase()
+		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		genesis = gspec.MustCommit(sdb)
+		signer  = types.HomesteadSigner{}
+
+		// emitter is the init code of a contract emitting an empty log
+		emitter     = common.Hex2Bytes("60006000a0")
+		emitterAddr = crypto.CreateAddress(testBankAddress, 1)
+	)
+	gspec.MustCommit(ldb)
+
+	transfer, _ := types.SignTx(types.NewTransaction(0, acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), signer, testBankKey)
+	create, _ := types.SignTx(types.NewContractCreation(1, big.NewInt(0), 100000, big.NewInt(0), emitter), signer, testBankKey)
+
+	// Assemble a chain mining the transfer, the log emission and an empty block
+	blockchain, _ := core.NewBlockChain(sdb, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{})
+	gchain, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), sdb, 3, func(i int, block *core.BlockGen) {
+		switch i {
+		case 0:
+			block.AddTx(transfer)
+		case 1:
+			block.AddTx(create)
+		}
+	})
+	if _, err := blockchain.InsertChain(gchain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	odr := &testOdr{sdb: sdb, ldb: ldb}
+	relay := &testTxRelay{
+		send:    make(chan int, 1),
+		discard: make(chan int, 1),
+		mined:   make(chan int, 1),
+	}
+	lightchain, _ := NewLightChain(odr, params.TestChainConfig, ethash.NewFullFaker())
+	pool := NewTxPool(params.TestChainConfig, lightchain, relay)
+	defer pool.Stop()
+
+	// Subscriptions need to track something, but not too much
+	if _, err := pool.SubscribeTransactionConfirmed(make(chan TransactionConfirmed), make([]common.Hash, MaxTrackedPerSubscription+1), nil); err != ErrTooManyTracked {
+		t.Fatalf("oversized subscription error mismatch: have %v, want %v", err, ErrTooManyTracked)
+	}
+	if _, err := pool.SubscribeTransactionConfirmed(make(chan TransactionConfirmed), nil, nil); err != ErrNothingTracked {
+		t.Fatalf("empty subscription error mismatch: have %v, want %v", err, ErrNothingTracked)
+	}
+	var (
+		txCh   = make(chan TransactionConfirmed, 1)
+		addrCh = make(chan TransactionConfirmed, 1)
+		dropCh = make(chan TransactionConfirmed, 1)
+	)
+	txSub, _ := pool.SubscribeTransactionConfirmed(txCh, []common.Hash{transfer.Hash()}, nil)
+	defer txSub.Unsubscribe()
+	addrSub, _ := pool.SubscribeTransactionConfirmed(addrCh, nil, []common.Address{emitterAddr})
+	defer addrSub.Unsubscribe()
+	dropSub, _ := pool.SubscribeTransactionConfirmed(dropCh, []common.Hash{transfer.Hash()}, []common.Address{emitterAddr})
+
+	// Ending a subscription should only release its own entries
+	dropSub.Unsubscribe()
+
+	pool.trackLock.Lock()
+	txRefs, addrRefs := pool.trackedTxs[transfer.Hash()], pool.trackedAddrs[emitterAddr]
+	pool.trackLock.Unlock()
+	if txRefs != 1 || addrRefs != 1 {
+		t.Fatalf("tracking references mismatch: have %d/%d, want 1/1", txRefs, addrRefs)
+	}
+	expect := func(ch chan TransactionConfirmed, tx *types.Transaction, number uint64) TransactionConfirmed {
+		select {
+		case ev := <-ch:
+			if ev.Tx.Hash() != tx.Hash() || ev.BlockNumber != number || ev.BlockHash != gchain[number-1].Hash() {
+				t.Fatalf("confirmation mismatch: have %x in #%d, want %x in #%d", ev.Tx.Hash(), ev.BlockNumber, tx.Hash(), number)
+			}
+			return ev
+		case <-time.After(time.Second):
+			t.Fatalf("confirmation of %x timed out", tx.Hash())
+		}
+		return TransactionConfirmed{}
+	}
+	insert := func(i int) {
+		if _, err := lightchain.InsertHeaderChain([]*types.Header{gchain[i].Header()}, 1); err != nil {
+			t.Fatalf("failed to insert header #%d: %v", i+1, err)
+		}
+	}
+	// The tracked transaction should be confirmed via its proven status
+	insert(0)
+	expect(txCh, transfer, 1)
+
+	pool.trackLock.Lock()
+	tracked := len(pool.trackedTxs)
+	pool.trackLock.Unlock()
+	if tracked != 0 {
+		t.Errorf("confirmed transaction still tracked")
+	}
+	// The log emission should be detected via the bloom filter
+	insert(1)
+	if ev := expect(addrCh, create, 2); len(ev.Receipt.Logs) != 1 || ev.Receipt.Logs[0].Address != emitterAddr {
+		t.Errorf("receipt logs mismatch: have %v", ev.Receipt.Logs)
+	}
+	// Blocks without tracked activity should not be retrieved
+	insert(2)
+	time.Sleep(100 * time.Millisecond)
+	if core.GetBodyRLP(ldb, gchain[2].Hash(), 3) != nil {
+		t.Errorf("block without tracked activity retrieved")
+	}
+	select {
+	case ev := <-txCh:
+		t.Errorf("unexpected confirmation of %x", ev.Tx.Hash())
+	case ev := <-addrCh:
+		t.Errorf("unexpected confirmation of %x", ev.Tx.Hash())
+	case ev := <-dropCh:
+		t.Errorf("confirmation delivered to ended subscription: %x", ev.Tx.Hash())
+	default:
+	}
+}
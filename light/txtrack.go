@@ -0,0 +1,323 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+)
+
+const (
+	// MaxTrackedPerSubscription is the maximum number of transactions and
+	// addresses a single confirmation subscription may track.
+	MaxTrackedPerSubscription = 64
+
+	// maxTrackedBlocks is the maximum number of recent blocks checked for the
+	// log activity of tracked addresses after a head update.
+	maxTrackedBlocks = 128
+)
+
+var (
+	// ErrTooManyTracked is returned if a confirmation subscription would track
+	// more than MaxTrackedPerSubscription transactions and addresses.
+	ErrTooManyTracked = errors.New("too many tracked transactions and addresses")
+
+	// ErrNothingTracked is returned if a confirmation subscription would not
+	// track any transactions or addresses.
+	ErrNothingTracked = errors.New("no tracked transactions or addresses")
+)
+
+// TransactionConfirmed is posted when a tracked transaction or a transaction
+// emitting logs related to a tracked address gets included in a block, carrying
+// the receipt fetched via ODR.
+type TransactionConfirmed struct {
+	Tx          *types.Transaction
+	Receipt     *types.Receipt
+	BlockHash   common.Hash
+	BlockNumber uint64
+}
+
+// confirmWatch is a confirmation subscription, owning its tracked transactions
+// and addresses. Confirmed transactions are removed from txs, addrs is never
+// modified after the subscription is created.
+type confirmWatch struct {
+	txs   map[common.Hash]struct{}
+	addrs map[common.Address]struct{}
+	ch    chan<- TransactionConfirmed
+	done  chan struct{} // closed when the subscription ends
+}
+
+// matchesLogs checks whgdaer any of the logs are emitted by or reference one of
+// the tracked addresses.
+func (w *confirmWatch) matchesLogs(logs []*types.Log) bool {
+	return matchesAddrs(w.addrs, logs)
+}
+
+// SubscribeTransactionConfirmed registers a subscription of TransactionConfirmed
+// events for the given transactions and for the log activity of the given
+// addresses. The transactions and addresses are only tracked for as long as the
+// subscription is alive.
+func (pool *TxPool) SubscribeTransactionConfirmed(ch chan<- TransactionConfirmed, hashes []common.Hash, addrs []common.Address) (event.Subscription, error) {
+	if len(hashes)+len(addrs) > MaxTrackedPerSubscription {
+		return nil, ErrTooManyTracked
+	}
+	if len(hashes)+len(addrs) == 0 {
+		return nil, ErrNothingTracked
+	}
+	w := &confirmWatch{
+		txs:   make(map[common.Hash]struct{}),
+		addrs: make(map[common.Address]struct{}),
+		ch:    ch,
+		done:  make(chan struct{}),
+	}
+	pool.trackLock.Lock()
+	for _, hash := range hashes {
+		if _, ok := w.txs[hash]; !ok {
+			w.txs[hash] = struct{}{}
+			pool.trackedTxs[hash]++
+		}
+	}
+	for _, addr := range addrs {
+		if _, ok := w.addrs[addr]; !ok {
+			w.addrs[addr] = struct{}{}
+			pool.trackedAddrs[addr]++
+		}
+	}
+	pool.watches[w] = struct{}{}
+	pool.trackLock.Unlock()
+
+	sub := event.NewSubscription(func(quit <-chan struct{}) error {
+		select {
+		case <-quit:
+		case <-pool.quit:
+		}
+		pool.unwatch(w)
+		return nil
+	})
+	return pool.scope.Track(sub), nil
+}
+
+// unwatch removes a confirmation subscription, releasing all its tracked
+// transactions and addresses.
+func (pool *TxPool) unwatch(w *confirmWatch) {
+	pool.trackLock.Lock()
+	defer pool.trackLock.Unlock()
+
+	delete(pool.watches, w)
+	for hash := range w.txs {
+		pool.untrackTx(hash)
+	}
+	for addr := range w.addrs {
+		if pool.trackedAddrs[addr]--; pool.trackedAddrs[addr] <= 0 {
+			delete(pool.trackedAddrs, addr)
+		}
+	}
+	close(w.done)
+}
+
+// untrackTx drops a reference to a tracked transaction. The caller must hold
+// the tracking lock.
+func (pool *TxPool) untrackTx(hash common.Hash) {
+	if pool.trackedTxs[hash]--; pool.trackedTxs[hash] <= 0 {
+		delete(pool.trackedTxs, hash)
+	}
+}
+
+// trackLoop checks the tracked transactions and addresses on every head update
+// and delivers the gathered confirmations. It runs separately from the head
+// processing of the pool, so that retrievals and slow subscribers don't hold
+// the pool lock.
+func (pool *TxPool) trackLoop() {
+	for {
+		select {
+		case <-pool.trackCh:
+			ctx, cancel := context.WithTimeout(context.Background(), blockCheckTimeout)
+			confirmed := pool.checkTracked(ctx, pool.chain.CurrentHeader())
+			cancel()
+
+			pool.deliverConfirmed(confirmed)
+
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// checkTracked gathers the confirmations of the tracked transactions, whose
+// inclusion is proven via ODR, and of the transactions of the new blocks whose
+// bloom filters hint at log activity of the tracked addresses. Block bodies and
+// receipts are only retrieved for blocks containing confirmations.
+func (pool *TxPool) checkTracked(ctx context.Context, head *types.Header) []TransactionConfirmed {
+	pool.trackLock.Lock()
+	hashes := make([]common.Hash, 0, len(pool.trackedTxs))
+	for hash := range pool.trackedTxs {
+		hashes = append(hashes, hash)
+	}
+	addrs := make(map[common.Address]struct{}, len(pool.trackedAddrs))
+	for addr := range pool.trackedAddrs {
+		addrs[addr] = struct{}{}
+	}
+	pool.trackLock.Unlock()
+
+	var (
+		confirmed []TransactionConfirmed
+		seen      = make(map[common.Hash]struct{})
+	)
+	// Check the proven status of the tracked transactions
+	if len(hashes) > 0 {
+		stats, err := GetTxStatus(ctx, pool.odr, hashes)
+		if err == nil {
+			for _, stat := range stats {
+				if stat.Status != core.TxStatusIncluded {
+					continue
+				}
+				block, receipts, err := pool.getBlockWithReceipts(ctx, stat.Lookup.BlockHash, stat.Lookup.BlockIndex)
+				if err != nil || stat.Lookup.Index >= uint64(len(receipts)) {
+					continue
+				}
+				tx := block.Transactions()[stat.Lookup.Index]
+				seen[tx.Hash()] = struct{}{}
+				confirmed = append(confirmed, TransactionConfirmed{
+					Tx:          tx,
+					Receipt:     receipts[stat.Lookup.Index],
+					BlockHash:   block.Hash(),
+					BlockNumber: block.NumberU64(),
+				})
+			}
+		}
+	}
+	// Check the blooms of the new blocks for the tracked addresses
+	from, to := pool.trackedHead+1, head.Number.Uint64()
+	if from+maxTrackedBlocks <= to {
+		from = to - maxTrackedBlocks + 1
+	}
+	if len(addrs) > 0 {
+		for number := from; number <= to; number++ {
+			header := pool.chain.GetHeaderByNumber(number)
+			if header == nil || !bloomMatchesAddrs(header.Bloom, addrs) {
+				continue
+			}
+			block, receipts, err := pool.getBlockWithReceipts(ctx, header.Hash(), number)
+			if err != nil {
+				// Check the block again on the next head update
+				to = number - 1
+				break
+			}
+			for i, receipt := range receipts {
+				tx := block.Transactions()[i]
+				if _, ok := seen[tx.Hash()]; ok || !matchesAddrs(addrs, receipt.Logs) {
+					continue
+				}
+				seen[tx.Hash()] = struct{}{}
+				confirmed = append(confirmed, TransactionConfirmed{
+					Tx:          tx,
+					Receipt:     receipt,
+					BlockHash:   block.Hash(),
+					BlockNumber: number,
+				})
+			}
+		}
+	}
+	pool.trackedHead = to
+
+	return confirmed
+}
+
+// getBlockWithReceipts retrieves a block along with its receipts, fetching the
+// header first via the CHT if it is not known locally.
+func (pool *TxPool) getBlockWithReceipts(ctx context.Context, hash common.Hash, number uint64) (*types.Block, types.Receipts, error) {
+	if core.GetHeader(pool.chainDb, hash, number) == nil {
+		if _, err := GetHeaderByNumber(ctx, pool.odr, number); err != nil {
+			return nil, nil, err
+		}
+	}
+	block, err := GetBlock(ctx, pool.odr, hash, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipts, err := GetBlockReceipts(ctx, pool.odr, hash, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(receipts) != len(block.Transactions()) {
+		return nil, nil, errors.New("receipt count mismatch")
+	}
+	return block, receipts, nil
+}
+
+// deliverConfirmed sends the confirmations to the subscriptions tracking the
+// transactions or the addresses referenced by their logs. Confirmed transactions
+// are not tracked any more.
+func (pool *TxPool) deliverConfirmed(confirmed []TransactionConfirmed) {
+	for _, ev := range confirmed {
+		hash := ev.Tx.Hash()
+
+		var targets []*confirmWatch
+		pool.trackLock.Lock()
+		for w := range pool.watches {
+			if _, ok := w.txs[hash]; ok {
+				delete(w.txs, hash)
+				pool.untrackTx(hash)
+				targets = append(targets, w)
+			} else if w.matchesLogs(ev.Receipt.Logs) {
+				targets = append(targets, w)
+			}
+		}
+		pool.trackLock.Unlock()
+
+		for _, w := range targets {
+			select {
+			case w.ch <- ev:
+			case <-w.done:
+			case <-pool.quit:
+				return
+			}
+		}
+	}
+}
+
+// bloomMatchesAddrs checks whgdaer a bloom filter indicates that a block may
+// contain logs emitted by or referencing any of the addresses.
+func bloomMatchesAddrs(bloom types.Bloom, addrs map[common.Address]struct{}) bool {
+	for addr := range addrs {
+		if types.BloomLookup(bloom, addr) || types.BloomLookup(bloom, addr.Hash()) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAddrs checks whgdaer any of the logs are emitted by or reference one of
+// the addresses as an indexed topic.
+func matchesAddrs(addrs map[common.Address]struct{}, logs []*types.Log) bool {
+	for _, entry := range logs {
+		if _, ok := addrs[entry.Address]; ok {
+			return true
+		}
+		for _, topic := range entry.Topics {
+			if _, ok := addrs[common.BytesToAddress(topic[:])]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
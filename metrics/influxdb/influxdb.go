@@ -1,15 +1,36 @@
 package influxdb
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
 	uurl "net/url"
+	"strings"
 	"time"
 
 	"github.com/gdachain/go-gdachain/metrics"
 	"github.com/influxdata/influxdb/client"
 )
 
+// Config is the configuration of an InfluxDB reporter. Version 1 servers are
+// written to by database name with optional credentials, version 2 servers by
+// organization and bucket, authenticated with a token.
+type Config struct {
+	Endpoint  string            // URL of the InfluxDB server (empty = reporting disabled)
+	Database  string            // Database to write to (v1)
+	Username  string            // Username to authenticate with (v1)
+	Password  string            // Password to authenticate with (v1)
+	Token     string            // Token to authenticate with, selecting the v2 API if set
+	Org       string            // Organization owning the bucket (v2)
+	Bucket    string            // Bucket to write to (v2)
+	Namespace string            // Prefix of the measurement names
+	Tags      map[string]string // Tags attached to all the reported points
+	Interval  time.Duration     // Reporting interval
+}
+
 type reporter struct {
 	reg      metrics.Registry
 	interval time.Duration
@@ -21,9 +42,15 @@ type reporter struct {
 	namespace string
 	tags      map[string]string
 
+	token  string // Authentication token of the v2 API (empty = v1 API)
+	org    string
+	bucket string
+
 	client *client.Client
+	http   *http.Client
 
 	cache map[string]int64
+	quit  chan struct{}
 }
 
 // InfluxDB starts a InfluxDB reporter which will post the from the given metrics.Registry at each d interval.
@@ -33,29 +60,86 @@ func InfluxDB(r metrics.Registry, d time.Duration, url, database, username, pass
 
 // InfluxDBWithTags starts a InfluxDB reporter which will post the from the given metrics.Registry at each d interval with the specified tags
 func InfluxDBWithTags(r metrics.Registry, d time.Duration, url, database, username, password, namespace string, tags map[string]string) {
-	u, err := uurl.Parse(url)
+	rep, err := newReporter(r, Config{
+		Endpoint:  url,
+		Database:  database,
+		Username:  username,
+		Password:  password,
+		Namespace: namespace,
+		Tags:      tags,
+		Interval:  d,
+	})
 	if err != nil {
-		log.Printf("unable to parse InfluxDB url %s. err=%v", url, err)
+		log.Printf("unable to create InfluxDB reporter. err=%v", err)
 		return
 	}
+	rep.run()
+}
+
+// InfluxDBV2WithTags starts a InfluxDB v2 reporter which will post the from the given metrics.Registry at each d interval with the specified tags
+func InfluxDBV2WithTags(r metrics.Registry, d time.Duration, url, token, org, bucket, namespace string, tags map[string]string) {
+	rep, err := newReporter(r, Config{
+		Endpoint:  url,
+		Token:     token,
+		Org:       org,
+		Bucket:    bucket,
+		Namespace: namespace,
+		Tags:      tags,
+		Interval:  d,
+	})
+	if err != nil {
+		log.Printf("unable to create InfluxDB reporter. err=%v", err)
+		return
+	}
+	rep.run()
+}
+
+// Start starts a reporter posting the metrics of the given registry in the
+// background, returning a function to stop it.
+func Start(r metrics.Registry, config Config) (func(), error) {
+	rep, err := newReporter(r, config)
+	if err != nil {
+		return nil, err
+	}
+	go rep.run()
+	return func() { close(rep.quit) }, nil
+}
 
+// newReporter validates a reporter configuration and creates the reporter.
+func newReporter(r metrics.Registry, config Config) (*reporter, error) {
+	u, err := uurl.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid InfluxDB url %s: %v", config.Endpoint, err)
+	}
+	if config.Interval <= 0 {
+		return nil, errors.New("invalid InfluxDB reporting interval")
+	}
+	if config.Token != "" && (config.Org == "" || config.Bucket == "") {
+		return nil, errors.New("InfluxDB v2 reporting requires an organization and a bucket")
+	}
 	rep := &reporter{
 		reg:       r,
-		interval:  d,
+		interval:  config.Interval,
 		url:       *u,
-		database:  database,
-		username:  username,
-		password:  password,
-		namespace: namespace,
-		tags:      tags,
+		database:  config.Database,
+		username:  config.Username,
+		password:  config.Password,
+		namespace: config.Namespace,
+		tags:      config.Tags,
+		token:     config.Token,
+		org:       config.Org,
+		bucket:    config.Bucket,
 		cache:     make(map[string]int64),
+		quit:      make(chan struct{}),
+	}
+	if rep.token != "" {
+		rep.http = &http.Client{Timeout: 10 * time.Second}
+		return rep, nil
 	}
 	if err := rep.makeClient(); err != nil {
-		log.Printf("unable to make InfluxDB client. err=%v", err)
-		return
+		return nil, fmt.Errorf("unable to make InfluxDB client: %v", err)
 	}
-
-	rep.run()
+	return rep, nil
 }
 
 func (r *reporter) makeClient() (err error) {
@@ -69,16 +153,21 @@ func (r *reporter) makeClient() (err error) {
 }
 
 func (r *reporter) run() {
-	intervalTicker := time.Tick(r.interval)
-	pingTicker := time.Tick(time.Second * 5)
+	intervalTicker := time.NewTicker(r.interval)
+	defer intervalTicker.Stop()
+	pingTicker := time.NewTicker(time.Second * 5)
+	defer pingTicker.Stop()
 
 	for {
 		select {
-		case <-intervalTicker:
+		case <-intervalTicker.C:
 			if err := r.send(); err != nil {
 				log.Printf("unable to send to InfluxDB. err=%v", err)
 			}
-		case <-pingTicker:
+		case <-pingTicker.C:
+			if r.client == nil {
+				continue // The v2 API is stateless, nothing to recreate
+			}
 			_, _, err := r.client.Ping()
 			if err != nil {
 				log.Printf("got error while sending a ping to InfluxDB, trying to recreate client. err=%v", err)
@@ -87,35 +176,100 @@ func (r *reporter) run() {
 					log.Printf("unable to make InfluxDB client. err=%v", err)
 				}
 			}
+		case <-r.quit:
+			return
 		}
 	}
 }
 
 func (r *reporter) send() error {
+	pts := r.points()
+	if r.token != "" {
+		return r.writeV2(pts)
+	}
+	bps := client.BatchPoints{
+		Points:   pts,
+		Database: r.database,
+	}
+
+	_, err := r.client.Write(bps)
+	return err
+}
+
+// writeV2 posts the points in line protocol to the write endpoint of the v2 API.
+func (r *reporter) writeV2(pts []client.Point) error {
+	lines := make([]string, len(pts))
+	for i := range pts {
+		lines[i] = pts[i].MarshalString()
+	}
+	u := r.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+	u.RawQuery = uurl.Values{"org": {r.org}, "bucket": {r.bucket}, "precision": {"ns"}}.Encode()
+
+	req, err := http.NewRequest("POST", u.String(), bytes.NewBufferString(strings.Join(lines, "\n")))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+r.token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	res, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("write failed: %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// pointTags returns the tags of a point, merging the tags of a metric
+// registered with metrics.TaggedName into the reporter's tags.
+func (r *reporter) pointTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return r.tags
+	}
+	merged := make(map[string]string, len(r.tags)+len(tags))
+	for k, v := range r.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return merged
+}
+
+// points assembles the points reporting the current state of the registry.
+func (r *reporter) points() []client.Point {
 	var pts []client.Point
 
-	r.reg.Each(func(name string, i interface{}) {
+	r.reg.Each(func(key string, i interface{}) {
 		now := time.Now()
 		namespace := r.namespace
+		name, tags := metrics.SplitTags(key)
+		tags = r.pointTags(tags)
 
 		switch metric := i.(type) {
 		case metrics.Counter:
 			v := metric.Count()
-			l := r.cache[name]
+			l := r.cache[key]
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.count", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"value": v - l,
 				},
 				Time: now,
 			})
-			r.cache[name] = v
+			r.cache[key] = v
 		case metrics.Gauge:
 			ms := metric.Snapshot()
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"value": ms.Value(),
 				},
@@ -125,7 +279,7 @@ func (r *reporter) send() error {
 			ms := metric.Snapshot()
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"value": ms.Value(),
 				},
@@ -136,7 +290,7 @@ func (r *reporter) send() error {
 			ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.histogram", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"count":    ms.Count(),
 					"max":      ms.Max(),
@@ -157,7 +311,7 @@ func (r *reporter) send() error {
 			ms := metric.Snapshot()
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.meter", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"count": ms.Count(),
 					"m1":    ms.Rate1(),
@@ -172,7 +326,7 @@ func (r *reporter) send() error {
 			ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
 			pts = append(pts, client.Point{
 				Measurement: fmt.Sprintf("%s%s.timer", namespace, name),
-				Tags:        r.tags,
+				Tags:        tags,
 				Fields: map[string]interface{}{
 					"count":    ms.Count(),
 					"max":      ms.Max(),
@@ -201,7 +355,7 @@ func (r *reporter) send() error {
 				val := t.Values()
 				pts = append(pts, client.Point{
 					Measurement: fmt.Sprintf("%s%s.span", namespace, name),
-					Tags:        r.tags,
+					Tags:        tags,
 					Fields: map[string]interface{}{
 						"count": len(val),
 						"max":   val[len(val)-1],
@@ -216,12 +370,5 @@ func (r *reporter) send() error {
 			}
 		}
 	})
-
-	bps := client.BatchPoints{
-		Points:   pts,
-		Database: r.database,
-	}
-
-	_, err := r.client.Write(bps)
-	return err
+	return pts
 }
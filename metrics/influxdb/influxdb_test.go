@@ -0,0 +1,72 @@
+package influxdb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+func init() {
+	metrics.Enabled = true
+}
+
+// Tests that the v2 reporter authenticates with its token and writes tagged
+// points into the configured bucket.
+func TestInfluxDBV2Write(t *testing.T) {
+	var (
+		auth  string
+		query string
+		body  string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" {
+			http.NotFound(w, r)
+			return
+		}
+		blob, _ := ioutil.ReadAll(r.Body)
+		auth, query, body = r.Header.Get("Authorization"), r.URL.RawQuery, string(blob)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	reg := metrics.NewRegistry()
+	metrics.GetOrRegisterTaggedMeter("les/peer/traffic", map[string]string{"peer": "a1b2"}, reg).Mark(3)
+
+	rep, err := newReporter(reg, Config{
+		Endpoint:  server.URL,
+		Token:     "secret",
+		Org:       "gda",
+		Bucket:    "nodes",
+		Namespace: "gtst.",
+		Tags:      map[string]string{"network": "1", "instance": "node-1"},
+		Interval:  time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create reporter: %v", err)
+	}
+	if err := rep.send(); err != nil {
+		t.Fatalf("failed to send metrics: %v", err)
+	}
+	if auth != "Token secret" {
+		t.Errorf("authorization mismatch: have %q, want %q", auth, "Token secret")
+	}
+	if want := "bucket=nodes&org=gda&precision=ns"; query != want {
+		t.Errorf("query mismatch: have %q, want %q", query, want)
+	}
+	if want := "gtst.les/peer/traffic.meter,instance=node-1,network=1,peer=a1b2 count=3i"; !strings.HasPrefix(body, want) {
+		t.Errorf("point mismatch: have %q, want prefix %q", body, want)
+	}
+}
+
+// Tests that v2 reporters are rejected without a bucket to write to.
+func TestInfluxDBV2Config(t *testing.T) {
+	_, err := newReporter(metrics.NewRegistry(), Config{Endpoint: "http://localhost:8086", Token: "secret", Org: "gda", Interval: time.Second})
+	if err == nil {
+		t.Fatalf("reporter without bucket accepted")
+	}
+}
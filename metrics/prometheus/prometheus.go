@@ -0,0 +1,167 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package prometheus exposes go-metrics registries in the Prometheus text
+// exposition format.
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+// quantiles are the percentiles exported for histograms and timers.
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// Handler returns an HTTP handler serving the metrics of a registry in the
+// Prometheus text exposition format.
+func Handler(reg metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(Collect(reg))
+	})
+}
+
+// sample is a registered metric along with its Prometheus identity.
+type sample struct {
+	family string            // Sanitized metric name, shared by all tag sets
+	labels map[string]string // Tags of the metric, exported as labels
+	key    string            // Registry name, ordering the tag sets of a family
+	metric interface{}
+}
+
+// Collect renders the metrics of a registry in the Prometheus text exposition
+// format. Metric names are sanitized into valid Prometheus identifiers, so
+// gda/downloader/headers/in is exported as gda_downloader_headers_in, and the
+// tags of metrics registered with metrics.TaggedName are exported as labels.
+//
+// Counters and gauges are exported as gauges (go-metrics counters may also be
+// decremented), meters as counters of their event counts, and histograms and
+// timers as summaries. Resetting timers are skipped, as taking their snapshot
+// would clear them for the other reporters.
+func Collect(reg metrics.Registry) []byte {
+	var samples []sample
+	reg.Each(func(name string, metric interface{}) {
+		base, tags := metrics.SplitTags(name)
+		samples = append(samples, sample{family: sanitize(base), labels: tags, key: name, metric: metric})
+	})
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].family != samples[j].family {
+			return samples[i].family < samples[j].family
+		}
+		return samples[i].key < samples[j].key
+	})
+	buf := new(bytes.Buffer)
+	for i, s := range samples {
+		// Announce the type of each family once, ahead of its first sample
+		kind := ""
+		switch s.metric.(type) {
+		case metrics.Counter, metrics.Gauge, metrics.GaugeFloat64:
+			kind = "gauge"
+		case metrics.Meter:
+			kind = "counter"
+		case metrics.Histogram, metrics.Timer:
+			kind = "summary"
+		default:
+			continue
+		}
+		if i == 0 || samples[i-1].family != s.family {
+			fmt.Fprintf(buf, "# TYPE %s %s\n", s.family, kind)
+		}
+		switch metric := s.metric.(type) {
+		case metrics.Counter:
+			writeValue(buf, s.family, s.labels, float64(metric.Count()))
+		case metrics.Gauge:
+			writeValue(buf, s.family, s.labels, float64(metric.Value()))
+		case metrics.GaugeFloat64:
+			writeValue(buf, s.family, s.labels, metric.Value())
+		case metrics.Meter:
+			writeValue(buf, s.family, s.labels, float64(metric.Snapshot().Count()))
+		case metrics.Histogram:
+			h := metric.Snapshot()
+			writeSummary(buf, s.family, s.labels, h.Percentiles(quantiles), h.Sum(), h.Count())
+		case metrics.Timer:
+			t := metric.Snapshot()
+			writeSummary(buf, s.family, s.labels, t.Percentiles(quantiles), t.Sum(), t.Count())
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeValue writes a metric consisting of a single sample.
+func writeValue(buf *bytes.Buffer, name string, labels map[string]string, value float64) {
+	fmt.Fprintf(buf, "%s%s %s\n", name, formatLabels(labels, ""), formatFloat(value))
+}
+
+// writeSummary writes a summary with the given quantile values.
+func writeSummary(buf *bytes.Buffer, name string, labels map[string]string, values []float64, sum int64, count int64) {
+	for i, q := range quantiles {
+		fmt.Fprintf(buf, "%s%s %s\n", name, formatLabels(labels, formatFloat(q)), formatFloat(values[i]))
+	}
+	fmt.Fprintf(buf, "%s_sum%s %d\n", name, formatLabels(labels, ""), sum)
+	fmt.Fprintf(buf, "%s_count%s %d\n", name, formatLabels(labels, ""), count)
+}
+
+// labelEscaper escapes label values as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats the label set of a sample, including the quantile label
+// of summaries if set.
+func formatLabels(labels map[string]string, quantile string) string {
+	if len(labels) == 0 && quantile == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", sanitize(key), labelEscaper.Replace(labels[key])))
+	}
+	if quantile != "" {
+		pairs = append(pairs, fmt.Sprintf("quantile=\"%s\"", quantile))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat formats a sample value as accepted by Prometheus.
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sanitize converts a metric name into a valid Prometheus identifier by
+// replacing all unsupported characters with underscores.
+func sanitize(name string) string {
+	id := []byte(name)
+	for i, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			id[i] = '_'
+		}
+	}
+	return string(id)
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+func init() {
+	metrics.Enabled = true
+}
+
+// Tests that the registered metrics are rendered in the exposition format.
+func TestCollect(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	metrics.NewRegisteredCounter("gda/downloader/headers/in", reg).Inc(3)
+	metrics.NewRegisteredGauge("les/server/clients", reg).Update(5)
+	metrics.NewRegisteredMeter("p2p/InboundTraffic", reg).Mark(1024)
+	metrics.NewRegisteredTimer("chain/inserts", reg).Update(time.Second)
+	metrics.GetOrRegisterTaggedMeter("les/peer/traffic", map[string]string{"peer": "a1b2"}, reg).Mark(1)
+	metrics.GetOrRegisterTaggedMeter("les/peer/traffic", map[string]string{"peer": "c3d4"}, reg).Mark(2)
+
+	have := string(Collect(reg))
+	for _, want := range []string{
+		"# TYPE gda_downloader_headers_in gauge\ngda_downloader_headers_in 3\n",
+		"# TYPE les_server_clients gauge\nles_server_clients 5\n",
+		"# TYPE p2p_InboundTraffic counter\np2p_InboundTraffic 1024\n",
+		"# TYPE chain_inserts summary\n",
+		"chain_inserts{quantile=\"0.99\"} 1e+09\n",
+		"chain_inserts_sum 1000000000\nchain_inserts_count 1\n",
+		"# TYPE les_peer_traffic counter\nles_peer_traffic{peer=\"a1b2\"} 1\nles_peer_traffic{peer=\"c3d4\"} 2\n",
+	} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing %q in output:\n%s", want, have)
+		}
+	}
+}
+
+// Tests that metric names are converted into valid Prometheus identifiers.
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"gda/db/chaindata/compact/input", "gda_db_chaindata_compact_input"},
+		{"les/client/req/rtt.mean", "les_client_req_rtt_mean"},
+		{"1st-metric", "_st_metric"},
+	}
+	for _, tt := range tests {
+		if have := sanitize(tt.name); have != tt.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tt.name, have, tt.want)
+		}
+	}
+}
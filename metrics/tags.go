@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+)
+
+// tagEscaper replaces the characters delimiting the tags of a tagged name.
+var tagEscaper = strings.NewReplacer("{", "_", "}", "_", ",", "_", "=", "_")
+
+// TaggedName returns the name under which a metric carrying the given tags is
+// registered, e.g. les/peer/traffic{peer=a1b2c3,version=2}. Reporters supporting
+// tags split the name again with SplitTags, the others report it verbatim. The
+// characters {}=, are replaced in the tag keys and values.
+func TaggedName(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = tagEscaper.Replace(key) + "=" + tagEscaper.Replace(tags[key])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// SplitTags splits a name created by TaggedName into the metric name and its
+// tags. Names without tags are returned as is, along with nil tags.
+func SplitTags(name string) (string, map[string]string) {
+	start := strings.IndexByte(name, '{')
+	if start < 0 || !strings.HasSuffix(name, "}") {
+		return name, nil
+	}
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(name[start+1:len(name)-1], ",") {
+		if kv := strings.SplitN(pair, "=", 2); len(kv) == 2 {
+			tags[kv[0]] = kv[1]
+		}
+	}
+	return name[:start], tags
+}
+
+// GetOrRegisterTaggedCounter returns an existing Counter carrying the given tags
+// or constructs and registers a new StandardCounter.
+func GetOrRegisterTaggedCounter(name string, tags map[string]string, r Registry) Counter {
+	return GetOrRegisterCounter(TaggedName(name, tags), r)
+}
+
+// GetOrRegisterTaggedMeter returns an existing Meter carrying the given tags or
+// constructs and registers a new StandardMeter.
+func GetOrRegisterTaggedMeter(name string, tags map[string]string, r Registry) Meter {
+	return GetOrRegisterMeter(TaggedName(name, tags), r)
+}
+
+// GetOrRegisterTaggedTimer returns an existing Timer carrying the given tags or
+// constructs and registers a new StandardTimer.
+func GetOrRegisterTaggedTimer(name string, tags map[string]string, r Registry) Timer {
+	return GetOrRegisterTimer(TaggedName(name, tags), r)
+}
+
+// UnregisterTagged removes the metric carrying the given tags from the registry,
+// releasing metrics tagged with short lived values such as peer ids.
+func UnregisterTagged(name string, tags map[string]string, r Registry) {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Unregister(TaggedName(name, tags))
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTaggedName(t *testing.T) {
+	tags := map[string]string{"version": "2", "peer": "a1b2,c3"}
+
+	name := TaggedName("les/peer/traffic", tags)
+	if want := "les/peer/traffic{peer=a1b2_c3,version=2}"; name != want {
+		t.Fatalf("tagged name mismatch: have %q, want %q", name, want)
+	}
+	base, split := SplitTags(name)
+	if base != "les/peer/traffic" {
+		t.Fatalf("name mismatch: have %q, want %q", base, "les/peer/traffic")
+	}
+	if want := map[string]string{"version": "2", "peer": "a1b2_c3"}; !reflect.DeepEqual(split, want) {
+		t.Fatalf("tags mismatch: have %v, want %v", split, want)
+	}
+	if name := TaggedName("chain/inserts", nil); name != "chain/inserts" {
+		t.Fatalf("untagged name mismatch: have %q", name)
+	}
+	if base, split := SplitTags("chain/inserts"); base != "chain/inserts" || split != nil {
+		t.Fatalf("untagged split mismatch: have %q, %v", base, split)
+	}
+}
+
+func TestGetOrRegisterTaggedMeter(t *testing.T) {
+	r := NewRegistry()
+	tags := map[string]string{"peer": "a1b2"}
+
+	GetOrRegisterTaggedMeter("foo", tags, r).Mark(47)
+	if m := GetOrRegisterTaggedMeter("foo", tags, r); m.Count() != 47 {
+		t.Fatal(m)
+	}
+	UnregisterTagged("foo", tags, r)
+	if m := r.Get(TaggedName("foo", tags)); m != nil {
+		t.Fatal(m)
+	}
+}
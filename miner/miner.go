@@ -20,6 +20,7 @@ package miner
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/common"
@@ -40,12 +41,11 @@ type Backend interface {
 	BlockChain() *core.BlockChain
 	TxPool() *core.TxPool
 	ChainDb() gdadb.Database
+	Downloader() *downloader.Downloader
 }
 
 // Miner creates blocks and searches for proof-of-work values.
 type Miner struct {
-	mux *event.TypeMux
-
 	worker *worker
 
 	coinbase common.Address
@@ -57,16 +57,18 @@ type Miner struct {
 	shouldStart int32 // should start indicates whgdaer we should start after sync
 }
 
-func New(gda Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) *Miner {
+func New(gda Backend, config *params.ChainConfig, engine consensus.Engine) *Miner {
 	miner := &Miner{
 		gda:      gda,
-		mux:      mux,
 		engine:   engine,
-		worker:   newWorker(config, engine, common.Address{}, gda, mux),
+		worker:   newWorker(config, engine, common.Address{}, gda),
 		canStart: 1,
 	}
 	miner.Register(NewCpuAgent(gda.BlockChain(), engine))
-	go miner.update()
+
+	events := make(chan downloader.SyncEvent, 4)
+	sub := gda.Downloader().SubscribeSyncEvent(events)
+	go miner.update(events, sub)
 
 	return miner
 }
@@ -75,30 +77,34 @@ func New(gda Backend, config *params.ChainConfig, mux *event.TypeMux, engine con
 // It's entered once and as soon as `Done` or `Failed` has been broadcasted the events are unregistered and
 // the loop is exited. This to prevent a major security vuln where external parties can DOS you with blocks
 // and halt your mining operation for as long as the DOS continues.
-func (self *Miner) update() {
-	events := self.mux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
-out:
-	for ev := range events.Chan() {
-		switch ev.Data.(type) {
-		case downloader.StartEvent:
-			atomic.StoreInt32(&self.canStart, 0)
-			if self.Mining() {
-				self.Stop()
-				atomic.StoreInt32(&self.shouldStart, 1)
-				log.Info("Mining aborted due to sync")
-			}
-		case downloader.DoneEvent, downloader.FailedEvent:
-			shouldStart := atomic.LoadInt32(&self.shouldStart) == 1
-
-			atomic.StoreInt32(&self.canStart, 1)
-			atomic.StoreInt32(&self.shouldStart, 0)
-			if shouldStart {
-				self.Start(self.coinbase)
+func (self *Miner) update(events chan downloader.SyncEvent, sub event.Subscription) {
+	// unsubscribe on exit. we're only interested in this event once
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Status {
+			case downloader.SyncStarted:
+				atomic.StoreInt32(&self.canStart, 0)
+				if self.Mining() {
+					self.Stop()
+					atomic.StoreInt32(&self.shouldStart, 1)
+					log.Info("Mining aborted due to sync")
+				}
+			case downloader.SyncDone, downloader.SyncFailed:
+				shouldStart := atomic.LoadInt32(&self.shouldStart) == 1
+
+				atomic.StoreInt32(&self.canStart, 1)
+				atomic.StoreInt32(&self.shouldStart, 0)
+				if shouldStart {
+					self.Start(self.coinbase)
+				}
+				// stop immediately and ignore all further pending events
+				return
 			}
-			// unsubscribe. we're only interested in this event once
-			events.Unsubscribe()
-			// stop immediately and ignore all further pending events
-			break out
+		case <-sub.Err():
+			return
 		}
 	}
 }
@@ -136,6 +142,24 @@ func (self *Miner) Unregister(agent Agent) {
 	self.worker.unregister(agent)
 }
 
+// SubscribeNewMinedBlockEvent registers a subscription of NewMinedBlockEvent
+// and starts sending event to the given channel.
+func (self *Miner) SubscribeNewMinedBlockEvent(ch chan<- core.NewMinedBlockEvent) event.Subscription {
+	return self.worker.minedFeed.Subscribe(ch)
+}
+
+// SubscribePendingLogsEvent registers a subscription of PendingLogsEvent and
+// starts sending event to the given channel.
+func (self *Miner) SubscribePendingLogsEvent(ch chan<- core.PendingLogsEvent) event.Subscription {
+	return self.worker.pendingLogsFeed.Subscribe(ch)
+}
+
+// SubscribePendingStateEvent registers a subscription of PendingStateEvent and
+// starts sending event to the given channel.
+func (self *Miner) SubscribePendingStateEvent(ch chan<- core.PendingStateEvent) event.Subscription {
+	return self.worker.pendingStateFeed.Subscribe(ch)
+}
+
 func (self *Miner) Mining() bool {
 	return atomic.LoadInt32(&self.mining) > 0
 }
@@ -163,6 +187,40 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SubscribePendingBlockEvent starts delivering the pending blocks assembled by
+// the miner, each time the sealing work is recreated.
+func (self *Miner) SubscribePendingBlockEvent(ch chan<- core.PendingBlockEvent) event.Subscription {
+	return self.worker.pendingFeed.Subscribe(ch)
+}
+
+// SetUnclePolicy replaces the rules by which side blocks are included as uncles
+// into the locally sealed blocks.
+func (self *Miner) SetUnclePolicy(policy UnclePolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	self.worker.setUnclePolicy(policy.copy())
+	return nil
+}
+
+// SetRecommitInterval sets the interval at which the sealing work is recreated
+// to include newly arrived transactions. Zero only recreates it on new heads.
+func (self *Miner) SetRecommitInterval(interval time.Duration) {
+	self.worker.setRecommitInterval(interval)
+}
+
+// SetEmptyWait sets how long after its parent an empty block is held back from
+// sealing, waiting for transactions to arrive. Zero seals empty blocks at once.
+func (self *Miner) SetEmptyWait(wait time.Duration) {
+	self.worker.setEmptyWait(wait)
+}
+
+// SetDeadline sets the maximum time spent assembling a block before sealing it
+// with the transactions gathered so far. Zero means no limit.
+func (self *Miner) SetDeadline(deadline time.Duration) {
+	self.worker.setDeadline(deadline)
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
@@ -0,0 +1,76 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+const (
+	maxUncles     = 2 // Maximum number of uncles allowed in a single block
+	maxUncleDepth = 7 // Maximum distance of an uncle from the block including it
+)
+
+// UnclePolicy configures which side blocks the miner includes as uncles into
+// the blocks it seals.
+type UnclePolicy struct {
+	MaxUncles int              `json:"maxUncles"` // Maximum number of uncles to include per block
+	MinDepth  uint64           `json:"minDepth"`  // Minimum distance of an uncle from the sealed block
+	Blacklist []common.Address `json:"blacklist"` // Coinbases whose orphans are never included (e.g. own sealers)
+}
+
+// DefaultUnclePolicy includes as many uncles as the consensus rules permit.
+var DefaultUnclePolicy = UnclePolicy{
+	MaxUncles: maxUncles,
+	MinDepth:  1,
+}
+
+// validate checks that the policy is within the bounds of the consensus rules.
+func (p *UnclePolicy) validate() error {
+	if p.MaxUncles < 0 || p.MaxUncles > maxUncles {
+		return fmt.Errorf("max uncles %d out of range [0, %d]", p.MaxUncles, maxUncles)
+	}
+	if p.MinDepth > maxUncleDepth {
+		return fmt.Errorf("min uncle depth %d exceeds limit %d", p.MinDepth, maxUncleDepth)
+	}
+	return nil
+}
+
+// shallow returns whgdaer the uncle is too close to the block being sealed to
+// be included yet. Such uncles may still qualify for later blocks.
+func (p *UnclePolicy) shallow(number uint64, uncle *types.Header) bool {
+	return number < uncle.Number.Uint64()+p.MinDepth
+}
+
+// banned returns whgdaer the uncle was mined by a blacklisted coinbase.
+func (p *UnclePolicy) banned(uncle *types.Header) bool {
+	for _, addr := range p.Blacklist {
+		if addr == uncle.Coinbase {
+			return true
+		}
+	}
+	return false
+}
+
+// copy creates a deep copy of the policy.
+func (p UnclePolicy) copy() UnclePolicy {
+	p.Blacklist = append([]common.Address(nil), p.Blacklist...)
+	return p
+}
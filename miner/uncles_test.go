@@ -0,0 +1,66 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// Tests that uncle policies are bounded by the consensus rules.
+func TestUnclePolicyValidation(t *testing.T) {
+	tests := []struct {
+		policy UnclePolicy
+		valid  bool
+	}{
+		{DefaultUnclePolicy, true},
+		{UnclePolicy{}, true},
+		{UnclePolicy{MaxUncles: 3}, false},
+		{UnclePolicy{MaxUncles: -1}, false},
+		{UnclePolicy{MaxUncles: 1, MinDepth: maxUncleDepth}, true},
+		{UnclePolicy{MaxUncles: 1, MinDepth: maxUncleDepth + 1}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.policy.validate(); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch: have %v, want valid %v", i, err, tt.valid)
+		}
+	}
+}
+
+// Tests that uncles are filtered by depth and coinbase.
+func TestUnclePolicyFilter(t *testing.T) {
+	own := common.HexToAddress("0x01")
+	policy := UnclePolicy{MaxUncles: 2, MinDepth: 3, Blacklist: []common.Address{own}}
+
+	uncle := &types.Header{Number: big.NewInt(10), Coinbase: common.HexToAddress("0x02")}
+	if !policy.shallow(12, uncle) {
+		t.Errorf("uncle at depth 2 accepted")
+	}
+	if policy.shallow(13, uncle) {
+		t.Errorf("uncle at depth 3 rejected")
+	}
+	if policy.banned(uncle) {
+		t.Errorf("foreign uncle banned")
+	}
+	uncle.Coinbase = own
+	if !policy.banned(uncle) {
+		t.Errorf("blacklisted uncle allowed")
+	}
+}
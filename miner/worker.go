@@ -79,6 +79,7 @@ type Work struct {
 	receipts []*types.Receipt
 
 	createdAt time.Time
+	deadline  time.Time // Time after which no more transactions are added (zero = none)
 }
 
 type Result struct {
@@ -93,8 +94,14 @@ type worker struct {
 
 	mu sync.Mutex
 
+	// feeds, delivering to all subscribers before Send returns, so a slow
+	// subscriber holds up both the miner and every other subscriber
+	minedFeed        event.Feed // Announces locally mined blocks
+	pendingFeed      event.Feed // Announces freshly assembled pending blocks
+	pendingLogsFeed  event.Feed // Announces logs of the pending block
+	pendingStateFeed event.Feed // Announces changes of the pending state
+
 	// update loop
-	mux          *event.TypeMux
 	txCh         chan core.TxPreEvent
 	txSub        event.Subscription
 	chainHeadCh  chan core.ChainHeadEvent
@@ -113,6 +120,12 @@ type worker struct {
 
 	coinbase common.Address
 	extra    []byte
+	uncles   UnclePolicy
+
+	recommit      time.Duration // Interval to recreate the sealing work (0 = only on new heads)
+	emptyWait     time.Duration // Time to hold off sealing empty blocks, waiting for transactions
+	deadline      time.Duration // Maximum time to spend assembling a block (0 = unlimited)
+	recommitTimer *time.Timer   // Timer scheduling the next sealing work recreation
 
 	currentMu sync.Mutex
 	current   *Work
@@ -123,16 +136,16 @@ type worker struct {
 	unconfirmed *unconfirmedBlocks // set of locally mined blocks pending canonicalness confirmations
 
 	// atomic status counters
-	mining int32
-	atWork int32
+	mining     int32
+	atWork     int32
+	suppressed int32 // Whgdaer sealing of the current empty block is held back
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, gda Backend, mux *event.TypeMux) *worker {
+func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, gda Backend) *worker {
 	worker := &worker{
 		config:         config,
 		engine:         engine,
 		gda:            gda,
-		mux:            mux,
 		txCh:           make(chan core.TxPreEvent, txChanSize),
 		chainHeadCh:    make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:    make(chan core.ChainSideEvent, chainSideChanSize),
@@ -142,6 +155,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		proc:           gda.BlockChain().Validator(),
 		possibleUncles: make(map[common.Hash]*types.Block),
 		coinbase:       coinbase,
+		uncles:         DefaultUnclePolicy,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(gda.BlockChain(), miningLogAtDepth),
 	}
@@ -170,6 +184,30 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+func (self *worker) setUnclePolicy(policy UnclePolicy) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.uncles = policy
+}
+
+func (self *worker) setRecommitInterval(interval time.Duration) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.recommit = interval
+}
+
+func (self *worker) setEmptyWait(wait time.Duration) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.emptyWait = wait
+}
+
+func (self *worker) setDeadline(deadline time.Duration) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.deadline = deadline
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
@@ -224,6 +262,12 @@ func (self *worker) stop() {
 	}
 	atomic.StoreInt32(&self.mining, 0)
 	atomic.StoreInt32(&self.atWork, 0)
+	atomic.StoreInt32(&self.suppressed, 0)
+
+	if self.recommitTimer != nil {
+		self.recommitTimer.Stop()
+		self.recommitTimer = nil
+	}
 }
 
 func (self *worker) register(agent Agent) {
@@ -267,11 +311,12 @@ func (self *worker) update() {
 				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
 				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
 
-				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
+				self.current.commitTransactions(&self.pendingLogsFeed, &self.pendingStateFeed, txset, self.chain, self.coinbase)
 				self.currentMu.Unlock()
 			} else {
-				// If we're mining, but nothing is being processed, wake on new transactions
-				if self.config.Clique != nil && self.config.Clique.Period == 0 {
+				// If we're mining, but nothing is being processed or an empty block is
+				// held back, wake on new transactions
+				if (self.config.Clique != nil && self.config.Clique.Period == 0) || atomic.LoadInt32(&self.suppressed) == 1 {
 					self.commitNewWork()
 				}
 			}
@@ -320,7 +365,7 @@ func (self *worker) wait() {
 				mustCommitNewWork = false
 			}
 			// Broadcast the block and announce chain insertion event
-			self.mux.Post(core.NewMinedBlockEvent{Block: block})
+			self.minedFeed.Send(core.NewMinedBlockEvent{Block: block})
 			var (
 				events []interface{}
 				logs   = work.state.Logs()
@@ -386,7 +431,19 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	return nil
 }
 
+// recommitWork recreates the sealing work if the miner is still running, to pick
+// up transactions arrived since or to seal a previously held back empty block.
+func (self *worker) recommitWork() {
+	if atomic.LoadInt32(&self.mining) == 1 {
+		self.commitNewWork()
+	}
+}
+
 func (self *worker) commitNewWork() {
+	// Start the assembly deadline before acquiring the locks, so that consumers
+	// holding on to the pending state cannot delay sealing indefinitely
+	begin := time.Now()
+
 	self.mu.Lock()
 	defer self.mu.Unlock()
 	self.uncleMu.Lock()
@@ -445,6 +502,9 @@ func (self *worker) commitNewWork() {
 	}
 	// Create the current work task and check any fork transitions needed
 	work := self.current
+	if self.deadline > 0 {
+		work.deadline = begin.Add(self.deadline)
+	}
 	if self.config.DAOForkSupport && self.config.DAOForkBlock != nil && self.config.DAOForkBlock.Cmp(header.Number) == 0 {
 		misc.ApplyDAOHardFork(work.state)
 	}
@@ -454,7 +514,7 @@ func (self *worker) commitNewWork() {
 		return
 	}
 	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitTransactions(&self.pendingLogsFeed, &self.pendingStateFeed, txs, self.chain, self.coinbase)
 
 	// compute uncles for the new block.
 	var (
@@ -462,9 +522,17 @@ func (self *worker) commitNewWork() {
 		badUncles []common.Hash
 	)
 	for hash, uncle := range self.possibleUncles {
-		if len(uncles) == 2 {
+		if len(uncles) >= self.uncles.MaxUncles {
 			break
 		}
+		if self.uncles.shallow(header.Number.Uint64(), uncle.Header()) {
+			continue
+		}
+		if self.uncles.banned(uncle.Header()) {
+			log.Trace("Blacklisted uncle found and will be removed", "hash", hash, "coinbase", uncle.Coinbase())
+			badUncles = append(badUncles, hash)
+			continue
+		}
 		if err := self.commitUncle(work, uncle.Header()); err != nil {
 			log.Trace("Bad uncle found and will be removed", "hash", hash)
 			log.Trace(fmt.Sprint(uncle))
@@ -483,14 +551,46 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to finalize block for sealing", "err", err)
 		return
 	}
-	// We only care about logging if we're actually mining.
-	if atomic.LoadInt32(&self.mining) == 1 {
-		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(gdaart)))
-		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
+	self.pendingFeed.Send(core.PendingBlockEvent{Block: work.Block})
+
+	// Nothing to seal (or log) if we're not actually mining
+	if atomic.LoadInt32(&self.mining) != 1 {
+		return
 	}
+	// Hold back sealing empty blocks for a while, waiting for transactions
+	next := self.recommit
+	if work.tcount == 0 && self.emptyWait > 0 {
+		if wait := time.Unix(parent.Time().Int64(), 0).Add(self.emptyWait).Sub(gdaart); wait > 0 {
+			log.Debug("Holding back empty block", "number", work.Block.Number(), "wait", common.PrettyDuration(wait))
+			atomic.StoreInt32(&self.suppressed, 1)
+			if next == 0 || wait < next {
+				next = wait
+			}
+			self.scheduleRecommit(next)
+			return
+		}
+	}
+	atomic.StoreInt32(&self.suppressed, 0)
+	self.scheduleRecommit(next)
+
+	log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(gdaart)))
+	self.unconfirmed.Shift(work.Block.NumberU64() - 1)
 	self.push(work)
 }
 
+// scheduleRecommit replaces any pending sealing work recreation with one firing
+// after the given delay. A zero delay disables recommits. The worker lock must
+// be held.
+func (self *worker) scheduleRecommit(delay time.Duration) {
+	if self.recommitTimer != nil {
+		self.recommitTimer.Stop()
+		self.recommitTimer = nil
+	}
+	if delay > 0 {
+		self.recommitTimer = time.AfterFunc(delay, self.recommitWork)
+	}
+}
+
 func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	hash := uncle.Hash()
 	if work.uncles.Has(hash) {
@@ -506,7 +606,7 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	return nil
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
+func (env *Work) commitTransactions(pendingLogsFeed, pendingStateFeed *event.Feed, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
 	gp := new(core.GasPool).AddGas(env.header.GasLimit)
 
 	var coalescedLogs []*types.Log
@@ -517,6 +617,11 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			log.Trace("Not enough gas for further transactions", "gp", gp)
 			break
 		}
+		// Stop adding transactions if the assembly deadline passed
+		if !env.deadline.IsZero() && time.Now().After(env.deadline) {
+			log.Debug("Block assembly deadline reached", "number", env.header.Number, "txs", env.tcount)
+			break
+		}
 		// Retrieve the next transaction and abort if all done
 		tx := txs.Peek()
 		if tx == nil {
@@ -578,14 +683,16 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			cpy[i] = new(types.Log)
 			*cpy[i] = *l
 		}
-		go func(logs []*types.Log, tcount int) {
+		// The events are sent from a new goroutine, so slow subscribers don't
+		// hold up the mining work.
+		go func(logs []*types.Log, tcount int, posted time.Time) {
 			if len(logs) > 0 {
-				mux.Post(core.PendingLogsEvent{Logs: logs})
+				pendingLogsFeed.Send(core.PendingLogsEvent{Logs: logs, Time: posted})
 			}
 			if tcount > 0 {
-				mux.Post(core.PendingStateEvent{})
+				pendingStateFeed.Send(core.PendingStateEvent{})
 			}
-		}(cpy, env.tcount)
+		}(cpy, env.tcount, time.Now())
 	}
 }
 
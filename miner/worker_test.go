@@ -0,0 +1,258 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+var (
+	testBankKey, _  = crypto.GenerateKey()
+	testBankAddress = crypto.PubkeyToAddress(testBankKey.PublicKey)
+	testBankFunds   = big.NewInt(1000000000000000000)
+)
+
+// testWorkerBackend is a miner backend with a freshly created chain and pool.
+type testWorkerBackend struct {
+	db     gdadb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+}
+
+func newTestWorkerBackend(t *testing.T) *testWorkerBackend {
+	db, _ := gdadb.NewMemDatabase()
+	gspec := core.Genesis{
+		Config:    params.TestChainConfig,
+		Timestamp: uint64(time.Now().Unix()),
+		Alloc:     core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
+	}
+	gspec.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+
+	return &testWorkerBackend{
+		db:     db,
+		chain:  chain,
+		txPool: core.NewTxPool(config, gspec.Config, chain),
+	}
+}
+
+func (b *testWorkerBackend) AccountManager() *accounts.Manager  { return nil }
+func (b *testWorkerBackend) BlockChain() *core.BlockChain       { return b.chain }
+func (b *testWorkerBackend) TxPool() *core.TxPool               { return b.txPool }
+func (b *testWorkerBackend) ChainDb() gdadb.Database            { return b.db }
+func (b *testWorkerBackend) Downloader() *downloader.Downloader { return nil }
+
+func (b *testWorkerBackend) close() {
+	b.txPool.Stop()
+	b.chain.Stop()
+}
+
+// addTransaction adds a new transfer from the test bank to the pool.
+func (b *testWorkerBackend) addTransaction(t *testing.T, nonce uint64) {
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, testBankKey)
+	if err := b.txPool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+}
+
+// testAgent is a mining agent collecting the sealing work pushed by the worker.
+type testAgent struct {
+	work chan *Work
+}
+
+func newTestAgent() *testAgent {
+	return &testAgent{work: make(chan *Work, 16)}
+}
+
+func (a *testAgent) Work() chan<- *Work         { return a.work }
+func (a *testAgent) SetReturnCh(chan<- *Result) {}
+func (a *testAgent) Stop()                      {}
+func (a *testAgent) Start()                     {}
+func (a *testAgent) GetHashRate() int64         { return 0 }
+
+// expectWork waits for the worker to push sealing work with the given number of
+// transactions to the agent.
+func (a *testAgent) expectWork(t *testing.T, txs int, timeout time.Duration) {
+	select {
+	case work := <-a.work:
+		if work.tcount != txs {
+			t.Fatalf("transaction count mismatch: have %d, want %d", work.tcount, txs)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("no sealing work pushed")
+	}
+}
+
+// expectNoWork ensures the worker does not push sealing work for a while.
+func (a *testAgent) expectNoWork(t *testing.T, wait time.Duration) {
+	select {
+	case work := <-a.work:
+		t.Fatalf("unexpected sealing work with %d transactions", work.tcount)
+	case <-time.After(wait):
+	}
+}
+
+// newTestWorker creates a mining worker configured by the given callback before
+// starting to mine.
+func newTestWorker(t *testing.T, configure func(w *worker)) (*worker, *testWorkerBackend, *testAgent) {
+	backend := newTestWorkerBackend(t)
+	w := newWorker(params.TestChainConfig, ethash.NewFaker(), common.Address{0xff}, backend)
+
+	agent := newTestAgent()
+	w.register(agent)
+	configure(w)
+
+	w.start()
+	w.commitNewWork()
+	return w, backend, agent
+}
+
+// Tests that empty blocks are held back while waiting for transactions, and that
+// the arrival of a transaction releases the block for sealing.
+func TestEmptyBlockWaitForTransactions(t *testing.T) {
+	w, backend, agent := newTestWorker(t, func(w *worker) { w.setEmptyWait(time.Hour) })
+	defer backend.close()
+	defer w.stop()
+
+	agent.expectNoWork(t, 100*time.Millisecond)
+	if atomic.LoadInt32(&w.suppressed) != 1 {
+		t.Fatalf("empty block not held back")
+	}
+	backend.addTransaction(t, 0)
+	agent.expectWork(t, 1, time.Second)
+
+	if atomic.LoadInt32(&w.suppressed) != 0 {
+		t.Fatalf("non-empty block still held back")
+	}
+}
+
+// Tests that held back empty blocks are sealed once the wait expires.
+func TestEmptyBlockWaitExpiry(t *testing.T) {
+	w, backend, agent := newTestWorker(t, func(w *worker) { w.setEmptyWait(2 * time.Second) })
+	defer backend.close()
+	defer w.stop()
+
+	// The genesis is at most a second old, so the block is held back at least a second
+	agent.expectNoWork(t, 500*time.Millisecond)
+	agent.expectWork(t, 0, 3*time.Second)
+}
+
+// Tests that no transactions are added to the block after the assembly deadline.
+func TestAssemblyDeadline(t *testing.T) {
+	w, backend, agent := newTestWorker(t, func(w *worker) { w.setDeadline(time.Nanosecond) })
+	defer backend.close()
+	defer w.stop()
+
+	agent.expectWork(t, 0, time.Second)
+
+	backend.addTransaction(t, 0)
+	w.commitNewWork()
+	agent.expectWork(t, 0, time.Second)
+
+	// Lifting the deadline includes the transaction again
+	w.setDeadline(0)
+	w.commitNewWork()
+	agent.expectWork(t, 1, time.Second)
+}
+
+// Tests that the sealing work is periodically recreated to pick up transactions,
+// and that recommits end with mining.
+func TestRecommitInterval(t *testing.T) {
+	w, backend, agent := newTestWorker(t, func(w *worker) { w.setRecommitInterval(100 * time.Millisecond) })
+	defer backend.close()
+
+	agent.expectWork(t, 0, time.Second)
+
+	// Transactions arriving while mining are only picked up by a recommit
+	backend.addTransaction(t, 0)
+	timeout := time.After(time.Second)
+	for included := false; !included; {
+		select {
+		case work := <-agent.work:
+			included = work.tcount == 1
+		case <-timeout:
+			t.Fatalf("transaction not picked up by recommit")
+		}
+	}
+	agent.expectWork(t, 1, time.Second)
+
+	w.stop()
+	agent.expectNoWork(t, 300*time.Millisecond)
+}
+
+// Tests that every freshly assembled pending block is announced, whether mining
+// or not.
+func TestPendingBlockSubscription(t *testing.T) {
+	backend := newTestWorkerBackend(t)
+	defer backend.close()
+
+	w := newWorker(params.TestChainConfig, ethash.NewFaker(), common.Address{0xff}, backend)
+
+	blocks := make(chan core.PendingBlockEvent, 16)
+	sub := w.pendingFeed.Subscribe(blocks)
+	defer sub.Unsubscribe()
+
+	expectBlock := func(txs int) {
+		select {
+		case ev := <-blocks:
+			if ev.Block.NumberU64() != 1 {
+				t.Fatalf("pending block number mismatch: have %d, want %d", ev.Block.NumberU64(), 1)
+			}
+			if have := len(ev.Block.Transactions()); have != txs {
+				t.Fatalf("pending transaction count mismatch: have %d, want %d", have, txs)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("pending block not announced")
+		}
+	}
+	w.commitNewWork()
+	expectBlock(0)
+
+	backend.addTransaction(t, 0)
+	w.commitNewWork()
+	expectBlock(1)
+
+	// Mining with held back empty blocks must still announce the pending block
+	w.setEmptyWait(time.Hour)
+	w.register(newTestAgent())
+	w.start()
+	defer w.stop()
+
+	backend.addTransaction(t, 1)
+	w.commitNewWork()
+	expectBlock(2)
+}
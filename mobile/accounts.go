@@ -21,12 +21,16 @@ package ggda
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/accounts/keystore"
 	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/rlp"
 )
 
 const (
@@ -147,6 +151,100 @@ func (ks *KeyStore) SignTxPassphrase(account *Account, passphrase string, tx *Tr
 	return &Transaction{signed}, nil
 }
 
+// FindAccount retrieves the stored account with the given hex encoded address.
+func (ks *KeyStore) FindAccount(address string) (account *Account, _ error) {
+	if !common.IsHexAddress(address) {
+		return nil, errors.New("invalid address")
+	}
+	acc, err := ks.keystore.Find(accounts.Account{Address: common.HexToAddress(address)})
+	if err != nil {
+		return nil, err
+	}
+	return &Account{acc}, nil
+}
+
+// SignMessage signs a message with the requested unlocked account, prefixing it
+// the same way as the personal_sign RPC method. The produced signature is in the
+// [R || S || V] format where V is 27 or 28.
+func (ks *KeyStore) SignMessage(account *Account, message []byte) (signature []byte, _ error) {
+	sig, err := ks.keystore.SignHash(account.account, signHash(message))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignMessagePassphrase signs a message like SignMessage if the private key of
+// the account can be decrypted with the given passphrase.
+func (ks *KeyStore) SignMessagePassphrase(account *Account, passphrase string, message []byte) (signature []byte, _ error) {
+	sig, err := ks.keystore.SignHashWithPassphrase(account.account, passphrase, signHash(message))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignRawTx signs an RLP encoded transaction with the requested unlocked account
+// for the given chain (0 = unprotected) and returns the RLP encoding of the signed
+// transaction, ready to be submitted.
+func (ks *KeyStore) SignRawTx(account *Account, rawTx []byte, chainID int64) (signedTx []byte, _ error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	signed, err := ks.keystore.SignTx(account.account, tx, big.NewInt(chainID))
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// SignRawTxPassphrase signs an RLP encoded transaction like SignRawTx if the
+// private key of the account can be decrypted with the given passphrase.
+func (ks *KeyStore) SignRawTxPassphrase(account *Account, passphrase string, rawTx []byte, chainID int64) (signedTx []byte, _ error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(rawTx, tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	signed, err := ks.keystore.SignTxWithPassphrase(account.account, passphrase, tx, big.NewInt(chainID))
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// RecoverMessageSigner returns the address of the account that signed a message
+// with SignMessage or the personal_sign RPC method.
+func RecoverMessageSigner(message []byte, signature []byte) (address *Address, _ error) {
+	if len(signature) != 65 {
+		return nil, errors.New("signature must be 65 bytes long")
+	}
+	if signature[64] != 27 && signature[64] != 28 {
+		return nil, errors.New("invalid signature recovery id (V is not 27 or 28)")
+	}
+	sig := common.CopyBytes(signature)
+	sig[64] -= 27
+
+	pubkey, err := crypto.SigToPub(signHash(message), sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Address{crypto.PubkeyToAddress(*pubkey)}, nil
+}
+
+// signHash calculates the hash signed for a message, which is
+//
+//	keccak256("\x19gdachain Signed Message:\n"${message length}${message}).
+//
+// The prefix gives context to the signed message and prevents signing of
+// transactions.
+func signHash(data []byte) []byte {
+	msg := fmt.Sprintf("\x19gdachain Signed Message:\n%d%s", len(data), data)
+	return crypto.Keccak256([]byte(msg))
+}
+
 // Unlock unlocks the given account indefinitely.
 func (ks *KeyStore) Unlock(account *Account, passphrase string) error {
 	return ks.keystore.TimedUnlock(account.account, passphrase, 0)
@@ -0,0 +1,84 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ggda
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+// Tests that messages and raw transactions can be signed through the mobile
+// keystore wrappers and their signers recovered.
+func TestKeyStoreSigning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ggda-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := NewKeyStore(dir, LightScryptN, LightScryptP)
+	account, err := ks.NewAccount("password")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	found, err := ks.FindAccount(account.GetAddress().GetHex())
+	if err != nil || found.GetAddress().GetHex() != account.GetAddress().GetHex() {
+		t.Fatalf("failed to find account: %v", err)
+	}
+	// Sign a message both with a passphrase and an unlocked account
+	message := []byte("hello mobile")
+	if _, err := ks.SignMessage(account, message); err == nil {
+		t.Errorf("signed message with locked account")
+	}
+	sig, err := ks.SignMessagePassphrase(account, "password", message)
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	signer, err := RecoverMessageSigner(message, sig)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer.GetHex() != account.GetAddress().GetHex() {
+		t.Errorf("signer mismatch: have %s, want %s", signer.GetHex(), account.GetAddress().GetHex())
+	}
+	if err := ks.Unlock(account, "password"); err != nil {
+		t.Fatalf("failed to unlock account: %v", err)
+	}
+	// Sign a raw transaction for a specific chain
+	raw, _ := rlp.EncodeToBytes(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil))
+	signedRaw, err := ks.SignRawTx(account, raw, 5)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(signedRaw, signed); err != nil {
+		t.Fatalf("failed to decode signed transaction: %v", err)
+	}
+	from, err := types.Sender(types.NewEIP155Signer(big.NewInt(5)), signed)
+	if err != nil {
+		t.Fatalf("failed to recover sender: %v", err)
+	}
+	if from.Hex() != account.GetAddress().GetHex() {
+		t.Errorf("sender mismatch: have %s, want %s", from.Hex(), account.GetAddress().GetHex())
+	}
+}
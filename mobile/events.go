@@ -0,0 +1,200 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains callbacks delivering events of the embedded light client directly
+// to the mobile platform, without going through the RPC layer.
+
+package ggda
+
+import (
+	gdaereum "github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
+	"github.com/gdachain/go-gdachain/les"
+)
+
+// SyncProgressHandler is a callback to invoke on chain synchronisation events
+// and subscription failure.
+type SyncProgressHandler interface {
+	OnSyncProgress(progress *SyncProgress)
+	OnSyncDone()
+	OnSyncFailed(failure string)
+	OnError(failure string)
+}
+
+// lightService retrieves the light client running inside the node.
+func (n *Node) lightService() (*les.Lightgdachain, error) {
+	var lesServ *les.Lightgdachain
+	if err := n.node.Service(&lesServ); err != nil {
+		return nil, err
+	}
+	return lesServ, nil
+}
+
+// chainHeadSubscriber is a chain able to notify about new chain heads.
+type chainHeadSubscriber interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// syncProgressReporter is a chain downloader able to notify about sync cycles
+// and report its progress.
+type syncProgressReporter interface {
+	SubscribeSyncEvent(ch chan<- downloader.SyncEvent) event.Subscription
+	Progress() gdaereum.SyncProgress
+}
+
+// SubscribeNewHead subscribes to notifications about the current blockchain
+// head of the embedded light client.
+func (n *Node) SubscribeNewHead(handler NewHeadHandler, buffer int) (sub *Subscription, _ error) {
+	lesServ, err := n.lightService()
+	if err != nil {
+		return nil, err
+	}
+	return subscribeNewHead(lesServ.BlockChain(), handler, buffer), nil
+}
+
+// subscribeNewHead feeds the head events of a chain into a callback.
+func subscribeNewHead(chain chainHeadSubscriber, handler NewHeadHandler, buffer int) *Subscription {
+	// Subscribe to the event internally
+	ch := make(chan core.ChainHeadEvent, buffer)
+	rawSub := chain.SubscribeChainHeadEvent(ch)
+
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case ev := <-ch:
+				handler.OnNewHead(&Header{ev.Block.Header()})
+
+			case err := <-rawSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query
+// evaluated by the embedded light client.
+func (n *Node) SubscribeFilterLogs(query *FilterQuery, handler FilterLogsHandler, buffer int) (sub *Subscription, _ error) {
+	lesServ, err := n.lightService()
+	if err != nil {
+		return nil, err
+	}
+	n.eventsOnce.Do(func() {
+		n.events = filters.NewEventSystem(lesServ.ApiBackend, true)
+	})
+	// Subscribe to the event internally
+	ch := make(chan []*types.Log, buffer)
+	rawSub, err := n.events.SubscribeLogs(query.query, ch)
+	if err != nil {
+		return nil, err
+	}
+	dispatchFilterLogs(ch, rawSub, handler)
+	return &Subscription{rawSub}, nil
+}
+
+// dispatchFilterLogs starts up a dispatcher feeding the log batches of a filter
+// subscription one by one into a callback.
+func dispatchFilterLogs(ch <-chan []*types.Log, sub event.Subscription, handler FilterLogsHandler) {
+	go func() {
+		for {
+			select {
+			case logs := <-ch:
+				for _, log := range logs {
+					handler.OnFilterLogs(&Log{log})
+				}
+
+			case err := <-sub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+}
+
+// SubscribeSyncProgress subscribes to the chain synchronisation of the embedded
+// light client. The progress is reported when a sync cycle starts and on every
+// new head imported while it runs.
+func (n *Node) SubscribeSyncProgress(handler SyncProgressHandler) (sub *Subscription, _ error) {
+	lesServ, err := n.lightService()
+	if err != nil {
+		return nil, err
+	}
+	return subscribeSyncProgress(lesServ.Downloader(), lesServ.BlockChain(), handler), nil
+}
+
+// subscribeSyncProgress feeds the sync cycles of a downloader into a callback,
+// reporting the progress on every new head of the chain while syncing.
+func subscribeSyncProgress(dl syncProgressReporter, chain chainHeadSubscriber, handler SyncProgressHandler) *Subscription {
+	var (
+		syncCh  = make(chan downloader.SyncEvent, 16)
+		headCh  = make(chan core.ChainHeadEvent, 16)
+		syncSub = dl.SubscribeSyncEvent(syncCh)
+		headSub = chain.SubscribeChainHeadEvent(headCh)
+	)
+	rawSub := event.NewSubscription(func(quit <-chan struct{}) error {
+		defer syncSub.Unsubscribe()
+		defer headSub.Unsubscribe()
+
+		syncing := false
+		for {
+			select {
+			case ev := <-syncCh:
+				switch ev.Status {
+				case downloader.SyncStarted:
+					syncing = true
+					handler.OnSyncProgress(&SyncProgress{dl.Progress()})
+				case downloader.SyncDone:
+					syncing = false
+					handler.OnSyncDone()
+				case downloader.SyncFailed:
+					syncing = false
+					handler.OnSyncFailed(ev.Err.Error())
+				}
+
+			case <-headCh:
+				if syncing {
+					handler.OnSyncProgress(&SyncProgress{dl.Progress()})
+				}
+
+			case err := <-syncSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return err
+
+			case err := <-headSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return err
+
+			case <-quit:
+				return nil
+			}
+		}
+	})
+	return &Subscription{rawSub}
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ggda
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	gdaereum "github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+)
+
+// testEventSource is a chain and downloader feeding events from the tests.
+type testEventSource struct {
+	headFeed event.Feed
+	syncFeed event.Feed
+	progress gdaereum.SyncProgress
+}
+
+func (s *testEventSource) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return s.headFeed.Subscribe(ch)
+}
+
+func (s *testEventSource) SubscribeSyncEvent(ch chan<- downloader.SyncEvent) event.Subscription {
+	return s.syncFeed.Subscribe(ch)
+}
+
+func (s *testEventSource) Progress() gdaereum.SyncProgress { return s.progress }
+
+// testEventHandler records the events delivered to the mobile callbacks.
+type testEventHandler struct {
+	heads    chan int64
+	logs     chan int64
+	progress chan int64
+	done     chan struct{}
+	failures chan string
+	errors   chan string
+}
+
+func newTestEventHandler() *testEventHandler {
+	return &testEventHandler{
+		heads:    make(chan int64, 16),
+		logs:     make(chan int64, 16),
+		progress: make(chan int64, 16),
+		done:     make(chan struct{}, 16),
+		failures: make(chan string, 16),
+		errors:   make(chan string, 16),
+	}
+}
+
+func (h *testEventHandler) OnNewHead(header *Header)          { h.heads <- header.GetNumber() }
+func (h *testEventHandler) OnFilterLogs(log *Log)             { h.logs <- log.GetBlockNumber() }
+func (h *testEventHandler) OnSyncProgress(prog *SyncProgress) { h.progress <- prog.GetCurrentBlock() }
+func (h *testEventHandler) OnSyncDone()                       { h.done <- struct{}{} }
+func (h *testEventHandler) OnSyncFailed(failure string)       { h.failures <- failure }
+func (h *testEventHandler) OnError(failure string)            { h.errors <- failure }
+
+// expectEvent waits for a single event on a callback channel.
+func expectEvent(t *testing.T, kind string, ch <-chan int64, want int64) {
+	select {
+	case have := <-ch:
+		if have != want {
+			t.Fatalf("%s mismatch: have %d, want %d", kind, have, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%s not delivered", kind)
+	}
+}
+
+// expectNoEvent ensures no event arrives on a callback channel for a while.
+func expectNoEvent(t *testing.T, kind string, ch <-chan int64) {
+	select {
+	case have := <-ch:
+		t.Fatalf("unexpected %s: %d", kind, have)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestHead(number int64) core.ChainHeadEvent {
+	return core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number)})}
+}
+
+// Tests that chain head events are delivered to the callback until unsubscribed.
+func TestNewHeadCallback(t *testing.T) {
+	source, handler := new(testEventSource), newTestEventHandler()
+
+	sub := subscribeNewHead(source, handler, 16)
+	for i := int64(1); i <= 3; i++ {
+		source.headFeed.Send(newTestHead(i))
+		expectEvent(t, "head", handler.heads, i)
+	}
+	sub.Unsubscribe()
+
+	if n := source.headFeed.Send(newTestHead(4)); n != 0 {
+		t.Fatalf("head delivered to %d subscribers after unsubscribe", n)
+	}
+	expectNoEvent(t, "head", handler.heads)
+}
+
+// Tests that log batches are delivered to the callback one by one, and that a
+// subscription failure is reported.
+func TestFilterLogsCallback(t *testing.T) {
+	var (
+		feed    event.Feed
+		handler = newTestEventHandler()
+		ch      = make(chan []*types.Log, 16)
+	)
+	sub := feed.Subscribe(ch)
+	dispatchFilterLogs(ch, sub, handler)
+
+	feed.Send([]*types.Log{{BlockNumber: 1}, {BlockNumber: 2}})
+	expectEvent(t, "log", handler.logs, 1)
+	expectEvent(t, "log", handler.logs, 2)
+
+	sub.Unsubscribe()
+	expectNoEvent(t, "log", handler.logs)
+
+	// Failing subscriptions must report the error
+	failing := event.NewSubscription(func(quit <-chan struct{}) error {
+		return errors.New("filter failure")
+	})
+	dispatchFilterLogs(make(chan []*types.Log), failing, handler)
+
+	select {
+	case failure := <-handler.errors:
+		if failure != "filter failure" {
+			t.Fatalf("failure mismatch: have %q, want %q", failure, "filter failure")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscription failure not delivered")
+	}
+}
+
+// Tests that sync cycles are reported, with progress updates on every new head
+// imported while syncing only.
+func TestSyncProgressCallback(t *testing.T) {
+	source, handler := new(testEventSource), newTestEventHandler()
+
+	sub := subscribeSyncProgress(source, source, handler)
+	defer sub.Unsubscribe()
+
+	// Heads outside of sync cycles must not report progress
+	source.headFeed.Send(newTestHead(1))
+	expectNoEvent(t, "progress", handler.progress)
+
+	// Progress must be reported on sync start and each new head while syncing
+	source.progress.CurrentBlock = 1
+	source.syncFeed.Send(downloader.SyncEvent{Status: downloader.SyncStarted})
+	expectEvent(t, "progress", handler.progress, 1)
+
+	source.progress.CurrentBlock = 2
+	source.headFeed.Send(newTestHead(2))
+	expectEvent(t, "progress", handler.progress, 2)
+
+	source.syncFeed.Send(downloader.SyncEvent{Status: downloader.SyncDone})
+	select {
+	case <-handler.done:
+	case <-time.After(time.Second):
+		t.Fatalf("sync completion not delivered")
+	}
+	source.headFeed.Send(newTestHead(3))
+	expectNoEvent(t, "progress", handler.progress)
+
+	// Failed cycles must be reported with their reason
+	source.syncFeed.Send(downloader.SyncEvent{Status: downloader.SyncStarted})
+	expectEvent(t, "progress", handler.progress, 2)
+
+	source.syncFeed.Send(downloader.SyncEvent{Status: downloader.SyncFailed, Err: errors.New("peer dropped")})
+	select {
+	case failure := <-handler.failures:
+		if failure != "peer dropped" {
+			t.Fatalf("sync failure mismatch: have %q, want %q", failure, "peer dropped")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sync failure not delivered")
+	}
+}
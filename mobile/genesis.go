@@ -0,0 +1,116 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains helpers to programmatically assemble a custom genesis spec.
+
+package ggda
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// GenesisSpec is a builder for custom genesis blocks, allowing mobile apps to
+// assemble private network definitions without hand writing the JSON spec.
+type GenesisSpec struct {
+	genesis *core.Genesis
+}
+
+// NewGenesisSpec creates a proof-of-work genesis spec with all the protocol
+// changes enabled from the first block, on the given chain id.
+func NewGenesisSpec(chainID int64) *GenesisSpec {
+	config := *params.AllgdaashProtocolChanges
+	config.ChainId = big.NewInt(chainID)
+
+	return &GenesisSpec{&core.Genesis{
+		Config:     &config,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: new(big.Int).Set(params.GenesisDifficulty),
+		Alloc:      make(core.GenesisAlloc),
+	}}
+}
+
+// NewCliqueGenesisSpec creates a proof-of-authority genesis spec with all the
+// protocol changes enabled from the first block, on the given chain id and
+// block period (in seconds).
+func NewCliqueGenesisSpec(chainID int64, period int64) *GenesisSpec {
+	config := *params.AllCliqueProtocolChanges
+	config.ChainId = big.NewInt(chainID)
+	config.Clique = &params.CliqueConfig{
+		Period: uint64(period),
+		Epoch:  params.AllCliqueProtocolChanges.Clique.Epoch,
+	}
+	spec := &GenesisSpec{&core.Genesis{
+		Config:     &config,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      make(core.GenesisAlloc),
+	}}
+	spec.genesis.SetCliqueSigners(nil)
+	return spec
+}
+
+// SetGasLimit sets the gas limit of the genesis block.
+func (g *GenesisSpec) SetGasLimit(limit int64) {
+	g.genesis.GasLimit = uint64(limit)
+}
+
+// SetTimestamp sets the timestamp (in seconds) of the genesis block.
+func (g *GenesisSpec) SetTimestamp(timestamp int64) {
+	g.genesis.Timestamp = uint64(timestamp)
+}
+
+// SetBalance pre-funds an account with the given balance.
+func (g *GenesisSpec) SetBalance(address *Address, balance *BigInt) {
+	g.genesis.Alloc.SetBalance(address.address, balance.bigint)
+}
+
+// SetCode deploys the given contract code to an account.
+func (g *GenesisSpec) SetCode(address *Address, code []byte) {
+	g.genesis.Alloc.SetCode(address.address, code)
+}
+
+// SetStorage sets a storage slot of an account.
+func (g *GenesisSpec) SetStorage(address *Address, key *Hash, value *Hash) {
+	g.genesis.Alloc.SetState(address.address, key.hash, value.hash)
+}
+
+// SetCliqueSigners sets the initial authorized signers of a clique network.
+func (g *GenesisSpec) SetCliqueSigners(signers *Addresses) error {
+	if g.genesis.Config.Clique == nil {
+		return errors.New("genesis is not proof-of-authority")
+	}
+	if signers == nil || len(signers.addresses) == 0 {
+		return errors.New("no signers specified")
+	}
+	g.genesis.SetCliqueSigners(append([]common.Address{}, signers.addresses...))
+	return nil
+}
+
+// EncodeJSON serializes the genesis spec into the JSON format accepted by the
+// node configuration.
+func (g *GenesisSpec) EncodeJSON() (string, error) {
+	enc, err := json.Marshal(g.genesis)
+	if err != nil {
+		return "", err
+	}
+	return string(enc), nil
+}
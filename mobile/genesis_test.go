@@ -0,0 +1,101 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ggda
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// Tests that programmatically assembled clique genesis specs encode into a valid
+// genesis with the requested signers and allocations.
+func TestCliqueGenesisSpec(t *testing.T) {
+	spec := NewCliqueGenesisSpec(1337, 5)
+
+	if err := spec.SetCliqueSigners(nil); err == nil {
+		t.Fatalf("empty signer set accepted")
+	}
+	signers := NewAddressesEmpty()
+	for _, addr := range []common.Address{{0x02}, {0x01}} {
+		signers.Append(&Address{addr})
+	}
+	if err := spec.SetCliqueSigners(signers); err != nil {
+		t.Fatalf("failed to set signers: %v", err)
+	}
+	var (
+		funded   = &Address{common.Address{0xaa}}
+		contract = &Address{common.Address{0xbb}}
+		key      = &Hash{common.Hash{0x01}}
+		value    = &Hash{common.Hash{0x02}}
+	)
+	spec.SetGasLimit(8000000)
+	spec.SetBalance(funded, NewBigInt(1000))
+	spec.SetCode(contract, []byte{0x60, 0x00})
+	spec.SetStorage(contract, key, value)
+
+	enc, err := spec.EncodeJSON()
+	if err != nil {
+		t.Fatalf("failed to encode genesis: %v", err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal([]byte(enc), genesis); err != nil {
+		t.Fatalf("failed to decode genesis: %v", err)
+	}
+	if genesis.Config.ChainId.Int64() != 1337 || genesis.Config.Clique == nil || genesis.Config.Clique.Period != 5 {
+		t.Fatalf("chain config mismatch: %v", genesis.Config)
+	}
+	if genesis.GasLimit != 8000000 {
+		t.Errorf("gas limit mismatch: have %d, want %d", genesis.GasLimit, 8000000)
+	}
+	// Signers must be sorted in between the vanity and the seal
+	want := append(append(make([]byte, 32), common.Address{0x01}.Bytes()...), common.Address{0x02}.Bytes()...)
+	want = append(want, make([]byte, 65)...)
+	if !bytes.Equal(genesis.ExtraData, want) {
+		t.Errorf("extra data mismatch: have %x, want %x", genesis.ExtraData, want)
+	}
+	db, _ := gdadb.NewMemDatabase()
+	block, err := genesis.Commit(db)
+	if err != nil {
+		t.Fatalf("failed to commit genesis: %v", err)
+	}
+	statedb, _ := state.New(block.Root(), state.NewDatabase(db))
+	if balance := statedb.GetBalance(funded.address); balance.Int64() != 1000 {
+		t.Errorf("balance mismatch: have %v, want 1000", balance)
+	}
+	if code := statedb.GetCode(contract.address); !bytes.Equal(code, []byte{0x60, 0x00}) {
+		t.Errorf("code mismatch: have %x, want 6000", code)
+	}
+	if slot := statedb.Gegdaate(contract.address, key.hash); slot != value.hash {
+		t.Errorf("storage mismatch: have %x, want %x", slot, value.hash)
+	}
+}
+
+// Tests that clique signers can't be set on proof-of-work genesis specs.
+func TestGenesisSpecSignersRequireClique(t *testing.T) {
+	signers := NewAddressesEmpty()
+	signers.Append(&Address{common.Address{0x01}})
+
+	if err := NewGenesisSpec(1337).SetCliqueSigners(signers); err == nil {
+		t.Fatalf("clique signers accepted on proof-of-work genesis")
+	}
+}
@@ -23,10 +23,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sync"
 
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/gda"
 	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gdaclient"
 	"github.com/gdachain/go-gdachain/gdastats"
 	"github.com/gdachain/go-gdachain/les"
@@ -72,6 +74,11 @@ type NodeConfig struct {
 
 	// WhisperEnabled specifies whgdaer the node should run the Whisper protocol.
 	WhisperEnabled bool
+
+	// MaxDailyTrafficMB is the network traffic in MB the node may use within 24
+	// hours. Once reached, peers are dropped and light client requests fail until
+	// the period ends. Zero means no limit.
+	MaxDailyTrafficMB int
 }
 
 // defaultNodeConfig contains the default node configuration values to use if all
@@ -90,9 +97,23 @@ func NewNodeConfig() *NodeConfig {
 	return &config
 }
 
+// SetGenesisSpec configures the node to seed its blockchain with a custom genesis
+// assembled programmatically, instead of a hand-written JSON spec.
+func (conf *NodeConfig) SetGenesisSpec(spec *GenesisSpec) error {
+	genesis, err := spec.EncodeJSON()
+	if err != nil {
+		return err
+	}
+	conf.gdachainGenesis = genesis
+	return nil
+}
+
 // Node represents a Ggda gdachain node instance.
 type Node struct {
 	node *node.Node
+
+	eventsOnce sync.Once
+	events     *filters.EventSystem // Log filter system, created on first use
 }
 
 // NewNode creates and configures a new Ggda node.
@@ -120,6 +141,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 			ListenAddr:       ":0",
 			NAT:              nat.Any(),
 			MaxPeers:         config.MaxPeers,
+			MaxDailyTraffic:  uint64(config.MaxDailyTrafficMB) * 1024 * 1024,
 		},
 	}
 	rawStack, err := node.New(nodeConf)
@@ -160,7 +182,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 				var lesServ *les.Lightgdachain
 				ctx.Service(&lesServ)
 
-				return gdastats.New(config.gdachainNegdaats, nil, lesServ)
+				return gdastats.New(gdastats.Config{URL: config.gdachainNegdaats}, nil, lesServ)
 			}); err != nil {
 				return nil, fmt.Errorf("negdaats init: %v", err)
 			}
@@ -174,7 +196,7 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 			return nil, fmt.Errorf("whisper init: %v", err)
 		}
 	}
-	return &Node{rawStack}, nil
+	return &Node{node: rawStack}, nil
 }
 
 // Start creates a live P2P node and starts running it.
@@ -206,3 +228,9 @@ func (n *Node) GetNodeInfo() *NodeInfo {
 func (n *Node) GetPeersInfo() *PeerInfos {
 	return &PeerInfos{n.node.Server().PeersInfo()}
 }
+
+// GetTrafficStats returns the network traffic counters of the current 24 hour
+// accounting period.
+func (n *Node) GetTrafficStats() *TrafficStats {
+	return &TrafficStats{n.node.Server().TrafficStats()}
+}
@@ -44,6 +44,18 @@ func (ni *NodeInfo) GetProtocols() *Strings {
 	return &Strings{protos}
 }
 
+// TrafficStats represents the network traffic used by the node within the
+// current accounting period.
+type TrafficStats struct {
+	stats p2p.TrafficStats
+}
+
+func (ts *TrafficStats) GetIngress() int64 { return int64(ts.stats.Ingress) }
+func (ts *TrafficStats) GetEgress() int64  { return int64(ts.stats.Egress) }
+func (ts *TrafficStats) GetLimit() int64   { return int64(ts.stats.Limit) }
+func (ts *TrafficStats) GetSince() int64   { return ts.stats.Since.Unix() }
+func (ts *TrafficStats) IsExhausted() bool { return ts.stats.Exhausted }
+
 // PeerInfo represents pi short summary of the information known about pi connected peer.
 type PeerInfo struct {
 	info *p2p.PeerInfo
@@ -75,6 +75,90 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// PeerProbe is the result of probing the protocol capabilities of a remote node.
+type PeerProbe struct {
+	Peer  *p2p.PeerInfo `json:"peer,omitempty"`  // Peer metadata after all protocol handshakes completed
+	Error string        `json:"error,omitempty"` // Reason the connection was dropped during the handshakes
+}
+
+// ProbePeer connects to a remote node, waits for all protocol handshakes to
+// complete and reports the negotiated protocols along with their metadata (e.g.
+// head, total difficulty and genesis), after which it disconnects. If the node
+// is already connected, its current metadata is reported without disconnecting.
+// The optional timeout is in seconds, defaulting to 10.
+func (api *PrivateAdminAPI) ProbePeer(ctx context.Context, url string, timeout *uint64) (*PeerProbe, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enode: %v", err)
+	}
+	info, connected := probedPeerInfo(server, node.ID)
+	if info != nil {
+		return &PeerProbe{Peer: info}, nil
+	}
+	wait := 10 * time.Second
+	if timeout != nil {
+		wait = time.Duration(*timeout) * time.Second
+	}
+	// Subscribe to peer events before dialing to not miss a fast drop
+	events := make(chan *p2p.PeerEvent, 16)
+	sub := server.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	// Only dial and drop the node if it's not an existing, still handshaking peer
+	if !connected {
+		server.AddPeer(node)
+		defer server.RemovePeer(node)
+	}
+
+	expire := time.NewTimer(wait)
+	defer expire.Stop()
+
+	poll := time.NewTicker(100 * time.Millisecond)
+	defer poll.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if event.Peer == node.ID && event.Type == p2p.PeerEventTypeDrop {
+				return &PeerProbe{Error: event.Error}, nil
+			}
+		case <-poll.C:
+			if info, _ := probedPeerInfo(server, node.ID); info != nil {
+				return &PeerProbe{Peer: info}, nil
+			}
+		case <-expire.C:
+			return nil, fmt.Errorf("probe timed out after %v", wait)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-sub.Err():
+			return nil, err
+		}
+	}
+}
+
+// probedPeerInfo returns the metadata of a connected peer if all its protocol
+// handshakes already completed, or nil otherwise, along with whether the peer is
+// connected at all.
+func probedPeerInfo(server *p2p.Server, id discover.NodeID) (*p2p.PeerInfo, bool) {
+	for _, info := range server.PeersInfo() {
+		if info.ID != id.String() {
+			continue
+		}
+		for _, proto := range info.Protocols {
+			if proto == "handshake" {
+				return nil, true
+			}
+		}
+		return info, true
+	}
+	return nil, false
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
@@ -144,7 +228,7 @@ func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 	allowedVHosts := api.node.config.HTTPVirtualHosts
 	if vhosts != nil {
 		allowedVHosts = nil
-		for _, vhost := range strings.Split(*host, ",") {
+		for _, vhost := range strings.Split(*vhosts, ",") {
 			allowedVHosts = append(allowedVHosts, strings.TrimSpace(vhost))
 		}
 	}
@@ -229,6 +313,149 @@ func (api *PrivateAdminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// SetRPCCors changes the cross-origin domains allowed to access the running HTTP
+// RPC endpoint. The endpoint is reopened on the same address.
+func (api *PrivateAdminAPI) SetRPCCors(cors string) (bool, error) {
+	api.node.lock.Lock()
+	defer api.node.lock.Unlock()
+
+	if api.node.httpHandler == nil {
+		return false, fmt.Errorf("HTTP RPC not running")
+	}
+	if err := api.node.restartHTTP(splitList(cors), api.node.httpVHosts); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetRPCVirtualHosts changes the virtual hostnames accepted by the running HTTP
+// RPC endpoint. The endpoint is reopened on the same address.
+func (api *PrivateAdminAPI) SetRPCVirtualHosts(vhosts string) (bool, error) {
+	api.node.lock.Lock()
+	defer api.node.lock.Unlock()
+
+	if api.node.httpHandler == nil {
+		return false, fmt.Errorf("HTTP RPC not running")
+	}
+	if err := api.node.restartHTTP(api.node.httpCors, splitList(vhosts)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetWSOrigins changes the origins allowed to open connections to the running
+// websocket RPC endpoint. The endpoint is reopened on the same address, dropping
+// the open connections along with their subscriptions.
+func (api *PrivateAdminAPI) SetWSOrigins(origins string) (bool, error) {
+	api.node.lock.Lock()
+	defer api.node.lock.Unlock()
+
+	if api.node.wsHandler == nil {
+		return false, fmt.Errorf("WebSocket RPC not running")
+	}
+	if err := api.node.restartWS(splitList(origins)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RPCEndpoint describes a running network RPC endpoint.
+type RPCEndpoint struct {
+	Endpoint    string         `json:"endpoint"`    // Address the endpoint listens on
+	Origins     []string       `json:"origins"`     // Cross-origin domains allowed access
+	VHosts      []string       `json:"vhosts"`      // Virtual hostnames accepted (HTTP only)
+	Modules     []string       `json:"modules"`     // API modules exposed (empty = public ones)
+	Connections []rpc.ConnInfo `json:"connections"` // Connections and requests currently served
+}
+
+// RpcConnections returns the running HTTP and websocket RPC endpoints, keyed by
+// transport, along with the connections they currently serve.
+func (api *PrivateAdminAPI) RpcConnections() map[string]*RPCEndpoint {
+	api.node.lock.RLock()
+	defer api.node.lock.RUnlock()
+
+	endpoints := make(map[string]*RPCEndpoint)
+	if n := api.node; n.httpHandler != nil {
+		endpoints["http"] = &RPCEndpoint{
+			Endpoint:    n.httpListener.Addr().String(),
+			Origins:     n.httpCors,
+			VHosts:      n.httpVHosts,
+			Modules:     n.httpWhitelist,
+			Connections: n.httpHandler.Connections(),
+		}
+	}
+	if n := api.node; n.wsHandler != nil {
+		endpoints["ws"] = &RPCEndpoint{
+			Endpoint:    n.wsListener.Addr().String(),
+			Origins:     n.wsOrigins,
+			Modules:     n.wsWhitelist,
+			Connections: n.wsHandler.Connections(),
+		}
+	}
+	return endpoints
+}
+
+// splitList splits a comma separated list, trimming the whitespace around the
+// elements and dropping the empty ones.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Drain stops the HTTP and websocket RPC endpoints from accepting new connections
+// and subscriptions, shutting them down after the grace period (in seconds, 30 if
+// omitted) to allow in-flight requests to finish. They may be started again once
+// the drain is over.
+func (api *PrivateAdminAPI) Drain(grace *uint64) (bool, error) {
+	period := 30 * time.Second
+	if grace != nil {
+		period = time.Duration(*grace) * time.Second
+	}
+	if err := api.node.Drain(period); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NatStatus retrieves the state of the port mappings and the external IP address
+// of the NAT gateway, or nil if no NAT traversal mechanism is configured.
+func (api *PrivateAdminAPI) NatStatus() (*p2p.NATStatus, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.NATStatus(), nil
+}
+
+// SetPeerAllowlist puts the node into permissioned mode, admitting only peers
+// matching one of the given enode URLs, node IDs or CIDR masks. Connected peers
+// not matching the new allowlist are dropped. An empty list admits all peers.
+func (api *PrivateAdminAPI) SetPeerAllowlist(entries []string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.SetAllowlist(entries); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PeerAllowlist retrieves the entries of the peer allowlist, or nil if the node
+// is not running in permissioned mode.
+func (api *PrivateAdminAPI) PeerAllowlist() ([]string, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Allowlist(), nil
+}
+
 // PublicAdminAPI is the collection of administrative API methods exposed over
 // both secure and unsecure RPC channels.
 type PublicAdminAPI struct {
@@ -381,6 +608,21 @@ func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
 	return counters, nil
 }
 
+// PublicNodeAPI offers the health of the node.
+type PublicNodeAPI struct {
+	node *Node
+}
+
+// NewPublicNodeAPI creates a new API exposing the health of the node.
+func NewPublicNodeAPI(node *Node) *PublicNodeAPI {
+	return &PublicNodeAPI{node: node}
+}
+
+// Health returns the health of the node against the configured criteria.
+func (api *PublicNodeAPI) Health() HealthReport {
+	return api.node.Health()
+}
+
 // PublicWeb3API offers helper utils
 type PublicWeb3API struct {
 	stack *Node
@@ -0,0 +1,198 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/p2p/discover"
+)
+
+// probeTestInfo is the protocol metadata reported for probed test peers.
+type probeTestInfo struct {
+	Head uint64 `json:"head"`
+}
+
+// newProbeTestNode starts a node listening on a local port and running a test
+// protocol, whose handshake takes a while to complete.
+func newProbeTestNode(t *testing.T, head uint64) *Node {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate node key: %v", err)
+	}
+	stack, err := New(&Config{
+		Name: "probe test node",
+		P2P: p2p.Config{
+			PrivateKey:  key,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			MaxPeers:    10,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	var (
+		lock  sync.Mutex
+		heads = make(map[discover.NodeID]uint64)
+	)
+	protocol := p2p.Protocol{
+		Name:    "probe",
+		Version: 1,
+		Length:  1,
+		Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			defer func() {
+				lock.Lock()
+				delete(heads, peer.ID())
+				lock.Unlock()
+			}()
+			time.Sleep(250 * time.Millisecond)
+
+			// Exchange the chain heads, the handshake is done afterwards
+			errc := make(chan error, 1)
+			go func() { errc <- p2p.Send(rw, 0, head) }()
+
+			msg, err := rw.ReadMsg()
+			if err != nil {
+				return err
+			}
+			var remote uint64
+			if err := msg.Decode(&remote); err != nil {
+				return err
+			}
+			if err := <-errc; err != nil {
+				return err
+			}
+			lock.Lock()
+			heads[peer.ID()] = remote
+			lock.Unlock()
+
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+		},
+		PeerInfo: func(id discover.NodeID) interface{} {
+			lock.Lock()
+			defer lock.Unlock()
+
+			if head, ok := heads[id]; ok {
+				return &probeTestInfo{Head: head}
+			}
+			return nil
+		},
+	}
+	constructor := func(*ServiceContext) (Service, error) {
+		return &InstrumentedService{protocols: []p2p.Protocol{protocol}}, nil
+	}
+	if err := stack.Register(constructor); err != nil {
+		t.Fatalf("failed to register test service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	return stack
+}
+
+// waitPeerCount waits until the node has the given number of peers connected.
+func waitPeerCount(t *testing.T, stack *Node, count int) {
+	for i := 0; i < 100; i++ {
+		if stack.Server().PeerCount() == count {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("peer count mismatch: have %d, want %d", stack.Server().PeerCount(), count)
+}
+
+// Tests that probing a remote node reports its protocol metadata only after the
+// handshakes completed, and that the probe connection is dropped afterwards.
+func TestProbePeer(t *testing.T) {
+	local, remote := newProbeTestNode(t, 1), newProbeTestNode(t, 2)
+	defer local.Stop()
+	defer remote.Stop()
+
+	api := NewPrivateAdminAPI(local)
+	probe, err := api.ProbePeer(context.Background(), remote.Server().Self().String(), nil)
+	if err != nil {
+		t.Fatalf("failed to probe peer: %v", err)
+	}
+	if probe.Error != "" || probe.Peer == nil {
+		t.Fatalf("probe failed: %s", probe.Error)
+	}
+	if probe.Peer.ID != remote.Server().Self().ID.String() {
+		t.Errorf("probed peer mismatch: have %s, want %s", probe.Peer.ID, remote.Server().Self().ID)
+	}
+	info, ok := probe.Peer.Protocols["probe"].(*probeTestInfo)
+	if !ok {
+		t.Fatalf("protocol metadata mismatch: have %v, want %T", probe.Peer.Protocols["probe"], info)
+	}
+	if info.Head != 2 {
+		t.Errorf("head mismatch: have %d, want %d", info.Head, 2)
+	}
+	waitPeerCount(t, local, 0)
+}
+
+// Tests that probing an already connected node reports its metadata without
+// dropping the connection.
+func TestProbeConnectedPeer(t *testing.T) {
+	local, remote := newProbeTestNode(t, 1), newProbeTestNode(t, 2)
+	defer local.Stop()
+	defer remote.Stop()
+
+	local.Server().AddPeer(remote.Server().Self())
+	waitPeerCount(t, local, 1)
+
+	api := NewPrivateAdminAPI(local)
+	probe, err := api.ProbePeer(context.Background(), remote.Server().Self().String(), nil)
+	if err != nil {
+		t.Fatalf("failed to probe peer: %v", err)
+	}
+	if probe.Peer == nil {
+		t.Fatalf("probe failed: %s", probe.Error)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if count := local.Server().PeerCount(); count != 1 {
+		t.Errorf("peer count mismatch after probe: have %d, want %d", count, 1)
+	}
+}
+
+// Tests that probing an unreachable node fails once the timeout expires.
+func TestProbePeerTimeout(t *testing.T) {
+	local := newProbeTestNode(t, 1)
+	defer local.Stop()
+
+	key, _ := crypto.GenerateKey()
+	url := discover.NewNode(discover.PubkeyID(&key.PublicKey), []byte{127, 0, 0, 1}, 1, 1).String()
+
+	timeout := uint64(1)
+	if _, err := NewPrivateAdminAPI(local).ProbePeer(context.Background(), url, &timeout); err == nil {
+		t.Fatalf("probe of unreachable node succeeded")
+	}
+	// Invalid enodes are rejected right away
+	if _, err := NewPrivateAdminAPI(local).ProbePeer(context.Background(), "enode://invalid", &timeout); err == nil {
+		t.Fatalf("probe of invalid enode succeeded")
+	}
+}
@@ -18,19 +18,23 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/accounts/external"
 	"github.com/gdachain/go-gdachain/accounts/keystore"
 	"github.com/gdachain/go-gdachain/accounts/usbwallet"
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/metrics/influxdb"
 	"github.com/gdachain/go-gdachain/p2p"
 	"github.com/gdachain/go-gdachain/p2p/discover"
 )
@@ -82,9 +86,20 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// KeyStoreScryptN and KeyStoreScryptP override the scrypt parameters used to
+	// encrypt new and imported keys, taking precedence over UseLightweightKDF.
+	// Zero values select the defaults.
+	KeyStoreScryptN int `toml:",omitempty"`
+	KeyStoreScryptP int `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint (IPC path or HTTP/websocket URL) of an
+	// external signer holding the account keys. If set, hardware wallets are not
+	// monitored, and the keystore is expected to be left empty.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -119,6 +134,10 @@ type Config struct {
 	// exposed.
 	HTTPModules []string `toml:",omitempty"`
 
+	// HTTPMaxRequestSize is the maximum size in bytes of an HTTP RPC request body,
+	// after decompression. Zero selects the default limit of 128KB.
+	HTTPMaxRequestSize int64 `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string `toml:",omitempty"`
@@ -145,6 +164,58 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// RPCMethodTimeouts maps RPC method names to the maximum time their execution
+	// may take on the IPC, HTTP and websocket endpoints. Entries may also name all
+	// the methods of a namespace (debug_*) or every method (*).
+	RPCMethodTimeouts map[string]time.Duration `toml:",omitempty"`
+
+	// RPCMaxConcurrent is the maximum number of method calls executing at the same
+	// time on each of the IPC, HTTP and websocket endpoints (0 = unlimited).
+	RPCMaxConcurrent int `toml:",omitempty"`
+
+	// RPCMaxBatchSize is the maximum number of requests permitted in a single
+	// batch on the IPC, HTTP and websocket endpoints (0 = unlimited).
+	RPCMaxBatchSize int `toml:",omitempty"`
+
+	// RPCJWTSecretFile is the path of a file holding the hex encoded shared secret
+	// used to verify the HS256 signed JWTs authenticating HTTP and websocket RPC
+	// requests to the protected namespaces.
+	RPCJWTSecretFile string `toml:",omitempty"`
+
+	// RPCAuthTokens maps static bearer tokens to the namespaces they grant access
+	// to on the HTTP and websocket endpoints ("*" granting all of them).
+	RPCAuthTokens map[string][]string `toml:",omitempty"`
+
+	// RPCAuthModules is the list of namespaces requiring authentication on the
+	// HTTP and websocket endpoints once a JWT secret or bearer tokens are set. If
+	// empty, the admin, debug, miner and personal namespaces are protected.
+	RPCAuthModules []string `toml:",omitempty"`
+
+	// MetricsHTTP is the host:port to serve the collected metrics on in the
+	// Prometheus exposition format. If empty, the metrics endpoint is disabled.
+	MetricsHTTP string `toml:",omitempty"`
+
+	// MetricsPath is the HTTP path of the Prometheus metrics endpoint.
+	MetricsPath string `toml:",omitempty"`
+
+	// HealthMinPeers is the minimum number of peers for the node to be reported
+	// healthy by the /health HTTP endpoint and node_health (0 = not checked).
+	HealthMinPeers int `toml:",omitempty"`
+
+	// HealthMaxHeadAge is the maximum age of the head block for the node to be
+	// reported healthy (0 = not checked).
+	HealthMaxHeadAge time.Duration `toml:",omitempty"`
+
+	// HealthNotSyncing reports the node degraded while it is synchronising.
+	HealthNotSyncing bool `toml:",omitempty"`
+
+	// HealthDBWritable reports the node degraded if its database rejects writes.
+	HealthDBWritable bool `toml:",omitempty"`
+
+	// MetricsInfluxDB configures the reporting of the collected metrics to an
+	// InfluxDB v1 or v2 server. Reporting is disabled if no endpoint is set.
+	MetricsInfluxDB influxdb.Config
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -330,6 +401,30 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	return key
 }
 
+// defaultAuthModules are the namespaces protected by RPC authentication if none
+// are explicitly configured.
+var defaultAuthModules = []string{"admin", "debug", "miner", "personal"}
+
+// rpcJWTSecret loads the shared secret of the RPC JWT authentication, returning
+// nil if none is configured.
+func (c *Config) rpcJWTSecret() ([]byte, error) {
+	if c.RPCJWTSecretFile == "" {
+		return nil, nil
+	}
+	blob, err := ioutil.ReadFile(c.RPCJWTSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT secret: %v", err)
+	}
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(blob)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT secret: %v", err)
+	}
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("JWT secret too short: have %d bytes, want at least 32", len(secret))
+	}
+	return secret, nil
+}
+
 // StaticNodes returns a list of node enode URLs configured as static nodes.
 func (c *Config) StaticNodes() []*discover.Node {
 	return c.parsePersistentNodes(c.resolvePath(datadirStaticNodes))
@@ -380,6 +475,15 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 		scryptN = keystore.LightScryptN
 		scryptP = keystore.LightScryptP
 	}
+	if c.KeyStoreScryptN != 0 {
+		scryptN = c.KeyStoreScryptN
+	}
+	if c.KeyStoreScryptP != 0 {
+		scryptP = c.KeyStoreScryptP
+	}
+	if err := keystore.ValidateScryptParams(scryptN, scryptP); err != nil {
+		return 0, 0, "", err
+	}
 
 	var (
 		keydir string
@@ -419,7 +523,15 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
 	}
-	if !conf.NoUSB {
+	if conf.ExternalSigner != "" {
+		// Keys are held by the external signer, don't touch any hardware wallets
+		log.Info("Using external signer", "endpoint", conf.ExternalSigner)
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to connect to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	} else if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
 		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
 			log.Warn(fmt.Sprintf("Failed to start Ledger hub, disabling: %v", err))
@@ -21,6 +21,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/gdachain/go-gdachain/p2p"
 	"github.com/gdachain/go-gdachain/p2p/nat"
@@ -31,6 +32,9 @@ const (
 	DefaultHTTPPort = 8545        // Default TCP port for the HTTP RPC server
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 8546        // Default TCP port for the websocket RPC server
+
+	DefaultMetricsPath     = "/metrics"       // Default HTTP path of the Prometheus metrics endpoint
+	DefaultMetricsInterval = 10 * time.Second // Default interval of the InfluxDB metrics reporting
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -41,6 +45,7 @@ var DefaultConfig = Config{
 	HTTPVirtualHosts: []string{"localhost"},
 	WSPort:           DefaultWSPort,
 	WSModules:        []string{"net", "web3"},
+	MetricsPath:      DefaultMetricsPath,
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   25,
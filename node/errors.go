@@ -28,6 +28,7 @@ var (
 	ErrNodeStopped    = errors.New("node not started")
 	ErrNodeRunning    = errors.New("node already running")
 	ErrServiceUnknown = errors.New("unknown service")
+	ErrDraining       = errors.New("node already draining")
 
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 )
@@ -0,0 +1,134 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// healthPath is the HTTP path of the health check on the HTTP RPC endpoint.
+const healthPath = "/health"
+
+const (
+	HealthOK       = "ok"       // All the configured criteria are met
+	HealthDegraded = "degraded" // Some of the configured criteria are not met
+)
+
+// HealthState is the state of a service the health criteria are checked against.
+type HealthState struct {
+	Head    time.Time // Timestamp of the current head block
+	Syncing bool      // Whether the service is synchronising with the network
+	DBError error     // Outcome of a probe write into the service's database, if requested
+}
+
+// HealthChecker is implemented by the services contributing to the health of
+// the node, such as the full and light gdachain protocols. The database write
+// probe is only run if probeDB is set, keeping health polls read only otherwise.
+type HealthChecker interface {
+	HealthState(probeDB bool) HealthState
+}
+
+// HealthCheck is the outcome of a single health criterion.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// HealthReport is the health of the node along with the outcome of each of the
+// configured criteria.
+type HealthReport struct {
+	Status string        `json:"status"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+// Health checks the node against the configured health criteria. The node is
+// reported degraded if it isn't running, is draining, or any criterion fails.
+func (n *Node) Health() HealthReport {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	if n.server == nil {
+		return HealthReport{Status: HealthDegraded, Checks: []HealthCheck{{Name: "running", Detail: "node stopped"}}}
+	}
+	checks := []HealthCheck{{Name: "draining", Healthy: n.drain == nil}}
+
+	if min := n.config.HealthMinPeers; min > 0 {
+		peers := n.server.PeerCount()
+		checks = append(checks, HealthCheck{
+			Name:    "peers",
+			Healthy: peers >= min,
+			Detail:  fmt.Sprintf("%d peers, minimum %d", peers, min),
+		})
+	}
+	for _, service := range n.services {
+		checker, ok := service.(HealthChecker)
+		if !ok {
+			continue
+		}
+		state := checker.HealthState(n.config.HealthDBWritable)
+		if max := n.config.HealthMaxHeadAge; max > 0 {
+			age := time.Since(state.Head)
+			checks = append(checks, HealthCheck{
+				Name:    "head",
+				Healthy: age <= max,
+				Detail:  fmt.Sprintf("head age %v, maximum %v", common.PrettyDuration(age), max),
+			})
+		}
+		if n.config.HealthNotSyncing {
+			checks = append(checks, HealthCheck{Name: "syncing", Healthy: !state.Syncing})
+		}
+		if n.config.HealthDBWritable {
+			check := HealthCheck{Name: "database", Healthy: state.DBError == nil}
+			if state.DBError != nil {
+				check.Detail = state.DBError.Error()
+			}
+			checks = append(checks, check)
+		}
+	}
+	report := HealthReport{Status: HealthOK, Checks: checks}
+	for _, check := range checks {
+		if !check.Healthy {
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+// newHealthHandler wraps an HTTP handler, serving the health report of the node
+// on the health check path. Degraded nodes respond with 503 Service Unavailable
+// so load balancers take them out of rotation.
+func newHealthHandler(n *Node, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != healthPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		report := n.Health()
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != HealthOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// healthService is a service reporting a fixed health state.
+type healthService struct {
+	NoopService
+	state  HealthState
+	probed bool // Whether the database write probe was requested
+}
+
+func (s *healthService) HealthState(probeDB bool) HealthState {
+	s.probed = s.probed || probeDB
+	return s.state
+}
+
+// Tests that the health of the node is checked against the configured criteria
+// and served over HTTP with the matching status codes.
+func TestHealth(t *testing.T) {
+	config := testNodeConfig()
+	config.HealthMaxHeadAge = time.Minute
+	config.HealthNotSyncing = true
+	config.HealthDBWritable = true
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	service := &healthService{state: HealthState{Head: time.Now()}}
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return service, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	handler := newHealthHandler(stack, http.NotFoundHandler())
+
+	// A stopped node is never healthy
+	if report := stack.Health(); report.Status != HealthDegraded {
+		t.Fatalf("stopped node status mismatch: have %s, want %s", report.Status, HealthDegraded)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	tests := []struct {
+		state HealthState
+		code  int
+	}{
+		{HealthState{Head: time.Now()}, http.StatusOK},
+		{HealthState{Head: time.Now().Add(-time.Hour)}, http.StatusServiceUnavailable},
+		{HealthState{Head: time.Now(), Syncing: true}, http.StatusServiceUnavailable},
+		{HealthState{Head: time.Now(), DBError: errors.New("read only")}, http.StatusServiceUnavailable},
+	}
+	for i, tt := range tests {
+		service.state = tt.state
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", healthPath, nil))
+		if rec.Code != tt.code {
+			t.Errorf("test %d: status code mismatch: have %d, want %d", i, rec.Code, tt.code)
+		}
+		var report HealthReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("test %d: failed to decode report: %v", i, err)
+		}
+		if healthy := report.Status == HealthOK; healthy != (tt.code == http.StatusOK) {
+			t.Errorf("test %d: report status mismatch: have %s", i, report.Status)
+		}
+	}
+	// Requests to other paths are passed through
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("passthrough status code mismatch: have %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if !service.probed {
+		t.Errorf("database write probe not requested")
+	}
+}
+
+// Tests that the database write probe is only requested if the check is enabled.
+func TestHealthNoDBProbe(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	service := &healthService{state: HealthState{Head: time.Now(), DBError: errors.New("read only")}}
+	if err := stack.Register(func(*ServiceContext) (Service, error) { return service, nil }); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	if report := stack.Health(); report.Status != HealthOK {
+		t.Errorf("status mismatch: have %s, want %s", report.Status, HealthOK)
+	}
+	if service.probed {
+		t.Errorf("database write probe requested with the check disabled")
+	}
+}
@@ -20,17 +20,22 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/internal/debug"
 	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/metrics"
+	"github.com/gdachain/go-gdachain/metrics/influxdb"
+	"github.com/gdachain/go-gdachain/metrics/prometheus"
 	"github.com/gdachain/go-gdachain/p2p"
 	"github.com/gdachain/go-gdachain/rpc"
 	"github.com/prometheus/prometheus/util/flock"
@@ -60,15 +65,24 @@ type Node struct {
 
 	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
 	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
+	httpCors      []string     // Cross-origin domains allowed to access the HTTP endpoint
+	httpVHosts    []string     // Virtual hostnames accepted by the HTTP endpoint
 	httpListener  net.Listener // HTTP RPC listener socket to server API requests
 	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
 
-	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
-	wsListener net.Listener // Websocket RPC listener socket to server API requests
-	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
+	wsEndpoint  string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
+	wsWhitelist []string     // Websocket RPC modules to allow through this endpoint
+	wsOrigins   []string     // Origins allowed to open websocket connections
+	wsListener  net.Listener // Websocket RPC listener socket to server API requests
+	wsHandler   *rpc.Server  // Websocket RPC request handler to process the API requests
 
-	stop chan struct{} // Channel to wait for termination notifications
-	lock sync.RWMutex
+	metricsEndpoint string       // Prometheus metrics endpoint (interface + port) to listen at (empty = disabled)
+	metricsListener net.Listener // Prometheus metrics listener socket to serve scrapes
+	influxDBStop    func()       // Stops the InfluxDB metrics reporter (nil = not reporting)
+
+	drain *time.Timer   // Timer tearing down the RPC endpoints at the end of a drain
+	stop  chan struct{} // Channel to wait for termination notifications
+	lock  sync.RWMutex
 
 	log log.Logger
 }
@@ -274,11 +288,58 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
+	if err := n.startMetrics(n.config.MetricsHTTP, n.config.MetricsPath); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
+	if err := n.startInfluxDB(n.config.MetricsInfluxDB); err != nil {
+		n.stopMetrics()
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		return err
+	}
 	// All API endpoints started successfully
 	n.rpcAPIs = apis
 	return nil
 }
 
+// rpcLimits assembles the resource limits of the externally reachable RPC
+// endpoints from the node configuration.
+func (n *Node) rpcLimits() rpc.Limits {
+	return rpc.Limits{
+		MethodTimeouts: n.config.RPCMethodTimeouts,
+		MaxConcurrent:  n.config.RPCMaxConcurrent,
+		MaxBatchSize:   n.config.RPCMaxBatchSize,
+		MaxRequestSize: n.config.HTTPMaxRequestSize,
+	}
+}
+
+// rpcAuth assembles the authenticator of the HTTP and websocket RPC endpoints
+// from the node configuration, returning nil if authentication is disabled.
+func (n *Node) rpcAuth() (*rpc.Authenticator, error) {
+	secret, err := n.config.rpcJWTSecret()
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil && len(n.config.RPCAuthTokens) == 0 {
+		return nil, nil
+	}
+	modules := n.config.RPCAuthModules
+	if len(modules) == 0 {
+		modules = defaultAuthModules
+	}
+	return rpc.NewAuthenticator(rpc.AuthConfig{
+		Protected: modules,
+		JWTSecret: secret,
+		Tokens:    n.config.RPCAuthTokens,
+	}), nil
+}
+
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
@@ -309,6 +370,7 @@ func (n *Node) startIPC(apis []rpc.API) error {
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetLimits(n.rpcLimits())
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return err
@@ -340,7 +402,9 @@ func (n *Node) startIPC(apis []rpc.API) error {
 				n.log.Error("IPC accept failed", "err", err)
 				continue
 			}
-			go handler.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+			go func(conn net.Conn) {
+				handler.ServeCodec(rpc.NewAutoCodec(conn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+			}(conn)
 		}
 	}()
 	// All listeners booted successfully
@@ -376,7 +440,13 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 		whitelist[module] = true
 	}
 	// Register all the APIs exposed by the services
+	auth, err := n.rpcAuth()
+	if err != nil {
+		return err
+	}
 	handler := rpc.NewServer()
+	handler.SetLimits(n.rpcLimits())
+	handler.SetAuthenticator(auth)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -386,17 +456,19 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 		}
 	}
 	// All APIs registered, start the HTTP listener
-	var (
-		listener net.Listener
-		err      error
-	)
+	var listener net.Listener
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	server := rpc.NewHTTPServer(cors, vhosts, handler)
+	server.Handler = newHealthHandler(n, server.Handler)
+	go server.Serve(listener)
 	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
+	n.httpWhitelist = modules
+	n.httpCors = cors
+	n.httpVHosts = vhosts
 	n.httpListener = listener
 	n.httpHandler = handler
 
@@ -417,6 +489,18 @@ func (n *Node) stopHTTP() {
 	}
 }
 
+// restartHTTP reopens the running HTTP RPC endpoint on the same address with the
+// given cross-origin and virtual host settings.
+func (n *Node) restartHTTP(cors []string, vhosts []string) error {
+	if n.drain != nil {
+		return ErrDraining
+	}
+	endpoint, modules := n.httpListener.Addr().String(), n.httpWhitelist
+
+	n.stopHTTP()
+	return n.startHTTP(endpoint, n.rpcAPIs, modules, cors, vhosts)
+}
+
 // startWS initializes and starts the websocket RPC endpoint.
 func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
 	// Short circuit if the WS endpoint isn't being exposed
@@ -429,7 +513,13 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 		whitelist[module] = true
 	}
 	// Register all the APIs exposed by the services
+	auth, err := n.rpcAuth()
+	if err != nil {
+		return err
+	}
 	handler := rpc.NewServer()
+	handler.SetLimits(n.rpcLimits())
+	handler.SetAuthenticator(auth)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -439,10 +529,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 		}
 	}
 	// All APIs registered, start the HTTP listener
-	var (
-		listener net.Listener
-		err      error
-	)
+	var listener net.Listener
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
@@ -451,6 +538,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
+	n.wsWhitelist = modules
+	n.wsOrigins = wsOrigins
 	n.wsListener = listener
 	n.wsHandler = handler
 
@@ -471,6 +560,135 @@ func (n *Node) stopWS() {
 	}
 }
 
+// restartWS reopens the running websocket RPC endpoint on the same address with
+// the given allowed origins.
+func (n *Node) restartWS(origins []string) error {
+	if n.drain != nil {
+		return ErrDraining
+	}
+	endpoint, modules := n.wsListener.Addr().String(), n.wsWhitelist
+
+	n.stopWS()
+	return n.startWS(endpoint, n.rpcAPIs, modules, origins, n.config.WSExposeAll)
+}
+
+// startMetrics initializes and starts the Prometheus metrics endpoint.
+func (n *Node) startMetrics(endpoint string, path string) error {
+	// Short circuit if the metrics endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	if !metrics.Enabled {
+		n.log.Warn("Metrics endpoint enabled without metrics collection", "flag", metrics.MetricsEnabledFlag)
+	}
+	if path == "" {
+		path = DefaultMetricsPath
+	}
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, prometheus.Handler(metrics.DefaultRegistry))
+	go http.Serve(listener, mux)
+	n.log.Info("Metrics endpoint opened", "url", fmt.Sprintf("http://%s%s", endpoint, path))
+
+	n.metricsEndpoint = endpoint
+	n.metricsListener = listener
+	return nil
+}
+
+// stopMetrics terminates the Prometheus metrics endpoint.
+func (n *Node) stopMetrics() {
+	if n.metricsListener != nil {
+		n.metricsListener.Close()
+		n.metricsListener = nil
+
+		n.log.Info("Metrics endpoint closed", "url", fmt.Sprintf("http://%s", n.metricsEndpoint))
+	}
+}
+
+// startInfluxDB starts reporting the collected metrics to an InfluxDB server.
+func (n *Node) startInfluxDB(config influxdb.Config) error {
+	// Short circuit if metrics reporting isn't enabled
+	if config.Endpoint == "" {
+		return nil
+	}
+	if !metrics.Enabled {
+		n.log.Warn("Metrics reporting enabled without metrics collection", "flag", metrics.MetricsEnabledFlag)
+	}
+	if config.Interval == 0 {
+		config.Interval = DefaultMetricsInterval
+	}
+	stop, err := influxdb.Start(metrics.DefaultRegistry, config)
+	if err != nil {
+		return err
+	}
+	n.log.Info("Metrics reporting to InfluxDB started", "url", config.Endpoint, "v2", config.Token != "", "interval", config.Interval)
+
+	n.influxDBStop = stop
+	return nil
+}
+
+// stopInfluxDB terminates the InfluxDB metrics reporter.
+func (n *Node) stopInfluxDB() {
+	if n.influxDBStop != nil {
+		n.influxDBStop()
+		n.influxDBStop = nil
+
+		n.log.Info("Metrics reporting to InfluxDB stopped")
+	}
+}
+
+// Drain stops the HTTP and websocket RPC endpoints from accepting new connections
+// and subscriptions. Requests in flight and existing subscriptions are served for
+// the given grace period, after which the endpoints are shut down and the drain
+// ends, allowing them to be started again. The IPC endpoint is left running as
+// the operator's control channel. The HTTP health check reports the node
+// unavailable while draining.
+func (n *Node) Drain(grace time.Duration) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.server == nil {
+		return ErrNodeStopped
+	}
+	if n.drain != nil {
+		return ErrDraining
+	}
+	for _, handler := range []*rpc.Server{n.httpHandler, n.wsHandler} {
+		if handler != nil {
+			handler.Drain()
+		}
+	}
+	n.log.Info("Draining RPC endpoints", "grace", grace)
+
+	var drain *time.Timer
+	drain = time.AfterFunc(grace, func() {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+
+		// Bail out if the node was stopped in the meantime
+		if n.drain != drain {
+			return
+		}
+		n.stopWS()
+		n.stopHTTP()
+		n.drain = nil
+		n.log.Info("RPC endpoints drained")
+	})
+	n.drain = drain
+	return nil
+}
+
+// Draining returns whether the node's RPC endpoints are being drained.
+func (n *Node) Draining() bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.drain != nil
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -483,6 +701,12 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	if n.drain != nil {
+		n.drain.Stop()
+		n.drain = nil
+	}
+	n.stopInfluxDB()
+	n.stopMetrics()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
@@ -679,6 +903,11 @@ func (n *Node) apis() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicWeb3API(n),
 			Public:    true,
+		}, {
+			Namespace: "node",
+			Version:   "1.0",
+			Service:   NewPublicNodeAPI(n),
+			Public:    true,
 		},
 	}
 }
@@ -573,3 +573,105 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the cross-origin settings of the HTTP endpoint can be changed at
+// runtime without moving the endpoint.
+func TestHTTPReconfigure(t *testing.T) {
+	config := testNodeConfig()
+	config.HTTPHost = "127.0.0.1"
+	config.HTTPPort = 0
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	api := NewPrivateAdminAPI(stack)
+	before := api.RpcConnections()["http"]
+	if before == nil {
+		t.Fatalf("HTTP endpoint not running")
+	}
+	if _, err := api.SetRPCCors("http://a.example, http://b.example"); err != nil {
+		t.Fatalf("failed to change cors: %v", err)
+	}
+	if _, err := api.SetRPCVirtualHosts("localhost"); err != nil {
+		t.Fatalf("failed to change vhosts: %v", err)
+	}
+	after := api.RpcConnections()["http"]
+	if after == nil {
+		t.Fatalf("HTTP endpoint not running after reconfiguration")
+	}
+	if after.Endpoint != before.Endpoint {
+		t.Errorf("endpoint mismatch: have %s, want %s", after.Endpoint, before.Endpoint)
+	}
+	if want := []string{"http://a.example", "http://b.example"}; !reflect.DeepEqual(after.Origins, want) {
+		t.Errorf("origins mismatch: have %v, want %v", after.Origins, want)
+	}
+	if want := []string{"localhost"}; !reflect.DeepEqual(after.VHosts, want) {
+		t.Errorf("vhosts mismatch: have %v, want %v", after.VHosts, want)
+	}
+	if _, err := api.SetWSOrigins("*"); err == nil {
+		t.Errorf("websocket origins changed without a running endpoint")
+	}
+}
+
+// Tests that a drain ends once the endpoints are torn down, leaving IPC running
+// and allowing the endpoints to be restarted and drained again.
+func TestDrainRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	config.IPCPath = "test.ipc"
+	config.HTTPHost = "127.0.0.1"
+	config.HTTPPort = 0
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	defer stack.Stop()
+
+	if err := stack.Drain(10 * time.Millisecond); err != nil {
+		t.Fatalf("failed to drain node: %v", err)
+	}
+	if !stack.Draining() {
+		t.Fatalf("node not draining")
+	}
+	if err := stack.Drain(10 * time.Millisecond); err != ErrDraining {
+		t.Fatalf("error mismatch for repeated drain: have %v, want %v", err, ErrDraining)
+	}
+	for deadline := time.Now().Add(time.Second); stack.Draining(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("drain did not end after the grace period")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	api := NewPrivateAdminAPI(stack)
+	if api.RpcConnections()["http"] != nil {
+		t.Fatalf("HTTP endpoint running after drain")
+	}
+	if stack.ipcHandler == nil {
+		t.Fatalf("IPC endpoint stopped by drain")
+	}
+	if _, err := api.StartRPC(nil, nil, nil, nil, nil); err != nil {
+		t.Fatalf("failed to restart HTTP endpoint: %v", err)
+	}
+	if _, err := api.SetRPCCors("http://a.example"); err != nil {
+		t.Fatalf("failed to reconfigure restarted HTTP endpoint: %v", err)
+	}
+	if err := stack.Drain(time.Minute); err != nil {
+		t.Fatalf("failed to drain node again: %v", err)
+	}
+}
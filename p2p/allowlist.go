@@ -0,0 +1,95 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gdachain/go-gdachain/p2p/discover"
+	"github.com/gdachain/go-gdachain/p2p/netutil"
+)
+
+var errNotAllowlisted = errors.New("node not in peer allowlist")
+
+// Allowlist is the set of peers admitted by a server running in permissioned
+// mode. A peer is admitted if its node ID is listed or its remote IP address
+// falls into one of the listed networks.
+type Allowlist struct {
+	ids     map[discover.NodeID]struct{}
+	nets    netutil.Netlist
+	entries []string
+}
+
+// ParseAllowlist creates an allowlist from a list of entries. Each entry is
+// either an enode URL, a hex encoded node ID or a CIDR mask.
+func ParseAllowlist(entries []string) (*Allowlist, error) {
+	l := &Allowlist{ids: make(map[discover.NodeID]struct{})}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(entry, "/") && !strings.HasPrefix(entry, "enode://"):
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist network %q: %v", entry, err)
+			}
+			l.nets = append(l.nets, *n)
+		case strings.HasPrefix(entry, "enode://"):
+			node, err := discover.ParseNode(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist enode %q: %v", entry, err)
+			}
+			l.ids[node.ID] = struct{}{}
+		default:
+			id, err := discover.HexID(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist node ID %q: %v", entry, err)
+			}
+			l.ids[id] = struct{}{}
+		}
+		l.entries = append(l.entries, entry)
+	}
+	return l, nil
+}
+
+// Allows reports whgdaer a peer with the given node ID and remote IP address
+// may connect. The IP may be nil if the address of the peer is unknown.
+func (l *Allowlist) Allows(id discover.NodeID, ip net.IP) bool {
+	if _, ok := l.ids[id]; ok {
+		return true
+	}
+	return ip != nil && l.nets.Contains(ip)
+}
+
+// Entries returns the entries the allowlist was created from.
+func (l *Allowlist) Entries() []string {
+	return append([]string{}, l.entries...)
+}
+
+// remoteIP returns the IP address of the remote end of a connection, or nil if
+// the connection is not a TCP connection.
+func remoteIP(addr net.Addr) net.IP {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	return nil
+}
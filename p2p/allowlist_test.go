@@ -0,0 +1,65 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/p2p/discover"
+)
+
+func TestAllowlist(t *testing.T) {
+	var (
+		id1 = randomID()
+		id2 = randomID()
+		id3 = randomID()
+	)
+	l, err := ParseAllowlist([]string{
+		"enode://" + id1.String() + "@10.0.0.1:30303",
+		"0x" + id2.String(),
+		" 192.168.0.0/16 ",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("failed to parse allowlist: %v", err)
+	}
+	if n := len(l.Entries()); n != 3 {
+		t.Errorf("entry count mismatch: have %d, want 3", n)
+	}
+	tests := []struct {
+		id    discover.NodeID
+		ip    net.IP
+		allow bool
+	}{
+		{id1, nil, true},
+		{id2, net.ParseIP("8.8.8.8"), true},
+		{id3, net.ParseIP("192.168.1.7"), true},
+		{id3, net.ParseIP("10.0.0.1"), false},
+		{id3, nil, false},
+	}
+	for i, tt := range tests {
+		if allow := l.Allows(tt.id, tt.ip); allow != tt.allow {
+			t.Errorf("test %d: allowed mismatch: have %v, want %v", i, allow, tt.allow)
+		}
+	}
+	for _, entry := range []string{"10.0.0.1/33", "0x1234", "enode://foo@bar"} {
+		if _, err := ParseAllowlist([]string{entry}); err == nil {
+			t.Errorf("expected error for invalid entry %q", entry)
+		}
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"net"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdachain/go-gdachain/common"
@@ -84,8 +85,9 @@ type Table struct {
 
 	nodeAddedHook func(*Node) // for testing
 
-	net  transport
-	self *Node // metadata of the local node
+	net       transport
+	self      *Node        // metadata of the local node
+	announced atomic.Value // *Node, local node with an updated endpoint (see SetSelfIP)
 }
 
 type bondproc struct {
@@ -164,9 +166,24 @@ func (tab *Table) seedRand() {
 // Self returns the local node.
 // The returned node should not be modified by the caller.
 func (tab *Table) Self() *Node {
+	if self, ok := tab.announced.Load().(*Node); ok {
+		return self
+	}
 	return tab.self
 }
 
+// SetSelfIP changes the IP address the local node is announced on, such as
+// after the external address of its NAT gateway changed.
+func (tab *Table) SetSelfIP(ip net.IP) {
+	self := *tab.Self()
+	self.IP = ip
+	tab.announced.Store(&self)
+
+	if t, ok := tab.net.(*udp); ok {
+		t.setEndpointIP(ip)
+	}
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
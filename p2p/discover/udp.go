@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/gdachain/go-gdachain/crypto"
@@ -163,6 +164,7 @@ type udp struct {
 	netrestrict *netutil.Netlist
 	priv        *ecdsa.PrivateKey
 	ourEndpoint rpcEndpoint
+	endpointMu  sync.RWMutex // protects ourEndpoint, which changes with the external IP
 
 	addpending chan *pending
 	gotreply   chan reply
@@ -265,6 +267,22 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 	return udp.Table, udp, nil
 }
 
+// endpoint returns the endpoint the local node is announced on.
+func (t *udp) endpoint() rpcEndpoint {
+	t.endpointMu.RLock()
+	defer t.endpointMu.RUnlock()
+
+	return t.ourEndpoint
+}
+
+// setEndpointIP changes the IP address of the announced endpoint.
+func (t *udp) setEndpointIP(ip net.IP) {
+	t.endpointMu.Lock()
+	defer t.endpointMu.Unlock()
+
+	t.ourEndpoint = makeEndpoint(&net.UDPAddr{IP: ip, Port: int(t.ourEndpoint.UDP)}, t.ourEndpoint.TCP)
+}
+
 func (t *udp) close() {
 	close(t.closing)
 	t.conn.Close()
@@ -275,7 +293,7 @@ func (t *udp) close() {
 func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
 	req := &ping{
 		Version:    Version,
-		From:       t.ourEndpoint,
+		From:       t.endpoint(),
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 	}
@@ -29,6 +29,11 @@ var (
 	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/InboundTraffic", nil)
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/OutboundConnects", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/OutboundTraffic", nil)
+
+	// Message payload sizes before compression, to be compared against the above
+	// traffic meters for the effective compression ratio of the transport
+	ingressPlainMeter = metrics.NewRegisteredMeter("p2p/InboundPlainTraffic", nil)
+	egressPlainMeter  = metrics.NewRegisteredMeter("p2p/OutboundPlainTraffic", nil)
 )
 
 // meteredConn is a wrapper around a network TCP connection that meters both the
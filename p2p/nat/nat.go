@@ -93,11 +93,79 @@ func Parse(spec string) (Interface, error) {
 const (
 	mapTimeout        = 20 * time.Minute
 	mapUpdateInterval = 15 * time.Minute
+	mapRetryInterval  = time.Minute // Retry interval of failed mappings, e.g. after gateway restarts
 )
 
+// Mapping is the state of a port mapping kept alive by Map.
+type Mapping struct {
+	Protocol    string    `json:"protocol"`
+	ExtPort     int       `json:"externalPort"`
+	IntPort     int       `json:"internalPort"`
+	Mapped      bool      `json:"mapped"`          // Whether the last attempt to add the mapping succeeded
+	LastRefresh time.Time `json:"lastRefresh"`     // Time of the last attempt to add the mapping
+	Error       string    `json:"error,omitempty"` // Error of the last attempt to add the mapping
+}
+
+// Status records the state of the port mappings kept alive through it. It is
+// safe for concurrent use.
+type Status struct {
+	lock     sync.RWMutex
+	mappings []*Mapping
+}
+
+// Mappings returns the state of all port mappings kept alive through s.
+func (s *Status) Mappings() []Mapping {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	mappings := make([]Mapping, len(s.mappings))
+	for i, mapping := range s.mappings {
+		mappings[i] = *mapping
+	}
+	return mappings
+}
+
+// Map adds a port mapping on m and keeps it alive until c is closed, recording
+// its state in s. This function is typically invoked in its own goroutine.
+func (s *Status) Map(m Interface, c chan struct{}, protocol string, extport, intport int, name string) {
+	mapping := &Mapping{Protocol: protocol, ExtPort: extport, IntPort: intport}
+
+	s.lock.Lock()
+	s.mappings = append(s.mappings, mapping)
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		for i, m := range s.mappings {
+			if m == mapping {
+				s.mappings = append(s.mappings[:i], s.mappings[i+1:]...)
+				break
+			}
+		}
+	}()
+	mapPort(m, c, protocol, extport, intport, name, func(err error) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		mapping.Mapped, mapping.LastRefresh, mapping.Error = err == nil, time.Now(), ""
+		if err != nil {
+			mapping.Error = err.Error()
+		}
+	})
+}
+
 // Map adds a port mapping on m and keeps it alive until c is closed.
 // This function is typically invoked in its own goroutine.
 func Map(m Interface, c chan struct{}, protocol string, extport, intport int, name string) {
+	mapPort(m, c, protocol, extport, intport, name, nil)
+}
+
+// mapPort keeps a port mapping alive until c is closed, reporting the outcome
+// of every attempt to add it to the optional report callback. Failed attempts
+// are retried sooner than successful ones are refreshed.
+func mapPort(m Interface, c chan struct{}, protocol string, extport, intport int, name string, report func(error)) {
 	log := log.New("proto", protocol, "extport", extport, "intport", intport, "interface", m)
 	refresh := time.NewTimer(mapUpdateInterval)
 	defer func() {
@@ -105,10 +173,21 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 		log.Debug("Deleting port mapping")
 		m.DeleteMapping(protocol, extport, intport)
 	}()
-	if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-		log.Debug("Couldn't add port mapping", "err", err)
-	} else {
+	add := func() time.Duration {
+		err := m.AddMapping(protocol, extport, intport, name, mapTimeout)
+		if report != nil {
+			report(err)
+		}
+		if err != nil {
+			log.Debug("Couldn't add port mapping", "err", err)
+			return mapRetryInterval
+		}
+		return mapUpdateInterval
+	}
+	if next := add(); next == mapUpdateInterval {
 		log.Info("Mapped network port")
+	} else {
+		refresh.Reset(next)
 	}
 	for {
 		select {
@@ -118,10 +197,7 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 			}
 		case <-refresh.C:
 			log.Trace("Refreshing port mapping")
-			if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-				log.Debug("Couldn't add port mapping", "err", err)
-			}
-			refresh.Reset(mapUpdateInterval)
+			refresh.Reset(add())
 		}
 	}
 }
@@ -17,6 +17,7 @@
 package nat
 
 import (
+	"errors"
 	"net"
 	"testing"
 	"time"
@@ -61,3 +62,49 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+// failingNAT is a NAT mechanism whose gateway rejects all port mappings.
+type failingNAT struct {
+	extIP
+	deleted chan struct{}
+}
+
+func (n failingNAT) AddMapping(string, int, int, string, time.Duration) error {
+	return errors.New("mapping rejected")
+}
+
+func (n failingNAT) DeleteMapping(string, int, int) error {
+	close(n.deleted)
+	return nil
+}
+
+// Tests that the state of the port mappings is tracked while they are kept alive
+// and dropped once they are released.
+func TestStatusMap(t *testing.T) {
+	var (
+		status Status
+		quit   = make(chan struct{})
+		m      = failingNAT{extIP{33, 44, 55, 66}, make(chan struct{})}
+	)
+	go status.Map(m, quit, "tcp", 30303, 30303, "test")
+
+	deadline := time.Now().Add(time.Second)
+	for len(status.Mappings()) == 0 || status.Mappings()[0].LastRefresh.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("mapping attempt not recorded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mapping := status.Mappings()[0]
+	if mapping.Mapped || mapping.Error != "mapping rejected" || mapping.ExtPort != 30303 {
+		t.Fatalf("mapping state mismatch: %+v", mapping)
+	}
+	close(quit)
+	<-m.deleted
+	for len(status.Mappings()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("released mapping still tracked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
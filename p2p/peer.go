@@ -422,6 +422,7 @@ type PeerInfo struct {
 		Inbound       bool   `json:"inbound"`
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
+		Compressed    bool   `json:"compressed"` // Whether messages are snappy compressed on the wire
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
 }
@@ -445,6 +446,9 @@ func (p *Peer) Info() *PeerInfo {
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	if t, ok := p.rw.transport.(*rlpx); ok {
+		info.Network.Compressed = t.compressed()
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
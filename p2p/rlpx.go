@@ -88,6 +88,13 @@ func newRLPX(fd net.Conn) transport {
 	return &rlpx{fd: fd}
 }
 
+// compressed reports whether messages are snappy compressed on the connection.
+// It is negotiated during the protocol handshake and never changes afterwards,
+// so it may be read without holding the (long held) read lock.
+func (t *rlpx) compressed() bool {
+	return t.rw != nil && t.rw.snappy
+}
+
 func (t *rlpx) ReadMsg() (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
@@ -600,6 +607,8 @@ func newRLPXFrameRW(conn io.ReadWriter, s secrets) *rlpxFrameRW {
 func (rw *rlpxFrameRW) WriteMsg(msg Msg) error {
 	ptype, _ := rlp.EncodeToBytes(msg.Code)
 
+	egressPlainMeter.Mark(int64(msg.Size))
+
 	// if snappy is enabled, compress message now
 	if rw.snappy {
 		if msg.Size > maxUint24 {
@@ -716,6 +725,7 @@ func (rw *rlpxFrameRW) ReadMsg() (msg Msg, err error) {
 		}
 		msg.Size, msg.Payload = uint32(size), bytes.NewReader(payload)
 	}
+	ingressPlainMeter.Mark(int64(msg.Size))
 	return msg, nil
 }
 
@@ -106,6 +106,17 @@ type Config struct {
 	// IP networks contained in the list are considered.
 	NetRestrict *netutil.Netlist `toml:",omitempty"`
 
+	// PeerAllowlist enables permissioned mode, in which only the listed nodes
+	// may connect. Entries are enode URLs, hex node IDs or CIDR masks. Peer
+	// discovery is disabled while the allowlist is set.
+	PeerAllowlist []string `toml:",omitempty"`
+
+	// MaxDailyTraffic limits the total number of bytes sent and received over
+	// peer connections within 24 hours. Once the limit is reached all peers are
+	// dropped and no new connections are made until the period ends. Zero
+	// means no limit.
+	MaxDailyTraffic uint64 `toml:",omitempty"`
+
 	// NodeDatabase is the path to the database containing the previously seen
 	// live nodes in the network.
 	NodeDatabase string `toml:",omitempty"`
@@ -157,6 +168,9 @@ type Server struct {
 	running bool
 
 	ntab         discoverTable
+	natState     *natState
+	allowlist    *Allowlist // owned by run after Start
+	traffic      *trafficMeter
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
@@ -300,6 +314,62 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// SetAllowlist replaces the peer allowlist and disconnects all peers that are
+// not admitted by the new one. An empty list leaves permissioned mode, but does
+// not restart peer discovery.
+func (srv *Server) SetAllowlist(entries []string) error {
+	var allowlist *Allowlist
+	if len(entries) > 0 {
+		var err error
+		if allowlist, err = ParseAllowlist(entries); err != nil {
+			return err
+		}
+	}
+	select {
+	case srv.peerOp <- func(peers map[discover.NodeID]*Peer) {
+		srv.allowlist = allowlist
+		if allowlist == nil {
+			return
+		}
+		for id, p := range peers {
+			if !allowlist.Allows(id, remoteIP(p.RemoteAddr())) {
+				p.Disconnect(DiscRequested)
+			}
+		}
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
+		return errServerStopped
+	}
+	return nil
+}
+
+// Allowlist returns the entries of the peer allowlist, or nil if the server is
+// not running in permissioned mode.
+func (srv *Server) Allowlist() []string {
+	var entries []string
+	select {
+	case srv.peerOp <- func(map[discover.NodeID]*Peer) {
+		if srv.allowlist != nil {
+			entries = srv.allowlist.Entries()
+		}
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
+	}
+	return entries
+}
+
+// TrafficStats returns the traffic counters of the current accounting window.
+func (srv *Server) TrafficStats() TrafficStats {
+	return srv.traffic.stats()
+}
+
+// TrafficExhausted reports whether the daily traffic quota has been used up.
+func (srv *Server) TrafficExhausted() bool {
+	return srv.traffic.exhausted()
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -324,11 +394,15 @@ func (srv *Server) makeSelf(listener net.Listener, ntab discoverTable) *discover
 		if listener == nil {
 			return &discover.Node{IP: net.ParseIP("0.0.0.0"), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
 		}
-		// Otherwise inject the listener address too
+		// Otherwise inject the listener address too, preferring the external IP
 		addr := listener.Addr().(*net.TCPAddr)
+		ip := addr.IP
+		if ext := srv.externalIP(); ext != nil {
+			ip = ext
+		}
 		return &discover.Node{
 			ID:  discover.PubkeyID(&srv.PrivateKey.PublicKey),
-			IP:  addr.IP,
+			IP:  ip,
 			TCP: uint16(addr.Port),
 		}
 	}
@@ -404,6 +478,15 @@ func (srv *Server) Start() (err error) {
 	if srv.Dialer == nil {
 		srv.Dialer = TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	srv.allowlist = nil
+	if len(srv.PeerAllowlist) > 0 {
+		if srv.allowlist, err = ParseAllowlist(srv.PeerAllowlist); err != nil {
+			return err
+		}
+		srv.log.Info("Permissioned mode, peer discovery disabled", "allowlist", len(srv.allowlist.Entries()))
+		srv.NoDiscovery, srv.DiscoveryV5 = true, false
+	}
+	srv.traffic = newTrafficMeter(srv.MaxDailyTraffic)
 	srv.quit = make(chan struct{})
 	srv.addpeer = make(chan *conn)
 	srv.delpeer = make(chan peerDrop)
@@ -412,6 +495,10 @@ func (srv *Server) Start() (err error) {
 	srv.removestatic = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
+	srv.natState = nil
+	if srv.NAT != nil {
+		srv.natState = new(natState)
+	}
 
 	var (
 		conn      *net.UDPConn
@@ -432,10 +519,10 @@ func (srv *Server) Start() (err error) {
 		realaddr = conn.LocalAddr().(*net.UDPAddr)
 		if srv.NAT != nil {
 			if !realaddr.IP.IsLoopback() {
-				go nat.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "gdaereum discovery")
+				go srv.natState.mappings.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "gdaereum discovery")
 			}
-			// TODO: react to external IP changes over time.
-			if ext, err := srv.NAT.ExternalIP(); err == nil {
+			// Changes of the external IP are picked up by natLoop
+			if ext, err := srv.checkExternalIP(); err == nil {
 				realaddr = &net.UDPAddr{IP: ext, Port: realaddr.Port}
 			}
 		}
@@ -502,6 +589,10 @@ func (srv *Server) Start() (err error) {
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
+	if srv.NAT != nil {
+		srv.loopWG.Add(1)
+		go srv.natLoop()
+	}
 	srv.running = true
 	return nil
 }
@@ -521,7 +612,7 @@ func (srv *Server) startListening() error {
 	if !laddr.IP.IsLoopback() && srv.NAT != nil {
 		srv.loopWG.Add(1)
 		go func() {
-			nat.Map(srv.NAT, srv.quit, "tcp", laddr.Port, laddr.Port, "gdaereum p2p")
+			srv.natState.mappings.Map(srv.NAT, srv.quit, "tcp", laddr.Port, laddr.Port, "gdaereum p2p")
 			srv.loopWG.Done()
 		}()
 	}
@@ -711,6 +802,8 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case srv.allowlist != nil && !srv.allowlist.Allows(c.id, remoteIP(c.fd.RemoteAddr())):
+		return errNotAllowlisted
 	default:
 		return nil
 	}
@@ -797,6 +890,7 @@ func (srv *Server) SetupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	if self == nil {
 		return errors.New("shutdown")
 	}
+	fd = srv.traffic.wrap(fd)
 	c := &conn{fd: fd, transport: srv.newTransport(fd), flags: flags, cont: make(chan error)}
 	err := srv.setupConn(c, flags, dialDest)
 	if err != nil {
@@ -814,6 +908,9 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *discover.Node) e
 	if !running {
 		return errServerStopped
 	}
+	if srv.traffic.exhausted() {
+		return ErrTrafficQuotaExceeded
+	}
 	// Run the encryption handshake.
 	var err error
 	if c.id, err = c.doEncHandshake(srv.PrivateKey, dialDest); err != nil {
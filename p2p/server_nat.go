@@ -0,0 +1,135 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/p2p/discover"
+	"github.com/gdachain/go-gdachain/p2p/nat"
+)
+
+// natCheckInterval is the interval at which the external IP address of the NAT
+// gateway is checked for changes.
+const natCheckInterval = 5 * time.Minute
+
+// NATStatus is the state of the NAT traversal of the server.
+type NATStatus struct {
+	Mechanism  string        `json:"mechanism"`
+	ExternalIP net.IP        `json:"externalIP"`      // Last external IP reported by the gateway
+	LastCheck  time.Time     `json:"lastCheck"`       // Time the external IP was last queried
+	Error      string        `json:"error,omitempty"` // Error of the last external IP query
+	Mappings   []nat.Mapping `json:"mappings"`
+}
+
+// natState tracks the port mappings and the external IP address of the NAT
+// gateway of a running server.
+type natState struct {
+	mappings nat.Status
+
+	lock    sync.RWMutex
+	extIP   net.IP
+	checked time.Time
+	err     error
+}
+
+// NATStatus returns the state of the NAT traversal of the server, or nil if it
+// isn't running or has no NAT mechanism configured.
+func (srv *Server) NATStatus() *NATStatus {
+	srv.lock.Lock()
+	running, state := srv.running, srv.natState
+	srv.lock.Unlock()
+
+	if !running || state == nil {
+		return nil
+	}
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	status := &NATStatus{
+		Mechanism:  srv.NAT.String(),
+		ExternalIP: state.extIP,
+		LastCheck:  state.checked,
+		Mappings:   state.mappings.Mappings(),
+	}
+	if state.err != nil {
+		status.Error = state.err.Error()
+	}
+	return status
+}
+
+// externalIP returns the last known external IP address of the NAT gateway, or
+// nil if it's unknown.
+func (srv *Server) externalIP() net.IP {
+	if srv.natState == nil {
+		return nil
+	}
+	srv.natState.lock.RLock()
+	defer srv.natState.lock.RUnlock()
+
+	return srv.natState.extIP
+}
+
+// natLoop periodically checks the external IP address of the NAT gateway, so
+// the local node is announced on its new address after it changed.
+func (srv *Server) natLoop() {
+	defer srv.loopWG.Done()
+
+	ticker := time.NewTicker(natCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			srv.checkExternalIP()
+		case <-srv.quit:
+			return
+		}
+	}
+}
+
+// checkExternalIP queries the external IP address of the NAT gateway, updating
+// the endpoint the local node is announced on in discovery if it changed.
+func (srv *Server) checkExternalIP() (net.IP, error) {
+	ip, err := srv.NAT.ExternalIP()
+
+	srv.natState.lock.Lock()
+	srv.natState.checked, srv.natState.err = time.Now(), err
+	if err == nil {
+		srv.natState.extIP = ip
+	}
+	srv.natState.lock.Unlock()
+
+	if err != nil {
+		srv.log.Debug("Couldn't query external IP", "interface", srv.NAT, "err", err)
+		return nil, err
+	}
+	// Announce the local node on the new address if discovery is running. The
+	// initial address is passed to discovery when it's created.
+	if tab, ok := srv.ntab.(interface {
+		Self() *discover.Node
+		SetSelfIP(net.IP)
+	}); ok {
+		if old := tab.Self().IP; !old.Equal(ip) {
+			srv.log.Info("External IP changed", "interface", srv.NAT, "old", old, "new", ip)
+			tab.SetSelfIP(ip)
+		}
+	}
+	return ip, nil
+}
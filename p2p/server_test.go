@@ -375,6 +375,7 @@ func TestServerSetupConn(t *testing.T) {
 		tt        *setupTransport
 		flags     connFlag
 		dialDest  *discover.Node
+		allowlist []string
 
 		wantCloseErr error
 		wantCalls    string
@@ -424,15 +425,30 @@ func TestServerSetupConn(t *testing.T) {
 			wantCalls:    "doEncHandshake,doProtoHandshake,close,",
 			wantCloseErr: DiscUselessPeer,
 		},
+		{
+			tt:           &setupTransport{id: id, phs: &protoHandshake{ID: id}},
+			flags:        inboundConn,
+			allowlist:    []string{randomID().String()},
+			wantCalls:    "doEncHandshake,close,",
+			wantCloseErr: errNotAllowlisted,
+		},
+		{
+			tt:           &setupTransport{id: id, phs: &protoHandshake{ID: id}},
+			flags:        inboundConn,
+			allowlist:    []string{id.String()},
+			wantCalls:    "doEncHandshake,doProtoHandshake,close,",
+			wantCloseErr: DiscUselessPeer,
+		},
 	}
 
 	for i, test := range tests {
 		srv := &Server{
 			Config: Config{
-				PrivateKey: srvkey,
-				MaxPeers:   10,
-				NoDial:     true,
-				Protocols:  []Protocol{discard},
+				PrivateKey:    srvkey,
+				MaxPeers:      10,
+				NoDial:        true,
+				Protocols:     []Protocol{discard},
+				PeerAllowlist: test.allowlist,
 			},
 			newTransport: func(fd net.Conn) transport { return test.tt },
 			log:          log.New(),
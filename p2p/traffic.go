@@ -0,0 +1,151 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// trafficWindow is the period after which the traffic counters are reset.
+const trafficWindow = 24 * time.Hour
+
+// ErrTrafficQuotaExceeded is returned when the daily traffic quota of the
+// server has been used up. No connections are set up until the quota is reset.
+var ErrTrafficQuotaExceeded = errors.New("daily traffic quota exceeded")
+
+// TrafficStats contains the traffic counters of a server for the current
+// accounting window.
+type TrafficStats struct {
+	Ingress   uint64    `json:"ingress"`   // Bytes received since the window start
+	Egress    uint64    `json:"egress"`    // Bytes sent since the window start
+	Limit     uint64    `json:"limit"`     // Maximum total bytes per window (0 = unlimited)
+	Since     time.Time `json:"since"`     // Start of the current accounting window
+	Exhausted bool      `json:"exhausted"` // Whether the quota has been used up
+}
+
+// trafficMeter counts the bytes transferred over all peer connections of a
+// server and enforces the daily traffic limit.
+type trafficMeter struct {
+	limit uint64
+	now   func() time.Time // overridden in tests
+
+	lock            sync.Mutex
+	since           time.Time
+	ingress, egress uint64
+}
+
+func newTrafficMeter(limit uint64) *trafficMeter {
+	return &trafficMeter{limit: limit, now: time.Now, since: time.Now()}
+}
+
+// add accounts n transferred bytes and reports whether the quota has been
+// exceeded afterwards.
+func (m *trafficMeter) add(n int, ingress bool) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.rollover()
+	if ingress {
+		m.ingress += uint64(n)
+	} else {
+		m.egress += uint64(n)
+	}
+	return m.exceeded()
+}
+
+// exhausted reports whether the quota of the current window is used up.
+func (m *trafficMeter) exhausted() bool {
+	if m == nil {
+		return false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.rollover()
+	return m.exceeded()
+}
+
+// stats returns a snapshot of the counters.
+func (m *trafficMeter) stats() TrafficStats {
+	if m == nil {
+		return TrafficStats{}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.rollover()
+	return TrafficStats{
+		Ingress:   m.ingress,
+		Egress:    m.egress,
+		Limit:     m.limit,
+		Since:     m.since,
+		Exhausted: m.exceeded(),
+	}
+}
+
+// rollover resets the counters if the accounting window has passed. The
+// caller must hold the lock.
+func (m *trafficMeter) rollover() {
+	if now := m.now(); now.Sub(m.since) >= trafficWindow {
+		m.since, m.ingress, m.egress = now, 0, 0
+	}
+}
+
+// exceeded reports whether the counters exceed the limit. The caller must
+// hold the lock.
+func (m *trafficMeter) exceeded() bool {
+	return m.limit != 0 && m.ingress+m.egress >= m.limit
+}
+
+// wrap returns a connection that accounts its traffic to the meter. Reads and
+// writes fail with ErrTrafficQuotaExceeded once the quota is used up.
+func (m *trafficMeter) wrap(fd net.Conn) net.Conn {
+	if m == nil {
+		return fd
+	}
+	return &quotaConn{Conn: fd, meter: m}
+}
+
+// quotaConn is a network connection counting its traffic into a trafficMeter.
+type quotaConn struct {
+	net.Conn
+	meter *trafficMeter
+}
+
+// Read delegates a network read to the underlying connection and accounts the
+// received bytes.
+func (c *quotaConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	if c.meter.add(n, true) && err == nil {
+		err = ErrTrafficQuotaExceeded
+	}
+	return n, err
+}
+
+// Write delegates a network write to the underlying connection and accounts
+// the sent bytes.
+func (c *quotaConn) Write(b []byte) (n int, err error) {
+	if c.meter.exhausted() {
+		return 0, ErrTrafficQuotaExceeded
+	}
+	n, err = c.Conn.Write(b)
+	c.meter.add(n, false)
+	return n, err
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTrafficMeterQuota(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	m := newTrafficMeter(100)
+	m.now = func() time.Time { return now }
+	m.since = now
+
+	if m.add(60, true) {
+		t.Fatal("quota exceeded after 60 bytes")
+	}
+	if !m.add(40, false) {
+		t.Fatal("quota not exceeded after 100 bytes")
+	}
+	stats := m.stats()
+	if stats.Ingress != 60 || stats.Egress != 40 || !stats.Exhausted {
+		t.Fatalf("wrong stats: %+v", stats)
+	}
+	// Move past the accounting window, the counters should be reset.
+	now = now.Add(trafficWindow)
+	if m.exhausted() {
+		t.Fatal("quota still exhausted after window rollover")
+	}
+	if stats := m.stats(); stats.Ingress != 0 || stats.Egress != 0 || !stats.Since.Equal(now) {
+		t.Fatalf("counters not reset: %+v", stats)
+	}
+}
+
+func TestTrafficMeterUnlimited(t *testing.T) {
+	m := newTrafficMeter(0)
+	if m.add(1<<40, true) || m.exhausted() {
+		t.Fatal("unlimited meter reported exhausted quota")
+	}
+}
+
+func TestQuotaConn(t *testing.T) {
+	m := newTrafficMeter(10)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	qc := m.wrap(c1)
+
+	go c2.Write(make([]byte, 6))
+	if n, err := qc.Read(make([]byte, 6)); n != 6 || err != nil {
+		t.Fatalf("read failed: n=%d err=%v", n, err)
+	}
+	go c2.Read(make([]byte, 6))
+	if n, err := qc.Write(make([]byte, 6)); n != 6 || err != nil {
+		t.Fatalf("write failed: n=%d err=%v", n, err)
+	}
+	if _, err := qc.Write([]byte{1}); err != ErrTrafficQuotaExceeded {
+		t.Fatalf("write after quota: got %v, want %v", err, ErrTrafficQuotaExceeded)
+	}
+}
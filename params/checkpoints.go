@@ -0,0 +1,98 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+// Checkpoints maps block numbers to the hashes of the canonical blocks at those
+// heights. Any chain conflicting with a checkpoint is rejected, preventing peers
+// from feeding a freshly syncing node a bogus history.
+type Checkpoints map[uint64]common.Hash
+
+var (
+	// MainnetCheckpoints contains the hard coded checkpoints of the main network.
+	// It is extended at release time with blocks deep enough to be final.
+	MainnetCheckpoints = Checkpoints{}
+
+	// TestnetCheckpoints contains the hard coded checkpoints of the test network.
+	TestnetCheckpoints = Checkpoints{}
+)
+
+// KnownCheckpoints returns the hard coded checkpoints of the network identified
+// by its genesis hash, or nil for unknown networks.
+func KnownCheckpoints(genesis common.Hash) Checkpoints {
+	switch genesis {
+	case MainnetGenesisHash:
+		return MainnetCheckpoints
+	case TestnetGenesisHash:
+		return TestnetCheckpoints
+	default:
+		return nil
+	}
+}
+
+// ParseCheckpoints parses a comma separated list of number=hash pairs. The value
+// "none" yields an empty (but non-nil) set, disabling checkpoint enforcement.
+func ParseCheckpoints(list string) (Checkpoints, error) {
+	checkpoints := make(Checkpoints)
+	if list = strings.TrimSpace(list); list == "none" {
+		return checkpoints, nil
+	}
+	for _, entry := range strings.Split(list, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid checkpoint %q, want number=hash", entry)
+		}
+		number, err := strconv.ParseUint(parts[0], 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint number %q: %v", parts[0], err)
+		}
+		if len(common.FromHex(parts[1])) != common.HashLength {
+			return nil, fmt.Errorf("invalid checkpoint hash %q", parts[1])
+		}
+		checkpoints[number] = common.HexToHash(parts[1])
+	}
+	return checkpoints, nil
+}
+
+// Conflicts returns whgdaer the block with the given number and hash contradicts
+// any of the checkpoints.
+func (c Checkpoints) Conflicts(number uint64, hash common.Hash) bool {
+	want, ok := c[number]
+	return ok && want != hash
+}
+
+// Highest returns the highest checkpoint at or below the given block number.
+func (c Checkpoints) Highest(limit uint64) (uint64, common.Hash, bool) {
+	var (
+		number uint64
+		hash   common.Hash
+		found  bool
+	)
+	for n, h := range c {
+		if n <= limit && (!found || n > number) {
+			number, hash, found = n, h, true
+		}
+	}
+	return number, hash, found
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import (
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+func TestParseCheckpoints(t *testing.T) {
+	hash1 := common.HexToHash("0x01")
+	hash2 := common.HexToHash("0x02")
+
+	checkpoints, err := ParseCheckpoints(" 100=" + hash1.Hex() + ", 0x200=" + hash2.Hex())
+	if err != nil {
+		t.Fatalf("failed to parse checkpoints: %v", err)
+	}
+	if len(checkpoints) != 2 || checkpoints[100] != hash1 || checkpoints[512] != hash2 {
+		t.Fatalf("checkpoint mismatch: have %v", checkpoints)
+	}
+	if !checkpoints.Conflicts(100, hash2) || checkpoints.Conflicts(100, hash1) || checkpoints.Conflicts(101, hash2) {
+		t.Errorf("conflict detection mismatch")
+	}
+	if _, _, ok := checkpoints.Highest(99); ok {
+		t.Errorf("found checkpoint below the lowest one")
+	}
+	if number, hash, ok := checkpoints.Highest(511); !ok || number != 100 || hash != hash1 {
+		t.Errorf("highest checkpoint mismatch: have %d/%x/%v, want 100/%x/true", number, hash, ok, hash1)
+	}
+	if number, hash, ok := checkpoints.Highest(1000); !ok || number != 512 || hash != hash2 {
+		t.Errorf("highest checkpoint mismatch: have %d/%x/%v, want 512/%x/true", number, hash, ok, hash2)
+	}
+	if checkpoints, err := ParseCheckpoints("none"); err != nil || checkpoints == nil || len(checkpoints) != 0 {
+		t.Errorf("disabled checkpoints mismatch: have %v/%v", checkpoints, err)
+	}
+	for _, invalid := range []string{"100", "x=" + hash1.Hex(), "100=0x1234", ""} {
+		if _, err := ParseCheckpoints(invalid); err == nil {
+			t.Errorf("invalid checkpoints %q accepted", invalid)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package plugins implements an in-tree registry through which third party
+// services can hook into a full node without modifying its construction code.
+//
+// A plugin registers itself from the init function of its package, which node
+// operators link into their build with a blank import:
+//
+//	import _ "example.com/gdachain-plugin"
+//
+// Every registered plugin is instantiated as a separate node service, so its RPC
+// APIs are exposed and its Start and Stop methods follow the node's lifecycle.
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/chainevents"
+	"github.com/gdachain/go-gdachain/internal/ethapi"
+	"github.com/gdachain/go-gdachain/node"
+)
+
+// Plugin is a third party extension of a full node.
+type Plugin interface {
+	// Name returns the unique name of the plugin, also used as the key of its
+	// section in the node's configuration.
+	Name() string
+
+	// Config returns a pointer to the configuration of the plugin, filled with its
+	// defaults. The user supplied settings are decoded into it before the plugin
+	// service is created. A nil configuration means the plugin has no settings.
+	Config() interface{}
+
+	// New creates the node service implementing the plugin.
+	New(host *Host) (node.Service, error)
+}
+
+// Host is the environment a full node provides to its plugins.
+type Host struct {
+	Context *node.ServiceContext // Service context for data directory access and service lookups
+	Backend ethapi.Backend       // Backend serving the chain, state and transaction pool APIs
+	Events  *chainevents.Feed    // Versioned chain and transaction pool events
+}
+
+var (
+	registry = make(map[string]Plugin)
+	lock     sync.RWMutex
+)
+
+// Register adds a plugin to the registry. It panics if the plugin is nil or one
+// with the same name was already registered.
+func Register(plugin Plugin) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if plugin == nil {
+		panic("plugins: Register plugin is nil")
+	}
+	name := plugin.Name()
+	if _, ok := registry[name]; ok {
+		panic("plugins: Register called twice for plugin " + name)
+	}
+	registry[name] = plugin
+}
+
+// Lookup retrieves a registered plugin by name, or nil if it's unknown.
+func Lookup(name string) Plugin {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	return registry[name]
+}
+
+// Names returns the sorted names of all the registered plugins.
+func Names() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Configure decodes the user supplied settings of a plugin into its configuration.
+// The settings are converted through their JSON representation, so the plugin's
+// configuration type needs to be JSON decodable.
+func Configure(plugin Plugin, settings map[string]interface{}) error {
+	config := plugin.Config()
+	if config == nil {
+		if len(settings) > 0 {
+			return fmt.Errorf("plugin %s takes no settings", plugin.Name())
+		}
+		return nil
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	blob, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("invalid settings for plugin %s: %v", plugin.Name(), err)
+	}
+	if err := json.Unmarshal(blob, config); err != nil {
+		return fmt.Errorf("invalid settings for plugin %s: %v", plugin.Name(), err)
+	}
+	return nil
+}
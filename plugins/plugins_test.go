@@ -0,0 +1,96 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package plugins
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/node"
+)
+
+type testConfig struct {
+	Endpoint string
+	Interval time.Duration
+	Limit    int
+}
+
+type testPlugin struct {
+	name   string
+	config *testConfig
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) Config() interface{} {
+	if p.config == nil {
+		return nil
+	}
+	return p.config
+}
+
+func (p *testPlugin) New(host *Host) (node.Service, error) { return nil, nil }
+
+// Tests that plugins can be registered and looked up, and that registering one
+// under an already taken name panics.
+func TestRegistry(t *testing.T) {
+	Register(&testPlugin{name: "registry-b"})
+	Register(&testPlugin{name: "registry-a"})
+
+	if plugin := Lookup("registry-a"); plugin == nil || plugin.Name() != "registry-a" {
+		t.Fatalf("registered plugin not found: %v", plugin)
+	}
+	if plugin := Lookup("registry-c"); plugin != nil {
+		t.Fatalf("unknown plugin found: %v", plugin)
+	}
+	var seen []string
+	for _, name := range Names() {
+		if name == "registry-a" || name == "registry-b" {
+			seen = append(seen, name)
+		}
+	}
+	if len(seen) != 2 || seen[0] != "registry-a" || seen[1] != "registry-b" {
+		t.Fatalf("plugin names mismatch: have %v, want [registry-a registry-b]", seen)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("duplicate registration didn't panic")
+		}
+	}()
+	Register(&testPlugin{name: "registry-a"})
+}
+
+// Tests that user settings are decoded into the plugin configuration, keeping the
+// defaults of the fields not set.
+func TestConfigure(t *testing.T) {
+	plugin := &testPlugin{name: "configure", config: &testConfig{Endpoint: "default", Interval: time.Second, Limit: 1}}
+
+	settings := map[string]interface{}{"Endpoint": "http://localhost", "Limit": int64(10)}
+	if err := Configure(plugin, settings); err != nil {
+		t.Fatalf("failed to configure plugin: %v", err)
+	}
+	want := testConfig{Endpoint: "http://localhost", Interval: time.Second, Limit: 10}
+	if *plugin.config != want {
+		t.Fatalf("configuration mismatch: have %+v, want %+v", *plugin.config, want)
+	}
+	if err := Configure(plugin, map[string]interface{}{"Limit": "many"}); err == nil {
+		t.Fatalf("invalid setting accepted")
+	}
+	if err := Configure(&testPlugin{name: "unconfigurable"}, settings); err == nil {
+		t.Fatalf("settings accepted for plugin without configuration")
+	}
+}
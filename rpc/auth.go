@@ -0,0 +1,165 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+// authScopeAll is the scope granting access to every protected namespace.
+const authScopeAll = "*"
+
+var (
+	errAuthMissing = errors.New("missing bearer token")
+	errAuthInvalid = errors.New("invalid bearer token")
+)
+
+// AuthConfig configures the authentication of requests to protected namespaces.
+//
+// Clients authenticate with an "Authorization: Bearer <token>" header, where the
+// token is either one of the configured static tokens, or a JWT signed with the
+// shared secret using HMAC-SHA256. A JWT lists the namespaces it grants access to
+// in its space separated "scope" claim; its "exp", "nbf" and "iat" claims are
+// enforced if present. The scope "*" grants access to all namespaces.
+type AuthConfig struct {
+	Protected []string            // Namespaces requiring authentication
+	JWTSecret []byte              // Shared secret for HS256 signed tokens (nil = JWT disabled)
+	Tokens    map[string][]string // Static bearer tokens mapped to the namespaces they grant
+}
+
+// Authenticator checks the credentials of requests against the namespaces they
+// are permitted to access.
+type Authenticator struct {
+	protected map[string]bool
+	secret    []byte
+	tokens    map[string]authScope
+}
+
+// authScope is the set of namespaces a request was granted access to.
+type authScope map[string]bool
+
+// allows reports whgdaer the scope grants access to the given namespace.
+func (s authScope) allows(namespace string) bool {
+	return s[authScopeAll] || s[namespace]
+}
+
+// newAuthScope creates a scope from a list of namespaces.
+func newAuthScope(namespaces []string) authScope {
+	scope := make(authScope)
+	for _, namespace := range namespaces {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			scope[namespace] = true
+		}
+	}
+	return scope
+}
+
+// NewAuthenticator creates a request authenticator from the given configuration.
+func NewAuthenticator(config AuthConfig) *Authenticator {
+	auth := &Authenticator{
+		protected: make(map[string]bool),
+		secret:    config.JWTSecret,
+		tokens:    make(map[string]authScope),
+	}
+	for _, namespace := range config.Protected {
+		auth.protected[namespace] = true
+	}
+	for token, namespaces := range config.Tokens {
+		auth.tokens[token] = newAuthScope(namespaces)
+	}
+	return auth
+}
+
+// SetAuthenticator configures the server to require authentication for requests
+// to protected namespaces. It must be called before the server starts serving
+// requests, and is only meaningful for the HTTP and websocket transports, which
+// carry the credentials.
+func (s *Server) SetAuthenticator(auth *Authenticator) {
+	s.auth = auth
+}
+
+// authenticate resolves the scope granted by the credentials of an HTTP request.
+func (a *Authenticator) authenticate(header http.Header) (authScope, error) {
+	value := header.Get("Authorization")
+	if value == "" {
+		return nil, errAuthMissing
+	}
+	if len(value) < 7 || !strings.EqualFold(value[:7], "bearer ") {
+		return nil, errAuthInvalid
+	}
+	token := strings.TrimSpace(value[7:])
+
+	// Check the static tokens first, comparing in constant time
+	for static, scope := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(static), []byte(token)) == 1 {
+			return scope, nil
+		}
+	}
+	// Fall back to validating the token as a JWT
+	if len(a.secret) == 0 {
+		return nil, errAuthInvalid
+	}
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return a.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, errAuthInvalid
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errAuthInvalid
+	}
+	scope, _ := claims["scope"].(string)
+	return newAuthScope(strings.Fields(scope)), nil
+}
+
+// authKey is the context key under which the granted scope is stored.
+type authKey struct{}
+
+// withAuth returns a copy of ctx carrying the scope granted by the credentials
+// of the request. If the credentials are missing or invalid, no scope is granted.
+func (s *Server) withAuth(ctx context.Context, header http.Header) context.Context {
+	if s.auth == nil {
+		return ctx
+	}
+	scope, err := s.auth.authenticate(header)
+	if err != nil && err != errAuthMissing {
+		log.Debug("Rejected RPC credentials", "err", err)
+	}
+	return context.WithValue(ctx, authKey{}, scope)
+}
+
+// authorized reports whgdaer a request within the given context may access the
+// given namespace.
+func (s *Server) authorized(ctx context.Context, namespace string) bool {
+	if s.auth == nil || !s.auth.protected[namespace] {
+		return true
+	}
+	scope, _ := ctx.Value(authKey{}).(authScope)
+	return scope.allows(namespace)
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Tests that requests to protected namespaces are only served with credentials
+// granting access to them, while other namespaces remain open.
+func TestHTTPAuthentication(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	srv := newTestServer("test", new(Service))
+	srv.SetAuthenticator(NewAuthenticator(AuthConfig{
+		Protected: []string{"test"},
+		JWTSecret: secret,
+		Tokens:    map[string][]string{"static": {"test"}},
+	}))
+	defer srv.Stop()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return token
+	}
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"no credentials", "", false},
+		{"unknown token", "bogus", false},
+		{"static token", "static", true},
+		{"scoped jwt", sign(jwt.MapClaims{"scope": "admin test"}), true},
+		{"wildcard jwt", sign(jwt.MapClaims{"scope": "*"}), true},
+		{"out of scope jwt", sign(jwt.MapClaims{"scope": "admin"}), false},
+		{"expired jwt", sign(jwt.MapClaims{"scope": "test", "exp": time.Now().Add(-time.Minute).Unix()}), false},
+	}
+	for _, tt := range tests {
+		header := make(http.Header)
+		if tt.token != "" {
+			header.Set("Authorization", "Bearer "+tt.token)
+		}
+		client, err := DialHTTPWithOptions(hs.URL, HTTPOptions{Header: header})
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", tt.name, err)
+		}
+		var result Result
+		err = client.Call(&result, "test_echo", "hello", 10, &Args{"world"})
+		switch {
+		case tt.ok && err != nil:
+			t.Errorf("%s: protected call failed: %v", tt.name, err)
+		case !tt.ok && err == nil:
+			t.Errorf("%s: protected call succeeded without access", tt.name)
+		case !tt.ok:
+			if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&unauthorizedError{}).ErrorCode() {
+				t.Errorf("%s: error mismatch: have %v", tt.name, err)
+			}
+		}
+		// Unprotected namespaces must remain reachable regardless of credentials
+		var modules map[string]string
+		if err := client.Call(&modules, "rpc_modules"); err != nil {
+			t.Errorf("%s: unprotected call failed: %v", tt.name, err)
+		}
+		client.Close()
+	}
+}
@@ -0,0 +1,744 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// CBORProtocol is the websocket sub-protocol a client has to request in order
+// to switch the connection to the CBOR encoding.
+const CBORProtocol = "cbor"
+
+const (
+	cborMaxItemSize = 32 * 1024 * 1024 // Maximum size of a single CBOR string or container
+	cborMaxDepth    = 128              // Maximum nesting depth of incoming CBOR messages
+)
+
+// CBOR major types
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborText   = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+const (
+	cborFalse     = cborSimple | 20
+	cborTrue      = cborSimple | 21
+	cborNull      = cborSimple | 22
+	cborUndefined = cborSimple | 23
+	cborFloat16   = cborSimple | 25
+	cborFloat32   = cborSimple | 26
+	cborFloat64   = cborSimple | 27
+	cborBreak     = cborSimple | 31
+
+	cborTagPosBignum = 2
+	cborTagNegBignum = 3
+)
+
+var (
+	errCBORTooLarge = errors.New("cbor item too large")
+	errCBORTooDeep  = errors.New("cbor message nested too deep")
+	errCBORSyntax   = errors.New("invalid cbor encoding")
+
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// cborCodec reads and writes RPC messages encoded with CBOR (RFC 7049) to the
+// underlying connection. The messages have the same structure as their JSON-RPC
+// counterparts, but binary data (hashes, addresses, byte blobs) and quantities
+// are sent in their native binary form instead of hex strings.
+//
+// Incoming requests are transcoded into JSON so that argument parsing is shared
+// with the JSON codec.
+type cborCodec struct {
+	*jsonCodec
+
+	decMu sync.Mutex    // guards r
+	r     *bufio.Reader // buffered reader of incoming requests
+	encMu sync.Mutex    // guards writes
+}
+
+// NewCBORCodec creates a new RPC server codec using the CBOR encoding.
+func NewCBORCodec(rwc io.ReadWriteCloser) ServerCodec {
+	return newCBORCodec(bufio.NewReader(rwc), rwc)
+}
+
+func newCBORCodec(r *bufio.Reader, rwc io.ReadWriteCloser) *cborCodec {
+	return &cborCodec{
+		jsonCodec: &jsonCodec{closed: make(chan interface{}), rw: rwc},
+		r:         r,
+	}
+}
+
+// NewAutoCodec creates a new RPC server codec which detects the encoding used
+// by the client from the first byte it sends. CBOR requests always start with
+// an array or map header, everything else is treated as JSON. It blocks until
+// the first byte is received, so it should be called on the serving goroutine.
+func NewAutoCodec(conn io.ReadWriteCloser) ServerCodec {
+	r := bufio.NewReader(conn)
+	if head, err := r.Peek(1); err == nil && isCBORContainer(head[0]) {
+		return newCBORCodec(r, conn)
+	}
+	rwc := &bufferedReadWriteCloser{Reader: r, ReadWriteCloser: conn}
+	return NewJSONCodec(rwc)
+}
+
+// isCBORContainer reports whether b is the initial byte of a CBOR array or map.
+func isCBORContainer(b byte) bool {
+	major := b & 0xe0
+	return major == cborArray || major == cborMap
+}
+
+// bufferedReadWriteCloser reads through a buffered reader that already holds
+// data peeked from the underlying connection.
+type bufferedReadWriteCloser struct {
+	*bufio.Reader
+	io.ReadWriteCloser
+}
+
+func (rwc *bufferedReadWriteCloser) Read(p []byte) (int, error) {
+	return rwc.Reader.Read(p)
+}
+
+// ReadRequestHeaders will read new requests without parsing the arguments. It will
+// return a collection of requests, an indication if these requests are in batch
+// form or an error when the incoming message could not be read/parsed.
+func (c *cborCodec) ReadRequestHeaders() ([]rpcRequest, bool, Error) {
+	c.decMu.Lock()
+	defer c.decMu.Unlock()
+
+	msg, err := decodeCBOR(c.r, 0)
+	if err != nil {
+		return nil, false, &invalidRequestError{err.Error()}
+	}
+	incomingMsg, err := json.Marshal(cborToJSON(msg))
+	if err != nil {
+		return nil, false, &invalidMessageError{err.Error()}
+	}
+	if _, ok := msg.([]interface{}); ok {
+		return parseBatchRequest(incomingMsg)
+	}
+	return parseRequest(incomingMsg)
+}
+
+// Write message to client
+func (c *cborCodec) Write(res interface{}) error {
+	c.encMu.Lock()
+	defer c.encMu.Unlock()
+
+	// Encode the whole message first, message based transports (websocket)
+	// need it delivered in a single write
+	var enc cborEncoder
+	if err := enc.encode(reflect.ValueOf(res)); err != nil {
+		return err
+	}
+	_, err := c.rw.Write(enc.buf.Bytes())
+	return err
+}
+
+// cborEncoder serializes Go values into CBOR, following the encoding/json
+// conventions for struct fields.
+type cborEncoder struct {
+	buf bytes.Buffer
+}
+
+// writeHead writes the initial byte(s) of an item with the given major type
+// and argument.
+func (e *cborEncoder) writeHead(major byte, n uint64) {
+	var b [9]byte
+	switch {
+	case n < 24:
+		e.buf.WriteByte(major | byte(n))
+		return
+	case n <= math.MaxUint8:
+		b[0], b[1] = major|24, byte(n)
+		e.buf.Write(b[:2])
+	case n <= math.MaxUint16:
+		b[0] = major | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(n))
+		e.buf.Write(b[:3])
+	case n <= math.MaxUint32:
+		b[0] = major | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(n))
+		e.buf.Write(b[:5])
+	default:
+		b[0] = major | 27
+		binary.BigEndian.PutUint64(b[1:], n)
+		e.buf.Write(b[:9])
+	}
+}
+
+func (e *cborEncoder) encodeInt(n int64) {
+	if n < 0 {
+		e.writeHead(cborNegInt, uint64(-(n + 1)))
+	} else {
+		e.writeHead(cborUint, uint64(n))
+	}
+}
+
+func (e *cborEncoder) encodeBytes(b []byte) {
+	e.writeHead(cborBytes, uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *cborEncoder) encodeString(s string) {
+	e.writeHead(cborText, uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *cborEncoder) encodeBig(n *big.Int) {
+	switch {
+	case n.Sign() >= 0 && n.BitLen() <= 64:
+		e.writeHead(cborUint, n.Uint64())
+	case n.Sign() < 0 && n.BitLen() <= 63:
+		e.encodeInt(n.Int64())
+	case n.Sign() >= 0:
+		e.writeHead(cborTag, cborTagPosBignum)
+		e.encodeBytes(n.Bytes())
+	default:
+		e.writeHead(cborTag, cborTagNegBignum)
+		e.encodeBytes(new(big.Int).Sub(new(big.Int).Neg(n), big.NewInt(1)).Bytes())
+	}
+}
+
+// encodeJSON transcodes an already JSON encoded value into CBOR.
+func (e *cborEncoder) encodeJSON(blob []byte) error {
+	if len(blob) == 0 {
+		e.buf.WriteByte(cborNull)
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(blob))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return e.encode(reflect.ValueOf(v))
+}
+
+func (e *cborEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf.WriteByte(cborNull)
+		return nil
+	}
+	// Handle the types with a dedicated binary form first
+	switch t := v.Type(); {
+	case t == rawMessageType:
+		return e.encodeJSON(v.Bytes())
+	case t.Kind() == reflect.Struct && t.ConvertibleTo(bigIntType):
+		n := v.Convert(bigIntType).Interface().(big.Int)
+		e.encodeBig(&n)
+		return nil
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Elem().ConvertibleTo(bigIntType):
+		if v.IsNil() {
+			e.buf.WriteByte(cborNull)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case t == reflect.TypeOf(json.Number("")):
+		return e.encodeNumber(json.Number(v.String()))
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf.WriteByte(cborTrue)
+		} else {
+			e.buf.WriteByte(cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.writeHead(cborUint, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		var b [9]byte
+		b[0] = cborFloat64
+		binary.BigEndian.PutUint64(b[1:], math.Float64bits(v.Float()))
+		e.buf.Write(b[:])
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteByte(cborNull)
+			return nil
+		}
+		if v.Kind() == reflect.Ptr && v.Type().Implements(jsonMarshalerType) && v.Elem().Kind() == reflect.Struct {
+			return e.encodeMarshaler(v)
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteByte(cborNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			e.encodeBytes(b)
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf.WriteByte(cborNull)
+			return nil
+		}
+		e.writeHead(cborMap, uint64(v.Len()))
+		for _, key := range v.MapKeys() {
+			if err := e.encode(key); err != nil {
+				return err
+			}
+			if err := e.encode(v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if v.Type().Implements(jsonMarshalerType) || reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+			return e.encodeMarshaler(v)
+		}
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("cbor: unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+func (e *cborEncoder) encodeNumber(n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		e.encodeInt(i)
+		return nil
+	}
+	if i, ok := new(big.Int).SetString(string(n), 10); ok {
+		e.encodeBig(i)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	return e.encode(reflect.ValueOf(f))
+}
+
+func (e *cborEncoder) encodeArray(v reflect.Value) error {
+	e.writeHead(cborArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMarshaler encodes a value with custom JSON marshalling by transcoding
+// its JSON form, keeping any fields computed during marshalling.
+func (e *cborEncoder) encodeMarshaler(v reflect.Value) error {
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		v = v.Addr()
+	}
+	blob, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	return e.encodeJSON(blob)
+}
+
+// cborField is a struct field to be encoded as a map entry.
+type cborField struct {
+	name  string
+	value reflect.Value
+}
+
+func (e *cborEncoder) encodeStruct(v reflect.Value) error {
+	fields := cborStructFields(v, nil)
+	e.writeHead(cborMap, uint64(len(fields)))
+	for _, field := range fields {
+		e.encodeString(field.name)
+		if err := e.encode(field.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cborStructFields collects the encodable fields of a struct according to
+// their json tags, flattening embedded structs like encoding/json does.
+func cborStructFields(v reflect.Value, fields []cborField) []cborField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct && !value.Type().Implements(textMarshalerType) {
+				fields = cborStructFields(value, fields)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+		fields = append(fields, cborField{name, value})
+	}
+	return fields
+}
+
+// isEmptyValue mirrors the omitempty semantics of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// decodeCBOR reads a single CBOR item from r into its generic Go form: uint64,
+// int64, *big.Int, float64, bool, nil, string, []byte, []interface{} or
+// map[interface{}]interface{}.
+func decodeCBOR(r *bufio.Reader, depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errCBORTooDeep
+	}
+	head, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := head&0xe0, head&0x1f
+
+	// Handle the simple values and floats which don't carry a length argument
+	if major == cborSimple {
+		switch head {
+		case cborFalse:
+			return false, nil
+		case cborTrue:
+			return true, nil
+		case cborNull, cborUndefined:
+			return nil, nil
+		case cborFloat16:
+			var b [2]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return nil, err
+			}
+			return float16ToFloat64(binary.BigEndian.Uint16(b[:])), nil
+		case cborFloat32:
+			var b [4]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return nil, err
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b[:]))), nil
+		case cborFloat64:
+			var b [8]byte
+			if _, err := io.ReadFull(r, b[:]); err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(b[:])), nil
+		}
+		return nil, errCBORSyntax
+	}
+	// Indefinite length strings and containers
+	if info == 31 {
+		return decodeCBORIndefinite(r, major, depth)
+	}
+	n, err := readCBORArgument(r, info)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return n, nil
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			return new(big.Int).Sub(big.NewInt(-1), new(big.Int).SetUint64(n)), nil
+		}
+		return -1 - int64(n), nil
+	case cborBytes, cborText:
+		if n > cborMaxItemSize {
+			return nil, errCBORTooLarge
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		if major == cborText {
+			return string(b), nil
+		}
+		return b, nil
+	case cborArray:
+		if n > cborMaxItemSize {
+			return nil, errCBORTooLarge
+		}
+		size := n
+		if size > 1024 {
+			size = 1024 // don't trust the announced length for preallocation
+		}
+		list := make([]interface{}, 0, size)
+		for i := uint64(0); i < n; i++ {
+			item, err := decodeCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case cborMap:
+		if n > cborMaxItemSize {
+			return nil, errCBORTooLarge
+		}
+		m := make(map[interface{}]interface{})
+		for i := uint64(0); i < n; i++ {
+			if err := decodeCBORMapEntry(r, m, depth); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case cborTag:
+		item, err := decodeCBOR(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if b, ok := item.([]byte); ok && (n == cborTagPosBignum || n == cborTagNegBignum) {
+			num := new(big.Int).SetBytes(b)
+			if n == cborTagNegBignum {
+				num.Sub(big.NewInt(-1), num)
+			}
+			return num, nil
+		}
+		return item, nil // unknown tags are ignored
+	}
+	return nil, errCBORSyntax
+}
+
+// readCBORArgument reads the length/value argument of an item header.
+func readCBORArgument(r *bufio.Reader, info byte) (uint64, error) {
+	var b [8]byte
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		if _, err := io.ReadFull(r, b[:1]); err != nil {
+			return 0, err
+		}
+		return uint64(b[0]), nil
+	case info == 25:
+		if _, err := io.ReadFull(r, b[:2]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint16(b[:2])), nil
+	case info == 26:
+		if _, err := io.ReadFull(r, b[:4]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(b[:4])), nil
+	case info == 27:
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(b[:]), nil
+	}
+	return 0, errCBORSyntax
+}
+
+func decodeCBORMapEntry(r *bufio.Reader, m map[interface{}]interface{}, depth int) error {
+	key, err := decodeCBOR(r, depth+1)
+	if err != nil {
+		return err
+	}
+	value, err := decodeCBOR(r, depth+1)
+	if err != nil {
+		return err
+	}
+	switch k := key.(type) {
+	case []byte:
+		key = string(k) // byte slices are not hashable
+	case *big.Int, []interface{}, map[interface{}]interface{}:
+		return errCBORSyntax
+	}
+	m[key] = value
+	return nil
+}
+
+// isCBORBreak consumes the break marker if it's next in the stream.
+func isCBORBreak(r *bufio.Reader) (bool, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	if b[0] == cborBreak {
+		r.ReadByte()
+		return true, nil
+	}
+	return false, nil
+}
+
+func decodeCBORIndefinite(r *bufio.Reader, major byte, depth int) (interface{}, error) {
+	switch major {
+	case cborBytes, cborText:
+		var buf []byte
+		for {
+			if done, err := isCBORBreak(r); err != nil {
+				return nil, err
+			} else if done {
+				break
+			}
+			chunk, err := decodeCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			switch c := chunk.(type) {
+			case []byte:
+				buf = append(buf, c...)
+			case string:
+				buf = append(buf, c...)
+			default:
+				return nil, errCBORSyntax
+			}
+			if len(buf) > cborMaxItemSize {
+				return nil, errCBORTooLarge
+			}
+		}
+		if major == cborText {
+			return string(buf), nil
+		}
+		return buf, nil
+	case cborArray:
+		list := []interface{}{}
+		for {
+			if done, err := isCBORBreak(r); err != nil {
+				return nil, err
+			} else if done {
+				return list, nil
+			}
+			item, err := decodeCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+	case cborMap:
+		m := make(map[interface{}]interface{})
+		for {
+			if done, err := isCBORBreak(r); err != nil {
+				return nil, err
+			} else if done {
+				return m, nil
+			}
+			if err := decodeCBORMapEntry(r, m, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, errCBORSyntax
+}
+
+// float16ToFloat64 converts an IEEE 754 half precision float.
+func float16ToFloat64(h uint16) float64 {
+	exp, mant := int((h>>10)&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// cborToJSON converts a decoded CBOR item into a value encoding/json can
+// marshal. Byte strings are converted into 0x-prefixed hex strings, which is
+// how the RPC API expects binary arguments.
+func cborToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = cborToJSON(v[i])
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = cborToJSON(value)
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestCBOREncoding(t *testing.T) {
+	type bigAlias big.Int
+
+	tests := []struct {
+		input interface{}
+		want  string
+	}{
+		{nil, "f6"},
+		{true, "f5"},
+		{uint64(0), "00"},
+		{uint64(23), "17"},
+		{uint64(24), "1818"},
+		{uint64(1000), "1903e8"},
+		{int64(-1), "20"},
+		{int64(-1000), "3903e7"},
+		{"a", "6161"},
+		{[]byte{1, 2, 3}, "43010203"},
+		{[2]byte{1, 2}, "420102"},
+		{[]uint64{1, 2}, "820102"},
+		{big.NewInt(1000), "1903e8"},
+		{(*bigAlias)(big.NewInt(1)), "01"},
+		{new(big.Int).Lsh(big.NewInt(1), 64), "c249010000000000000000"},
+		{json.RawMessage(`{"a":[1,"b"]}`), "a16161820161" + "62"},
+		{struct {
+			A uint64 `json:"a"`
+			B string `json:"b,omitempty"`
+			C bool   `json:"-"`
+			d int
+		}{A: 1}, "a1616101"},
+	}
+	for i, tt := range tests {
+		var enc cborEncoder
+		if err := enc.encode(reflect.ValueOf(tt.input)); err != nil {
+			t.Errorf("test %d: encoding failed: %v", i, err)
+			continue
+		}
+		if have := hex.EncodeToString(enc.buf.Bytes()); have != tt.want {
+			t.Errorf("test %d: encoding mismatch: have %s, want %s", i, have, tt.want)
+		}
+	}
+}
+
+func TestCBORDecoding(t *testing.T) {
+	tests := []struct {
+		input string
+		want  interface{}
+	}{
+		{"f6", nil},
+		{"f4", false},
+		{"1903e8", uint64(1000)},
+		{"3903e7", int64(-1000)},
+		{"f93e00", float64(1.5)},
+		{"6161", "a"},
+		{"43010203", []byte{1, 2, 3}},
+		{"5f4201024103ff", []byte{1, 2, 3}},
+		{"9f0102ff", []interface{}{uint64(1), uint64(2)}},
+		{"a1616101", map[interface{}]interface{}{"a": uint64(1)}},
+		{"c249010000000000000000", new(big.Int).Lsh(big.NewInt(1), 64)},
+	}
+	for i, tt := range tests {
+		blob, _ := hex.DecodeString(tt.input)
+		have, err := decodeCBOR(bufio.NewReader(bytes.NewReader(blob)), 0)
+		if err != nil {
+			t.Errorf("test %d: decoding failed: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: decoding mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	// Deeply nested and oversized items must be rejected
+	deep := bytes.Repeat([]byte{0x81}, cborMaxDepth+2)
+	if _, err := decodeCBOR(bufio.NewReader(bytes.NewReader(deep)), 0); err != errCBORTooDeep {
+		t.Errorf("nested message error mismatch: have %v, want %v", err, errCBORTooDeep)
+	}
+	huge, _ := hex.DecodeString("5bffffffffffffffff")
+	if _, err := decodeCBOR(bufio.NewReader(bytes.NewReader(huge)), 0); err != errCBORTooLarge {
+		t.Errorf("oversized item error mismatch: have %v, want %v", err, errCBORTooLarge)
+	}
+}
+
+func TestCBORToJSON(t *testing.T) {
+	blob, _ := hex.DecodeString("a2666d6574686f646c6764615f676574426c6f636b66706172616d738242abcdf5")
+	msg, err := decodeCBOR(bufio.NewReader(bytes.NewReader(blob)), 0)
+	if err != nil {
+		t.Fatalf("decoding failed: %v", err)
+	}
+	have, err := json.Marshal(cborToJSON(msg))
+	if err != nil {
+		t.Fatalf("json encoding failed: %v", err)
+	}
+	if want := `{"method":"gda_getBlock","params":["0xabcd",true]}`; string(have) != want {
+		t.Errorf("json mismatch: have %s, want %s", have, want)
+	}
+}
@@ -593,11 +593,20 @@ func (c *Client) handleNotification(msg *jsonrpcMessage) {
 	var subResult struct {
 		ID     string          `json:"subscription"`
 		Result json.RawMessage `json:"result"`
+		Error  *jsonError      `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Params, &subResult); err != nil {
 		log.Debug(fmt.Sprint("dropping invalid subscription message: ", msg))
 		return
 	}
+	// Subscriptions failed by the server end with the reported error
+	if subResult.Error != nil {
+		if sub := c.subs[subResult.ID]; sub != nil {
+			delete(c.subs, subResult.ID)
+			sub.quitWithError(subResult.Error, false)
+		}
+		return
+	}
 	if c.subs[subResult.ID] != nil {
 		c.subs[subResult.ID].deliver(subResult.Result)
 	}
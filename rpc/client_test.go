@@ -251,6 +251,31 @@ func TestClientSubscribe(t *testing.T) {
 	}
 }
 
+// Tests that subscriptions failed by the server end with the reported error, both
+// before and after their activation.
+func TestClientSubscriptionFailure(t *testing.T) {
+	server := newTestServer("gda", new(NotificationTestService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	for _, immediate := range []bool{true, false} {
+		nc := make(chan int)
+		sub, err := client.gdaSubscribe(context.Background(), nc, "failingSubscription", immediate)
+		if err != nil {
+			t.Fatal("can't subscribe:", err)
+		}
+		select {
+		case err := <-sub.Err():
+			if err == nil || err.Error() != "subscription failed" {
+				t.Fatalf("immediate %v: error mismatch: have %v, want %q", immediate, err, "subscription failed")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("immediate %v: subscription not failed within 1s", immediate)
+		}
+	}
+}
+
 func TestClientSubscribeCustomNamespace(t *testing.T) {
 	namespace := "custom"
 	server := newTestServer(namespace, new(NotificationTestService))
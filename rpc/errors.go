@@ -64,3 +64,42 @@ type shutdownError struct{}
 func (e *shutdownError) ErrorCode() int { return -32000 }
 
 func (e *shutdownError) Error() string { return "server is shutting down" }
+
+// issued when a new connection or subscription is attempted while the server is draining.
+type drainingError struct{}
+
+func (e *drainingError) ErrorCode() int { return -32000 }
+
+func (e *drainingError) Error() string { return "server is draining" }
+
+// issued when a method call exceeds its configured execution timeout.
+type timeoutError struct{ method string }
+
+func (e *timeoutError) ErrorCode() int { return -32002 }
+
+func (e *timeoutError) Error() string { return fmt.Sprintf("request %s timed out", e.method) }
+
+// issued when the maximum number of concurrently executing requests is reached.
+type overloadError struct{}
+
+func (e *overloadError) ErrorCode() int { return -32005 }
+
+func (e *overloadError) Error() string { return "too many requests in flight" }
+
+// issued when a batch contains more requests than permitted.
+type batchTooLargeError struct{ size, limit int }
+
+func (e *batchTooLargeError) ErrorCode() int { return -32600 }
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch too large: %d requests, limit %d", e.size, e.limit)
+}
+
+// issued when a request to a protected namespace lacks valid credentials.
+type unauthorizedError struct{ namespace string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized access to namespace %s", e.namespace)
+}
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -44,6 +45,7 @@ var nullAddr, _ = net.ResolveTCPAddr("tcp", "127.0.0.1:0")
 type httpConn struct {
 	client    *http.Client
 	req       *http.Request
+	compress  bool // Whgdaer request bodies are gzip compressed
 	closeOnce sync.Once
 	closed    chan struct{}
 }
@@ -66,19 +68,44 @@ func (hc *httpConn) Close() error {
 	return nil
 }
 
+// HTTPOptions configures the transport of an RPC client connecting over HTTP.
+type HTTPOptions struct {
+	Client   *http.Client // HTTP client to send the requests with (nil = default client)
+	Compress bool         // Whgdaer to gzip compress the request bodies
+	Header   http.Header  // Extra headers to send with each request (e.g. Authorization)
+}
+
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+	return DialHTTPWithOptions(endpoint, HTTPOptions{Client: client})
+}
+
+// DialHTTPWithOptions creates a new RPC client that connects to an RPC server over
+// HTTP using the provided transport options. Compressed responses are requested
+// and decoded transparently by the default HTTP transport.
+func DialHTTPWithOptions(endpoint string, opts HTTPOptions) (*Client, error) {
 	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", contentType)
-
+	for key, values := range opts.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if opts.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	client := opts.Client
+	if client == nil {
+		client = new(http.Client)
+	}
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
-		return &httpConn{client: client, req: req, closed: make(chan struct{})}, nil
+		return &httpConn{client: client, req: req, compress: opts.Compress, closed: make(chan struct{})}, nil
 	})
 }
 
@@ -124,6 +151,17 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	if err != nil {
 		return nil, err
 	}
+	if hc.compress {
+		buf := new(bytes.Buffer)
+		gz := gzip.NewWriter(buf)
+		if _, err := gz.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
 	req := hc.req.WithContext(ctx)
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
@@ -158,32 +196,75 @@ func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Permit dumb empty requests for remote health-checks (AWS)
+	// Permit dumb empty requests for remote health-checks (AWS), reporting the
+	// server unavailable while draining so load balancers take it out of rotation
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
+		if srv.Draining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		return
+	}
+	if srv.Draining() {
+		w.Header().Set("Connection", "close")
+		http.Error(w, (&drainingError{}).Error(), http.StatusServiceUnavailable)
 		return
 	}
-	if code, err := validateRequest(r); err != nil {
+	limit := srv.maxRequestSize()
+	if code, err := validateRequest(r, limit); err != nil {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	// Decompress the request body if needed, limiting the decompressed size too
+	// so compressed payloads cannot be used to bypass the request size limit
+	var body io.ReadCloser = r.Body
+	if strings.EqualFold(r.Header.Get("content-encoding"), "gzip") {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	body = http.MaxBytesReader(w, body, limit)
+
+	// Compress the response if the client supports it
+	var out io.Writer = w
+	w.Header().Set("content-type", contentType)
+	if strings.Contains(r.Header.Get("accept-encoding"), "gzip") {
+		w.Header().Set("content-encoding", "gzip")
+		w.Header().Add("vary", "accept-encoding")
+
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
 	// All checks passed, create a codec that reads direct from the request body
 	// untilEOF and writes the response to w and order the server to process a
 	// single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	codec := NewJSONCodec(&httpReadWriteNopCloser{body, out})
 	defer codec.Close()
 
-	w.Header().Set("content-type", contentType)
-	srv.ServeSingleRequest(codec, OptionMethodInvocation)
+	ctx := srv.withAuth(withConsumer(context.Background(), r.Header), r.Header)
+	srv.ServeSingleRequestContext(ctx, codec, OptionMethodInvocation)
+}
+
+// maxRequestSize returns the maximum permitted size of an HTTP request body.
+func (srv *Server) maxRequestSize() int64 {
+	if srv.limits.MaxRequestSize > 0 {
+		return srv.limits.MaxRequestSize
+	}
+	return maxHTTPRequestContentLength
 }
 
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
-func validateRequest(r *http.Request) (int, error) {
+func validateRequest(r *http.Request, limit int64) (int, error) {
 	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
 		return http.StatusMethodNotAllowed, errors.New("method not allowed")
 	}
-	if r.ContentLength > maxHTTPRequestContentLength {
-		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxHTTPRequestContentLength)
+	if r.ContentLength > limit {
+		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, limit)
 		return http.StatusRequestEntityTooLarge, err
 	}
 	mt, _, err := mime.ParseMediaType(r.Header.Get("content-type"))
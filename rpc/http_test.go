@@ -17,6 +17,9 @@
 package rpc
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -48,7 +51,111 @@ func TestHTTPErrorResponseWithValidRequest(t *testing.T) {
 func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expected int) {
 	request := httptest.NewRequest(method, "http://url.com", strings.NewReader(body))
 	request.Header.Set("content-type", contentType)
-	if code, _ := validateRequest(request); code != expected {
+	if code, _ := validateRequest(request, maxHTTPRequestContentLength); code != expected {
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestHTTPDraining(t *testing.T) {
+	srv := NewServer()
+
+	// Health checks should succeed until the server is drained
+	recorder := httptest.NewRecorder()
+	srv.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://url.com", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("health check response code should be %d not %d", http.StatusOK, recorder.Code)
+	}
+	srv.Drain()
+
+	recorder = httptest.NewRecorder()
+	srv.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://url.com", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("health check response code should be %d not %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+	request := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	request.Header.Set("content-type", contentType)
+
+	recorder = httptest.NewRecorder()
+	srv.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request response code should be %d not %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+}
+
+// Tests that gzip compressed requests are accepted and responses compressed if
+// the client supports it.
+func TestHTTPCompression(t *testing.T) {
+	srv := newTestServer("test", new(Service))
+	defer srv.Stop()
+
+	hs := httptest.NewServer(srv)
+	defer hs.Close()
+
+	// Ensure a compressing client can talk to the server transparently
+	client, err := DialHTTPWithOptions(hs.URL, HTTPOptions{Compress: true})
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("compressed call failed: %v", err)
+	}
+	if result.String != "hello" || result.Int != 10 || result.Args.S != "world" {
+		t.Fatalf("compressed call result mismatch: %+v", result)
+	}
+	// Ensure the response itself is compressed when requested
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	gz.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	gz.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "http://url.com", buf)
+	request.Header.Set("content-type", contentType)
+	request.Header.Set("content-encoding", "gzip")
+	request.Header.Set("accept-encoding", "gzip")
+
+	recorder := httptest.NewRecorder()
+	srv.ServeHTTP(recorder, request)
+	if enc := recorder.Header().Get("content-encoding"); enc != "gzip" {
+		t.Fatalf("response encoding mismatch: have %q, want %q", enc, "gzip")
+	}
+	reader, err := gzip.NewReader(recorder.Body)
+	if err != nil {
+		t.Fatalf("failed to open compressed response: %v", err)
+	}
+	blob, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decompress response: %v", err)
+	}
+	if !strings.Contains(string(blob), `"test"`) {
+		t.Fatalf("unexpected response: %s", blob)
+	}
+}
+
+// Tests that the request size limit applies to the decompressed request body.
+func TestHTTPCompressedSizeLimit(t *testing.T) {
+	srv := newTestServer("test", new(Service))
+	srv.SetLimits(Limits{MaxRequestSize: 1024})
+	defer srv.Stop()
+
+	// Compress a request well beyond the limit into a much smaller payload
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	gz.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["` + strings.Repeat("a", 4096) + `",1,null]}`))
+	gz.Close()
+
+	if buf.Len() >= 1024 {
+		t.Fatalf("compressed request too large for test: %d bytes", buf.Len())
+	}
+	request := httptest.NewRequest(http.MethodPost, "http://url.com", buf)
+	request.Header.Set("content-type", contentType)
+	request.Header.Set("content-encoding", "gzip")
+
+	recorder := httptest.NewRecorder()
+	srv.ServeHTTP(recorder, request)
+	if strings.Contains(recorder.Body.String(), strings.Repeat("a", 4096)) {
+		t.Fatalf("oversized decompressed request was served")
+	}
+}
@@ -30,7 +30,8 @@ func CreateIPCListener(endpoint string) (net.Listener, error) {
 	return ipcListen(endpoint)
 }
 
-// ServeListener accepts connections on l, serving JSON-RPC on them.
+// ServeListener accepts connections on l, serving JSON-RPC on them. Clients may
+// opt into the CBOR encoding by sending their first request in CBOR.
 func (srv *Server) ServeListener(l net.Listener) error {
 	for {
 		conn, err := l.Accept()
@@ -38,7 +39,9 @@ func (srv *Server) ServeListener(l net.Listener) error {
 			return err
 		}
 		log.Trace(fmt.Sprint("accepted conn", conn.RemoteAddr()))
-		go srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
+		go func(conn net.Conn) {
+			srv.ServeCodec(NewAutoCodec(conn), OptionMethodInvocation|OptionSubscriptions)
+		}(conn)
 	}
 }
 
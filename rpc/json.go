@@ -21,6 +21,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -67,12 +69,23 @@ type jsonSubscription struct {
 	Result       interface{} `json:"result,omitempty"`
 }
 
+type jsonSubscriptionError struct {
+	Subscription string    `json:"subscription"`
+	Error        jsonError `json:"error"`
+}
+
 type jsonNotification struct {
 	Version string           `json:"jsonrpc"`
 	Method  string           `json:"method"`
 	Params  jsonSubscription `json:"params"`
 }
 
+type jsonErrorNotification struct {
+	Version string                `json:"jsonrpc"`
+	Method  string                `json:"method"`
+	Params  jsonSubscriptionError `json:"params"`
+}
+
 // jsonCodec reads and writes JSON-RPC messages to the underlying connection. It
 // also has support for parsing arguments and serializing (result) objects.
 type jsonCodec struct {
@@ -333,6 +346,13 @@ func (c *jsonCodec) CreateNotification(subid, namespace string, event interface{
 		Params: jsonSubscription{Subscription: subid, Result: event}}
 }
 
+// CreateErrorNotification will create a JSON-RPC notification ending the given
+// subscription with an error.
+func (c *jsonCodec) CreateErrorNotification(subid, namespace string, err Error) interface{} {
+	return &jsonErrorNotification{Version: jsonrpcVersion, Method: namespace + notificationMethodSuffix,
+		Params: jsonSubscriptionError{Subscription: subid, Error: jsonError{Code: err.ErrorCode(), Message: err.Error()}}}
+}
+
 // Write message to client
 func (c *jsonCodec) Write(res interface{}) error {
 	c.encMu.Lock()
@@ -353,3 +373,26 @@ func (c *jsonCodec) Close() {
 func (c *jsonCodec) Closed() <-chan interface{} {
 	return c.closed
 }
+
+// RemoteAddr returns the address of the remote end of the connection, or an
+// empty string if the transport doesn't have one.
+func (c *jsonCodec) RemoteAddr() string {
+	return remoteAddr(c.rw)
+}
+
+// remoteAddr extracts the remote address from a connection.
+func remoteAddr(rw io.ReadWriteCloser) string {
+	switch conn := rw.(type) {
+	case *bufferedReadWriteCloser:
+		return remoteAddr(conn.ReadWriteCloser)
+	case interface {
+		Request() *http.Request
+	}:
+		// Websocket connections report their origin as the remote address,
+		// use the address of the upgrade request instead
+		return conn.Request().RemoteAddr
+	case net.Conn:
+		return conn.RemoteAddr().String()
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Limits restricts the resources a server may spend on serving requests, so that
+// a few expensive or stuck calls cannot exhaust the node.
+type Limits struct {
+	// MethodTimeouts maps method names to the maximum time their execution may
+	// take. Entries may name a single method (debug_traceTransaction), all the
+	// methods of a namespace (debug_*) or all methods (*), the most specific
+	// matching entry being used.
+	MethodTimeouts map[string]time.Duration
+
+	// MaxConcurrent is the maximum number of method calls executing at the same
+	// time across all connections, excess requests being rejected (0 = unlimited).
+	// Calls that timed out keep their slot until they actually return.
+	MaxConcurrent int
+
+	// MaxBatchSize is the maximum number of requests in a batch (0 = unlimited).
+	MaxBatchSize int
+
+	// MaxRequestSize is the maximum size in bytes of an HTTP request body, after
+	// decompression (0 = default of 128KB).
+	MaxRequestSize int64
+}
+
+// SetLimits configures the resource limits of the server. It must be called
+// before the server starts serving requests.
+func (s *Server) SetLimits(limits Limits) {
+	s.limits = limits
+	s.inflight = nil
+	if limits.MaxConcurrent > 0 {
+		s.inflight = make(chan struct{}, limits.MaxConcurrent)
+	}
+}
+
+// methodTimeout returns the execution timeout configured for a method, or zero
+// if its execution time is not limited.
+func (l *Limits) methodTimeout(service, method string) time.Duration {
+	if len(l.MethodTimeouts) == 0 {
+		return 0
+	}
+	for _, key := range []string{service + serviceMethodSeparator + method, service + serviceMethodSeparator + "*", "*"} {
+		if timeout, ok := l.MethodTimeouts[key]; ok {
+			return timeout
+		}
+	}
+	return 0
+}
+
+// invoke executes a method callback within the configured concurrency limit. If
+// the call is subject to a timeout (carried by ctx), a response is returned as
+// soon as the context expires, leaving the callback to finish in the background.
+func (s *Server) invoke(ctx context.Context, req *serverRequest, arguments []reflect.Value, timed bool) ([]reflect.Value, Error) {
+	if s.inflight != nil {
+		select {
+		case s.inflight <- struct{}{}:
+		default:
+			return nil, &overloadError{}
+		}
+	}
+	release := func() {
+		if s.inflight != nil {
+			<-s.inflight
+		}
+	}
+	if !timed {
+		defer release()
+		return req.callb.method.Func.Call(arguments), nil
+	}
+	done := make(chan []reflect.Value, 1)
+	go func() {
+		defer release()
+		done <- req.callb.method.Func.Call(arguments)
+	}()
+	select {
+	case reply := <-done:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, &timeoutError{req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+)
+
+// Tests that method timeouts are resolved from the most specific entry.
+func TestMethodTimeoutLookup(t *testing.T) {
+	limits := Limits{MethodTimeouts: map[string]time.Duration{
+		"debug_traceBlock": time.Second,
+		"debug_*":          time.Minute,
+		"*":                time.Hour,
+	}}
+	tests := []struct {
+		service, method string
+		timeout         time.Duration
+	}{
+		{"debug", "traceBlock", time.Second},
+		{"debug", "traceTransaction", time.Minute},
+		{"gda", "call", time.Hour},
+	}
+	for _, tt := range tests {
+		if timeout := limits.methodTimeout(tt.service, tt.method); timeout != tt.timeout {
+			t.Errorf("%s_%s: timeout mismatch: have %v, want %v", tt.service, tt.method, timeout, tt.timeout)
+		}
+	}
+	if timeout := new(Limits).methodTimeout("debug", "traceBlock"); timeout != 0 {
+		t.Errorf("unlimited server returned timeout %v", timeout)
+	}
+}
+
+// Tests that calls exceeding their timeout are aborted with a timeout error.
+func TestServerMethodTimeout(t *testing.T) {
+	server := newTestServer("test", new(Service))
+	server.SetLimits(Limits{MethodTimeouts: map[string]time.Duration{"test_sleep": 50 * time.Millisecond}})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	start := time.Now()
+	err := client.Call(nil, "test_sleep", 5*time.Second)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&timeoutError{}).ErrorCode() {
+		t.Fatalf("timeout error mismatch: have %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timed out call returned too late: %v", elapsed)
+	}
+}
+
+// Tests that calls beyond the concurrency limit are rejected while the slots are
+// taken, and accepted again once they are freed.
+func TestServerConcurrencyLimit(t *testing.T) {
+	server := newTestServer("test", new(Service))
+	server.SetLimits(Limits{MaxConcurrent: 1})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- client.Call(nil, "test_sleep", 500*time.Millisecond)
+	}()
+	for len(server.inflight) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	err := client.Call(nil, "test_noArgsRets")
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&overloadError{}).ErrorCode() {
+		t.Fatalf("overload error mismatch: have %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("slot holding call failed: %v", err)
+	}
+	if err := client.Call(nil, "test_noArgsRets"); err != nil {
+		t.Fatalf("call failed after slot freed: %v", err)
+	}
+}
+
+// Tests that batches exceeding the size limit are rejected as a whole.
+func TestServerBatchLimit(t *testing.T) {
+	server := newTestServer("test", new(Service))
+	server.SetLimits(Limits{MaxBatchSize: 1})
+	defer server.Stop()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	batch := []map[string]interface{}{
+		{"id": 1, "method": "test_noArgsRets", "jsonrpc": "2.0"},
+		{"id": 2, "method": "test_noArgsRets", "jsonrpc": "2.0"},
+	}
+	if err := json.NewEncoder(clientConn).Encode(batch); err != nil {
+		t.Fatal(err)
+	}
+	var response jsonErrResponse
+	if err := json.NewDecoder(clientConn).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error.Code != (&batchTooLargeError{}).ErrorCode() {
+		t.Fatalf("batch error code mismatch: have %d, want %d", response.Error.Code, (&batchTooLargeError{}).ErrorCode())
+	}
+}
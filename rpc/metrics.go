@@ -0,0 +1,118 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+const (
+	// consumerHeader is the HTTP header clients may set to tag their requests.
+	consumerHeader = "X-Consumer"
+
+	// apiKeyHeader is used as a fallback consumer tag if no explicit one is set.
+	apiKeyHeader = "X-API-Key"
+
+	maxConsumerTagLength = 32 // Maximum length of a consumer tag, longer ones are truncated
+	maxConsumerTags      = 64 // Maximum number of distinct consumers tracked individually
+
+	// otherConsumerTag aggregates the consumers beyond the tracked limit. It is
+	// reserved, requests tagged with it are not accounted to any consumer.
+	otherConsumerTag = "other"
+)
+
+var (
+	consumerTags   = make(map[string]struct{}) // Set of consumer tags already tracked
+	consumerTagsMu sync.Mutex
+)
+
+// consumerKey is the context key under which the consumer tag is stored.
+type consumerKey struct{}
+
+// withConsumer returns a copy of ctx tagged with the consumer retrieved from the
+// request headers. If no consumer was specified, or the reserved overflow tag
+// was used, ctx is returned unmodified.
+func withConsumer(ctx context.Context, header http.Header) context.Context {
+	tag := header.Get(consumerHeader)
+	if tag == "" {
+		tag = header.Get(apiKeyHeader)
+	}
+	if tag = sanitizeConsumerTag(tag); tag == "" || tag == otherConsumerTag {
+		return ctx
+	}
+	return context.WithValue(ctx, consumerKey{}, tag)
+}
+
+// consumerFromContext retrieves the consumer tag of a request, if any.
+func consumerFromContext(ctx context.Context) string {
+	tag, _ := ctx.Value(consumerKey{}).(string)
+	return tag
+}
+
+// sanitizeConsumerTag strips all characters from a consumer tag which are not
+// safe to be used in a metric name and limits its length.
+func sanitizeConsumerTag(tag string) string {
+	clean := make([]byte, 0, len(tag))
+	for i := 0; i < len(tag) && len(clean) < maxConsumerTagLength; i++ {
+		switch c := tag[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+			clean = append(clean, c)
+		}
+	}
+	return string(clean)
+}
+
+// trackedConsumerTag returns the tag under which the metrics of a consumer are
+// aggregated. To avoid unbounded metric registrations, consumers beyond a fixed
+// limit are all accounted under a shared tag.
+func trackedConsumerTag(tag string) string {
+	consumerTagsMu.Lock()
+	defer consumerTagsMu.Unlock()
+
+	if _, ok := consumerTags[tag]; ok {
+		return tag
+	}
+	if len(consumerTags) >= maxConsumerTags {
+		return otherConsumerTag
+	}
+	consumerTags[tag] = struct{}{}
+	return tag
+}
+
+// updateConsumerMetrics accounts a served request to the consumer the request
+// context was tagged with.
+func updateConsumerMetrics(ctx context.Context, start time.Time, failed bool) {
+	if !metrics.Enabled {
+		return
+	}
+	tag := consumerFromContext(ctx)
+	if tag == "" {
+		return
+	}
+	prefix := "rpc/consumer/" + trackedConsumerTag(tag) + "/"
+
+	metrics.GetOrRegisterMeter(prefix+"requests", nil).Mark(1)
+	metrics.GetOrRegisterTimer(prefix+"latency", nil).UpdateSince(start)
+	if failed {
+		metrics.GetOrRegisterMeter(prefix+"errors", nil).Mark(1)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestSanitizeConsumerTag(t *testing.T) {
+	tests := []struct {
+		tag, want string
+	}{
+		{"", ""},
+		{"indexer", "indexer"},
+		{"team-a_v1.2", "team-a_v1.2"},
+		{"team/a b", "teamab"},
+		{"0123456789012345678901234567890123456789", "01234567890123456789012345678901"},
+	}
+	for _, test := range tests {
+		if have := sanitizeConsumerTag(test.tag); have != test.want {
+			t.Errorf("tag %q: have %q, want %q", test.tag, have, test.want)
+		}
+	}
+}
+
+func TestConsumerFromHeaders(t *testing.T) {
+	header := make(http.Header)
+	if tag := consumerFromContext(withConsumer(context.Background(), header)); tag != "" {
+		t.Fatalf("untagged request reported consumer %q", tag)
+	}
+	header.Set(apiKeyHeader, "key")
+	if tag := consumerFromContext(withConsumer(context.Background(), header)); tag != "key" {
+		t.Fatalf("api key fallback mismatch: have %q, want %q", tag, "key")
+	}
+	header.Set(consumerHeader, "explorer")
+	if tag := consumerFromContext(withConsumer(context.Background(), header)); tag != "explorer" {
+		t.Fatalf("consumer tag mismatch: have %q, want %q", tag, "explorer")
+	}
+	header.Set(consumerHeader, otherConsumerTag)
+	if tag := consumerFromContext(withConsumer(context.Background(), header)); tag != "" {
+		t.Fatalf("reserved overflow tag accepted as consumer %q", tag)
+	}
+}
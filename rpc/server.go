@@ -24,6 +24,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gdachain/go-gdachain/log"
 	"gopkg.in/fatih/set.v0"
@@ -125,7 +126,7 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 // If singleShot is true it will process a single request, otherwise it will handle
 // requests until the codec returns an error when reading a request (in most cases
 // an EOF). It executes requests in parallel when singleShot is false.
-func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecOption) error {
+func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot bool, options CodecOption) error {
 	var pend sync.WaitGroup
 
 	defer func() {
@@ -140,7 +141,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		s.codecsMu.Unlock()
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// if the codec supports notification include a notifier that callbacks can use
@@ -154,6 +155,10 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		s.codecsMu.Unlock()
 		return &shutdownError{}
 	}
+	if !singleShot && atomic.LoadInt32(&s.draining) == 1 { // no new connections while draining
+		s.codecsMu.Unlock()
+		return &drainingError{}
+	}
 	s.codecs.Add(codec)
 	s.codecsMu.Unlock()
 
@@ -186,6 +191,14 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			}
 			return nil
 		}
+		// Reject batches exceeding the configured size limit as a whole
+		if batch && s.limits.MaxBatchSize > 0 && len(reqs) > s.limits.MaxBatchSize {
+			codec.Write(codec.CreateErrorResponse(nil, &batchTooLargeError{len(reqs), s.limits.MaxBatchSize}))
+			if singleShot {
+				return nil
+			}
+			continue
+		}
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -215,14 +228,20 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(codec, false, options)
+	s.serveRequest(context.Background(), codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
 // close the codec unless a non-recoverable error has occurred. Note, this method will return after
 // a single request has been processed!
 func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
-	s.serveRequest(codec, true, options)
+	s.ServeSingleRequestContext(context.Background(), codec, options)
+}
+
+// ServeSingleRequestContext is like ServeSingleRequest, but processes the request
+// within the given context, carrying e.g. the consumer tag of the request.
+func (s *Server) ServeSingleRequestContext(ctx context.Context, codec ServerCodec, options CodecOption) {
+	s.serveRequest(ctx, codec, true, options)
 }
 
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
@@ -239,6 +258,44 @@ func (s *Server) Stop() {
 	}
 }
 
+// Drain stops the server from accepting new connections and subscriptions, while
+// existing connections and subscriptions keep being served until Stop is called.
+func (s *Server) Drain() {
+	if atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		log.Debug("RPC Server draining initiated")
+	}
+}
+
+// Draining returns whether the server was ordered to drain.
+func (s *Server) Draining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// ConnInfo describes a connection served by the server.
+type ConnInfo struct {
+	RemoteAddr string `json:"remoteAddress"` // Address of the remote end, empty if unknown
+}
+
+// Connections returns the connections currently served, including the HTTP
+// requests in flight.
+func (s *Server) Connections() []ConnInfo {
+	s.codecsMu.Lock()
+	defer s.codecsMu.Unlock()
+
+	conns := make([]ConnInfo, 0, s.codecs.Size())
+	s.codecs.Each(func(c interface{}) bool {
+		var info ConnInfo
+		if codec, ok := c.(interface {
+			RemoteAddr() string
+		}); ok {
+			info.RemoteAddr = codec.RemoteAddr()
+		}
+		conns = append(conns, info)
+		return true
+	})
+	return conns
+}
+
 // createSubscription will call the subscription callback and returns the subscription id or error.
 func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *serverRequest) (ID, error) {
 	// subscription have as first argument the context following optional arguments
@@ -258,6 +315,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.err != nil {
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
+	if req.svcname != "" && !s.authorized(ctx, req.svcname) {
+		return codec.CreateErrorResponse(&req.id, &unauthorizedError{req.svcname}), nil
+	}
 
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
@@ -277,6 +337,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.callb.isSubscribe {
+		if s.Draining() {
+			return codec.CreateErrorResponse(&req.id, &drainingError{}), nil
+		}
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
@@ -299,6 +362,12 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
+	timeout := s.limits.methodTimeout(req.svcname, formatName(req.callb.method.Name))
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -308,7 +377,10 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	reply, err := s.invoke(ctx, req, arguments, timeout > 0)
+	if err != nil {
+		return codec.CreateErrorResponse(&req.id, err), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -316,8 +388,16 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+
+			// Retain the code and data of errors that define them
+			rpcErr, ok := e.(Error)
+			if !ok {
+				rpcErr = &callbackError{e.Error()}
+			}
+			if de, ok := e.(DataError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, rpcErr, de.ErrorData()), nil
+			}
+			return codec.CreateErrorResponse(&req.id, rpcErr), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
@@ -327,11 +407,14 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
 	var callback func()
+	start := time.Now()
 	if req.err != nil {
 		response = codec.CreateErrorResponse(&req.id, req.err)
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	_, failed := response.(*jsonErrResponse)
+	updateConsumerMetrics(ctx, start, failed)
 
 	if err := codec.Write(response); err != nil {
 		log.Error(fmt.Sprintf("%v\n", err))
@@ -350,6 +433,7 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
 	for i, req := range requests {
+		start := time.Now()
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
@@ -358,6 +442,8 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 				callbacks = append(callbacks, callback)
 			}
 		}
+		_, failed := responses[i].(*jsonErrResponse)
+		updateConsumerMetrics(ctx, start, failed)
 	}
 
 	if err := codec.Write(responses); err != nil {
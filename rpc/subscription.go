@@ -38,9 +38,12 @@ type Subscription struct {
 	ID        ID
 	namespace string
 	err       chan error // closed on unsubscribe
+	failure   error         // error ending the subscription before it was activated
+	pending   []interface{} // notifications sent before the subscription was activated
 }
 
-// Err returns a channel that is closed when the client send an unsubscribe request.
+// Err returns a channel that is closed when the client send an unsubscribe request,
+// or when the server fails the subscription.
 func (s *Subscription) Err() <-chan error {
 	return s.err
 }
@@ -75,7 +78,7 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 
 // CreateSubscription returns a new subscription that is coupled to the
 // RPC connection. By default subscriptions are inactive and notifications
-// are dropped until the subscription is marked as active. This is done
+// are queued until the subscription is marked as active. This is done
 // by the RPC server after the subscription ID is send to the client.
 func (n *Notifier) CreateSubscription() *Subscription {
 	s := &Subscription{ID: NewID(), err: make(chan error)}
@@ -88,9 +91,15 @@ func (n *Notifier) CreateSubscription() *Subscription {
 // Notify sends a notification to the client with the given data as payload.
 // If an error occurs the RPC connection is closed and the error is returned.
 func (n *Notifier) Notify(id ID, data interface{}) error {
-	n.subMu.RLock()
-	defer n.subMu.RUnlock()
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
 
+	if sub, inactive := n.inactive[id]; inactive {
+		if sub.failure == nil {
+			sub.pending = append(sub.pending, data)
+		}
+		return nil
+	}
 	sub, active := n.active[id]
 	if active {
 		notification := n.codec.CreateNotification(string(id), sub.namespace, data)
@@ -107,6 +116,42 @@ func (n *Notifier) Closed() <-chan interface{} {
 	return n.codec.Closed()
 }
 
+// Fail terminates a subscription from the server side, sending the error that
+// ended it to the client. The subscription's Err channel is closed the same way
+// as if the client unsubscribed. Subscriptions not yet activated are failed as
+// soon as their ID reaches the client.
+func (n *Notifier) Fail(id ID, err error) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	if sub, found := n.inactive[id]; found {
+		if sub.failure == nil {
+			sub.failure = err
+			close(sub.err)
+		}
+		return nil
+	}
+	sub, found := n.active[id]
+	if !found {
+		return ErrSubscriptionNotFound
+	}
+	close(sub.err)
+	delete(n.active, id)
+
+	return n.writeFailure(sub, err)
+}
+
+// writeFailure sends the error ending a subscription to the client. If an error
+// occurs the RPC connection is closed and the error is returned.
+func (n *Notifier) writeFailure(sub *Subscription, err error) error {
+	notification := n.codec.CreateErrorNotification(string(sub.ID), sub.namespace, &callbackError{err.Error()})
+	if err := n.codec.Write(notification); err != nil {
+		n.codec.Close()
+		return err
+	}
+	return nil
+}
+
 // unsubscribe a subscription.
 // If the subscription could not be found ErrSubscriptionNotFound is returned.
 func (n *Notifier) unsubscribe(id ID) error {
@@ -129,7 +174,21 @@ func (n *Notifier) activate(id ID, namespace string) {
 	defer n.subMu.Unlock()
 	if sub, found := n.inactive[id]; found {
 		sub.namespace = namespace
-		n.active[id] = sub
 		delete(n.inactive, id)
+
+		// Flush the notifications queued before activation
+		for _, data := range sub.pending {
+			if err := n.codec.Write(n.codec.CreateNotification(string(id), namespace, data)); err != nil {
+				n.codec.Close()
+				return
+			}
+		}
+		sub.pending = nil
+
+		if sub.failure != nil {
+			n.writeFailure(sub, sub.failure)
+			return
+		}
+		n.active[id] = sub
 	}
 }
@@ -19,6 +19,7 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -87,6 +88,25 @@ func (s *NotificationTestService) SomeSubscription(ctx context.Context, n, val i
 	return subscription, nil
 }
 
+// FailingSubscription ends the subscription with an error, either before its ID
+// is sent to the client or afterwards.
+func (s *NotificationTestService) FailingSubscription(ctx context.Context, immediate bool) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	if immediate {
+		notifier.Fail(subscription.ID, errors.New("subscription failed"))
+		return subscription, nil
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		notifier.Fail(subscription.ID, errors.New("subscription failed"))
+	}()
+	return subscription, nil
+}
+
 // HangSubscription blocks on s.unblockHangSubscription before
 // sending anything.
 func (s *NotificationTestService) HangSubscription(ctx context.Context, val int) (*Subscription, error) {
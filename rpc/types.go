@@ -72,8 +72,13 @@ type Server struct {
 	services serviceRegistry
 
 	run      int32
+	draining int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	limits   Limits
+	inflight chan struct{}  // Execution slots of method calls, nil if unlimited
+	auth     *Authenticator // Authentication of protected namespaces, nil if disabled
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -92,6 +97,13 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError wraps RPC errors, which carry structured data in addition to the
+// message, e.g. to let clients react to the cause of a failure.
+type DataError interface {
+	Error() string          // returns the message
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -108,6 +120,8 @@ type ServerCodec interface {
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
 	CreateNotification(id, namespace string, event interface{}) interface{}
+	// Create notification terminating a subscription with an error
+	CreateErrorNotification(id, namespace string, err Error) interface{}
 	// Write msg to client.
 	Write(msg interface{}) error
 	// Close underlying data stream
@@ -33,6 +33,7 @@ import (
 )
 
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
+// Clients requesting the "cbor" sub-protocol are served with the CBOR encoding.
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
@@ -40,7 +41,21 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 	return websocket.Server{
 		Handshake: wsHandshakeValidator(allowedOrigins),
 		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
+			// Tag the connection with the consumer and the credentials from the
+			// upgrade request
+			ctx := withConsumer(context.Background(), conn.Request().Header)
+			ctx = srv.withAuth(ctx, conn.Request().Header)
+
+			// Switch to the binary encoding if the client negotiated it
+			var codec ServerCodec
+			if wsWantsCBOR(conn.Config()) {
+				conn.PayloadType = websocket.BinaryFrame
+				codec = NewCBORCodec(conn)
+			} else {
+				codec = NewJSONCodec(conn)
+			}
+			defer codec.Close()
+			srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
@@ -81,6 +96,10 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 	f := func(cfg *websocket.Config, req *http.Request) error {
 		origin := strings.ToLower(req.Header.Get("Origin"))
 		if allowAllOrigins || origins.Has(origin) {
+			// Select the binary sub-protocol if the client offered it
+			if wsWantsCBOR(cfg) {
+				cfg.Protocol = []string{CBORProtocol}
+			}
 			return nil
 		}
 		log.Warn(fmt.Sprintf("origin '%s' not allowed on WS-RPC interface\n", origin))
@@ -90,6 +109,17 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 	return f
 }
 
+// wsWantsCBOR reports whether the client requested the CBOR sub-protocol during
+// the websocket handshake.
+func wsWantsCBOR(cfg *websocket.Config) bool {
+	for _, protocol := range cfg.Protocol {
+		if protocol == CBORProtocol {
+			return true
+		}
+	}
+	return false
+}
+
 // DialWebsocket creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint.
 //
@@ -0,0 +1,148 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sdk
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/accounts/abi/bind"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdaclient"
+	"github.com/gdachain/go-gdachain/node"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Client implements all the facade interfaces on top of an RPC connection to a
+// node, be it remote or running in the same process.
+type Client struct {
+	rpc    *rpc.Client
+	client *gdaclient.Client
+}
+
+// Dial connects to a remote node at the given URL (http, ws or IPC path).
+func Dial(rawurl string) (*Client, error) {
+	c, err := rpc.Dial(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// Attach connects to a node running in the same process, bypassing any network
+// transport. The node must already be started.
+func Attach(stack *node.Node) (*Client, error) {
+	c, err := stack.Attach()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c), nil
+}
+
+// NewClient creates a facade client over an established RPC connection.
+func NewClient(c *rpc.Client) *Client {
+	return &Client{
+		rpc:    c,
+		client: gdaclient.NewClient(c),
+	}
+}
+
+// Close terminates the underlying connection.
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+// ChainID retrieves the network identifier of the chain.
+func (c *Client) ChainID(ctx context.Context) (*big.Int, error) {
+	return c.client.NetworkID(ctx)
+}
+
+// HeadNumber retrieves the number of the current head block.
+func (c *Client) HeadNumber(ctx context.Context) (uint64, error) {
+	header, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+// HeaderByNumber retrieves a block header.
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.client.HeaderByNumber(ctx, number)
+}
+
+// BlockByHash retrieves a full block.
+func (c *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return c.client.BlockByHash(ctx, hash)
+}
+
+// BlockByNumber retrieves a full block.
+func (c *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return c.client.BlockByNumber(ctx, number)
+}
+
+// TransactionReceipt retrieves the receipt of a mined transaction.
+func (c *Client) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return c.client.TransactionReceipt(ctx, hash)
+}
+
+// BalanceAt retrieves the balance of an account at the given block.
+func (c *Client) BalanceAt(ctx context.Context, account common.Address, number *big.Int) (*big.Int, error) {
+	return c.client.BalanceAt(ctx, account, number)
+}
+
+// NonceAt retrieves the nonce of an account at the given block.
+func (c *Client) NonceAt(ctx context.Context, account common.Address, number *big.Int) (uint64, error) {
+	return c.client.NonceAt(ctx, account, number)
+}
+
+// PendingNonceAt retrieves the next nonce to use for an account.
+func (c *Client) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.client.PendingNonceAt(ctx, account)
+}
+
+// SuggestGasPrice retrieves the gas price suggested by the node.
+func (c *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.client.SuggestGasPrice(ctx)
+}
+
+// EstimateGas estimates the gas needed to execute the given call.
+func (c *Client) EstimateGas(ctx context.Context, call gdaereum.CallMsg) (uint64, error) {
+	return c.client.EstimateGas(ctx, call)
+}
+
+// SendTransaction submits a signed transaction to the node.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.client.SendTransaction(ctx, tx)
+}
+
+// WaitMined blocks until the transaction is mined, returning its receipt.
+func (c *Client) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	return bind.WaitMined(ctx, c.client, tx)
+}
+
+// FilterLogs retrieves all the logs matching the query.
+func (c *Client) FilterLogs(ctx context.Context, query gdaereum.FilterQuery) ([]types.Log, error) {
+	return c.client.FilterLogs(ctx, query)
+}
+
+// WatchLogs subscribes to the logs matching the query as they are mined.
+func (c *Client) WatchLogs(ctx context.Context, query gdaereum.FilterQuery, ch chan<- types.Log) (gdaereum.Subscription, error) {
+	return c.client.SubscribeFilterLogs(ctx, query, ch)
+}
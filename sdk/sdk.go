@@ -0,0 +1,97 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sdk is a small, stable facade for services interacting with a gdachain
+// node. It exposes a handful of narrow interfaces for reading the chain, sending
+// transactions and watching logs, backed either by a remote node over RPC or by
+// a node running in the same process.
+//
+// Downstream services should depend on this package instead of the internal
+// node packages, whose APIs may shift between releases. Only additive changes
+// are made to the interfaces defined here.
+package sdk
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// ChainReader provides read access to the blockchain and its state. A nil block
+// number selects the latest block.
+type ChainReader interface {
+	// ChainID retrieves the network identifier of the chain.
+	ChainID(ctx context.Context) (*big.Int, error)
+
+	// HeadNumber retrieves the number of the current head block.
+	HeadNumber(ctx context.Context) (uint64, error)
+
+	// HeaderByNumber retrieves a block header, returning gdaereum.NotFound if
+	// it does not exist.
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+
+	// BlockByHash retrieves a full block, returning gdaereum.NotFound if it
+	// does not exist.
+	BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error)
+
+	// BlockByNumber retrieves a full block, returning gdaereum.NotFound if it
+	// does not exist.
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+
+	// TransactionReceipt retrieves the receipt of a mined transaction, returning
+	// gdaereum.NotFound if it is not yet mined.
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+
+	// BalanceAt retrieves the balance of an account at the given block.
+	BalanceAt(ctx context.Context, account common.Address, number *big.Int) (*big.Int, error)
+
+	// NonceAt retrieves the nonce of an account at the given block.
+	NonceAt(ctx context.Context, account common.Address, number *big.Int) (uint64, error)
+}
+
+// TxSender provides everything needed to assemble, submit and follow a signed
+// transaction.
+type TxSender interface {
+	// PendingNonceAt retrieves the next nonce to use for an account, taking the
+	// transactions waiting in the pool into account.
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+
+	// SuggestGasPrice retrieves the gas price suggested by the node.
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+
+	// EstimateGas estimates the gas needed to execute the given call.
+	EstimateGas(ctx context.Context, call gdaereum.CallMsg) (uint64, error)
+
+	// SendTransaction submits a signed transaction to the node.
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+
+	// WaitMined blocks until the transaction is mined, returning its receipt,
+	// or until the context is cancelled.
+	WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error)
+}
+
+// LogWatcher provides access to contract log events.
+type LogWatcher interface {
+	// FilterLogs retrieves all the logs matching the query.
+	FilterLogs(ctx context.Context, query gdaereum.FilterQuery) ([]types.Log, error)
+
+	// WatchLogs subscribes to the logs matching the query as they are mined.
+	// Subscriptions need a stateful connection (in-process, IPC or websocket).
+	WatchLogs(ctx context.Context, query gdaereum.FilterQuery, ch chan<- types.Log) (gdaereum.Subscription, error)
+}
@@ -0,0 +1,432 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package core implements the hashing of typed structured data for signing, as
+// specified by EIP-712. Typed data lets wallets show users the individual fields
+// of an off-chain message (e.g. an exchange order) instead of an opaque hash,
+// while the domain separator binds signatures to a single application and chain.
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/common/math"
+	"github.com/gdachain/go-gdachain/crypto"
+)
+
+// domainType is the name of the struct type describing the signing domain.
+const domainType = "EIP712Domain"
+
+// maxEncodingDepth limits the nesting of struct and array values, protecting
+// against malicious recursive type definitions.
+const maxEncodingDepth = 32
+
+var (
+	errMissingDomain  = errors.New("domain type EIP712Domain undefined")
+	errMissingPrimary = errors.New("primary type undefined")
+	errTooDeep        = errors.New("typed data nested too deeply")
+
+	typeNameRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*$`)
+	sizedRegexp    = regexp.MustCompile(`^(u?int|bytes)([0-9]+)$`)
+)
+
+// TypedData is a message of typed structured data, along with the definition of
+// its types and the domain it is to be signed in.
+type TypedData struct {
+	Types       Types                  `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      TypedDataDomain        `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// Types maps struct type names to their ordered list of fields.
+type Types map[string][]Type
+
+// Type is a single named and typed field of a struct.
+type Type struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain is the signing domain of typed data, separating the signatures
+// of different applications, versions and chains. Only the set fields are part
+// of the domain, and they must all be declared in the EIP712Domain type.
+type TypedDataDomain struct {
+	Name              string                `json:"name,omitempty"`
+	Version           string                `json:"version,omitempty"`
+	ChainId           *math.HexOrDecimal256 `json:"chainId,omitempty"`
+	VerifyingContract string                `json:"verifyingContract,omitempty"`
+	Salt              string                `json:"salt,omitempty"`
+}
+
+// UnmarshalJSON decodes a domain, accepting the chain id both as a JSON number
+// and as a hex or decimal string, as wallets and dapps differ in its encoding.
+func (domain *TypedDataDomain) UnmarshalJSON(input []byte) error {
+	type plainDomain TypedDataDomain
+	var dec struct {
+		plainDomain
+		ChainId json.RawMessage `json:"chainId,omitempty"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*domain = TypedDataDomain(dec.plainDomain)
+	domain.ChainId = nil
+
+	if len(dec.ChainId) > 0 && string(dec.ChainId) != "null" {
+		text := strings.Trim(string(dec.ChainId), `"`)
+		id, ok := math.ParseBig256(text)
+		if !ok || text == "" || id.Sign() < 0 {
+			return fmt.Errorf("invalid chain id %s", dec.ChainId)
+		}
+		domain.ChainId = (*math.HexOrDecimal256)(id)
+	}
+	return nil
+}
+
+// Map returns the set fields of the domain, keyed by their EIP-712 names.
+func (domain *TypedDataDomain) Map() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if domain.Name != "" {
+		fields["name"] = domain.Name
+	}
+	if domain.Version != "" {
+		fields["version"] = domain.Version
+	}
+	if domain.ChainId != nil {
+		fields["chainId"] = (*big.Int)(domain.ChainId).String()
+	}
+	if domain.VerifyingContract != "" {
+		fields["verifyingContract"] = domain.VerifyingContract
+	}
+	if domain.Salt != "" {
+		fields["salt"] = domain.Salt
+	}
+	return fields
+}
+
+// Validate checks that the type definitions are well formed and cover both the
+// domain and the primary type.
+func (typedData *TypedData) Validate() error {
+	for name, fields := range typedData.Types {
+		if !typeNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid type name %q", name)
+		}
+		seen := make(map[string]bool)
+		for _, field := range fields {
+			if field.Name == "" || seen[field.Name] {
+				return fmt.Errorf("type %s: missing or duplicate field name %q", name, field.Name)
+			}
+			seen[field.Name] = true
+
+			if err := typedData.validateFieldType(field.Type); err != nil {
+				return fmt.Errorf("type %s, field %s: %v", name, field.Name, err)
+			}
+		}
+	}
+	if _, ok := typedData.Types[domainType]; !ok {
+		return errMissingDomain
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return errMissingPrimary
+	}
+	return nil
+}
+
+// validateFieldType checks that a field type is either a known struct, a valid
+// atomic or dynamic type, or an array of those.
+func (typedData *TypedData) validateFieldType(kind string) error {
+	kind = strings.TrimSuffix(kind, "[]")
+	if _, ok := typedData.Types[kind]; ok {
+		return nil
+	}
+	switch kind {
+	case "address", "bool", "string", "bytes":
+		return nil
+	}
+	match := sizedRegexp.FindStringSubmatch(kind)
+	if match == nil {
+		return fmt.Errorf("unknown type %q", kind)
+	}
+	size, _ := strconv.Atoi(match[2])
+	if match[1] == "bytes" {
+		if size < 1 || size > 32 {
+			return fmt.Errorf("invalid byte array size %d", size)
+		}
+		return nil
+	}
+	if size < 8 || size > 256 || size%8 != 0 {
+		return fmt.Errorf("invalid integer size %d", size)
+	}
+	return nil
+}
+
+// SignatureHash validates the typed data and returns the hash to be signed:
+//
+//	keccak256("\x19\x01" || hashStruct(domain) || hashStruct(message))
+func (typedData *TypedData) SignatureHash() ([]byte, error) {
+	if err := typedData.Validate(); err != nil {
+		return nil, err
+	}
+	domainSeparator, err := typedData.HashStruct(domainType, typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("domain: %v", err)
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// HashStruct computes the hash of a struct value of the given type.
+func (typedData *TypedData) HashStruct(primaryType string, data map[string]interface{}) (hexutil.Bytes, error) {
+	encoded, err := typedData.EncodeData(primaryType, data, 1)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// Dependencies returns the struct types referenced, directly or transitively, by
+// the given type, including the type itself.
+func (typedData *TypedData) Dependencies(primaryType string, found []string) []string {
+	for _, dep := range found {
+		if dep == primaryType {
+			return found
+		}
+	}
+	if _, ok := typedData.Types[primaryType]; !ok {
+		return found
+	}
+	found = append(found, primaryType)
+	for _, field := range typedData.Types[primaryType] {
+		found = typedData.Dependencies(strings.TrimSuffix(field.Type, "[]"), found)
+	}
+	return found
+}
+
+// EncodeType returns the canonical encoding of a struct type: the type itself
+// followed by all its dependencies sorted by name, each in the form
+// Name(type1 field1,type2 field2).
+func (typedData *TypedData) EncodeType(primaryType string) hexutil.Bytes {
+	deps := typedData.Dependencies(primaryType, nil)
+	if len(deps) > 0 {
+		sort.Strings(deps[1:])
+	}
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		buffer.WriteString(dep)
+		buffer.WriteString("(")
+		for i, field := range typedData.Types[dep] {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(field.Type)
+			buffer.WriteString(" ")
+			buffer.WriteString(field.Name)
+		}
+		buffer.WriteString(")")
+	}
+	return buffer.Bytes()
+}
+
+// TypeHash returns the hash of the canonical encoding of a struct type.
+func (typedData *TypedData) TypeHash(primaryType string) hexutil.Bytes {
+	return crypto.Keccak256(typedData.EncodeType(primaryType))
+}
+
+// EncodeData encodes a struct value of the given type as its type hash followed
+// by the 32 byte encoding of each of its fields.
+func (typedData *TypedData) EncodeData(primaryType string, data map[string]interface{}, depth int) (hexutil.Bytes, error) {
+	if depth > maxEncodingDepth {
+		return nil, errTooDeep
+	}
+	fields, ok := typedData.Types[primaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", primaryType)
+	}
+	if len(data) > len(fields) {
+		return nil, fmt.Errorf("type %s: %d values for %d fields", primaryType, len(data), len(fields))
+	}
+	buffer := bytes.NewBuffer(typedData.TypeHash(primaryType))
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("type %s: missing value for field %s", primaryType, field.Name)
+		}
+		encoded, err := typedData.encodeValue(field.Type, value, depth)
+		if err != nil {
+			return nil, fmt.Errorf("type %s, field %s: %v", primaryType, field.Name, err)
+		}
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+// encodeValue encodes a single field value into 32 bytes.
+func (typedData *TypedData) encodeValue(kind string, value interface{}, depth int) ([]byte, error) {
+	// Arrays are encoded as the hash of their concatenated element encodings
+	if strings.HasSuffix(kind, "[]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid array value %v", value)
+		}
+		var buffer bytes.Buffer
+		for _, item := range items {
+			encoded, err := typedData.encodeValue(strings.TrimSuffix(kind, "[]"), item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			buffer.Write(encoded)
+		}
+		return crypto.Keccak256(buffer.Bytes()), nil
+	}
+	// Structs are encoded as their hash
+	if _, ok := typedData.Types[kind]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid struct value %v", value)
+		}
+		encoded, err := typedData.EncodeData(kind, fields, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	return encodePrimitive(kind, value)
+}
+
+// encodePrimitive encodes an atomic or dynamic value into 32 bytes.
+func encodePrimitive(kind string, value interface{}) ([]byte, error) {
+	switch kind {
+	case "address":
+		str, ok := value.(string)
+		if !ok || !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(str).Bytes(), 32), nil
+
+	case "bool":
+		flag, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid bool %v", value)
+		}
+		if flag {
+			return math.PaddedBigBytes(common.Big1, 32), nil
+		}
+		return make([]byte, 32), nil
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid string %v", value)
+		}
+		return crypto.Keccak256([]byte(str)), nil
+
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(blob), nil
+	}
+	match := sizedRegexp.FindStringSubmatch(kind)
+	if match == nil {
+		return nil, fmt.Errorf("unknown type %q", kind)
+	}
+	size, _ := strconv.Atoi(match[2])
+
+	switch match[1] {
+	case "bytes":
+		blob, err := parseBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != size {
+			return nil, fmt.Errorf("invalid %s length %d", kind, len(blob))
+		}
+		return common.RightPadBytes(blob, 32), nil
+
+	case "uint":
+		number, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if number.Sign() < 0 || number.BitLen() > size {
+			return nil, fmt.Errorf("%s out of range: %v", kind, number)
+		}
+		return math.PaddedBigBytes(number, 32), nil
+
+	default: // int
+		number, err := parseInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		limit := new(big.Int).Lsh(common.Big1, uint(size-1))
+		if number.Cmp(limit) >= 0 || number.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("%s out of range: %v", kind, number)
+		}
+		return math.PaddedBigBytes(math.U256(number), 32), nil
+	}
+}
+
+// parseBytes interprets a hex string as a byte array.
+func parseBytes(value interface{}) ([]byte, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid bytes %v", value)
+	}
+	blob, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytes %q: %v", str, err)
+	}
+	return blob, nil
+}
+
+// parseInteger interprets a JSON number, or a decimal or hex string, as a big
+// integer. Numbers beyond the exact range of float64 must be passed as strings.
+func parseInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case float64:
+		if v > 1<<53 || v < -(1<<53) || v != float64(int64(v)) {
+			return nil, fmt.Errorf("inexact integer %v, use a string", v)
+		}
+		return big.NewInt(int64(v)), nil
+
+	case string:
+		digits := strings.TrimPrefix(v, "-")
+		number, ok := math.ParseBig256(digits)
+		if !ok || digits == "" {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		if digits != v {
+			number.Neg(number)
+		}
+		return number, nil
+	}
+	return nil, fmt.Errorf("invalid integer %v", value)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common/hexutil"
+)
+
+// mailTypedData is the example message of the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+// Tests that the example message of the EIP-712 specification hashes to the
+// reference values.
+func TestTypedDataHashing(t *testing.T) {
+	var typedData TypedData
+	if err := json.Unmarshal([]byte(mailTypedData), &typedData); err != nil {
+		t.Fatalf("failed to decode typed data: %v", err)
+	}
+	if have, want := string(typedData.EncodeType("Mail")), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; have != want {
+		t.Errorf("type encoding mismatch: have %s, want %s", have, want)
+	}
+	if have, want := typedData.TypeHash("Mail").String(), "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"; have != want {
+		t.Errorf("type hash mismatch: have %s, want %s", have, want)
+	}
+	domain, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	if have, want := domain.String(), "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"; have != want {
+		t.Errorf("domain separator mismatch: have %s, want %s", have, want)
+	}
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		t.Fatalf("failed to hash message: %v", err)
+	}
+	if have, want := message.String(), "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"; have != want {
+		t.Errorf("message hash mismatch: have %s, want %s", have, want)
+	}
+	hash, err := typedData.SignatureHash()
+	if err != nil {
+		t.Fatalf("failed to compute signature hash: %v", err)
+	}
+	if have, want := hexutil.Encode(hash), "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"; have != want {
+		t.Errorf("signature hash mismatch: have %s, want %s", have, want)
+	}
+}
+
+// Tests that malformed typed data is rejected instead of hashed.
+func TestTypedDataInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*TypedData)
+	}{
+		{"missing domain type", func(td *TypedData) { delete(td.Types, "EIP712Domain") }},
+		{"missing primary type", func(td *TypedData) { td.PrimaryType = "Letter" }},
+		{"unknown field type", func(td *TypedData) { td.Types["Mail"][2].Type = "text" }},
+		{"invalid integer size", func(td *TypedData) { td.Types["Mail"][2].Type = "uint7" }},
+		{"missing field value", func(td *TypedData) { delete(td.Message, "contents") }},
+		{"extra field value", func(td *TypedData) { td.Message["subject"] = "Hi" }},
+		{"invalid address", func(td *TypedData) { td.Message["to"].(map[string]interface{})["wallet"] = "0x1234" }},
+		{"integer out of range", func(td *TypedData) {
+			td.Types["Mail"][2].Type = "uint8"
+			td.Message["contents"] = "256"
+		}},
+	}
+	for _, tt := range tests {
+		var typedData TypedData
+		if err := json.Unmarshal([]byte(mailTypedData), &typedData); err != nil {
+			t.Fatalf("failed to decode typed data: %v", err)
+		}
+		tt.mutate(&typedData)
+		if _, err := typedData.SignatureHash(); err == nil {
+			t.Errorf("%s: invalid typed data hashed", tt.name)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package telemetry
+
+import "time"
+
+// DefaultConfig contains default settings for the telemetry reporter.
+var DefaultConfig = Config{
+	Interval: 6 * time.Hour,
+}
+
+// Config contains the configuration parameters of the telemetry reporter.
+type Config struct {
+	// Endpoint is the URL the telemetry reports are posted to. If this field is
+	// empty, no telemetry is reported.
+	Endpoint string `toml:",omitempty"`
+
+	// Interval is the time between two consecutive reports.
+	Interval time.Duration `toml:",omitempty"`
+}
@@ -0,0 +1,234 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package telemetry implements an opt-in reporter posting anonymous node usage
+// statistics to a configured endpoint.
+package telemetry
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+const (
+	// minInterval is the minimum time permitted between two reports.
+	minInterval = time.Minute
+
+	// maxErrorCategories is the maximum number of distinct error categories
+	// counted between two reports, any further ones are counted as "other".
+	maxErrorCategories = 64
+)
+
+// Report is the anonymous usage information posted to the telemetry endpoint.
+// Errors are counted by their log message, without any of the log context.
+type Report struct {
+	ID       string            `json:"id"` // Random identifier of the node installation
+	Version  string            `json:"version"`
+	OS       string            `json:"os"`
+	Arch     string            `json:"arch"`
+	Go       string            `json:"go"`
+	SyncMode string            `json:"syncMode"`
+	Peers    int               `json:"peers"`
+	MaxPeers int               `json:"maxPeers"`
+	Errors   map[string]uint64 `json:"errors"` // Errors logged since the last report, by message
+}
+
+// Reporter is a node service periodically posting telemetry reports.
+type Reporter struct {
+	config   *Config
+	id       string
+	version  string
+	syncMode string
+
+	server *p2p.Server
+	client *http.Client
+
+	errors  map[string]uint64 // Errors logged since the last report
+	lock    sync.Mutex        // Protects the error counters
+	handler log.Handler       // Root log handler wrapped for counting the errors
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a telemetry reporter. The random identifier of the installation
+// is persisted at idPath, or regenerated on every start if the path is empty.
+func New(config *Config, version, syncMode, idPath string) (*Reporter, error) {
+	if config.Interval < minInterval {
+		log.Warn("Sanitizing invalid telemetry interval", "provided", config.Interval, "updated", minInterval)
+		config.Interval = minInterval
+	}
+	id, err := loadID(idPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Reporter{
+		config:   config,
+		id:       id,
+		version:  version,
+		syncMode: syncMode,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		errors:   make(map[string]uint64),
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+// loadID loads the installation identifier from disk, generating and storing a
+// new one if none exists yet.
+func loadID(path string) (string, error) {
+	if path != "" {
+		if blob, err := ioutil.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(blob)); id != "" {
+				return id, nil
+			}
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	if path != "" {
+		if err := ioutil.WriteFile(path, []byte(id), 0600); err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the reporter (nil as it doesn't use the devp2p overlay network).
+func (r *Reporter) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// reporter (nil as it doesn't provide any user callable APIs).
+func (r *Reporter) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting to count the logged errors and to
+// post the periodic reports.
+func (r *Reporter) Start(server *p2p.Server) error {
+	r.server = server
+
+	r.handler = log.Root().GetHandler()
+	log.Root().SetHandler(log.FuncHandler(func(rec *log.Record) error {
+		if rec.Lvl <= log.LvlError {
+			r.countError(rec.Msg)
+		}
+		return r.handler.Log(rec)
+	}))
+	r.wg.Add(1)
+	go r.loop()
+
+	log.Info("Telemetry reporter started", "endpoint", r.config.Endpoint, "interval", r.config.Interval)
+	return nil
+}
+
+// Stop implements node.Service, terminating the reporter.
+func (r *Reporter) Stop() error {
+	close(r.quit)
+	r.wg.Wait()
+
+	log.Root().SetHandler(r.handler)
+	log.Info("Telemetry reporter stopped")
+	return nil
+}
+
+// countError increments the counter of an error category.
+func (r *Reporter) countError(msg string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.errors[msg]; !ok && len(r.errors) >= maxErrorCategories {
+		msg = "other"
+	}
+	r.errors[msg]++
+}
+
+// loop posts a report at every interval until termination.
+func (r *Reporter) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.post(r.report()); err != nil {
+				log.Debug("Failed to post telemetry report", "err", err)
+			}
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// report assembles a telemetry report, resetting the error counters.
+func (r *Reporter) report() *Report {
+	r.lock.Lock()
+	errors := r.errors
+	r.errors = make(map[string]uint64)
+	r.lock.Unlock()
+
+	report := &Report{
+		ID:       r.id,
+		Version:  r.version,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		Go:       runtime.Version(),
+		SyncMode: r.syncMode,
+		Errors:   errors,
+	}
+	if r.server != nil {
+		report.Peers = r.server.PeerCount()
+		report.MaxPeers = r.server.MaxPeers
+	}
+	return report
+}
+
+// post sends a report to the telemetry endpoint.
+func (r *Reporter) post(report *Report) error {
+	blob, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	res, err := r.client.Post(r.config.Endpoint, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint replied %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that reports carry the persisted installation identifier and the error
+// counters accumulated since the previous report.
+func TestReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetry-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	reports := make(chan *Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := new(Report)
+		if err := json.NewDecoder(r.Body).Decode(report); err != nil {
+			t.Errorf("failed to decode report: %v", err)
+		}
+		reports <- report
+	}))
+	defer server.Close()
+
+	path := filepath.Join(dir, "telemetry-id")
+	reporter, err := New(&Config{Endpoint: server.URL, Interval: minInterval}, "1.0.0", "fast", path)
+	if err != nil {
+		t.Fatalf("failed to create reporter: %v", err)
+	}
+	// Count more error categories than permitted
+	for i := 0; i < maxErrorCategories+2; i++ {
+		reporter.countError(string(rune('a' + i)))
+	}
+	reporter.countError("a")
+
+	if err := reporter.post(reporter.report()); err != nil {
+		t.Fatalf("failed to post report: %v", err)
+	}
+	report := <-reports
+	if report.ID != reporter.id || report.Version != "1.0.0" || report.SyncMode != "fast" {
+		t.Fatalf("report mismatch: %+v", report)
+	}
+	if len(report.Errors) != maxErrorCategories+1 || report.Errors["a"] != 2 || report.Errors["other"] != 2 {
+		t.Fatalf("error counters mismatch: %v", report.Errors)
+	}
+	if errors := reporter.report().Errors; len(errors) != 0 {
+		t.Fatalf("error counters not reset: %v", errors)
+	}
+	// Recreating the reporter must retain the installation identifier
+	again, err := New(&Config{Endpoint: server.URL}, "1.0.0", "fast", path)
+	if err != nil {
+		t.Fatalf("failed to recreate reporter: %v", err)
+	}
+	if again.id != reporter.id {
+		t.Fatalf("identifier mismatch: have %s, want %s", again.id, reporter.id)
+	}
+}
@@ -17,12 +17,16 @@
 package trie
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
 )
 
 // secureKeyPrefix is the database key prefix used to store trie node preimages.
@@ -353,3 +357,82 @@ func (db *Database) Size() common.StorageSize {
 
 	return db.nodesSize + db.preimagesSize
 }
+
+// journalNode is the journal representation of a cached node along with its
+// references. The node with the empty hash holds the root references.
+type journalNode struct {
+	Hash     common.Hash
+	Blob     []byte
+	Parents  uint64
+	Children []journalChild
+}
+
+// journalChild is a reference from a journaled node to one of its children.
+type journalChild struct {
+	Hash common.Hash
+	Refs uint64
+}
+
+// Journal writes all the nodes cached in memory along with their references
+// into w, so that the cache can be restored by LoadJournal after a restart.
+func (db *Database) Journal(w io.Writer) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	start := time.Now()
+	for hash, node := range db.nodes {
+		entry := journalNode{Hash: hash, Blob: node.blob, Parents: uint64(node.parents)}
+		for child, refs := range node.children {
+			entry.Children = append(entry.Children, journalChild{Hash: child, Refs: uint64(refs)})
+		}
+		if err := rlp.Encode(w, &entry); err != nil {
+			return err
+		}
+	}
+	log.Info("Journalled trie memory database", "nodes", len(db.nodes)-1, "size", db.nodesSize, "time", time.Since(start))
+	return nil
+}
+
+// LoadJournal restores the nodes cached in memory from a journal written by
+// Journal, reading r until its end. The memory database must be empty.
+func (db *Database) LoadJournal(r io.Reader) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if len(db.nodes) != 1 || len(db.nodes[common.Hash{}].children) != 0 {
+		return errors.New("memory database not empty")
+	}
+	start := time.Now()
+
+	nodes := make(map[common.Hash]*cachedNode)
+	size := common.StorageSize(0)
+
+	stream := rlp.NewStream(r, 0)
+	for {
+		var entry journalNode
+		if err := stream.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("invalid trie journal: %v", err)
+		}
+		node := &cachedNode{
+			blob:     entry.Blob,
+			parents:  int(entry.Parents),
+			children: make(map[common.Hash]int, len(entry.Children)),
+		}
+		for _, child := range entry.Children {
+			node.children[child.Hash] = int(child.Refs)
+		}
+		nodes[entry.Hash] = node
+		if entry.Hash != (common.Hash{}) {
+			size += common.StorageSize(common.HashLength + len(entry.Blob))
+		}
+	}
+	if _, ok := nodes[common.Hash{}]; !ok {
+		return errors.New("invalid trie journal: missing root references")
+	}
+	db.nodes, db.nodesSize = nodes, size
+
+	log.Info("Loaded trie memory database journal", "nodes", len(nodes)-1, "size", size, "time", time.Since(start))
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// Tests that the memory database can be journalled and restored into a fresh
+// database, keeping both the node contents and their references.
+func TestDatabaseJournal(t *testing.T) {
+	diskdb, _ := gdadb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+
+	trie, _ := New(common.Hash{}, triedb)
+	for i := 0; i < 100; i++ {
+		trie.Update([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	root, err := trie.Commit(nil)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	triedb.Reference(root, common.Hash{})
+
+	journal := new(bytes.Buffer)
+	if err := triedb.Journal(journal); err != nil {
+		t.Fatalf("failed to journal database: %v", err)
+	}
+	restored := NewDatabase(diskdb)
+	if err := restored.LoadJournal(journal); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	if have, want := restored.Size(), triedb.Size(); have != want {
+		t.Errorf("size mismatch: have %v, want %v", have, want)
+	}
+	// Nothing was written to disk, so the trie must be served from memory
+	trie, err = New(root, restored)
+	if err != nil {
+		t.Fatalf("failed to open restored trie: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if have, want := trie.Get([]byte(fmt.Sprintf("key-%d", i))), []byte(fmt.Sprintf("value-%d", i)); !bytes.Equal(have, want) {
+			t.Fatalf("value %d mismatch: have %s, want %s", i, have, want)
+		}
+	}
+	// Dropping the root reference must garbage collect the entire trie
+	restored.Dereference(root, common.Hash{})
+	if nodes := restored.Nodes(); len(nodes) != 0 {
+		t.Errorf("dangling nodes after dereference: %d", len(nodes))
+	}
+	// Journals may only be loaded into empty databases
+	if err := triedb.LoadJournal(new(bytes.Buffer)); err == nil {
+		t.Errorf("journal loaded into non-empty database")
+	}
+}
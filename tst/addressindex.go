@@ -0,0 +1,503 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/consensus/misc"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+const (
+	// addressIndexName is the name of the address index, namespacing its data in
+	// the chain database.
+	addressIndexName = "addresses"
+
+	// addressPageSize is the number of index entries returned in a single page of
+	// an address' activity.
+	addressPageSize = 100
+)
+
+var (
+	addressIndexHeadKey  = []byte("h") // addressIndexHeadKey -> number of the last indexed block (uint64 big endian)
+	addressJournalPrefix = []byte("j") // addressJournalPrefix + num (uint64 big endian) -> entry counts of the addresses touched, prior to the block
+	addressCountSuffix   = []byte("n") // address + addressCountSuffix -> number of entries of the address (uint64 big endian)
+
+	errAddressIndexDisabled = errors.New("address index not enabled")
+)
+
+// addressEntry is a single item of the activity of an address, as stored in the
+// address index. The entries of an address are numbered from zero upwards in
+// the order their blocks were indexed in.
+type addressEntry struct {
+	Number   uint64
+	TxHash   common.Hash
+	TxIndex  uint64
+	Internal bool // Whether the entry is a value transfer made during execution
+	From     common.Address
+	To       common.Address
+	Value    *big.Int
+}
+
+// addressCount is the number of entries an address had before a block was
+// indexed, journaled to roll back the index on reorgs.
+type addressCount struct {
+	Address common.Address
+	Count   uint64
+}
+
+// addressEntryKey = address + index (uint64 big endian)
+func addressEntryKey(address common.Address, index uint64) []byte {
+	key := make([]byte, common.AddressLength+8)
+	copy(key, address[:])
+	binary.BigEndian.PutUint64(key[common.AddressLength:], index)
+	return key
+}
+
+// addressCountKey = address + addressCountSuffix
+func addressCountKey(address common.Address) []byte {
+	return append(append([]byte{}, address[:]...), addressCountSuffix...)
+}
+
+// addressJournalKey = addressJournalPrefix + num (uint64 big endian)
+func addressJournalKey(number uint64) []byte {
+	key := make([]byte, len(addressJournalPrefix)+8)
+	copy(key, addressJournalPrefix)
+	binary.BigEndian.PutUint64(key[len(addressJournalPrefix):], number)
+	return key
+}
+
+// readUint64 retrieves a big endian number from the database, or false if it's
+// missing.
+func readUint64(db gdadb.Database, key []byte) (uint64, bool) {
+	data, _ := db.Get(key)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// encodeUint64 returns the big endian encoding of a number.
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
+
+// addressIndexer is a chain indexer backend maintaining the index of the
+// transactions sent and received by every address, along with the value
+// transfers they took part in during the execution of transactions. It indexes
+// sections of a single block, so blocks are indexed as soon as they're imported.
+//
+// Internal transfers are found by re-executing the block, which is only possible
+// while its parent state is still available. Blocks without state, such as those
+// imported by a fast sync, only have their transactions indexed.
+type addressIndexer struct {
+	db    gdadb.Database // Namespaced database of the address index
+	chain *core.BlockChain
+
+	number  uint64                             // Number of the block being indexed
+	entries map[common.Address][]*addressEntry // Entries of the block being indexed
+	order   []common.Address                   // Addresses in the order they were touched in
+	err     error                              // Failure while processing the block
+}
+
+// newAddressIndex returns a chain indexer maintaining the address index.
+func newAddressIndex(chainDb gdadb.Database, chain *core.BlockChain) (*core.ChainIndexer, error) {
+	return core.NewRegisteredIndexer(chainDb, core.IndexerSpec{
+		Name:        addressIndexName,
+		SectionSize: 1,
+		New: func(db gdadb.Database) (core.ChainIndexerBackend, error) {
+			return &addressIndexer{db: db, chain: chain}, nil
+		},
+	})
+}
+
+// Reset implements core.ChainIndexerBackend, starting the indexing of a new
+// block. Blocks at or above it that were already indexed (i.e. the indexer is
+// reprocessing them after a reorg) are removed from the index first.
+func (b *addressIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.number, b.entries, b.order, b.err = section, make(map[common.Address][]*addressEntry), nil, nil
+	return b.rollback(section)
+}
+
+// rollback removes the entries of all the indexed blocks from number onwards.
+func (b *addressIndexer) rollback(number uint64) error {
+	head, ok := readUint64(b.db, addressIndexHeadKey)
+	if !ok || head < number {
+		return nil
+	}
+	batch := b.db.NewBatch()
+	for n := head; n >= number; n-- {
+		blob, err := b.db.Get(addressJournalKey(n))
+		if err != nil {
+			return fmt.Errorf("missing address index journal of block %d", n)
+		}
+		var journal []addressCount
+		if err := rlp.DecodeBytes(blob, &journal); err != nil {
+			return err
+		}
+		for _, count := range journal {
+			batch.Put(addressCountKey(count.Address), encodeUint64(count.Count))
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if number > 0 {
+		batch.Put(addressIndexHeadKey, encodeUint64(number-1))
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Database batches can't delete, so drop the head marker and the stale
+	// journals only after the restored counters are written. Rolling back
+	// again after a crash in between re-applies the same journals, so any
+	// partial cleanup is harmless.
+	if number == 0 {
+		if err := b.db.Delete(addressIndexHeadKey); err != nil {
+			return err
+		}
+	}
+	for n := number; n <= head; n++ {
+		if err := b.db.Delete(addressJournalKey(n)); err != nil {
+			return err
+		}
+	}
+	log.Debug("Rolled back address index", "from", head, "to", number)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, collecting the entries of the
+// transactions of a block.
+func (b *addressIndexer) Process(header *types.Header) {
+	block := b.chain.GetBlock(header.Hash(), header.Number.Uint64())
+	if block == nil {
+		b.err = fmt.Errorf("missing block #%d [%x]", header.Number, header.Hash())
+		return
+	}
+	var (
+		receipts = b.chain.GetReceiptsByHash(block.Hash())
+		internal = b.transfers(block)
+		signer   = types.MakeSigner(b.chain.Config(), block.Number())
+	)
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			b.err = err
+			return
+		}
+		entry := &addressEntry{
+			Number:  block.NumberU64(),
+			TxHash:  tx.Hash(),
+			TxIndex: uint64(i),
+			From:    from,
+			Value:   tx.Value(),
+		}
+		if to := tx.To(); to != nil {
+			entry.To = *to
+		} else if i < len(receipts) {
+			entry.To = receipts[i].ContractAddress
+		}
+		b.add(entry)
+
+		if internal != nil {
+			for _, transfer := range internal[i] {
+				b.add(&addressEntry{
+					Number:   block.NumberU64(),
+					TxHash:   tx.Hash(),
+					TxIndex:  uint64(i),
+					Internal: true,
+					From:     transfer.from,
+					To:       transfer.to,
+					Value:    transfer.value,
+				})
+			}
+		}
+	}
+}
+
+// add appends an entry to the activity of both of its parties.
+func (b *addressIndexer) add(entry *addressEntry) {
+	for _, addr := range []common.Address{entry.From, entry.To} {
+		entries, ok := b.entries[addr]
+		if !ok {
+			b.order = append(b.order, addr)
+		}
+		b.entries[addr] = append(entries, entry)
+
+		if entry.From == entry.To {
+			break
+		}
+	}
+}
+
+// transfers re-executes a block, returning the internal value transfers made by
+// each of its transactions, or nil if the parent state is not available.
+func (b *addressIndexer) transfers(block *types.Block) [][]*internalTransfer {
+	if block.NumberU64() == 0 || len(block.Transactions()) == 0 {
+		return nil
+	}
+	parent := b.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil
+	}
+	statedb, err := b.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil
+	}
+	config := b.chain.Config()
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	var (
+		header    = block.Header()
+		gp        = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas   uint64
+		transfers = make([][]*internalTransfer, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		tracer := new(transferTracer)
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if _, _, err := core.ApplyTransaction(config, b.chain, nil, gp, statedb, header, tx, &usedGas, vm.Config{Debug: true, Tracer: tracer}); err != nil {
+			log.Warn("Failed to trace transfers for address index", "number", block.Number(), "hash", block.Hash(), "tx", tx.Hash(), "err", err)
+			return nil
+		}
+		transfers[i] = tracer.result
+	}
+	return transfers
+}
+
+// Commit implements core.ChainIndexerBackend, appending the entries of the block
+// to the activity of their addresses and journaling the previous entry counts.
+func (b *addressIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	var (
+		batch   = b.db.NewBatch()
+		journal = make([]addressCount, 0, len(b.order))
+	)
+	for _, addr := range b.order {
+		count, _ := readUint64(b.db, addressCountKey(addr))
+		journal = append(journal, addressCount{Address: addr, Count: count})
+
+		for _, entry := range b.entries[addr] {
+			blob, err := rlp.EncodeToBytes(entry)
+			if err != nil {
+				return err
+			}
+			batch.Put(addressEntryKey(addr, count), blob)
+			count++
+		}
+		batch.Put(addressCountKey(addr), encodeUint64(count))
+	}
+	blob, err := rlp.EncodeToBytes(journal)
+	if err != nil {
+		return err
+	}
+	batch.Put(addressJournalKey(b.number), blob)
+	batch.Put(addressIndexHeadKey, encodeUint64(b.number))
+	return batch.Write()
+}
+
+// internalTransfer is a value transfer made during the execution of a
+// transaction, by a message call, contract creation or self-destruct.
+type internalTransfer struct {
+	from, to common.Address
+	value    *big.Int
+}
+
+// transferFrame is the state of a call frame traced by a transferTracer.
+type transferFrame struct {
+	transfers []*internalTransfer // Transfers of this frame and its successful subcalls
+	calling   bool                // Whether a subcall was issued and its result is pending
+	creating  bool                // Whether the pending subcall creates a contract
+	call      *internalTransfer   // Transfer of the pending subcall, nil if it carries no value
+}
+
+// transferTracer is an EVM tracer collecting the internal value transfers of a
+// transaction. The transfers of a call frame are only kept if it (and all the
+// frames calling it) returned successfully, which is learnt from the result a
+// call or create leaves on the stack of its caller.
+type transferTracer struct {
+	frames []*transferFrame    // Open call frames, the first being the transaction's
+	result []*internalTransfer // Transfers of the transaction, set if it succeeded
+}
+
+// CaptureStart implements vm.Tracer, opening the frame of the transaction.
+func (t *transferTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.frames = []*transferFrame{new(transferFrame)}
+	return nil
+}
+
+// CaptureState implements vm.Tracer, tracking the call frames and the value
+// transfers they make.
+func (t *transferTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil || depth == 0 {
+		return nil
+	}
+	for len(t.frames) < depth {
+		t.frames = append(t.frames, new(transferFrame))
+	}
+	// Resolve the subcall of the frame if it returned since the previous step
+	frame := t.frames[depth-1]
+	if frame.calling {
+		if result := stack.Back(0); result.Sign() != 0 {
+			if frame.call != nil {
+				if frame.creating {
+					frame.call.to = common.BigToAddress(result)
+				}
+				frame.transfers = append(frame.transfers, frame.call)
+			}
+			if len(t.frames) > depth {
+				frame.transfers = append(frame.transfers, t.frames[depth].transfers...)
+			}
+		}
+		frame.calling, frame.creating, frame.call = false, false, nil
+	}
+	t.frames = t.frames[:depth]
+
+	switch op {
+	case vm.CALL:
+		frame.calling = true
+		if value := stack.Back(2); value.Sign() > 0 {
+			frame.call = &internalTransfer{from: contract.Address(), to: common.BigToAddress(stack.Back(1)), value: new(big.Int).Set(value)}
+		}
+	case vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Value of a CALLCODE stays with the caller, the others carry none
+		frame.calling = true
+	case vm.CREATE:
+		frame.calling, frame.creating = true, true
+		if value := stack.Back(0); value.Sign() > 0 {
+			frame.call = &internalTransfer{from: contract.Address(), value: new(big.Int).Set(value)}
+		}
+	case vm.SELFDESTRUCT:
+		if balance := env.StateDB.GetBalance(contract.Address()); balance.Sign() > 0 {
+			frame.transfers = append(frame.transfers, &internalTransfer{from: contract.Address(), to: common.BigToAddress(stack.Back(0)), value: new(big.Int).Set(balance)})
+		}
+	}
+	return nil
+}
+
+// CaptureFault implements vm.Tracer. Faulting frames are dropped by their callers
+// as the call's result on the stack signals the failure.
+func (t *transferTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer, keeping the transfers of the transaction if
+// it succeeded.
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if err == nil && len(t.frames) > 0 {
+		t.result = t.frames[0].transfers
+	}
+	return nil
+}
+
+// AddressActivity is an entry of the activity of an address: a transaction it
+// sent or received, or an internal value transfer it took part in.
+type AddressActivity struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	Type        string         `json:"type"` // "transaction" or "internal"
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+}
+
+// AddressTransactions is a page of the activity of an address, most recent first.
+type AddressTransactions struct {
+	Address     common.Address     `json:"address"`
+	Page        hexutil.Uint64     `json:"page"`
+	Total       hexutil.Uint64     `json:"total"`       // Number of entries of the address
+	IndexedHead *hexutil.Uint64    `json:"indexedHead"` // Last block covered by the index
+	Activity    []*AddressActivity `json:"activity"`
+}
+
+// addressActivity retrieves a page of the activity of an address from the
+// address index, pages being numbered from the most recent entries backwards.
+func addressActivity(db, chainDb gdadb.Database, address common.Address, page uint64) (*AddressTransactions, error) {
+	total, _ := readUint64(db, addressCountKey(address))
+
+	result := &AddressTransactions{
+		Address:  address,
+		Page:     hexutil.Uint64(page),
+		Total:    hexutil.Uint64(total),
+		Activity: []*AddressActivity{},
+	}
+	if head, ok := readUint64(db, addressIndexHeadKey); ok {
+		result.IndexedHead = (*hexutil.Uint64)(&head)
+	}
+	if page >= (total+addressPageSize-1)/addressPageSize {
+		return result, nil
+	}
+	end := total - page*addressPageSize
+	start := uint64(0)
+	if end > addressPageSize {
+		start = end - addressPageSize
+	}
+	for i := end; i > start; i-- {
+		blob, err := db.Get(addressEntryKey(address, i-1))
+		if err != nil {
+			return nil, fmt.Errorf("missing address index entry %d of %x", i-1, address)
+		}
+		entry := new(addressEntry)
+		if err := rlp.DecodeBytes(blob, entry); err != nil {
+			return nil, err
+		}
+		activity := &AddressActivity{
+			BlockNumber: hexutil.Uint64(entry.Number),
+			BlockHash:   core.GetCanonicalHash(chainDb, entry.Number),
+			TxHash:      entry.TxHash,
+			TxIndex:     hexutil.Uint64(entry.TxIndex),
+			Type:        "transaction",
+			From:        entry.From,
+			To:          entry.To,
+			Value:       (*hexutil.Big)(entry.Value),
+		}
+		if entry.Internal {
+			activity.Type = "internal"
+		}
+		result.Activity = append(result.Activity, activity)
+	}
+	return result, nil
+}
+
+// GetTransactionsByAddress returns a page of the transactions sent and received
+// by an address and the internal value transfers it took part in, most recent
+// first. It requires the node to maintain the address index.
+func (api *PublicgdachainAPI) GetTransactionsByAddress(address common.Address, page hexutil.Uint64) (*AddressTransactions, error) {
+	if api.e.addressIndex == nil {
+		return nil, errAddressIndexDisabled
+	}
+	return addressActivity(core.IndexerDatabase(api.e.chainDb, addressIndexName), api.e.chainDb, address, uint64(page))
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// forwarderCode returns the code of a contract forwarding the value it receives
+// to the given address, reverting afterwards if requested.
+func forwarderCode(to common.Address, revert bool) []byte {
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLVALUE), byte(vm.PUSH20)}
+	code = append(code, to[:]...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL))
+	if revert {
+		return append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT))
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// Tests that the address index records the transactions and the successful
+// internal transfers of every address, and that it is rolled back on reorgs.
+func TestAddressIndex(t *testing.T) {
+	var (
+		recipient = common.Address{0x01}
+		sink      = common.Address{0x02}
+		forwarder = common.Address{0xf1}
+		reverter  = common.Address{0xf2}
+
+		db, _ = gdadb.NewMemDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testBank:  {Balance: big.NewInt(1000000)},
+				forwarder: {Balance: new(big.Int), Code: forwarderCode(sink, false)},
+				reverter:  {Balance: new(big.Int), Code: forwarderCode(sink, true)},
+			},
+		}
+		genesis  = gspec.MustCommit(db)
+		signer   = types.HomesteadSigner{}
+		payments = []struct {
+			to    common.Address
+			value int64
+		}{{recipient, 1000}, {forwarder, 500}, {reverter, 300}}
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, len(payments), func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), payments[i].to, big.NewInt(payments[i].value), 100000, nil, nil), signer, testBankKey)
+		block.AddTx(tx)
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	// Index the chain block by block, as the chain indexer would
+	index := core.IndexerDatabase(db, addressIndexName)
+	indexer := &addressIndexer{db: index, chain: chain}
+
+	for i := uint64(0); i <= uint64(len(blocks)); i++ {
+		header := chain.GetHeaderByNumber(i)
+		if err := indexer.Reset(i, header.ParentHash); err != nil {
+			t.Fatalf("block %d: failed to reset indexer: %v", i, err)
+		}
+		indexer.Process(header)
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("block %d: failed to index: %v", i, err)
+		}
+	}
+	type entry struct {
+		number uint64
+		kind   string
+		from   common.Address
+		to     common.Address
+		value  int64
+	}
+	check := func(address common.Address, want []entry) {
+		res, err := addressActivity(index, db, address, 0)
+		if err != nil {
+			t.Fatalf("%x: failed to retrieve activity: %v", address, err)
+		}
+		if int(res.Total) != len(want) || len(res.Activity) != len(want) {
+			t.Fatalf("%x: entry count mismatch: have %d/%d, want %d", address, res.Total, len(res.Activity), len(want))
+		}
+		for i, act := range res.Activity {
+			have := entry{uint64(act.BlockNumber), act.Type, act.From, act.To, act.Value.ToInt().Int64()}
+			if have != want[i] {
+				t.Errorf("%x: entry %d mismatch: have %+v, want %+v", address, i, have, want[i])
+			}
+			if act.BlockHash != blocks[act.BlockNumber-1].Hash() {
+				t.Errorf("%x: entry %d block hash mismatch: have %x, want %x", address, i, act.BlockHash, blocks[act.BlockNumber-1].Hash())
+			}
+		}
+	}
+	check(testBank, []entry{
+		{3, "transaction", testBank, reverter, 300},
+		{2, "transaction", testBank, forwarder, 500},
+		{1, "transaction", testBank, recipient, 1000},
+	})
+	check(forwarder, []entry{
+		{2, "internal", forwarder, sink, 500},
+		{2, "transaction", testBank, forwarder, 500},
+	})
+	check(sink, []entry{
+		{2, "internal", forwarder, sink, 500},
+	})
+	check(reverter, []entry{
+		{3, "transaction", testBank, reverter, 300},
+	})
+	// Reprocessing the chain from block 2 onwards must remove its entries
+	if err := indexer.Reset(2, blocks[0].Hash()); err != nil {
+		t.Fatalf("failed to roll back index: %v", err)
+	}
+	check(testBank, []entry{
+		{1, "transaction", testBank, recipient, 1000},
+	})
+	check(forwarder, nil)
+	check(sink, nil)
+
+	if res, _ := addressActivity(index, db, testBank, 0); res.IndexedHead == nil || *res.IndexedHead != 1 {
+		t.Errorf("indexed head mismatch: have %v, want 1", res.IndexedHead)
+	}
+	// Rolling back the entire index must drop its head and all journals
+	if err := indexer.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("failed to roll back index: %v", err)
+	}
+	if has, _ := index.Has(addressIndexHeadKey); has {
+		t.Errorf("index head retained after full rollback")
+	}
+	for n := uint64(0); n <= uint64(len(blocks)); n++ {
+		if has, _ := index.Has(addressJournalKey(n)); has {
+			t.Errorf("journal of block %d retained after rollback", n)
+		}
+	}
+}
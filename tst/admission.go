@@ -0,0 +1,125 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/metrics"
+)
+
+const (
+	// importGateRecheck is the interval at which a held back request re-evaluates
+	// whgdaer block import caught up with the network.
+	importGateRecheck = 250 * time.Millisecond
+
+	// importGateMaxWait is the maximum time a single shot debug request is held
+	// back before being rejected. Long running subscriptions wait indefinitely.
+	importGateMaxWait = time.Minute
+)
+
+// errImportBehind is returned if a heavy debug request was held back for too
+// long waiting for block import to catch up.
+var errImportBehind = errors.New("block import behind, debug request deferred")
+
+var (
+	importGateDeferMeter  = metrics.NewRegisteredMeter("gda/debug/deferred", nil)
+	importGateRejectMeter = metrics.NewRegisteredMeter("gda/debug/rejected", nil)
+)
+
+// importGate coordinates heavy debug and tracing RPC work with block import.
+// Whenever the local head falls more than a configured number of blocks behind
+// the best known network head, new work is held back until import catches up,
+// so serving traffic cannot starve the node of the resources needed to follow
+// the chain.
+type importGate struct {
+	threshold uint64                                    // Maximum head lag tolerated before deferring work
+	progress  func() (current, highest uint64, ok bool) // Sync progress source, ok is false if not syncing
+	closed    int32                                     // Flag whgdaer the gate is currently closed (for logging)
+}
+
+// newImportGate creates an admission gate tracking the sync progress reported by
+// the given downloader. A zero threshold disables the gate.
+func newImportGate(threshold uint64, d *downloader.Downloader) *importGate {
+	return &importGate{
+		threshold: threshold,
+		progress: func() (uint64, uint64, bool) {
+			if !d.Synchronising() {
+				return 0, 0, false
+			}
+			progress := d.Progress()
+			return progress.CurrentBlock, progress.HighestBlock, true
+		},
+	}
+}
+
+// behind reports whgdaer the local chain head lags the network by more than the
+// configured threshold.
+func (g *importGate) behind() bool {
+	if g == nil || g.threshold == 0 {
+		return false
+	}
+	current, highest, ok := g.progress()
+	lagging := ok && highest > current && highest-current > g.threshold
+
+	// Log gate transitions to make any deferral visible to operators
+	if lagging && atomic.CompareAndSwapInt32(&g.closed, 0, 1) {
+		log.Warn("Deferring debug requests, block import behind", "current", current, "highest", highest, "threshold", g.threshold)
+	}
+	if !lagging && atomic.CompareAndSwapInt32(&g.closed, 1, 0) {
+		log.Info("Resuming debug requests, block import caught up")
+	}
+	return lagging
+}
+
+// wait blocks until block import is within the configured lag of the network,
+// the context is cancelled, the abort channel is closed or the timeout (if
+// non-zero) expires.
+func (g *importGate) wait(ctx context.Context, abort <-chan interface{}, timeout time.Duration) error {
+	if !g.behind() {
+		return nil
+	}
+	importGateDeferMeter.Mark(1)
+
+	var expire <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expire = timer.C
+	}
+	recheck := time.NewTicker(importGateRecheck)
+	defer recheck.Stop()
+
+	for g.behind() {
+		select {
+		case <-recheck.C:
+		case <-expire:
+			importGateRejectMeter.Mark(1)
+			return errImportBehind
+		case <-abort:
+			return context.Canceled
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
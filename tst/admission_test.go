@@ -0,0 +1,86 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tests that the import gate only holds back requests while the local head lags
+// the network by more than the configured threshold.
+func TestImportGateBehind(t *testing.T) {
+	tests := []struct {
+		threshold uint64
+		current   uint64
+		highest   uint64
+		syncing   bool
+		behind    bool
+	}{
+		{threshold: 0, current: 0, highest: 1000, syncing: true, behind: false},
+		{threshold: 16, current: 100, highest: 116, syncing: true, behind: false},
+		{threshold: 16, current: 100, highest: 117, syncing: true, behind: true},
+		{threshold: 16, current: 100, highest: 1000, syncing: false, behind: false},
+		{threshold: 16, current: 200, highest: 100, syncing: true, behind: false},
+	}
+	for i, tt := range tests {
+		tt := tt
+		gate := &importGate{
+			threshold: tt.threshold,
+			progress:  func() (uint64, uint64, bool) { return tt.current, tt.highest, tt.syncing },
+		}
+		if behind := gate.behind(); behind != tt.behind {
+			t.Errorf("test %d: behind mismatch: have %v, want %v", i, behind, tt.behind)
+		}
+	}
+	// A missing gate must never hold anything back
+	var gate *importGate
+	if gate.behind() {
+		t.Errorf("nil gate reported lagging import")
+	}
+}
+
+// Tests that waiting on the import gate resumes once import catches up, and that
+// single shot requests are rejected after the timeout expires.
+func TestImportGateWait(t *testing.T) {
+	var current uint64 = 100
+
+	gate := &importGate{
+		threshold: 16,
+		progress:  func() (uint64, uint64, bool) { return atomic.LoadUint64(&current), 200, true },
+	}
+	if err := gate.wait(context.Background(), nil, 2*importGateRecheck); err != errImportBehind {
+		t.Fatalf("timed out wait error mismatch: have %v, want %v", err, errImportBehind)
+	}
+	go func() {
+		time.Sleep(importGateRecheck)
+		atomic.StoreUint64(&current, 190)
+	}()
+	if err := gate.wait(context.Background(), nil, 0); err != nil {
+		t.Fatalf("failed to resume after import caught up: %v", err)
+	}
+	// Ensure pending waits can be aborted by the caller
+	atomic.StoreUint64(&current, 100)
+
+	abort := make(chan interface{})
+	close(abort)
+	if err := gate.wait(context.Background(), abort, 0); err != context.Canceled {
+		t.Fatalf("aborted wait error mismatch: have %v, want %v", err, context.Canceled)
+	}
+}
@@ -17,19 +17,27 @@
 package gda
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/common/hexutil"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/state"
 	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gda/fetcher"
+	"github.com/gdachain/go-gdachain/internal/objectstore"
 	"github.com/gdachain/go-gdachain/log"
 	"github.com/gdachain/go-gdachain/miner"
 	"github.com/gdachain/go-gdachain/params"
@@ -64,6 +72,47 @@ func (api *PublicgdachainAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().HashRate())
 }
 
+// GetBlockConfidence returns the information needed to decide whgdaer a block
+// can be considered final: its depth in the canonical chain and the number of
+// competing blocks seen at the same height.
+func (api *PublicgdachainAPI) GetBlockConfidence(hash common.Hash) (*BlockConfidence, error) {
+	return api.e.confirmations.confidence(hash)
+}
+
+// GetHeaderByNumber returns the requested canonical header, which (unlike the
+// full block) is also available on nodes synchronising headers only.
+func (api *PublicgdachainAPI) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	return api.e.ApiBackend.HeaderByNumber(ctx, number)
+}
+
+// GetHeaderByHash returns the requested header, which (unlike the full block) is
+// also available on nodes synchronising headers only.
+func (api *PublicgdachainAPI) GetHeaderByHash(hash common.Hash) *types.Header {
+	return api.e.blockchain.GetHeaderByHash(hash)
+}
+
+// GetBlockSidecarNames returns the names of the extension data stored alongside
+// the block with the given hash.
+func (api *PublicgdachainAPI) GetBlockSidecarNames(hash common.Hash) []string {
+	return api.e.BlockChain().GetSidecarNames(hash)
+}
+
+// GetBlockSidecar returns the named extension data stored alongside the block
+// with the given hash.
+func (api *PublicgdachainAPI) GetBlockSidecar(hash common.Hash, name string) (hexutil.Bytes, error) {
+	data := api.e.BlockChain().GetSidecar(hash, name)
+	if data == nil {
+		return nil, fmt.Errorf("sidecar %q not found for block %x", name, hash)
+	}
+	return data, nil
+}
+
+// GetLocalTxStatus reports the state of a transaction submitted through this
+// node, which may be identified by the hash of any of its (bumped) versions.
+func (api *PublicgdachainAPI) GetLocalTxStatus(hash common.Hash) (*LocalTxStatus, error) {
+	return api.e.localTxs.status(hash)
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -177,6 +226,64 @@ func (api *PrivateMinerAPI) SetExtra(extra string) (bool, error) {
 	return true, nil
 }
 
+// SetUnclePolicy configures which side blocks the miner includes as uncles.
+func (api *PrivateMinerAPI) SetUnclePolicy(policy miner.UnclePolicy) (bool, error) {
+	if err := api.e.Miner().SetUnclePolicy(policy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetRecommitInterval updates the interval (in milliseconds) at which the miner
+// recreates its sealing work to include newly arrived transactions.
+func (api *PrivateMinerAPI) SetRecommitInterval(interval int) bool {
+	if interval < 0 {
+		interval = 0
+	}
+	api.e.Miner().SetRecommitInterval(time.Duration(interval) * time.Millisecond)
+	return true
+}
+
+// PendingBlockNotification is the RPC representation of a pending block freshly
+// assembled by the miner.
+type PendingBlockNotification struct {
+	Header       *types.Header        `json:"header"`
+	Transactions []*types.Transaction `json:"transactions"`
+}
+
+// PendingBlock creates a subscription that fires every time the miner assembles
+// a new pending block, sparing consumers from polling for it.
+func (api *PrivateMinerAPI) PendingBlock(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		blocks := make(chan core.PendingBlockEvent, 16)
+		sub := api.e.Miner().SubscribePendingBlockEvent(blocks)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-blocks:
+				notifier.Notify(rpcSub.ID, &PendingBlockNotification{
+					Header:       ev.Block.Header(),
+					Transactions: ev.Block.Transactions(),
+				})
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // SetGasPrice sets the minimum accepted gas price for the miner.
 func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	api.e.lock.Lock()
@@ -198,6 +305,53 @@ func (api *PrivateMinerAPI) GetHashrate() uint64 {
 	return uint64(api.e.miner.HashRate())
 }
 
+// PrivateTxPoolAPI is the collection of gdachain APIs managing the handling of
+// local transactions, which may re-sign them and is thus not public.
+type PrivateTxPoolAPI struct {
+	e *gdachain
+}
+
+// NewPrivateTxPoolAPI creates a new API definition for managing local transactions.
+func NewPrivateTxPoolAPI(e *gdachain) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{e: e}
+}
+
+// SetAutoBump configures how local transactions stuck below the gas price floor
+// are rebroadcast or re-signed with a higher gas price. Bumping requires the
+// sending accounts to be unlocked.
+func (api *PrivateTxPoolAPI) SetAutoBump(policy LocalTxPolicy) (bool, error) {
+	if err := api.e.localTxs.setPolicy(policy); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetGasPrice updates the minimum gas price accepted by the transaction pool,
+// without changing the price the miner is configured with.
+func (api *PrivateTxPoolAPI) SetGasPrice(gasPrice hexutil.Big) bool {
+	api.e.txPool.SetGasPrice((*big.Int)(&gasPrice))
+	return true
+}
+
+// SetPolicyRules replaces the address admission ruleset of the transaction pool,
+// dropping all pooled transactions the new rules reject.
+func (api *PrivateTxPoolAPI) SetPolicyRules(rules core.PolicyRules) (bool, error) {
+	if err := api.e.txPool.SetPolicyRules(&rules); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PolicyRules returns the address admission ruleset of the transaction pool.
+func (api *PrivateTxPoolAPI) PolicyRules() *core.PolicyRules {
+	return api.e.txPool.PolicyRules()
+}
+
+// AutoBump returns the current handling policy of stuck local transactions.
+func (api *PrivateTxPoolAPI) AutoBump() LocalTxPolicy {
+	return api.e.localTxs.getPolicy()
+}
+
 // PrivateAdminAPI is the collection of gdachain full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -210,6 +364,105 @@ func NewPrivateAdminAPI(gda *gdachain) *PrivateAdminAPI {
 	return &PrivateAdminAPI{gda: gda}
 }
 
+// ChainConfig returns the chain configuration the node is currently running
+// with, including all the resolved fork blocks.
+func (api *PrivateAdminAPI) ChainConfig() *params.ChainConfig {
+	return api.gda.chainConfig
+}
+
+// ChainConfigCheck is the result of a chain configuration dry run.
+type ChainConfigCheck struct {
+	Compatible bool              `json:"compatible"`
+	Head       uint64            `json:"head"`
+	Error      string            `json:"error,omitempty"`
+	RewindTo   *uint64           `json:"rewindTo,omitempty"`
+	Upcoming   map[string]uint64 `json:"upcoming"` // Forks scheduled after the current head
+}
+
+// ValidateChainConfig checks whgdaer the given chain configuration could be
+// applied on top of the current chain head without rewinding it. The config is
+// never stored, allowing operators to dry-run scheduled fork transitions.
+func (api *PrivateAdminAPI) ValidateChainConfig(config params.ChainConfig) (*ChainConfigCheck, error) {
+	if config.ChainId == nil {
+		return nil, errors.New("missing chain id")
+	}
+	head := api.gda.blockchain.CurrentHeader().Number.Uint64()
+
+	check := &ChainConfigCheck{
+		Compatible: true,
+		Head:       head,
+		Upcoming:   scheduledForks(&config, head),
+	}
+	if err := api.gda.chainConfig.CheckCompatible(&config, head); err != nil {
+		check.Compatible = false
+		check.Error = err.Error()
+		check.RewindTo = &err.RewindTo
+	}
+	return check, nil
+}
+
+// scheduledForks returns the fork transitions of the given config which are not
+// yet active at the specified head block.
+func scheduledForks(config *params.ChainConfig, head uint64) map[string]uint64 {
+	forks := map[string]*big.Int{
+		"homestead":      config.HomesteadBlock,
+		"dao":            config.DAOForkBlock,
+		"eip150":         config.EIP150Block,
+		"eip155":         config.EIP155Block,
+		"eip158":         config.EIP158Block,
+		"byzantium":      config.ByzantiumBlock,
+		"constantinople": config.ConstantinopleBlock,
+	}
+	upcoming := make(map[string]uint64)
+	for name, block := range forks {
+		if block != nil && block.Uint64() > head {
+			upcoming[name] = block.Uint64()
+		}
+	}
+	return upcoming
+}
+
+// IndexerStatus is the progress of a chain indexer maintained by the node.
+type IndexerStatus struct {
+	Name        string          `json:"name"`
+	SectionSize hexutil.Uint64  `json:"sectionSize"`
+	Confirms    hexutil.Uint64  `json:"confirms"`
+	Sections    hexutil.Uint64  `json:"sections"`
+	LastHeader  *hexutil.Uint64 `json:"lastHeader"`
+	SectionHead common.Hash     `json:"sectionHead"`
+}
+
+// Indexers returns the progress of the chain indexers maintained by the node,
+// both the built in bloom bits index and the user-defined ones registered in
+// the core indexer registry.
+func (api *PrivateAdminAPI) Indexers() []*IndexerStatus {
+	statuses := []*IndexerStatus{indexerStatus(api.gda.bloomIndexer, "bloombits", params.BloomBitsBlocks, bloomConfirms)}
+	for _, spec := range core.RegisteredIndexers() {
+		if indexer, ok := api.gda.indexers[spec.Name]; ok {
+			statuses = append(statuses, indexerStatus(indexer, spec.Name, spec.SectionSize, spec.Confirms))
+		}
+	}
+	return statuses
+}
+
+// indexerStatus assembles the progress report of a single chain indexer.
+func indexerStatus(indexer *core.ChainIndexer, name string, size, confirms uint64) *IndexerStatus {
+	sections, last, head := indexer.Sections()
+
+	status := &IndexerStatus{
+		Name:        name,
+		SectionSize: hexutil.Uint64(size),
+		Confirms:    hexutil.Uint64(confirms),
+		Sections:    hexutil.Uint64(sections),
+	}
+	if sections > 0 {
+		lastHeader := hexutil.Uint64(last)
+		status.LastHeader = &lastHeader
+		status.SectionHead = head
+	}
+	return status
+}
+
 // ExportChain exports the current blockchain into a local file.
 func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	// Make sure we can create the file to export into
@@ -232,14 +485,75 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	return true, nil
 }
 
-func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
-	for _, b := range bs {
-		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
-			return false
-		}
+// objectStoreSegmentBlocks is the number of blocks bundled into a single object
+// when exporting the chain into an object store.
+const objectStoreSegmentBlocks = 2048
+
+// ObjectStoreSegment describes a segment of RLP encoded blocks uploaded into an
+// object store.
+type ObjectStoreSegment struct {
+	Key      string `json:"key"`
+	First    uint64 `json:"first"`
+	Last     uint64 `json:"last"`
+	Size     int    `json:"size"`
+	Checksum string `json:"sha256"`
+}
+
+// ExportToObjectStore exports a range of the blockchain into an S3-compatible
+// object store. Blocks are streamed in fixed size RLP segments, each uploaded
+// with its SHA256 checksum, followed by a manifest describing all of them. The
+// credentials are loaded from the JSON file referenced by credsRef.
+func (api *PrivateAdminAPI) ExportToObjectStore(first, last uint64, bucket string, credsRef string) ([]ObjectStoreSegment, error) {
+	if first > last {
+		return nil, fmt.Errorf("first (%d) is greater than last (%d)", first, last)
 	}
+	if head := api.gda.BlockChain().CurrentBlock().NumberU64(); last > head {
+		return nil, fmt.Errorf("last (%d) is above the current head (%d)", last, head)
+	}
+	creds, err := objectstore.LoadCredentials(credsRef)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		client   = objectstore.NewClient(creds)
+		segments []ObjectStoreSegment
+		buffer   = new(bytes.Buffer)
+	)
+	for start := first; start <= last; start += objectStoreSegmentBlocks {
+		end := start + objectStoreSegmentBlocks - 1
+		if end > last {
+			end = last
+		}
+		buffer.Reset()
+		if err := api.gda.BlockChain().ExportN(buffer, start, end); err != nil {
+			return segments, err
+		}
+		key := fmt.Sprintf("blocks-%09d-%09d.rlp", start, end)
+		checksum, err := client.Put(bucket, key, buffer.Bytes())
+		if err != nil {
+			return segments, err
+		}
+		segments = append(segments, ObjectStoreSegment{
+			Key:      key,
+			First:    start,
+			Last:     end,
+			Size:     buffer.Len(),
+			Checksum: checksum,
+		})
+		log.Info("Exported chain segment to object store", "bucket", bucket, "key", key, "size", common.StorageSize(buffer.Len()))
 
-	return true
+		if end == last {
+			break // Avoid overflowing at the end of the uint64 range
+		}
+	}
+	manifest, err := json.MarshalIndent(segments, "", "  ")
+	if err != nil {
+		return segments, err
+	}
+	if _, err := client.Put(bucket, fmt.Sprintf("manifest-%09d-%09d.json", first, last), manifest); err != nil {
+		return segments, err
+	}
+	return segments, nil
 }
 
 // ImportChain imports a blockchain from a local file.
@@ -257,36 +571,8 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 			return false, err
 		}
 	}
-
-	// Run actual the import in pre-configured batches
-	stream := rlp.NewStream(reader, 0)
-
-	blocks, index := make([]*types.Block, 0, 2500), 0
-	for batch := 0; ; batch++ {
-		// Load a batch of blocks from the input file
-		for len(blocks) < cap(blocks) {
-			block := new(types.Block)
-			if err := stream.Decode(block); err == io.EOF {
-				break
-			} else if err != nil {
-				return false, fmt.Errorf("block %d: failed to parse: %v", index, err)
-			}
-			blocks = append(blocks, block)
-			index++
-		}
-		if len(blocks) == 0 {
-			break
-		}
-
-		if hasAllBlocks(api.gda.BlockChain(), blocks) {
-			blocks = blocks[:0]
-			continue
-		}
-		// Import the batch and reset the buffer
-		if _, err := api.gda.BlockChain().InsertChain(blocks); err != nil {
-			return false, fmt.Errorf("batch %d: failed to insert: %v", batch, err)
-		}
-		blocks = blocks[:0]
+	if _, err := api.gda.ImportBlocks(reader, ImportOptions{}); err != nil {
+		return false, err
 	}
 	return true, nil
 }
@@ -341,18 +627,282 @@ func NewPrivateDebugAPI(config *params.ChainConfig, gda *gdachain) *PrivateDebug
 	return &PrivateDebugAPI{config: config, gda: gda}
 }
 
+// ExportBlocks starts exporting a range of blocks into a new local file in the
+// given format (rlp, jsonl, csv-headers, csv-txs, csv-receipts, or a format of
+// a registered encoder), compressed if the path ends with ".gz". The export is
+// run in the background, its progress is reported by ExportStatus.
+func (api *PrivateDebugAPI) ExportBlocks(format string, blocks ExportRange, path string) (ExportProgress, error) {
+	return api.gda.exporter.start(format, blocks, path)
+}
+
+// ExportStatus returns the progress of a block export.
+func (api *PrivateDebugAPI) ExportStatus(id int) (ExportProgress, error) {
+	return api.gda.exporter.status(id)
+}
+
+// CancelExport aborts a running block export, returning whether it was running.
+func (api *PrivateDebugAPI) CancelExport(id int) (bool, error) {
+	return api.gda.exporter.cancel(id)
+}
+
+// StateStats starts walking the state of a block in the background, counting
+// its accounts, contracts, storage slots and code size and the depths of its
+// trie leaves. The progress of the job is reported by JobStatus.
+func (api *PrivateDebugAPI) StateStats(blockNr rpc.BlockNumber) (StateStats, error) {
+	if blockNr == rpc.PendingBlockNumber {
+		return StateStats{}, errors.New("pending state statistics not supported")
+	}
+	var block *types.Block
+	if blockNr == rpc.LatestBlockNumber {
+		block = api.gda.blockchain.CurrentBlock()
+	} else {
+		block = api.gda.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return StateStats{}, fmt.Errorf("block #%d not found", blockNr)
+	}
+	if _, err := api.gda.blockchain.StateAt(block.Root()); err != nil {
+		return StateStats{}, err
+	}
+	return api.gda.stateStats.start(block)
+}
+
+// JobStatus returns the progress of a state statistics job.
+func (api *PrivateDebugAPI) JobStatus(id int) (StateStats, error) {
+	return api.gda.stateStats.status(id)
+}
+
+// CancelJob aborts a running state statistics job, returning whether it was
+// running.
+func (api *PrivateDebugAPI) CancelJob(id int) (bool, error) {
+	return api.gda.stateStats.cancel(id)
+}
+
 // Preimage is a debug API function that returns the preimage for a sha3 hash, if known.
 func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
 	db := core.PreimageTable(api.gda.ChainDb())
 	return db.Get(hash.Bytes())
 }
 
+// CodeByHash returns the contract code with the given hash from the database,
+// letting callers fetch code shared by many contracts only once.
+func (api *PrivateDebugAPI) CodeByHash(hash common.Hash) (hexutil.Bytes, error) {
+	if hash == emptyCodeHash {
+		return hexutil.Bytes{}, nil
+	}
+	return api.gda.blockchain.StateCache().ContractCode(common.Hash{}, hash)
+}
+
+// PropagationStats returns a summary of how long recently propagated blocks took
+// from first being seen in the network until they were imported.
+func (api *PrivateDebugAPI) PropagationStats() fetcher.PropagationStats {
+	return api.gda.protocolManager.fetcher.PropagationStats()
+}
+
+// SetPreimageRecording toggles the recording of the SHA3 preimages seen by the
+// VM while importing blocks, returning the previous setting.
+func (api *PrivateDebugAPI) SetPreimageRecording(enabled bool) bool {
+	previous := api.gda.blockchain.PreimageRecording()
+	api.gda.blockchain.SetPreimageRecording(enabled)
+	return previous
+}
+
+// VMOpProfile is the execution statistics of an opcode in a VM profile.
+type VMOpProfile struct {
+	Op    string         `json:"op"`
+	Count hexutil.Uint64 `json:"count"`
+	Gas   hexutil.Uint64 `json:"gas"`
+}
+
+// VMContractProfile is the execution statistics of a contract code in a VM
+// profile.
+type VMContractProfile struct {
+	Address common.Address `json:"address"`
+	Calls   hexutil.Uint64 `json:"calls"`
+	Ops     hexutil.Uint64 `json:"ops"`
+	Gas     hexutil.Uint64 `json:"gas"`
+}
+
+// VMProfile is the aggregated execution profile of recently imported blocks.
+type VMProfile struct {
+	First     hexutil.Uint64      `json:"first"`
+	Last      hexutil.Uint64      `json:"last"`
+	Blocks    int                 `json:"blocks"`
+	Elapsed   string              `json:"elapsed"`
+	Ops       []VMOpProfile       `json:"ops"`
+	Contracts []VMContractProfile `json:"contracts"`
+}
+
+// defaultVMProfileContracts is the number of contracts reported by VMProfile if
+// no limit is requested.
+const defaultVMProfileContracts = 50
+
+// VMProfile returns the opcodes executed while importing the recently profiled
+// blocks, along with the contracts (up to limit, 50 by default) consuming the
+// most gas, both ordered by gas consumed. Profiling is enabled by --vmprofile.
+func (api *PrivateDebugAPI) VMProfile(limit *int) (*VMProfile, error) {
+	profiles := api.gda.blockchain.VMProfiles()
+	if profiles == nil {
+		return nil, errors.New("VM profiling disabled")
+	}
+	summary := profiles.Summary()
+
+	result := &VMProfile{
+		First:   hexutil.Uint64(summary.First),
+		Last:    hexutil.Uint64(summary.Last),
+		Blocks:  summary.Blocks,
+		Elapsed: summary.Elapsed.String(),
+	}
+	for op, stats := range summary.Profile.Ops {
+		if stats.Count > 0 {
+			result.Ops = append(result.Ops, VMOpProfile{Op: vm.OpCode(op).String(), Count: hexutil.Uint64(stats.Count), Gas: hexutil.Uint64(stats.Gas)})
+		}
+	}
+	sort.Slice(result.Ops, func(i, j int) bool { return result.Ops[i].Gas > result.Ops[j].Gas })
+
+	for addr, stats := range summary.Profile.Contracts {
+		result.Contracts = append(result.Contracts, VMContractProfile{Address: addr, Calls: hexutil.Uint64(stats.Calls), Ops: hexutil.Uint64(stats.Ops), Gas: hexutil.Uint64(stats.Gas)})
+	}
+	sort.Slice(result.Contracts, func(i, j int) bool { return result.Contracts[i].Gas > result.Contracts[j].Gas })
+
+	max := defaultVMProfileContracts
+	if limit != nil {
+		max = *limit
+	}
+	if max >= 0 && len(result.Contracts) > max {
+		result.Contracts = result.Contracts[:max]
+	}
+	return result, nil
+}
+
+// ExportPreimages writes all the known preimages into a local file as a stream
+// of RLP encoded byte strings, compressed if the path ends with ".gz". The hash
+// of each preimage is its Keccak256 hash. The number of preimages exported is
+// returned.
+func (api *PrivateDebugAPI) ExportPreimages(file string) (hexutil.Uint64, error) {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var (
+		writer io.Writer = out
+		zipper *gzip.Writer
+	)
+	if strings.HasSuffix(file, ".gz") {
+		zipper = gzip.NewWriter(writer)
+		writer = zipper
+	}
+	var exported hexutil.Uint64
+	err = core.IteratePreimages(api.gda.ChainDb(), func(hash common.Hash, preimage []byte) error {
+		exported++
+		return rlp.Encode(writer, preimage)
+	})
+	if err != nil {
+		return exported, err
+	}
+	if zipper != nil {
+		if err := zipper.Close(); err != nil {
+			return exported, err
+		}
+	}
+	log.Info("Exported preimages", "count", uint64(exported), "file", file)
+	return exported, nil
+}
+
+// ReorgBlock is the RPC representation of a block taking part in a chain
+// reorganisation.
+type ReorgBlock struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	ParentHash   common.Hash    `json:"parentHash"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+// ReorgNotification is the RPC representation of a chain reorganisation. The
+// dropped and added chain segments are ordered by ascending block number.
+type ReorgNotification struct {
+	CommonAncestor ReorgBlock   `json:"commonAncestor"`
+	OldChain       []ReorgBlock `json:"oldChain"`
+	NewChain       []ReorgBlock `json:"newChain"`
+}
+
+// newReorgBlock converts a block into its reorg notification representation.
+func newReorgBlock(block *types.Block) ReorgBlock {
+	txs := make([]common.Hash, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		txs[i] = tx.Hash()
+	}
+	return ReorgBlock{
+		Number:       hexutil.Uint64(block.NumberU64()),
+		Hash:         block.Hash(),
+		ParentHash:   block.ParentHash(),
+		Transactions: txs,
+	}
+}
+
+// newReorgSegment converts a chain segment ordered from the highest block down
+// into its ascending reorg notification representation.
+func newReorgSegment(blocks types.Blocks) []ReorgBlock {
+	segment := make([]ReorgBlock, len(blocks))
+	for i, block := range blocks {
+		segment[len(blocks)-1-i] = newReorgBlock(block)
+	}
+	return segment
+}
+
+// Reorgs creates a subscription that fires whenever the canonical chain is
+// reorganised, reporting the common ancestor along with the dropped and added
+// chain segments (including their transactions).
+func (api *PrivateDebugAPI) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ReorgEvent, 16)
+		sub := api.gda.BlockChain().SubscribeReorgEvent(reorgs)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, &ReorgNotification{
+					CommonAncestor: newReorgBlock(ev.CommonAncestor),
+					OldChain:       newReorgSegment(ev.OldChain),
+					NewChain:       newReorgSegment(ev.NewChain),
+				})
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // GetBadBLocks returns a list of the last 'bad blocks' that the client has seen on the network
 // and returns them as a JSON list of block-hashes
 func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]core.BadBlockArgs, error) {
 	return api.gda.BlockChain().BadBlocks()
 }
 
+// BadBlockRLP returns the RLP encoding of a recently rejected block, so it can be
+// replayed and analysed offline.
+func (api *PrivateDebugAPI) BadBlockRLP(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	bad := api.gda.BlockChain().BadBlock(hash)
+	if bad == nil {
+		return nil, fmt.Errorf("bad block %#x not found", hash)
+	}
+	return rlp.EncodeToBytes(bad.Block)
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
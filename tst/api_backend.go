@@ -19,6 +19,7 @@ package gda
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
 	"github.com/gdachain/go-gdachain/common"
@@ -29,6 +30,7 @@ import (
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/core/vm"
 	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
 	"github.com/gdachain/go-gdachain/gdadb"
 	"github.com/gdachain/go-gdachain/event"
@@ -63,6 +65,10 @@ func (b *gdaApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	}
 	// Otherwise resolve and return the block
 	if blockNr == rpc.LatestBlockNumber {
+		// Header only nodes never advance their head block, only their head header
+		if b.gda.config.SyncMode == downloader.HeaderSync {
+			return b.gda.blockchain.CurrentHeader(), nil
+		}
 		return b.gda.blockchain.CurrentBlock().Header(), nil
 	}
 	return b.gda.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
@@ -116,6 +122,10 @@ func (b *gdaApiBackend) GetLogs(ctx context.Context, blockHash common.Hash) ([][
 	return logs, nil
 }
 
+func (b *gdaApiBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	return b.gda.txLookup.find(txHash)
+}
+
 func (b *gdaApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.gda.blockchain.GetTdByHash(blockHash)
 }
@@ -148,8 +158,16 @@ func (b *gdaApiBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 	return b.gda.BlockChain().SubscribeLogsEvent(ch)
 }
 
+func (b *gdaApiBackend) SubscribePendingLogsEvent(ch chan<- core.PendingLogsEvent) event.Subscription {
+	return b.gda.miner.SubscribePendingLogsEvent(ch)
+}
+
 func (b *gdaApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	return b.gda.txPool.AddLocal(signedTx)
+	if err := b.gda.txPool.AddLocal(signedTx); err != nil {
+		return err
+	}
+	b.gda.localTxs.track(signedTx)
+	return nil
 }
 
 func (b *gdaApiBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -184,6 +202,10 @@ func (b *gdaApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Sub
 	return b.gda.TxPool().SubscribeTxPreEvent(ch)
 }
 
+func (b *gdaApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return b.gda.TxPool().SubscribeTxDropEvent(ch)
+}
+
 func (b *gdaApiBackend) Downloader() *downloader.Downloader {
 	return b.gda.Downloader()
 }
@@ -200,21 +222,38 @@ func (b *gdaApiBackend) ChainDb() gdadb.Database {
 	return b.gda.ChainDb()
 }
 
-func (b *gdaApiBackend) EventMux() *event.TypeMux {
-	return b.gda.EventMux()
-}
-
 func (b *gdaApiBackend) AccountManager() *accounts.Manager {
 	return b.gda.AccountManager()
 }
 
+func (b *gdaApiBackend) RPCTxFeeCap() float64 {
+	return b.gda.config.RPCTxFeeCap
+}
+
+func (b *gdaApiBackend) RPCGasCap() uint64 {
+	return b.gda.config.RPCGasCap
+}
+
+func (b *gdaApiBackend) RPCEVMTimeout() time.Duration {
+	return b.gda.config.RPCEVMTimeout
+}
+
+func (b *gdaApiBackend) RPCEVMMemory() uint64 {
+	return b.gda.config.RPCEVMMemory
+}
+
 func (b *gdaApiBackend) BloomStatus() (uint64, uint64) {
 	sections, _, _ := b.gda.bloomIndexer.Sections()
 	return params.BloomBitsBlocks, sections
 }
 
 func (b *gdaApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
-	for i := 0; i < bloomFilterThreads; i++ {
-		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.gda.bloomRequests)
+	requests := b.gda.bloomRequests
+	if filters.IsPriority(ctx) {
+		requests = b.gda.bloomPriorityRequests
+	}
+	config := b.gda.config
+	for i := 0; i < config.BloomFilterThreads; i++ {
+		go session.Multiplex(config.BloomRetrievalBatch, config.BloomRetrievalWait, requests)
 	}
 }
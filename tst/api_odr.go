@@ -0,0 +1,135 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/light"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/trie"
+)
+
+// PrivateOdrAPI serves the raw chain and state data light clients retrieve on
+// demand, letting them use a trusted full node instead of (or next to) LES
+// servers. Replies are in the same encoding as their LES counterparts.
+type PrivateOdrAPI struct {
+	gda *gdachain
+}
+
+// NewPrivateOdrAPI creates a new on-demand retrieval API for light clients.
+func NewPrivateOdrAPI(gda *gdachain) *PrivateOdrAPI {
+	return &PrivateOdrAPI{gda: gda}
+}
+
+// OdrHeader is a canonical header along with its total difficulty.
+type OdrHeader struct {
+	Header hexutil.Bytes `json:"header"`
+	Td     *hexutil.Big  `json:"td"`
+}
+
+// GetHeaderByNumber retrieves the RLP encoded canonical header with the given
+// number along with its total difficulty.
+func (api *PrivateOdrAPI) GetHeaderByNumber(number hexutil.Uint64) (*OdrHeader, error) {
+	header := api.gda.blockchain.GetHeaderByNumber(uint64(number))
+	if header == nil {
+		return nil, fmt.Errorf("header #%d not found", number)
+	}
+	td := api.gda.blockchain.GetTd(header.Hash(), uint64(number))
+	if td == nil {
+		return nil, fmt.Errorf("total difficulty #%d not found", number)
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &OdrHeader{Header: enc, Td: (*hexutil.Big)(td)}, nil
+}
+
+// GetBlockBody retrieves the RLP encoded body of the block with the given hash.
+func (api *PrivateOdrAPI) GetBlockBody(hash common.Hash) (hexutil.Bytes, error) {
+	body := core.GetBodyRLP(api.gda.chainDb, hash, core.GetBlockNumber(api.gda.chainDb, hash))
+	if len(body) == 0 {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return hexutil.Bytes(body), nil
+}
+
+// GetReceipts retrieves the consensus RLP encoded receipts of the block with the
+// given hash.
+func (api *PrivateOdrAPI) GetReceipts(hash common.Hash) (hexutil.Bytes, error) {
+	number := core.GetBlockNumber(api.gda.chainDb, hash)
+	if core.GetHeader(api.gda.chainDb, hash, number) == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return rlp.EncodeToBytes(core.GetBlockReceipts(api.gda.chainDb, hash, number))
+}
+
+// GetCode retrieves the contract code with the given hash.
+func (api *PrivateOdrAPI) GetCode(hash common.Hash) (hexutil.Bytes, error) {
+	code, err := api.gda.blockchain.StateCache().TrieDB().Node(hash)
+	if err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// GetProof retrieves the merkle proof of a key in the state trie of the block
+// with the given hash, or in the storage trie of the account with the given key
+// hash if one is specified.
+func (api *PrivateOdrAPI) GetProof(blockHash common.Hash, accKey hexutil.Bytes, key hexutil.Bytes) ([]hexutil.Bytes, error) {
+	header := core.GetHeader(api.gda.chainDb, blockHash, core.GetBlockNumber(api.gda.chainDb, blockHash))
+	if header == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	db := api.gda.blockchain.StateCache()
+	tr, err := db.OpenTrie(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	if len(accKey) > 0 {
+		// Account keys are already hashed, look them up in the raw trie
+		raw, err := trie.New(header.Root, db.TrieDB())
+		if err != nil {
+			return nil, err
+		}
+		blob, err := raw.TryGet(accKey)
+		if err != nil {
+			return nil, err
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return nil, err
+		}
+		if tr, err = db.OpenStorageTrie(common.BytesToHash(accKey), account.Root); err != nil {
+			return nil, err
+		}
+	}
+	var nodes light.NodeList
+	if err := tr.Prove(key, 0, &nodes); err != nil {
+		return nil, err
+	}
+	proof := make([]hexutil.Bytes, len(nodes))
+	for i, node := range nodes {
+		proof[i] = hexutil.Bytes(node)
+	}
+	return proof, nil
+}
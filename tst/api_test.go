@@ -17,13 +17,20 @@
 package gda
 
 import (
+	"bytes"
+	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
 	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
 )
 
 var dumper = spew.ConfigState{Indent: "    "}
@@ -89,3 +96,71 @@ func TesgdaorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+func TestScheduledForks(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(10),
+		EIP155Block:    big.NewInt(10),
+		ByzantiumBlock: big.NewInt(100),
+	}
+	tests := []struct {
+		head uint64
+		want map[string]uint64
+	}{
+		{0, map[string]uint64{"eip150": 10, "eip155": 10, "byzantium": 100}},
+		{10, map[string]uint64{"byzantium": 100}},
+		{100, map[string]uint64{}},
+	}
+	for _, test := range tests {
+		if have := scheduledForks(config, test.head); !reflect.DeepEqual(have, test.want) {
+			t.Errorf("head %d: upcoming forks mismatch: have %v, want %v", test.head, have, test.want)
+		}
+	}
+}
+
+// testSidecarProducer is a sidecar plugin storing the hash of every block.
+type testSidecarProducer struct{}
+
+func (testSidecarProducer) Name() string { return "hash" }
+
+func (testSidecarProducer) Produce(block *types.Block, receipts types.Receipts) ([]byte, error) {
+	return block.Hash().Bytes(), nil
+}
+
+// Tests that block sidecars are served over the RPC API.
+func TestBlockSidecarAPI(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, nil)
+
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	chain.RegisterSidecarProducer(testSidecarProducer{})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	api := NewPublicgdachainAPI(&gdachain{blockchain: chain})
+
+	hash := blocks[1].Hash()
+	if names := api.GetBlockSidecarNames(hash); !reflect.DeepEqual(names, []string{"hash"}) {
+		t.Errorf("sidecar names mismatch: have %v, want [hash]", names)
+	}
+	if data, err := api.GetBlockSidecar(hash, "hash"); err != nil || !bytes.Equal(data, hash.Bytes()) {
+		t.Errorf("sidecar mismatch: have %x, %v, want %x", data, err, hash)
+	}
+	if _, err := api.GetBlockSidecar(hash, "missing"); err == nil {
+		t.Errorf("missing sidecar returned")
+	}
+	if names := api.GetBlockSidecarNames(common.Hash{0xff}); len(names) != 0 {
+		t.Errorf("sidecars of unknown block returned: %v", names)
+	}
+	if _, err := api.GetBlockSidecar(common.Hash{0xff}, "hash"); err == nil {
+		t.Errorf("sidecar of unknown block returned")
+	}
+}
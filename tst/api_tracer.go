@@ -247,6 +247,10 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 				return
 			default:
 			}
+			// Hold back tracing while block import is falling behind
+			if err := api.gda.importGate.wait(ctx, notifier.Closed(), 0); err != nil {
+				return
+			}
 			// Print progress logs if long enough time elapsed
 			if time.Since(logged) > 8*time.Second {
 				if number > origin {
@@ -386,6 +390,10 @@ func (api *PrivateDebugAPI) TraceBlockFromFile(ctx context.Context, file string,
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requestd tracer.
 func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
+	// Defer the trace while block import is falling behind
+	if err := api.gda.importGate.wait(ctx, nil, importGateMaxWait); err != nil {
+		return nil, err
+	}
 	// Create the parent state database
 	if err := api.gda.engine.VerifyHeader(api.gda.blockchain, block.Header(), true); err != nil {
 		return nil, err
@@ -532,8 +540,15 @@ func (api *PrivateDebugAPI) computeStateDB(block *types.Block, reexec uint64) (*
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	// Defer the trace while block import is falling behind
+	if err := api.gda.importGate.wait(ctx, nil, importGateMaxWait); err != nil {
+		return nil, err
+	}
 	// Retrieve the transaction and assemble its EVM context
-	tx, blockHash, _, index := core.GetTransaction(api.gda.ChainDb(), hash)
+	tx, blockHash, _, index, err := api.gda.txLookup.find(hash)
+	if err != nil {
+		return nil, err
+	}
 	if tx == nil {
 		return nil, fmt.Errorf("transaction %x not found", hash)
 	}
@@ -586,7 +601,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
 	// Run the transaction with tracing enabled.
-	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: tracer})
+	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{Debug: true, Tracer: tracer, MemoryLimit: api.gda.config.RPCEVMMemory})
 
 	ret, gas, failed, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
 	if err != nil {
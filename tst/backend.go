@@ -22,13 +22,19 @@ import (
 	"fmt"
 	"math/big"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/accounts/external"
+	"github.com/gdachain/go-gdachain/accounts/keystore"
+	"github.com/gdachain/go-gdachain/chainevents"
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/common/hexutil"
 	"github.com/gdachain/go-gdachain/consensus"
+	"github.com/gdachain/go-gdachain/consensus/alert"
 	"github.com/gdachain/go-gdachain/consensus/clique"
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
@@ -70,17 +76,31 @@ type gdachain struct {
 	txPool          *core.TxPool
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
+	importGate      *importGate
+	confirmations   *confirmationTracker
+	localTxs        *localTxMonitor
+	txLookup        *txLookup
+	exporter        *blockExporter
+	stateStats      *stateStatsCollector
+	chainEvents     *chainevents.Feed
+	eventSocket     *chainevents.SocketPublisher // Local chain event socket, nil if disabled
+	alertHook       *alert.Webhook               // Consensus fault webhook, nil if disabled
+	alertReorgs     event.Subscription           // Deep reorg watcher, nil if alerts are disabled
 	lesServer       LesServer
+	shutdownTracker *shutdownTracker
 
 	// DB interfaces
 	chainDb gdadb.Database // Block chain database
 
-	eventMux       *event.TypeMux
 	engine         consensus.Engine
 	accountManager *accounts.Manager
 
-	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
-	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	bloomRequests         chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
+	bloomPriorityRequests chan chan *bloombits.Retrieval // Channel receiving latency sensitive bloom data retrieval requests
+	bloomIndexer          *core.ChainIndexer             // Bloom indexer operating during block imports
+	indexers              map[string]*core.ChainIndexer  // User-defined indexers registered in the core registry
+	addressIndex          *core.ChainIndexer             // Index of the activity of every address, nil if disabled
+	tokenIndex            *core.ChainIndexer             // Index of the token transfers of every address, nil if disabled
 
 	ApiBackend *gdaApiBackend
 
@@ -108,6 +128,20 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	if config.BloomFilterThreads <= 0 {
+		config.BloomFilterThreads = bloomFilterThreads
+	}
+	if config.BloomRetrievalBatch <= 0 {
+		config.BloomRetrievalBatch = bloomRetrievalBatch
+	}
+	if config.BloomRetrievalWait <= 0 {
+		config.BloomRetrievalWait = bloomRetrievalWait
+	}
+	if config.DevMode {
+		if err := setupDevMode(ctx, config); err != nil {
+			return nil, err
+		}
+	}
 	chainDb, err := CreateDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
@@ -123,7 +157,6 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 		config:         config,
 		chainDb:        chainDb,
 		chainConfig:    chainConfig,
-		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
 		engine:         CreateConsensusEngine(ctx, &config.gdaash, chainConfig, chainDb),
 		shutdownChan:   make(chan bool),
@@ -133,6 +166,9 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 		gdaerbase:      config.gdaerbase,
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks),
+		indexers:       make(map[string]*core.ChainIndexer),
+
+		bloomPriorityRequests: make(chan chan *bloombits.Retrieval),
 	}
 
 	log.Info("Initialising gdachain protocol", "versions", ProtocolVersions, "network", config.NetworkId)
@@ -145,13 +181,29 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 		core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
 	}
 	var (
-		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording, RecordRevertReasons: config.RecordRevertReasons}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout, TdCacheLimit: config.TdCache}
 	)
-	gda.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, gda.chainConfig, gda.engine, vmConfig)
+	if config.TrieJournal != "" {
+		cacheConfig.Journal = ctx.ResolvePath(config.TrieJournal)
+	}
+	// Verify blocks through the alerting wrapper if consensus faults are reported,
+	// keeping the raw engine for sealing
+	verifier := gda.engine
+	if config.AlertWebhook != "" {
+		gda.alertHook = alert.NewWebhook(config.AlertWebhook)
+		verifier = alert.Wrap(gda.engine, gda.alertHook)
+	}
+	gda.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, gda.chainConfig, verifier, vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	gda.blockchain.SetVMProfiling(config.VMProfileBlocks)
+	checkpoints, err := MakeCheckpoints(config.Checkpoints, genesisHash)
 	if err != nil {
 		return nil, err
 	}
+	gda.blockchain.SetCheckpoints(checkpoints)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -159,17 +211,69 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	gda.bloomIndexer.Start(gda.blockchain)
+	for _, spec := range core.RegisteredIndexers() {
+		indexer, err := core.NewRegisteredIndexer(chainDb, spec)
+		if err != nil {
+			return nil, err
+		}
+		indexer.Start(gda.blockchain)
+		gda.indexers[spec.Name] = indexer
+	}
+	if config.AddressIndex {
+		if gda.addressIndex, err = newAddressIndex(chainDb, gda.blockchain); err != nil {
+			return nil, err
+		}
+		gda.addressIndex.Start(gda.blockchain)
+	}
+	if config.TokenIndex {
+		if gda.tokenIndex, err = newTokenIndex(chainDb, gda.blockchain); err != nil {
+			return nil, err
+		}
+		gda.tokenIndex.Start(gda.blockchain)
+	}
+	if gda.alertHook != nil {
+		gda.alertReorgs = alert.WatchReorgs(gda.blockchain, config.AlertReorgDepth, gda.alertHook)
+	}
+	gda.confirmations = newConfirmationTracker(gda.blockchain)
+	gda.txLookup = newTxLookup(chainDb, gda.blockchain, config.TxLookupScan)
+	gda.exporter = newBlockExporter(chainDb, gda.blockchain)
+	gda.stateStats = newStateStatsCollector(gda.blockchain)
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
+	if config.TxPool.PolicyRules != "" {
+		config.TxPool.PolicyRules = ctx.ResolvePath(config.TxPool.PolicyRules)
+		if _, err := core.LoadPolicyRules(config.TxPool.PolicyRules); err != nil {
+			return nil, fmt.Errorf("invalid transaction admission policy: %v", err)
+		}
+	}
 	gda.txPool = core.NewTxPool(config.TxPool, gda.chainConfig, gda.blockchain)
+	gda.chainEvents = chainevents.NewFeed(gda.blockchain, gda.txPool)
+	if config.EventSocket != "" {
+		if gda.eventSocket, err = chainevents.NewSocketPublisher(gda.chainEvents, eventSocketEndpoint(ctx, config.EventSocket)); err != nil {
+			return nil, err
+		}
+	}
 
-	if gda.protocolManager, err = NewProtocolManager(gda.chainConfig, config.SyncMode, config.NetworkId, gda.eventMux, gda.txPool, gda.engine, gda.blockchain, chainDb); err != nil {
+	if gda.protocolManager, err = NewProtocolManager(gda.chainConfig, config.SyncMode, config.NetworkId, gda, gda.txPool, verifier, gda.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	gda.miner = miner.New(gda, gda.chainConfig, gda.EventMux(), gda.engine)
+	gda.protocolManager.requestRate, gda.protocolManager.requestBurst = config.PeerRequestRate, config.PeerRequestBurst
+	gda.protocolManager.downloader.SetCheckpoints(checkpoints)
+	gda.importGate = newImportGate(config.DebugImportLag, gda.protocolManager.downloader)
+
+	gda.miner = miner.New(gda, gda.chainConfig, gda.engine)
 	gda.miner.SetExtra(makeExtraData(config.ExtraData))
+	if err := gda.miner.SetUnclePolicy(config.UnclePolicy); err != nil {
+		return nil, err
+	}
+	gda.miner.SetRecommitInterval(config.MinerRecommit)
+	gda.miner.SetEmptyWait(config.MinerEmptyWait)
+	gda.miner.SetDeadline(config.MinerDeadline)
 
 	gda.ApiBackend = &gdaApiBackend{gda, nil}
 	gpoParams := config.GPO
@@ -177,6 +281,8 @@ func New(ctx *node.ServiceContext, config *Config) (*gdachain, error) {
 		gpoParams.Default = config.GasPrice
 	}
 	gda.ApiBackend.gpo = gasprice.NewOracle(gda.ApiBackend, gpoParams)
+	gda.localTxs = newLocalTxMonitor(gda)
+	gda.shutdownTracker = newShutdownTracker(chainDb)
 
 	return gda, nil
 }
@@ -198,6 +304,18 @@ func makeExtraData(extra []byte) []byte {
 	return extra
 }
 
+// eventSocketEndpoint resolves the location of the chain event socket, placing it
+// into the data directory or the named pipe namespace on Windows.
+func eventSocketEndpoint(ctx *node.ServiceContext, path string) string {
+	if runtime.GOOS == "windows" {
+		if strings.HasPrefix(path, `\\.\pipe\`) {
+			return path
+		}
+		return `\\.\pipe\` + path
+	}
+	return ctx.ResolvePath(path)
+}
+
 // CreateDB creates the chain database.
 func CreateDB(ctx *node.ServiceContext, config *Config, name string) (gdadb.Database, error) {
 	db, err := ctx.OpenDatabase(name, config.DatabaseCache, config.DatabaseHandles)
@@ -210,6 +328,19 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (gdadb.Data
 	return db, nil
 }
 
+// MakeCheckpoints resolves the checkpoints to enforce, falling back to the ones
+// hard-coded for the network if no override was configured.
+func MakeCheckpoints(override string, genesis common.Hash) (params.Checkpoints, error) {
+	if override == "" {
+		return params.KnownCheckpoints(genesis), nil
+	}
+	checkpoints, err := params.ParseCheckpoints(override)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoints: %v", err)
+	}
+	return checkpoints, nil
+}
+
 // CreateConsensusEngine creates the required type of consensus engine instance for an gdachain service
 func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chainConfig *params.ChainConfig, db gdadb.Database) consensus.Engine {
 	// If proof-of-authority is requested, set it up
@@ -249,8 +380,8 @@ func (s *gdachain) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
-	// Append all the local APIs and return
-	return append(apis, []rpc.API{
+	// Append all the local APIs
+	apis = append(apis, []rpc.API{
 		{
 			Namespace: "gda",
 			Version:   "1.0",
@@ -264,17 +395,32 @@ func (s *gdachain) APIs() []rpc.API {
 		}, {
 			Namespace: "gda",
 			Version:   "1.0",
-			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader, s.eventMux),
+			Service:   downloader.NewPublicDownloaderAPI(s.protocolManager.downloader),
 			Public:    true,
+		}, {
+			Namespace: "gda",
+			Version:   "1.0",
+			Service:   downloader.NewPrivateDownloaderAPI(s.protocolManager.downloader),
+			Public:    false,
+		}, {
+			Namespace: "odr",
+			Version:   "1.0",
+			Service:   NewPrivateOdrAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "miner",
 			Version:   "1.0",
 			Service:   NewPrivateMinerAPI(s),
 			Public:    false,
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(s),
+			Public:    false,
 		}, {
 			Namespace: "gda",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false),
+			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false, s.config.Filters),
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -296,6 +442,17 @@ func (s *gdachain) APIs() []rpc.API {
 			Public:    true,
 		},
 	}...)
+
+	// Append the token index API if the index is maintained
+	if s.tokenIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "token",
+			Version:   "1.0",
+			Service:   NewPublicTokenAPI(s),
+			Public:    true,
+		})
+	}
+	return apis
 }
 
 func (s *gdachain) ResetWithGenesisBlock(gb *types.Block) {
@@ -335,6 +492,9 @@ func (self *gdachain) Setgdaerbase(gdaerbase common.Address) {
 }
 
 func (s *gdachain) StartMining(local bool) error {
+	if s.config.SyncMode == downloader.HeaderSync {
+		return errors.New("mining unavailable in header sync mode")
+	}
 	eb, err := s.gdaerbase()
 	if err != nil {
 		log.Error("Cannot start mining without gdaerbase", "err", err)
@@ -346,6 +506,11 @@ func (s *gdachain) StartMining(local bool) error {
 			log.Error("gdaerbase account unavailable locally", "err", err)
 			return fmt.Errorf("signer missing: %v", err)
 		}
+		// Hardware wallets only sign transactions, never arbitrary header hashes
+		if scheme := wallet.URL().Scheme; scheme != keystore.KeyStoreScheme && scheme != external.Scheme {
+			log.Error("gdaerbase account cannot seal blocks", "wallet", wallet.URL())
+			return fmt.Errorf("signer %s is held by a %s wallet, which cannot seal blocks", eb.Hex(), wallet.URL().Scheme)
+		}
 		clique.Authorize(eb, wallet.SignHash)
 	}
 	if local {
@@ -366,7 +531,6 @@ func (s *gdachain) Miner() *miner.Miner { return s.miner }
 func (s *gdachain) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *gdachain) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *gdachain) TxPool() *core.TxPool               { return s.txPool }
-func (s *gdachain) EventMux() *event.TypeMux           { return s.eventMux }
 func (s *gdachain) Engine() consensus.Engine           { return s.engine }
 func (s *gdachain) ChainDb() gdadb.Database            { return s.chainDb }
 func (s *gdachain) IsListening() bool                  { return true } // Always listening
@@ -374,6 +538,33 @@ func (s *gdachain) gdaVersion() int                    { return int(s.protocolMa
 func (s *gdachain) NetVersion() uint64                 { return s.networkId }
 func (s *gdachain) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// SubscribeNewMinedBlockEvent registers a subscription of the blocks sealed by
+// the local miner.
+func (s *gdachain) SubscribeNewMinedBlockEvent(ch chan<- core.NewMinedBlockEvent) event.Subscription {
+	return s.miner.SubscribeNewMinedBlockEvent(ch)
+}
+
+// HealthState implements node.HealthChecker, reporting the head of the chain,
+// the sync status and, if requested, whgdaer the chain database accepts writes.
+func (s *gdachain) HealthState(probeDB bool) node.HealthState {
+	state := node.HealthState{
+		Head:    time.Unix(s.blockchain.CurrentBlock().Time().Int64(), 0),
+		Syncing: s.protocolManager.downloader.Synchronising(),
+	}
+	if probeDB {
+		state.DBError = core.WriteHealthProbe(s.chainDb)
+	}
+	return state
+}
+
+// SetTxIndexer installs an external transaction index, consulted for transactions
+// missing from the local lookup index. Passing nil removes the index.
+func (s *gdachain) SetTxIndexer(indexer TxIndexer) { s.txLookup.setIndexer(indexer) }
+
+// ChainEvents returns the versioned public chain and transaction pool event feed,
+// meant to be consumed by plugins and exporters.
+func (s *gdachain) ChainEvents() *chainevents.Feed { return s.chainEvents }
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *gdachain) Protocols() []p2p.Protocol {
@@ -405,27 +596,86 @@ func (s *gdachain) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Developer mode seals its own blocks from the get go
+	if s.config.DevMode {
+		if err := s.StartMining(true); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutines used by the
 // gdachain protocol.
 func (s *gdachain) Stop() error {
-	if s.stopDbUpgrade != nil {
-		s.stopDbUpgrade()
-	}
-	s.bloomIndexer.Close()
-	s.blockchain.Stop()
+	// Stop accepting blocks and transactions from the network and the miner
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
-	s.txPool.Stop()
 	s.miner.Stop()
-	s.eventMux.Stop()
 
+	if s.stopDbUpgrade != nil {
+		s.stopDbUpgrade()
+	}
+	s.bloomIndexer.Close()
+	for _, indexer := range s.indexers {
+		indexer.Close()
+	}
+	if s.addressIndex != nil {
+		s.addressIndex.Close()
+	}
+	if s.tokenIndex != nil {
+		s.tokenIndex.Close()
+	}
+	s.confirmations.stop()
+	s.localTxs.stop()
+	s.exporter.stop()
+	s.stateStats.stop()
+	if s.eventSocket != nil {
+		s.eventSocket.Close()
+	}
+	s.chainEvents.Stop()
+	if s.alertHook != nil {
+		s.alertReorgs.Unsubscribe()
+		s.alertHook.Close()
+	}
+
+	// Flush the trie caches and the transaction journal, marking the shutdown
+	// clean only if they made it to disk in time
+	clean := s.flush()
+	s.shutdownTracker.stop(clean)
+	if !clean {
+		// The flush is still writing into the database, leave it open
+		close(s.shutdownChan)
+		return errShutdownTimeout
+	}
 	s.chainDb.Close()
 	close(s.shutdownChan)
 
 	return nil
 }
+
+// flush stops the blockchain and the transaction pool, writing their caches and
+// journals to disk. It reports whgdaer they finished within the shutdown timeout.
+func (s *gdachain) flush() bool {
+	done := make(chan struct{})
+	go func() {
+		s.blockchain.Stop()
+		s.txPool.Stop()
+		close(done)
+	}()
+	var timeout <-chan time.Time
+	if s.config.ShutdownTimeout > 0 {
+		timer := time.NewTimer(s.config.ShutdownTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-done:
+		return true
+	case <-timeout:
+		log.Error("Timed out flushing chain state, the next startup will be unclean", "timeout", s.config.ShutdownTimeout)
+		return false
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// BlockEncoder writes blocks along with their receipts into an export format.
+type BlockEncoder interface {
+	// Encode writes a single block and its receipts to the output.
+	Encode(block *types.Block, receipts types.Receipts) error
+
+	// Flush writes any buffered data to the output.
+	Flush() error
+}
+
+// BlockEncoderFactory creates a block encoder writing into w.
+type BlockEncoderFactory func(w io.Writer, config *params.ChainConfig) BlockEncoder
+
+var (
+	blockEncodersLock sync.RWMutex
+	blockEncoders     = map[string]BlockEncoderFactory{
+		"rlp":          newRLPBlockEncoder,
+		"jsonl":        newJSONBlockEncoder,
+		"csv-headers":  newHeaderCSVEncoder,
+		"csv-txs":      newTxCSVEncoder,
+		"csv-receipts": newReceiptCSVEncoder,
+	}
+)
+
+// RegisterBlockEncoder makes an export format available to debug_exportBlocks.
+// Registering a format a second time replaces the previous encoder.
+func RegisterBlockEncoder(format string, factory BlockEncoderFactory) {
+	blockEncodersLock.Lock()
+	defer blockEncodersLock.Unlock()
+
+	blockEncoders[format] = factory
+}
+
+// blockEncoder returns the encoder factory of an export format.
+func blockEncoder(format string) (BlockEncoderFactory, error) {
+	blockEncodersLock.RLock()
+	defer blockEncodersLock.RUnlock()
+
+	factory, ok := blockEncoders[format]
+	if !ok {
+		formats := make([]string, 0, len(blockEncoders))
+		for name := range blockEncoders {
+			formats = append(formats, name)
+		}
+		sort.Strings(formats)
+		return nil, fmt.Errorf("unknown export format %q, available: %v", format, formats)
+	}
+	return factory, nil
+}
+
+// rlpBlockEncoder writes blocks in the format of admin_exportChain, importable
+// with admin_importChain. Receipts are not exported.
+type rlpBlockEncoder struct {
+	w io.Writer
+}
+
+func newRLPBlockEncoder(w io.Writer, config *params.ChainConfig) BlockEncoder {
+	return &rlpBlockEncoder{w: w}
+}
+
+func (e *rlpBlockEncoder) Encode(block *types.Block, receipts types.Receipts) error {
+	return block.EncodeRLP(e.w)
+}
+
+func (e *rlpBlockEncoder) Flush() error { return nil }
+
+// jsonBlockEncoder writes one JSON object per line and block, containing the
+// header, the transactions and the receipts.
+type jsonBlockEncoder struct {
+	enc    *json.Encoder
+	config *params.ChainConfig
+}
+
+func newJSONBlockEncoder(w io.Writer, config *params.ChainConfig) BlockEncoder {
+	return &jsonBlockEncoder{enc: json.NewEncoder(w), config: config}
+}
+
+func (e *jsonBlockEncoder) Encode(block *types.Block, receipts types.Receipts) error {
+	signer := types.MakeSigner(e.config, block.Number())
+	if err := receipts.DeriveFields(signer, block.Hash(), block.NumberU64(), block.Transactions()); err != nil {
+		return fmt.Errorf("block #%d: %v", block.NumberU64(), err)
+	}
+	return e.enc.Encode(map[string]interface{}{
+		"hash":         block.Hash(),
+		"header":       block.Header(),
+		"transactions": block.Transactions(),
+		"uncles":       block.Uncles(),
+		"receipts":     receipts,
+	})
+}
+
+func (e *jsonBlockEncoder) Flush() error { return nil }
+
+// csvEncoder is the base of the CSV encoders, writing the column names before
+// the first record.
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []string
+	started bool
+}
+
+func (e *csvEncoder) write(record []string) error {
+	if !e.started {
+		if err := e.w.Write(e.columns); err != nil {
+			return err
+		}
+		e.started = true
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// headerCSVEncoder writes one row per block header.
+type headerCSVEncoder struct {
+	csvEncoder
+}
+
+func newHeaderCSVEncoder(w io.Writer, config *params.ChainConfig) BlockEncoder {
+	return &headerCSVEncoder{csvEncoder{
+		w:       csv.NewWriter(w),
+		columns: []string{"number", "hash", "parent_hash", "timestamp", "miner", "difficulty", "gas_limit", "gas_used", "transactions", "uncles"},
+	}}
+}
+
+func (e *headerCSVEncoder) Encode(block *types.Block, receipts types.Receipts) error {
+	return e.write([]string{
+		strconv.FormatUint(block.NumberU64(), 10),
+		block.Hash().Hex(),
+		block.ParentHash().Hex(),
+		block.Time().String(),
+		block.Coinbase().Hex(),
+		block.Difficulty().String(),
+		strconv.FormatUint(block.GasLimit(), 10),
+		strconv.FormatUint(block.GasUsed(), 10),
+		strconv.Itoa(len(block.Transactions())),
+		strconv.Itoa(len(block.Uncles())),
+	})
+}
+
+// txCSVEncoder writes one row per transaction.
+type txCSVEncoder struct {
+	csvEncoder
+	config *params.ChainConfig
+}
+
+func newTxCSVEncoder(w io.Writer, config *params.ChainConfig) BlockEncoder {
+	return &txCSVEncoder{
+		csvEncoder: csvEncoder{
+			w:       csv.NewWriter(w),
+			columns: []string{"block_number", "block_hash", "index", "hash", "from", "to", "nonce", "value", "gas", "gas_price", "input"},
+		},
+		config: config,
+	}
+}
+
+func (e *txCSVEncoder) Encode(block *types.Block, receipts types.Receipts) error {
+	signer := types.MakeSigner(e.config, block.Number())
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		var to string
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		err = e.write([]string{
+			strconv.FormatUint(block.NumberU64(), 10),
+			block.Hash().Hex(),
+			strconv.Itoa(i),
+			tx.Hash().Hex(),
+			from.Hex(),
+			to,
+			strconv.FormatUint(tx.Nonce(), 10),
+			tx.Value().String(),
+			strconv.FormatUint(tx.Gas(), 10),
+			tx.GasPrice().String(),
+			hexutil.Encode(tx.Data()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receiptCSVEncoder writes one row per transaction receipt.
+type receiptCSVEncoder struct {
+	csvEncoder
+}
+
+func newReceiptCSVEncoder(w io.Writer, config *params.ChainConfig) BlockEncoder {
+	return &receiptCSVEncoder{csvEncoder{
+		w:       csv.NewWriter(w),
+		columns: []string{"block_number", "block_hash", "index", "tx_hash", "status", "gas_used", "cumulative_gas_used", "contract_address", "logs"},
+	}}
+}
+
+func (e *receiptCSVEncoder) Encode(block *types.Block, receipts types.Receipts) error {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return fmt.Errorf("block #%d: receipt count mismatch: have %d, want %d", block.NumberU64(), len(receipts), len(txs))
+	}
+	for i, receipt := range receipts {
+		var contract string
+		if receipt.ContractAddress != (common.Address{}) {
+			contract = receipt.ContractAddress.Hex()
+		}
+		var status string
+		if len(receipt.Posgdaate) == 0 {
+			status = strconv.FormatUint(uint64(receipt.Status), 10)
+		}
+		err := e.write([]string{
+			strconv.FormatUint(block.NumberU64(), 10),
+			block.Hash().Hex(),
+			strconv.Itoa(i),
+			txs[i].Hash().Hex(),
+			status,
+			strconv.FormatUint(receipt.GasUsed, 10),
+			strconv.FormatUint(receipt.CumulativeGasUsed, 10),
+			contract,
+			strconv.Itoa(len(receipt.Logs)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+const (
+	// maxRunningExports is the maximum number of block exports running at once.
+	maxRunningExports = 2
+
+	// maxExportHistory is the number of finished block exports whose outcome is
+	// retained for status queries.
+	maxExportHistory = 32
+)
+
+var (
+	errExportCancelled = errors.New("export cancelled")
+	errTooManyExports  = errors.New("too many exports running")
+	errUnknownExport   = errors.New("unknown export")
+)
+
+// ExportRange is the inclusive range of block numbers to export.
+type ExportRange struct {
+	First uint64 `json:"first"`
+	Last  uint64 `json:"last"`
+}
+
+// ExportProgress reports the state of a block export job.
+type ExportProgress struct {
+	ID       int         `json:"id"`
+	Format   string      `json:"format"`
+	Range    ExportRange `json:"range"`
+	Path     string      `json:"path"`
+	Exported uint64      `json:"exported"` // Number of blocks written so far
+	Started  time.Time   `json:"started"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// exportJob is a block export running in the background.
+type exportJob struct {
+	progress ExportProgress // Guarded by the exporter's lock
+	cancel   chan struct{}
+}
+
+// blockExporter runs block exports in the background, tracking their progress.
+type blockExporter struct {
+	db    gdadb.Database
+	chain *core.BlockChain
+
+	jobs    map[int]*exportJob
+	history []int // Finished jobs, oldest first
+	running int
+	nextID  int
+	lock    sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newBlockExporter creates an exporter for the blocks of the given chain.
+func newBlockExporter(db gdadb.Database, chain *core.BlockChain) *blockExporter {
+	return &blockExporter{
+		db:    db,
+		chain: chain,
+		jobs:  make(map[int]*exportJob),
+		quit:  make(chan struct{}),
+	}
+}
+
+// start validates an export request, creates its output file and starts the
+// export in the background, returning the job's initial progress.
+func (e *blockExporter) start(format string, blocks ExportRange, path string) (ExportProgress, error) {
+	factory, err := blockEncoder(format)
+	if err != nil {
+		return ExportProgress{}, err
+	}
+	if blocks.First > blocks.Last {
+		return ExportProgress{}, fmt.Errorf("first (%d) is greater than last (%d)", blocks.First, blocks.Last)
+	}
+	if head := e.chain.CurrentBlock().NumberU64(); blocks.Last > head {
+		return ExportProgress{}, fmt.Errorf("last (%d) is above the current head (%d)", blocks.Last, head)
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.running >= maxRunningExports {
+		return ExportProgress{}, errTooManyExports
+	}
+	// Never overwrite existing files, the path is chosen by the caller
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return ExportProgress{}, err
+	}
+	e.nextID++
+	job := &exportJob{
+		progress: ExportProgress{
+			ID:      e.nextID,
+			Format:  format,
+			Range:   blocks,
+			Path:    path,
+			Started: time.Now(),
+		},
+		cancel: make(chan struct{}),
+	}
+	e.jobs[job.progress.ID] = job
+	e.running++
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		err := e.export(job, out, factory)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		e.finish(job, err)
+	}()
+	return job.progress, nil
+}
+
+// export writes the blocks of an export job into the output file.
+func (e *blockExporter) export(job *exportJob, out *os.File, factory BlockEncoderFactory) error {
+	var (
+		buffered = bufio.NewWriter(out)
+		writer   io.Writer
+		zipper   *gzip.Writer
+	)
+	writer = buffered
+	if strings.HasSuffix(job.progress.Path, ".gz") {
+		zipper = gzip.NewWriter(buffered)
+		writer = zipper
+	}
+	enc := factory(writer, e.chain.Config())
+
+	blocks := job.progress.Range
+	log.Info("Exporting blocks", "id", job.progress.ID, "format", job.progress.Format, "first", blocks.First, "last", blocks.Last, "path", job.progress.Path)
+
+	for number := blocks.First; ; number++ {
+		select {
+		case <-job.cancel:
+			return errExportCancelled
+		case <-e.quit:
+			return errExportCancelled
+		default:
+		}
+		block := e.chain.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", number)
+		}
+		receipts := core.GetBlockReceipts(e.db, block.Hash(), number)
+		if err := enc.Encode(block, receipts); err != nil {
+			return fmt.Errorf("export failed on #%d: %v", number, err)
+		}
+		e.lock.Lock()
+		job.progress.Exported++
+		e.lock.Unlock()
+
+		if number == blocks.Last {
+			break // Avoid overflowing at the end of the uint64 range
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	if zipper != nil {
+		if err := zipper.Close(); err != nil {
+			return err
+		}
+	}
+	return buffered.Flush()
+}
+
+// finish records the outcome of an export job, dropping the oldest finished
+// jobs beyond the history limit.
+func (e *blockExporter) finish(job *exportJob, err error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	now := time.Now()
+	job.progress.Finished = &now
+	if err != nil {
+		job.progress.Error = err.Error()
+		log.Warn("Block export failed", "id", job.progress.ID, "exported", job.progress.Exported, "err", err)
+	} else {
+		log.Info("Exported blocks", "id", job.progress.ID, "exported", job.progress.Exported, "elapsed", common.PrettyDuration(now.Sub(job.progress.Started)))
+	}
+	e.running--
+
+	e.history = append(e.history, job.progress.ID)
+	for len(e.history) > maxExportHistory {
+		delete(e.jobs, e.history[0])
+		e.history = e.history[1:]
+	}
+}
+
+// status returns the progress of an export job.
+func (e *blockExporter) status(id int) (ExportProgress, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok {
+		return ExportProgress{}, errUnknownExport
+	}
+	return job.progress, nil
+}
+
+// cancel aborts a running export job, returning whether it was running.
+func (e *blockExporter) cancel(id int) (bool, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	job, ok := e.jobs[id]
+	if !ok {
+		return false, errUnknownExport
+	}
+	if job.progress.Finished != nil {
+		return false, nil
+	}
+	select {
+	case <-job.cancel:
+		return false, nil
+	default:
+		close(job.cancel)
+	}
+	return true, nil
+}
+
+// stop aborts all running export jobs and waits for them to terminate.
+func (e *blockExporter) stop() {
+	close(e.quit)
+	e.wg.Wait()
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// waitExport waits until an export job finishes, returning its final progress.
+func waitExport(t *testing.T, exporter *blockExporter, id int) ExportProgress {
+	for i := 0; i < 100; i++ {
+		progress, err := exporter.status(id)
+		if err != nil {
+			t.Fatalf("failed to retrieve export status: %v", err)
+		}
+		if progress.Finished != nil {
+			return progress
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("export %d did not finish", id)
+	return ExportProgress{}
+}
+
+// Tests that block ranges are exported in the requested formats.
+func TestBlockExport(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	generator := func(i int, block *core.BlockGen) {
+		for j := 0; j < 2; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 4, generator, nil)
+	defer pm.Stop()
+
+	dir, err := ioutil.TempDir("", "blockexport-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	exporter := newBlockExporter(db, pm.blockchain)
+	defer exporter.stop()
+
+	// Export the transactions of blocks 2-3 as CSV
+	path := filepath.Join(dir, "txs.csv")
+	progress, err := exporter.start("csv-txs", ExportRange{First: 2, Last: 3}, path)
+	if err != nil {
+		t.Fatalf("failed to start export: %v", err)
+	}
+	if progress = waitExport(t, exporter, progress.ID); progress.Error != "" || progress.Exported != 2 {
+		t.Fatalf("export outcome mismatch: exported %d, error %q", progress.Exported, progress.Error)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open export: %v", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse export: %v", err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("record count mismatch: have %d, want %d", len(records), 5)
+	}
+	if records[1][0] != "2" || records[1][4] != testBank.Hex() {
+		t.Fatalf("first record mismatch: have %v", records[1])
+	}
+	// Export receipts as JSON lines
+	path = filepath.Join(dir, "blocks.jsonl")
+	if progress, err = exporter.start("jsonl", ExportRange{First: 0, Last: 4}, path); err != nil {
+		t.Fatalf("failed to start export: %v", err)
+	}
+	if progress = waitExport(t, exporter, progress.ID); progress.Error != "" {
+		t.Fatalf("export failed: %v", progress.Error)
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read export: %v", err)
+	}
+	if lines := strings.Count(string(blob), "\n"); lines != 5 {
+		t.Fatalf("line count mismatch: have %d, want %d", lines, 5)
+	}
+	if want := fmt.Sprintf(`"from":"%s"`, strings.ToLower(testBank.Hex())); !strings.Contains(string(blob), want) {
+		t.Fatalf("receipts not decorated with their sender: missing %s", want)
+	}
+	// Invalid requests are rejected
+	if _, err := exporter.start("parquet", ExportRange{First: 0, Last: 1}, filepath.Join(dir, "blocks.parquet")); err == nil {
+		t.Fatalf("unknown format accepted")
+	}
+	if _, err := exporter.start("rlp", ExportRange{First: 0, Last: 5}, filepath.Join(dir, "blocks.rlp")); err == nil {
+		t.Fatalf("range above head accepted")
+	}
+	if _, err := exporter.start("rlp", ExportRange{First: 0, Last: 1}, path); err == nil {
+		t.Fatalf("existing file overwritten")
+	}
+	if _, err := exporter.status(100); err != errUnknownExport {
+		t.Fatalf("unknown export status error mismatch: have %v, want %v", err, errUnknownExport)
+	}
+}
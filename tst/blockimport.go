@@ -0,0 +1,202 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+// defaultImportBatch is the number of blocks inserted into the chain at once if
+// not configured otherwise.
+const defaultImportBatch = 2500
+
+var errImportInterrupted = errors.New("import interrupted")
+
+// ImportOptions configures a block import.
+type ImportOptions struct {
+	BatchSize int                  // Number of blocks inserted at once (0 = 2500)
+	Progress  func(ImportProgress) // Invoked after every processed batch (nil = no reporting)
+	Stop      <-chan struct{}      // Aborts the import before the next batch when closed (nil = never)
+}
+
+// ImportProgress reports the state of a block import.
+type ImportProgress struct {
+	Read     uint64        // Blocks decoded from the input, excluding the genesis
+	Imported uint64        // Blocks inserted into the chain
+	Skipped  uint64        // Blocks already present in the chain
+	Number   uint64        // Number of the last processed block
+	Elapsed  time.Duration // Time since the import started
+}
+
+// importBatch is a batch of decoded blocks ready to be inserted, along with the
+// error that terminated the decoding, if any.
+type importBatch struct {
+	blocks types.Blocks
+	err    error
+}
+
+// ImportBlocks reads a stream of RLP encoded blocks, as written by the chain
+// exporters, and inserts them into the chain in batches, skipping the ones that
+// are already present. The next batch is decoded and its transaction senders
+// recovered in parallel while the current one is being inserted, whose headers
+// are in turn verified in parallel by the consensus engine.
+//
+// The progress reached is returned even if the import fails midway, in which
+// case all batches before the failing block are imported.
+func ImportBlocks(chain *core.BlockChain, r io.Reader, opts ImportOptions) (ImportProgress, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultImportBatch
+	}
+	var (
+		start    = time.Now()
+		progress ImportProgress
+		batches  = make(chan importBatch, 1)
+		done     = make(chan struct{})
+	)
+	defer close(done)
+	go decodeBlocks(chain.Config(), rlp.NewStream(r, 0), opts.BatchSize, batches, done)
+
+	for batch := range batches {
+		select {
+		case <-opts.Stop:
+			return progress, errImportInterrupted
+		default:
+		}
+		if len(batch.blocks) > 0 {
+			progress.Read += uint64(len(batch.blocks))
+			progress.Number = batch.blocks[len(batch.blocks)-1].NumberU64()
+
+			missing := missingBlocks(chain, batch.blocks)
+			progress.Skipped += uint64(len(batch.blocks) - len(missing))
+			if len(missing) > 0 {
+				if index, err := chain.InsertChain(missing); err != nil {
+					if index < len(missing) {
+						progress.Imported += uint64(index)
+						return progress, fmt.Errorf("block %d: failed to insert: %v", missing[index].NumberU64(), err)
+					}
+					return progress, err
+				}
+				progress.Imported += uint64(len(missing))
+			}
+			progress.Elapsed = time.Since(start)
+			if opts.Progress != nil {
+				opts.Progress(progress)
+			}
+		}
+		if batch.err != nil {
+			return progress, batch.err
+		}
+	}
+	return progress, nil
+}
+
+// ImportBlocks reads a stream of RLP encoded blocks and inserts them into the
+// chain of the service. See the package level ImportBlocks for details.
+func (s *gdachain) ImportBlocks(r io.Reader, opts ImportOptions) (ImportProgress, error) {
+	return ImportBlocks(s.blockchain, r, opts)
+}
+
+// decodeBlocks reads batches of blocks from the stream until its end or the
+// first decoding error, recovering the transaction senders of each batch before
+// handing it over. The genesis block is skipped. The batches channel is closed
+// when decoding stops.
+func decodeBlocks(config *params.ChainConfig, stream *rlp.Stream, size int, batches chan<- importBatch, done <-chan struct{}) {
+	defer close(batches)
+
+	for index, eof := 0, false; !eof; {
+		var batch importBatch
+		for len(batch.blocks) < size {
+			block := new(types.Block)
+			if err := stream.Decode(block); err == io.EOF {
+				eof = true
+				break
+			} else if err != nil {
+				batch.err = fmt.Errorf("block %d: failed to parse: %v", index, err)
+				eof = true
+				break
+			}
+			index++
+			if block.NumberU64() == 0 {
+				continue
+			}
+			batch.blocks = append(batch.blocks, block)
+		}
+		recoverSenders(config, batch.blocks)
+
+		select {
+		case batches <- batch:
+		case <-done:
+			return
+		}
+	}
+}
+
+// recoverSenders derives the senders of all transactions in the blocks on all
+// CPU cores, caching them in the transactions for the block processor.
+func recoverSenders(config *params.ChainConfig, blocks types.Blocks) {
+	var (
+		tasks = make(chan *types.Block, len(blocks))
+		pend  sync.WaitGroup
+	)
+	for _, block := range blocks {
+		tasks <- block
+	}
+	close(tasks)
+
+	for i := 0; i < runtime.NumCPU(); i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+			for block := range tasks {
+				signer := types.MakeSigner(config, block.Number())
+				for _, tx := range block.Transactions() {
+					types.Sender(signer, tx)
+				}
+			}
+		}()
+	}
+	pend.Wait()
+}
+
+// missingBlocks returns the blocks starting from the first one that needs to be
+// imported. Blocks behind the chain head only need to be present, while blocks
+// from the head on must also have their state available.
+func missingBlocks(chain *core.BlockChain, blocks []*types.Block) []*types.Block {
+	head := chain.CurrentBlock()
+	for i, block := range blocks {
+		if head.NumberU64() > block.NumberU64() {
+			if !chain.HasBlock(block.Hash(), block.NumberU64()) {
+				return blocks[i:]
+			}
+			continue
+		}
+		if !chain.HasBlockAndState(block.Hash(), block.NumberU64()) {
+			return blocks[i:]
+		}
+	}
+	return nil
+}
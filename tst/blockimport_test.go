@@ -0,0 +1,96 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rlp"
+)
+
+// Tests that exported blocks are imported in batches, skipping the genesis and
+// the blocks already present, and that progress is reported along the way.
+func TestImportBlocks(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+		block.AddTx(tx)
+	}
+	source, _ := newTestProtocolManagerMust(t, downloader.FullSync, 10, generator, nil)
+	defer source.Stop()
+
+	dump := new(bytes.Buffer)
+	for i := uint64(0); i <= 10; i++ {
+		if err := rlp.Encode(dump, source.blockchain.GetBlockByNumber(i)); err != nil {
+			t.Fatalf("failed to encode block %d: %v", i, err)
+		}
+	}
+	sink, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer sink.Stop()
+
+	var reports []ImportProgress
+	opts := ImportOptions{
+		BatchSize: 4,
+		Progress:  func(p ImportProgress) { reports = append(reports, p) },
+	}
+	progress, err := ImportBlocks(sink.blockchain, bytes.NewReader(dump.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	if progress.Read != 10 || progress.Imported != 10 || progress.Skipped != 0 || progress.Number != 10 {
+		t.Errorf("progress mismatch: %+v", progress)
+	}
+	if len(reports) != 3 {
+		t.Errorf("progress report count mismatch: have %d, want 3", len(reports))
+	}
+	if head := sink.blockchain.CurrentBlock(); head.Hash() != source.blockchain.CurrentBlock().Hash() {
+		t.Errorf("head mismatch: have %d, want %d", head.NumberU64(), source.blockchain.CurrentBlock().NumberU64())
+	}
+	// Reimporting must skip everything
+	progress, err = ImportBlocks(sink.blockchain, bytes.NewReader(dump.Bytes()), ImportOptions{})
+	if err != nil {
+		t.Fatalf("failed to reimport blocks: %v", err)
+	}
+	if progress.Imported != 0 || progress.Skipped != 10 {
+		t.Errorf("reimport progress mismatch: %+v", progress)
+	}
+	// Truncated input must import the complete blocks before failing
+	fresh, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer fresh.Stop()
+
+	progress, err = ImportBlocks(fresh.blockchain, bytes.NewReader(dump.Bytes()[:dump.Len()-1]), opts)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Fatalf("error mismatch: have %v, want parse failure", err)
+	}
+	if progress.Imported != 9 {
+		t.Errorf("imported block count mismatch: have %d, want 9", progress.Imported)
+	}
+	// Closing the stop channel must abort the import
+	stop := make(chan struct{})
+	close(stop)
+	if _, err := ImportBlocks(fresh.blockchain, bytes.NewReader(dump.Bytes()), ImportOptions{Stop: stop}); err != errImportInterrupted {
+		t.Errorf("error mismatch: have %v, want %v", err, errImportInterrupted)
+	}
+}
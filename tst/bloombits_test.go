@@ -0,0 +1,109 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/core/bloombits"
+	"github.com/gdachain/go-gdachain/gda/filters"
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// Tests that a bloom handler serves retrievals queued on the priority lane
+// before the ones already waiting on the bulk lane.
+func TestBloomHandlerPriority(t *testing.T) {
+	db, _ := gdadb.NewMemDatabase()
+	gda := &gdachain{
+		chainDb:               db,
+		shutdownChan:          make(chan bool),
+		bloomRequests:         make(chan chan *bloombits.Retrieval, 1),
+		bloomPriorityRequests: make(chan chan *bloombits.Retrieval, 1),
+	}
+	defer close(gda.shutdownChan)
+
+	bulk, priority := make(chan *bloombits.Retrieval), make(chan *bloombits.Retrieval)
+	gda.bloomRequests <- bulk
+	gda.bloomPriorityRequests <- priority
+
+	go gda.bloomHandler()
+
+	// The handler must be waiting for the priority task first
+	for i, lane := range []string{"priority", "bulk"} {
+		select {
+		case priority <- new(bloombits.Retrieval):
+			if lane != "priority" {
+				t.Fatalf("retrieval %d: served priority lane, want %s", i, lane)
+			}
+			<-priority
+		case bulk <- new(bloombits.Retrieval):
+			if lane != "bulk" {
+				t.Fatalf("retrieval %d: served bulk lane, want %s", i, lane)
+			}
+			<-bulk
+		case <-time.After(time.Second):
+			t.Fatalf("retrieval %d: %s lane not served", i, lane)
+		}
+	}
+}
+
+// Tests that filter sessions are multiplexed onto the lane requested by their
+// context.
+func TestServiceFilterLanes(t *testing.T) {
+	for i, priority := range []bool{false, true} {
+		gda := &gdachain{
+			config: &Config{
+				BloomFilterThreads:  bloomFilterThreads,
+				BloomRetrievalBatch: bloomRetrievalBatch,
+				BloomRetrievalWait:  bloomRetrievalWait,
+			},
+			bloomRequests:         make(chan chan *bloombits.Retrieval),
+			bloomPriorityRequests: make(chan chan *bloombits.Retrieval),
+		}
+		backend := &gdaApiBackend{gda: gda}
+
+		ctx := context.Background()
+		if priority {
+			ctx = filters.WithPriority(ctx)
+		}
+		matcher := bloombits.NewMatcher(4096, [][][]byte{{{0x01}}})
+		session, err := matcher.Start(ctx, 0, 4095, make(chan uint64, 1))
+		if err != nil {
+			t.Fatalf("test %d: failed to start matcher session: %v", i, err)
+		}
+		backend.ServiceFilter(ctx, session)
+
+		// Wait for a retrieval on the expected lane, none may arrive on the other
+		want, other := gda.bloomRequests, gda.bloomPriorityRequests
+		if priority {
+			want, other = other, want
+		}
+		select {
+		case request := <-want:
+			task := <-request
+			task.Bitsets = make([][]byte, len(task.Sections))
+			request <- task
+		case <-other:
+			t.Errorf("test %d: retrieval arrived on the wrong lane", i)
+		case <-time.After(time.Second):
+			t.Errorf("test %d: no retrieval arrived", i)
+		}
+		session.Close()
+	}
+}
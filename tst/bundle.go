@@ -0,0 +1,201 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/internal/ethapi"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// maxBundleTxs is the maximum number of transactions accepted in a single
+// bundle simulation request.
+const maxBundleTxs = 256
+
+var errEmptyBundle = errors.New("bundle contains no transactions")
+
+// CallBundleArgs are the arguments of a bundle simulation. The transactions are
+// executed in order in a new block on top of the state of StateBlockNumber, or
+// of the latest block if it's omitted.
+type CallBundleArgs struct {
+	Txs              []hexutil.Bytes  `json:"txs"`
+	StateBlockNumber *rpc.BlockNumber `json:"stateBlockNumber"`
+	Coinbase         *common.Address  `json:"coinbase"`
+	Timestamp        *hexutil.Uint64  `json:"timestamp"`
+}
+
+// BundleTxResult is the outcome of a single transaction of a simulated bundle.
+type BundleTxResult struct {
+	TxHash     common.Hash     `json:"txHash"`
+	From       common.Address  `json:"from"`
+	To         *common.Address `json:"to"`
+	GasUsed    hexutil.Uint64  `json:"gasUsed"`
+	Failed     bool            `json:"failed"`
+	Error      string          `json:"error,omitempty"`
+	ReturnData hexutil.Bytes   `json:"returnData"`
+	Logs       []*types.Log    `json:"logs"`
+}
+
+// BundleResult is the outcome of a simulated bundle, along with the root hash of
+// the state it leaves behind.
+type BundleResult struct {
+	Results          []*BundleTxResult `json:"results"`
+	TotalGasUsed     hexutil.Uint64    `json:"totalGasUsed"`
+	StateRoot        common.Hash       `json:"stateRoot"`
+	StateBlockNumber hexutil.Uint64    `json:"stateBlockNumber"`
+}
+
+// CallBundle simulates the execution of an ordered bundle of signed transactions
+// on top of the state of the requested block, without adding any of them to the
+// transaction pool. Transactions that are invalid (bad nonce, insufficient funds,
+// block gas limit exceeded) abort the simulation, while reverted ones are
+// reported along with their revert reason.
+func (api *PublicgdachainAPI) CallBundle(ctx context.Context, args CallBundleArgs) (*BundleResult, error) {
+	if len(args.Txs) == 0 {
+		return nil, errEmptyBundle
+	}
+	if len(args.Txs) > maxBundleTxs {
+		return nil, fmt.Errorf("bundle too large: %d transactions, limit %d", len(args.Txs), maxBundleTxs)
+	}
+	txs := make([]*types.Transaction, len(args.Txs))
+	for i, enc := range args.Txs {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(enc, tx); err != nil {
+			return nil, fmt.Errorf("tx %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	number := rpc.LatestBlockNumber
+	if args.StateBlockNumber != nil {
+		number = *args.StateBlockNumber
+	}
+	statedb, parent, err := api.e.ApiBackend.StateAndHeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil {
+		return nil, fmt.Errorf("state of block %d not available", number)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       new(big.Int).Add(parent.Time, common.Big1),
+		Difficulty: parent.Difficulty,
+		Coinbase:   parent.Coinbase,
+	}
+	if args.Coinbase != nil {
+		header.Coinbase = *args.Coinbase
+	}
+	if args.Timestamp != nil {
+		header.Time = new(big.Int).SetUint64(uint64(*args.Timestamp))
+	}
+	// Bundles are bound by the same limits as any other RPC initiated execution
+	timeout := api.e.config.RPCEVMTimeout
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	vmCfg := vm.Config{MemoryLimit: api.e.config.RPCEVMMemory}
+
+	result, err := simulateBundle(ctx, api.e.chainConfig, api.e.blockchain, statedb, header, txs, vmCfg)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		return nil, err
+	}
+	result.StateBlockNumber = hexutil.Uint64(parent.Number.Uint64())
+	return result, nil
+}
+
+// simulateBundle applies the transactions one after the other to statedb in
+// the context of header, collecting the outcome of each.
+func simulateBundle(ctx context.Context, config *params.ChainConfig, chain core.ChainContext, statedb *state.StateDB, header *types.Header, txs []*types.Transaction, vmCfg vm.Config) (*BundleResult, error) {
+	var (
+		signer  = types.MakeSigner(config, header.Number)
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		result  = &BundleResult{Results: make([]*BundleTxResult, 0, len(txs))}
+		gasUsed uint64
+	)
+	for i, tx := range txs {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, fmt.Errorf("tx %d [%x]: %v", i, tx.Hash(), err)
+		}
+		statedb.Prepare(tx.Hash(), common.Hash{}, i)
+
+		evm := vm.NewEVM(core.NewEVMContext(msg, header, chain, &header.Coinbase), statedb, config, vmCfg)
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-ctx.Done():
+				evm.Cancel()
+			case <-done:
+			}
+		}()
+		st := core.NewStateTransition(evm, msg, gp)
+		ret, gas, failed, err := st.TransitionDb()
+		close(done)
+
+		if evm.Cancelled() {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tx %d [%x]: %v", i, tx.Hash(), err)
+		}
+		statedb.Finalise(config.IsEIP158(header.Number))
+		gasUsed += gas
+
+		res := &BundleTxResult{
+			TxHash:     tx.Hash(),
+			From:       msg.From(),
+			To:         tx.To(),
+			GasUsed:    hexutil.Uint64(gas),
+			ReturnData: ret,
+			Logs:       statedb.GetLogs(tx.Hash()),
+		}
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		if failed {
+			res.Failed = true
+			if vmerr := st.VMError(); vmerr == vm.ErrExecutionReverted {
+				res.Error = (&ethapi.RevertError{Data: ret}).Error()
+			} else if vmerr != nil {
+				res.Error = vmerr.Error()
+			}
+		}
+		result.Results = append(result.Results, res)
+	}
+	result.TotalGasUsed = hexutil.Uint64(gasUsed)
+	result.StateRoot = statedb.IntermediateRoot(config.IsEIP158(header.Number))
+	return result, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// Tests that bundles are simulated in order on top of the requested state,
+// reporting reverts per transaction and aborting on invalid transactions.
+func TestSimulateBundle(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, nil, nil)
+	defer pm.Stop()
+
+	var (
+		chain    = pm.blockchain
+		parent   = chain.CurrentBlock().Header()
+		reverter = common.Address{0xff}
+		signer   = types.MakeSigner(chain.Config(), parent.Number)
+	)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       new(big.Int).Add(parent.Time, common.Big1),
+		Difficulty: parent.Difficulty,
+	}
+	sign := func(nonce uint64, to common.Address) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), 100000, nil, nil), signer, testBankKey)
+		return tx
+	}
+	simulate := func(txs ...*types.Transaction) (*BundleResult, error) {
+		statedb, _ := chain.State()
+		statedb.SetCode(reverter, []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)})
+		return simulateBundle(context.Background(), chain.Config(), chain, statedb, header, txs, vm.Config{})
+	}
+	// A valid bundle with a reverting transaction in the middle
+	res, err := simulate(sign(0, common.Address{0x01}), sign(1, reverter), sign(2, common.Address{0x02}))
+	if err != nil {
+		t.Fatalf("failed to simulate bundle: %v", err)
+	}
+	if len(res.Results) != 3 {
+		t.Fatalf("result count mismatch: have %d, want 3", len(res.Results))
+	}
+	for i, want := range []bool{false, true, false} {
+		if res.Results[i].Failed != want {
+			t.Errorf("tx %d: failure mismatch: have %v, want %v", i, res.Results[i].Failed, want)
+		}
+	}
+	if res.Results[0].GasUsed != hexutil.Uint64(params.TxGas) {
+		t.Errorf("gas used mismatch: have %d, want %d", res.Results[0].GasUsed, params.TxGas)
+	}
+	if !strings.HasPrefix(res.Results[1].Error, "execution reverted") {
+		t.Errorf("revert error mismatch: have %q", res.Results[1].Error)
+	}
+	var total uint64
+	for _, r := range res.Results {
+		total += uint64(r.GasUsed)
+	}
+	if uint64(res.TotalGasUsed) != total {
+		t.Errorf("total gas mismatch: have %d, want %d", res.TotalGasUsed, total)
+	}
+	if res.StateRoot == parent.Root {
+		t.Errorf("state root unchanged by bundle")
+	}
+	// Simulating the same bundle again must produce the same state
+	again, err := simulate(sign(0, common.Address{0x01}), sign(1, reverter), sign(2, common.Address{0x02}))
+	if err != nil {
+		t.Fatalf("failed to resimulate bundle: %v", err)
+	}
+	if again.StateRoot != res.StateRoot {
+		t.Errorf("state root mismatch: have %x, want %x", again.StateRoot, res.StateRoot)
+	}
+	// A bundle with a nonce gap must be rejected
+	if _, err := simulate(sign(0, common.Address{0x01}), sign(2, common.Address{0x02})); err == nil {
+		t.Errorf("bundle with nonce gap accepted")
+	}
+	// None of the simulations may have touched the chain state
+	if statedb, _ := chain.State(); statedb.GetNonce(testBank) != 0 {
+		t.Errorf("chain state modified: nonce %d", statedb.GetNonce(testBank))
+	}
+	// Fees must be credited to the coinbase of the simulated block
+	header.Coinbase = common.Address{0xc0}
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 100000, big.NewInt(1), nil), signer, testBankKey)
+
+	statedb, _ := chain.State()
+	if _, err := simulateBundle(context.Background(), chain.Config(), chain, statedb, header, []*types.Transaction{tx}, vm.Config{}); err != nil {
+		t.Fatalf("failed to simulate paying bundle: %v", err)
+	}
+	if fees := statedb.GetBalance(header.Coinbase); fees.Uint64() != params.TxGas {
+		t.Errorf("coinbase fees mismatch: have %v, want %d", fees, params.TxGas)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/golang/snappy"
+)
+
+// compressedPayload is the snappy compressed RLP encoding of a message, which is
+// written to the remote peer as is.
+type compressedPayload []byte
+
+// isCompressed reports whether the payload of a message with the given code is
+// snappy compressed on the wire between peers running the given protocol version.
+// Only the bulky sync responses are compressed, as the small, latency sensitive
+// messages would not gain anything.
+func isCompressed(version int, code uint64) bool {
+	if version < gda65 {
+		return false
+	}
+	switch code {
+	case BlockBodiesMsg, NodeDataMsg, ReceiptsMsg:
+		return true
+	}
+	return false
+}
+
+// compressMsg RLP encodes data and snappy compresses the result.
+func compressMsg(data interface{}) (compressedPayload, error) {
+	blob, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, blob), nil
+}
+
+// decompressMsg replaces the payload of a compressed inbound message with its
+// decompressed content. The decompressed size is checked against the protocol
+// limit before anything gets allocated.
+func decompressMsg(msg p2p.Msg) (p2p.Msg, error) {
+	payload := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, payload); err != nil {
+		return msg, errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	size, err := snappy.DecodedLen(payload)
+	if err != nil {
+		return msg, errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if size > ProtocolMaxMsgSize {
+		rejectedMsgMeter.Mark(1)
+		return msg, errResp(ErrMsgTooLarge, "msg %v: decompressed %v > %v", msg, size, ProtocolMaxMsgSize)
+	}
+	blob, err := snappy.Decode(nil, payload)
+	if err != nil {
+		return msg, errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	msg.Size, msg.Payload = uint32(len(blob)), bytes.NewReader(blob)
+	return msg, nil
+}
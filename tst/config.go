@@ -29,7 +29,9 @@ import (
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
+	"github.com/gdachain/go-gdachain/miner"
 	"github.com/gdachain/go-gdachain/params"
 )
 
@@ -43,18 +45,31 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:     1,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   5 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:        1,
+	LightPeers:       100,
+	PeerRequestRate:  100,
+	PeerRequestBurst: 200,
+	DatabaseCache:    768,
+	TrieCache:        256,
+	TrieTimeout:      5 * time.Minute,
+	TrieJournal:      "triecache",
+	TdCache:          16384,
+	ShutdownTimeout:  2 * time.Minute,
+	GasPrice:         big.NewInt(18 * params.Shannon),
+	UnclePolicy:      miner.DefaultUnclePolicy,
+	RPCTxFeeCap:      1, // 1 gdaer
+	RPCGasCap:        25000000,
+	RPCEVMTimeout:    5 * time.Second,
+	RPCEVMMemory:     64 * 1024 * 1024,
+	DebugImportLag:   16,
+	AlertReorgDepth:  6,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
+	Filters: filters.DefaultConfig,
 }
 
 func init() {
@@ -83,9 +98,19 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Checkpoints overrides the hard-coded canonical hashes enforced during sync,
+	// as a comma separated number=hash list ("none" disables them)
+	Checkpoints string `toml:",omitempty"`
+
 	// Light client options
-	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
-	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
+	LightServ  int    `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
+	LightPeers int    `toml:",omitempty"` // Maximum number of LES client peers
+	LightRPC   string `toml:",omitempty"` // Trusted full node RPC endpoint to retrieve light client data from
+
+	// Limits on the data requests (headers, state and receipts) served to a
+	// single peer, which is throttled and eventually dropped beyond them
+	PeerRequestRate  float64 `toml:",omitempty"` // Requests served per second on average (0 = unlimited)
+	PeerRequestBurst int     `toml:",omitempty"` // Requests served in a burst
 
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
@@ -93,12 +118,36 @@ type Config struct {
 	DatabaseCache      int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	TrieJournal        string `toml:",omitempty"` // Journal persisting the trie cache across restarts
+	TdCache            int    `toml:",omitempty"` // Number of block total difficulties to keep in memory
+
+	// Maximum time to wait for the trie caches and the transaction journal to be
+	// flushed on shutdown (0 = wait indefinitely)
+	ShutdownTimeout time.Duration `toml:",omitempty"`
 
 	// Mining-related options
 	gdaerbase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
+	UnclePolicy  miner.UnclePolicy // Rules for including side blocks as uncles
+
+	// Sealing work is recreated every MinerRecommit to include new transactions,
+	// empty blocks are held back for up to MinerEmptyWait after their parent and
+	// block assembly is cut short after MinerDeadline (0 = disabled)
+	MinerRecommit  time.Duration `toml:",omitempty"`
+	MinerEmptyWait time.Duration `toml:",omitempty"`
+	MinerDeadline  time.Duration `toml:",omitempty"`
+
+	// Developer mode runs a single node chain sealed by an automatically created
+	// and funded account, mining blocks only while transactions are pending
+	DevMode   bool   `toml:",omitempty"`
+	DevPeriod uint64 `toml:",omitempty"` // Block period in developer mode (0 = seal on demand)
+
+	// Consensus fault alerts (invalid seals, double signing, deep reorgs) are
+	// POSTed to this URL if set
+	AlertWebhook    string `toml:",omitempty"`
+	AlertReorgDepth uint64 `toml:",omitempty"` // Reorgs dropping more blocks than this raise an alert
 
 	// gdaash options
 	gdaash ethash.Config
@@ -109,11 +158,60 @@ type Config struct {
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
+	// Historical log query limits
+	Filters filters.Config
+
+	// Bloombits servicing options (0 = use the protocol default)
+	BloomFilterThreads  int           `toml:",omitempty"` // Goroutines per filter multiplexing bloom retrievals
+	BloomRetrievalBatch int           `toml:",omitempty"` // Maximum number of bloom bit retrievals per batch
+	BloomRetrievalWait  time.Duration `toml:",omitempty"` // Maximum time to wait for a batch to fill up
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Number of recent blocks whose executed opcodes are profiled (0 = disabled)
+	VMProfileBlocks int `toml:",omitempty"`
+
+	// Enables storing the revert data of failed transactions in their receipts
+	RecordRevertReasons bool `toml:",omitempty"`
+
+	// Maximum number of blocks the local head may lag the network before heavy
+	// debug and tracing requests are deferred (0 = never defer)
+	DebugImportLag uint64
+
+	// Number of recent blocks scanned for transactions missing from the lookup
+	// index (0 = the lookup index is authoritative)
+	TxLookupScan uint64
+
+	// Enables the index of the transactions and internal value transfers of every
+	// address, served by gda_getTransactionsByAddress
+	AddressIndex bool `toml:",omitempty"`
+
+	// Enables the index of the ERC-20 token transfers and balances of every
+	// address, served by the token RPC namespace
+	TokenIndex bool `toml:",omitempty"`
+
+	// Chain events are published as JSON lines to the local clients of this unix
+	// socket or Windows named pipe if set
+	EventSocket string `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
+
+	// RPCTxFeeCap is the global transaction fee (price * gaslimit) cap, in gdaer,
+	// for locally signed send-transaction variants. Zero disables the cap.
+	RPCTxFeeCap float64
+
+	// RPCGasCap is the global gas cap for eth_call and gas estimation. Zero
+	// disables the cap.
+	RPCGasCap uint64
+
+	// RPCEVMTimeout is the global timeout for eth_call. Zero disables the timeout.
+	RPCEVMTimeout time.Duration
+
+	// RPCEVMMemory is the memory limit, in bytes, of the EVM executions initiated
+	// by calls, gas estimations and traces. Zero disables the limit.
+	RPCEVMMemory uint64
 }
 
 type configMarshaling struct {
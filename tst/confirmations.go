@@ -0,0 +1,156 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// confirmationWindow is the number of most recent block heights for which the
+// confirmation tracker remembers all the competing blocks seen.
+const confirmationWindow = 1024
+
+// BlockConfidence contains the information needed to judge how final a block is.
+type BlockConfidence struct {
+	Number         hexutil.Uint64 `json:"number"`
+	Hash           common.Hash    `json:"hash"`
+	Canonical      bool           `json:"canonical"`      // Whgdaer the block is part of the canonical chain
+	Depth          hexutil.Uint64 `json:"depth"`          // Number of confirmations (including the block itself), 0 if not canonical
+	CompetingForks hexutil.Uint   `json:"competingForks"` // Number of other blocks seen at the same height
+	Tracked        bool           `json:"tracked"`        // Whgdaer the height is within the tracked window (competing forks are exact)
+}
+
+// confirmationTracker follows the canonical and side chain events of the local
+// chain, remembering all the blocks seen at each recent height, so that the
+// number of competing forks for a block can be reported alongside its depth.
+type confirmationTracker struct {
+	chain *core.BlockChain
+
+	seen map[uint64]map[common.Hash]struct{} // Blocks seen at each recent height
+	head uint64                              // Highest height seen so far
+	lock sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newConfirmationTracker creates a tracker for the given chain and starts
+// following its chain events.
+func newConfirmationTracker(chain *core.BlockChain) *confirmationTracker {
+	t := &confirmationTracker{
+		chain: chain,
+		seen:  make(map[uint64]map[common.Hash]struct{}),
+		quit:  make(chan struct{}),
+	}
+	chainCh := make(chan core.ChainEvent, 64)
+	sideCh := make(chan core.ChainSideEvent, 64)
+
+	chainSub := chain.SubscribeChainEvent(chainCh)
+	sideSub := chain.SubscribeChainSideEvent(sideCh)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer chainSub.Unsubscribe()
+		defer sideSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-chainCh:
+				t.add(ev.Block)
+			case ev := <-sideCh:
+				t.add(ev.Block)
+			case <-chainSub.Err():
+				return
+			case <-sideSub.Err():
+				return
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+	return t
+}
+
+// stop terminates the event loop of the tracker.
+func (t *confirmationTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+}
+
+// add records a block seen on the network (canonical or not), dropping any
+// heights that fell out of the tracked window.
+func (t *confirmationTracker) add(block *types.Block) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	number := block.NumberU64()
+	if number+confirmationWindow <= t.head {
+		return
+	}
+	if t.seen[number] == nil {
+		t.seen[number] = make(map[common.Hash]struct{})
+	}
+	t.seen[number][block.Hash()] = struct{}{}
+
+	if number > t.head {
+		for old := range t.seen {
+			if old+confirmationWindow <= number {
+				delete(t.seen, old)
+			}
+		}
+		t.head = number
+	}
+}
+
+// confidence assembles the finality information of the block with the given hash.
+func (t *confirmationTracker) confidence(hash common.Hash) (*BlockConfidence, error) {
+	header := t.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	number := header.Number.Uint64()
+
+	result := &BlockConfidence{
+		Number: hexutil.Uint64(number),
+		Hash:   hash,
+	}
+	if canon := t.chain.GetHeaderByNumber(number); canon != nil && canon.Hash() == hash {
+		result.Canonical = true
+		if head := t.chain.CurrentBlock().NumberU64(); head >= number {
+			result.Depth = hexutil.Uint64(head - number + 1)
+		}
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if blocks, ok := t.seen[number]; ok || number+confirmationWindow > t.head {
+		result.Tracked = true
+		for seen := range blocks {
+			if seen != hash {
+				result.CompetingForks++
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// Tests that the confirmation tracker reports the depth of canonical blocks and
+// counts the competing side chain blocks seen at the same height.
+func TestBlockConfidence(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		gspec   = &core.Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	tracker := newConfirmationTracker(blockchain)
+	defer tracker.stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Insert a lighter competing block at the height of the second block
+	forks, _ := core.GenerateChain(gspec.Config, chain[0], ethash.NewFaker(), db, 1, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	if _, err := blockchain.InsertChain(forks); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	// Wait for the tracker to process the chain events
+	deadline := time.Now().Add(time.Second)
+	for {
+		conf, err := tracker.confidence(chain[1].Hash())
+		if err != nil {
+			t.Fatalf("failed to retrieve confidence: %v", err)
+		}
+		if conf.CompetingForks == 1 {
+			if !conf.Canonical || conf.Depth != 2 || !conf.Tracked {
+				t.Fatalf("canonical block confidence mismatch: %+v", conf)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("competing fork not tracked: %+v", conf)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	conf, err := tracker.confidence(forks[0].Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve fork confidence: %v", err)
+	}
+	if conf.Canonical || conf.Depth != 0 || conf.CompetingForks != 1 {
+		t.Fatalf("side block confidence mismatch: %+v", conf)
+	}
+	if _, err := tracker.confidence(common.Hash{0xff}); err == nil {
+		t.Fatalf("confidence of unknown block returned")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/accounts/keystore"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/node"
+)
+
+// setupDevMode prepares the configuration of a developer mode node. The first
+// account of the keystore is used as the developer account, creating one with
+// an empty passphrase if none exists, and it is unlocked to seal blocks. Unless
+// a genesis block is explicitly configured, a proof-of-authority chain is set
+// up with the developer as its only signer and funded account.
+func setupDevMode(ctx *node.ServiceContext, config *Config) error {
+	backends := ctx.AccountManager.Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return errors.New("developer mode requires a keystore")
+	}
+	ks := backends[0].(*keystore.KeyStore)
+
+	var developer accounts.Account
+	if accs := ks.Accounts(); len(accs) > 0 {
+		developer = accs[0]
+	} else {
+		var err error
+		if developer, err = ks.NewAccount(""); err != nil {
+			return fmt.Errorf("failed to create developer account: %v", err)
+		}
+	}
+	if err := ks.Unlock(developer, ""); err != nil {
+		return fmt.Errorf("failed to unlock developer account: %v", err)
+	}
+	log.Info("Using developer account", "address", developer.Address)
+
+	if config.Genesis == nil {
+		config.Genesis = core.DeveloperGenesisBlock(config.DevPeriod, developer.Address)
+	}
+	if config.gdaerbase == (common.Address{}) {
+		config.gdaerbase = developer.Address
+	}
+	return nil
+}
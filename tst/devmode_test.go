@@ -0,0 +1,72 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/accounts/keystore"
+	"github.com/gdachain/go-gdachain/node"
+)
+
+// Tests that developer mode creates an unlocked developer account, makes it the
+// coinbase and the sole signer of the chain, and reuses it on later runs.
+func TestSetupDevMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gda-devmode-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	ctx := &node.ServiceContext{AccountManager: accounts.NewManager(ks)}
+
+	config := &Config{DevMode: true, DevPeriod: 5}
+	if err := setupDevMode(ctx, config); err != nil {
+		t.Fatalf("failed to set up developer mode: %v", err)
+	}
+	accs := ks.Accounts()
+	if len(accs) != 1 {
+		t.Fatalf("developer account count mismatch: have %d, want 1", len(accs))
+	}
+	developer := accs[0]
+	if config.gdaerbase != developer.Address {
+		t.Errorf("coinbase mismatch: have %x, want %x", config.gdaerbase, developer.Address)
+	}
+	if _, err := ks.SignHash(developer, make([]byte, 32)); err != nil {
+		t.Errorf("developer account locked: %v", err)
+	}
+	if config.Genesis == nil || config.Genesis.Config.Clique == nil {
+		t.Fatalf("developer genesis is not proof-of-authority")
+	}
+	if period := config.Genesis.Config.Clique.Period; period != 5 {
+		t.Errorf("block period mismatch: have %d, want 5", period)
+	}
+	if _, ok := config.Genesis.Alloc[developer.Address]; !ok {
+		t.Errorf("developer account not funded")
+	}
+	// A second run must reuse the existing account
+	if err := setupDevMode(ctx, &Config{DevMode: true}); err != nil {
+		t.Fatalf("failed to set up developer mode again: %v", err)
+	}
+	if n := len(ks.Accounts()); n != 1 {
+		t.Errorf("developer account count mismatch after rerun: have %d, want 1", n)
+	}
+}
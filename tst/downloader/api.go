@@ -19,30 +19,38 @@ package downloader
 import (
 	"context"
 	"sync"
+	"time"
 
 	gdaereum "github.com/gdachain/go-gdachain"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/rpc"
 )
 
+// syncProgressInterval is the interval at which detailed syncing subscriptions
+// are notified of the progress of a running synchronisation cycle.
+const syncProgressInterval = 5 * time.Second
+
 // PublicDownloaderAPI provides an API which gives information about the current synchronisation status.
 // It offers only methods that operates on data that can be available to anyone without security risks.
 type PublicDownloaderAPI struct {
 	d                         *Downloader
-	mux                       *event.TypeMux
-	installSyncSubscription   chan chan interface{}
+	installSyncSubscription   chan *syncSubscription
 	uninstallSyncSubscription chan *uninstallSyncSubscriptionRequest
 }
 
+// syncSubscription is a channel receiving the sync status updates, either in the
+// legacy format or as detailed SyncingEvents.
+type syncSubscription struct {
+	c        chan interface{}
+	detailed bool
+}
+
 // NewPublicDownloaderAPI create a new PublicDownloaderAPI. The API has an internal event loop that
-// listens for events from the downloader through the global event mux. In case it receives one of
-// these events it broadcasts it to all syncing subscriptions that are installed through the
-// installSyncSubscription channel.
-func NewPublicDownloaderAPI(d *Downloader, m *event.TypeMux) *PublicDownloaderAPI {
+// listens for sync events from the downloader. In case it receives one of these events it broadcasts
+// it to all syncing subscriptions that are installed through the installSyncSubscription channel.
+func NewPublicDownloaderAPI(d *Downloader) *PublicDownloaderAPI {
 	api := &PublicDownloaderAPI{
-		d:   d,
-		mux: m,
-		installSyncSubscription:   make(chan chan interface{}),
+		d:                         d,
+		installSyncSubscription:   make(chan *syncSubscription),
 		uninstallSyncSubscription: make(chan *uninstallSyncSubscriptionRequest),
 	}
 
@@ -51,46 +59,82 @@ func NewPublicDownloaderAPI(d *Downloader, m *event.TypeMux) *PublicDownloaderAP
 	return api
 }
 
-// eventLoop runs an loop until the event mux closes. It will install and uninstall new
+// eventLoop runs an loop until the downloader terminates. It will install and uninstall new
 // sync subscriptions and broadcasts sync status updates to the installed sync subscriptions.
 func (api *PublicDownloaderAPI) eventLoop() {
 	var (
-		sub               = api.mux.Subscribe(StartEvent{}, DoneEvent{}, FailedEvent{})
-		syncSubscriptions = make(map[chan interface{}]struct{})
+		events            = make(chan SyncEvent, 16)
+		sub               = api.d.SubscribeSyncEvent(events)
+		syncSubscriptions = make(map[chan interface{}]bool) // Channels mapped to whether they want detailed events
+		progress          = time.NewTicker(syncProgressInterval)
 	)
+	defer sub.Unsubscribe()
+	defer progress.Stop()
 
 	for {
 		select {
 		case i := <-api.installSyncSubscription:
-			syncSubscriptions[i] = struct{}{}
+			syncSubscriptions[i.c] = i.detailed
 		case u := <-api.uninstallSyncSubscription:
 			delete(syncSubscriptions, u.c)
 			close(u.uninstalled)
-		case event := <-sub.Chan():
-			if event == nil {
-				return
-			}
+		case <-sub.Err():
+			return
 
-			var notification interface{}
-			switch event.Data.(type) {
-			case StartEvent:
+		case event := <-events:
+			var (
+				notification interface{}
+				detailed     = &SyncingEvent{Status: api.d.Progress()}
+			)
+			switch event.Status {
+			case SyncStarted:
 				notification = &SyncingResult{
 					Syncing: true,
-					Status:  api.d.Progress(),
+					Status:  detailed.Status,
 				}
-			case DoneEvent, FailedEvent:
+				detailed.Event, detailed.Syncing = "started", true
+			case SyncDone:
 				notification = false
+				detailed.Event = "done"
+			case SyncFailed:
+				notification = false
+				detailed.Event = "failed"
+				if event.Err != nil {
+					detailed.Error = event.Err.Error()
+				}
 			}
 			// broadcast
-			for c := range syncSubscriptions {
-				c <- notification
+			for c, wantDetailed := range syncSubscriptions {
+				if wantDetailed {
+					c <- detailed
+				} else {
+					c <- notification
+				}
+			}
+
+		case <-progress.C:
+			if !api.d.Synchronising() {
+				continue
+			}
+			detailed := &SyncingEvent{Event: "progress", Syncing: true, Status: api.d.Progress()}
+			for c, wantDetailed := range syncSubscriptions {
+				if wantDetailed {
+					c <- detailed
+				}
 			}
 		}
 	}
 }
 
+// SyncingOptions configures a syncing subscription.
+type SyncingOptions struct {
+	Detailed bool `json:"detailed"` // Deliver SyncingEvents, including periodic progress updates
+}
+
 // Syncing provides information when this nodes starts synchronising with the gdachain network and when it's finished.
-func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription, error) {
+// If detailed events are requested, the subscription is also notified of the progress of running sync cycles and of
+// the reasons of failed ones.
+func (api *PublicDownloaderAPI) Syncing(ctx context.Context, opts *SyncingOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -99,8 +143,15 @@ func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription,
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
-		statuses := make(chan interface{})
-		sub := api.SubscribeSyncStatus(statuses)
+		var (
+			statuses = make(chan interface{})
+			sub      *SyncStatusSubscription
+		)
+		if opts != nil && opts.Detailed {
+			sub = api.SubscribeSyncEvents(statuses)
+		} else {
+			sub = api.SubscribeSyncStatus(statuses)
+		}
 
 		for {
 			select {
@@ -119,12 +170,56 @@ func (api *PublicDownloaderAPI) Syncing(ctx context.Context) (*rpc.Subscription,
 	return rpcSub, nil
 }
 
+// PrivateDownloaderAPI provides an API to inspect and control the synchronisation
+// with individual peers. It offers methods that can interfere with the operation
+// of the node, so it should only be exposed to trusted parties.
+type PrivateDownloaderAPI struct {
+	d *Downloader
+}
+
+// NewPrivateDownloaderAPI creates a new API to control the downloader.
+func NewPrivateDownloaderAPI(d *Downloader) *PrivateDownloaderAPI {
+	return &PrivateDownloaderAPI{d: d}
+}
+
+// CancelSync aborts the currently running sync cycle. A new cycle may be started
+// later on with the best available peer.
+func (api *PrivateDownloaderAPI) CancelSync() error {
+	if !api.d.Synchronising() {
+		return errNoSyncActive
+	}
+	api.d.Cancel()
+	return nil
+}
+
+// SyncPeers reports the retrievals assigned to and completed by each peer in the
+// current sync cycle, along with their measured performance.
+func (api *PrivateDownloaderAPI) SyncPeers() []PeerProgress {
+	return api.d.PeerProgress()
+}
+
+// SetSyncConcurrency limits the number of peers concurrently retrieving each
+// type of data during sync. Zero removes the limit.
+func (api *PrivateDownloaderAPI) SetSyncConcurrency(peers int) bool {
+	api.d.SetConcurrency(peers)
+	return true
+}
+
 // SyncingResult provides information about the current synchronisation status for this node.
 type SyncingResult struct {
 	Syncing bool                  `json:"syncing"`
 	Status  gdaereum.SyncProgress `json:"status"`
 }
 
+// SyncingEvent is a detailed notification of a syncing subscription, announcing
+// the start, the progress or the end of a synchronisation cycle.
+type SyncingEvent struct {
+	Event   string                `json:"event"` // started, progress, done or failed
+	Syncing bool                  `json:"syncing"`
+	Status  gdaereum.SyncProgress `json:"status"`
+	Error   string                `json:"error,omitempty"` // Reason of the failure if the cycle failed
+}
+
 // uninstallSyncSubscriptionRequest uninstalles a syncing subscription in the API event loop.
 type uninstallSyncSubscriptionRequest struct {
 	c           chan interface{}
@@ -161,6 +256,13 @@ func (s *SyncStatusSubscription) Unsubscribe() {
 // SubscribeSyncStatus creates a subscription that will broadcast new synchronisation updates.
 // The given channel must receive interface values, the result can either
 func (api *PublicDownloaderAPI) SubscribeSyncStatus(status chan interface{}) *SyncStatusSubscription {
-	api.installSyncSubscription <- status
+	api.installSyncSubscription <- &syncSubscription{c: status}
+	return &SyncStatusSubscription{api: api, c: status}
+}
+
+// SubscribeSyncEvents creates a subscription that will broadcast detailed
+// SyncingEvents, including the periodic progress of running sync cycles.
+func (api *PublicDownloaderAPI) SubscribeSyncEvents(status chan interface{}) *SyncStatusSubscription {
+	api.installSyncSubscription <- &syncSubscription{c: status, detailed: true}
 	return &SyncStatusSubscription{api: api, c: status}
 }
@@ -93,8 +93,10 @@ var (
 )
 
 type Downloader struct {
-	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
-	mux  *event.TypeMux // Event multiplexer to announce sync operation events
+	mode SyncMode // Synchronisation mode defining the strategy used (per sync cycle)
+
+	syncFeed  event.Feed              // Feed announcing the start and end of sync cycles
+	syncScope event.SubscriptionScope // Subscription scope tracking the sync subscribers
 
 	queue   *queue   // Scheduler for selecting the hashes to download
 	peers   *peerSet // Set of active peers from which download can proceed
@@ -103,12 +105,21 @@ type Downloader struct {
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
+	concurrency int32 // Maximum number of peers retrieving a data type at once (0 = unlimited, atomic)
+
+	checkpoints params.Checkpoints // Canonical hashes remote chains must agree with
+
 	// Statistics
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
+	syncStatsStart       time.Time    // Time when the current sync cycle started
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
+	syncStatsHeaders  uint64 // Number of headers processed in the current sync cycle (atomic)
+	syncStatsBodies   uint64 // Number of block bodies imported in the current sync cycle (atomic)
+	syncStatsReceipts uint64 // Number of block receipts imported in the current sync cycle (atomic)
+
 	lightchain LightChain
 	blockchain BlockChain
 
@@ -197,7 +208,7 @@ type BlockChain interface {
 }
 
 // New creates a new downloader to fetch hashes and blocks from remote peers.
-func New(mode SyncMode, stateDb gdadb.Database, mux *event.TypeMux, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
+func New(mode SyncMode, stateDb gdadb.Database, chain BlockChain, lightchain LightChain, dropPeer peerDropFn) *Downloader {
 	if lightchain == nil {
 		lightchain = chain
 	}
@@ -205,7 +216,6 @@ func New(mode SyncMode, stateDb gdadb.Database, mux *event.TypeMux, chain BlockC
 	dl := &Downloader{
 		mode:           mode,
 		stateDB:        stateDb,
-		mux:            mux,
 		queue:          newQueue(),
 		peers:          newPeerSet(),
 		rttEstimate:    uint64(rttMaxEstimate),
@@ -239,7 +249,14 @@ func New(mode SyncMode, stateDb gdadb.Database, mux *event.TypeMux, chain BlockC
 // In addition, during the state download phase of fast synchronisation the number
 // of processed and the total number of known states are also returned. Otherwise
 // these are zero.
+//
+// The number of headers, bodies and receipts processed and still queued in the
+// current sync cycle are reported too, along with an estimate of the time left
+// until the chain catches up, extrapolated from the import rate of the cycle.
 func (d *Downloader) Progress() gdaereum.SyncProgress {
+	// Gather the queue stats first, they are guarded by their own lock
+	bodies, receipts := d.queue.PendingBlocks(), d.queue.PendingReceipts()
+
 	// Lock the current stats and return the progress
 	d.syncStatsLock.RLock()
 	defer d.syncStatsLock.RUnlock()
@@ -250,16 +267,118 @@ func (d *Downloader) Progress() gdaereum.SyncProgress {
 		current = d.blockchain.CurrentBlock().NumberU64()
 	case FastSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
-	case LightSync:
+	case LightSync, HeaderSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
 	}
-	return gdaereum.SyncProgress{
-		StartingBlock: d.syncStatsChainOrigin,
-		CurrentBlock:  current,
-		HighestBlock:  d.syncStatsChainHeight,
-		PulledStates:  d.syncStatsState.processed,
-		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
+	progress := gdaereum.SyncProgress{
+		StartingBlock:  d.syncStatsChainOrigin,
+		CurrentBlock:   current,
+		HighestBlock:   d.syncStatsChainHeight,
+		PulledStates:   d.syncStatsState.processed,
+		KnownStates:    d.syncStatsState.processed + d.syncStatsState.pending,
+		PulledHeaders:  atomic.LoadUint64(&d.syncStatsHeaders),
+		PulledBodies:   atomic.LoadUint64(&d.syncStatsBodies),
+		PulledReceipts: atomic.LoadUint64(&d.syncStatsReceipts),
+		QueuedBodies:   uint64(bodies),
+		QueuedReceipts: uint64(receipts),
+	}
+	progress.ETA = syncETA(progress, time.Since(d.syncStatsStart))
+	return progress
+}
+
+// syncETA estimates the time needed to reach the highest known block, based on
+// the blocks imported since the sync started and the time it took.
+func syncETA(progress gdaereum.SyncProgress, elapsed time.Duration) time.Duration {
+	if progress.CurrentBlock <= progress.StartingBlock || progress.CurrentBlock >= progress.HighestBlock || elapsed <= 0 {
+		return 0
 	}
+	done := progress.CurrentBlock - progress.StartingBlock
+	left := progress.HighestBlock - progress.CurrentBlock
+
+	return time.Duration(float64(elapsed) * float64(left) / float64(done))
+}
+
+// PeerProgress is the synchronisation status of a single peer, reporting its
+// measured performance along with its outstanding and completed retrievals in
+// the current sync cycle.
+type PeerProgress struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Master  bool   `json:"master"` // Whgdaer the chain is being synced to this peer's head
+	RTT     string `json:"rtt"`
+
+	HeaderThroughput  float64 `json:"headerThroughput"`
+	BlockThroughput   float64 `json:"blockThroughput"`
+	ReceiptThroughput float64 `json:"receiptThroughput"`
+	StateThroughput   float64 `json:"stateThroughput"`
+
+	PendingHeaders  *uint64 `json:"pendingHeaders,omitempty"` // First header of the batch being fetched
+	PendingBodies   int     `json:"pendingBodies"`
+	PendingReceipts int     `json:"pendingReceipts"`
+	PendingStates   bool    `json:"pendingStates"`
+
+	Headers  uint64 `json:"headers"`
+	Bodies   uint64 `json:"bodies"`
+	Receipts uint64 `json:"receipts"`
+	States   uint64 `json:"states"`
+}
+
+// PeerProgress retrieves the synchronisation status of all the peers known to
+// the downloader.
+func (d *Downloader) PeerProgress() []PeerProgress {
+	d.cancelLock.RLock()
+	master := d.cancelPeer
+	d.cancelLock.RUnlock()
+
+	peers := d.peers.AllPeers()
+	progress := make([]PeerProgress, 0, len(peers))
+	for _, p := range peers {
+		headers, bodies, receipts := d.queue.Assignments(p.id)
+
+		p.lock.RLock()
+		progress = append(progress, PeerProgress{
+			ID:                p.id,
+			Version:           p.version,
+			Master:            p.id == master && d.Synchronising(),
+			RTT:               common.PrettyDuration(p.rtt).String(),
+			HeaderThroughput:  p.headerThroughput,
+			BlockThroughput:   p.blockThroughput,
+			ReceiptThroughput: p.receiptThroughput,
+			StateThroughput:   p.stateThroughput,
+			PendingHeaders:    headers,
+			PendingBodies:     bodies,
+			PendingReceipts:   receipts,
+			PendingStates:     atomic.LoadInt32(&p.stateIdle) != 0,
+			Headers:           p.headersDelivered,
+			Bodies:            p.blocksDelivered,
+			Receipts:          p.receiptsDelivered,
+			States:            p.statesDelivered,
+		})
+		p.lock.RUnlock()
+	}
+	return progress
+}
+
+// SetCheckpoints sets the canonical hashes remote chains must agree with to be
+// synchronised from.
+func (d *Downloader) SetCheckpoints(checkpoints params.Checkpoints) {
+	d.checkpoints = checkpoints
+}
+
+// SetConcurrency limits the number of peers concurrently retrieving each type
+// of data (headers, bodies, receipts, state) during sync. Zero removes the limit.
+func (d *Downloader) SetConcurrency(peers int) {
+	if peers < 0 {
+		peers = 0
+	}
+	atomic.StoreInt32(&d.concurrency, int32(peers))
+}
+
+// saturated returns whgdaer the given number of busy peers reached the limit of
+// concurrent retrievals of a single data type.
+func (d *Downloader) saturated(busy int) bool {
+	limit := atomic.LoadInt32(&d.concurrency)
+	return limit > 0 && busy >= int(limit)
 }
 
 // Synchronising returns whgdaer the downloader is currently retrieving blocks.
@@ -401,13 +520,13 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 // syncWithPeer starts a block synchronization based on the hash chain from the
 // specified peer and head hash.
 func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.Int) (err error) {
-	d.mux.Post(StartEvent{})
+	d.syncFeed.Send(SyncEvent{Status: SyncStarted})
 	defer func() {
 		// reset on error
 		if err != nil {
-			d.mux.Post(FailedEvent{err})
+			d.syncFeed.Send(SyncEvent{Status: SyncFailed, Err: err})
 		} else {
-			d.mux.Post(DoneEvent{})
+			d.syncFeed.Send(SyncEvent{Status: SyncDone})
 		}
 	}()
 	if p.version < 62 {
@@ -426,6 +545,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	}
 	height := latest.Number.Uint64()
 
+	if err := d.verifyCheckpoint(p, height); err != nil {
+		return err
+	}
 	origin, err := d.findAncestor(p, height)
 	if err != nil {
 		return err
@@ -435,8 +557,13 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		d.syncStatsChainOrigin = origin
 	}
 	d.syncStatsChainHeight = height
+	d.syncStatsStart = time.Now()
 	d.syncStatsLock.Unlock()
 
+	atomic.StoreUint64(&d.syncStatsHeaders, 0)
+	atomic.StoreUint64(&d.syncStatsBodies, 0)
+	atomic.StoreUint64(&d.syncStatsReceipts, 0)
+
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
 	if d.mode == FastSync {
@@ -530,8 +657,16 @@ func (d *Downloader) Terminate() {
 	}
 	d.quitLock.Unlock()
 
-	// Cancel any pending download requests
+	// Cancel any pending download requests and end the sync subscriptions
 	d.Cancel()
+	d.syncScope.Close()
+}
+
+// SubscribeSyncEvent registers a subscription of SyncEvent and starts sending
+// event to the given channel. The subscription ends when the downloader is
+// terminated.
+func (d *Downloader) SubscribeSyncEvent(ch chan<- SyncEvent) event.Subscription {
+	return d.syncScope.Track(d.syncFeed.Subscribe(ch))
 }
 
 // fetchHeight retrieves the head header of the remote peer to aid in estimating
@@ -577,6 +712,53 @@ func (d *Downloader) fetchHeight(p *peerConnection) (*types.Header, error) {
 	}
 }
 
+// verifyCheckpoint ensures the remote chain agrees with the highest checkpoint
+// below its head, rejecting peers feeding a bogus history before any of it is
+// downloaded.
+func (d *Downloader) verifyCheckpoint(p *peerConnection, height uint64) error {
+	number, hash, ok := d.checkpoints.Highest(height)
+	if !ok || number == 0 {
+		return nil
+	}
+	p.log.Debug("Verifying remote chain checkpoint", "number", number, "hash", hash)
+	go p.peer.RequestHeadersByNumber(number, 1, 0, false)
+
+	ttl := d.requestTTL()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return errCancelBlockFetch
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			// Make sure the peer gave us the requested header, matching the checkpoint
+			headers := packet.(*headerPack).headers
+			if len(headers) != 1 || headers[0].Number.Uint64() != number {
+				p.log.Debug("Invalid checkpoint header response", "headers", len(headers))
+				return errBadPeer
+			}
+			if headers[0].Hash() != hash {
+				p.log.Warn("Remote chain contradicts checkpoint", "number", number, "have", headers[0].Hash(), "want", hash)
+				return errInvalidChain
+			}
+			return nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for checkpoint header timed out", "elapsed", ttl)
+			return errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // findAncestor tries to locate the common ancestor link of the local chain and
 // a remote peers blockchain. In the general case when our node was in sync and
 // on the correct chain, checking the top N links should already get us a match.
@@ -594,6 +776,10 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 	if ceil >= MaxForkAncestry {
 		floor = int64(ceil - MaxForkAncestry)
 	}
+	// Never reorg below a checkpoint already present in the local chain
+	if number, _, ok := d.checkpoints.Highest(ceil); ok && int64(number)-1 > floor {
+		floor = int64(number) - 1
+	}
 	p.log.Debug("Looking for common ancestor", "local", ceil, "remote", height)
 
 	// Request the topmost blocks to short circuit binary ancestor lookup
@@ -1095,10 +1281,11 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 			// Send a download request to all idle peers, until throttled
 			progressed, throttled, running := false, false, inFlight()
 			idles, total := idle()
+			busy := total - len(idles)
 
 			for _, peer := range idles {
 				// Short circuit if throttling activated
-				if throttle() {
+				if throttle() || d.saturated(busy) {
 					throttled = true
 					break
 				}
@@ -1137,6 +1324,7 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 					panic(fmt.Sprintf("%v: %s fetch assignment failed", peer, kind))
 				}
 				running = true
+				busy++
 			}
 			// Make sure that we have peers available for fetching. If all peers have been tried
 			// and all failed throw an error
@@ -1208,7 +1396,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				// L: Sync begins, and finds common ancestor at 11
 				// L: Request new headers up from 11 (R's TD was higher, it must have somgdaing)
 				// R: Nothing to give
-				if d.mode != LightSync {
+				if !d.mode.headersOnly() {
 					head := d.blockchain.CurrentBlock()
 					if !gotHeaders && td.Cmp(d.blockchain.GetTd(head.Hash(), head.NumberU64())) > 0 {
 						return errStallingPeer
@@ -1221,7 +1409,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us somgdaing useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode.headersOnly() {
 					head := d.lightchain.CurrentHeader()
 					if td.Cmp(d.lightchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
 						return errStallingPeer
@@ -1249,7 +1437,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode.headersOnly() {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1293,6 +1481,8 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						return errBadPeer
 					}
 				}
+				atomic.AddUint64(&d.syncStatsHeaders, uint64(limit))
+
 				headers = headers[limit:]
 				origin += uint64(limit)
 			}
@@ -1339,14 +1529,21 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 		"firstnum", first.Number, "firsthash", first.Hash(),
 		"lastnum", last.Number, "lasthash", last.Hash(),
 	)
+	// Downloaded chains are attributed to the master peer advertising them
+	d.cancelLock.RLock()
+	origin := d.cancelPeer
+	d.cancelLock.RUnlock()
+
 	blocks := make([]*types.Block, len(results))
 	for i, result := range results {
 		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+		blocks[i].ReceivedFrom = origin
 	}
 	if index, err := d.blockchain.InsertChain(blocks); err != nil {
 		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
 		return errInvalidChain
 	}
+	atomic.AddUint64(&d.syncStatsBodies, uint64(len(blocks)))
 	return nil
 }
 
@@ -1490,6 +1687,8 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
 		return errInvalidChain
 	}
+	atomic.AddUint64(&d.syncStatsBodies, uint64(len(blocks)))
+	atomic.AddUint64(&d.syncStatsReceipts, uint64(len(receipts)))
 	return nil
 }
 
@@ -1499,6 +1698,8 @@ func (d *Downloader) commitPivotBlock(result *fetchResult) error {
 	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{result.Receipts}); err != nil {
 		return err
 	}
+	atomic.AddUint64(&d.syncStatsBodies, 1)
+	atomic.AddUint64(&d.syncStatsReceipts, 1)
 	if err := d.blockchain.FastSyncCommitHead(block.Hash()); err != nil {
 		return err
 	}
@@ -25,13 +25,13 @@ import (
 	"testing"
 	"time"
 
+	gdaereum "github.com/gdachain/go-gdachain"
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/gdadb"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/params"
 	"github.com/gdachain/go-gdachain/trie"
 )
@@ -96,7 +96,7 @@ func newTester() *downloadTester {
 	tester.stateDb, _ = gdadb.NewMemDatabase()
 	tester.stateDb.Put(genesis.Root().Bytes(), []byte{0x00})
 
-	tester.downloader = New(FullSync, tester.stateDb, new(event.TypeMux), tester, nil, tester.dropPeer)
+	tester.downloader = New(FullSync, tester.stateDb, tester, nil, tester.dropPeer)
 
 	return tester
 }
@@ -627,7 +627,7 @@ func assertOwnForkedChain(t *testing.T, tester *downloadTester, common int, leng
 	switch tester.downloader.mode {
 	case FullSync:
 		receipts = 1
-	case LightSync:
+	case LightSync, HeaderSync:
 		blocks, receipts = 1, 1
 	}
 	if hs := len(tester.ownHeaders); hs != headers {
@@ -685,6 +685,88 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// Tests that header-only synchronisation into a full node's chain retrieves the
+// headers without any of the block bodies or receipts.
+func TestCanonicalSynchronisation64Header(t *testing.T) {
+	testCanonicalSynchronisation(t, 64, HeaderSync)
+}
+
+// Tests that sync cycles are announced to the sync event subscribers, and that
+// the subscriptions end when the downloader is terminated.
+func TestSyncEvents(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+
+	events := make(chan SyncEvent, 4)
+	sub := tester.downloader.SubscribeSyncEvent(events)
+
+	hashes, headers, blocks, receipts := tester.makeChain(MaxHashFetch, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 63, hashes, headers, blocks, receipts)
+
+	if err := tester.sync("peer", nil, FullSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	for i, want := range []SyncStatus{SyncStarted, SyncDone} {
+		select {
+		case event := <-events:
+			if event.Status != want || event.Err != nil {
+				t.Fatalf("event %d mismatch: have %v (err %v), want %v", i, event.Status, event.Err, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout waiting for %v", i, want)
+		}
+	}
+	tester.terminate()
+	select {
+	case <-sub.Err():
+	case <-time.After(time.Second):
+		t.Fatalf("subscription not ended by termination")
+	}
+}
+
+// Tests that syncing subscriptions receive either the legacy notifications or
+// the detailed events they asked for.
+func TestSyncStatusSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	api := NewPublicDownloaderAPI(tester.downloader)
+
+	legacy, detailed := make(chan interface{}, 4), make(chan interface{}, 4)
+	defer api.SubscribeSyncStatus(legacy).Unsubscribe()
+	defer api.SubscribeSyncEvents(detailed).Unsubscribe()
+
+	hashes, headers, blocks, receipts := tester.makeChain(MaxHashFetch, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 63, hashes, headers, blocks, receipts)
+
+	if err := tester.sync("peer", nil, FullSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	next := func(ch chan interface{}) interface{} {
+		select {
+		case status := <-ch:
+			return status
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for sync status")
+			return nil
+		}
+	}
+	if status, ok := next(legacy).(*SyncingResult); !ok || !status.Syncing {
+		t.Fatalf("legacy start notification mismatch: %v", status)
+	}
+	if status := next(legacy); status != false {
+		t.Fatalf("legacy end notification mismatch: %v", status)
+	}
+	for _, want := range []string{"started", "done"} {
+		if event, ok := next(detailed).(*SyncingEvent); !ok || event.Event != want {
+			t.Fatalf("detailed event mismatch: have %v, want %s", event, want)
+		}
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling62(t *testing.T)     { testThrottling(t, 62, FullSync) }
@@ -773,12 +855,13 @@ func testThrottling(t *testing.T, protocol int, mode SyncMode) {
 // Tests that simple synchronization against a forked chain works correctly. In
 // this test common ancestor lookup should *not* be short circuited, and a full
 // binary search should be executed.
-func TestForkedSync62(t *testing.T)      { testForkedSync(t, 62, FullSync) }
-func TestForkedSync63Full(t *testing.T)  { testForkedSync(t, 63, FullSync) }
-func TestForkedSync63Fast(t *testing.T)  { testForkedSync(t, 63, FastSync) }
-func TestForkedSync64Full(t *testing.T)  { testForkedSync(t, 64, FullSync) }
-func TestForkedSync64Fast(t *testing.T)  { testForkedSync(t, 64, FastSync) }
-func TestForkedSync64Light(t *testing.T) { testForkedSync(t, 64, LightSync) }
+func TestForkedSync62(t *testing.T)       { testForkedSync(t, 62, FullSync) }
+func TestForkedSync63Full(t *testing.T)   { testForkedSync(t, 63, FullSync) }
+func TestForkedSync63Fast(t *testing.T)   { testForkedSync(t, 63, FastSync) }
+func TestForkedSync64Full(t *testing.T)   { testForkedSync(t, 64, FullSync) }
+func TestForkedSync64Fast(t *testing.T)   { testForkedSync(t, 64, FastSync) }
+func TestForkedSync64Light(t *testing.T)  { testForkedSync(t, 64, LightSync) }
+func TestForkedSync64Header(t *testing.T) { testForkedSync(t, 64, HeaderSync) }
 
 func testForkedSync(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -1018,6 +1101,56 @@ func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// Tests that limiting the sync concurrency caps the number of peers fetching
+// block bodies at once, and that per-peer progress is reported.
+func TestConcurrencyLimitedSync(t *testing.T) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	targetPeers := 4
+	targetBlocks := 2*blockCacheItems - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+
+	for i := 0; i < targetPeers; i++ {
+		tester.newPeer(fmt.Sprintf("peer #%d", i), 63, hashes, headers, blocks, receipts)
+	}
+	tester.downloader.SetConcurrency(1)
+
+	var (
+		lock    sync.Mutex
+		maxBusy int
+	)
+	tester.downloader.bodyFetchHook = func([]*types.Header) {
+		q := tester.downloader.queue
+		q.lock.Lock()
+		busy := len(q.blockPendPool)
+		q.lock.Unlock()
+
+		lock.Lock()
+		if busy > maxBusy {
+			maxBusy = busy
+		}
+		lock.Unlock()
+	}
+	if err := tester.sync("peer #0", nil, FullSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, targetBlocks+1)
+
+	if maxBusy > 1 {
+		t.Errorf("concurrent body fetches mismatch: have %d, want at most 1", maxBusy)
+	}
+	var delivered uint64
+	for _, progress := range tester.downloader.PeerProgress() {
+		delivered += progress.Headers + progress.Bodies
+	}
+	if delivered == 0 {
+		t.Errorf("no deliveries reported")
+	}
+}
+
 // Tests that synchronisations behave well in multi-version protocol environments
 // and not wreak havoc on other nodes in the network.
 func TestMultiProtoSynchronisation62(t *testing.T)      { testMultiProtoSync(t, 62, FullSync) }
@@ -1423,6 +1556,40 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	if progress := tester.downloader.Progress(); progress.StartingBlock != uint64(targetBlocks/2+1) || progress.CurrentBlock != uint64(targetBlocks) || progress.HighestBlock != uint64(targetBlocks) {
 		t.Fatalf("Final progress mismatch: have %v/%v/%v, want %v/%v/%v", progress.StartingBlock, progress.CurrentBlock, progress.HighestBlock, targetBlocks/2+1, targetBlocks, targetBlocks)
 	}
+	// Check the stage statistics of the last sync cycle
+	prog := tester.downloader.Progress()
+	if prog.PulledHeaders == 0 {
+		t.Errorf("No headers reported as pulled")
+	}
+	if mode != LightSync && prog.PulledBodies == 0 {
+		t.Errorf("No bodies reported as pulled")
+	}
+	if mode == FastSync && prog.PulledReceipts == 0 {
+		t.Errorf("No receipts reported as pulled")
+	}
+	if prog.ETA != 0 {
+		t.Errorf("Completed sync ETA mismatch: have %v, want 0", prog.ETA)
+	}
+}
+
+// Tests that the remaining sync time is extrapolated from the import rate.
+func TestSyncETA(t *testing.T) {
+	tests := []struct {
+		starting, current, highest uint64
+		elapsed, eta               time.Duration
+	}{
+		{starting: 0, current: 0, highest: 100, elapsed: time.Minute, eta: 0},
+		{starting: 0, current: 25, highest: 100, elapsed: time.Minute, eta: 3 * time.Minute},
+		{starting: 50, current: 75, highest: 100, elapsed: time.Minute, eta: time.Minute},
+		{starting: 0, current: 100, highest: 100, elapsed: time.Minute, eta: 0},
+		{starting: 0, current: 50, highest: 100, elapsed: 0, eta: 0},
+	}
+	for i, tt := range tests {
+		progress := gdaereum.SyncProgress{StartingBlock: tt.starting, CurrentBlock: tt.current, HighestBlock: tt.highest}
+		if eta := syncETA(progress, tt.elapsed); eta != tt.eta {
+			t.Errorf("test %d: eta mismatch: have %v, want %v", i, eta, tt.eta)
+		}
+	}
 }
 
 // Tests that synchronisation progress (origin block number and highest block
@@ -16,6 +16,17 @@
 
 package downloader
 
-type DoneEvent struct{}
-type StartEvent struct{}
-type FailedEvent struct{ Err error }
+// SyncStatus is the stage of a synchronisation cycle announced by a SyncEvent.
+type SyncStatus int
+
+const (
+	SyncStarted SyncStatus = iota // Synchronisation cycle started
+	SyncDone                      // Synchronisation cycle completed successfully
+	SyncFailed                    // Synchronisation cycle failed with the event's error
+)
+
+// SyncEvent is posted when a synchronisation cycle starts or ends.
+type SyncEvent struct {
+	Status SyncStatus
+	Err    error // Reason of the failure, if the cycle failed
+}
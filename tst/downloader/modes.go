@@ -22,13 +22,20 @@ import "fmt"
 type SyncMode int
 
 const (
-	FullSync  SyncMode = iota // Synchronise the entire blockchain history from full blocks
-	FastSync                  // Quickly download the headers, full sync only at the chain head
-	LightSync                 // Download only the headers and terminate afterwards
+	FullSync   SyncMode = iota // Synchronise the entire blockchain history from full blocks
+	FastSync                   // Quickly download the headers, full sync only at the chain head
+	LightSync                  // Download only the headers and terminate afterwards
+	HeaderSync                 // Download and verify only the headers into a full node's chain
 )
 
 func (mode SyncMode) IsValid() bool {
-	return mode >= FullSync && mode <= LightSync
+	return mode >= FullSync && mode <= HeaderSync
+}
+
+// headersOnly returns whgdaer the mode retrieves headers without any associated
+// block content.
+func (mode SyncMode) headersOnly() bool {
+	return mode == LightSync || mode == HeaderSync
 }
 
 // String implements the stringer interface.
@@ -40,6 +47,8 @@ func (mode SyncMode) String() string {
 		return "fast"
 	case LightSync:
 		return "light"
+	case HeaderSync:
+		return "header"
 	default:
 		return "unknown"
 	}
@@ -53,6 +62,8 @@ func (mode SyncMode) MarshalText() ([]byte, error) {
 		return []byte("fast"), nil
 	case LightSync:
 		return []byte("light"), nil
+	case HeaderSync:
+		return []byte("header"), nil
 	default:
 		return nil, fmt.Errorf("unknown sync mode %d", mode)
 	}
@@ -66,8 +77,10 @@ func (mode *SyncMode) UnmarshalText(text []byte) error {
 		*mode = FastSync
 	case "light":
 		*mode = LightSync
+	case "header":
+		*mode = HeaderSync
 	default:
-		return fmt.Errorf(`unknown sync mode %q, want "full", "fast" or "light"`, text)
+		return fmt.Errorf(`unknown sync mode %q, want "full", "fast", "light" or "header"`, text)
 	}
 	return nil
 }
@@ -176,6 +176,26 @@ func (q *queue) PendingReceipts() int {
 	return q.receiptTaskQueue.Size()
 }
 
+// Assignments retrieves the retrievals currently pending from the given peer:
+// the first header of the requested header batch (if any) and the number of
+// block bodies and receipts requested.
+func (q *queue) Assignments(id string) (headers *uint64, bodies int, receipts int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if req := q.headerPendPool[id]; req != nil {
+		from := req.From
+		headers = &from
+	}
+	if req := q.blockPendPool[id]; req != nil {
+		bodies = len(req.Headers)
+	}
+	if req := q.receiptPendPool[id]; req != nil {
+		receipts = len(req.Headers)
+	}
+	return headers, bodies, receipts
+}
+
 // InFlightHeaders retrieves whgdaer there are header fetch requests currently
 // in flight.
 func (q *queue) InFlightHeaders() bool {
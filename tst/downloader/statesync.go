@@ -337,8 +337,13 @@ func (s *stateSync) commit(force bool) error {
 // batch currently being retried, or fetching new data from the trie sync itself.
 func (s *stateSync) assignTasks() {
 	// Iterate over all idle peers and try to assign them state fetches
-	peers, _ := s.d.peers.NodeDataIdlePeers()
+	peers, total := s.d.peers.NodeDataIdlePeers()
+	busy := total - len(peers)
 	for _, p := range peers {
+		// Stop assigning if the concurrency limit was reached
+		if s.d.saturated(busy) {
+			break
+		}
 		// Assign a batch of fetches proportional to the estimated latency/bandwidth
 		cap := p.NodeDataCapacity(s.d.requestRTT())
 		req := &stateReq{peer: p, timeout: s.d.requestTTL()}
@@ -350,6 +355,7 @@ func (s *stateSync) assignTasks() {
 			select {
 			case s.d.trackStateReq <- req:
 				req.peer.FetchNodeData(req.items)
+				busy++
 			case <-s.cancel:
 			case <-s.d.cancelCh:
 			}
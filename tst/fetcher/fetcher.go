@@ -129,6 +129,8 @@ type Fetcher struct {
 	queues map[string]int          // Per peer block counts to prevent memory exhaustion
 	queued map[common.Hash]*inject // Set of already queued blocks (to dedup imports)
 
+	propagation *propagationTracker // First sightings and import latencies of propagated blocks
+
 	// Callbacks
 	getBlock       blockRetrievalFn   // Retrieves a block from the local chain
 	verifyHeader   headerVerifierFn   // Checks if a block's headers have a valid proof of work
@@ -163,6 +165,7 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 		queue:          prque.New(),
 		queues:         make(map[string]int),
 		queued:         make(map[common.Hash]*inject),
+		propagation:    newPropagationTracker(),
 		getBlock:       getBlock,
 		verifyHeader:   verifyHeader,
 		broadcastBlock: broadcastBlock,
@@ -218,6 +221,12 @@ func (f *Fetcher) Enqueue(peer string, block *types.Block) error {
 	}
 }
 
+// PropagationStats returns a summary of how long recently propagated blocks
+// took to be imported after first being seen in the network.
+func (f *Fetcher) PropagationStats() PropagationStats {
+	return f.propagation.stats()
+}
+
 // FilterHeaders extracts all the headers that were explicitly requested by the fetcher,
 // returning those that should be handled differently.
 func (f *Fetcher) FilterHeaders(peer string, headers []*types.Header, time time.Time) []*types.Header {
@@ -343,6 +352,7 @@ func (f *Fetcher) loop() {
 			if _, ok := f.completing[notification.hash]; ok {
 				break
 			}
+			f.propagation.sighted(notification.hash, notification.time, true)
 			f.announces[notification.origin] = count
 			f.announced[notification.hash] = append(f.announced[notification.hash], notification)
 			if f.announceChangeHook != nil && len(f.announced[notification.hash]) == 1 {
@@ -622,6 +632,12 @@ func (f *Fetcher) enqueue(peer string, block *types.Block) {
 			origin: peer,
 			block:  block,
 		}
+		seen := block.ReceivedAt
+		if seen.IsZero() {
+			seen = time.Now()
+		}
+		f.propagation.sighted(hash, seen, false)
+
 		f.queues[peer] = count
 		f.queued[hash] = op
 		f.queue.Push(op, -float32(block.NumberU64()))
@@ -670,7 +686,8 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 			log.Debug("Propagated block import failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
 			return
 		}
-		// If import succeeded, broadcast the block
+		// If import succeeded, account the propagation latency and broadcast the block
+		f.propagation.imported(hash)
 		propAnnounceOutTimer.UpdateSince(block.ReceivedAt)
 		go f.broadcastBlock(block, false)
 
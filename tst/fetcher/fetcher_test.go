@@ -788,3 +788,34 @@ func TestBlockMemoryExhaustionAttack(t *testing.T) {
 	}
 	verifyImportDone(t, imported)
 }
+
+// Tests that the fetcher measures the propagation latency of both announced and
+// directly broadcast blocks, accounting them separately.
+func TestPropagationStats(t *testing.T) {
+	hashes, blocks := makeChain(2, 0, genesis)
+
+	tester := newTester()
+	headerFetcher := tester.makeHeaderFetcher("valid", blocks, -gatherSlack)
+	bodyFetcher := tester.makeBodyFetcher("valid", blocks, 0)
+
+	imported := make(chan *types.Block)
+	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
+
+	// Announce the first block and broadcast the second one
+	tester.fetcher.Notify("valid", hashes[1], 1, time.Now().Add(-arriveTimeout), headerFetcher, bodyFetcher)
+	verifyImportEvent(t, imported, true)
+
+	tester.fetcher.Enqueue("valid", blocks[hashes[0]])
+	verifyImportEvent(t, imported, true)
+
+	stats := tester.fetcher.PropagationStats()
+	if stats.Tracked != 0 || stats.Imported != 2 {
+		t.Fatalf("tracking mismatch: have %d tracked, %d imported, want %d, %d", stats.Tracked, stats.Imported, 0, 2)
+	}
+	if stats.Announce.Samples != 1 || stats.Broadcast.Samples != 1 {
+		t.Fatalf("sample mismatch: have %d announce, %d broadcast, want %d, %d", stats.Announce.Samples, stats.Broadcast.Samples, 1, 1)
+	}
+	if min := millis(arriveTimeout); stats.Announce.Min < min {
+		t.Fatalf("announce latency too low: have %vms, want at least %vms", stats.Announce.Min, min)
+	}
+}
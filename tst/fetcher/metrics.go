@@ -33,6 +33,9 @@ var (
 	propBroadcastDropMeter = metrics.NewRegisteredMeter("gda/fetcher/prop/broadcasts/drop", nil)
 	propBroadcastDOSMeter  = metrics.NewRegisteredMeter("gda/fetcher/prop/broadcasts/dos", nil)
 
+	propAnnounceLatencyHist  = metrics.NewRegisteredHistogram("gda/fetcher/prop/latency/announce", nil, metrics.NewExpDecaySample(1028, 0.015))
+	propBroadcastLatencyHist = metrics.NewRegisteredHistogram("gda/fetcher/prop/latency/broadcast", nil, metrics.NewExpDecaySample(1028, 0.015))
+
 	headerFetchMeter = metrics.NewRegisteredMeter("gda/fetcher/fetch/headers", nil)
 	bodyFetchMeter   = metrics.NewRegisteredMeter("gda/fetcher/fetch/bodies", nil)
 
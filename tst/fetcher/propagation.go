@@ -0,0 +1,191 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the block propagation latency tracking of the fetcher.
+
+package fetcher
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+)
+
+const (
+	maxPropagationTracked = 1024 // Maximum number of unimported blocks to keep first-seen timestamps for
+	maxPropagationSamples = 1024 // Number of recent latencies to summarise in the propagation stats
+)
+
+// PropagationStats summarises how long it took for recently propagated blocks
+// to be imported after they were first seen in the network.
+type PropagationStats struct {
+	Tracked   int            `json:"tracked"`   // Number of blocks seen but not yet imported
+	Imported  uint64         `json:"imported"`  // Number of tracked blocks imported since startup
+	Announce  LatencySummary `json:"announce"`  // Latencies of blocks first seen through a hash announcement
+	Broadcast LatencySummary `json:"broadcast"` // Latencies of blocks first seen through a full block broadcast
+}
+
+// LatencySummary is a digest of recent propagation latencies, all of them in
+// milliseconds.
+type LatencySummary struct {
+	Samples int     `json:"samples"`
+	Mean    float64 `json:"mean"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+}
+
+// sighting is the first time a block was seen in the network.
+type sighting struct {
+	time      time.Time // Time the block was first seen
+	announced bool      // Whether the block was first seen through an announcement
+}
+
+// latencyWindow is a fixed size ring buffer of the most recent latencies.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// add inserts a new latency into the window, overwriting the oldest one if full.
+func (w *latencyWindow) add(latency time.Duration) {
+	if len(w.samples) < maxPropagationSamples {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % maxPropagationSamples
+}
+
+// summary computes the digest of the latencies currently in the window.
+func (w *latencyWindow) summary() LatencySummary {
+	if len(w.samples) == 0 {
+		return LatencySummary{}
+	}
+	sorted := make([]time.Duration, len(w.samples))
+	copy(sorted, w.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	percentile := func(p float64) float64 {
+		return millis(sorted[int(p*float64(len(sorted)-1))])
+	}
+	return LatencySummary{
+		Samples: len(sorted),
+		Mean:    millis(total / time.Duration(len(sorted))),
+		Min:     millis(sorted[0]),
+		Max:     millis(sorted[len(sorted)-1]),
+		P50:     percentile(0.50),
+		P95:     percentile(0.95),
+		P99:     percentile(0.99),
+	}
+}
+
+// millis converts a duration into fractional milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// propagationTracker remembers when blocks were first seen in the network and
+// measures how long it took for them to be imported.
+type propagationTracker struct {
+	seen  map[common.Hash]*sighting // First sightings of blocks not yet imported
+	order []common.Hash             // Sighting order of the blocks, used for eviction
+
+	announce  latencyWindow // Recent latencies of announced blocks
+	broadcast latencyWindow // Recent latencies of broadcast blocks
+	imports   uint64        // Number of tracked blocks imported
+
+	lock sync.Mutex
+}
+
+// newPropagationTracker creates an empty block propagation tracker.
+func newPropagationTracker() *propagationTracker {
+	return &propagationTracker{
+		seen: make(map[common.Hash]*sighting),
+	}
+}
+
+// sighted records the first time a block was seen, either through an announce
+// or a direct broadcast. Subsequent sightings of the same block are ignored.
+func (t *propagationTracker) sighted(hash common.Hash, time time.Time, announced bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.seen[hash]; ok {
+		return
+	}
+	t.seen[hash] = &sighting{time: time, announced: announced}
+	t.order = append(t.order, hash)
+
+	// Evict the oldest sightings if too many blocks never made it to import
+	for len(t.seen) > maxPropagationTracked {
+		delete(t.seen, t.order[0])
+		t.order = t.order[1:]
+	}
+	if len(t.order) > 2*maxPropagationTracked {
+		order := make([]common.Hash, 0, len(t.seen))
+		for _, hash := range t.order {
+			if _, ok := t.seen[hash]; ok {
+				order = append(order, hash)
+			}
+		}
+		t.order = order
+	}
+}
+
+// imported marks a block imported, measuring the time elapsed since it was
+// first seen in the network.
+func (t *propagationTracker) imported(hash common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	sight, ok := t.seen[hash]
+	if !ok {
+		return
+	}
+	delete(t.seen, hash)
+	t.imports++
+
+	latency := time.Since(sight.time)
+	if sight.announced {
+		t.announce.add(latency)
+		propAnnounceLatencyHist.Update(int64(latency / time.Millisecond))
+	} else {
+		t.broadcast.add(latency)
+		propBroadcastLatencyHist.Update(int64(latency / time.Millisecond))
+	}
+}
+
+// stats returns a digest of the recent block propagation latencies.
+func (t *propagationTracker) stats() PropagationStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return PropagationStats{
+		Tracked:   len(t.seen),
+		Imported:  t.imports,
+		Announce:  t.announce.summary(),
+		Broadcast: t.broadcast.summary(),
+	}
+}
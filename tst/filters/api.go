@@ -30,7 +30,6 @@ import (
 	"github.com/gdachain/go-gdachain/common/hexutil"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/gdadb"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/rpc"
 )
 
@@ -38,6 +37,28 @@ var (
 	deadline = 5 * time.Minute // consider a filter inactive if it has not been polled for within deadline
 )
 
+// Config are the limits imposed on historical log queries served over RPC.
+type Config struct {
+	RangeLimit  uint64        // Maximum number of blocks a single query may span (0 = unlimited)
+	ResultLimit int           // Maximum number of logs a single query may return (0 = unlimited)
+	Timeout     time.Duration // Maximum time a single query may execute for (0 = unlimited)
+}
+
+// DefaultConfig contains the default log query limits.
+var DefaultConfig = Config{
+	RangeLimit:  100000,
+	ResultLimit: 10000,
+	Timeout:     30 * time.Second,
+}
+
+// LogsPage is a single page of logs returned by a paginated log query. If the
+// query could not be completed within the configured limits, Next contains the
+// block number to resume the query from.
+type LogsPage struct {
+	Logs []*types.Log    `json:"logs"`
+	Next *hexutil.Uint64 `json:"next,omitempty"`
+}
+
 // filter is a helper struct that holds meta information over the filter type
 // and associated subscription in the event system.
 type filter struct {
@@ -53,22 +74,22 @@ type filter struct {
 // information related to the gdachain protocol such als blocks, transactions and logs.
 type PublicFilterAPI struct {
 	backend   Backend
-	mux       *event.TypeMux
 	quit      chan struct{}
 	chainDb   gdadb.Database
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+	config    Config
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
+func NewPublicFilterAPI(backend Backend, lightMode bool, config Config) *PublicFilterAPI {
 	api := &PublicFilterAPI{
 		backend: backend,
-		mux:     backend.EventMux(),
 		chainDb: backend.ChainDb(),
-		events:  NewEventSystem(backend.EventMux(), backend, lightMode),
+		events:  NewEventSystem(backend, lightMode),
 		filters: make(map[rpc.ID]*filter),
+		config:  config,
 	}
 	go api.timeoutLoop()
 
@@ -230,12 +251,27 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+// If the criteria starts at a block in the past, the matching historical logs are
+// streamed first, after which the subscription seamlessly switches to new logs.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
+	// Historical logs are subject to the same block range limit as log queries
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 && api.config.RangeLimit > 0 {
+		header, err := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+		if header == nil {
+			return nil, err
+		}
+		end := header.Number.Uint64()
+		if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < end {
+			end = crit.ToBlock.Uint64()
+		}
+		if begin := crit.FromBlock.Uint64(); end >= begin && end-begin >= api.config.RangeLimit {
+			return nil, fmt.Errorf("subscription spans %d historical blocks, exceeding the limit of %d", end-begin+1, api.config.RangeLimit)
+		}
+	}
 	var (
 		rpcSub      = notifier.CreateSubscription()
 		matchedLogs = make(chan []*types.Log)
@@ -246,6 +282,14 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 		return nil, err
 	}
 
+	// Stream the historical logs first if the subscription starts in the past
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		go func() {
+			defer logsSub.Unsubscribe()
+			api.runHybridLogs(crit.FromBlock.Uint64(), crit, matchedLogs, rpcSub, notifier)
+		}()
+		return rpcSub, nil
+	}
 	go func() {
 
 		for {
@@ -333,16 +377,100 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 	if crit.ToBlock == nil {
 		crit.ToBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
 	}
-	// Create and run the filter to get all the logs
-	filter := New(api.backend, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit.Addresses, crit.Topics)
-
-	logs, err := filter.Logs(ctx)
+	logs, _, err := api.queryLogs(ctx, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit, false)
 	if err != nil {
 		return nil, err
 	}
 	return returnLogs(logs), err
 }
 
+// GetLogsPage returns logs matching the given argument, stopping early if the
+// query would exceed the node's range, result or time limits. In that case the
+// returned page contains a cursor with the block number to resume from, which
+// can be used as the starting block of the next query.
+func (api *PublicFilterAPI) GetLogsPage(ctx context.Context, crit FilterCriteria) (*LogsPage, error) {
+	// Convert the RPC block numbers into internal representations
+	if crit.FromBlock == nil {
+		crit.FromBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
+	}
+	if crit.ToBlock == nil {
+		crit.ToBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
+	}
+	logs, next, err := api.queryLogs(ctx, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit, true)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogsPage{Logs: returnLogs(logs)}
+	if next != nil {
+		page.Next = (*hexutil.Uint64)(next)
+	}
+	return page, nil
+}
+
+// queryLogs runs a historical log query over the given block range, enforcing
+// the configured range, result and time limits. If paged is set, a query hitting
+// the limits returns the logs gathered so far along with the block to resume
+// from, otherwise the limits result in an error.
+func (api *PublicFilterAPI) queryLogs(ctx context.Context, begin, end int64, crit FilterCriteria, paged bool) ([]*types.Log, *uint64, error) {
+	if api.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.config.Timeout)
+		defer cancel()
+	}
+	// Resolve the query range and enforce the block range limit
+	header, _ := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil {
+		return nil, nil, nil
+	}
+	head := header.Number.Int64()
+	if begin < 0 {
+		begin = head
+	}
+	if end < 0 {
+		end = head
+	}
+	last := end
+	if limit := int64(api.config.RangeLimit); limit > 0 && end >= begin && end-begin >= limit {
+		if !paged {
+			return nil, nil, fmt.Errorf("query spans %d blocks, exceeding the limit of %d", end-begin+1, limit)
+		}
+		last = begin + limit - 1
+	}
+	// Create and run the filter to get all the logs
+	filter := New(api.backend, begin, last, crit.Addresses, crit.Topics)
+	filter.limit = api.config.ResultLimit
+
+	logs, err := filter.Logs(ctx)
+	switch {
+	case err == nil || (err == errResultLimit && filter.begin > last):
+		// Range fully processed, return a cursor if it was clamped
+		if last < end {
+			next := uint64(last) + 1
+			return logs, &next, nil
+		}
+		return logs, nil, nil
+
+	case err == errResultLimit:
+		if !paged {
+			return nil, nil, fmt.Errorf("query returned more than %d results", api.config.ResultLimit)
+		}
+		next := uint64(filter.begin)
+		return logs, &next, nil
+
+	case err == context.DeadlineExceeded:
+		// Return the partial results if progress was made, otherwise the client
+		// would spin on the same cursor indefinitely
+		if !paged || filter.begin <= begin {
+			return nil, nil, fmt.Errorf("query timed out after %v", api.config.Timeout)
+		}
+		next := uint64(filter.begin)
+		return logs, &next, nil
+
+	default:
+		return nil, nil, err
+	}
+}
+
 // UninstallFilter removes the filter with the given filter id.
 //
 // https://github.com/gdaereum/wiki/wiki/JSON-RPC#eth_uninstallfilter
@@ -381,10 +509,7 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 	if f.crit.ToBlock != nil {
 		end = f.crit.ToBlock.Int64()
 	}
-	// Create and run the filter to get all the logs
-	filter := New(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
-
-	logs, err := filter.Logs(ctx)
+	logs, _, err := api.queryLogs(ctx, begin, end, f.crit, false)
 	if err != nil {
 		return nil, err
 	}
@@ -123,14 +123,14 @@ func benchmarkBloomBits(b *testing.B, sectionSize uint64) {
 
 	fmt.Println("Running filter benchmarks...")
 	start = time.Now()
-	mux := new(event.TypeMux)
+	pendingLogsFeed := new(event.Feed)
 	var backend *testBackend
 
 	for i := 0; i < benchFilterCnt; i++ {
 		if i%20 == 0 {
 			db.Close()
 			db, _ = gdadb.NewLDBDatabase(benchDataDir, 128, 1024)
-			backend = &testBackend{mux, db, cnt, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+			backend = &testBackend{pendingLogsFeed, db, cnt, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
 		}
 		var addr common.Address
 		addr[0] = byte(i)
@@ -190,8 +190,8 @@ func BenchmarkNoBloomBits(b *testing.B) {
 
 	fmt.Println("Running filter benchmarks...")
 	start := time.Now()
-	mux := new(event.TypeMux)
-	backend := &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+	pendingLogsFeed := new(event.Feed)
+	backend := &testBackend{pendingLogsFeed, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
 	filter := New(backend, 0, int64(headNum), []common.Address{{}}, nil)
 	filter.Logs(context.Background())
 	d := time.Since(start)
@@ -18,6 +18,7 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/gdachain/go-gdachain/common"
@@ -31,7 +32,6 @@ import (
 
 type Backend interface {
 	ChainDb() gdadb.Database
-	EventMux() *event.TypeMux
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
@@ -40,11 +40,41 @@ type Backend interface {
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
+	SubscribePendingLogsEvent(ch chan<- core.PendingLogsEvent) event.Subscription
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
+// errResultLimit is returned internally by a filter if the number of matched
+// logs reached the configured result limit. The filter's starting block points
+// to the first block not yet processed.
+var errResultLimit = errors.New("result limit reached")
+
+// priorityKey is the context key marking a log query as latency sensitive.
+type priorityKey struct{}
+
+// WithPriority returns a copy of the context marking any log filtering done with
+// it as latency sensitive (e.g. live subscriptions), permitting backends to serve
+// its bloombits retrievals ahead of bulk historical queries.
+func WithPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, true)
+}
+
+// IsPriority reports whgdaer the context was marked latency sensitive.
+func IsPriority(ctx context.Context) bool {
+	priority, _ := ctx.Value(priorityKey{}).(bool)
+	return priority
+}
+
+// LogsDelegator is an optional interface implemented by backends able to hand
+// a log filter query over to a remote server (e.g. light clients). Only the part
+// of the range not covered by the bloom bits index is delegated, the rest is
+// filtered via the (verifiable) bloom bits.
+type LogsDelegator interface {
+	FilterLogs(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error)
+}
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend Backend
@@ -54,6 +84,9 @@ type Filter struct {
 	addresses  []common.Address
 	topics     [][]common.Hash
 
+	limit int // Maximum number of logs to gather before stopping (0 = unlimited)
+	found int // Number of logs gathered so far
+
 	matcher *bloombits.Matcher
 }
 
@@ -125,6 +158,15 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 			return logs, err
 		}
 	}
+	// If the backend can delegate the query, hand the non indexed range over to
+	// it instead of iterating it block by block, falling back to local filtering
+	// if it fails
+	if delegator, ok := f.backend.(LogsDelegator); ok && uint64(f.begin) <= end {
+		if rest, err := delegator.FilterLogs(ctx, uint64(f.begin), end, f.addresses, f.topics); err == nil {
+			f.begin = int64(end) + 1
+			return f.truncate(append(logs, rest...))
+		}
+	}
 	rest, err := f.unindexedLogs(ctx, end)
 	logs = append(logs, rest...)
 	return logs, err
@@ -158,8 +200,6 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 				}
 				return logs, err
 			}
-			f.begin = int64(number) + 1
-
 			// Retrieve the suggested block and pull any truly matching logs
 			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
 			if header == nil || err != nil {
@@ -170,6 +210,11 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 				return logs, err
 			}
 			logs = append(logs, found...)
+			f.begin = int64(number) + 1
+
+			if f.found += len(found); f.limit > 0 && f.found >= f.limit {
+				return logs, errResultLimit
+			}
 
 		case <-ctx.Done():
 			return logs, ctx.Err()
@@ -182,7 +227,13 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
 	var logs []*types.Log
 
-	for ; f.begin <= int64(end); f.begin++ {
+	for f.begin <= int64(end) {
+		// Abort if the caller gave up on the query
+		select {
+		case <-ctx.Done():
+			return logs, ctx.Err()
+		default:
+		}
 		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
 		if header == nil || err != nil {
 			return logs, err
@@ -193,11 +244,35 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 				return logs, err
 			}
 			logs = append(logs, found...)
+			f.found += len(found)
+		}
+		f.begin++
+
+		if f.limit > 0 && f.found >= f.limit {
+			return logs, errResultLimit
 		}
 	}
 	return logs, nil
 }
 
+// truncate cuts a fully retrieved set of logs down to the configured result
+// limit on a block boundary, rewinding the filter's starting block to the first
+// block whose logs were dropped.
+func (f *Filter) truncate(logs []*types.Log) ([]*types.Log, error) {
+	if f.limit <= 0 || len(logs) < f.limit {
+		return logs, nil
+	}
+	cut := f.limit
+	for cut < len(logs) && logs[cut].BlockNumber == logs[cut-1].BlockNumber {
+		cut++
+	}
+	if cut < len(logs) {
+		f.begin = int64(logs[cut].BlockNumber)
+	}
+	f.found = cut
+	return logs[:cut], errResultLimit
+}
+
 // checkMatches checks if the receipts belonging to the given header contain any log events that
 // match the filter criteria. This function is called when the bloom filter signals a potential match.
 func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
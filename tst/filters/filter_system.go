@@ -29,7 +29,6 @@ import (
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/core/types"
-	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/rpc"
 )
 
@@ -66,6 +65,8 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// pendingLogsChanSize is the size of channel listening to PendingLogsEvent.
+	pendingLogsChanSize = 10
 )
 
 var (
@@ -87,7 +88,6 @@ type subscription struct {
 // EventSystem creates subscriptions, processes events and broadcasts them to the
 // subscription which match the subscription criteria.
 type EventSystem struct {
-	mux       *event.TypeMux
 	backend   Backend
 	lightMode bool
 	lastHead  *types.Header
@@ -95,15 +95,15 @@ type EventSystem struct {
 	uninstall chan *subscription // remove filter for event notification
 }
 
-// NewEventSystem creates a new manager that listens for event on the given mux,
-// parses and filters them. It uses the all map to retrieve filter changes. The
-// work loop holds its own index that is used to forward events to filters.
+// NewEventSystem creates a new manager that listens for event on the given
+// backend, parses and filters them. It uses the all map to retrieve filter
+// changes. The work loop holds its own index that is used to forward events to
+// filters.
 //
-// The returned manager has a loop that needs to be stopped with the Stop function
-// or by stopping the given mux.
-func NewEventSystem(mux *event.TypeMux, backend Backend, lightMode bool) *EventSystem {
+// The returned manager has a loop that terminates once the backend closes any of
+// its event subscriptions.
+func NewEventSystem(backend Backend, lightMode bool) *EventSystem {
 	m := &EventSystem{
-		mux:       mux,
 		backend:   backend,
 		lightMode: lightMode,
 		install:   make(chan *subscription),
@@ -305,14 +305,11 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 				f.logs <- matchedLogs
 			}
 		}
-	case *event.TypeMuxEvent:
-		switch muxe := e.Data.(type) {
-		case core.PendingLogsEvent:
-			for _, f := range filters[PendingLogsSubscription] {
-				if e.Time.After(f.created) {
-					if matchedLogs := filterLogs(muxe.Logs, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
-						f.logs <- matchedLogs
-					}
+	case core.PendingLogsEvent:
+		for _, f := range filters[PendingLogsSubscription] {
+			if e.Time.After(f.created) {
+				if matchedLogs := filterLogs(e.Logs, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics); len(matchedLogs) > 0 {
+					f.logs <- matchedLogs
 				}
 			}
 		}
@@ -409,11 +406,10 @@ func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.
 	return nil
 }
 
-// eventLoop (un)installs filters and processes mux events.
+// eventLoop (un)installs filters and processes backend events.
 func (es *EventSystem) eventLoop() {
 	var (
 		index = make(filterIndex)
-		sub   = es.mux.Subscribe(core.PendingLogsEvent{})
 		// Subscribe TxPreEvent form txpool
 		txCh  = make(chan core.TxPreEvent, txChanSize)
 		txSub = es.backend.SubscribeTxPreEvent(txCh)
@@ -426,14 +422,17 @@ func (es *EventSystem) eventLoop() {
 		// Subscribe ChainEvent
 		chainEvCh  = make(chan core.ChainEvent, chainEvChanSize)
 		chainEvSub = es.backend.SubscribeChainEvent(chainEvCh)
+		// Subscribe PendingLogsEvent
+		pendingLogsCh  = make(chan core.PendingLogsEvent, pendingLogsChanSize)
+		pendingLogsSub = es.backend.SubscribePendingLogsEvent(pendingLogsCh)
 	)
 
 	// Unsubscribe all events
-	defer sub.Unsubscribe()
 	defer txSub.Unsubscribe()
 	defer rmLogsSub.Unsubscribe()
 	defer logsSub.Unsubscribe()
 	defer chainEvSub.Unsubscribe()
+	defer pendingLogsSub.Unsubscribe()
 
 	for i := UnknownSubscription; i < LastIndexSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
@@ -441,12 +440,6 @@ func (es *EventSystem) eventLoop() {
 
 	for {
 		select {
-		case ev, active := <-sub.Chan():
-			if !active { // system stopped
-				return
-			}
-			es.broadcast(index, ev)
-
 		// Handle subscribed events
 		case ev := <-txCh:
 			es.broadcast(index, ev)
@@ -456,6 +449,8 @@ func (es *EventSystem) eventLoop() {
 			es.broadcast(index, ev)
 		case ev := <-chainEvCh:
 			es.broadcast(index, ev)
+		case ev := <-pendingLogsCh:
+			es.broadcast(index, ev)
 
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
@@ -485,6 +480,8 @@ func (es *EventSystem) eventLoop() {
 			return
 		case <-chainEvSub.Err():
 			return
+		case <-pendingLogsSub.Err():
+			return
 		}
 	}
 }
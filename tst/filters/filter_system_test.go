@@ -38,23 +38,19 @@ import (
 )
 
 type testBackend struct {
-	mux        *event.TypeMux
-	db         gdadb.Database
-	sections   uint64
-	txFeed     *event.Feed
-	rmLogsFeed *event.Feed
-	logsFeed   *event.Feed
-	chainFeed  *event.Feed
+	pendingLogsFeed *event.Feed
+	db              gdadb.Database
+	sections        uint64
+	txFeed          *event.Feed
+	rmLogsFeed      *event.Feed
+	logsFeed        *event.Feed
+	chainFeed       *event.Feed
 }
 
 func (b *testBackend) ChainDb() gdadb.Database {
 	return b.db
 }
 
-func (b *testBackend) EventMux() *event.TypeMux {
-	return b.mux
-}
-
 func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	var hash common.Hash
 	var num uint64
@@ -100,6 +96,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribePendingLogsEvent(ch chan<- core.PendingLogsEvent) event.Subscription {
+	return b.pendingLogsFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -140,17 +140,17 @@ func TestBlockSubscription(t *testing.T) {
 	t.Parallel()
 
 	var (
-		mux         = new(event.TypeMux)
-		db, _       = gdadb.NewMemDatabase()
-		txFeed      = new(event.Feed)
-		rmLogsFeed  = new(event.Feed)
-		logsFeed    = new(event.Feed)
-		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api         = NewPublicFilterAPI(backend, false)
-		genesis     = new(core.Genesis).MustCommit(db)
-		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
-		chainEvents = []core.ChainEvent{}
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
+		genesis         = new(core.Genesis).MustCommit(db)
+		chain, _        = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
+		chainEvents     = []core.ChainEvent{}
 	)
 
 	for _, blk := range chain {
@@ -192,19 +192,19 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
-// TestPendingTxFilter tests whgdaer pending tx filters retrieve all pending transactions that are posted to the event mux.
+// TestPendingTxFilter tests whgdaer pending tx filters retrieve all pending transactions that are sent to the transaction feed.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
 
 	var (
-		mux        = new(event.TypeMux)
-		db, _      = gdadb.NewMemDatabase()
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
 
 		transactions = []*types.Transaction{
 			types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil),
@@ -260,14 +260,14 @@ func TestPendingTxFilter(t *testing.T) {
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
 	var (
-		mux        = new(event.TypeMux)
-		db, _      = gdadb.NewMemDatabase()
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
 
 		testCases = []struct {
 			crit    FilterCriteria
@@ -309,14 +309,14 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 	t.Parallel()
 
 	var (
-		mux        = new(event.TypeMux)
-		db, _      = gdadb.NewMemDatabase()
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
 	)
 
 	// different situations where log filter creation should fail.
@@ -339,14 +339,14 @@ func TestLogFilter(t *testing.T) {
 	t.Parallel()
 
 	var (
-		mux        = new(event.TypeMux)
-		db, _      = gdadb.NewMemDatabase()
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -412,8 +412,8 @@ func TestLogFilter(t *testing.T) {
 	if nsend := logsFeed.Send(allLogs); nsend == 0 {
 		t.Fatal("Shoud have at least one subscription")
 	}
-	if err := mux.Post(core.PendingLogsEvent{Logs: allLogs}); err != nil {
-		t.Fatal(err)
+	if nsend := pendingLogsFeed.Send(core.PendingLogsEvent{Logs: allLogs, Time: time.Now()}); nsend == 0 {
+		t.Fatal("Shoud have at least one subscription")
 	}
 
 	for i, tt := range testCases {
@@ -458,14 +458,14 @@ func TestPendingLogsSubscription(t *testing.T) {
 	t.Parallel()
 
 	var (
-		mux        = new(event.TypeMux)
-		db, _      = gdadb.NewMemDatabase()
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		api        = NewPublicFilterAPI(backend, false)
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
 		secondAddr     = common.HexToAddress("0x2222222222222222222222222222222222222222")
@@ -565,8 +565,50 @@ func TestPendingLogsSubscription(t *testing.T) {
 	time.Sleep(1 * time.Second)
 	// allLogs are type of core.PendingLogsEvent
 	for _, l := range allLogs {
-		if err := mux.Post(l); err != nil {
-			t.Fatal(err)
+		l.Time = time.Now()
+		if nsend := pendingLogsFeed.Send(l); nsend == 0 {
+			t.Fatal("Shoud have at least one subscription")
 		}
 	}
 }
+
+// TestPendingLogsPostedBeforeSubscription tests that pending logs posted before
+// a subscription was created are not delivered to it.
+func TestPendingLogsPostedBeforeSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pendingLogsFeed = new(event.Feed)
+		db, _           = gdadb.NewMemDatabase()
+		backend         = &testBackend{pendingLogsFeed, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		api             = NewPublicFilterAPI(backend, false, DefaultConfig)
+
+		stale = core.PendingLogsEvent{Logs: []*types.Log{{BlockNumber: 1}}, Time: time.Now()}
+		fresh = core.PendingLogsEvent{Logs: []*types.Log{{BlockNumber: 2}}}
+	)
+	logs := make(chan []*types.Log, 2)
+	pending := big.NewInt(rpc.PendingBlockNumber.Int64())
+	sub, _ := api.events.SubscribeLogs(gdaereum.FilterQuery{FromBlock: pending, ToBlock: pending}, logs)
+	defer sub.Unsubscribe()
+
+	time.Sleep(100 * time.Millisecond)
+	fresh.Time = time.Now()
+	for _, ev := range []core.PendingLogsEvent{stale, fresh} {
+		if nsend := pendingLogsFeed.Send(ev); nsend == 0 {
+			t.Fatal("Shoud have at least one subscription")
+		}
+	}
+	select {
+	case have := <-logs:
+		if len(have) != 1 || have[0].BlockNumber != 2 {
+			t.Fatalf("delivered logs mismatch: have %v, want the fresh ones", have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fresh pending logs not delivered")
+	}
+	select {
+	case have := <-logs:
+		t.Fatalf("unexpected logs delivered: %v", have)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -18,14 +18,17 @@ package filters
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/bloombits"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/gdadb"
@@ -50,18 +53,18 @@ func BenchmarkFilters(b *testing.B) {
 	defer os.RemoveAll(dir)
 
 	var (
-		db, _      = gdadb.NewLDBDatabase(dir, 0, 0)
-		mux        = new(event.TypeMux)
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
-		addr1      = crypto.PubkeyToAddress(key1.PublicKey)
-		addr2      = common.BytesToAddress([]byte("jeff"))
-		addr3      = common.BytesToAddress([]byte("gdaereum"))
-		addr4      = common.BytesToAddress([]byte("random addresses please"))
+		db, _           = gdadb.NewLDBDatabase(dir, 0, 0)
+		pendingLogsFeed = new(event.Feed)
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		key1, _         = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1           = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2           = common.BytesToAddress([]byte("jeff"))
+		addr3           = common.BytesToAddress([]byte("gdaereum"))
+		addr4           = common.BytesToAddress([]byte("random addresses please"))
 	)
 	defer db.Close()
 
@@ -115,15 +118,15 @@ func TestFilters(t *testing.T) {
 	defer os.RemoveAll(dir)
 
 	var (
-		db, _      = gdadb.NewLDBDatabase(dir, 0, 0)
-		mux        = new(event.TypeMux)
-		txFeed     = new(event.Feed)
-		rmLogsFeed = new(event.Feed)
-		logsFeed   = new(event.Feed)
-		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
-		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
-		addr       = crypto.PubkeyToAddress(key1.PublicKey)
+		db, _           = gdadb.NewLDBDatabase(dir, 0, 0)
+		pendingLogsFeed = new(event.Feed)
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		key1, _         = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr            = crypto.PubkeyToAddress(key1.PublicKey)
 
 		hash1 = common.BytesToHash([]byte("topic1"))
 		hash2 = common.BytesToHash([]byte("topic2"))
@@ -241,3 +244,226 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// Tests that historical log queries respect the configured range and result
+// limits, and that paginated queries can be resumed from the returned cursor.
+func TestLogsLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filtertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		db, _           = gdadb.NewLDBDatabase(dir, 0, 0)
+		pendingLogsFeed = new(event.Feed)
+		txFeed          = new(event.Feed)
+		rmLogsFeed      = new(event.Feed)
+		logsFeed        = new(event.Feed)
+		chainFeed       = new(event.Feed)
+		backend         = &testBackend{pendingLogsFeed, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		key1, _         = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr            = crypto.PubkeyToAddress(key1.PublicKey)
+	)
+	defer db.Close()
+
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 100, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+	}
+	api := NewPublicFilterAPI(backend, false, Config{RangeLimit: 30, ResultLimit: 7})
+	crit := FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(100), Addresses: []common.Address{addr}}
+
+	// Non-paginated queries must fail instead of truncating the results
+	if _, err := api.GetLogs(context.Background(), crit); err == nil {
+		t.Fatalf("oversized block range accepted")
+	}
+	small := FilterCriteria{FromBlock: big.NewInt(1), ToBlock: big.NewInt(10), Addresses: []common.Address{addr}}
+	if _, err := api.GetLogs(context.Background(), small); err == nil {
+		t.Fatalf("oversized result set accepted")
+	}
+	small.ToBlock = big.NewInt(7)
+	if logs, err := api.GetLogs(context.Background(), small); err != nil || len(logs) != 7 {
+		t.Fatalf("limited query failed: have %d logs, err %v; want 7 logs", len(logs), err)
+	}
+	// Paginated queries must eventually return all the logs
+	var (
+		logs  int
+		pages int
+	)
+	for {
+		page, err := api.GetLogsPage(context.Background(), crit)
+		if err != nil {
+			t.Fatalf("page %d: query failed: %v", pages, err)
+		}
+		if len(page.Logs) > 7 {
+			t.Fatalf("page %d: result limit exceeded: have %d, want at most 7", pages, len(page.Logs))
+		}
+		logs += len(page.Logs)
+		if pages++; page.Next == nil {
+			break
+		}
+		if pages > 100 {
+			t.Fatalf("pagination made no progress")
+		}
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*page.Next))
+	}
+	if logs != 100 {
+		t.Fatalf("paginated log count mismatch: have %d, want 100", logs)
+	}
+}
+
+// delegatingBackend is a filter backend with a small bloom bits section size,
+// which can also delegate log queries to a (simulated) remote server.
+type delegatingBackend struct {
+	*testBackend
+
+	sectionSize uint64
+	bitsets     map[uint64]map[uint][]byte // Bloom bits by section and bit index
+
+	delegated [][2]uint64 // Ranges of the delegated queries
+	fail      bool        // Whether delegated queries fail
+}
+
+func (b *delegatingBackend) BloomStatus() (uint64, uint64) {
+	return b.sectionSize, uint64(len(b.bitsets))
+}
+
+func (b *delegatingBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
+	requests := make(chan chan *bloombits.Retrieval)
+
+	go session.Multiplex(16, 0, requests)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case request := <-requests:
+				task := <-request
+
+				task.Bitsets = make([][]byte, len(task.Sections))
+				for i, section := range task.Sections {
+					task.Bitsets[i] = b.bitsets[section][task.Bit]
+				}
+				request <- task
+			}
+		}
+	}()
+}
+
+func (b *delegatingBackend) FilterLogs(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	b.delegated = append(b.delegated, [2]uint64{begin, end})
+	if b.fail {
+		return nil, errors.New("delegation failed")
+	}
+	return []*types.Log{{Address: addresses[0], Data: []byte{byte(end)}}}, nil
+}
+
+// Tests that log queries of delegating backends (e.g. light clients) are served
+// from the bloom bits where indexed, and only the rest is delegated.
+func TestDelegatedLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filtertest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		db, _   = gdadb.NewLDBDatabase(dir, 0, 0)
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+		backend = &delegatingBackend{
+			testBackend: &testBackend{new(event.Feed), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)},
+			sectionSize: 16,
+			bitsets:     make(map[uint64]map[uint][]byte),
+		}
+	)
+	defer db.Close()
+
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 49, func(i int, gen *core.BlockGen) {
+		// Tag the logs with their block numbers, receipts don't store them
+		switch i {
+		case 4, 19, 39:
+			receipt := makeReceipt(addr)
+			receipt.Logs[0].Data = []byte{byte(i + 1)}
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	headers := []*types.Header{genesis.Header()}
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to insert block number: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatal("error writing block receipts:", err)
+		}
+		headers = append(headers, block.Header())
+	}
+	// Index the first two sections (blocks 0-31), leaving the rest unindexed
+	for section := uint64(0); section < 2; section++ {
+		backend.bitsets[section] = make(map[uint][]byte)
+		for bit := uint(0); bit < types.BloomBitLength; bit++ {
+			bitset := make([]byte, backend.sectionSize/8)
+			for i := uint64(0); i < backend.sectionSize; i++ {
+				bloom := headers[section*backend.sectionSize+i].Bloom
+				if bloom[types.BloomByteLength-1-bit/8]&(1<<(bit%8)) != 0 {
+					bitset[i/8] |= 1 << (7 - i%8)
+				}
+			}
+			backend.bitsets[section][bit] = bitset
+		}
+	}
+	// Query the whole chain, only the unindexed range may be delegated
+	logs, err := New(backend, 0, -1, []common.Address{addr}, nil).Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	if len(backend.delegated) != 1 || backend.delegated[0] != [2]uint64{32, 49} {
+		t.Fatalf("delegated ranges mismatch: have %v, want [[32 49]]", backend.delegated)
+	}
+	var numbers []byte
+	for _, log := range logs {
+		numbers = append(numbers, log.Data...)
+	}
+	if want := []byte{5, 20, 49}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("log blocks mismatch: have %v, want %v", numbers, want)
+	}
+	// Fully indexed queries must not be delegated at all
+	backend.delegated = nil
+	if logs, err = New(backend, 0, 31, []common.Address{addr}, nil).Logs(context.Background()); err != nil || len(logs) != 2 {
+		t.Fatalf("indexed query mismatch: have %d logs, err %v; want 2 logs", len(logs), err)
+	}
+	if len(backend.delegated) != 0 {
+		t.Fatalf("indexed range delegated: %v", backend.delegated)
+	}
+	// Failed delegations must fall back to filtering the unindexed range locally
+	backend.fail = true
+	if logs, err = New(backend, 0, -1, []common.Address{addr}, nil).Logs(context.Background()); err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	numbers = numbers[:0]
+	for _, log := range logs {
+		numbers = append(numbers, log.Data...)
+	}
+	if want := []byte{5, 20, 40}; !reflect.DeepEqual(numbers, want) {
+		t.Fatalf("log blocks mismatch after failed delegation: have %v, want %v", numbers, want)
+	}
+}
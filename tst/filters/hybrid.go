@@ -0,0 +1,200 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+const (
+	// historicalLogsChunk is the number of blocks filtered in one go while
+	// streaming historical logs to a subscriber.
+	historicalLogsChunk = 4096
+
+	// historicalReorgDepth is the number of blocks below the last historically
+	// streamed block for which delivered logs are tracked, so that overlapping
+	// live events (including reorg removals) are delivered exactly once.
+	historicalReorgDepth = 128
+
+	// historicalQueueLimit is the maximum number of live logs buffered while the
+	// historical logs are being streamed.
+	historicalQueueLimit = 10000
+)
+
+// errHistoricalQueueFull is returned if too many live logs arrive while the
+// historical logs of a subscription are being streamed.
+var errHistoricalQueueFull = errors.New("too many live logs during historical streaming")
+
+// logKey uniquely identifies a log within the chain.
+type logKey struct {
+	block common.Hash
+	index uint
+}
+
+// hybridLogs is the state of a log subscription that started in the past: the
+// historical logs are streamed first while live logs are buffered, then the
+// subscription switches over to the live feed.
+type hybridLogs struct {
+	head   uint64              // Last block covered by the historical phase
+	sent   map[logKey]struct{} // Logs delivered near the head of the historical phase
+	queued []*types.Log        // Live logs buffered during the historical phase
+}
+
+// streamHistorical delivers all logs matching crit from the given block up to
+// the current chain head (or crit.ToBlock if earlier), in chunks. More than limit
+// logs in total (if positive) result in an error.
+func (h *hybridLogs) streamHistorical(ctx context.Context, backend Backend, from uint64, crit FilterCriteria, limit int, notify func(*types.Log)) error {
+	header, err := backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if header == nil {
+		return err
+	}
+	h.head = header.Number.Uint64()
+
+	end := h.head
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < end {
+		end = crit.ToBlock.Uint64()
+	}
+	found := 0
+	for begin := from; begin <= end; begin += historicalLogsChunk {
+		last := begin + historicalLogsChunk - 1
+		if last > end {
+			last = end
+		}
+		filter := New(backend, int64(begin), int64(last), crit.Addresses, crit.Topics)
+		if limit > 0 {
+			filter.limit = limit - found + 1
+		}
+		logs, err := filter.Logs(WithPriority(ctx))
+		if err == errResultLimit {
+			return fmt.Errorf("historical logs exceed the limit of %d results", limit)
+		}
+		if err != nil {
+			return err
+		}
+		found += len(logs)
+		for _, log := range logs {
+			h.track(log)
+			notify(log)
+		}
+	}
+	return nil
+}
+
+// track records a delivered log if it's close enough to the historical head to
+// be affected by a reorg overlapping with the live feed.
+func (h *hybridLogs) track(log *types.Log) {
+	if log.BlockNumber+historicalReorgDepth > h.head {
+		h.sent[logKey{log.BlockHash, log.Index}] = struct{}{}
+	}
+}
+
+// queue buffers live logs arriving during the historical phase, failing if too
+// many are waiting.
+func (h *hybridLogs) queue(logs []*types.Log) error {
+	if len(h.queued)+len(logs) > historicalQueueLimit {
+		return errHistoricalQueueFull
+	}
+	h.queued = append(h.queued, logs...)
+	return nil
+}
+
+// deliver forwards a live log to the subscriber, filtering out the ones that
+// overlap with the historical phase: logs already delivered are skipped and
+// removals are only forwarded for logs the subscriber has seen.
+func (h *hybridLogs) deliver(log *types.Log, notify func(*types.Log)) {
+	if log.BlockNumber > h.head || log.BlockNumber+historicalReorgDepth <= h.head {
+		notify(log)
+		return
+	}
+	key := logKey{log.BlockHash, log.Index}
+	_, seen := h.sent[key]
+	switch {
+	case log.Removed && seen:
+		delete(h.sent, key)
+		notify(log)
+	case !log.Removed && !seen:
+		h.sent[key] = struct{}{}
+		notify(log)
+	}
+}
+
+// runHybridLogs drives a hybrid log subscription until the subscriber goes away.
+// The historical phase is subject to the result and time limits of the API, and
+// the subscription is failed if it cannot be completed.
+func (api *PublicFilterAPI) runHybridLogs(from uint64, crit FilterCriteria, matchedLogs chan []*types.Log, rpcSub *rpc.Subscription, notifier *rpc.Notifier) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	historicalCtx := ctx
+	if api.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		historicalCtx, cancel = context.WithTimeout(ctx, api.config.Timeout)
+		defer cancel()
+	}
+	var (
+		h      = &hybridLogs{sent: make(map[logKey]struct{})}
+		notify = func(log *types.Log) { notifier.Notify(rpcSub.ID, log) }
+		done   = make(chan error, 1)
+	)
+	go func() { done <- h.streamHistorical(historicalCtx, api.backend, from, crit, api.config.ResultLimit, notify) }()
+
+	// Buffer live logs until the historical phase completes
+	for historical := true; historical; {
+		select {
+		case logs := <-matchedLogs:
+			if err := h.queue(logs); err != nil {
+				cancel()
+				<-done
+				notifier.Fail(rpcSub.ID, err)
+				return
+			}
+		case err := <-done:
+			if err != nil {
+				notifier.Fail(rpcSub.ID, err)
+				return
+			}
+			historical = false
+		case <-rpcSub.Err(): // client send an unsubscribe request
+			return
+		case <-notifier.Closed(): // connection dropped
+			return
+		}
+	}
+	for _, log := range h.queued {
+		h.deliver(log, notify)
+	}
+	h.queued = nil
+	// Switch over to live mode
+	for {
+		select {
+		case logs := <-matchedLogs:
+			for _, log := range logs {
+				h.deliver(log, notify)
+			}
+		case <-rpcSub.Err(): // client send an unsubscribe request
+			return
+		case <-notifier.Closed(): // connection dropped
+			return
+		}
+	}
+}
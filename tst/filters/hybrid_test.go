@@ -0,0 +1,155 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/event"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Tests that live logs overlapping with the historical phase of a hybrid log
+// subscription are delivered exactly once, and removals only for seen logs.
+func TestHybridLogsDelivery(t *testing.T) {
+	h := &hybridLogs{head: 1000, sent: make(map[logKey]struct{})}
+
+	var (
+		old     = &types.Log{BlockNumber: 1000, BlockHash: common.Hash{1}, Index: 0}
+		unseen  = &types.Log{BlockNumber: 1000, BlockHash: common.Hash{2}, Index: 0}
+		ancient = &types.Log{BlockNumber: 10, BlockHash: common.Hash{3}, Index: 0}
+		fresh   = &types.Log{BlockNumber: 1001, BlockHash: common.Hash{4}, Index: 0}
+	)
+	h.track(old)
+	h.track(ancient)
+	if len(h.sent) != 1 {
+		t.Fatalf("tracked log count mismatch: have %d, want 1", len(h.sent))
+	}
+	var delivered []*types.Log
+	notify := func(log *types.Log) { delivered = append(delivered, log) }
+
+	h.deliver(old, notify)     // duplicate of a historical log, dropped
+	h.deliver(unseen, notify)  // reorged in block, delivered
+	h.deliver(fresh, notify)   // new block, delivered
+	h.deliver(ancient, notify) // too deep to be tracked, delivered
+
+	removed := *old
+	removed.Removed = true
+	h.deliver(&removed, notify) // removal of a seen log, delivered
+	h.deliver(&removed, notify) // repeated removal, dropped
+
+	want := []*types.Log{unseen, fresh, ancient, &removed}
+	if len(delivered) != len(want) {
+		t.Fatalf("delivered log count mismatch: have %d, want %d", len(delivered), len(want))
+	}
+	for i := range want {
+		if delivered[i] != want[i] {
+			t.Errorf("log %d mismatch: have %+v, want %+v", i, delivered[i], want[i])
+		}
+	}
+}
+
+// Tests that live logs arriving during the historical phase are only buffered up
+// to a limit.
+func TestHybridLogsQueueLimit(t *testing.T) {
+	h := &hybridLogs{sent: make(map[logKey]struct{})}
+
+	if err := h.queue(make([]*types.Log, historicalQueueLimit)); err != nil {
+		t.Fatalf("failed to queue logs up to the limit: %v", err)
+	}
+	if err := h.queue(make([]*types.Log, 1)); err != errHistoricalQueueFull {
+		t.Fatalf("error mismatch: have %v, want %v", err, errHistoricalQueueFull)
+	}
+}
+
+// Tests that log subscriptions starting in the past are subject to the block
+// range and result limits, and that failing to stream the historical logs ends
+// the subscription with an error.
+func TestHybridLogsLimits(t *testing.T) {
+	var (
+		db, _   = gdadb.NewMemDatabase()
+		backend = &testBackend{new(event.Feed), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.Address{0x01}
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 100, func(i int, gen *core.BlockGen) {
+		gen.AddUncheckedReceipt(makeReceipt(addr))
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		core.WriteHeadBlockHash(db, block.Hash())
+		core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	subscribe := func(config Config, from uint64) (chan types.Log, *rpc.ClientSubscription, error) {
+		server := rpc.NewServer()
+		if err := server.RegisterName("gda", NewPublicFilterAPI(backend, false, config)); err != nil {
+			t.Fatalf("failed to register filter API: %v", err)
+		}
+		logs := make(chan types.Log, 128)
+		crit := map[string]interface{}{"fromBlock": hexutil.Uint64(from), "address": []common.Address{addr}}
+		sub, err := rpc.DialInProc(server).Subscribe(context.Background(), "gda", logs, "logs", crit)
+		return logs, sub, err
+	}
+	// Historical ranges beyond the block range limit must be rejected
+	if _, _, err := subscribe(Config{RangeLimit: 30}, 1); err == nil {
+		t.Fatalf("oversized historical range accepted")
+	}
+	// Historical results beyond the result limit must fail the subscription
+	_, sub, err := subscribe(Config{RangeLimit: 30, ResultLimit: 7}, 71)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	select {
+	case err := <-sub.Err():
+		if err == nil || !strings.Contains(err.Error(), "limit of 7 results") {
+			t.Fatalf("subscription error mismatch: have %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("subscription not failed")
+	}
+	// Historical logs within the limits must all be delivered
+	logs, sub, err := subscribe(Config{RangeLimit: 30, ResultLimit: 30}, 71)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 30; i++ {
+		select {
+		case log := <-logs:
+			if log.Address != addr {
+				t.Fatalf("log %d address mismatch: have %x, want %x", i, log.Address, addr)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("historical log %d not delivered", i)
+		}
+	}
+}
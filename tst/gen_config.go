@@ -4,13 +4,16 @@ package gda
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/common/hexutil"
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
 	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/gda/filters"
 	"github.com/gdachain/go-gdachain/gda/gasprice"
+	"github.com/gdachain/go-gdachain/miner"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -20,39 +23,99 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		Checkpoints             string  `toml:",omitempty"`
+		LightServ               int     `toml:",omitempty"`
+		LightPeers              int     `toml:",omitempty"`
+		LightRPC                string  `toml:",omitempty"`
+		PeerRequestRate         float64 `toml:",omitempty"`
+		PeerRequestBurst        int     `toml:",omitempty"`
+		SkipBcVersionCheck      bool    `toml:"-"`
+		DatabaseHandles         int     `toml:"-"`
 		DatabaseCache           int
+		TrieJournal             string         `toml:",omitempty"`
+		TdCache                 int            `toml:",omitempty"`
+		ShutdownTimeout         time.Duration  `toml:",omitempty"`
 		gdaerbase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		UnclePolicy             miner.UnclePolicy
+		MinerRecommit           time.Duration `toml:",omitempty"`
+		MinerEmptyWait          time.Duration `toml:",omitempty"`
+		MinerDeadline           time.Duration `toml:",omitempty"`
+		DevMode                 bool          `toml:",omitempty"`
+		DevPeriod               uint64        `toml:",omitempty"`
+		AlertWebhook            string        `toml:",omitempty"`
+		AlertReorgDepth         uint64        `toml:",omitempty"`
 		gdaash                  ethash.Config
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
+		Filters                 filters.Config
+		BloomFilterThreads      int           `toml:",omitempty"`
+		BloomRetrievalBatch     int           `toml:",omitempty"`
+		BloomRetrievalWait      time.Duration `toml:",omitempty"`
 		EnablePreimageRecording bool
+		VMProfileBlocks         int  `toml:",omitempty"`
+		RecordRevertReasons     bool `toml:",omitempty"`
+		DebugImportLag          uint64
+		TxLookupScan            uint64
+		AddressIndex            bool   `toml:",omitempty"`
+		TokenIndex              bool   `toml:",omitempty"`
+		EventSocket             string `toml:",omitempty"`
 		DocRoot                 string `toml:"-"`
+		RPCTxFeeCap             float64
+		RPCGasCap               uint64
+		RPCEVMTimeout           time.Duration
+		RPCEVMMemory            uint64
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.Checkpoints = c.Checkpoints
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.LightRPC = c.LightRPC
+	enc.PeerRequestRate = c.PeerRequestRate
+	enc.PeerRequestBurst = c.PeerRequestBurst
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.TrieJournal = c.TrieJournal
+	enc.TdCache = c.TdCache
+	enc.ShutdownTimeout = c.ShutdownTimeout
 	enc.gdaerbase = c.gdaerbase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.UnclePolicy = c.UnclePolicy
+	enc.MinerRecommit = c.MinerRecommit
+	enc.MinerEmptyWait = c.MinerEmptyWait
+	enc.MinerDeadline = c.MinerDeadline
+	enc.DevMode = c.DevMode
+	enc.DevPeriod = c.DevPeriod
+	enc.AlertWebhook = c.AlertWebhook
+	enc.AlertReorgDepth = c.AlertReorgDepth
 	enc.gdaash = c.gdaash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
+	enc.Filters = c.Filters
+	enc.BloomFilterThreads = c.BloomFilterThreads
+	enc.BloomRetrievalBatch = c.BloomRetrievalBatch
+	enc.BloomRetrievalWait = c.BloomRetrievalWait
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.VMProfileBlocks = c.VMProfileBlocks
+	enc.RecordRevertReasons = c.RecordRevertReasons
+	enc.DebugImportLag = c.DebugImportLag
+	enc.TxLookupScan = c.TxLookupScan
+	enc.AddressIndex = c.AddressIndex
+	enc.TokenIndex = c.TokenIndex
+	enc.EventSocket = c.EventSocket
 	enc.DocRoot = c.DocRoot
+	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.RPCGasCap = c.RPCGasCap
+	enc.RPCEVMTimeout = c.RPCEVMTimeout
+	enc.RPCEVMMemory = c.RPCEVMMemory
 	return &enc, nil
 }
 
@@ -61,20 +124,50 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		Checkpoints             *string  `toml:",omitempty"`
+		LightServ               *int     `toml:",omitempty"`
+		LightPeers              *int     `toml:",omitempty"`
+		LightRPC                *string  `toml:",omitempty"`
+		PeerRequestRate         *float64 `toml:",omitempty"`
+		PeerRequestBurst        *int     `toml:",omitempty"`
+		SkipBcVersionCheck      *bool    `toml:"-"`
+		DatabaseHandles         *int     `toml:"-"`
 		DatabaseCache           *int
+		TrieJournal             *string         `toml:",omitempty"`
+		TdCache                 *int            `toml:",omitempty"`
+		ShutdownTimeout         *time.Duration  `toml:",omitempty"`
 		gdaerbase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		UnclePolicy             *miner.UnclePolicy
+		MinerRecommit           *time.Duration `toml:",omitempty"`
+		MinerEmptyWait          *time.Duration `toml:",omitempty"`
+		MinerDeadline           *time.Duration `toml:",omitempty"`
+		DevMode                 *bool          `toml:",omitempty"`
+		DevPeriod               *uint64        `toml:",omitempty"`
+		AlertWebhook            *string        `toml:",omitempty"`
+		AlertReorgDepth         *uint64        `toml:",omitempty"`
 		gdaash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
+		Filters                 *filters.Config
+		BloomFilterThreads      *int           `toml:",omitempty"`
+		BloomRetrievalBatch     *int           `toml:",omitempty"`
+		BloomRetrievalWait      *time.Duration `toml:",omitempty"`
 		EnablePreimageRecording *bool
+		VMProfileBlocks         *int  `toml:",omitempty"`
+		RecordRevertReasons     *bool `toml:",omitempty"`
+		DebugImportLag          *uint64
+		TxLookupScan            *uint64
+		AddressIndex            *bool   `toml:",omitempty"`
+		TokenIndex              *bool   `toml:",omitempty"`
+		EventSocket             *string `toml:",omitempty"`
 		DocRoot                 *string `toml:"-"`
+		RPCTxFeeCap             *float64
+		RPCGasCap               *uint64
+		RPCEVMTimeout           *time.Duration
+		RPCEVMMemory            *uint64
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -89,12 +182,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.Checkpoints != nil {
+		c.Checkpoints = *dec.Checkpoints
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
 	if dec.LightPeers != nil {
 		c.LightPeers = *dec.LightPeers
 	}
+	if dec.LightRPC != nil {
+		c.LightRPC = *dec.LightRPC
+	}
+	if dec.PeerRequestRate != nil {
+		c.PeerRequestRate = *dec.PeerRequestRate
+	}
+	if dec.PeerRequestBurst != nil {
+		c.PeerRequestBurst = *dec.PeerRequestBurst
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -104,6 +209,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.TrieJournal != nil {
+		c.TrieJournal = *dec.TrieJournal
+	}
+	if dec.TdCache != nil {
+		c.TdCache = *dec.TdCache
+	}
+	if dec.ShutdownTimeout != nil {
+		c.ShutdownTimeout = *dec.ShutdownTimeout
+	}
 	if dec.gdaerbase != nil {
 		c.gdaerbase = *dec.gdaerbase
 	}
@@ -116,6 +230,30 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.UnclePolicy != nil {
+		c.UnclePolicy = *dec.UnclePolicy
+	}
+	if dec.MinerRecommit != nil {
+		c.MinerRecommit = *dec.MinerRecommit
+	}
+	if dec.MinerEmptyWait != nil {
+		c.MinerEmptyWait = *dec.MinerEmptyWait
+	}
+	if dec.MinerDeadline != nil {
+		c.MinerDeadline = *dec.MinerDeadline
+	}
+	if dec.DevMode != nil {
+		c.DevMode = *dec.DevMode
+	}
+	if dec.DevPeriod != nil {
+		c.DevPeriod = *dec.DevPeriod
+	}
+	if dec.AlertWebhook != nil {
+		c.AlertWebhook = *dec.AlertWebhook
+	}
+	if dec.AlertReorgDepth != nil {
+		c.AlertReorgDepth = *dec.AlertReorgDepth
+	}
 	if dec.gdaash != nil {
 		c.gdaash = *dec.gdaash
 	}
@@ -125,11 +263,56 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
+	if dec.Filters != nil {
+		c.Filters = *dec.Filters
+	}
+	if dec.BloomFilterThreads != nil {
+		c.BloomFilterThreads = *dec.BloomFilterThreads
+	}
+	if dec.BloomRetrievalBatch != nil {
+		c.BloomRetrievalBatch = *dec.BloomRetrievalBatch
+	}
+	if dec.BloomRetrievalWait != nil {
+		c.BloomRetrievalWait = *dec.BloomRetrievalWait
+	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.VMProfileBlocks != nil {
+		c.VMProfileBlocks = *dec.VMProfileBlocks
+	}
+	if dec.RecordRevertReasons != nil {
+		c.RecordRevertReasons = *dec.RecordRevertReasons
+	}
+	if dec.DebugImportLag != nil {
+		c.DebugImportLag = *dec.DebugImportLag
+	}
+	if dec.TxLookupScan != nil {
+		c.TxLookupScan = *dec.TxLookupScan
+	}
+	if dec.AddressIndex != nil {
+		c.AddressIndex = *dec.AddressIndex
+	}
+	if dec.TokenIndex != nil {
+		c.TokenIndex = *dec.TokenIndex
+	}
+	if dec.EventSocket != nil {
+		c.EventSocket = *dec.EventSocket
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
+	if dec.RPCTxFeeCap != nil {
+		c.RPCTxFeeCap = *dec.RPCTxFeeCap
+	}
+	if dec.RPCGasCap != nil {
+		c.RPCGasCap = *dec.RPCGasCap
+	}
+	if dec.RPCEVMTimeout != nil {
+		c.RPCEVMTimeout = *dec.RPCEVMTimeout
+	}
+	if dec.RPCEVMMemory != nil {
+		c.RPCEVMMemory = *dec.RPCEVMMemory
+	}
 	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"sync"
@@ -30,6 +31,7 @@ import (
 	"github.com/gdachain/go-gdachain/consensus"
 	"github.com/gdachain/go-gdachain/consensus/misc"
 	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/forkid"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/gda/downloader"
 	"github.com/gdachain/go-gdachain/gda/fetcher"
@@ -49,8 +51,34 @@ const (
 	// txChanSize is the size of channel listening to TxPreEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// minedChanSize is the size of channel listening to NewMinedBlockEvent.
+	minedChanSize = 16
+
+	maxHeaderRlpSize   = 4 * 1024 // Maximum accepted size of an RLP encoded block header
+	maxAnnouncesPerMsg = 256      // Maximum number of block hashes accepted in a single announcement
+	maxTxsPerMsg       = 4096     // Maximum number of transactions accepted in a single propagation
 )
 
+// msgLimit is the maximum number of list items and payload bytes accepted in a
+// single inbound message of a given type.
+type msgLimit struct {
+	items int
+	size  uint32
+}
+
+// msgLimits are the decoding limits of the list messages that remote peers may
+// send us. Responses are capped at what we ever request, so that a malicious peer
+// cannot make us allocate arbitrarily many objects during decoding.
+var msgLimits = map[uint64]msgLimit{
+	BlockHeadersMsg:   {items: downloader.MaxHeaderFetch, size: uint32(downloader.MaxHeaderFetch * maxHeaderRlpSize)},
+	BlockBodiesMsg:    {items: downloader.MaxBlockFetch, size: ProtocolMaxMsgSize},
+	NodeDataMsg:       {items: downloader.MaxStateFetch, size: ProtocolMaxMsgSize},
+	ReceiptsMsg:       {items: downloader.MaxReceiptFetch, size: ProtocolMaxMsgSize},
+	NewBlockHashesMsg: {items: maxAnnouncesPerMsg, size: maxAnnouncesPerMsg * 64},
+	TxMsg:             {items: maxTxsPerMsg, size: ProtocolMaxMsgSize},
+}
+
 var (
 	daoChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the DAO handshake challenge
 )
@@ -63,15 +91,55 @@ func errResp(code errCode, format string, v ...interface{}) error {
 	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
 }
 
+// decodeMsg decodes the list payload of an inbound message into val. If limits
+// are defined for the message type, the payload size and the number of items in
+// the list are checked before any of them get decoded.
+func decodeMsg(msg p2p.Msg, val interface{}) error {
+	limit, ok := msgLimits[msg.Code]
+	if !ok {
+		if err := msg.Decode(val); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return nil
+	}
+	if msg.Size > limit.size {
+		rejectedMsgMeter.Mark(1)
+		return errResp(ErrMsgTooLarge, "msg %v: %v > %v", msg, msg.Size, limit.size)
+	}
+	payload := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, payload); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	content, _, err := rlp.SplitList(payload)
+	if err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	items, err := rlp.CountValues(content)
+	if err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if items > limit.items {
+		rejectedMsgMeter.Mark(1)
+		return errResp(ErrMsgTooManyItems, "msg %v: %d > %d", msg, items, limit.items)
+	}
+	if err := rlp.DecodeBytes(payload, val); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	return nil
+}
+
 type ProtocolManager struct {
 	networkId uint64
 
 	fastSync  uint32 // Flag whgdaer fast sync is enabled (gets disabled if we already have blocks)
 	acceptTxs uint32 // Flag whgdaer we're considered synchronised (enables transaction processing)
 
+	headerSync bool // Flag whgdaer only headers are synchronised (no block or transaction processing)
+
 	txpool      txPool
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
+	forkFilter  forkid.Filter // Fork ID filter rejecting peers on incompatible chains
 	maxPeers    int
 
 	downloader *downloader.Downloader
@@ -80,10 +148,14 @@ type ProtocolManager struct {
 
 	SubProtocols []p2p.Protocol
 
-	eventMux      *event.TypeMux
+	requestRate  float64 // Data requests served per second to a single peer (0 = unlimited)
+	requestBurst int     // Data requests served to a single peer in a burst
+
+	mined         minedBlockSource
 	txCh          chan core.TxPreEvent
 	txSub         event.Subscription
-	minedBlockSub *event.TypeMuxSubscription
+	minedBlockCh  chan core.NewMinedBlockEvent
+	minedBlockSub event.Subscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -98,14 +170,15 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new gdaereum sub protocol manager. The gdachain sub protocol manages peers capable
 // with the gdaereum network.
-func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkId uint64, mux *event.TypeMux, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb gdadb.Database) (*ProtocolManager, error) {
+func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, networkId uint64, mined minedBlockSource, txpool txPool, engine consensus.Engine, blockchain *core.BlockChain, chaindb gdadb.Database) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:   networkId,
-		eventMux:    mux,
+		mined:       mined,
 		txpool:      txpool,
 		blockchain:  blockchain,
 		chainconfig: config,
+		forkFilter:  forkid.NewFilter(blockchain),
 		peers:       newPeerSet(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
@@ -120,6 +193,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	if mode == downloader.FastSync {
 		manager.fastSync = uint32(1)
 	}
+	manager.headerSync = mode == downloader.HeaderSync
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
@@ -159,7 +233,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return nil, errIncompatibleConfig
 	}
 	// Construct the different synchronisation mechanisms
-	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader = downloader.New(mode, chaindb, blockchain, nil, manager.removePeer)
 
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -168,8 +242,8 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return blockchain.CurrentBlock().NumberU64()
 	}
 	inserter := func(blocks types.Blocks) (int, error) {
-		// If fast sync is running, deny importing weird blocks
-		if atomic.LoadUint32(&manager.fastSync) == 1 {
+		// If fast sync is running or only headers are tracked, deny importing weird blocks
+		if atomic.LoadUint32(&manager.fastSync) == 1 || manager.headerSync {
 			log.Warn("Discarded bad propagated block", "number", blocks[0].Number(), "hash", blocks[0].Hash())
 			return 0, nil
 		}
@@ -209,7 +283,8 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	go pm.txBroadcastLoop()
 
 	// broadcast mined blocks
-	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
+	pm.minedBlockCh = make(chan core.NewMinedBlockEvent, minedChanSize)
+	pm.minedBlockSub = pm.mined.SubscribeNewMinedBlockEvent(pm.minedBlockCh)
 	go pm.minedBroadcastLoop()
 
 	// start sync handlers
@@ -243,7 +318,11 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, p, newMeteredMsgWriter(rw))
+	if pm.requestRate > 0 {
+		peer.limiter = newRequestLimiter(pm.requestRate, pm.requestBurst)
+	}
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of an gda peer. When
@@ -263,13 +342,17 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash(), forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("gdachain handshake failed", "err", err)
 		return err
 	}
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
+	// Start writing outbound messages, prioritising block propagation
+	go p.queue.loop()
+	defer p.queue.close()
+
 	// Register the peer locally
 	if err := pm.peers.Register(p); err != nil {
 		p.Log().Error("gdachain peer registration failed", "err", err)
@@ -313,6 +396,38 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 }
 
+// isDataRequest reports whether a message is a request for chain data, whose
+// serving is rate limited.
+func isDataRequest(version int, code uint64) bool {
+	switch {
+	case code == GetBlockHeadersMsg:
+		return true
+	case version >= gda63 && (code == GetNodeDataMsg || code == GetReceiptsMsg):
+		return true
+	}
+	return false
+}
+
+// throttle answers a data request over the peer's allowance with an empty reply,
+// disconnecting the peer if it keeps requesting regardless.
+func (pm *ProtocolManager) throttle(p *peer, msg p2p.Msg) error {
+	reqThrottledMeter.Mark(1)
+	if p.limiter.abusive() {
+		return errResp(ErrRequestRateExceeded, "%d consecutive requests throttled", p.limiter.throttled)
+	}
+	p.Log().Trace("Throttling data request", "code", msg.Code)
+
+	switch msg.Code {
+	case GetBlockHeadersMsg:
+		return p.SendBlockHeaders(nil)
+	case GetNodeDataMsg:
+		return p.SendNodeData(nil)
+	case GetReceiptsMsg:
+		return p.SendReceiptsRLP(nil)
+	}
+	return nil
+}
+
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func (pm *ProtocolManager) handleMsg(p *peer) error {
@@ -326,6 +441,16 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	}
 	defer msg.Discard()
 
+	// Decompress the bulky responses of peers that negotiated it
+	if isCompressed(p.version, msg.Code) {
+		if msg, err = decompressMsg(msg); err != nil {
+			return err
+		}
+	}
+	// Throttle the data requests of peers exceeding their allowance
+	if p.limiter != nil && isDataRequest(p.version, msg.Code) && !p.limiter.allow(time.Now()) {
+		return pm.throttle(p, msg)
+	}
 	// Handle the message depending on its contents
 	switch {
 	case msg.Code == StatusMsg:
@@ -414,8 +539,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Code == BlockHeadersMsg:
 		// A batch of headers arrived to one of our previous requests
 		var headers []*types.Header
-		if err := msg.Decode(&headers); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		if err := decodeMsg(msg, &headers); err != nil {
+			return err
 		}
 		// If no headers were received, but we're expending a DAO fork check, maybe it's that
 		if len(headers) == 0 && p.forkDrop != nil {
@@ -494,8 +619,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Code == BlockBodiesMsg:
 		// A batch of block bodies arrived to one of our previous requests
 		var request blockBodiesData
-		if err := msg.Decode(&request); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		if err := decodeMsg(msg, &request); err != nil {
+			return err
 		}
 		// Deliver them all to the downloader for queuing
 		trasactions := make([][]*types.Transaction, len(request))
@@ -547,8 +672,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case p.version >= gda63 && msg.Code == NodeDataMsg:
 		// A batch of node state data arrived to one of our previous requests
 		var data [][]byte
-		if err := msg.Decode(&data); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		if err := decodeMsg(msg, &data); err != nil {
+			return err
 		}
 		// Deliver all to the downloader
 		if err := pm.downloader.DeliverNodeData(p.id, data); err != nil {
@@ -594,8 +719,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case p.version >= gda63 && msg.Code == ReceiptsMsg:
 		// A batch of receipts arrived to one of our previous requests
 		var receipts [][]*types.Receipt
-		if err := msg.Decode(&receipts); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		if err := decodeMsg(msg, &receipts); err != nil {
+			return err
 		}
 		// Deliver all to the downloader
 		if err := pm.downloader.DeliverReceipts(p.id, receipts); err != nil {
@@ -604,8 +729,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 	case msg.Code == NewBlockHashesMsg:
 		var announces newBlockHashesData
-		if err := msg.Decode(&announces); err != nil {
-			return errResp(ErrDecode, "%v: %v", msg, err)
+		if err := decodeMsg(msg, &announces); err != nil {
+			return err
 		}
 		// Mark the hashes as present at the remote node
 		for _, block := range announces {
@@ -648,8 +773,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
 			// a singe block (as the true TD is below the propagated block), however this
 			// scenario should easily be covered by the fetcher.
-			currentBlock := pm.blockchain.CurrentBlock()
-			if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())) > 0 {
+			if trueTD.Cmp(pm.localTd()) > 0 {
 				go pm.synchronise(p)
 			}
 		}
@@ -661,8 +785,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		// Transactions can be processed, parse all of them and deliver to the pool
 		var txs []*types.Transaction
-		if err := msg.Decode(&txs); err != nil {
-			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		if err := decodeMsg(msg, &txs); err != nil {
+			return err
 		}
 		for i, tx := range txs {
 			// Validate and mark the remote transaction
@@ -671,7 +795,25 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 			p.MarkTransaction(tx.Hash())
 		}
-		pm.txpool.AddRemotes(txs)
+		// Ignore the transactions of peers downgraded for flooding us with dust
+		now := time.Now()
+		if p.underpriced.downgraded(now) {
+			propTxnIgnoredMeter.Mark(int64(len(txs)))
+			break
+		}
+		var accepted, underpriced int
+		for _, err := range pm.txpool.AddRemotes(txs) {
+			switch err {
+			case nil:
+				accepted++
+			case core.ErrUnderpriced:
+				underpriced++
+			}
+		}
+		propTxnUnderpricedMeter.Mark(int64(underpriced))
+		if p.underpriced.record(accepted, underpriced, now) {
+			p.Log().Debug("Downgrading peer sending underpriced transactions", "rejected", p.underpriced.rejected(), "cooldown", underpricedCooldown)
+		}
 
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -726,12 +868,15 @@ func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction)
 
 // Mined broadcast loop
 func (self *ProtocolManager) minedBroadcastLoop() {
-	// automatically stops if unsubscribe
-	for obj := range self.minedBlockSub.Chan() {
-		switch ev := obj.Data.(type) {
-		case core.NewMinedBlockEvent:
+	for {
+		select {
+		case ev := <-self.minedBlockCh:
 			self.BroadcastBlock(ev.Block, true)  // First propagate block to peers
 			self.BroadcastBlock(ev.Block, false) // Only then announce to the rest
+
+		// Err() channel will be closed when unsubscribing.
+		case <-self.minedBlockSub.Err():
+			return
 		}
 	}
 }
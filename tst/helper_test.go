@@ -20,8 +20,11 @@
 package gda
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
+	"fmt"
+	"io/ioutil"
 	"math/big"
 	"sort"
 	"sync"
@@ -30,6 +33,7 @@ import (
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/consensus/ethash"
 	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/forkid"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/core/vm"
 	"github.com/gdachain/go-gdachain/crypto"
@@ -39,6 +43,7 @@ import (
 	"github.com/gdachain/go-gdachain/p2p"
 	"github.com/gdachain/go-gdachain/p2p/discover"
 	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rlp"
 )
 
 var (
@@ -51,7 +56,7 @@ var (
 // channels for different events.
 func newTestProtocolManager(mode downloader.SyncMode, blocks int, generator func(int, *core.BlockGen), newtx chan<- []*types.Transaction) (*ProtocolManager, *gdadb.MemDatabase, error) {
 	var (
-		evmux  = new(event.TypeMux)
+		mined  = new(testMiner)
 		engine = ethash.NewFaker()
 		db, _  = gdadb.NewMemDatabase()
 		gspec  = &core.Genesis{
@@ -66,7 +71,7 @@ func newTestProtocolManager(mode downloader.SyncMode, blocks int, generator func
 		panic(err)
 	}
 
-	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, evmux, &testTxPool{added: newtx}, engine, blockchain, db)
+	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, mined, &testTxPool{added: newtx}, engine, blockchain, db)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -86,6 +91,16 @@ func newTestProtocolManagerMust(t *testing.T, mode downloader.SyncMode, blocks i
 	return pm, db
 }
 
+// testMiner is a fake, helper mined block source for testing purposes
+type testMiner struct {
+	feed event.Feed
+}
+
+// SubscribeNewMinedBlockEvent subscribes to the blocks "mined" by the test.
+func (m *testMiner) SubscribeNewMinedBlockEvent(ch chan<- core.NewMinedBlockEvent) event.Subscription {
+	return m.feed.Subscribe(ch)
+}
+
 // testTxPool is a fake, helper transaction pool for testing purposes
 type testTxPool struct {
 	txFeed event.Feed
@@ -171,21 +186,31 @@ func newTestPeer(name string, version int, pm *ProtocolManager, shake bool) (*te
 			head    = pm.blockchain.CurrentHeader()
 			td      = pm.blockchain.GetTd(head.Hash(), head.Number.Uint64())
 		)
-		tp.handshake(nil, td, head.Hash(), genesis.Hash())
+		tp.handshake(nil, td, head.Hash(), genesis.Hash(), forkid.NewID(pm.blockchain))
 	}
 	return tp, errc
 }
 
 // handshake simulates a trivial handshake that expects the same state from the
 // remote side as we are simulating locally.
-func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesis common.Hash) {
-	msg := &statusData{
+func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID) {
+	var msg interface{} = &statusData{
 		ProtocolVersion: uint32(p.version),
 		NetworkId:       DefaultConfig.NetworkId,
 		TD:              td,
 		CurrentBlock:    head,
 		GenesisBlock:    genesis,
 	}
+	if p.version >= gda64 {
+		msg = &statusData64{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       DefaultConfig.NetworkId,
+			TD:              td,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+			ForkID:          forkID,
+		}
+	}
 	if err := p2p.ExpectMsg(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
@@ -194,6 +219,40 @@ func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesi
 	}
 }
 
+// readMsg reads the next message sent to the remote side, decompressing it if
+// the negotiated protocol version requires so.
+func (p *testPeer) readMsg() (p2p.Msg, error) {
+	msg, err := p.app.ReadMsg()
+	if err != nil || !isCompressed(p.version, msg.Code) {
+		return msg, err
+	}
+	return decompressMsg(msg)
+}
+
+// expectMsg reads the next message sent to the remote side and checks that its
+// code and (decompressed) content match the expected ones.
+func (p *testPeer) expectMsg(code uint64, content interface{}) error {
+	msg, err := p.readMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != code {
+		return fmt.Errorf("message code mismatch: got %d, expected %d", msg.Code, code)
+	}
+	want, err := rlp.EncodeToBytes(content)
+	if err != nil {
+		return err
+	}
+	have, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(have, want) {
+		return fmt.Errorf("message payload mismatch:\ngot:  %x\nwant: %x", have, want)
+	}
+	return nil
+}
+
 // close terminates the local side of the peer, notifying the remote protocol
 // manager of termination.
 func (p *testPeer) close() {
@@ -0,0 +1,398 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/accounts"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+const (
+	// maxLocalTxs is the maximum number of local transactions tracked at once.
+	maxLocalTxs = 4096
+
+	// localTxRetention is the number of blocks a finalized (mined, replaced or
+	// dropped) local transaction is still reported on before being forgotten.
+	localTxRetention = 1024
+)
+
+// Statuses of tracked local transactions.
+const (
+	LocalTxPending  = "pending"  // Waiting in the pool with a competitive gas price
+	LocalTxStuck    = "stuck"    // Waiting in the pool below the gas price floor
+	LocalTxMined    = "mined"    // Included in the canonical chain
+	LocalTxReplaced = "replaced" // Nonce consumed by a transaction not issued by the monitor
+	LocalTxDropped  = "dropped"  // Neither pending nor mined
+)
+
+var errGasPriceCap = errors.New("gas price cap reached")
+
+// LocalTxPolicy configures how local transactions stuck below the gas price floor
+// are handled. The zero policy only tracks their status.
+type LocalTxPolicy struct {
+	Rebroadcast bool         `json:"rebroadcast"`           // Re-announce stuck transactions to all peers
+	Bump        bool         `json:"bump"`                  // Re-sign stuck transactions with a higher gas price
+	BumpPercent uint64       `json:"bumpPercent"`           // Gas price increase per bump (0 = pool's replacement bump)
+	MaxGasPrice *hexutil.Big `json:"maxGasPrice,omitempty"` // Gas price cap of bumped transactions (nil = uncapped)
+	StuckBlocks uint64       `json:"stuckBlocks"`           // Blocks spent below the floor before acting (0 = act immediately)
+}
+
+// LocalTxStatus reports the state of a tracked local transaction.
+type LocalTxStatus struct {
+	Hash         common.Hash     `json:"hash"`     // Hash of the latest version of the transaction
+	Original     common.Hash     `json:"original"` // Hash of the transaction as originally submitted
+	Status       string          `json:"status"`
+	GasPrice     *hexutil.Big    `json:"gasPrice"`
+	Floor        *hexutil.Big    `json:"floor,omitempty"` // Gas price floor at the last check
+	Bumps        hexutil.Uint    `json:"bumps"`
+	Rebroadcasts hexutil.Uint    `json:"rebroadcasts"`
+	BlockNumber  *hexutil.Uint64 `json:"blockNumber,omitempty"` // Block the transaction was mined in
+	Error        string          `json:"error,omitempty"`       // Last failure handling the transaction
+}
+
+// localTx is a locally submitted transaction tracked by the monitor.
+type localTx struct {
+	tx       *types.Transaction // Latest version of the transaction
+	from     common.Address
+	versions []common.Hash // Hashes of all versions issued, oldest first
+
+	status       string
+	floor        *big.Int
+	stuckSince   uint64 // Block number the current version fell below the floor (0 = not stuck)
+	finalized    uint64 // Block number the transaction was finalized at (0 = still pending)
+	block        uint64
+	bumps        uint
+	rebroadcasts uint
+	err          string
+}
+
+// localTxMonitor tracks the transactions submitted through the local RPC APIs,
+// and on every new head checks whgdaer they are stuck below the current gas price
+// floor, rebroadcasting or price-bumping them according to the configured policy.
+type localTxMonitor struct {
+	config    *params.ChainConfig
+	chain     *core.BlockChain
+	chainDb   gdadb.Database
+	pool      *core.TxPool
+	manager   *accounts.Manager
+	priceBump uint64
+
+	floor     func(ctx context.Context) (*big.Int, error) // Current gas price floor
+	broadcast func(tx *types.Transaction)                 // Announces a transaction to all peers
+
+	policy  LocalTxPolicy
+	txs     map[common.Hash]*localTx    // Tracked transactions keyed by original hash
+	aliases map[common.Hash]common.Hash // Hashes of all versions, mapped to the original
+	lock    sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newLocalTxMonitor creates a monitor for the local transactions of the given
+// node and starts following its chain head.
+func newLocalTxMonitor(gda *gdachain) *localTxMonitor {
+	m := &localTxMonitor{
+		config:    gda.chainConfig,
+		chain:     gda.blockchain,
+		chainDb:   gda.chainDb,
+		pool:      gda.txPool,
+		manager:   gda.accountManager,
+		priceBump: gda.config.TxPool.PriceBump,
+		floor:     gda.ApiBackend.SuggestPrice,
+		broadcast: func(tx *types.Transaction) { gda.protocolManager.BroadcastTx(tx.Hash(), tx) },
+		txs:       make(map[common.Hash]*localTx),
+		aliases:   make(map[common.Hash]common.Hash),
+		quit:      make(chan struct{}),
+	}
+	headCh := make(chan core.ChainHeadEvent, 16)
+	headSub := m.chain.SubscribeChainHeadEvent(headCh)
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer headSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-headCh:
+				m.check(ev.Block.NumberU64())
+			case <-headSub.Err():
+				return
+			case <-m.quit:
+				return
+			}
+		}
+	}()
+	return m
+}
+
+// stop terminates the event loop of the monitor.
+func (m *localTxMonitor) stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// setPolicy replaces the handling policy of stuck transactions.
+func (m *localTxMonitor) setPolicy(policy LocalTxPolicy) error {
+	if policy.BumpPercent != 0 && policy.BumpPercent < m.priceBump {
+		return fmt.Errorf("bump of %d%% below the pool's replacement bump of %d%%", policy.BumpPercent, m.priceBump)
+	}
+	m.lock.Lock()
+	m.policy = policy
+	m.lock.Unlock()
+
+	log.Info("Updated local transaction policy", "rebroadcast", policy.Rebroadcast, "bump", policy.Bump, "percent", policy.BumpPercent, "stuck", policy.StuckBlocks)
+	return nil
+}
+
+// getPolicy returns the current handling policy of stuck transactions.
+func (m *localTxMonitor) getPolicy() LocalTxPolicy {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.policy
+}
+
+// track starts monitoring a transaction just accepted into the pool as local.
+func (m *localTxMonitor) track(tx *types.Transaction) {
+	from, err := types.Sender(types.NewEIP155Signer(m.config.ChainId), tx)
+	if err != nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.aliases[tx.Hash()]; ok {
+		return
+	}
+	if len(m.txs) >= maxLocalTxs {
+		log.Warn("Too many local transactions tracked, ignoring", "hash", tx.Hash())
+		return
+	}
+	m.txs[tx.Hash()] = &localTx{
+		tx:       tx,
+		from:     from,
+		versions: []common.Hash{tx.Hash()},
+		status:   LocalTxPending,
+	}
+	m.aliases[tx.Hash()] = tx.Hash()
+}
+
+// status reports the state of the tracked transaction with the given hash, which
+// may be that of any of its versions.
+func (m *localTxMonitor) status(hash common.Hash) (*LocalTxStatus, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	original, ok := m.aliases[hash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %x not tracked", hash)
+	}
+	ltx := m.txs[original]
+
+	status := &LocalTxStatus{
+		Hash:         ltx.tx.Hash(),
+		Original:     original,
+		Status:       ltx.status,
+		GasPrice:     (*hexutil.Big)(ltx.tx.GasPrice()),
+		Bumps:        hexutil.Uint(ltx.bumps),
+		Rebroadcasts: hexutil.Uint(ltx.rebroadcasts),
+		Error:        ltx.err,
+	}
+	if ltx.floor != nil {
+		status.Floor = (*hexutil.Big)(ltx.floor)
+	}
+	if ltx.status == LocalTxMined {
+		status.BlockNumber = (*hexutil.Uint64)(&ltx.block)
+	}
+	return status, nil
+}
+
+// check updates the status of all tracked transactions at a new chain head, and
+// handles the ones stuck for long enough according to the policy.
+func (m *localTxMonitor) check(head uint64) {
+	floor, err := m.floor(context.Background())
+	if err != nil {
+		log.Debug("Failed to retrieve gas price floor", "err", err)
+		floor = nil
+	}
+	state, err := m.chain.State()
+	if err != nil {
+		log.Debug("Failed to retrieve head state", "err", err)
+		return
+	}
+	m.lock.Lock()
+	policy := m.policy
+
+	var stuck []*localTx
+	for original, ltx := range m.txs {
+		// Forget transactions finalized long enough ago
+		if ltx.finalized != 0 {
+			if ltx.finalized+localTxRetention <= head {
+				for _, hash := range ltx.versions {
+					delete(m.aliases, hash)
+				}
+				delete(m.txs, original)
+			}
+			continue
+		}
+		ltx.floor = floor
+
+		// Check whgdaer any version of the transaction was mined
+		mined := false
+		for _, hash := range ltx.versions {
+			if blockHash, number, _ := core.GetTxLookupEntry(m.chainDb, hash); blockHash != (common.Hash{}) {
+				ltx.status, ltx.block, ltx.finalized = LocalTxMined, number, head
+				mined = true
+				break
+			}
+		}
+		switch {
+		case mined:
+		case state.GetNonce(ltx.from) > ltx.tx.Nonce():
+			ltx.status, ltx.finalized = LocalTxReplaced, head
+		case m.pool.Get(ltx.tx.Hash()) == nil:
+			ltx.status, ltx.finalized = LocalTxDropped, head
+		case floor == nil || ltx.tx.GasPrice().Cmp(floor) >= 0:
+			ltx.status, ltx.stuckSince = LocalTxPending, 0
+		default:
+			ltx.status = LocalTxStuck
+			if ltx.stuckSince == 0 {
+				ltx.stuckSince = head
+			}
+			if (policy.Rebroadcast || policy.Bump) && head-ltx.stuckSince >= policy.StuckBlocks {
+				stuck = append(stuck, ltx)
+			}
+		}
+	}
+	m.lock.Unlock()
+
+	// Handle the stuck transactions without holding the lock, as signing may wait
+	// for user confirmation on hardware wallets
+	for _, ltx := range stuck {
+		m.handle(ltx, policy, floor, head)
+	}
+}
+
+// handle rebroadcasts or bumps a stuck transaction according to the policy. It
+// is only ever called from the monitor loop, which is the sole writer of the
+// tracked transactions besides track.
+func (m *localTxMonitor) handle(ltx *localTx, policy LocalTxPolicy, floor *big.Int, head uint64) {
+	m.lock.RLock()
+	tx := ltx.tx
+	m.lock.RUnlock()
+
+	if policy.Bump {
+		bumped, err := m.bump(tx, ltx.from, policy, floor, head)
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		if err != nil {
+			ltx.err = err.Error()
+			log.Debug("Failed to bump local transaction", "hash", tx.Hash(), "err", err)
+			return
+		}
+		ltx.tx = bumped
+		ltx.versions = append(ltx.versions, bumped.Hash())
+		ltx.bumps++
+		ltx.stuckSince, ltx.status, ltx.err = 0, LocalTxPending, ""
+		m.aliases[bumped.Hash()] = ltx.versions[0]
+
+		log.Info("Bumped stuck local transaction", "hash", tx.Hash(), "replacement", bumped.Hash(), "price", bumped.GasPrice())
+		return
+	}
+	m.broadcast(tx)
+
+	m.lock.Lock()
+	ltx.rebroadcasts++
+	ltx.stuckSince = head // Wait another stuck period before the next rebroadcast
+	m.lock.Unlock()
+
+	log.Debug("Rebroadcast stuck local transaction", "hash", tx.Hash())
+}
+
+// bump re-signs a transaction with an increased gas price and submits it to the
+// pool as a replacement.
+func (m *localTxMonitor) bump(tx *types.Transaction, from common.Address, policy LocalTxPolicy, floor *big.Int, head uint64) (*types.Transaction, error) {
+	percent := policy.BumpPercent
+	if percent == 0 {
+		percent = m.priceBump
+	}
+	price, err := bumpedPrice(tx.GasPrice(), floor, percent, (*big.Int)(policy.MaxGasPrice))
+	if err != nil {
+		return nil, err
+	}
+	var replacement *types.Transaction
+	if tx.To() == nil {
+		replacement = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), price, tx.Data())
+	} else {
+		replacement = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), price, tx.Data())
+	}
+	account := accounts.Account{Address: from}
+	wallet, err := m.manager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	var chainID *big.Int
+	if m.config.IsEIP155(new(big.Int).SetUint64(head)) {
+		chainID = m.config.ChainId
+	}
+	signed, err := wallet.SignTx(account, replacement, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.pool.AddLocal(signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// bumpedPrice calculates the gas price of a replacement transaction: at least the
+// given percentage above the old price, raised to the floor if that is higher,
+// and limited by the cap (if any).
+func bumpedPrice(old, floor *big.Int, percent uint64, limit *big.Int) (*big.Int, error) {
+	price := new(big.Int).Mul(old, new(big.Int).SetUint64(100+percent))
+	price.Div(price, big.NewInt(100))
+	if price.Cmp(old) <= 0 {
+		price.Add(old, common.Big1)
+	}
+	minimum := new(big.Int).Set(price)
+	if floor != nil && floor.Cmp(price) > 0 {
+		price.Set(floor)
+	}
+	if limit != nil && price.Cmp(limit) > 0 {
+		if minimum.Cmp(limit) > 0 {
+			return nil, errGasPriceCap
+		}
+		price.Set(limit)
+	}
+	return price, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"math/big"
+	"testing"
+)
+
+// Tests that replacement gas prices clear both the pool's price bump and the
+// floor, without exceeding the configured cap.
+func TestBumpedPrice(t *testing.T) {
+	tests := []struct {
+		old, floor, limit int64 // 0 floor/limit = unset
+		percent           uint64
+		price             int64 // 0 = cap reached
+	}{
+		{old: 100, percent: 10, price: 110},                            // Plain bump
+		{old: 100, floor: 150, percent: 10, price: 150},                // Floor above the bump
+		{old: 100, floor: 105, percent: 10, price: 110},                // Floor below the bump
+		{old: 100, floor: 150, limit: 130, percent: 10, price: 130},    // Cap between bump and floor
+		{old: 100, floor: 150, limit: 105, percent: 10, price: 0},      // Cap below the minimum bump
+		{old: 5, percent: 10, price: 6},                                // Rounding never stalls
+		{old: 1000, floor: 900, limit: 2000, percent: 25, price: 1250}, // Custom percentage
+	}
+	for i, tt := range tests {
+		var floor, limit *big.Int
+		if tt.floor != 0 {
+			floor = big.NewInt(tt.floor)
+		}
+		if tt.limit != 0 {
+			limit = big.NewInt(tt.limit)
+		}
+		price, err := bumpedPrice(big.NewInt(tt.old), floor, tt.percent, limit)
+		switch {
+		case tt.price == 0 && err != errGasPriceCap:
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, errGasPriceCap)
+		case tt.price != 0 && err != nil:
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		case tt.price != 0 && price.Int64() != tt.price:
+			t.Errorf("test %d: price mismatch: have %v, want %v", i, price, tt.price)
+		}
+	}
+}
@@ -54,6 +54,12 @@ var (
 	miscInTrafficMeter        = metrics.NewRegisteredMeter("gda/misc/in/traffic", nil)
 	miscOutPacketsMeter       = metrics.NewRegisteredMeter("gda/misc/out/packets", nil)
 	miscOutTrafficMeter       = metrics.NewRegisteredMeter("gda/misc/out/traffic", nil)
+	reqThrottledMeter         = metrics.NewRegisteredMeter("gda/req/throttled", nil)
+
+	propTxnUnderpricedMeter = metrics.NewRegisteredMeter("gda/prop/txns/underpriced", nil)
+	propTxnIgnoredMeter     = metrics.NewRegisteredMeter("gda/prop/txns/ignored", nil)
+
+	rejectedMsgMeter = metrics.NewRegisteredMeter("gda/misc/rejected", nil)
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/p2p"
+)
+
+// maxQueuedPriorityMsgs is the number of consensus-critical messages that may be
+// waiting to be written to a peer before further ones are dropped.
+const maxQueuedPriorityMsgs = 16
+
+var (
+	errQueueClosed = errors.New("message queue closed")
+	errQueueFull   = errors.New("priority message queue full")
+)
+
+// outboundMsg is a message waiting to be written to a remote peer.
+type outboundMsg struct {
+	code uint64
+	data interface{}
+	errc chan error // Channel to report the write result on (nil = fire and forget)
+}
+
+// msgQueue serialises the outbound messages of a peer through a single writer,
+// which always prefers consensus-critical messages (block propagations and
+// announcements) over everything else. This ensures that serving bulky sync data
+// to a leeching peer does not hold up the propagation of new blocks.
+type msgQueue struct {
+	rw       p2p.MsgReadWriter
+	priority chan *outboundMsg // Consensus-critical messages, written asynchronously
+	normal   chan *outboundMsg // All other messages, written synchronously
+
+	term chan struct{}
+	once sync.Once
+}
+
+// newMsgQueue creates an outbound message queue writing into rw. The queue does
+// not write anything until its loop is started.
+func newMsgQueue(rw p2p.MsgReadWriter) *msgQueue {
+	return &msgQueue{
+		rw:       rw,
+		priority: make(chan *outboundMsg, maxQueuedPriorityMsgs),
+		normal:   make(chan *outboundMsg),
+		term:     make(chan struct{}),
+	}
+}
+
+// loop writes the queued messages to the remote peer until the queue is closed.
+func (q *msgQueue) loop() {
+	for {
+		// Flush any consensus-critical messages before looking at anything else
+		select {
+		case msg := <-q.priority:
+			q.write(msg)
+			continue
+		default:
+		}
+		select {
+		case msg := <-q.priority:
+			q.write(msg)
+		case msg := <-q.normal:
+			q.write(msg)
+		case <-q.term:
+			return
+		}
+	}
+}
+
+// write sends a single message to the remote peer, reporting the result if the
+// originator is waiting for it.
+func (q *msgQueue) write(msg *outboundMsg) {
+	var err error
+	if payload, ok := msg.data.(compressedPayload); ok {
+		err = q.rw.WriteMsg(p2p.Msg{Code: msg.code, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)})
+	} else {
+		err = p2p.Send(q.rw, msg.code, msg.data)
+	}
+	if msg.errc != nil {
+		msg.errc <- err
+	}
+}
+
+// send queues a regular message and waits until it is written to the peer.
+func (q *msgQueue) send(code uint64, data interface{}) error {
+	msg := &outboundMsg{code: code, data: data, errc: make(chan error, 1)}
+	select {
+	case q.normal <- msg:
+		return <-msg.errc
+	case <-q.term:
+		return errQueueClosed
+	}
+}
+
+// prioritise queues a consensus-critical message to be written ahead of all the
+// regular ones, without waiting for it to be sent. The message is dropped if too
+// many are already waiting.
+func (q *msgQueue) prioritise(code uint64, data interface{}) error {
+	select {
+	case <-q.term:
+		return errQueueClosed
+	default:
+	}
+	select {
+	case q.priority <- &outboundMsg{code: code, data: data}:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// close terminates the writer loop, failing all pending and future sends.
+func (q *msgQueue) close() {
+	q.once.Do(func() { close(q.term) })
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/p2p"
+)
+
+// Tests that consensus-critical messages are written ahead of regular ones, and
+// that a closed queue fails any further sends.
+func TestMsgQueuePriority(t *testing.T) {
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	queue := newMsgQueue(net)
+
+	// Queue up a regular message, and a priority one afterwards
+	errc := make(chan error, 1)
+	go func() { errc <- queue.send(TxMsg, []uint{1}) }()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := queue.prioritise(NewBlockHashesMsg, []uint{2}); err != nil {
+		t.Fatalf("failed to queue priority message: %v", err)
+	}
+	go queue.loop()
+
+	// Ensure the priority message overtook the regular one
+	if err := p2p.ExpectMsg(app, NewBlockHashesMsg, []uint{2}); err != nil {
+		t.Fatalf("priority message mismatch: %v", err)
+	}
+	if err := p2p.ExpectMsg(app, TxMsg, []uint{1}); err != nil {
+		t.Fatalf("regular message mismatch: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("regular message send failed: %v", err)
+	}
+	// Close the queue and ensure further sends are rejected
+	queue.close()
+	if err := queue.send(TxMsg, []uint{3}); err != errQueueClosed {
+		t.Fatalf("send on closed queue error mismatch: have %v, want %v", err, errQueueClosed)
+	}
+	if err := queue.prioritise(NewBlockMsg, []uint{4}); err != errQueueClosed {
+		t.Fatalf("prioritise on closed queue error mismatch: have %v, want %v", err, errQueueClosed)
+	}
+}
+
+// Tests that priority messages are dropped instead of blocking when too many of
+// them are waiting to be written.
+func TestMsgQueueOverflow(t *testing.T) {
+	_, net := p2p.MsgPipe()
+	queue := newMsgQueue(net)
+
+	for i := 0; i < maxQueuedPriorityMsgs; i++ {
+		if err := queue.prioritise(NewBlockHashesMsg, []uint{uint(i)}); err != nil {
+			t.Fatalf("message %d: failed to queue: %v", i, err)
+		}
+	}
+	if err := queue.prioritise(NewBlockHashesMsg, []uint{0}); err != errQueueFull {
+		t.Fatalf("overflow error mismatch: have %v, want %v", err, errQueueFull)
+	}
+}
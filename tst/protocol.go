@@ -23,6 +23,7 @@ import (
 
 	"github.com/gdachain/go-gdachain/common"
 	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/forkid"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/event"
 	"github.com/gdachain/go-gdachain/rlp"
@@ -32,16 +33,19 @@ import (
 const (
 	gda62 = 62
 	gda63 = 63
+	gda64 = 64
+	gda65 = 65
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "gda"
 
-// Supported versions of the gda protocol (first is primary).
-var ProtocolVersions = []uint{gda63, gda62}
+// Supported versions of the gda protocol (first is primary). Since gda/65 the
+// bulky sync responses are snappy compressed, see isCompressed.
+var ProtocolVersions = []uint{gda65, gda64, gda63, gda62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{17, 17, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -76,6 +80,9 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrRequestRateExceeded
+	ErrForkIDRejected
+	ErrMsgTooManyItems
 )
 
 func (e errCode) String() string {
@@ -93,6 +100,9 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrRequestRateExceeded:     "Request rate exceeded",
+	ErrForkIDRejected:          "Fork ID rejected",
+	ErrMsgTooManyItems:         "Too many message items",
 }
 
 type txPool interface {
@@ -108,6 +118,14 @@ type txPool interface {
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
 }
 
+// minedBlockSource defines the methods needed by the protocol manager to be
+// notified of locally mined blocks.
+type minedBlockSource interface {
+	// SubscribeNewMinedBlockEvent should return an event subscription of
+	// NewMinedBlockEvent and send events to the given channel.
+	SubscribeNewMinedBlockEvent(chan<- core.NewMinedBlockEvent) event.Subscription
+}
+
 // statusData is the network packet for the status message.
 type statusData struct {
 	ProtocolVersion uint32
@@ -117,6 +135,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData64 is the network packet for the status message since gda/64,
+// additionally announcing the fork identifier of the sender.
+type statusData64 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
@@ -17,12 +17,17 @@
 package gda
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math/big"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/forkid"
 	"github.com/gdachain/go-gdachain/core/types"
 	"github.com/gdachain/go-gdachain/crypto"
 	"github.com/gdachain/go-gdachain/gda/downloader"
@@ -92,6 +97,137 @@ func tesgdaatusMsgErrors(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that gda/64 handshake failures, including incompatible fork IDs, are
+// detected and reported correctly.
+func TesgdaatusMsgErrors64(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	var (
+		genesis = pm.blockchain.Genesis()
+		head    = pm.blockchain.CurrentHeader()
+		td      = pm.blockchain.GetTd(head.Hash(), head.Number.Uint64())
+		forkID  = forkid.NewID(pm.blockchain)
+	)
+	defer pm.Stop()
+
+	tests := []struct {
+		code      uint64
+		data      interface{}
+		wantError error
+	}{
+		{
+			code: TxMsg, data: []interface{}{},
+			wantError: errResp(ErrNoStatusMsg, "first msg has code 2 (!= 0)"),
+		},
+		{
+			code: StatusMsg, data: statusData64{10, DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash(), forkID},
+			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", gda64),
+		},
+		{
+			code: StatusMsg, data: statusData64{gda64, 999, td, head.Hash(), genesis.Hash(), forkID},
+			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 1)"),
+		},
+		{
+			code: StatusMsg, data: statusData64{gda64, DefaultConfig.NetworkId, td, head.Hash(), common.Hash{3}, forkID},
+			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis.Hash().Bytes()[:8]),
+		},
+		{
+			code: StatusMsg, data: statusData64{gda64, DefaultConfig.NetworkId, td, head.Hash(), genesis.Hash(), forkid.ID{Hash: [4]byte{0xde, 0xad}}},
+			wantError: errResp(ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale),
+		},
+	}
+
+	for i, test := range tests {
+		p, errc := newTestPeer("peer", gda64, pm, false)
+		// The send call might hang until reset because
+		// the protocol might not read the payload.
+		go p2p.Send(p.app, test.code, test.data)
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Errorf("test %d: protocol returned nil error, want %q", i, test.wantError)
+			} else if err.Error() != test.wantError.Error() {
+				t.Errorf("test %d: wrong error: got %q, want %q", i, err, test.wantError)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("protocol did not shut down within 2 seconds")
+		}
+		p.close()
+	}
+}
+
+// Tests that list messages exceeding their item limits are rejected before being
+// decoded, and the offending peer disconnected.
+func TestOverpopulatedMessages(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	tests := []struct {
+		code uint64
+		data interface{}
+	}{
+		{code: BlockHeadersMsg, data: make([]*types.Header, downloader.MaxHeaderFetch+1)},
+		{code: NewBlockHashesMsg, data: make(newBlockHashesData, maxAnnouncesPerMsg+1)},
+	}
+	for i, test := range tests {
+		if headers, ok := test.data.([]*types.Header); ok {
+			for j := range headers {
+				headers[j] = &types.Header{Number: big.NewInt(int64(j)), Difficulty: big.NewInt(1)}
+			}
+		}
+		p, errc := newTestPeer("peer", gda63, pm, true)
+		go p2p.Send(p.app, test.code, test.data)
+
+		select {
+		case err := <-errc:
+			if err == nil || !strings.HasPrefix(err.Error(), errCode(ErrMsgTooManyItems).String()) {
+				t.Errorf("test %d: wrong error: got %v, want %q", i, err, errCode(ErrMsgTooManyItems))
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("test %d: protocol did not shut down within 2 seconds", i)
+		}
+		p.close()
+	}
+}
+
+// Tests that the bulky responses of gda/65 peers are decompressed before being
+// decoded, and that malformed or oversized compressed payloads are rejected.
+func TestCompressedMessages65(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	overpopulated, err := compressMsg(make([][]byte, downloader.MaxStateFetch+1))
+	if err != nil {
+		t.Fatalf("failed to compress node data: %v", err)
+	}
+	bomb := make([]byte, binary.MaxVarintLen32, binary.MaxVarintLen32+1)
+	bomb = append(bomb[:binary.PutUvarint(bomb, ProtocolMaxMsgSize+1)], 0x00)
+
+	tests := []struct {
+		code    uint64
+		payload []byte
+		want    errCode
+	}{
+		{code: NodeDataMsg, payload: overpopulated, want: ErrMsgTooManyItems},
+		{code: ReceiptsMsg, payload: bomb, want: ErrMsgTooLarge},
+		{code: BlockBodiesMsg, payload: []byte{0x0a, 0xff}, want: ErrDecode},
+	}
+	for i, test := range tests {
+		p, errc := newTestPeer("peer", gda65, pm, true)
+		go p.app.WriteMsg(p2p.Msg{Code: test.code, Size: uint32(len(test.payload)), Payload: bytes.NewReader(test.payload)})
+
+		select {
+		case err := <-errc:
+			if err == nil || !strings.HasPrefix(err.Error(), test.want.String()) {
+				t.Errorf("test %d: wrong error: got %v, want %q", i, err, test.want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("test %d: protocol did not shut down within 2 seconds", i)
+		}
+		p.close()
+	}
+}
+
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
@@ -0,0 +1,76 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"time"
+)
+
+// maxThrottledRequests is the number of consecutive requests a peer may send
+// while being throttled before it is disconnected.
+const maxThrottledRequests = 64
+
+// requestLimiter is a token bucket limiting the rate at which the data requests
+// of a single peer are served. It is only accessed from the message handling
+// loop of its peer, so it isn't safe for concurrent use.
+type requestLimiter struct {
+	rate   float64   // Tokens replenished per second
+	burst  float64   // Maximum number of tokens held
+	tokens float64   // Tokens currently available
+	last   time.Time // Time the tokens were last replenished
+
+	throttled int // Number of consecutive requests rejected
+}
+
+// newRequestLimiter creates a limiter allowing rate requests per second on
+// average, with bursts of up to burst requests. The bucket starts out full.
+func newRequestLimiter(rate float64, burst int) *requestLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &requestLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// allow reports whether a request arriving at the given time may be served,
+// consuming a token if so.
+func (l *requestLimiter) allow(now time.Time) bool {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+	if l.tokens < 1 {
+		l.throttled++
+		return false
+	}
+	l.tokens--
+	l.throttled = 0
+	return true
+}
+
+// abusive reports whether the peer kept sending requests despite being
+// throttled for too long.
+func (l *requestLimiter) abusive() bool {
+	return l.throttled >= maxThrottledRequests
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that the request limiter allows bursts, replenishes at the configured
+// rate and flags peers that keep requesting while throttled.
+func TestRequestLimiter(t *testing.T) {
+	limiter := newRequestLimiter(10, 5)
+	now := limiter.last
+
+	// The full burst is allowed right away, the request after it isn't
+	for i := 0; i < 5; i++ {
+		if !limiter.allow(now) {
+			t.Fatalf("request %d of burst throttled", i)
+		}
+	}
+	if limiter.allow(now) {
+		t.Fatalf("request over burst allowed")
+	}
+	// After 100ms a single token is replenished
+	now = now.Add(100 * time.Millisecond)
+	if !limiter.allow(now) {
+		t.Fatalf("request after replenishment throttled")
+	}
+	if limiter.allow(now) {
+		t.Fatalf("request over replenished allowance allowed")
+	}
+	// Tokens never accumulate above the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 5; i++ {
+		if !limiter.allow(now) {
+			t.Fatalf("request %d of refilled burst throttled", i)
+		}
+	}
+	// Peers ignoring the throttling are eventually flagged
+	for i := 0; i < maxThrottledRequests; i++ {
+		if limiter.abusive() {
+			t.Fatalf("peer flagged after %d throttled requests", i)
+		}
+		limiter.allow(now)
+	}
+	if !limiter.abusive() {
+		t.Fatalf("peer not flagged after %d throttled requests", maxThrottledRequests)
+	}
+	// Served requests reset the count
+	if !limiter.allow(now.Add(time.Second)) || limiter.abusive() {
+		t.Fatalf("peer still flagged after a served request")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+// uncleanShutdownRefresh is the interval between refreshes of the unclean
+// shutdown marker of the running session, bounding the error of the reported
+// crash times.
+const uncleanShutdownRefresh = 5 * time.Minute
+
+// errShutdownTimeout is returned if the chain state couldn't be flushed to disk
+// within the configured shutdown timeout.
+var errShutdownTimeout = errors.New("timed out flushing chain state")
+
+// shutdownTracker records a marker in the database for the running session that
+// is only removed on clean shutdown, reporting the crashes of previous sessions.
+type shutdownTracker struct {
+	db   gdadb.Database
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newShutdownTracker pushes the unclean shutdown marker of the running session,
+// warning about the previous sessions that crashed, and starts refreshing it.
+func newShutdownTracker(db gdadb.Database) *shutdownTracker {
+	previous, err := core.PushUncleanShutdownMarker(db)
+	if err != nil {
+		log.Error("Failed to push unclean shutdown marker", "err", err)
+	}
+	for _, marker := range previous {
+		crash := time.Unix(int64(marker), 0)
+		log.Warn("Unclean shutdown detected", "crashed", crash, "age", common.PrettyDuration(time.Since(crash)))
+	}
+	t := &shutdownTracker{
+		db:   db,
+		quit: make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.loop()
+	return t
+}
+
+// loop periodically refreshes the marker of the running session.
+func (t *shutdownTracker) loop() {
+	defer t.wg.Done()
+
+	refresh := time.NewTicker(uncleanShutdownRefresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-refresh.C:
+			if err := core.UpdateUncleanShutdownMarker(t.db); err != nil {
+				log.Warn("Failed to refresh unclean shutdown marker", "err", err)
+			}
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// stop terminates the refreshes. If the shutdown was clean, the marker of the
+// running session is removed.
+func (t *shutdownTracker) stop(clean bool) {
+	close(t.quit)
+	t.wg.Wait()
+
+	if !clean {
+		return
+	}
+	if err := core.PopUncleanShutdownMarker(t.db); err != nil {
+		log.Error("Failed to pop unclean shutdown marker", "err", err)
+	}
+}
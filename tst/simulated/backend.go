@@ -0,0 +1,205 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulated runs a full in-process gdachain node on a simulated chain,
+// for testing applications against the complete RPC API.
+package simulated
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda"
+	"github.com/gdachain/go-gdachain/gdaclient"
+	"github.com/gdachain/go-gdachain/internal/ethapi"
+	"github.com/gdachain/go-gdachain/node"
+	"github.com/gdachain/go-gdachain/p2p"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// DefaultGasLimit is the gas limit of the simulated genesis block.
+const DefaultGasLimit = 8000000
+
+var errUnknownSnapshot = errors.New("unknown snapshot")
+
+// Backend is a simulated blockchain served by an in-process node running the
+// full gdachain service. Transactions submitted through any of its APIs are kept
+// in the transaction pool until Commit is called, which seals them instantly
+// into a new block using a fake consensus engine.
+type Backend struct {
+	stack  *node.Node
+	gda    *gda.gdachain
+	rpc    *rpc.Client
+	client *gdaclient.Client
+
+	lock sync.Mutex // Serializes block production and chain rewinds
+}
+
+// NewBackend creates a simulated blockchain with the given accounts allocated
+// in its genesis block and starts a networkless node serving it. The optional
+// config hooks can adjust the node and gdachain configurations before the node
+// is started.
+func NewBackend(alloc core.GenesisAlloc, options ...func(nodeConf *node.Config, gdaConf *gda.Config)) (*Backend, error) {
+	nodeConf := node.Config{
+		Name: "simulated",
+		P2P: p2p.Config{
+			NoDiscovery: true,
+			NoDial:      true,
+			MaxPeers:    0,
+		},
+		UseLightweightKDF: true,
+	}
+	gdaConf := gda.DefaultConfig
+	gdaConf.Genesis = &core.Genesis{
+		Config:   params.AllgdaashProtocolChanges,
+		GasLimit: DefaultGasLimit,
+		Alloc:    alloc,
+	}
+	gdaConf.NetworkId = params.AllgdaashProtocolChanges.ChainId.Uint64()
+	gdaConf.NoPruning = true // Snapshots need the state of past blocks
+	gdaConf.TxPool.Journal = ""
+
+	for _, option := range options {
+		option(&nodeConf, &gdaConf)
+	}
+	// The fake engine is needed to seal blocks on demand, don't let it be overridden
+	gdaConf.gdaash.PowMode = ethash.ModeFake
+	if genesis := gdaConf.Genesis; genesis == nil || (genesis.Config != nil && genesis.Config.Clique != nil) {
+		return nil, errors.New("simulated backend requires an gdaash chain configuration")
+	}
+	stack, err := node.New(&nodeConf)
+	if err != nil {
+		return nil, err
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) { return gda.New(ctx, &gdaConf) }); err != nil {
+		return nil, err
+	}
+	if err := stack.Start(); err != nil {
+		return nil, err
+	}
+	b := &Backend{stack: stack}
+	if err := stack.Service(&b.gda); err != nil {
+		stack.Stop()
+		return nil, err
+	}
+	if b.rpc, err = stack.Attach(); err != nil {
+		stack.Stop()
+		return nil, err
+	}
+	b.client = gdaclient.NewClient(b.rpc)
+	return b, nil
+}
+
+// Close terminates the node serving the simulated chain.
+func (b *Backend) Close() error {
+	b.rpc.Close()
+	return b.stack.Stop()
+}
+
+// Client returns a client connected to the in-process node. It satisfies the
+// contract binding backend interfaces.
+func (b *Backend) Client() *gdaclient.Client {
+	return b.client
+}
+
+// RPC returns the raw in-process RPC client, exposing every API namespace of
+// the node, not just the ones wrapped by Client.
+func (b *Backend) RPC() *rpc.Client {
+	return b.rpc
+}
+
+// APIBackend returns the backend the node's RPC APIs are built on.
+func (b *Backend) APIBackend() ethapi.Backend {
+	return b.gda.ApiBackend
+}
+
+// Node returns the node serving the simulated chain.
+func (b *Backend) Node() *node.Node {
+	return b.stack
+}
+
+// Commit seals all executable transactions of the pool into a new block, makes
+// it the head of the chain and returns its hash. Transactions not fitting into
+// the block gas limit stay in the pool for the next one.
+func (b *Backend) Commit() common.Hash {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var (
+		chain  = b.gda.BlockChain()
+		pool   = b.gda.TxPool()
+		config = chain.Config()
+		parent = chain.CurrentBlock()
+	)
+	pending, err := pool.Pending()
+	if err != nil {
+		panic(fmt.Errorf("failed to retrieve pending transactions: %v", err))
+	}
+	signer := types.MakeSigner(config, new(big.Int).Add(parent.Number(), common.Big1))
+	txs := types.NewTransactionsByPriceAndNonce(signer, pending)
+
+	blocks, _ := core.GenerateChain(config, parent, b.gda.Engine(), b.gda.ChainDb(), 1, func(i int, gen *core.BlockGen) {
+		var (
+			limit = core.CalcGasLimit(parent)
+			used  uint64
+		)
+		for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+			if used+tx.Gas() > limit {
+				txs.Pop()
+				continue
+			}
+			gen.AddTx(tx)
+			used += tx.Gas()
+			txs.Shift()
+		}
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		panic(fmt.Errorf("failed to insert simulated block: %v", err)) // This cannot happen unless the simulator is wrong
+	}
+	pool.Reset()
+	return blocks[0].Hash()
+}
+
+// Snapshot returns an identifier of the current head of the chain, which the
+// chain can later be reverted to.
+func (b *Backend) Snapshot() uint64 {
+	return b.gda.BlockChain().CurrentBlock().NumberU64()
+}
+
+// Revert rewinds the chain to the given snapshot, discarding all blocks that were
+// committed since. The transactions of the discarded blocks are dropped, while
+// the ones still pending in the pool are kept if they remain executable.
+func (b *Backend) Revert(snapshot uint64) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	chain := b.gda.BlockChain()
+	if snapshot > chain.CurrentBlock().NumberU64() {
+		return errUnknownSnapshot
+	}
+	if err := chain.SetHead(snapshot); err != nil {
+		return err
+	}
+	b.gda.TxPool().Reset()
+	return nil
+}
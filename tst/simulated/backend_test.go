@@ -0,0 +1,129 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulated
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1000 * params.Finney)
+)
+
+func newTestBackend(t *testing.T) *Backend {
+	backend, err := NewBackend(core.GenesisAlloc{testAddr: {Balance: testBalance}})
+	if err != nil {
+		t.Fatalf("failed to create simulated backend: %v", err)
+	}
+	return backend
+}
+
+func sendTestTransfer(t *testing.T, backend *Backend, nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+	signer := types.NewEIP155Signer(params.AllgdaashProtocolChanges.ChainId)
+	tx, err := types.SignTx(types.NewTransaction(nonce, to, value, params.TxGas, big.NewInt(params.Shannon), nil), signer, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := backend.Client().SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that transactions are only mined when committing, and that the results
+// are visible through the RPC API.
+func TestCommit(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.Close()
+
+	ctx := context.Background()
+	recipient := common.Address{0xaa}
+
+	tx := sendTestTransfer(t, backend, 0, recipient, big.NewInt(1000))
+	if receipt, _ := backend.Client().TransactionReceipt(ctx, tx.Hash()); receipt != nil {
+		t.Fatalf("transaction mined before commit")
+	}
+	hash := backend.Commit()
+
+	receipt, err := backend.Client().TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve receipt: %v", err)
+	}
+	if receipt.BlockHash != hash {
+		t.Errorf("receipt block mismatch: have %x, want %x", receipt.BlockHash, hash)
+	}
+	balance, err := backend.Client().BalanceAt(ctx, recipient, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve balance: %v", err)
+	}
+	if balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("balance mismatch: have %v, want 1000", balance)
+	}
+	// The next transaction of the sender must be accepted right away
+	sendTestTransfer(t, backend, 1, recipient, big.NewInt(1000))
+}
+
+// Tests that reverting to a snapshot rewinds both the chain and the state.
+func TestSnapshotRevert(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.Close()
+
+	ctx := context.Background()
+	recipient := common.Address{0xbb}
+
+	backend.Commit()
+	snapshot := backend.Snapshot()
+
+	sendTestTransfer(t, backend, 0, recipient, big.NewInt(1000))
+	backend.Commit()
+	backend.Commit()
+
+	if err := backend.Revert(snapshot); err != nil {
+		t.Fatalf("failed to revert: %v", err)
+	}
+	header, err := backend.Client().HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve head: %v", err)
+	}
+	if header.Number.Uint64() != snapshot {
+		t.Errorf("head mismatch: have %d, want %d", header.Number, snapshot)
+	}
+	balance, err := backend.Client().BalanceAt(ctx, recipient, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve balance: %v", err)
+	}
+	if balance.Sign() != 0 {
+		t.Errorf("balance mismatch after revert: have %v, want 0", balance)
+	}
+	// The reverted nonce must be reusable on the new chain
+	sendTestTransfer(t, backend, 0, recipient, big.NewInt(2000))
+	backend.Commit()
+
+	if err := backend.Revert(snapshot + 10); err != errUnknownSnapshot {
+		t.Errorf("error mismatch for future snapshot: have %v, want %v", err, errUnknownSnapshot)
+	}
+}
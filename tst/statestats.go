@@ -0,0 +1,293 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/state"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/crypto"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/trie"
+)
+
+const (
+	// maxRunningStateStats is the maximum number of state statistics jobs walking
+	// the state tries at once.
+	maxRunningStateStats = 1
+
+	// maxStateStatsHistory is the number of finished state statistics jobs whose
+	// results are retained for status queries.
+	maxStateStatsHistory = 16
+)
+
+var (
+	errStateStatsCancelled = errors.New("state statistics cancelled")
+	errTooManyStateStats   = errors.New("too many state statistics jobs running")
+	errUnknownJob          = errors.New("unknown job")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// StateStats reports the progress and the results of a state statistics job.
+// The depth histograms count the leaves by the number of trie nodes on their
+// path from the root.
+type StateStats struct {
+	ID            int         `json:"id"`
+	Block         uint64      `json:"block"`
+	Root          common.Hash `json:"root"`
+	Accounts      uint64      `json:"accounts"`
+	Contracts     uint64      `json:"contracts"`
+	StorageSlots  uint64      `json:"storageSlots"`
+	CodeSize      uint64      `json:"codeSize"` // Total bytes of contract code, summed over accounts
+	AccountDepths []uint64    `json:"accountDepths"`
+	StorageDepths []uint64    `json:"storageDepths"`
+	Started       time.Time   `json:"started"`
+	Finished      *time.Time  `json:"finished,omitempty"`
+	Error         string      `json:"error,omitempty"`
+}
+
+// stateStatsJob is a state statistics job running in the background.
+type stateStatsJob struct {
+	stats  StateStats // Guarded by the collector's lock
+	cancel chan struct{}
+}
+
+// stateStatsCollector walks the state tries in the background, gathering size
+// statistics about the state.
+type stateStatsCollector struct {
+	chain *core.BlockChain
+
+	jobs    map[int]*stateStatsJob
+	history []int // Finished jobs, oldest first
+	running int
+	nextID  int
+	lock    sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newStateStatsCollector creates a statistics collector for the states of the
+// given chain.
+func newStateStatsCollector(chain *core.BlockChain) *stateStatsCollector {
+	return &stateStatsCollector{
+		chain: chain,
+		jobs:  make(map[int]*stateStatsJob),
+		quit:  make(chan struct{}),
+	}
+}
+
+// start launches a new statistics job over the state of the given block in the
+// background, returning the job's initial progress.
+func (c *stateStatsCollector) start(block *types.Block) (StateStats, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.running >= maxRunningStateStats {
+		return StateStats{}, errTooManyStateStats
+	}
+	c.nextID++
+	job := &stateStatsJob{
+		stats: StateStats{
+			ID:      c.nextID,
+			Block:   block.NumberU64(),
+			Root:    block.Root(),
+			Started: time.Now(),
+		},
+		cancel: make(chan struct{}),
+	}
+	c.jobs[job.stats.ID] = job
+	c.running++
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.finish(job, c.collect(job))
+	}()
+	return job.stats, nil
+}
+
+// collect walks the state trie and all the storage tries of a job's state.
+func (c *stateStatsCollector) collect(job *stateStatsJob) error {
+	db := c.chain.StateCache()
+	tr, err := db.OpenTrie(job.stats.Root)
+	if err != nil {
+		return err
+	}
+	log.Info("Collecting state statistics", "id", job.stats.ID, "number", job.stats.Block, "root", job.stats.Root)
+
+	return c.walk(job, tr.NodeIterator(nil), func(key, value []byte, depth int) error {
+		var account state.Account
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return err
+		}
+		addrHash := common.BytesToHash(key)
+
+		var size int
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+			if size, err = db.ContractCodeSize(addrHash, codeHash); err != nil {
+				return err
+			}
+		}
+		var (
+			slots  uint64
+			depths []uint64
+		)
+		if account.Root != types.EmptyRootHash {
+			storage, err := db.OpenStorageTrie(addrHash, account.Root)
+			if err != nil {
+				return err
+			}
+			err = c.walk(job, storage.NodeIterator(nil), func(key, value []byte, depth int) error {
+				slots++
+				depths = countDepth(depths, depth)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		stats := &job.stats
+		stats.Accounts++
+		stats.AccountDepths = countDepth(stats.AccountDepths, depth)
+		if size > 0 {
+			stats.Contracts++
+			stats.CodeSize += uint64(size)
+		}
+		stats.StorageSlots += slots
+		for depth, count := range depths {
+			for len(stats.StorageDepths) <= depth {
+				stats.StorageDepths = append(stats.StorageDepths, 0)
+			}
+			stats.StorageDepths[depth] += count
+		}
+		return nil
+	})
+}
+
+// walk iterates over all the nodes of a trie, calling onLeaf with the key, the
+// value and the depth of every leaf.
+func (c *stateStatsCollector) walk(job *stateStatsJob, it trie.NodeIterator, onLeaf func(key, value []byte, depth int) error) error {
+	var parents [][]byte // Paths of the nodes leading to the current one
+	for it.Next(true) {
+		select {
+		case <-job.cancel:
+			return errStateStatsCancelled
+		case <-c.quit:
+			return errStateStatsCancelled
+		default:
+		}
+		path := it.Path()
+		for len(parents) > 0 && !bytes.HasPrefix(path, parents[len(parents)-1]) {
+			parents = parents[:len(parents)-1]
+		}
+		if it.Leaf() {
+			if err := onLeaf(it.LeafKey(), it.LeafBlob(), len(parents)); err != nil {
+				return err
+			}
+			continue
+		}
+		parents = append(parents, common.CopyBytes(path))
+	}
+	return it.Error()
+}
+
+// countDepth increments the counter of the given depth in a histogram, growing
+// it as needed.
+func countDepth(histogram []uint64, depth int) []uint64 {
+	for len(histogram) <= depth {
+		histogram = append(histogram, 0)
+	}
+	histogram[depth]++
+	return histogram
+}
+
+// finish records the outcome of a statistics job, dropping the oldest finished
+// jobs beyond the history limit.
+func (c *stateStatsCollector) finish(job *stateStatsJob, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	job.stats.Finished = &now
+	if err != nil {
+		job.stats.Error = err.Error()
+		log.Warn("State statistics failed", "id", job.stats.ID, "accounts", job.stats.Accounts, "err", err)
+	} else {
+		log.Info("Collected state statistics", "id", job.stats.ID, "accounts", job.stats.Accounts, "slots", job.stats.StorageSlots, "elapsed", common.PrettyDuration(now.Sub(job.stats.Started)))
+	}
+	c.running--
+
+	c.history = append(c.history, job.stats.ID)
+	for len(c.history) > maxStateStatsHistory {
+		delete(c.jobs, c.history[0])
+		c.history = c.history[1:]
+	}
+}
+
+// status returns the progress of a statistics job.
+func (c *stateStatsCollector) status(id int) (StateStats, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok {
+		return StateStats{}, errUnknownJob
+	}
+	stats := job.stats
+	stats.AccountDepths = append([]uint64(nil), stats.AccountDepths...)
+	stats.StorageDepths = append([]uint64(nil), stats.StorageDepths...)
+	return stats, nil
+}
+
+// cancel aborts a running statistics job, returning whether it was running.
+func (c *stateStatsCollector) cancel(id int) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	job, ok := c.jobs[id]
+	if !ok {
+		return false, errUnknownJob
+	}
+	if job.stats.Finished != nil {
+		return false, nil
+	}
+	select {
+	case <-job.cancel:
+		return false, nil
+	default:
+		close(job.cancel)
+	}
+	return true, nil
+}
+
+// stop aborts all running statistics jobs and waits for them to terminate.
+func (c *stateStatsCollector) stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// waitStateStats waits until a state statistics job finishes, returning its
+// final results.
+func waitStateStats(t *testing.T, collector *stateStatsCollector, id int) StateStats {
+	for i := 0; i < 100; i++ {
+		stats, err := collector.status(id)
+		if err != nil {
+			t.Fatalf("failed to retrieve job status: %v", err)
+		}
+		if stats.Finished != nil {
+			return stats
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish", id)
+	return StateStats{}
+}
+
+// Tests that the state statistics match the contents of the state.
+func TestStateStats(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{byte(i + 1)}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+		block.AddTx(tx)
+	}
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 4, generator, nil)
+	defer pm.Stop()
+
+	collector := newStateStatsCollector(pm.blockchain)
+	defer collector.stop()
+
+	block := pm.blockchain.CurrentBlock()
+	stats, err := collector.start(block)
+	if err != nil {
+		t.Fatalf("failed to start job: %v", err)
+	}
+	if stats = waitStateStats(t, collector, stats.ID); stats.Error != "" {
+		t.Fatalf("job failed: %v", stats.Error)
+	}
+	statedb, err := pm.blockchain.StateAt(block.Root())
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	dump := statedb.RawDump()
+	if stats.Accounts != uint64(len(dump.Accounts)) {
+		t.Errorf("account count mismatch: have %d, want %d", stats.Accounts, len(dump.Accounts))
+	}
+	if stats.Contracts != 0 || stats.StorageSlots != 0 || stats.CodeSize != 0 {
+		t.Errorf("unexpected contract stats: contracts %d, slots %d, code %d", stats.Contracts, stats.StorageSlots, stats.CodeSize)
+	}
+	var leaves uint64
+	for _, count := range stats.AccountDepths {
+		leaves += count
+	}
+	if leaves != stats.Accounts {
+		t.Errorf("depth histogram mismatch: have %d leaves, want %d", leaves, stats.Accounts)
+	}
+	if _, err := collector.status(stats.ID + 1); err != errUnknownJob {
+		t.Errorf("unknown job status error mismatch: have %v, want %v", err, errUnknownJob)
+	}
+}
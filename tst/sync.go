@@ -17,6 +17,7 @@
 package gda
 
 import (
+	"math/big"
 	"math/rand"
 	"sync/atomic"
 	"time"
@@ -160,6 +161,17 @@ func (pm *ProtocolManager) syncer() {
 	}
 }
 
+// localTd retrieves the total difficulty of the local chain head: the head block
+// normally, or the head header if only headers are synchronised.
+func (pm *ProtocolManager) localTd() *big.Int {
+	if pm.headerSync {
+		head := pm.blockchain.CurrentHeader()
+		return pm.blockchain.GetTd(head.Hash(), head.Number.Uint64())
+	}
+	head := pm.blockchain.CurrentBlock()
+	return pm.blockchain.GetTd(head.Hash(), head.NumberU64())
+}
+
 // synchronise tries to sync up our local block chain with a remote peer.
 func (pm *ProtocolManager) synchronise(peer *peer) {
 	// Short circuit if no peers are available
@@ -167,19 +179,19 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 	// Make sure the peer's TD is higher than our own
-	currentBlock := pm.blockchain.CurrentBlock()
-	td := pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())
-
 	pHead, pTd := peer.Head()
-	if pTd.Cmp(td) <= 0 {
+	if pTd.Cmp(pm.localTd()) <= 0 {
 		return
 	}
 	// Otherwise try to sync with the downloader
 	mode := downloader.FullSync
-	if atomic.LoadUint32(&pm.fastSync) == 1 {
+	if pm.headerSync {
+		// Only headers are tracked, block content is never retrieved
+		mode = downloader.HeaderSync
+	} else if atomic.LoadUint32(&pm.fastSync) == 1 {
 		// Fast sync was explicitly requested, and explicitly granted
 		mode = downloader.FastSync
-	} else if currentBlock.NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
+	} else if pm.blockchain.CurrentBlock().NumberU64() == 0 && pm.blockchain.CurrentFastBlock().NumberU64() > 0 {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
 		// The only scenario where this can happen is if the user manually (or via a
@@ -196,6 +208,9 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		log.Info("Fast sync complete, auto disabling")
 		atomic.StoreUint32(&pm.fastSync, 0)
 	}
+	if pm.headerSync {
+		return
+	}
 	atomic.StoreUint32(&pm.acceptTxs, 1) // Mark initial sync done
 	if head := pm.blockchain.CurrentBlock(); head.NumberU64() > 0 {
 		// We've completed a sync cycle, notify all peers of new state. This path is
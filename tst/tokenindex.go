@@ -0,0 +1,512 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/common/hexutil"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rlp"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+const (
+	// tokenIndexName is the name of the token index, namespacing its data in the
+	// chain database.
+	tokenIndexName = "tokens"
+
+	// tokenPageSize is the number of transfers returned in a single page of an
+	// address' token transfers.
+	tokenPageSize = 100
+)
+
+var (
+	// transferTopic is the signature hash of the ERC-20 Transfer event:
+	// Transfer(address indexed from, address indexed to, uint256 value)
+	transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+	tokenIndexHeadKey    = []byte("h") // tokenIndexHeadKey -> number of the last indexed block (uint64 big endian)
+	tokenJournalPrefix   = []byte("j") // tokenJournalPrefix + num (uint64 big endian) -> lengths of the lists appended to, prior to the block
+	tokenTransfersPrefix = []byte("t") // tokenTransfersPrefix + address -> list of token transfers of the address
+	tokenBalancesPrefix  = []byte("b") // tokenBalancesPrefix + address + token -> list of balance snapshots
+	tokenHoldingsPrefix  = []byte("l") // tokenHoldingsPrefix + address -> list of the tokens the address ever held
+)
+
+// The token index is made of append-only lists. The length of a list is stored
+// under its key and its items under the key followed by their position (uint64
+// big endian), so the lists can be rolled back by restoring their lengths.
+
+// tokenListItemKey = list + index (uint64 big endian)
+func tokenListItemKey(list []byte, index uint64) []byte {
+	return append(append([]byte{}, list...), encodeUint64(index)...)
+}
+
+// tokenTransfersKey = tokenTransfersPrefix + address
+func tokenTransfersKey(address common.Address) []byte {
+	return append(append([]byte{}, tokenTransfersPrefix...), address[:]...)
+}
+
+// tokenBalancesKey = tokenBalancesPrefix + address + token
+func tokenBalancesKey(address, token common.Address) []byte {
+	return append(append(append([]byte{}, tokenBalancesPrefix...), address[:]...), token[:]...)
+}
+
+// tokenHoldingsKey = tokenHoldingsPrefix + address
+func tokenHoldingsKey(address common.Address) []byte {
+	return append(append([]byte{}, tokenHoldingsPrefix...), address[:]...)
+}
+
+// tokenJournalKey = tokenJournalPrefix + num (uint64 big endian)
+func tokenJournalKey(number uint64) []byte {
+	return append(append([]byte{}, tokenJournalPrefix...), encodeUint64(number)...)
+}
+
+// tokenTransfer is an ERC-20 transfer as stored in the token index.
+type tokenTransfer struct {
+	Number   uint64
+	TxHash   common.Hash
+	TxIndex  uint64
+	LogIndex uint64
+	Token    common.Address
+	From     common.Address
+	To       common.Address
+	Value    *big.Int
+}
+
+// tokenBalance is a snapshot of the token balance of an address, taken at the
+// end of every block changing it.
+type tokenBalance struct {
+	Number  uint64
+	Balance *big.Int
+}
+
+// tokenListLength is the length of a list before a block was indexed, journaled
+// to roll back the index on reorgs.
+type tokenListLength struct {
+	List   []byte
+	Length uint64
+}
+
+// tokenHolding identifies the balance of an address in a token.
+type tokenHolding struct {
+	address, token common.Address
+}
+
+// parseTransfer extracts an ERC-20 transfer from a log, or nil if the log is not
+// one. ERC-721 transfers share the signature but index the token id as a fourth
+// topic, so they are skipped.
+func parseTransfer(log *types.Log) (from, to common.Address, value *big.Int, ok bool) {
+	if len(log.Topics) != 3 || log.Topics[0] != transferTopic || len(log.Data) != 32 {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	return common.BytesToAddress(log.Topics[1][:]), common.BytesToAddress(log.Topics[2][:]), new(big.Int).SetBytes(log.Data), true
+}
+
+// tokenIndexer is a chain indexer backend maintaining the index of the ERC-20
+// transfers of every address, derived from the Transfer events of the receipts,
+// along with snapshots of the resulting token balances. Like the address index,
+// it indexes sections of a single block.
+//
+// Balances are computed from the transfers alone, so they're only accurate for
+// tokens emitting a transfer from the zero address when minting and to it when
+// burning, as the standard recommends. The zero address itself is not indexed.
+type tokenIndexer struct {
+	db    gdadb.Database // Namespaced database of the token index
+	chain *core.BlockChain
+
+	number   uint64                    // Number of the block being indexed
+	items    map[string][][]byte       // Items appended to the lists by the block
+	order    []string                  // Lists in the order they were appended to
+	balances map[tokenHolding]*big.Int // Balances changed by the block
+	holdings []tokenHolding            // Holdings in the order they were changed
+	err      error                     // Failure while processing the block
+}
+
+// newTokenIndex returns a chain indexer maintaining the token index.
+func newTokenIndex(chainDb gdadb.Database, chain *core.BlockChain) (*core.ChainIndexer, error) {
+	return core.NewRegisteredIndexer(chainDb, core.IndexerSpec{
+		Name:        tokenIndexName,
+		SectionSize: 1,
+		New: func(db gdadb.Database) (core.ChainIndexerBackend, error) {
+			return &tokenIndexer{db: db, chain: chain}, nil
+		},
+	})
+}
+
+// Reset implements core.ChainIndexerBackend, starting the indexing of a new
+// block. Blocks at or above it that were already indexed are removed from the
+// index first.
+func (b *tokenIndexer) Reset(section uint64, prevHead common.Hash) error {
+	b.number, b.err = section, nil
+	b.items, b.order = make(map[string][][]byte), nil
+	b.balances, b.holdings = make(map[tokenHolding]*big.Int), nil
+
+	return b.rollback(section)
+}
+
+// rollback removes the data of all the indexed blocks from number onwards.
+func (b *tokenIndexer) rollback(number uint64) error {
+	head, ok := readUint64(b.db, tokenIndexHeadKey)
+	if !ok || head < number {
+		return nil
+	}
+	batch := b.db.NewBatch()
+	for n := head; n >= number; n-- {
+		blob, err := b.db.Get(tokenJournalKey(n))
+		if err != nil {
+			return fmt.Errorf("missing token index journal of block %d", n)
+		}
+		var journal []tokenListLength
+		if err := rlp.DecodeBytes(blob, &journal); err != nil {
+			return err
+		}
+		for _, list := range journal {
+			batch.Put(list.List, encodeUint64(list.Length))
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if number > 0 {
+		batch.Put(tokenIndexHeadKey, encodeUint64(number-1))
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Drop the head marker and stale journals once the restored list lengths
+	// are persisted (same crash reasoning as the address index rollback)
+	if number == 0 {
+		if err := b.db.Delete(tokenIndexHeadKey); err != nil {
+			return err
+		}
+	}
+	for n := number; n <= head; n++ {
+		if err := b.db.Delete(tokenJournalKey(n)); err != nil {
+			return err
+		}
+	}
+	log.Debug("Rolled back token index", "from", head, "to", number)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, collecting the token transfers
+// of a block and applying them to the balances of their parties.
+func (b *tokenIndexer) Process(header *types.Header) {
+	if b.err != nil {
+		return
+	}
+	receipts := b.chain.GetReceiptsByHash(header.Hash())
+	for i, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			from, to, value, ok := parseTransfer(log)
+			if !ok {
+				continue
+			}
+			blob, err := rlp.EncodeToBytes(&tokenTransfer{
+				Number:   header.Number.Uint64(),
+				TxHash:   receipt.TxHash,
+				TxIndex:  uint64(i),
+				LogIndex: uint64(log.Index),
+				Token:    log.Address,
+				From:     from,
+				To:       to,
+				Value:    value,
+			})
+			if err != nil {
+				b.err = err
+				return
+			}
+			for _, addr := range []common.Address{from, to} {
+				if addr != (common.Address{}) {
+					b.append(tokenTransfersKey(addr), blob)
+				}
+				if from == to {
+					break
+				}
+			}
+			b.transfer(tokenHolding{from, log.Address}, new(big.Int).Neg(value))
+			b.transfer(tokenHolding{to, log.Address}, value)
+		}
+	}
+}
+
+// append queues an item to be appended to a list.
+func (b *tokenIndexer) append(list []byte, item []byte) {
+	items, ok := b.items[string(list)]
+	if !ok {
+		b.order = append(b.order, string(list))
+	}
+	b.items[string(list)] = append(items, item)
+}
+
+// transfer applies a balance change to a holding, clamping it at zero for tokens
+// whose transfers don't account for all of their supply. The first change of a
+// holding records the token among the ones held by the address.
+func (b *tokenIndexer) transfer(holding tokenHolding, amount *big.Int) {
+	if holding.address == (common.Address{}) {
+		return
+	}
+	balance, ok := b.balances[holding]
+	if !ok {
+		if balance, ok = latestTokenBalance(b.db, holding.address, holding.token); !ok {
+			b.append(tokenHoldingsKey(holding.address), holding.token[:])
+		}
+		b.holdings = append(b.holdings, holding)
+	}
+	balance = new(big.Int).Add(balance, amount)
+	if balance.Sign() < 0 {
+		balance.SetUint64(0)
+	}
+	b.balances[holding] = balance
+}
+
+// Commit implements core.ChainIndexerBackend, snapshotting the changed balances
+// and appending all the collected items to their lists, journaling the previous
+// list lengths.
+func (b *tokenIndexer) Commit() error {
+	if b.err != nil {
+		return b.err
+	}
+	for _, holding := range b.holdings {
+		blob, err := rlp.EncodeToBytes(&tokenBalance{Number: b.number, Balance: b.balances[holding]})
+		if err != nil {
+			return err
+		}
+		b.append(tokenBalancesKey(holding.address, holding.token), blob)
+	}
+	var (
+		batch   = b.db.NewBatch()
+		journal = make([]tokenListLength, 0, len(b.order))
+	)
+	for _, list := range b.order {
+		length, _ := readUint64(b.db, []byte(list))
+		journal = append(journal, tokenListLength{List: []byte(list), Length: length})
+
+		for _, item := range b.items[list] {
+			batch.Put(tokenListItemKey([]byte(list), length), item)
+			length++
+		}
+		batch.Put([]byte(list), encodeUint64(length))
+	}
+	blob, err := rlp.EncodeToBytes(journal)
+	if err != nil {
+		return err
+	}
+	batch.Put(tokenJournalKey(b.number), blob)
+	batch.Put(tokenIndexHeadKey, encodeUint64(b.number))
+	return batch.Write()
+}
+
+// latestTokenBalance retrieves the most recent balance snapshot of an address in
+// a token, or false if it never held the token.
+func latestTokenBalance(db gdadb.Database, address, token common.Address) (*big.Int, bool) {
+	list := tokenBalancesKey(address, token)
+
+	length, _ := readUint64(db, list)
+	if length == 0 {
+		return new(big.Int), false
+	}
+	snapshot, err := readTokenBalance(db, list, length-1)
+	if err != nil {
+		return new(big.Int), false
+	}
+	return snapshot.Balance, true
+}
+
+// readTokenBalance retrieves a balance snapshot from a list of snapshots.
+func readTokenBalance(db gdadb.Database, list []byte, index uint64) (*tokenBalance, error) {
+	blob, err := db.Get(tokenListItemKey(list, index))
+	if err != nil {
+		return nil, fmt.Errorf("missing token balance snapshot %d", index)
+	}
+	snapshot := new(tokenBalance)
+	if err := rlp.DecodeBytes(blob, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// TokenTransfer is an ERC-20 token transfer an address took part in.
+type TokenTransfer struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+	LogIndex    hexutil.Uint64 `json:"logIndex"`
+	Token       common.Address `json:"token"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+}
+
+// TokenTransfers is a page of the token transfers of an address, most recent
+// first.
+type TokenTransfers struct {
+	Address     common.Address   `json:"address"`
+	Page        hexutil.Uint64   `json:"page"`
+	Total       hexutil.Uint64   `json:"total"`       // Number of transfers of the address
+	IndexedHead *hexutil.Uint64  `json:"indexedHead"` // Last block covered by the index
+	Transfers   []*TokenTransfer `json:"transfers"`
+}
+
+// PublicTokenAPI provides access to the ERC-20 token transfers and balances of
+// the token index.
+type PublicTokenAPI struct {
+	e *gdachain
+}
+
+// NewPublicTokenAPI creates a new API definition for the token index.
+func NewPublicTokenAPI(e *gdachain) *PublicTokenAPI {
+	return &PublicTokenAPI{e}
+}
+
+// db returns the namespaced database of the token index.
+func (api *PublicTokenAPI) db() gdadb.Database {
+	return core.IndexerDatabase(api.e.chainDb, tokenIndexName)
+}
+
+// GetTransfers returns a page of the token transfers sent and received by an
+// address, most recent first.
+func (api *PublicTokenAPI) GetTransfers(address common.Address, page hexutil.Uint64) (*TokenTransfers, error) {
+	return tokenTransfers(api.db(), api.e.chainDb, address, uint64(page))
+}
+
+// GetTokens returns the tokens an address ever held, in the order it first
+// received them.
+func (api *PublicTokenAPI) GetTokens(address common.Address) ([]common.Address, error) {
+	return tokenHoldings(api.db(), address)
+}
+
+// GetBalance returns the balance of an address in a token at the end of the
+// given block, as derived from the indexed transfers.
+func (api *PublicTokenAPI) GetBalance(address, token common.Address, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := tokenBalanceAt(api.db(), address, token, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(balance), nil
+}
+
+// tokenTransfers retrieves a page of the token transfers of an address, pages
+// being numbered from the most recent transfers backwards.
+func tokenTransfers(db, chainDb gdadb.Database, address common.Address, page uint64) (*TokenTransfers, error) {
+	list := tokenTransfersKey(address)
+	total, _ := readUint64(db, list)
+
+	result := &TokenTransfers{
+		Address:   address,
+		Page:      hexutil.Uint64(page),
+		Total:     hexutil.Uint64(total),
+		Transfers: []*TokenTransfer{},
+	}
+	if head, ok := readUint64(db, tokenIndexHeadKey); ok {
+		result.IndexedHead = (*hexutil.Uint64)(&head)
+	}
+	if page >= (total+tokenPageSize-1)/tokenPageSize {
+		return result, nil
+	}
+	end := total - page*tokenPageSize
+	start := uint64(0)
+	if end > tokenPageSize {
+		start = end - tokenPageSize
+	}
+	for i := end; i > start; i-- {
+		blob, err := db.Get(tokenListItemKey(list, i-1))
+		if err != nil {
+			return nil, fmt.Errorf("missing token transfer %d of %x", i-1, address)
+		}
+		transfer := new(tokenTransfer)
+		if err := rlp.DecodeBytes(blob, transfer); err != nil {
+			return nil, err
+		}
+		result.Transfers = append(result.Transfers, &TokenTransfer{
+			BlockNumber: hexutil.Uint64(transfer.Number),
+			BlockHash:   core.GetCanonicalHash(chainDb, transfer.Number),
+			TxHash:      transfer.TxHash,
+			TxIndex:     hexutil.Uint64(transfer.TxIndex),
+			LogIndex:    hexutil.Uint64(transfer.LogIndex),
+			Token:       transfer.Token,
+			From:        transfer.From,
+			To:          transfer.To,
+			Value:       (*hexutil.Big)(transfer.Value),
+		})
+	}
+	return result, nil
+}
+
+// tokenHoldings retrieves the tokens an address ever held.
+func tokenHoldings(db gdadb.Database, address common.Address) ([]common.Address, error) {
+	list := tokenHoldingsKey(address)
+	length, _ := readUint64(db, list)
+
+	tokens := make([]common.Address, 0, length)
+	for i := uint64(0); i < length; i++ {
+		blob, err := db.Get(tokenListItemKey(list, i))
+		if err != nil {
+			return nil, fmt.Errorf("missing token %d of %x", i, address)
+		}
+		tokens = append(tokens, common.BytesToAddress(blob))
+	}
+	return tokens, nil
+}
+
+// tokenBalanceAt retrieves the balance of an address in a token at the end of a
+// block, from the last snapshot taken at or before it.
+func tokenBalanceAt(db gdadb.Database, address, token common.Address, blockNr rpc.BlockNumber) (*big.Int, error) {
+	head, ok := readUint64(db, tokenIndexHeadKey)
+	if !ok {
+		return nil, fmt.Errorf("token index is empty")
+	}
+	number := head
+	if blockNr >= 0 {
+		if uint64(blockNr) > head {
+			return nil, fmt.Errorf("block #%d not yet indexed, token index head #%d", blockNr, head)
+		}
+		number = uint64(blockNr)
+	}
+	list := tokenBalancesKey(address, token)
+	length, _ := readUint64(db, list)
+
+	// Find the first snapshot taken after the block, the balance is the one before
+	var failure error
+	index := sort.Search(int(length), func(i int) bool {
+		snapshot, err := readTokenBalance(db, list, uint64(i))
+		if err != nil {
+			failure = err
+			return true
+		}
+		return snapshot.Number > number
+	})
+	if failure != nil {
+		return nil, failure
+	}
+	if index == 0 {
+		return new(big.Int), nil
+	}
+	snapshot, err := readTokenBalance(db, list, uint64(index-1))
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Balance, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/consensus/ethash"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/core/vm"
+	"github.com/gdachain/go-gdachain/gdadb"
+	"github.com/gdachain/go-gdachain/params"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// transferEmitterCode is the code of a contract emitting a Transfer event with
+// the sender, recipient and value passed as the three words of its input.
+var transferEmitterCode = append(append([]byte{
+	byte(vm.PUSH1), 64, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0, byte(vm.MSTORE),
+	byte(vm.PUSH1), 32, byte(vm.CALLDATALOAD), byte(vm.PUSH1), 0, byte(vm.CALLDATALOAD),
+	byte(vm.PUSH32)}, transferTopic[:]...),
+	byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.LOG3), byte(vm.STOP),
+)
+
+// Tests that only ERC-20 transfer events are picked up by the token index.
+func TestParseTransfer(t *testing.T) {
+	from, to := common.Address{0x01}, common.Address{0x02}
+	value := common.LeftPadBytes([]byte{0x2a}, 32)
+
+	tests := []struct {
+		log *types.Log
+		ok  bool
+	}{
+		{&types.Log{Topics: []common.Hash{transferTopic, from.Hash(), to.Hash()}, Data: value}, true},
+		{&types.Log{Topics: []common.Hash{transferTopic, from.Hash(), to.Hash(), {0x01}}}, false}, // ERC-721
+		{&types.Log{Topics: []common.Hash{{0x01}, from.Hash(), to.Hash()}, Data: value}, false},
+		{&types.Log{Topics: []common.Hash{transferTopic, from.Hash(), to.Hash()}, Data: value[1:]}, false},
+	}
+	for i, tt := range tests {
+		haveFrom, haveTo, haveValue, ok := parseTransfer(tt.log)
+		if ok != tt.ok {
+			t.Errorf("test %d: match mismatch: have %v, want %v", i, ok, tt.ok)
+			continue
+		}
+		if ok && (haveFrom != from || haveTo != to || haveValue.Int64() != 0x2a) {
+			t.Errorf("test %d: transfer mismatch: have %x -> %x (%v)", i, haveFrom, haveTo, haveValue)
+		}
+	}
+}
+
+// Tests that the token index records the transfers and balance history of every
+// address, and that it is rolled back on reorgs.
+func TestTokenIndex(t *testing.T) {
+	var (
+		token = common.Address{0xee}
+		alice = common.Address{0xa1}
+		bob   = common.Address{0xb0}
+
+		db, _ = gdadb.NewMemDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				testBank: {Balance: big.NewInt(1000000)},
+				token:    {Balance: new(big.Int), Code: transferEmitterCode},
+			},
+		}
+		genesis   = gspec.MustCommit(db)
+		signer    = types.HomesteadSigner{}
+		transfers = [][]struct {
+			from, to common.Address
+			value    int64
+		}{
+			{{common.Address{}, alice, 1000}},
+			{{alice, bob, 300}},
+			{{bob, bob, 50}, {alice, common.Address{}, 100}},
+		}
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, len(transfers), func(i int, block *core.BlockGen) {
+		for _, transfer := range transfers[i] {
+			input := append(append(transfer.from.Hash().Bytes(), transfer.to.Hash().Bytes()...), common.BigToHash(big.NewInt(transfer.value)).Bytes()...)
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), token, new(big.Int), 100000, nil, input), signer, testBankKey)
+			block.AddTx(tx)
+		}
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	// Index the chain block by block, as the chain indexer would
+	index := core.IndexerDatabase(db, tokenIndexName)
+	indexer := &tokenIndexer{db: index, chain: chain}
+
+	for i := uint64(0); i <= uint64(len(blocks)); i++ {
+		header := chain.GetHeaderByNumber(i)
+		if err := indexer.Reset(i, header.ParentHash); err != nil {
+			t.Fatalf("block %d: failed to reset indexer: %v", i, err)
+		}
+		indexer.Process(header)
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("block %d: failed to index: %v", i, err)
+		}
+	}
+	checkTransfers := func(address common.Address, want []uint64) {
+		res, err := tokenTransfers(index, db, address, 0)
+		if err != nil {
+			t.Fatalf("%x: failed to retrieve transfers: %v", address, err)
+		}
+		if int(res.Total) != len(want) || len(res.Transfers) != len(want) {
+			t.Fatalf("%x: transfer count mismatch: have %d/%d, want %d", address, res.Total, len(res.Transfers), len(want))
+		}
+		for i, transfer := range res.Transfers {
+			if uint64(transfer.BlockNumber) != want[i] || transfer.Token != token {
+				t.Errorf("%x: transfer %d mismatch: have #%d of %x, want #%d of %x", address, i, transfer.BlockNumber, transfer.Token, want[i], token)
+			}
+		}
+	}
+	checkBalance := func(address common.Address, number rpc.BlockNumber, want int64) {
+		balance, err := tokenBalanceAt(index, address, token, number)
+		if err != nil {
+			t.Fatalf("%x: failed to retrieve balance at %d: %v", address, number, err)
+		}
+		if balance.Int64() != want {
+			t.Errorf("%x: balance mismatch at %d: have %v, want %d", address, number, balance, want)
+		}
+	}
+	checkTransfers(alice, []uint64{3, 2, 1})
+	checkTransfers(bob, []uint64{3, 2})
+	checkTransfers(common.Address{}, nil)
+
+	checkBalance(alice, 0, 0)
+	checkBalance(alice, 1, 1000)
+	checkBalance(alice, 2, 700)
+	checkBalance(alice, rpc.LatestBlockNumber, 600)
+	checkBalance(bob, 1, 0)
+	checkBalance(bob, rpc.LatestBlockNumber, 300)
+
+	if _, err := tokenBalanceAt(index, alice, token, 4); err == nil {
+		t.Errorf("balance of unindexed block returned")
+	}
+	if tokens, _ := tokenHoldings(index, bob); len(tokens) != 1 || tokens[0] != token {
+		t.Errorf("token holdings mismatch: have %x, want [%x]", tokens, token)
+	}
+	// Reprocessing the chain from block 2 onwards must remove its data
+	if err := indexer.Reset(2, blocks[0].Hash()); err != nil {
+		t.Fatalf("failed to roll back index: %v", err)
+	}
+	checkTransfers(alice, []uint64{1})
+	checkTransfers(bob, nil)
+	checkBalance(alice, rpc.LatestBlockNumber, 1000)
+
+	if tokens, _ := tokenHoldings(index, bob); len(tokens) != 0 {
+		t.Errorf("token holdings not rolled back: %x", tokens)
+	}
+	// Rolling back the entire index must drop its head and all journals
+	if err := indexer.Reset(0, common.Hash{}); err != nil {
+		t.Fatalf("failed to roll back index: %v", err)
+	}
+	if has, _ := index.Has(tokenIndexHeadKey); has {
+		t.Errorf("index head retained after full rollback")
+	}
+	for n := uint64(0); n <= uint64(len(blocks)); n++ {
+		if has, _ := index.Has(tokenJournalKey(n)); has {
+			t.Errorf("journal of block %d retained after rollback", n)
+		}
+	}
+}
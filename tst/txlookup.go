@@ -0,0 +1,131 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gdadb"
+)
+
+// TxIndexer is an external transaction index, consulted for transactions that
+// are missing from the node's own lookup index.
+type TxIndexer interface {
+	// TxBlock returns the hash of the block including the transaction, or false
+	// if the transaction is unknown to the index.
+	TxBlock(hash common.Hash) (common.Hash, bool)
+}
+
+// TxIndexPrunedError is returned by transaction lookups that missed the lookup
+// index, and whose fallbacks could not locate the transaction either, without
+// being able to rule out that it was included in an older block.
+type TxIndexPrunedError struct {
+	Scanned uint64 // Number of recent blocks scanned for the transaction
+}
+
+func (e *TxIndexPrunedError) Error() string {
+	return fmt.Sprintf("transaction index pruned: not found in the last %d blocks", e.Scanned)
+}
+
+func (e *TxIndexPrunedError) ErrorCode() int { return -32006 }
+
+func (e *TxIndexPrunedError) ErrorData() interface{} {
+	return map[string]interface{}{"reason": "index pruned", "scanned": e.Scanned}
+}
+
+// txLookup resolves transactions by hash, falling back to an external index and
+// to a bounded scan of the most recent blocks if the lookup index misses them.
+type txLookup struct {
+	db    gdadb.Database
+	chain *core.BlockChain
+	scan  uint64 // Maximum number of blocks scanned backwards (0 = no scan)
+
+	indexer TxIndexer
+	lock    sync.RWMutex
+}
+
+// newTxLookup creates a transaction resolver scanning at most scan blocks.
+func newTxLookup(db gdadb.Database, chain *core.BlockChain, scan uint64) *txLookup {
+	return &txLookup{
+		db:    db,
+		chain: chain,
+		scan:  scan,
+	}
+}
+
+// setIndexer installs or, if nil, removes the external transaction index.
+func (l *txLookup) setIndexer(indexer TxIndexer) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.indexer = indexer
+}
+
+// find retrieves a canonical transaction along with the hash and number of its
+// block and its position within. If the transaction is not found and neither an
+// external index nor a scan is configured, nil is returned as the lookup index
+// is authoritative. Otherwise a TxIndexPrunedError is returned if the fallbacks
+// do not reach down to the genesis block.
+func (l *txLookup) find(hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	if tx, blockHash, number, index := core.GetTransaction(l.db, hash); tx != nil {
+		return tx, blockHash, number, index, nil
+	}
+	l.lock.RLock()
+	indexer := l.indexer
+	l.lock.RUnlock()
+
+	if indexer == nil && l.scan == 0 {
+		return nil, common.Hash{}, 0, 0, nil
+	}
+	// Ask the external index, accepting only answers for canonical blocks
+	if indexer != nil {
+		if blockHash, ok := indexer.TxBlock(hash); ok {
+			if block := l.chain.GetBlockByHash(blockHash); block != nil && core.GetCanonicalHash(l.db, block.NumberU64()) == blockHash {
+				if tx, index := blockTransaction(block, hash); tx != nil {
+					return tx, blockHash, block.NumberU64(), index, nil
+				}
+			}
+		}
+	}
+	// Scan the most recent canonical blocks
+	block := l.chain.CurrentBlock()
+	for scanned := uint64(0); scanned < l.scan && block != nil; scanned++ {
+		if tx, index := blockTransaction(block, hash); tx != nil {
+			return tx, block.Hash(), block.NumberU64(), index, nil
+		}
+		if block.NumberU64() == 0 {
+			return nil, common.Hash{}, 0, 0, nil
+		}
+		block = l.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	return nil, common.Hash{}, 0, 0, &TxIndexPrunedError{Scanned: l.scan}
+}
+
+// blockTransaction returns the transaction with the given hash in a block, and
+// its position within.
+func blockTransaction(block *types.Block, hash common.Hash) (*types.Transaction, uint64) {
+	for i, tx := range block.Transactions() {
+		if tx.Hash() == hash {
+			return tx, uint64(i)
+		}
+	}
+	return nil, 0
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/gda/downloader"
+	"github.com/gdachain/go-gdachain/params"
+)
+
+// mapIndexer is an external transaction index backed by a map.
+type mapIndexer map[common.Hash]common.Hash
+
+func (idx mapIndexer) TxBlock(hash common.Hash) (common.Hash, bool) {
+	block, ok := idx[hash]
+	return block, ok
+}
+
+// Tests that transactions missing from the lookup index are resolved via the
+// external index or the bounded block scan, and reported as pruned otherwise.
+func TestTxLookupFallback(t *testing.T) {
+	signer := types.HomesteadSigner{}
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{0x01}, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+		block.AddTx(tx)
+	}
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 8, generator, nil)
+	defer pm.Stop()
+
+	// Drop the lookup entries of all the transactions
+	var txs []*types.Transaction
+	for i := uint64(1); i <= 8; i++ {
+		block := pm.blockchain.GetBlockByNumber(i)
+		txs = append(txs, block.Transactions()[0])
+		core.DeleteTxLookupEntry(db, block.Transactions()[0].Hash())
+	}
+	unknown := common.HexToHash("0xdeadbeef")
+
+	// Without fallbacks, the lookup index is authoritative
+	lookup := newTxLookup(db, pm.blockchain, 0)
+	if tx, _, _, _, err := lookup.find(txs[7].Hash()); tx != nil || err != nil {
+		t.Fatalf("unindexed transaction resolved without fallbacks: tx %v, err %v", tx, err)
+	}
+	// Recent transactions are found by the scan, older ones are reported pruned
+	lookup = newTxLookup(db, pm.blockchain, 3)
+	if tx, _, number, index, err := lookup.find(txs[6].Hash()); err != nil || tx == nil || number != 7 || index != 0 {
+		t.Fatalf("recent transaction mismatch: tx %v, number %d, index %d, err %v", tx, number, index, err)
+	}
+	if _, _, _, _, err := lookup.find(txs[1].Hash()); err == nil {
+		t.Fatalf("old transaction not reported pruned")
+	} else if _, ok := err.(*TxIndexPrunedError); !ok {
+		t.Fatalf("old transaction error mismatch: have %v, want %T", err, &TxIndexPrunedError{})
+	}
+	// The external index resolves canonical transactions only
+	lookup.setIndexer(mapIndexer{
+		txs[1].Hash(): pm.blockchain.GetBlockByNumber(2).Hash(),
+		txs[2].Hash(): common.HexToHash("0x01"),
+	})
+	if tx, blockHash, number, _, err := lookup.find(txs[1].Hash()); err != nil || tx == nil || number != 2 || blockHash != pm.blockchain.GetBlockByNumber(2).Hash() {
+		t.Fatalf("indexed transaction mismatch: tx %v, number %d, err %v", tx, number, err)
+	}
+	if _, _, _, _, err := lookup.find(txs[2].Hash()); err == nil {
+		t.Fatalf("transaction of unknown block resolved")
+	}
+	// Scans reaching the genesis block are conclusive
+	lookup = newTxLookup(db, pm.blockchain, 16)
+	if tx, _, _, _, err := lookup.find(unknown); tx != nil || err != nil {
+		t.Fatalf("unknown transaction mismatch: tx %v, err %v", tx, err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxUnderpricedTxs is the number of underpriced transactions a peer may send
+	// in excess of its accepted ones before it is downgraded.
+	maxUnderpricedTxs = 1024
+
+	// underpricedCooldown is the time the transactions of a downgraded peer are
+	// ignored for.
+	underpricedCooldown = 10 * time.Minute
+)
+
+// underpricedTracker tracks the transactions of a single peer rejected for being
+// priced below the pool's floor, downgrading peers flooding us with dust. Apart
+// from the total counter, it is only accessed from the message handling loop of
+// its peer.
+type underpricedTracker struct {
+	total uint64 // Total number of underpriced transactions received (atomic)
+
+	excess int       // Underpriced transactions in excess of the accepted ones
+	until  time.Time // Time until which the peer's transactions are ignored
+}
+
+// record accounts for the outcome of importing a batch of transactions from the
+// peer, reporting whether the peer got downgraded because of it.
+func (t *underpricedTracker) record(accepted, underpriced int, now time.Time) bool {
+	atomic.AddUint64(&t.total, uint64(underpriced))
+
+	t.excess += underpriced - accepted
+	if t.excess < 0 {
+		t.excess = 0
+	}
+	if t.excess < maxUnderpricedTxs {
+		return false
+	}
+	t.excess = 0
+	t.until = now.Add(underpricedCooldown)
+	return true
+}
+
+// downgraded reports whether the transactions of the peer are being ignored.
+func (t *underpricedTracker) downgraded(now time.Time) bool {
+	return now.Before(t.until)
+}
+
+// rejected returns the total number of underpriced transactions received from
+// the peer.
+func (t *underpricedTracker) rejected() uint64 {
+	return atomic.LoadUint64(&t.total)
+}
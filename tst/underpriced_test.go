@@ -0,0 +1,47 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gda
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that peers are downgraded once their underpriced transactions exceed
+// the accepted ones by the limit, and restored after the cooldown.
+func TestUnderpricedTracker(t *testing.T) {
+	tracker := new(underpricedTracker)
+	now := time.Now()
+
+	// Accepted transactions offset the underpriced ones
+	if tracker.record(10, maxUnderpricedTxs-1, now) || tracker.downgraded(now) {
+		t.Fatalf("peer downgraded below the limit")
+	}
+	if tracker.record(0, 10, now) || tracker.downgraded(now) {
+		t.Fatalf("peer downgraded with accepted transactions offsetting the excess")
+	}
+	if !tracker.record(0, 1, now) || !tracker.downgraded(now) {
+		t.Fatalf("peer not downgraded over the limit")
+	}
+	if have, want := tracker.rejected(), uint64(maxUnderpricedTxs+10); have != want {
+		t.Fatalf("rejection count mismatch: have %d, want %d", have, want)
+	}
+	// The downgrade expires after the cooldown
+	if tracker.downgraded(now.Add(underpricedCooldown)) {
+		t.Fatalf("peer still downgraded after the cooldown")
+	}
+}
@@ -0,0 +1,508 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdaclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/log"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+var (
+	errNoEndpoints       = errors.New("no endpoints specified")
+	errNoHealthyEndpoint = errors.New("no healthy endpoint available")
+	errNoStreamEndpoint  = errors.New("no healthy endpoint supporting subscriptions")
+)
+
+// BalancerPolicy configures how a load balanced client judges the health of its
+// endpoints and how it retries failed requests.
+type BalancerPolicy struct {
+	HealthInterval time.Duration // Time between two health checks of the endpoints
+	HealthTimeout  time.Duration // Timeout of a single health check request
+	MaxHeadLag     uint64        // Maximum number of blocks an endpoint may trail the best one
+	MaxLatency     time.Duration // Maximum health check latency of a usable endpoint
+	Retries        int           // Number of other endpoints to try if a read fails
+}
+
+// DefaultBalancerPolicy contains the default settings of a load balanced client.
+var DefaultBalancerPolicy = BalancerPolicy{
+	HealthInterval: 15 * time.Second,
+	HealthTimeout:  5 * time.Second,
+	MaxHeadLag:     4,
+	MaxLatency:     2 * time.Second,
+	Retries:        2,
+}
+
+// lbEndpoint is a single backend node of a load balanced client.
+type lbEndpoint struct {
+	url    string
+	stream bool    // Whgdaer the transport supports subscriptions
+	client *Client // Connection to the node, nil until successfully dialed
+
+	head    uint64        // Head block number at the last health check
+	latency time.Duration // Latency of the last health check
+	healthy bool          // Whgdaer the endpoint is eligible for serving requests
+}
+
+// lbTarget is a snapshot of a healthy endpoint to send a request to.
+type lbTarget struct {
+	endpoint *lbEndpoint
+	client   *Client
+	latency  time.Duration
+}
+
+// lbTargets implements sort.Interface to order endpoints by their latency.
+type lbTargets []lbTarget
+
+func (t lbTargets) Len() int           { return len(t) }
+func (t lbTargets) Less(i, j int) bool { return t[i].latency < t[j].latency }
+func (t lbTargets) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+// LoadBalancedClient spreads requests across multiple nodes, periodically
+// checking their health (head lag and latency). Idempotent reads are served by
+// the fastest healthy node and retried on others on failure, transactions are
+// sent to the fastest healthy node and subscriptions are pinned to a healthy
+// node reachable over a stateful (websocket or IPC) connection.
+type LoadBalancedClient struct {
+	policy    BalancerPolicy
+	endpoints []*lbEndpoint
+	lock      sync.RWMutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewLoadBalancedClient creates a client balancing requests across the nodes
+// reachable at the given URLs. Zero fields of the policy are set to defaults.
+func NewLoadBalancedClient(urls []string, policy BalancerPolicy) (*LoadBalancedClient, error) {
+	if len(urls) == 0 {
+		return nil, errNoEndpoints
+	}
+	if policy.HealthInterval <= 0 {
+		policy.HealthInterval = DefaultBalancerPolicy.HealthInterval
+	}
+	if policy.HealthTimeout <= 0 {
+		policy.HealthTimeout = DefaultBalancerPolicy.HealthTimeout
+	}
+	if policy.MaxLatency <= 0 {
+		policy.MaxLatency = DefaultBalancerPolicy.MaxLatency
+	}
+	lb := &LoadBalancedClient{
+		policy: policy,
+		quit:   make(chan struct{}),
+	}
+	for _, url := range urls {
+		lb.endpoints = append(lb.endpoints, &lbEndpoint{
+			url:    url,
+			stream: !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://"),
+		})
+	}
+	lb.checkHealth()
+	if len(lb.targets(false)) == 0 {
+		lb.closeEndpoints()
+		return nil, errNoHealthyEndpoint
+	}
+	lb.wg.Add(1)
+	go lb.loop()
+
+	return lb, nil
+}
+
+// Close stops the health checks and terminates all the endpoint connections.
+func (lb *LoadBalancedClient) Close() {
+	close(lb.quit)
+	lb.wg.Wait()
+	lb.closeEndpoints()
+}
+
+// closeEndpoints terminates all the established endpoint connections.
+func (lb *LoadBalancedClient) closeEndpoints() {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	for _, ep := range lb.endpoints {
+		if ep.client != nil {
+			ep.client.Close()
+			ep.client = nil
+		}
+	}
+}
+
+// loop periodically rechecks the health of all the endpoints.
+func (lb *LoadBalancedClient) loop() {
+	defer lb.wg.Done()
+
+	ticker := time.NewTicker(lb.policy.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lb.checkHealth()
+		case <-lb.quit:
+			return
+		}
+	}
+}
+
+// checkHealth concurrently queries the head of all endpoints, (re)dialing the
+// ones not yet connected, and updates their eligibility.
+func (lb *LoadBalancedClient) checkHealth() {
+	type result struct {
+		client  *Client
+		head    uint64
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(lb.endpoints))
+
+	lb.lock.RLock()
+	for i, ep := range lb.endpoints {
+		results[i].client = ep.client
+	}
+	lb.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	for i, ep := range lb.endpoints {
+		wg.Add(1)
+		go func(res *result, url string) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), lb.policy.HealthTimeout)
+			defer cancel()
+
+			if res.client == nil {
+				c, err := rpc.DialContext(ctx, url)
+				if err != nil {
+					res.err = err
+					return
+				}
+				res.client = NewClient(c)
+			}
+			start := time.Now()
+			header, err := res.client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				res.err = err
+				return
+			}
+			res.head, res.latency = header.Number.Uint64(), time.Since(start)
+		}(&results[i], ep.url)
+	}
+	wg.Wait()
+
+	// Judge all the endpoints relative to the best head seen
+	var best uint64
+	for _, res := range results {
+		if res.err == nil && res.head > best {
+			best = res.head
+		}
+	}
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	for i, ep := range lb.endpoints {
+		res := results[i]
+		ep.client = res.client
+
+		healthy := res.err == nil && res.head+lb.policy.MaxHeadLag >= best && res.latency <= lb.policy.MaxLatency
+		if healthy != ep.healthy {
+			log.Debug("Endpoint health changed", "url", ep.url, "healthy", healthy, "head", res.head, "best", best, "latency", res.latency, "err", res.err)
+		}
+		ep.head, ep.latency, ep.healthy = res.head, res.latency, healthy
+	}
+}
+
+// targets returns the currently healthy endpoints ordered by their latency,
+// optionally only the ones supporting subscriptions.
+func (lb *LoadBalancedClient) targets(stream bool) lbTargets {
+	lb.lock.RLock()
+	defer lb.lock.RUnlock()
+
+	var targets lbTargets
+	for _, ep := range lb.endpoints {
+		if ep.healthy && ep.client != nil && (!stream || ep.stream) {
+			targets = append(targets, lbTarget{endpoint: ep, client: ep.client, latency: ep.latency})
+		}
+	}
+	sort.Sort(targets)
+	return targets
+}
+
+// demote marks an endpoint unhealthy until the next health check succeeds.
+func (lb *LoadBalancedClient) demote(ep *lbEndpoint, err error) {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	if ep.healthy {
+		log.Debug("Endpoint request failed", "url", ep.url, "err", err)
+		ep.healthy = false
+	}
+}
+
+// retriable reports whgdaer a failed request may succeed on another endpoint.
+// Errors returned by the remote node itself or caused by the caller are final.
+func retriable(err error) bool {
+	if err == nil || err == gdaereum.NotFound || err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return false
+	}
+	return true
+}
+
+// read executes an idempotent request on the fastest healthy endpoint, retrying
+// it on the next ones if the endpoint fails.
+func (lb *LoadBalancedClient) read(ctx context.Context, fn func(c *Client) error) error {
+	targets := lb.targets(false)
+	if len(targets) == 0 {
+		return errNoHealthyEndpoint
+	}
+	var err error
+	for i, target := range targets {
+		if i > lb.policy.Retries {
+			break
+		}
+		if err = fn(target.client); !retriable(err) || ctx.Err() != nil {
+			return err
+		}
+		lb.demote(target.endpoint, err)
+	}
+	return err
+}
+
+// subscribe establishes a subscription on the fastest healthy endpoint having a
+// stateful connection. The subscription stays pinned to that endpoint.
+func (lb *LoadBalancedClient) subscribe(ctx context.Context, fn func(c *Client) (gdaereum.Subscription, error)) (gdaereum.Subscription, error) {
+	targets := lb.targets(true)
+	if len(targets) == 0 {
+		return nil, errNoStreamEndpoint
+	}
+	var err error
+	for _, target := range targets {
+		var sub gdaereum.Subscription
+		if sub, err = fn(target.client); !retriable(err) || ctx.Err() != nil {
+			return sub, err
+		}
+		lb.demote(target.endpoint, err)
+	}
+	return nil, err
+}
+
+// BlockByHash returns the given full block.
+func (lb *LoadBalancedClient) BlockByHash(ctx context.Context, hash common.Hash) (block *types.Block, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		block, err = c.BlockByHash(ctx, hash)
+		return err
+	})
+	return block, err
+}
+
+// BlockByNumber returns a block from the current canonical chain. If number is
+// nil, the latest known block is returned.
+func (lb *LoadBalancedClient) BlockByNumber(ctx context.Context, number *big.Int) (block *types.Block, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		block, err = c.BlockByNumber(ctx, number)
+		return err
+	})
+	return block, err
+}
+
+// HeaderByHash returns the block header with the given hash.
+func (lb *LoadBalancedClient) HeaderByHash(ctx context.Context, hash common.Hash) (header *types.Header, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		header, err = c.HeaderByHash(ctx, hash)
+		return err
+	})
+	return header, err
+}
+
+// HeaderByNumber returns a block header from the current canonical chain. If
+// number is nil, the latest known header is returned.
+func (lb *LoadBalancedClient) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		header, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+// TransactionCount returns the total number of transactions in the given block.
+func (lb *LoadBalancedClient) TransactionCount(ctx context.Context, blockHash common.Hash) (count uint, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		count, err = c.TransactionCount(ctx, blockHash)
+		return err
+	})
+	return count, err
+}
+
+// TransactionInBlock returns a single transaction at index in the given block.
+func (lb *LoadBalancedClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (tx *types.Transaction, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		tx, err = c.TransactionInBlock(ctx, blockHash, index)
+		return err
+	})
+	return tx, err
+}
+
+// TransactionByHash returns the transaction with the given hash.
+func (lb *LoadBalancedClient) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		tx, isPending, err = c.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+// TransactionReceipt returns the receipt of a transaction by transaction hash.
+func (lb *LoadBalancedClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		receipt, err = c.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return receipt, err
+}
+
+// NetworkID returns the network ID (also known as the chain ID) for this chain.
+func (lb *LoadBalancedClient) NetworkID(ctx context.Context) (id *big.Int, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		id, err = c.NetworkID(ctx)
+		return err
+	})
+	return id, err
+}
+
+// BalanceAt returns the wei balance of the given account.
+func (lb *LoadBalancedClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		balance, err = c.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// StorageAt returns the value of key in the contract storage of the given account.
+func (lb *LoadBalancedClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) (value []byte, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		value, err = c.StorageAt(ctx, account, key, blockNumber)
+		return err
+	})
+	return value, err
+}
+
+// CodeAt returns the contract code of the given account.
+func (lb *LoadBalancedClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		code, err = c.CodeAt(ctx, account, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// NonceAt returns the account nonce of the given account.
+func (lb *LoadBalancedClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		nonce, err = c.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state.
+func (lb *LoadBalancedClient) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		nonce, err = c.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+// FilterLogs executes a filter query.
+func (lb *LoadBalancedClient) FilterLogs(ctx context.Context, q gdaereum.FilterQuery) (logs []types.Log, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		logs, err = c.FilterLogs(ctx, q)
+		return err
+	})
+	return logs, err
+}
+
+// CallContract executes a message call transaction on a healthy endpoint.
+func (lb *LoadBalancedClient) CallContract(ctx context.Context, msg gdaereum.CallMsg, blockNumber *big.Int) (result []byte, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		result, err = c.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return result, err
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price.
+func (lb *LoadBalancedClient) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		price, err = c.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+// EstimateGas estimates the gas needed to execute a specific transaction.
+func (lb *LoadBalancedClient) EstimateGas(ctx context.Context, msg gdaereum.CallMsg) (gas uint64, err error) {
+	err = lb.read(ctx, func(c *Client) (err error) {
+		gas, err = c.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction injects a signed transaction into the pending pool of the
+// fastest healthy endpoint. Transactions are never retried on other endpoints.
+func (lb *LoadBalancedClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	targets := lb.targets(false)
+	if len(targets) == 0 {
+		return errNoHealthyEndpoint
+	}
+	err := targets[0].client.SendTransaction(ctx, tx)
+	if retriable(err) {
+		lb.demote(targets[0].endpoint, err)
+	}
+	return err
+}
+
+// SubscribeNewHead subscribes to notifications about the current blockchain head
+// on a healthy endpoint supporting subscriptions.
+func (lb *LoadBalancedClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (gdaereum.Subscription, error) {
+	return lb.subscribe(ctx, func(c *Client) (gdaereum.Subscription, error) {
+		return c.SubscribeNewHead(ctx, ch)
+	})
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query on
+// a healthy endpoint supporting subscriptions.
+func (lb *LoadBalancedClient) SubscribeFilterLogs(ctx context.Context, q gdaereum.FilterQuery, ch chan<- types.Log) (gdaereum.Subscription, error) {
+	return lb.subscribe(ctx, func(c *Client) (gdaereum.Subscription, error) {
+		return c.SubscribeFilterLogs(ctx, q, ch)
+	})
+}
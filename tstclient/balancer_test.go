@@ -0,0 +1,107 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdaclient
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/rpc"
+)
+
+// Verify that LoadBalancedClient implements the gdaereum interfaces.
+var (
+	_ = gdaereum.ChainReader(&LoadBalancedClient{})
+	_ = gdaereum.TransactionReader(&LoadBalancedClient{})
+	_ = gdaereum.ChainStateReader(&LoadBalancedClient{})
+	_ = gdaereum.ContractCaller(&LoadBalancedClient{})
+	_ = gdaereum.GasEstimator(&LoadBalancedClient{})
+	_ = gdaereum.GasPricer(&LoadBalancedClient{})
+	_ = gdaereum.LogFilterer(&LoadBalancedClient{})
+	_ = gdaereum.TransactionSender(&LoadBalancedClient{})
+)
+
+// TestChainService is a minimal RPC service reporting a fixed head block.
+type TestChainService struct {
+	head int64
+}
+
+func (s *TestChainService) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(s.head), Difficulty: big.NewInt(1), Time: big.NewInt(0), Extra: []byte{}}, nil
+}
+
+// newTestEndpoint starts an HTTP RPC endpoint reporting the given head block.
+func newTestEndpoint(t *testing.T, head int64) *httptest.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("gda", &TestChainService{head: head}); err != nil {
+		t.Fatalf("failed to register test service: %v", err)
+	}
+	return httptest.NewServer(server)
+}
+
+// Tests that endpoints lagging behind are not used, and that reads failing on an
+// endpoint are retried on the remaining healthy ones.
+func TestLoadBalancedClientFailover(t *testing.T) {
+	lagging := newTestEndpoint(t, 90)
+	defer lagging.Close()
+	first := newTestEndpoint(t, 100)
+	defer first.Close()
+	second := newTestEndpoint(t, 99)
+	defer second.Close()
+
+	policy := DefaultBalancerPolicy
+	policy.HealthInterval = time.Hour
+
+	lb, err := NewLoadBalancedClient([]string{lagging.URL, first.URL, second.URL}, policy)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer lb.Close()
+
+	if targets := lb.targets(false); len(targets) != 2 {
+		t.Fatalf("healthy endpoint count mismatch: have %d, want 2", len(targets))
+	}
+	for i := 0; i < 10; i++ {
+		header, err := lb.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("failed to retrieve header: %v", err)
+		}
+		if header.Number.Int64() == 90 {
+			t.Fatalf("lagging endpoint served request")
+		}
+	}
+	// Take down one of the healthy endpoints, reads must fail over to the other
+	first.Close()
+	for i := 0; i < 10; i++ {
+		header, err := lb.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("failed to retrieve header after failover: %v", err)
+		}
+		if header.Number.Int64() != 99 {
+			t.Fatalf("failover endpoint mismatch: have head %d, want 99", header.Number.Int64())
+		}
+	}
+	// Subscriptions need a stateful connection, none available over HTTP
+	if _, err := lb.SubscribeNewHead(context.Background(), make(chan *types.Header)); err != errNoStreamEndpoint {
+		t.Fatalf("subscription error mismatch: have %v, want %v", err, errNoStreamEndpoint)
+	}
+}
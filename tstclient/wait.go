@@ -0,0 +1,188 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdaclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+	"github.com/gdachain/go-gdachain/log"
+)
+
+// waitPollInterval is the time between two receipt checks if the backend can't
+// notify about new chain heads.
+var waitPollInterval = time.Second
+
+var (
+	// ErrNotContractCreation is returned by WaitDeployed if the awaited
+	// transaction does not create a contract.
+	ErrNotContractCreation = errors.New("transaction is not a contract creation")
+
+	// ErrNoCodeAfterDeploy is returned by WaitDeployed if contract creation
+	// leaves an empty contract behind.
+	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+)
+
+// waitBackend is the part of the client API needed to await transactions.
+type waitBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (gdaereum.Subscription, error)
+}
+
+// WaitMined waits until the transaction is included in the canonical chain and
+// buried under the given number of confirmation blocks, returning its receipt.
+// If the including block is reorged out, waiting restarts until the transaction
+// is included again. It stops waiting when the context is canceled.
+func (ec *Client) WaitMined(ctx context.Context, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	return waitMined(ctx, ec, txHash, confirmations)
+}
+
+// WaitDeployed waits for a contract deployment transaction to be mined with the
+// given number of confirmations and returns the address of the created contract.
+// It stops waiting when the context is canceled.
+func (ec *Client) WaitDeployed(ctx context.Context, txHash common.Hash, confirmations uint64) (common.Address, error) {
+	return waitDeployed(ctx, ec, txHash, confirmations)
+}
+
+// WaitMined waits until the transaction is included in the canonical chain and
+// buried under the given number of confirmation blocks, returning its receipt.
+func (lb *LoadBalancedClient) WaitMined(ctx context.Context, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	return waitMined(ctx, lb, txHash, confirmations)
+}
+
+// WaitDeployed waits for a contract deployment transaction to be mined with the
+// given number of confirmations and returns the address of the created contract.
+func (lb *LoadBalancedClient) WaitDeployed(ctx context.Context, txHash common.Hash, confirmations uint64) (common.Address, error) {
+	return waitDeployed(ctx, lb, txHash, confirmations)
+}
+
+func waitMined(ctx context.Context, b waitBackend, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	// Get notified about new heads if the backend supports it, poll otherwise
+	heads := make(chan *types.Header, 16)
+	sub, err := b.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		log.Debug("Head subscription failed, polling for receipt", "err", err)
+		sub = nil
+	} else {
+		defer sub.Unsubscribe()
+	}
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	logger := log.New("hash", txHash)
+	var (
+		head     *types.Header  // Latest head known, nil if it must be fetched
+		included *types.Receipt // Receipt seen during the previous check
+	)
+	for {
+		receipt, err := checkMined(ctx, b, txHash, head)
+		switch {
+		case err != nil:
+			logger.Trace("Receipt retrieval failed", "err", err)
+		case receipt == nil && included != nil:
+			logger.Debug("Transaction reorged out, waiting for reinclusion", "block", included.BlockHash)
+		case receipt == nil:
+			logger.Trace("Transaction not yet mined")
+		}
+		included = receipt
+		if receipt != nil && head != nil {
+			if depth := new(big.Int).Sub(head.Number, receipt.BlockNumber); depth.Sign() >= 0 && depth.Uint64() >= confirmations {
+				return receipt, nil
+			}
+		}
+		// Fetch the head ourselves on the next round, unless a newer one arrives
+		if receipt != nil && head == nil {
+			if head, err = b.HeaderByNumber(ctx, nil); err == nil {
+				continue
+			}
+			head = nil
+		}
+		var errc <-chan error
+		if sub != nil {
+			errc = sub.Err()
+		}
+		select {
+		case head = <-heads:
+		case <-ticker.C:
+			if sub == nil {
+				head = nil
+			}
+		case err := <-errc:
+			logger.Debug("Head subscription dropped, polling for receipt", "err", err)
+			sub, head = nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// checkMined returns the receipt of the transaction if it is part of the
+// canonical chain, or nil if it is not (or no longer) included.
+func checkMined(ctx context.Context, b waitBackend, txHash common.Hash, head *types.Header) (*types.Receipt, error) {
+	receipt, err := b.TransactionReceipt(ctx, txHash)
+	if err == gdaereum.NotFound || receipt == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if receipt.BlockNumber == nil {
+		return nil, errors.New("server returned receipt without block number")
+	}
+	// Nodes may briefly serve receipts of reorged blocks, check canonicality
+	if head != nil && head.Number.Cmp(receipt.BlockNumber) < 0 {
+		return nil, nil
+	}
+	header, err := b.HeaderByNumber(ctx, receipt.BlockNumber)
+	if err == gdaereum.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header.Hash() != receipt.BlockHash {
+		return nil, nil
+	}
+	return receipt, nil
+}
+
+func waitDeployed(ctx context.Context, b waitBackend, txHash common.Hash, confirmations uint64) (common.Address, error) {
+	receipt, err := waitMined(ctx, b, txHash, confirmations)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if receipt.To != nil {
+		return common.Address{}, ErrNotContractCreation
+	}
+	if receipt.ContractAddress == (common.Address{}) {
+		return common.Address{}, errors.New("zero contract address")
+	}
+	// Check that code has indeed been deployed at the address. A failing
+	// constructor leaves an empty account behind.
+	code, err := b.CodeAt(ctx, receipt.ContractAddress, receipt.BlockNumber)
+	if err == nil && len(code) == 0 {
+		err = ErrNoCodeAfterDeploy
+	}
+	return receipt.ContractAddress, err
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdaclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdachain/go-gdachain"
+	"github.com/gdachain/go-gdachain/common"
+	"github.com/gdachain/go-gdachain/core/types"
+)
+
+// waitTestBackend is a polling-only chain whose canonical headers and receipts
+// can be swapped out to simulate reorgs.
+type waitTestBackend struct {
+	lock     sync.Mutex
+	chain    []*types.Header
+	receipts map[common.Hash]*types.Receipt
+	code     map[common.Address][]byte
+}
+
+func newWaitTestBackend() *waitTestBackend {
+	return &waitTestBackend{
+		receipts: make(map[common.Hash]*types.Receipt),
+		code:     make(map[common.Address][]byte),
+	}
+}
+
+// extend appends blocks to the chain, starting at the given height. Blocks with
+// a different fork marker have different hashes.
+func (b *waitTestBackend) extend(from, n int, fork byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.chain = b.chain[:from]
+	for i := 0; i < n; i++ {
+		b.chain = append(b.chain, &types.Header{Number: big.NewInt(int64(len(b.chain))), Extra: []byte{fork}})
+	}
+}
+
+// include records a receipt for the transaction in the given canonical block.
+func (b *waitTestBackend) include(txHash common.Hash, number int, contract common.Address) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	header := b.chain[number]
+	b.receipts[txHash] = &types.Receipt{TxHash: txHash, BlockHash: header.Hash(), BlockNumber: header.Number, ContractAddress: contract}
+}
+
+func (b *waitTestBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if number == nil {
+		return b.chain[len(b.chain)-1], nil
+	}
+	if number.Int64() >= int64(len(b.chain)) {
+		return nil, gdaereum.NotFound
+	}
+	return b.chain[number.Int64()], nil
+}
+
+func (b *waitTestBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if receipt := b.receipts[txHash]; receipt != nil {
+		return receipt, nil
+	}
+	return nil, gdaereum.NotFound
+}
+
+func (b *waitTestBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.code[account], nil
+}
+
+func (b *waitTestBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (gdaereum.Subscription, error) {
+	return nil, errors.New("notifications not supported")
+}
+
+func init() {
+	waitPollInterval = 10 * time.Millisecond
+}
+
+// Tests that waiting for confirmations restarts if the including block is
+// reorged out of the canonical chain.
+func TestWaitMinedReorg(t *testing.T) {
+	b := newWaitTestBackend()
+	b.extend(0, 5, 0)
+
+	txHash := common.HexToHash("0x01")
+	b.include(txHash, 4, common.Address{})
+
+	done := make(chan *types.Receipt, 1)
+	go func() {
+		receipt, err := waitMined(context.Background(), b, txHash, 2)
+		if err != nil {
+			t.Errorf("wait failed: %v", err)
+		}
+		done <- receipt
+	}()
+	// Not enough confirmations yet.
+	select {
+	case <-done:
+		t.Fatal("returned without enough confirmations")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// Reorg out the including block, the stale receipt must not be accepted.
+	b.extend(4, 5, 1)
+	select {
+	case <-done:
+		t.Fatal("returned receipt of reorged block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	// Include the transaction again on the new fork.
+	b.include(txHash, 6, common.Address{})
+	select {
+	case receipt := <-done:
+		if receipt.BlockHash != b.chain[6].Hash() {
+			t.Fatalf("wrong inclusion block: have %x, want %x", receipt.BlockHash, b.chain[6].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for confirmations")
+	}
+}
+
+func TestWaitDeployed(t *testing.T) {
+	b := newWaitTestBackend()
+	b.extend(0, 3, 0)
+
+	var (
+		deployed = common.HexToHash("0x01")
+		empty    = common.HexToHash("0x02")
+		contract = common.HexToAddress("0xc0de")
+	)
+	b.code[contract] = []byte{0x60}
+	b.include(deployed, 2, contract)
+	b.include(empty, 2, common.HexToAddress("0xdead"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if addr, err := waitDeployed(ctx, b, deployed, 0); err != nil || addr != contract {
+		t.Fatalf("deployment: have %x, %v; want %x", addr, err, contract)
+	}
+	if _, err := waitDeployed(ctx, b, empty, 0); err != ErrNoCodeAfterDeploy {
+		t.Fatalf("empty deployment: have %v, want %v", err, ErrNoCodeAfterDeploy)
+	}
+	// Waiting for a transaction that never gets mined times out.
+	if _, err := waitDeployed(ctx, b, common.HexToHash("0x03"), 0); err != context.DeadlineExceeded {
+		t.Fatalf("missing transaction: have %v, want %v", err, context.DeadlineExceeded)
+	}
+}
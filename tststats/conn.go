@@ -0,0 +1,165 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdastats
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// protocolVersion is the version of the message schema spoken by the client.
+	// Servers not reporting a version in their login ack speak version 1.
+	protocolVersion = 2
+
+	// capGzip is the capability of exchanging gzip compressed messages.
+	capGzip = "gzip"
+
+	// minReconnectDelay and maxReconnectDelay bound the exponential backoff of
+	// the reconnection attempts to the stats server.
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 5 * time.Minute
+)
+
+// capabilities are the optional protocol features supported by the client.
+var capabilities = []string{capGzip}
+
+// statsConn is a websocket connection to a stats server, transparently
+// compressing and decompressing the messages once negotiated with the server.
+type statsConn struct {
+	*websocket.Conn
+
+	version int  // Negotiated message schema version
+	gzip    bool // Whether outbound messages are compressed
+}
+
+// dial connects to the stats server at the given websocket URL, authenticating
+// the handshake with the configured token if any.
+func dial(url string, config *Config, tlsConfig *tls.Config) (*statsConn, error) {
+	conf, err := websocket.NewConfig(url, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	conf.Dialer = &net.Dialer{Timeout: 5 * time.Second}
+	conf.TlsConfig = tlsConfig
+	if config.Token != "" {
+		conf.Header.Set("Authorization", "Bearer "+config.Token)
+	}
+	conn, err := websocket.DialConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &statsConn{Conn: conn, version: 1}, nil
+}
+
+// negotiate applies the protocol version and capabilities advertised by the
+// server in its login ack.
+func (c *statsConn) negotiate(version int, caps []string) {
+	if version > protocolVersion {
+		version = protocolVersion
+	}
+	if version < 1 {
+		version = 1
+	}
+	c.version, c.gzip = version, false
+	for _, cap := range caps {
+		if cap == capGzip && version >= 2 {
+			c.gzip = true
+		}
+	}
+}
+
+// WriteJSON sends a JSON encoded message, compressed into a binary frame if
+// gzip was negotiated.
+func (c *statsConn) WriteJSON(v interface{}) error {
+	if !c.gzip {
+		return websocket.JSON.Send(c.Conn, v)
+	}
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(blob); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return websocket.Message.Send(c.Conn, buf.Bytes())
+}
+
+// ReadJSON receives a JSON encoded message, decompressing it if it arrived
+// gzip compressed.
+func (c *statsConn) ReadJSON(v interface{}) error {
+	var blob []byte
+	if err := websocket.Message.Receive(c.Conn, &blob); err != nil {
+		return err
+	}
+	if len(blob) > 1 && blob[0] == 0x1f && blob[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(blob))
+		if err != nil {
+			return err
+		}
+		if blob, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(blob, v)
+}
+
+// makeTLSConfig creates the TLS configuration verifying the stats server, using
+// the certificates of the configured CA file or the system roots otherwise.
+func makeTLSConfig(config *Config) (*tls.Config, error) {
+	if config.CAFile == "" {
+		return nil, nil
+	}
+	blob, err := ioutil.ReadFile(config.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(blob) {
+		return nil, fmt.Errorf("no certificates in %s", config.CAFile)
+	}
+	return &tls.Config{RootCAs: roots}, nil
+}
+
+// reconnectDelay returns the time to wait before the next reconnection attempt
+// after the given number of consecutive failures, doubling the delay on every
+// failure up to a limit and randomizing its second half to avoid thundering
+// herds of nodes reconnecting to a restarted server at once.
+func reconnectDelay(failures int) time.Duration {
+	delay := maxReconnectDelay
+	if failures < 16 {
+		if d := minReconnectDelay << uint(failures); d < maxReconnectDelay {
+			delay = d
+		}
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-gdaereum library.
+//
+// The go-gdaereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-gdaereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-gdaereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gdastats
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Tests that the reconnection delays grow exponentially up to the limit, with
+// the jitter confined to their second half.
+func TestReconnectDelay(t *testing.T) {
+	for failures := 0; failures < 32; failures++ {
+		want := maxReconnectDelay
+		if failures < 16 && minReconnectDelay<<uint(failures) < maxReconnectDelay {
+			want = minReconnectDelay << uint(failures)
+		}
+		if delay := reconnectDelay(failures); delay < want/2 || delay > want {
+			t.Errorf("failures %d: delay %v out of range [%v, %v]", failures, delay, want/2, want)
+		}
+	}
+}
+
+// Tests that messages are exchanged compressed once gzip is negotiated, and the
+// bearer token is presented in the handshake.
+func TestStatsConnCompression(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		if auth := ws.Request().Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("authorization mismatch: have %q, want %q", auth, "Bearer secret")
+		}
+		var blob []byte
+		websocket.Message.Receive(ws, &blob)
+		received <- blob
+		websocket.Message.Send(ws, blob) // Echo the compressed message back
+	}))
+	defer server.Close()
+
+	conn, err := dial("ws"+strings.TrimPrefix(server.URL, "http"), &Config{Token: "secret"}, nil)
+	if err != nil {
+		t.Fatalf("failed to dial server: %v", err)
+	}
+	defer conn.Close()
+
+	conn.negotiate(protocolVersion, []string{capGzip})
+	if !conn.gzip {
+		t.Fatalf("gzip not negotiated")
+	}
+	if err := conn.WriteJSON(map[string]string{"hello": "world"}); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	select {
+	case blob := <-received:
+		if len(blob) < 2 || blob[0] != 0x1f || blob[1] != 0x8b {
+			t.Fatalf("message not compressed: %x", blob)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message not received")
+	}
+	var msg map[string]string
+	if err := conn.ReadJSON(&msg); err != nil || msg["hello"] != "world" {
+		t.Fatalf("echoed message mismatch: have %v, err %v", msg, err)
+	}
+	// Version 1 servers never get compressed messages
+	conn.negotiate(1, []string{capGzip})
+	if conn.version != 1 || conn.gzip {
+		t.Fatalf("version 1 negotiation mismatch: version %d, gzip %v", conn.version, conn.gzip)
+	}
+}
@@ -19,11 +19,11 @@ package gdastats
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
-	"net"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -41,7 +41,6 @@ import (
 	"github.com/gdachain/go-gdachain/log"
 	"github.com/gdachain/go-gdachain/p2p"
 	"github.com/gdachain/go-gdachain/rpc"
-	"golang.org/x/net/websocket"
 )
 
 const (
@@ -66,6 +65,13 @@ type blockChain interface {
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
 }
 
+// Config contains the configuration of the stats reporting service.
+type Config struct {
+	URL    string // Reporting URL of the stats server (nodename:secret@host:port)
+	Token  string // Bearer token authenticating the websocket handshake, requires TLS unless ws:// is explicit
+	CAFile string // PEM certificates to verify the server with (system roots if empty)
+}
+
 // Service implements an gdachain negdaats reporting daemon that pushes local
 // chain statistics up to a monitoring server.
 type Service struct {
@@ -78,17 +84,24 @@ type Service struct {
 	pass string // Password to authorize access to the monitoring page
 	host string // Remote address of the monitoring service
 
+	config    *Config     // Connection settings of the monitoring service
+	tlsConfig *tls.Config // TLS settings verifying the monitoring service (nil = defaults)
+
 	pongCh chan struct{} // Pong notifications are fed into this channel
 	histCh chan []uint64 // History request block numbers are fed into this channel
 }
 
 // New returns a monitoring service ready for stats reporting.
-func New(url string, gdaServ *gda.gdachain, lesServ *les.Lightgdachain) (*Service, error) {
+func New(config Config, gdaServ *gda.gdachain, lesServ *les.Lightgdachain) (*Service, error) {
 	// Parse the negdaats connection url
 	re := regexp.MustCompile("([^:@]*)(:([^@]*))?@(.+)")
-	parts := re.FindStringSubmatch(url)
+	parts := re.FindStringSubmatch(config.URL)
 	if len(parts) != 5 {
-		return nil, fmt.Errorf("invalid negdaats url: \"%s\", should be nodename:secret@host:port", url)
+		return nil, fmt.Errorf("invalid negdaats url: \"%s\", should be nodename:secret@host:port", config.URL)
+	}
+	tlsConfig, err := makeTLSConfig(&config)
+	if err != nil {
+		return nil, err
 	}
 	// Assemble and return the stats service
 	var engine consensus.Engine
@@ -104,6 +117,10 @@ func New(url string, gdaServ *gda.gdachain, lesServ *les.Lightgdachain) (*Servic
 		node:   parts[1],
 		pass:   parts[3],
 		host:   parts[4],
+
+		config:    &config,
+		tlsConfig: tlsConfig,
+
 		pongCh: make(chan struct{}),
 		histCh: make(chan []uint64, 1),
 	}, nil
@@ -195,41 +212,44 @@ func (s *Service) loop() {
 		close(quitCh)
 	}()
 	// Loop reporting until termination
-	for {
-		// Resolve the URL, defaulting to TLS, but falling back to none too
+	for failures := 0; ; failures++ {
+		if failures > 0 {
+			time.Sleep(reconnectDelay(failures - 1))
+		}
+		// Resolve the URL, defaulting to TLS, but falling back to none too unless
+		// authenticating with a token, which must never be sent in plaintext
 		path := fmt.Sprintf("%s/api", s.host)
 		urls := []string{path}
 
 		if !strings.Contains(path, "://") { // url.Parse and url.IsAbs is unsuitable (https://github.com/golang/go/issues/19779)
-			urls = []string{"wss://" + path, "ws://" + path}
+			urls = []string{"wss://" + path}
+			if s.config.Token == "" {
+				urls = append(urls, "ws://"+path)
+			}
 		}
 		// Establish a websocket connection to the server on any supported URL
 		var (
-			conf *websocket.Config
-			conn *websocket.Conn
+			conn *statsConn
 			err  error
 		)
 		for _, url := range urls {
-			if conf, err = websocket.NewConfig(url, "http://localhost/"); err != nil {
-				continue
-			}
-			conf.Dialer = &net.Dialer{Timeout: 5 * time.Second}
-			if conn, err = websocket.DialConfig(conf); err == nil {
+			if conn, err = dial(url, s.config, s.tlsConfig); err == nil {
 				break
 			}
 		}
 		if err != nil {
 			log.Warn("Stats server unreachable", "err", err)
-			time.Sleep(10 * time.Second)
 			continue
 		}
 		// Authenticate the client with the server
 		if err = s.login(conn); err != nil {
 			log.Warn("Stats login failed", "err", err)
 			conn.Close()
-			time.Sleep(10 * time.Second)
 			continue
 		}
+		log.Debug("Logged in to stats server", "version", conn.version, "gzip", conn.gzip)
+		failures = 0
+
 		go s.readLoop(conn)
 
 		// Send the initial stats so our node looks decent from the get go
@@ -277,14 +297,14 @@ func (s *Service) loop() {
 // from the network socket. If any of them match an active request, it forwards
 // it, if they themselves are requests it initiates a reply, and lastly it drops
 // unknown packets.
-func (s *Service) readLoop(conn *websocket.Conn) {
+func (s *Service) readLoop(conn *statsConn) {
 	// If the read loop exists, close the connection
 	defer conn.Close()
 
 	for {
 		// Retrieve the next generic network packet and bail out on error
 		var msg map[string][]interface{}
-		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		if err := conn.ReadJSON(&msg); err != nil {
 			log.Warn("Failed to decode stats server message", "err", err)
 			return
 		}
@@ -361,14 +381,24 @@ type nodeInfo struct {
 }
 
 // authMsg is the authentication infos needed to login to a monitoring server.
+// Version 1 servers ignore the protocol version and capabilities.
 type authMsg struct {
-	Id     string   `json:"id"`
-	Info   nodeInfo `json:"info"`
-	Secret string   `json:"secret"`
+	Id           string   `json:"id"`
+	Info         nodeInfo `json:"info"`
+	Secret       string   `json:"secret"`
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// readyMsg is the login ack of version 2 servers, reporting the protocol
+// version and the capabilities they support.
+type readyMsg struct {
+	Version      int      `json:"version"`
+	Capabilities []string `json:"capabilities"`
 }
 
 // login tries to authorize the client at the remote server.
-func (s *Service) login(conn *websocket.Conn) error {
+func (s *Service) login(conn *statsConn) error {
 	// Construct and send the login authentication
 	infos := s.server.NodeInfo()
 
@@ -394,26 +424,36 @@ func (s *Service) login(conn *websocket.Conn) error {
 			Client:   "0.1.1",
 			History:  true,
 		},
-		Secret: s.pass,
+		Secret:       s.pass,
+		Version:      protocolVersion,
+		Capabilities: capabilities,
 	}
 	login := map[string][]interface{}{
 		"emit": {"hello", auth},
 	}
-	if err := websocket.JSON.Send(conn, login); err != nil {
+	if err := conn.WriteJSON(login); err != nil {
 		return err
 	}
 	// Retrieve the remote ack or connection termination
-	var ack map[string][]string
-	if err := websocket.JSON.Receive(conn, &ack); err != nil || len(ack["emit"]) != 1 || ack["emit"][0] != "ready" {
+	var ack map[string][]json.RawMessage
+	if err := conn.ReadJSON(&ack); err != nil || len(ack["emit"]) == 0 || string(ack["emit"][0]) != `"ready"` {
 		return errors.New("unauthorized")
 	}
+	// Negotiate the protocol features, servers without any details speak version 1
+	var ready readyMsg
+	if len(ack["emit"]) > 1 {
+		if err := json.Unmarshal(ack["emit"][1], &ready); err != nil {
+			return fmt.Errorf("invalid login ack: %v", err)
+		}
+	}
+	conn.negotiate(ready.Version, ready.Capabilities)
 	return nil
 }
 
 // report collects all possible data to report and send it to the stats server.
 // This should only be used on reconnects or rarely to avoid overloading the
 // server. Use the individual methods for reporting subscribed events.
-func (s *Service) report(conn *websocket.Conn) error {
+func (s *Service) report(conn *statsConn) error {
 	if err := s.reportLatency(conn); err != nil {
 		return err
 	}
@@ -431,7 +471,7 @@ func (s *Service) report(conn *websocket.Conn) error {
 
 // reportLatency sends a ping request to the server, measures the RTT time and
 // finally sends a latency update.
-func (s *Service) reportLatency(conn *websocket.Conn) error {
+func (s *Service) reportLatency(conn *statsConn) error {
 	// Send the current time to the gdastats server
 	start := time.Now()
 
@@ -441,7 +481,7 @@ func (s *Service) reportLatency(conn *websocket.Conn) error {
 			"clientTime": start.String(),
 		}},
 	}
-	if err := websocket.JSON.Send(conn, ping); err != nil {
+	if err := conn.WriteJSON(ping); err != nil {
 		return err
 	}
 	// Wait for the pong request to arrive back
@@ -463,7 +503,7 @@ func (s *Service) reportLatency(conn *websocket.Conn) error {
 			"latency": latency,
 		}},
 	}
-	return websocket.JSON.Send(conn, stats)
+	return conn.WriteJSON(stats)
 }
 
 // blockStats is the information to report about individual blocks.
@@ -500,7 +540,7 @@ func (s uncleStats) MarshalJSON() ([]byte, error) {
 }
 
 // reportBlock retrieves the current chain head and repors it to the stats server.
-func (s *Service) reportBlock(conn *websocket.Conn, block *types.Block) error {
+func (s *Service) reportBlock(conn *statsConn, block *types.Block) error {
 	// Gather the block details from the header or block chain
 	details := s.assembleBlockStats(block)
 
@@ -514,7 +554,7 @@ func (s *Service) reportBlock(conn *websocket.Conn, block *types.Block) error {
 	report := map[string][]interface{}{
 		"emit": {"block", stats},
 	}
-	return websocket.JSON.Send(conn, report)
+	return conn.WriteJSON(report)
 }
 
 // assembleBlockStats retrieves any required metadata to report a single block
@@ -572,7 +612,7 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 
 // reportHistory retrieves the most recent batch of blocks and reports it to the
 // stats server.
-func (s *Service) reportHistory(conn *websocket.Conn, list []uint64) error {
+func (s *Service) reportHistory(conn *statsConn, list []uint64) error {
 	// Figure out the indexes that need reporting
 	indexes := make([]uint64, 0, historyUpdateRange)
 	if len(list) > 0 {
@@ -628,7 +668,7 @@ func (s *Service) reportHistory(conn *websocket.Conn, list []uint64) error {
 	report := map[string][]interface{}{
 		"emit": {"history", stats},
 	}
-	return websocket.JSON.Send(conn, report)
+	return conn.WriteJSON(report)
 }
 
 // pendStats is the information to report about pending transactions.
@@ -638,7 +678,7 @@ type pendStats struct {
 
 // reportPending retrieves the current number of pending transactions and reports
 // it to the stats server.
-func (s *Service) reportPending(conn *websocket.Conn) error {
+func (s *Service) reportPending(conn *statsConn) error {
 	// Retrieve the pending count from the local blockchain
 	var pending int
 	if s.gda != nil {
@@ -658,7 +698,7 @@ func (s *Service) reportPending(conn *websocket.Conn) error {
 	report := map[string][]interface{}{
 		"emit": {"pending", stats},
 	}
-	return websocket.JSON.Send(conn, report)
+	return conn.WriteJSON(report)
 }
 
 // nodeStats is the information to report about the local node.
@@ -674,7 +714,7 @@ type nodeStats struct {
 
 // reportPending retrieves various stats about the node at the networking and
 // mining layer and reports it to the stats server.
-func (s *Service) reporgdaats(conn *websocket.Conn) error {
+func (s *Service) reporgdaats(conn *statsConn) error {
 	// Gather the syncing and mining infos from the local miner instance
 	var (
 		mining   bool
@@ -713,5 +753,5 @@ func (s *Service) reporgdaats(conn *websocket.Conn) error {
 	report := map[string][]interface{}{
 		"emit": {"stats", stats},
 	}
-	return websocket.JSON.Send(conn, report)
+	return conn.WriteJSON(report)
 }